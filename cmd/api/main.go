@@ -9,6 +9,9 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	// Scheduled scaling actions name time zones; embed the zone database
+	// for images that lack one.
+	_ "time/tzdata"
 
 	"sync"
 
@@ -25,6 +28,7 @@ import (
 	"github.com/poyrazk/thecloud/internal/platform"
 	"github.com/poyrazk/thecloud/internal/repositories/docker"
 	"github.com/poyrazk/thecloud/internal/repositories/filesystem"
+	"github.com/poyrazk/thecloud/internal/repositories/lbproxy"
 	"github.com/poyrazk/thecloud/internal/repositories/postgres"
 	"github.com/poyrazk/thecloud/pkg/httputil"
 	"github.com/poyrazk/thecloud/pkg/ratelimit"
@@ -113,18 +117,24 @@ func main() {
 	eventSvc := services.NewEventService(eventRepo, logger)
 	volumeSvc := services.NewVolumeService(volumeRepo, dockerAdapter, eventSvc, logger)
 	instanceSvc := services.NewInstanceService(instanceRepo, vpcRepo, volumeRepo, dockerAdapter, eventSvc, logger)
+	launchTemplateRepo := postgres.NewLaunchTemplateRepository(db)
+	launchTemplateSvc := services.NewLaunchTemplateService(launchTemplateRepo, vpcRepo)
+
+	secretRepo := postgres.NewSecretRepository(db)
+	secretSvc := services.NewSecretService(secretRepo, eventSvc, logger)
+	secretHandler := httphandlers.NewSecretHandler(secretSvc)
 
 	lbRepo := postgres.NewLBRepository(db)
-	lbProxy, err := docker.NewLBProxyAdapter(instanceRepo, vpcRepo)
+	lbProxy, err := newLBProxy(cfg, instanceRepo, vpcRepo)
 	if err != nil {
 		logger.Error("failed to initialize load balancer proxy adapter", "error", err)
 		os.Exit(1)
 	}
-	lbSvc := services.NewLBService(lbRepo, vpcRepo, instanceRepo)
-	lbWorker := services.NewLBWorker(lbRepo, instanceRepo, lbProxy)
+	lbSvc := services.NewLBService(lbRepo, vpcRepo, instanceRepo, secretSvc)
 
 	vpcHandler := httphandlers.NewVpcHandler(vpcSvc)
-	instanceHandler := httphandlers.NewInstanceHandler(instanceSvc)
+	instanceHandler := httphandlers.NewInstanceHandler(instanceSvc, launchTemplateSvc)
+	launchTemplateHandler := httphandlers.NewLaunchTemplateHandler(launchTemplateSvc)
 	eventHandler := httphandlers.NewEventHandler(eventSvc)
 	volumeHandler := httphandlers.NewVolumeHandler(volumeSvc)
 	lbHandler := httphandlers.NewLBHandler(lbSvc)
@@ -134,26 +144,33 @@ func main() {
 	dashboardHandler := httphandlers.NewDashboardHandler(dashboardSvc)
 
 	// Storage Service
-	fileStore, err := filesystem.NewLocalFileStore("./thecloud-data/local/storage")
+	fileStore, err := newFileStore(cfg)
 	if err != nil {
 		logger.Error("failed to initialize file store", "error", err)
 		os.Exit(1)
 	}
+	fnRepo := postgres.NewFunctionRepository(db)
+	fnSvc := services.NewFunctionService(fnRepo, dockerAdapter, fileStore, logger)
+	fnHandler := httphandlers.NewFunctionHandler(fnSvc)
+
+	notificationRepo := postgres.NewNotificationRepository(db)
+	notificationSvc := services.NewNotificationService(notificationRepo, fnSvc, logger)
+	notificationHandler := httphandlers.NewNotificationHandler(notificationSvc)
+	notificationWorker := services.NewNotificationWorker(notificationRepo, fnSvc, ports.RealClock{})
+
 	storageRepo := postgres.NewStorageRepository(db)
-	storageSvc := services.NewStorageService(storageRepo, fileStore)
+	storageSvc := services.NewStorageService(storageRepo, fileStore, notificationSvc)
 	storageHandler := httphandlers.NewStorageHandler(storageSvc)
+	s3Handler := httphandlers.NewS3Handler(storageSvc)
+	websiteHandler := httphandlers.NewWebsiteHandler(storageSvc, cfg.WebsiteDomain)
+
+	lbWorker := services.NewLBWorker(lbRepo, lbProxy, secretSvc, storageSvc)
 
 	databaseRepo := postgres.NewDatabaseRepository(db)
 	databaseSvc := services.NewDatabaseService(databaseRepo, dockerAdapter, vpcRepo, eventSvc, logger)
 	databaseHandler := httphandlers.NewDatabaseHandler(databaseSvc)
 
-	secretRepo := postgres.NewSecretRepository(db)
-	secretSvc := services.NewSecretService(secretRepo, eventSvc, logger)
-	secretHandler := httphandlers.NewSecretHandler(secretSvc)
-
-	fnRepo := postgres.NewFunctionRepository(db)
-	fnSvc := services.NewFunctionService(fnRepo, dockerAdapter, fileStore, logger)
-	fnHandler := httphandlers.NewFunctionHandler(fnSvc)
+	storageWorker := services.NewStorageWorker(storageRepo, fileStore, storageSvc, ports.RealClock{})
 
 	cacheRepo := postgres.NewCacheRepository(db)
 	cacheSvc := services.NewCacheService(cacheRepo, dockerAdapter, vpcRepo, eventSvc, logger)
@@ -177,7 +194,13 @@ func main() {
 	limiter := ratelimit.NewIPRateLimiter(rate.Limit(5), 10, logger)
 	r.Use(ratelimit.Middleware(limiter))
 
+	// Bucket websites on their own hostnames bypass the API routes
+	r.Use(websiteHandler.Host())
+
 	// 6. Routes
+	// The electors of the singleton workers are set up with the workers
+	// below, before the server starts.
+	var electors []*services.LeaderElector
 	r.GET("/health", func(c *gin.Context) {
 		overallStatus := "UP"
 
@@ -200,13 +223,25 @@ func main() {
 			statusCode = http.StatusServiceUnavailable
 		}
 
+		// Leadership is informational: standby replicas are healthy.
+		leaders := gin.H{}
+		for _, e := range electors {
+			leader := ""
+			if lease := e.Leader(); lease != nil {
+				leader = lease.HolderID
+			}
+			leaders[e.Name()] = gin.H{"leader": leader, "is_leader": e.IsLeader()}
+		}
+
 		c.JSON(statusCode, gin.H{
 			"status": overallStatus,
 			"checks": gin.H{
 				"database": dbStatus,
 				"docker":   dockerStatus,
 			},
-			"time": time.Now().Format(time.RFC3339),
+			"replica": cfg.ReplicaID,
+			"leaders": leaders,
+			"time":    time.Now().Format(time.RFC3339),
 		})
 	})
 
@@ -241,6 +276,19 @@ func main() {
 		instanceGroup.DELETE("/:id", httputil.RequirePermission("instances", httputil.ActionDelete), instanceHandler.Terminate)
 	}
 
+	// Launch Template Routes (Protected)
+	launchTemplateGroup := r.Group("/launch-templates")
+	launchTemplateGroup.Use(httputil.Auth(identitySvc, authSvc))
+	{
+		launchTemplateGroup.POST("", httputil.RequirePermission("instances", httputil.ActionCreate), launchTemplateHandler.Create)
+		launchTemplateGroup.GET("", httputil.RequirePermission("instances", httputil.ActionRead), launchTemplateHandler.List)
+		launchTemplateGroup.GET("/:id", httputil.RequirePermission("instances", httputil.ActionRead), launchTemplateHandler.Get)
+		launchTemplateGroup.DELETE("/:id", httputil.RequirePermission("instances", httputil.ActionDelete), launchTemplateHandler.Delete)
+		launchTemplateGroup.POST("/:id/versions", httputil.RequirePermission("instances", httputil.ActionUpdate), launchTemplateHandler.CreateVersion)
+		launchTemplateGroup.GET("/:id/versions", httputil.RequirePermission("instances", httputil.ActionRead), launchTemplateHandler.ListVersions)
+		launchTemplateGroup.GET("/:id/versions/:version", httputil.RequirePermission("instances", httputil.ActionRead), launchTemplateHandler.GetVersion)
+	}
+
 	// VPC Routes (Protected)
 	vpcGroup := r.Group("/vpcs")
 	vpcGroup.Use(httputil.Auth(identitySvc, authSvc))
@@ -255,10 +303,64 @@ func main() {
 	storageGroup := r.Group("/storage")
 	storageGroup.Use(httputil.Auth(identitySvc, authSvc))
 	{
-		storageGroup.PUT("/:bucket/:key", httputil.RequirePermission("storage", httputil.ActionCreate), storageHandler.Upload)
-		storageGroup.GET("/:bucket/:key", httputil.RequirePermission("storage", httputil.ActionRead), storageHandler.Download)
 		storageGroup.GET("/:bucket", httputil.RequirePermission("storage", httputil.ActionRead), storageHandler.List)
 		storageGroup.DELETE("/:bucket/:key", httputil.RequirePermission("storage", httputil.ActionDelete), storageHandler.Delete)
+		storageGroup.POST("/:bucket/:key/presign", httputil.RequirePermission("storage", httputil.ActionRead), storageHandler.Presign)
+
+		// Multipart uploads
+		storageGroup.POST("/:bucket/:key/uploads", httputil.RequirePermission("storage", httputil.ActionCreate), storageHandler.InitiateMultipartUpload)
+		storageGroup.PUT("/:bucket/:key/uploads/:id/parts/:part", httputil.RequirePermission("storage", httputil.ActionCreate), storageHandler.UploadPart)
+		storageGroup.GET("/:bucket/:key/uploads/:id/parts", httputil.RequirePermission("storage", httputil.ActionRead), storageHandler.ListParts)
+		storageGroup.POST("/:bucket/:key/uploads/:id/complete", httputil.RequirePermission("storage", httputil.ActionCreate), storageHandler.CompleteMultipartUpload)
+		storageGroup.DELETE("/:bucket/:key/uploads/:id", httputil.RequirePermission("storage", httputil.ActionDelete), storageHandler.AbortMultipartUpload)
+	}
+
+	// Object Routes (Protected, also reachable through pre-signed URLs)
+	objectGroup := r.Group("/storage")
+	objectGroup.Use(httputil.Presigned(storageSvc, identitySvc, authSvc))
+	{
+		objectGroup.PUT("/:bucket/:key", httputil.RequirePermission("storage", httputil.ActionCreate), storageHandler.Upload)
+		objectGroup.GET("/:bucket/:key", httputil.RequirePermission("storage", httputil.ActionRead), storageHandler.Download)
+		objectGroup.HEAD("/:bucket/:key", httputil.RequirePermission("storage", httputil.ActionRead), storageHandler.Head)
+	}
+
+	// Bucket Configuration Routes (Protected)
+	bucketGroup := r.Group("/buckets")
+	bucketGroup.Use(httputil.Auth(identitySvc, authSvc))
+	{
+		bucketGroup.GET("/:bucket/lifecycle", httputil.RequirePermission("storage", httputil.ActionRead), storageHandler.ListLifecycleRules)
+		bucketGroup.POST("/:bucket/lifecycle", httputil.RequirePermission("storage", httputil.ActionUpdate), storageHandler.CreateLifecycleRule)
+		bucketGroup.DELETE("/:bucket/lifecycle/:id", httputil.RequirePermission("storage", httputil.ActionUpdate), storageHandler.DeleteLifecycleRule)
+		bucketGroup.GET("/:bucket/encryption", httputil.RequirePermission("storage", httputil.ActionRead), storageHandler.GetBucketEncryption)
+		bucketGroup.PUT("/:bucket/encryption", httputil.RequirePermission("storage", httputil.ActionUpdate), storageHandler.PutBucketEncryption)
+		bucketGroup.DELETE("/:bucket/encryption", httputil.RequirePermission("storage", httputil.ActionUpdate), storageHandler.DeleteBucketEncryption)
+		bucketGroup.GET("/:bucket/website", httputil.RequirePermission("storage", httputil.ActionRead), storageHandler.GetBucketWebsite)
+		bucketGroup.PUT("/:bucket/website", httputil.RequirePermission("storage", httputil.ActionUpdate), storageHandler.PutBucketWebsite)
+		bucketGroup.DELETE("/:bucket/website", httputil.RequirePermission("storage", httputil.ActionUpdate), storageHandler.DeleteBucketWebsite)
+		bucketGroup.GET("/:bucket/notifications", httputil.RequirePermission("storage", httputil.ActionRead), notificationHandler.List)
+		bucketGroup.POST("/:bucket/notifications", httputil.RequirePermission("storage", httputil.ActionUpdate), notificationHandler.Create)
+		bucketGroup.DELETE("/:bucket/notifications/:id", httputil.RequirePermission("storage", httputil.ActionUpdate), notificationHandler.Delete)
+	}
+
+	// Bucket Website Routes (Public)
+	r.GET("/website/:bucket/*path", websiteHandler.Serve)
+	r.HEAD("/website/:bucket/*path", websiteHandler.Serve)
+
+	// S3-Compatible Routes (SigV4, path-style: <endpoint>/s3/<bucket>/<key>)
+	s3Group := r.Group("/s3")
+	s3Group.Use(httputil.S3Auth(identitySvc, authSvc))
+	{
+		s3Group.GET("/", httputil.RequireS3Permission("storage", httputil.ActionRead), s3Handler.ListBuckets)
+		s3Group.PUT("/:bucket", httputil.RequireS3Permission("storage", httputil.ActionCreate), s3Handler.CreateBucket)
+		s3Group.HEAD("/:bucket", httputil.RequireS3Permission("storage", httputil.ActionRead), s3Handler.HeadBucket)
+		s3Group.GET("/:bucket", httputil.RequireS3Permission("storage", httputil.ActionRead), s3Handler.ListObjects)
+		s3Group.DELETE("/:bucket", httputil.RequireS3Permission("storage", httputil.ActionDelete), s3Handler.DeleteBucket)
+		s3Group.POST("/:bucket", s3Handler.PostBucket)
+		s3Group.PUT("/:bucket/*key", httputil.RequireS3Permission("storage", httputil.ActionCreate), s3Handler.PutObject)
+		s3Group.GET("/:bucket/*key", httputil.RequireS3Permission("storage", httputil.ActionRead), s3Handler.GetObject)
+		s3Group.HEAD("/:bucket/*key", httputil.RequireS3Permission("storage", httputil.ActionRead), s3Handler.HeadObject)
+		s3Group.DELETE("/:bucket/*key", httputil.RequireS3Permission("storage", httputil.ActionDelete), s3Handler.DeleteObject)
+		s3Group.POST("/:bucket/*key", httputil.RequireS3Permission("storage", httputil.ActionCreate), s3Handler.PostObject)
 	}
 
 	// Event Routes (Protected)
@@ -295,10 +397,20 @@ func main() {
 		lbGroup.POST("", httputil.RequirePermission("loadbalancers", httputil.ActionCreate), lbHandler.Create)
 		lbGroup.GET("", httputil.RequirePermission("loadbalancers", httputil.ActionRead), lbHandler.List)
 		lbGroup.GET("/:id", httputil.RequirePermission("loadbalancers", httputil.ActionRead), lbHandler.Get)
+		lbGroup.PATCH("/:id", httputil.RequirePermission("loadbalancers", httputil.ActionUpdate), lbHandler.Update)
 		lbGroup.DELETE("/:id", httputil.RequirePermission("loadbalancers", httputil.ActionDelete), lbHandler.Delete)
+		lbGroup.PUT("/:id/health-check", httputil.RequirePermission("loadbalancers", httputil.ActionUpdate), lbHandler.UpdateHealthCheck)
+		lbGroup.PUT("/:id/deregistration-delay", httputil.RequirePermission("loadbalancers", httputil.ActionUpdate), lbHandler.SetDeregistrationDelay)
+		lbGroup.PUT("/:id/access-logs", httputil.RequirePermission("loadbalancers", httputil.ActionUpdate), lbHandler.SetAccessLogs)
+		lbGroup.GET("/:id/metrics", httputil.RequirePermission("loadbalancers", httputil.ActionRead), lbHandler.GetMetrics)
 		lbGroup.POST("/:id/targets", httputil.RequirePermission("loadbalancers", httputil.ActionUpdate), lbHandler.AddTarget)
 		lbGroup.GET("/:id/targets", httputil.RequirePermission("loadbalancers", httputil.ActionRead), lbHandler.ListTargets)
 		lbGroup.DELETE("/:id/targets/:instanceId", httputil.RequirePermission("loadbalancers", httputil.ActionUpdate), lbHandler.RemoveTarget)
+		lbGroup.POST("/:id/listeners", httputil.RequirePermission("loadbalancers", httputil.ActionUpdate), lbHandler.AddListener)
+		lbGroup.GET("/:id/listeners", httputil.RequirePermission("loadbalancers", httputil.ActionRead), lbHandler.ListListeners)
+		lbGroup.DELETE("/:id/listeners/:listenerId", httputil.RequirePermission("loadbalancers", httputil.ActionUpdate), lbHandler.RemoveListener)
+		lbGroup.POST("/:id/listeners/:listenerId/rules", httputil.RequirePermission("loadbalancers", httputil.ActionUpdate), lbHandler.AddRoutingRule)
+		lbGroup.DELETE("/:id/listeners/:listenerId/rules/:ruleId", httputil.RequirePermission("loadbalancers", httputil.ActionUpdate), lbHandler.RemoveRoutingRule)
 	}
 
 	// Database Routes (Protected)
@@ -348,11 +460,22 @@ func main() {
 		cacheGroup.GET("/:id/stats", httputil.RequirePermission("caches", httputil.ActionRead), cacheHandler.GetStats)
 	}
 
+	// Custom Metrics Routes (Protected)
+	metricsRepo := postgres.NewMetricsRepository(db)
+	metricsHandler := httphandlers.NewMetricsHandler(services.NewMetricsService(metricsRepo, instanceRepo))
+
+	metricsGroup := r.Group("/metrics")
+	metricsGroup.Use(httputil.Auth(identitySvc, authSvc))
+	{
+		metricsGroup.PUT("/custom", httputil.RequirePermission("metrics", httputil.ActionUpdate), metricsHandler.PutCustomMetrics)
+	}
+
 	// Auto-Scaling Routes (Protected)
 	asgRepo := postgres.NewAutoScalingRepo(db)
-	asgSvc := services.NewAutoScalingService(asgRepo, vpcRepo)
+	asgSvc := services.NewAutoScalingService(asgRepo, vpcRepo, launchTemplateRepo, lbSvc, eventSvc, ports.RealClock{})
 	asgHandler := httphandlers.NewAutoScalingHandler(asgSvc)
-	asgWorker := services.NewAutoScalingWorker(asgRepo, instanceSvc, lbSvc, eventSvc, ports.RealClock{})
+	asgWorker := services.NewAutoScalingWorker(asgRepo, launchTemplateRepo, instanceSvc, lbSvc, eventSvc, ports.RealClock{})
+	metricsCollector := services.NewMetricsCollector(instanceRepo, dockerAdapter, metricsRepo, ports.RealClock{})
 
 	asgGroup := r.Group("/autoscaling")
 	asgGroup.Use(httputil.Auth(identitySvc, authSvc))
@@ -361,16 +484,48 @@ func main() {
 		asgGroup.GET("/groups", httputil.RequirePermission("autoscaling", httputil.ActionRead), asgHandler.ListGroups)
 		asgGroup.GET("/groups/:id", httputil.RequirePermission("autoscaling", httputil.ActionRead), asgHandler.GetGroup)
 		asgGroup.DELETE("/groups/:id", httputil.RequirePermission("autoscaling", httputil.ActionDelete), asgHandler.DeleteGroup)
+		asgGroup.PATCH("/groups/:id", httputil.RequirePermission("autoscaling", httputil.ActionUpdate), asgHandler.UpdateGroup)
+		asgGroup.POST("/groups/:id/suspend", httputil.RequirePermission("autoscaling", httputil.ActionUpdate), asgHandler.SuspendProcesses)
+		asgGroup.POST("/groups/:id/resume", httputil.RequirePermission("autoscaling", httputil.ActionUpdate), asgHandler.ResumeProcesses)
+		asgGroup.PUT("/groups/:id/launch-template", httputil.RequirePermission("autoscaling", httputil.ActionUpdate), asgHandler.SetLaunchTemplate)
+		asgGroup.POST("/groups/:id/refresh", httputil.RequirePermission("autoscaling", httputil.ActionUpdate), asgHandler.StartInstanceRefresh)
+		asgGroup.GET("/groups/:id/refresh", httputil.RequirePermission("autoscaling", httputil.ActionRead), asgHandler.GetInstanceRefresh)
+		asgGroup.POST("/groups/:id/refresh/pause", httputil.RequirePermission("autoscaling", httputil.ActionUpdate), asgHandler.PauseInstanceRefresh)
+		asgGroup.POST("/groups/:id/refresh/resume", httputil.RequirePermission("autoscaling", httputil.ActionUpdate), asgHandler.ResumeInstanceRefresh)
+		asgGroup.POST("/groups/:id/refresh/cancel", httputil.RequirePermission("autoscaling", httputil.ActionUpdate), asgHandler.CancelInstanceRefresh)
+		asgGroup.POST("/groups/:id/lifecycle-hooks", httputil.RequirePermission("autoscaling", httputil.ActionUpdate), asgHandler.CreateLifecycleHook)
+		asgGroup.GET("/groups/:id/lifecycle-hooks", httputil.RequirePermission("autoscaling", httputil.ActionRead), asgHandler.ListLifecycleHooks)
+		asgGroup.DELETE("/lifecycle-hooks/:id", httputil.RequirePermission("autoscaling", httputil.ActionDelete), asgHandler.DeleteLifecycleHook)
+		asgGroup.POST("/groups/:id/lifecycle-actions", httputil.RequirePermission("autoscaling", httputil.ActionUpdate), asgHandler.CompleteLifecycleAction)
+		asgGroup.GET("/groups/:id/activities", httputil.RequirePermission("autoscaling", httputil.ActionRead), asgHandler.ListActivities)
 		asgGroup.POST("/groups/:id/policies", httputil.RequirePermission("autoscaling", httputil.ActionUpdate), asgHandler.CreatePolicy)
 		asgGroup.DELETE("/policies/:id", httputil.RequirePermission("autoscaling", httputil.ActionDelete), asgHandler.DeletePolicy)
+		asgGroup.POST("/groups/:id/schedules", httputil.RequirePermission("autoscaling", httputil.ActionUpdate), asgHandler.CreateScheduledAction)
+		asgGroup.GET("/groups/:id/schedules", httputil.RequirePermission("autoscaling", httputil.ActionRead), asgHandler.ListScheduledActions)
+		asgGroup.DELETE("/schedules/:id", httputil.RequirePermission("autoscaling", httputil.ActionDelete), asgHandler.DeleteScheduledAction)
 	}
 
 	// 7. Background Workers
+	// The load balancer, auto-scaling, storage and metrics workers and the
+	// scrubber run on one replica only, elected through a lease. Every replica
+	// delivers notifications, as each delivery is claimed by one of them.
+	leaseRepo := postgres.NewLeaseRepository(db)
+	electors = []*services.LeaderElector{
+		services.NewLeaderElector(leaseRepo, "lb-worker", cfg.ReplicaID, lbWorker, ports.RealClock{}),
+		services.NewLeaderElector(leaseRepo, "autoscaling-worker", cfg.ReplicaID, asgWorker, ports.RealClock{}),
+		services.NewLeaderElector(leaseRepo, "storage-worker", cfg.ReplicaID, storageWorker, ports.RealClock{}),
+		services.NewLeaderElector(leaseRepo, "metrics-collector", cfg.ReplicaID, metricsCollector, ports.RealClock{}),
+	}
+	if scrubbing, ok := fileStore.(ports.ScrubbingFileStore); ok {
+		electors = append(electors, services.NewLeaderElector(leaseRepo, "storage-scrubber", cfg.ReplicaID, services.NewScrubWorker(scrubbing), ports.RealClock{}))
+	}
 	wg := &sync.WaitGroup{}
 	workerCtx, workerCancel := context.WithCancel(context.Background())
-	wg.Add(2)
-	go lbWorker.Run(workerCtx, wg)
-	go asgWorker.Run(workerCtx, wg)
+	wg.Add(len(electors) + 1)
+	for _, e := range electors {
+		go e.Run(workerCtx, wg)
+	}
+	go notificationWorker.Run(workerCtx, wg)
 
 	// 8. Server setup
 	srv := &http.Server{
@@ -403,6 +558,34 @@ func main() {
 	// Shutdown workers
 	workerCancel()
 	wg.Wait()
+	if closer, ok := lbProxy.(interface{ Close() }); ok {
+		closer.Close()
+	}
 
 	logger.Info("server exited")
 }
+
+// newFileStore builds the object store selected by the storage backend config.
+func newFileStore(cfg *platform.Config) (ports.FileStore, error) {
+	if cfg.StorageBackend != "distributed" {
+		return filesystem.NewLocalFileStore("./thecloud-data/local/storage")
+	}
+
+	dirs := cfg.StorageDataDirs
+	if len(dirs) == 0 {
+		dirs = []string{
+			"./thecloud-data/distributed/disk0",
+			"./thecloud-data/distributed/disk1",
+			"./thecloud-data/distributed/disk2",
+		}
+	}
+	return filesystem.NewDistributedFileStore(dirs, cfg.StorageReplicas)
+}
+
+// newLBProxy builds the load balancer data plane selected by the config.
+func newLBProxy(cfg *platform.Config, instanceRepo ports.InstanceRepository, vpcRepo ports.VpcRepository) (ports.LBProxyAdapter, error) {
+	if cfg.LBProxy == "go" {
+		return lbproxy.NewAdapter(lbproxy.HostPortResolver(instanceRepo)), nil
+	}
+	return docker.NewLBProxyAdapter(instanceRepo, vpcRepo)
+}
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/poyrazk/thecloud/pkg/sdk"
@@ -28,21 +31,31 @@ var asgCreateCmd = &cobra.Command{
 		desired, _ := cmd.Flags().GetInt("desired")
 		lbID, _ := cmd.Flags().GetString("lb")
 		ports, _ := cmd.Flags().GetString("ports")
+		templateID, _ := cmd.Flags().GetString("template")
+		templateVersion, _ := cmd.Flags().GetInt("template-version")
+		healthCheck, _ := cmd.Flags().GetString("health-check")
 
 		client := getClient()
 
 		req := sdk.CreateScalingGroupRequest{
-			Name:         name,
-			VpcID:        vpcID,
-			Image:        image,
-			MinInstances: min,
-			MaxInstances: max,
-			DesiredCount: desired,
-			Ports:        ports,
+			Name:                  name,
+			VpcID:                 vpcID,
+			Image:                 image,
+			MinInstances:          min,
+			MaxInstances:          max,
+			DesiredCount:          desired,
+			Ports:                 ports,
+			LaunchTemplateID:      templateID,
+			LaunchTemplateVersion: templateVersion,
+			HealthCheckType:       healthCheck,
 		}
 		if lbID != "" {
 			req.LoadBalancerID = &lbID
 		}
+		if cmd.Flags().Changed("health-check-grace") {
+			grace, _ := cmd.Flags().GetInt("health-check-grace")
+			req.HealthCheckGracePeriodSec = &grace
+		}
 
 		group, err := client.CreateScalingGroup(req)
 		if err != nil {
@@ -78,16 +91,94 @@ var asgListCmd = &cobra.Command{
 		}
 
 		table := tablewriter.NewWriter(os.Stdout)
-		table.Header([]string{"ID", "NAME", "INSTANCES (Cur/Des/Min/Max)", "STATUS"})
+		table.Header([]string{"ID", "NAME", "INSTANCES (Cur/Des/Min/Max)", "STATUS", "SUSPENDED"})
 
 		for _, g := range groups {
 			instances := fmt.Sprintf("%d / %d / %d / %d", g.CurrentCount, g.DesiredCount, g.MinInstances, g.MaxInstances)
-			table.Append([]string{g.ID, g.Name, instances, g.Status})
+			suspended := "-"
+			if len(g.SuspendedProcesses) > 0 {
+				suspended = strings.Join(g.SuspendedProcesses, ", ")
+			}
+			table.Append([]string{g.ID, g.Name, instances, g.Status, suspended})
 		}
 		table.Render()
 	},
 }
 
+var asgUpdateCmd = &cobra.Command{
+	Use:   "update <id>",
+	Short: "Change the name, image, sizes, load balancer or health check of a scaling group",
+	Long:  "Flags change only the given settings. --lb \"\" detaches the load balancer; instances in service move to a new one.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		var req sdk.UpdateScalingGroupRequest
+		for flag, value := range map[string]**string{"name": &req.Name, "image": &req.Image, "lb": &req.LoadBalancerID, "health-check": &req.HealthCheckType} {
+			if flags.Changed(flag) {
+				v, _ := flags.GetString(flag)
+				*value = &v
+			}
+		}
+		for flag, size := range map[string]**int{"min": &req.MinInstances, "max": &req.MaxInstances, "desired": &req.DesiredCount, "health-check-grace": &req.HealthCheckGracePeriodSec} {
+			if flags.Changed(flag) {
+				v, _ := flags.GetInt(flag)
+				*size = &v
+			}
+		}
+
+		client := getClient()
+		group, err := client.UpdateScalingGroup(args[0], req)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(group, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		fmt.Printf("[SUCCESS] Scaling Group %s updated\n", group.Name)
+	},
+}
+
+// scalingProcessesCmd builds the suspend and resume commands, which act on
+// every process when none are named.
+func scalingProcessesCmd(use, short string, action func(c *sdk.Client, groupID string, processes ...string) (*sdk.ScalingGroup, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " <id> [process...]",
+		Short: short,
+		Long:  short + ". Processes are launch, terminate, replace-unhealthy and scheduled-actions; all of them when none are given.",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			processes := make([]string, len(args)-1)
+			for i, p := range args[1:] {
+				processes[i] = strings.ToUpper(strings.ReplaceAll(p, "-", "_"))
+			}
+
+			client := getClient()
+			group, err := action(client, args[0], processes...)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if outputJSON {
+				data, _ := json.MarshalIndent(group, "", "  ")
+				fmt.Println(string(data))
+				return
+			}
+
+			suspended := "none"
+			if len(group.SuspendedProcesses) > 0 {
+				suspended = strings.Join(group.SuspendedProcesses, ", ")
+			}
+			fmt.Printf("[SUCCESS] Suspended processes of %s: %s\n", group.Name, suspended)
+		},
+	}
+}
+
 var asgRmCmd = &cobra.Command{
 	Use:   "rm <id>",
 	Short: "Delete a scaling group",
@@ -108,20 +199,44 @@ var asgPolicyAddCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		name, _ := cmd.Flags().GetString("name")
+		policyType, _ := cmd.Flags().GetString("type")
 		metric, _ := cmd.Flags().GetString("metric")
+		metricName, _ := cmd.Flags().GetString("metric-name")
 		target, _ := cmd.Flags().GetFloat64("target")
-		scaleOut, _ := cmd.Flags().GetInt("scale-out")
-		scaleIn, _ := cmd.Flags().GetInt("scale-in")
+		scaleInThreshold, _ := cmd.Flags().GetFloat64("scale-in-threshold")
+		stepFlags, _ := cmd.Flags().GetStringArray("step")
+		window, _ := cmd.Flags().GetInt("window")
 		cooldown, _ := cmd.Flags().GetInt("cooldown")
+		scaleOutCooldown, _ := cmd.Flags().GetInt("scale-out-cooldown")
+		scaleInCooldown, _ := cmd.Flags().GetInt("scale-in-cooldown")
+
+		var steps []sdk.ScalingStep
+		for _, f := range stepFlags {
+			step, err := parseScalingStep(f)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			steps = append(steps, step)
+		}
+		if policyType == "step" {
+			// Step policies scale on their bands alone.
+			target = 0
+		}
 
 		client := getClient()
 		req := sdk.CreatePolicyRequest{
-			Name:        name,
-			MetricType:  metric,
-			TargetValue: target,
-			ScaleOut:    scaleOut,
-			ScaleIn:     scaleIn,
-			CooldownSec: cooldown,
+			Name:                name,
+			PolicyType:          policyType,
+			MetricType:          metric,
+			MetricName:          metricName,
+			TargetValue:         target,
+			ScaleInThreshold:    scaleInThreshold,
+			Steps:               steps,
+			EvaluationPeriodSec: window,
+			CooldownSec:         cooldown,
+			ScaleOutCooldownSec: scaleOutCooldown,
+			ScaleInCooldownSec:  scaleInCooldown,
 		}
 
 		if err := client.CreateScalingPolicy(args[0], req); err != nil {
@@ -132,31 +247,453 @@ var asgPolicyAddCmd = &cobra.Command{
 	},
 }
 
+var asgScheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Manage scheduled scaling actions",
+}
+
+var asgScheduleAddCmd = &cobra.Command{
+	Use:   "add <group-id>",
+	Short: "Resize a scaling group on a cron schedule",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		schedule, _ := cmd.Flags().GetString("cron")
+		timeZone, _ := cmd.Flags().GetString("tz")
+
+		req := sdk.CreateScheduledActionRequest{Name: name, Schedule: schedule, TimeZone: timeZone}
+		// Only the sizes given are changed.
+		for flag, size := range map[string]**int{"min": &req.MinInstances, "max": &req.MaxInstances, "desired": &req.DesiredCount} {
+			if cmd.Flags().Changed(flag) {
+				v, _ := cmd.Flags().GetInt(flag)
+				*size = &v
+			}
+		}
+
+		client := getClient()
+		action, err := client.CreateScheduledAction(args[0], req)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(action, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		fmt.Printf("[SUCCESS] Scheduled action %s created (ID: %s), next run at %s\n", action.Name, action.ID, action.NextRunAt.Format(time.RFC3339))
+	},
+}
+
+var asgScheduleListCmd = &cobra.Command{
+	Use:   "list <group-id>",
+	Short: "List the scheduled actions of a scaling group",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		actions, err := client.ListScheduledActions(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(actions, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header([]string{"ID", "NAME", "SCHEDULE", "TIME ZONE", "SIZE (Des/Min/Max)", "NEXT RUN"})
+
+		size := func(v *int) string {
+			if v == nil {
+				return "-"
+			}
+			return strconv.Itoa(*v)
+		}
+		for _, a := range actions {
+			sizes := fmt.Sprintf("%s / %s / %s", size(a.DesiredCount), size(a.MinInstances), size(a.MaxInstances))
+			table.Append([]string{a.ID, a.Name, a.Schedule, a.TimeZone, sizes, a.NextRunAt.Format(time.RFC3339)})
+		}
+		table.Render()
+	},
+}
+
+var asgScheduleRmCmd = &cobra.Command{
+	Use:   "rm <action-id>",
+	Short: "Delete a scheduled action",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		if err := client.DeleteScheduledAction(args[0]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("[SUCCESS] Scheduled action deleted")
+	},
+}
+
+var asgSetTemplateCmd = &cobra.Command{
+	Use:   "set-template <group-id> <template-id>",
+	Short: "Move a scaling group to a launch template version",
+	Long:  "Move a scaling group to a launch template version. New instances use the version; running instances keep theirs.",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		version, _ := cmd.Flags().GetInt("version")
+
+		client := getClient()
+		group, err := client.SetScalingGroupLaunchTemplate(args[0], args[1], version)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(group, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		fmt.Printf("[SUCCESS] Scaling Group %s now launches version %d of template %s\n", group.Name, group.LaunchTemplateVersion, group.LaunchTemplateID)
+	},
+}
+
+var asgRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Replace the instances of a scaling group in batches",
+}
+
+var asgRefreshStartCmd = &cobra.Command{
+	Use:   "start <group-id>",
+	Short: "Start an instance refresh",
+	Long:  "Start replacing the instances of a scaling group in batches. With --template or --image the group moves to that configuration first and is rolled back if the replacements never turn healthy.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		timeout, _ := cmd.Flags().GetInt("timeout")
+		templateID, _ := cmd.Flags().GetString("template")
+		templateVersion, _ := cmd.Flags().GetInt("template-version")
+		image, _ := cmd.Flags().GetString("image")
+
+		req := sdk.StartInstanceRefreshRequest{
+			HealthCheckTimeoutSec: timeout,
+			LaunchTemplateID:      templateID,
+			LaunchTemplateVersion: templateVersion,
+			Image:                 image,
+		}
+		if cmd.Flags().Changed("min-healthy") {
+			v, _ := cmd.Flags().GetInt("min-healthy")
+			req.MinHealthyPercentage = &v
+		}
+
+		client := getClient()
+		refresh, err := client.StartInstanceRefresh(args[0], req)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		printInstanceRefresh(refresh)
+	},
+}
+
+var asgRefreshStatusCmd = &cobra.Command{
+	Use:   "status <group-id>",
+	Short: "Show the latest instance refresh of a scaling group",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		refresh, err := client.GetInstanceRefresh(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		printInstanceRefresh(refresh)
+	},
+}
+
+// instanceRefreshActionCmd builds the pause, resume and cancel commands.
+func instanceRefreshActionCmd(use, short string, action func(c *sdk.Client, groupID string) (*sdk.InstanceRefresh, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " <group-id>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			refresh, err := action(getClient(), args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			printInstanceRefresh(refresh)
+		},
+	}
+}
+
+func printInstanceRefresh(refresh *sdk.InstanceRefresh) {
+	if outputJSON {
+		data, _ := json.MarshalIndent(refresh, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Printf("Refresh:     %s\n", refresh.ID)
+	fmt.Printf("Status:      %s\n", refresh.Status)
+	if refresh.StatusReason != "" {
+		fmt.Printf("Reason:      %s\n", refresh.StatusReason)
+	}
+	fmt.Printf("Progress:    %d/%d instances replaced\n", refresh.InstancesReplaced, refresh.InstancesToReplace)
+	fmt.Printf("Min healthy: %d%%\n", refresh.MinHealthyPercentage)
+	fmt.Printf("Started:     %s\n", refresh.StartedAt.Format(time.RFC3339))
+	if refresh.EndedAt != nil {
+		fmt.Printf("Ended:       %s\n", refresh.EndedAt.Format(time.RFC3339))
+	}
+}
+
+// parseScalingStep parses a step band given as lower:upper:adjustment, such
+// as "80::2" for +2 instances at 80 and above.
+var asgHookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage lifecycle hooks",
+}
+
+var asgHookAddCmd = &cobra.Command{
+	Use:   "add <group-id>",
+	Short: "Hold launching or terminating instances until a lifecycle action completes",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		transition, _ := cmd.Flags().GetString("transition")
+		timeout, _ := cmd.Flags().GetInt("timeout")
+		defaultResult, _ := cmd.Flags().GetString("default-result")
+
+		client := getClient()
+		hook, err := client.CreateLifecycleHook(args[0], sdk.CreateLifecycleHookRequest{
+			Name:                name,
+			Transition:          strings.ToUpper(transition),
+			HeartbeatTimeoutSec: timeout,
+			DefaultResult:       strings.ToUpper(defaultResult),
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(hook, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		fmt.Printf("[SUCCESS] Lifecycle hook %s created (ID: %s)\n", hook.Name, hook.ID)
+	},
+}
+
+var asgHookListCmd = &cobra.Command{
+	Use:   "list <group-id>",
+	Short: "List the lifecycle hooks of a scaling group",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		hooks, err := client.ListLifecycleHooks(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(hooks, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header([]string{"ID", "NAME", "TRANSITION", "TIMEOUT", "DEFAULT RESULT"})
+		for _, h := range hooks {
+			table.Append([]string{h.ID, h.Name, h.Transition, fmt.Sprintf("%ds", h.HeartbeatTimeoutSec), h.DefaultResult})
+		}
+		table.Render()
+	},
+}
+
+var asgHookRmCmd = &cobra.Command{
+	Use:   "rm <hook-id>",
+	Short: "Delete a lifecycle hook",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		if err := client.DeleteLifecycleHook(args[0]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("[SUCCESS] Lifecycle hook deleted")
+	},
+}
+
+var asgCompleteActionCmd = &cobra.Command{
+	Use:   "complete-action <group-id> <instance-id>",
+	Short: "Release an instance waiting on a lifecycle hook",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		result, _ := cmd.Flags().GetString("result")
+
+		client := getClient()
+		if err := client.CompleteLifecycleAction(args[0], args[1], strings.ToUpper(result)); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("[SUCCESS] Lifecycle action completed")
+	},
+}
+
+var asgActivitiesCmd = &cobra.Command{
+	Use:   "activities <group-id>",
+	Short: "Show the scaling activity history of a group",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		client := getClient()
+		activities, err := client.ListScalingActivities(args[0], limit)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(activities, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header([]string{"STARTED", "DESCRIPTION", "CAUSE", "CAPACITY", "STATUS"})
+		for _, a := range activities {
+			status := a.Status
+			if a.StatusMessage != "" {
+				status += ": " + a.StatusMessage
+			}
+			table.Append([]string{a.StartedAt.Format(time.RFC3339), a.Description, a.Cause, fmt.Sprintf("%d -> %d", a.FromCapacity, a.ToCapacity), status})
+		}
+		table.Render()
+	},
+}
+
+func parseScalingStep(s string) (sdk.ScalingStep, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return sdk.ScalingStep{}, fmt.Errorf("invalid step %q, want lower:upper:adjustment", s)
+	}
+	var step sdk.ScalingStep
+	for i, bound := range []**float64{&step.LowerBound, &step.UpperBound} {
+		if parts[i] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(parts[i], 64)
+		if err != nil {
+			return sdk.ScalingStep{}, fmt.Errorf("invalid step bound %q", parts[i])
+		}
+		*bound = &v
+	}
+	adjustment, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return sdk.ScalingStep{}, fmt.Errorf("invalid step adjustment %q", parts[2])
+	}
+	step.Adjustment = adjustment
+	return step, nil
+}
+
 func init() {
 	asgCreateCmd.Flags().String("name", "", "Group Name")
 	asgCreateCmd.Flags().String("vpc", "", "VPC ID")
 	asgCreateCmd.Flags().String("image", "", "Docker Image")
 	asgCreateCmd.Flags().String("lb", "", "Load Balancer ID (Optional)")
 	asgCreateCmd.Flags().String("ports", "", "Ports (e.g. 8080:80)")
+	asgCreateCmd.Flags().String("template", "", "Launch template ID (replaces --image and --ports)")
+	asgCreateCmd.Flags().Int("template-version", 0, "Launch template version (default latest)")
 	asgCreateCmd.Flags().Int("min", 1, "Min instances")
 	asgCreateCmd.Flags().Int("max", 5, "Max instances")
 	asgCreateCmd.Flags().Int("desired", 1, "Desired instances")
+	asgCreateCmd.Flags().String("health-check", "", "Health check type (container|lb, default container)")
+	asgCreateCmd.Flags().Int("health-check-grace", 0, "Seconds after launch before instances are health checked (default 300)")
 	asgCreateCmd.MarkFlagRequired("name")
 	asgCreateCmd.MarkFlagRequired("vpc")
 	asgCreateCmd.MarkFlagRequired("image")
 
 	asgPolicyAddCmd.Flags().String("name", "", "Policy Name")
-	asgPolicyAddCmd.Flags().String("metric", "cpu", "Metric Type (cpu|memory)")
-	asgPolicyAddCmd.Flags().Float64("target", 80.0, "Target Value")
-	asgPolicyAddCmd.Flags().Int("scale-out", 1, "Scale out step")
-	asgPolicyAddCmd.Flags().Int("scale-in", 1, "Scale in step")
-	asgPolicyAddCmd.Flags().Int("cooldown", 300, "Cooldown seconds")
+	asgPolicyAddCmd.Flags().String("metric", "cpu", "Metric Type (cpu|memory|network_in|network_out|lb_request_count|custom)")
+	asgPolicyAddCmd.Flags().String("metric-name", "", "Custom metric name (for --metric custom)")
+	asgPolicyAddCmd.Flags().String("type", "target_tracking", "Policy Type (target_tracking|step)")
+	asgPolicyAddCmd.Flags().Float64("target", 80.0, "Target Value (target_tracking)")
+	asgPolicyAddCmd.Flags().Float64("scale-in-threshold", 0, "Scale in below this value (target_tracking, default 90% of target)")
+	asgPolicyAddCmd.Flags().StringArray("step", nil, "Step band as lower:upper:adjustment, either bound may be empty (repeatable, step)")
+	asgPolicyAddCmd.Flags().Int("window", 0, "Evaluation window in seconds (default 60)")
+	asgPolicyAddCmd.Flags().Int("cooldown", 0, "Cooldown seconds for both directions (default 300)")
+	asgPolicyAddCmd.Flags().Int("scale-out-cooldown", 0, "Scale out cooldown seconds (overrides --cooldown)")
+	asgPolicyAddCmd.Flags().Int("scale-in-cooldown", 0, "Scale in cooldown seconds (overrides --cooldown)")
 	asgPolicyAddCmd.MarkFlagRequired("name")
 
+	asgScheduleAddCmd.Flags().String("name", "", "Action Name")
+	asgScheduleAddCmd.Flags().String("cron", "", "Cron expression, e.g. \"0 8 * * MON-FRI\"")
+	asgScheduleAddCmd.Flags().String("tz", "UTC", "Time zone of the schedule, e.g. Europe/Istanbul")
+	asgScheduleAddCmd.Flags().Int("min", 0, "Min instances to set")
+	asgScheduleAddCmd.Flags().Int("max", 0, "Max instances to set")
+	asgScheduleAddCmd.Flags().Int("desired", 0, "Desired instances to set")
+	asgScheduleAddCmd.MarkFlagRequired("name")
+	asgScheduleAddCmd.MarkFlagRequired("cron")
+	asgScheduleCmd.AddCommand(asgScheduleAddCmd)
+	asgScheduleCmd.AddCommand(asgScheduleListCmd)
+	asgScheduleCmd.AddCommand(asgScheduleRmCmd)
+
 	autoscalingCmd.AddCommand(asgCreateCmd)
 	autoscalingCmd.AddCommand(asgListCmd)
 	autoscalingCmd.AddCommand(asgRmCmd)
+	autoscalingCmd.AddCommand(asgUpdateCmd)
+	autoscalingCmd.AddCommand(scalingProcessesCmd("suspend", "Suspend processes of a scaling group", (*sdk.Client).SuspendScalingProcesses))
+	autoscalingCmd.AddCommand(scalingProcessesCmd("resume", "Resume suspended processes of a scaling group", (*sdk.Client).ResumeScalingProcesses))
 	autoscalingCmd.AddCommand(asgPolicyAddCmd)
+	autoscalingCmd.AddCommand(asgScheduleCmd)
+	autoscalingCmd.AddCommand(asgSetTemplateCmd)
+
+	asgUpdateCmd.Flags().String("name", "", "New name")
+	asgUpdateCmd.Flags().String("image", "", "Docker Image (groups without a launch template)")
+	asgUpdateCmd.Flags().String("lb", "", "Load Balancer ID, empty to detach")
+	asgUpdateCmd.Flags().Int("min", 0, "Min instances")
+	asgUpdateCmd.Flags().Int("max", 0, "Max instances")
+	asgUpdateCmd.Flags().Int("desired", 0, "Desired instances (default: kept within the new bounds)")
+	asgUpdateCmd.Flags().String("health-check", "", "Health check type (container|lb)")
+	asgUpdateCmd.Flags().Int("health-check-grace", 0, "Seconds after launch before instances are health checked")
+
+	asgSetTemplateCmd.Flags().Int("version", 0, "Template version (default latest)")
+
+	asgRefreshStartCmd.Flags().Int("min-healthy", 90, "Percentage of the desired count kept in service")
+	asgRefreshStartCmd.Flags().Int("timeout", 0, "Seconds to wait for a batch to turn healthy (default 600)")
+	asgRefreshStartCmd.Flags().String("template", "", "Launch template ID to move the group to")
+	asgRefreshStartCmd.Flags().Int("template-version", 0, "Launch template version (default latest)")
+	asgRefreshStartCmd.Flags().String("image", "", "Image to move the group to")
+	asgRefreshCmd.AddCommand(asgRefreshStartCmd)
+	asgRefreshCmd.AddCommand(asgRefreshStatusCmd)
+	asgRefreshCmd.AddCommand(instanceRefreshActionCmd("pause", "Pause an instance refresh", (*sdk.Client).PauseInstanceRefresh))
+	asgRefreshCmd.AddCommand(instanceRefreshActionCmd("resume", "Resume a paused instance refresh", (*sdk.Client).ResumeInstanceRefresh))
+	asgRefreshCmd.AddCommand(instanceRefreshActionCmd("cancel", "Cancel an instance refresh", (*sdk.Client).CancelInstanceRefresh))
+	autoscalingCmd.AddCommand(asgRefreshCmd)
+
+	asgHookAddCmd.Flags().String("name", "", "Hook Name")
+	asgHookAddCmd.Flags().String("transition", "", "Transition to hold (launching|terminating)")
+	asgHookAddCmd.Flags().Int("timeout", 0, "Seconds to wait before the default result applies (default 3600)")
+	asgHookAddCmd.Flags().String("default-result", "abandon", "Result on timeout (continue|abandon)")
+	asgHookAddCmd.MarkFlagRequired("name")
+	asgHookAddCmd.MarkFlagRequired("transition")
+	asgHookCmd.AddCommand(asgHookAddCmd)
+	asgHookCmd.AddCommand(asgHookListCmd)
+	asgHookCmd.AddCommand(asgHookRmCmd)
+	autoscalingCmd.AddCommand(asgHookCmd)
+
+	asgCompleteActionCmd.Flags().String("result", "continue", "Lifecycle action result (continue|abandon)")
+	autoscalingCmd.AddCommand(asgCompleteActionCmd)
+
+	asgActivitiesCmd.Flags().Int("limit", 0, "Number of activities to show (default 50)")
+	autoscalingCmd.AddCommand(asgActivitiesCmd)
 
 	rootCmd.AddCommand(autoscalingCmd)
 }
@@ -98,6 +98,21 @@ var launchCmd = &cobra.Command{
 		ports, _ := cmd.Flags().GetString("port")
 		vpc, _ := cmd.Flags().GetString("vpc")
 		volumeStrs, _ := cmd.Flags().GetStringSlice("volume")
+		templateID, _ := cmd.Flags().GetString("template")
+		templateVersion, _ := cmd.Flags().GetInt("template-version")
+
+		client := getClient()
+		if templateID != "" {
+			inst, err := client.LaunchInstanceFromTemplate(name, templateID, templateVersion)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Printf("[SUCCESS] Instance launched successfully!\n")
+			data, _ := json.MarshalIndent(inst, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
 
 		// Parse volume strings like "vol-name:/path"
 		var volumes []sdk.VolumeAttachmentInput
@@ -111,7 +126,6 @@ var launchCmd = &cobra.Command{
 			}
 		}
 
-		client := getClient()
 		inst, err := client.LaunchInstance(name, image, ports, vpc, volumes)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
@@ -242,6 +256,8 @@ func init() {
 	launchCmd.Flags().StringP("port", "p", "", "Port mapping (host:container)")
 	launchCmd.Flags().StringP("vpc", "v", "", "VPC ID or Name to attach to")
 	launchCmd.Flags().StringSliceP("volume", "V", nil, "Volume attachment (vol-name:/path)")
+	launchCmd.Flags().String("template", "", "Launch template ID (replaces --image, --port, --vpc and --volume)")
+	launchCmd.Flags().Int("template-version", 0, "Launch template version (default latest)")
 	launchCmd.MarkFlagRequired("name")
 
 	rootCmd.PersistentFlags().BoolVarP(&outputJSON, "json", "j", false, "Output in JSON format")
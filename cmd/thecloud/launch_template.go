@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/poyrazk/thecloud/pkg/sdk"
+	"github.com/spf13/cobra"
+)
+
+var launchTemplateCmd = &cobra.Command{
+	Use:     "launch-template",
+	Aliases: []string{"lt"},
+	Short:   "Manage launch templates",
+}
+
+var ltCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a launch template",
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		description, _ := cmd.Flags().GetString("description")
+
+		version, err := launchTemplateVersionFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		client := getClient()
+		template, err := client.CreateLaunchTemplate(sdk.CreateLaunchTemplateRequest{
+			Name:                       name,
+			Description:                description,
+			LaunchTemplateVersionInput: version,
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(template, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		fmt.Printf("[SUCCESS] Launch template %s created (ID: %s)\n", template.Name, template.ID)
+	},
+}
+
+var ltListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List launch templates",
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		templates, err := client.ListLaunchTemplates()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(templates, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header([]string{"ID", "NAME", "LATEST VERSION", "DESCRIPTION"})
+
+		for _, t := range templates {
+			table.Append([]string{t.ID, t.Name, strconv.Itoa(t.LatestVersion), t.Description})
+		}
+		table.Render()
+	},
+}
+
+var ltRmCmd = &cobra.Command{
+	Use:   "rm <id>",
+	Short: "Delete a launch template and its versions",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		if err := client.DeleteLaunchTemplate(args[0]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("[SUCCESS] Launch template deleted")
+	},
+}
+
+var ltVersionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Manage launch template versions",
+}
+
+var ltVersionAddCmd = &cobra.Command{
+	Use:   "add <template-id>",
+	Short: "Add a version to a launch template",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		input, err := launchTemplateVersionFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		client := getClient()
+		version, err := client.CreateLaunchTemplateVersion(args[0], input)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(version, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		fmt.Printf("[SUCCESS] Version %d created\n", version.Version)
+	},
+}
+
+var ltVersionListCmd = &cobra.Command{
+	Use:   "list <template-id>",
+	Short: "List the versions of a launch template",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		versions, err := client.ListLaunchTemplateVersions(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(versions, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header([]string{"VERSION", "IMAGE", "TYPE", "PORTS", "VPC", "CREATED"})
+
+		for _, v := range versions {
+			instanceType := v.InstanceType
+			if instanceType == "" {
+				instanceType = "-"
+			}
+			vpc := v.VpcID
+			if vpc == "" {
+				vpc = "-"
+			}
+			table.Append([]string{strconv.Itoa(v.Version), v.Image, instanceType, v.Ports, vpc, v.CreatedAt.Format(time.RFC3339)})
+		}
+		table.Render()
+	},
+}
+
+var ltVersionShowCmd = &cobra.Command{
+	Use:   "show <template-id> [version]",
+	Short: "Show a launch template version (default latest)",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		version := 0
+		if len(args) == 2 && args[1] != "latest" {
+			v, err := strconv.Atoi(args[1])
+			if err != nil || v <= 0 {
+				fmt.Printf("Error: invalid version %q\n", args[1])
+				os.Exit(1)
+			}
+			version = v
+		}
+
+		client := getClient()
+		v, err := client.GetLaunchTemplateVersion(args[0], version)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		data, _ := json.MarshalIndent(v, "", "  ")
+		fmt.Println(string(data))
+	},
+}
+
+// launchTemplateVersionFromFlags reads the version flags shared by create and
+// version add.
+func launchTemplateVersionFromFlags(cmd *cobra.Command) (sdk.LaunchTemplateVersionInput, error) {
+	image, _ := cmd.Flags().GetString("image")
+	ports, _ := cmd.Flags().GetString("ports")
+	instanceType, _ := cmd.Flags().GetString("type")
+	vpc, _ := cmd.Flags().GetString("vpc")
+	envFlags, _ := cmd.Flags().GetStringArray("env")
+	volumeFlags, _ := cmd.Flags().GetStringArray("volume")
+	userDataFile, _ := cmd.Flags().GetString("user-data-file")
+
+	input := sdk.LaunchTemplateVersionInput{
+		Image:        image,
+		Ports:        ports,
+		InstanceType: instanceType,
+		VpcID:        vpc,
+	}
+	for _, e := range envFlags {
+		key, value, ok := strings.Cut(e, "=")
+		if !ok {
+			return input, fmt.Errorf("invalid env %q, want KEY=VALUE", e)
+		}
+		if input.Env == nil {
+			input.Env = map[string]string{}
+		}
+		input.Env[key] = value
+	}
+	for _, v := range volumeFlags {
+		volumeID, mountPath, ok := strings.Cut(v, ":")
+		if !ok {
+			return input, fmt.Errorf("invalid volume %q, want vol-name:/path", v)
+		}
+		input.Volumes = append(input.Volumes, sdk.VolumeAttachmentInput{VolumeID: volumeID, MountPath: mountPath})
+	}
+	if userDataFile != "" {
+		data, err := os.ReadFile(userDataFile)
+		if err != nil {
+			return input, err
+		}
+		input.UserData = string(data)
+	}
+	return input, nil
+}
+
+func addLaunchTemplateVersionFlags(cmd *cobra.Command) {
+	cmd.Flags().String("image", "", "Docker Image")
+	cmd.Flags().String("ports", "", "Ports (e.g. 8080:80)")
+	cmd.Flags().String("type", "", "Instance type (nano|micro|small|medium|large)")
+	cmd.Flags().String("vpc", "", "VPC ID")
+	cmd.Flags().StringArray("env", nil, "Environment variable as KEY=VALUE (repeatable)")
+	cmd.Flags().StringArray("volume", nil, "Volume attachment as vol-name:/path (repeatable)")
+	cmd.Flags().String("user-data-file", "", "Shell script run in the instance after it starts")
+}
+
+func init() {
+	ltCreateCmd.Flags().String("name", "", "Template Name")
+	ltCreateCmd.Flags().String("description", "", "Template Description")
+	addLaunchTemplateVersionFlags(ltCreateCmd)
+	addLaunchTemplateVersionFlags(ltVersionAddCmd)
+
+	ltVersionCmd.AddCommand(ltVersionAddCmd)
+	ltVersionCmd.AddCommand(ltVersionListCmd)
+	ltVersionCmd.AddCommand(ltVersionShowCmd)
+
+	launchTemplateCmd.AddCommand(ltCreateCmd)
+	launchTemplateCmd.AddCommand(ltListCmd)
+	launchTemplateCmd.AddCommand(ltRmCmd)
+	launchTemplateCmd.AddCommand(ltVersionCmd)
+
+	rootCmd.AddCommand(launchTemplateCmd)
+}
@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
+	"github.com/poyrazk/thecloud/pkg/sdk"
 	"github.com/spf13/cobra"
 )
 
@@ -55,10 +58,14 @@ var lbCreateCmd = &cobra.Command{
 		name, _ := cmd.Flags().GetString("name")
 		vpcID, _ := cmd.Flags().GetString("vpc")
 		port, _ := cmd.Flags().GetInt("port")
-		algo, _ := cmd.Flags().GetString("algorithm")
+		balancing := sdk.LBBalancing{}
+		balancing.Algorithm, _ = cmd.Flags().GetString("algorithm")
+		balancing.HashHeader, _ = cmd.Flags().GetString("hash-header")
+		balancing.Stickiness.Enabled, _ = cmd.Flags().GetBool("sticky")
+		balancing.Stickiness.TTLSeconds, _ = cmd.Flags().GetInt("sticky-ttl")
 
 		client := getClient()
-		lb, err := client.CreateLB(name, vpcID, port, algo)
+		lb, err := client.CreateLBWithBalancing(name, vpcID, port, balancing)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			return
@@ -70,6 +77,64 @@ var lbCreateCmd = &cobra.Command{
 	},
 }
 
+var lbUpdateCmd = &cobra.Command{
+	Use:   "update <lb-id>",
+	Short: "Change the name, algorithm or sticky sessions of a load balancer",
+	Long:  "Flags change only the given settings. Switching away from header-hash drops the hash header.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		var update sdk.LBUpdate
+		if flags.Changed("name") {
+			name, _ := flags.GetString("name")
+			update.Name = &name
+		}
+		if flags.Changed("algorithm") {
+			algo, _ := flags.GetString("algorithm")
+			update.Algorithm = &algo
+		}
+		if flags.Changed("hash-header") {
+			header, _ := flags.GetString("hash-header")
+			update.HashHeader = &header
+		}
+		if flags.Changed("sticky") || flags.Changed("sticky-ttl") {
+			sticky := sdk.LBStickiness{Enabled: true}
+			if flags.Changed("sticky") {
+				sticky.Enabled, _ = flags.GetBool("sticky")
+			}
+			sticky.TTLSeconds, _ = flags.GetInt("sticky-ttl")
+			update.Stickiness = &sticky
+		}
+
+		client := getClient()
+		lb, err := client.UpdateLB(args[0], update)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(lb, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+		fmt.Printf("[SUCCESS] Load Balancer %s updated.\n", lb.ID)
+		fmt.Printf("Algorithm: %s\n", describeBalancing(lb))
+	},
+}
+
+// describeBalancing summarizes the algorithm and stickiness of a load balancer.
+func describeBalancing(lb *sdk.LoadBalancer) string {
+	desc := lb.Algorithm
+	if lb.HashHeader != "" {
+		desc += fmt.Sprintf(" (%s)", lb.HashHeader)
+	}
+	if lb.Stickiness.Enabled {
+		desc += fmt.Sprintf(", sticky sessions for %ds", lb.Stickiness.TTLSeconds)
+	}
+	return desc
+}
+
 var lbRmCmd = &cobra.Command{
 	Use:   "rm [id]",
 	Short: "Remove a load balancer",
@@ -95,9 +160,10 @@ var lbAddTargetCmd = &cobra.Command{
 		instID, _ := cmd.Flags().GetString("instance")
 		port, _ := cmd.Flags().GetInt("port")
 		weight, _ := cmd.Flags().GetInt("weight")
+		group, _ := cmd.Flags().GetString("group")
 
 		client := getClient()
-		if err := client.AddLBTarget(lbID, instID, port, weight); err != nil {
+		if err := client.AddLBTargetToGroup(lbID, instID, group, port, weight); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			return
 		}
@@ -114,12 +180,17 @@ var lbRemoveTargetCmd = &cobra.Command{
 		lbID := args[0]
 		instID := args[1]
 		client := getClient()
-		if err := client.RemoveLBTarget(lbID, instID); err != nil {
+		removeAt, err := client.DeregisterLBTarget(lbID, instID)
+		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			return
 		}
 
-		fmt.Printf("[SUCCESS] Target %s removed from LB %s.\n", instID, lbID)
+		if time.Until(removeAt) <= 0 {
+			fmt.Printf("[SUCCESS] Target %s removed from LB %s.\n", instID, lbID)
+			return
+		}
+		fmt.Printf("[SUCCESS] Target %s is draining; it will be removed from LB %s at %s.\n", instID, lbID, removeAt.Local().Format(time.RFC3339))
 	},
 }
 
@@ -129,20 +200,69 @@ func init() {
 	lbCreateCmd.Flags().String("vpc", "", "VPC ID")
 	lbCreateCmd.MarkFlagRequired("vpc")
 	lbCreateCmd.Flags().Int("port", 80, "Public port for the LB")
-	lbCreateCmd.Flags().String("algorithm", "round-robin", "LB algorithm (round-robin or least-conn)")
+	lbCreateCmd.Flags().String("algorithm", "round-robin", "LB algorithm (round-robin, weighted, least-conn, source-ip-hash or header-hash)")
+	lbCreateCmd.Flags().String("hash-header", "", "Request header hashed by header-hash")
+	lbCreateCmd.Flags().Bool("sticky", false, "Pin clients to a target with a session cookie")
+	lbCreateCmd.Flags().Int("sticky-ttl", 0, "Session cookie lifetime in seconds (default 1 day)")
+
+	lbUpdateCmd.Flags().String("name", "", "New name")
+	lbUpdateCmd.Flags().String("algorithm", "", "LB algorithm (round-robin, weighted, least-conn, source-ip-hash or header-hash)")
+	lbUpdateCmd.Flags().String("hash-header", "", "Request header hashed by header-hash")
+	lbUpdateCmd.Flags().Bool("sticky", false, "Enable or, with --sticky=false, disable sticky sessions")
+	lbUpdateCmd.Flags().Int("sticky-ttl", 0, "Session cookie lifetime in seconds (default 1 day)")
 
 	lbAddTargetCmd.Flags().String("instance", "", "Target instance ID")
 	lbAddTargetCmd.MarkFlagRequired("instance")
 	lbAddTargetCmd.Flags().Int("port", 80, "Port on the instance")
 	lbAddTargetCmd.MarkFlagRequired("port")
 	lbAddTargetCmd.Flags().Int("weight", 1, "Weight for the target (optional)")
+	lbAddTargetCmd.Flags().String("group", "", "Target group (default \"default\")")
+
+	lbHealthCheckCmd.Flags().String("protocol", "", "Probe protocol (http or tcp)")
+	lbHealthCheckCmd.Flags().String("path", "", "HTTP path to request")
+	lbHealthCheckCmd.Flags().String("status-codes", "", "Healthy status codes, e.g. 200-399 or 200,204")
+	lbHealthCheckCmd.Flags().Int("interval", 0, "Seconds between probes")
+	lbHealthCheckCmd.Flags().Int("timeout", 0, "Probe timeout in seconds")
+	lbHealthCheckCmd.Flags().Int("healthy-threshold", 0, "Consecutive successes to become healthy")
+	lbHealthCheckCmd.Flags().Int("unhealthy-threshold", 0, "Consecutive failures to become unhealthy")
+
+	lbMetricsCmd.Flags().String("window", "", "Window to aggregate, e.g. 15m (default 1h, max 24h)")
+
+	lbAccessLogsCmd.Flags().String("bucket", "", "Bucket to write access logs to")
+	lbAccessLogsCmd.Flags().String("prefix", "", "Key prefix for log objects")
+	lbAccessLogsCmd.Flags().Bool("disable", false, "Stop shipping access logs")
+
+	lbListenerAddCmd.Flags().Int("port", 0, "Port to listen on")
+	lbListenerAddCmd.MarkFlagRequired("port")
+	lbListenerAddCmd.Flags().String("protocol", "http", "Protocol (http, https or tcp)")
+	lbListenerAddCmd.Flags().String("cert-secret", "", "Secret holding the PEM certificate and key (https)")
+	lbListenerAddCmd.Flags().Int("redirect-port", 0, "Redirect all requests to https on this port (http)")
+	lbListenerAddCmd.Flags().String("default-group", "", "Target group for requests no rule matches")
+
+	lbRuleAddCmd.Flags().String("host", "", "Host to match, e.g. api.example.com or *.example.com")
+	lbRuleAddCmd.Flags().String("path", "", "Path prefix to match, e.g. /api/")
+	lbRuleAddCmd.Flags().String("group", "", "Target group for matching requests")
+	lbRuleAddCmd.MarkFlagRequired("group")
+
+	lbListenerCmd.AddCommand(lbListenerAddCmd)
+	lbListenerCmd.AddCommand(lbListenerListCmd)
+	lbListenerCmd.AddCommand(lbListenerRmCmd)
+	lbRuleCmd.AddCommand(lbRuleAddCmd)
+	lbRuleCmd.AddCommand(lbRuleRmCmd)
 
 	lbCmd.AddCommand(lbListCmd)
 	lbCmd.AddCommand(lbCreateCmd)
+	lbCmd.AddCommand(lbUpdateCmd)
 	lbCmd.AddCommand(lbRmCmd)
 	lbCmd.AddCommand(lbAddTargetCmd)
 	lbCmd.AddCommand(lbRemoveTargetCmd)
 	lbCmd.AddCommand(lbListTargetsCmd)
+	lbCmd.AddCommand(lbHealthCheckCmd)
+	lbCmd.AddCommand(lbDeregistrationDelayCmd)
+	lbCmd.AddCommand(lbMetricsCmd)
+	lbCmd.AddCommand(lbAccessLogsCmd)
+	lbCmd.AddCommand(lbListenerCmd)
+	lbCmd.AddCommand(lbRuleCmd)
 }
 
 var lbListTargetsCmd = &cobra.Command{
@@ -164,7 +284,7 @@ var lbListTargetsCmd = &cobra.Command{
 		}
 
 		table := tablewriter.NewWriter(os.Stdout)
-		table.Header([]string{"INSTANCE ID", "PORT", "WEIGHT", "HEALTH"})
+		table.Header([]string{"INSTANCE ID", "GROUP", "PORT", "WEIGHT", "HEALTH"})
 		for _, t := range targets {
 			id := t.InstanceID
 			if len(id) > 8 {
@@ -172,6 +292,7 @@ var lbListTargetsCmd = &cobra.Command{
 			}
 			table.Append([]string{
 				id,
+				t.TargetGroup,
 				fmt.Sprintf("%d", t.Port),
 				fmt.Sprintf("%d", t.Weight),
 				t.Health,
@@ -180,3 +301,290 @@ var lbListTargetsCmd = &cobra.Command{
 		table.Render()
 	},
 }
+
+var lbHealthCheckCmd = &cobra.Command{
+	Use:   "health-check <lb-id>",
+	Short: "Show or change how targets are health checked",
+	Long:  "Without flags, shows the health check settings. Flags change only the given settings.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		lb, err := client.GetLB(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		hc := lb.HealthCheck
+		flags := cmd.Flags()
+		if flags.NFlag() > 0 {
+			if flags.Changed("protocol") {
+				hc.Protocol, _ = flags.GetString("protocol")
+				if hc.Protocol == sdk.HealthCheckTCP {
+					hc.Path, hc.StatusCodes = "", ""
+				}
+			}
+			if flags.Changed("path") {
+				hc.Path, _ = flags.GetString("path")
+			}
+			if flags.Changed("status-codes") {
+				hc.StatusCodes, _ = flags.GetString("status-codes")
+			}
+			if flags.Changed("interval") {
+				hc.IntervalSeconds, _ = flags.GetInt("interval")
+			}
+			if flags.Changed("timeout") {
+				hc.TimeoutSeconds, _ = flags.GetInt("timeout")
+			}
+			if flags.Changed("healthy-threshold") {
+				hc.HealthyThreshold, _ = flags.GetInt("healthy-threshold")
+			}
+			if flags.Changed("unhealthy-threshold") {
+				hc.UnhealthyThreshold, _ = flags.GetInt("unhealthy-threshold")
+			}
+
+			if lb, err = client.UpdateLBHealthCheck(args[0], hc); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			hc = lb.HealthCheck
+			fmt.Printf("[SUCCESS] Health check of LB %s updated.\n", args[0])
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(hc, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+		target := hc.Protocol
+		if hc.Protocol != sdk.HealthCheckTCP {
+			target = fmt.Sprintf("%s %s (expect %s)", hc.Protocol, hc.Path, hc.StatusCodes)
+		}
+		fmt.Printf("Probe:     %s\n", target)
+		fmt.Printf("Interval:  %ds (timeout %ds)\n", hc.IntervalSeconds, hc.TimeoutSeconds)
+		fmt.Printf("Healthy:   after %d successes\n", hc.HealthyThreshold)
+		fmt.Printf("Unhealthy: after %d failures\n", hc.UnhealthyThreshold)
+	},
+}
+
+var lbDeregistrationDelayCmd = &cobra.Command{
+	Use:   "deregistration-delay <lb-id> <seconds>",
+	Short: "Set how long deregistered targets drain before removal",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		seconds, err := strconv.Atoi(args[1])
+		if err != nil {
+			fmt.Printf("Error: invalid seconds %q\n", args[1])
+			return
+		}
+
+		client := getClient()
+		lb, err := client.SetLBDeregistrationDelay(args[0], seconds)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("[SUCCESS] Deregistration delay of LB %s set to %ds.\n", lb.ID, lb.DeregistrationDelaySeconds)
+	},
+}
+
+var lbMetricsCmd = &cobra.Command{
+	Use:   "metrics <lb-id>",
+	Short: "Show request counts, error rates and latency of a load balancer",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		window, _ := cmd.Flags().GetString("window")
+		client := getClient()
+		m, err := client.GetLBMetrics(args[0], window)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(m, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		fmt.Printf("Since %s\n\n", m.Since.Local().Format(time.RFC3339))
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header([]string{"TARGET", "REQUESTS", "REQ/S", "4XX", "5XX", "AVG MS", "P90 MS", "P99 MS"})
+		row := func(name string, s sdk.LBMetricsSummary) {
+			table.Append([]string{
+				name,
+				fmt.Sprintf("%d", s.Requests),
+				fmt.Sprintf("%.2f", s.RequestsPerSecond),
+				fmt.Sprintf("%.1f%%", s.Rate4xx*100),
+				fmt.Sprintf("%.1f%%", s.Rate5xx*100),
+				fmt.Sprintf("%.1f", s.LatencyAvgMs),
+				fmt.Sprintf("%.1f", s.LatencyP90Ms),
+				fmt.Sprintf("%.1f", s.LatencyP99Ms),
+			})
+		}
+		row("(all)", m.Total)
+		for _, t := range m.Targets {
+			id := t.InstanceID
+			if len(id) > 8 {
+				id = id[:8]
+			}
+			row(id, t.LBMetricsSummary)
+		}
+		table.Render()
+	},
+}
+
+var lbAccessLogsCmd = &cobra.Command{
+	Use:   "access-logs <lb-id>",
+	Short: "Ship access logs to a bucket, or stop shipping them",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		bucket, _ := cmd.Flags().GetString("bucket")
+		prefix, _ := cmd.Flags().GetString("prefix")
+		disable, _ := cmd.Flags().GetBool("disable")
+		if !disable && bucket == "" {
+			fmt.Println("Error: --bucket or --disable is required")
+			return
+		}
+
+		client := getClient()
+		lb, err := client.SetLBAccessLogs(args[0], sdk.AccessLogConfig{Enabled: !disable, Bucket: bucket, Prefix: prefix})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if !lb.AccessLogs.Enabled {
+			fmt.Printf("[SUCCESS] Access logs of LB %s are no longer shipped.\n", lb.ID)
+			return
+		}
+		fmt.Printf("[SUCCESS] Access logs of LB %s are shipped to %s/%s%s/.\n", lb.ID, lb.AccessLogs.Bucket, lb.AccessLogs.Prefix, lb.ID)
+	},
+}
+
+var lbListenerCmd = &cobra.Command{
+	Use:   "listener",
+	Short: "Manage load balancer listeners",
+}
+
+var lbListenerAddCmd = &cobra.Command{
+	Use:   "add <lb-id>",
+	Short: "Add a listener to a load balancer",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		port, _ := cmd.Flags().GetInt("port")
+		protocol, _ := cmd.Flags().GetString("protocol")
+		certSecret, _ := cmd.Flags().GetString("cert-secret")
+		redirectPort, _ := cmd.Flags().GetInt("redirect-port")
+		group, _ := cmd.Flags().GetString("default-group")
+
+		client := getClient()
+		l, err := client.AddLBListener(args[0], sdk.LBListener{
+			Port:               port,
+			Protocol:           protocol,
+			CertificateSecret:  certSecret,
+			RedirectPort:       redirectPort,
+			DefaultTargetGroup: group,
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("[SUCCESS] Listener %s (%s on port %d) added.\n", l.ID, l.Protocol, l.Port)
+	},
+}
+
+var lbListenerListCmd = &cobra.Command{
+	Use:   "list <lb-id>",
+	Short: "List the listeners and routing rules of a load balancer",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		listeners, err := client.ListLBListeners(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(listeners, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header([]string{"LISTENER", "PORT", "PROTOCOL", "RULE", "HOST", "PATH", "GROUP"})
+		for _, l := range listeners {
+			if l.RedirectPort != 0 {
+				table.Append([]string{l.ID[:8], fmt.Sprintf("%d", l.Port), l.Protocol, "", "", "", fmt.Sprintf("-> https:%d", l.RedirectPort)})
+				continue
+			}
+			for _, r := range l.Rules {
+				table.Append([]string{l.ID[:8], fmt.Sprintf("%d", l.Port), l.Protocol, r.ID[:8], r.Host, r.PathPrefix, r.TargetGroup})
+			}
+			table.Append([]string{l.ID[:8], fmt.Sprintf("%d", l.Port), l.Protocol, "default", "", "", l.DefaultTargetGroup})
+		}
+		table.Render()
+	},
+}
+
+var lbListenerRmCmd = &cobra.Command{
+	Use:   "rm <lb-id> <listener-id>",
+	Short: "Remove a listener from a load balancer",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		if err := client.RemoveLBListener(args[0], args[1]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("[SUCCESS] Listener %s removed.\n", args[1])
+	},
+}
+
+var lbRuleCmd = &cobra.Command{
+	Use:   "rule",
+	Short: "Manage listener routing rules",
+}
+
+var lbRuleAddCmd = &cobra.Command{
+	Use:   "add <lb-id> <listener-id>",
+	Short: "Route requests matching a host or path prefix to a target group",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		host, _ := cmd.Flags().GetString("host")
+		path, _ := cmd.Flags().GetString("path")
+		group, _ := cmd.Flags().GetString("group")
+
+		client := getClient()
+		r, err := client.AddLBRoutingRule(args[0], args[1], sdk.LBRoutingRule{
+			Host:        host,
+			PathPrefix:  path,
+			TargetGroup: group,
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("[SUCCESS] Rule %s added.\n", r.ID)
+	},
+}
+
+var lbRuleRmCmd = &cobra.Command{
+	Use:   "rm <lb-id> <listener-id> <rule-id>",
+	Short: "Remove a routing rule",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		if err := client.RemoveLBRoutingRule(args[0], args[1], args[2]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("[SUCCESS] Rule %s removed.\n", args[2])
+	},
+}
@@ -34,6 +34,7 @@ func init() {
 	rootCmd.AddCommand(secretsCmd)
 	rootCmd.AddCommand(fnCmd)
 	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(metricsCmd)
 }
 
 func main() {
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/poyrazk/thecloud/pkg/sdk"
+	"github.com/spf13/cobra"
+)
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Manage custom application metrics",
+}
+
+var metricsPutCmd = &cobra.Command{
+	Use:   "put <name> <value>",
+	Short: "Push a data point of a custom metric",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		value, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			fmt.Printf("Error: invalid value %q\n", args[1])
+			os.Exit(1)
+		}
+		instanceID, _ := cmd.Flags().GetString("instance")
+
+		client := getClient()
+		metric := sdk.CustomMetric{Name: args[0], Value: value, InstanceID: instanceID}
+		if err := client.PutCustomMetrics([]sdk.CustomMetric{metric}); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("[SUCCESS] Metric %s recorded\n", args[0])
+	},
+}
+
+func init() {
+	metricsPutCmd.Flags().String("instance", "", "Instance ID the data point belongs to (Optional)")
+
+	metricsCmd.AddCommand(metricsPutCmd)
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/olekukonko/tablewriter"
+	"github.com/poyrazk/thecloud/pkg/sdk"
 	"github.com/spf13/cobra"
 )
 
@@ -69,13 +71,60 @@ var storageUploadCmd = &cobra.Command{
 		}
 		defer f.Close()
 
+		info, err := f.Stat()
+		if err != nil {
+			fmt.Printf("Error reading file info: %v\n", err)
+			return
+		}
+
+		partSizeMB, _ := cmd.Flags().GetInt64("part-size")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		resumeID, _ := cmd.Flags().GetString("resume")
+		contentType, _ := cmd.Flags().GetString("content-type")
+		metadata, err := readMetadata(cmd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		encrypt, _ := cmd.Flags().GetBool("encrypt")
+		partSize := partSizeMB * 1024 * 1024
+		objOpts := sdk.ObjectOptions{ContentType: contentType, Metadata: metadata}
+		if encrypt {
+			objOpts.Encryption = sdk.EncryptionAES256
+		}
+		if objOpts.CustomerKey, err = readCustomerKey(cmd); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
 		client := getClient()
-		if err := client.UploadObject(bucket, key, f); err != nil {
+
+		// Large files (or resumed uploads) go through multipart upload with parallel parts
+		if info.Size() > partSize || resumeID != "" {
+			_, uploadID, err := client.UploadObjectMultipart(bucket, key, f, info.Size(), sdk.MultipartOptions{
+				ObjectOptions: objOpts,
+				PartSize:      partSize,
+				Concurrency:   concurrency,
+				UploadID:      resumeID,
+			})
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				if uploadID != "" {
+					fmt.Printf("Resume with: cloud storage upload %s %s --key %s --resume %s\n", bucket, filePath, key, uploadID)
+				}
+				return
+			}
+			fmt.Printf("[SUCCESS] Uploaded %s to bucket %s (multipart)\n", key, bucket)
+			return
+		}
+
+		obj, err := client.UploadObjectWithOptions(bucket, key, f, objOpts)
+		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			return
 		}
 
-		fmt.Printf("[SUCCESS] Uploaded %s to bucket %s\n", key, bucket)
+		fmt.Printf("[SUCCESS] Uploaded %s to bucket %s (%s, etag %s)\n", key, bucket, obj.ContentType, obj.ETag)
 	},
 }
 
@@ -88,8 +137,14 @@ var storageDownloadCmd = &cobra.Command{
 		key := args[1]
 		dest := args[2]
 
+		customerKey, err := readCustomerKey(cmd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
 		client := getClient()
-		body, err := client.DownloadObject(bucket, key)
+		body, err := client.DownloadObjectWithOptions(bucket, key, sdk.ReadOptions{CustomerKey: customerKey})
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			return
@@ -131,11 +186,411 @@ var storageDeleteCmd = &cobra.Command{
 	},
 }
 
+var storagePresignCmd = &cobra.Command{
+	Use:   "presign [bucket] [key]",
+	Short: "Create a temporary URL for an object",
+	Long:  "Create a pre-signed URL that allows downloading (GET) or uploading (PUT) an object without an API key.",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		bucket := args[0]
+		key := args[1]
+
+		method, _ := cmd.Flags().GetString("method")
+		expires, _ := cmd.Flags().GetDuration("expires")
+		maxSize, _ := cmd.Flags().GetInt64("max-size")
+
+		client := getClient()
+		p, err := client.PresignURL(bucket, key, sdk.PresignOptions{
+			Method:           method,
+			ExpiresIn:        expires,
+			MaxContentLength: maxSize,
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(p, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		fmt.Println(p.URL)
+		fmt.Printf("Method: %s, expires at %s\n", p.Method, p.ExpiresAt.Format(time.RFC3339))
+	},
+}
+
+var storageLifecycleCmd = &cobra.Command{
+	Use:   "lifecycle",
+	Short: "Manage bucket lifecycle rules",
+}
+
+var storageLifecycleAddCmd = &cobra.Command{
+	Use:   "add [bucket]",
+	Short: "Add a lifecycle rule to a bucket",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		prefix, _ := cmd.Flags().GetString("prefix")
+		expire, _ := cmd.Flags().GetInt("expire-days")
+		retain, _ := cmd.Flags().GetInt("deleted-retention-days")
+		abort, _ := cmd.Flags().GetInt("abort-upload-days")
+
+		client := getClient()
+		rule, err := client.CreateLifecycleRule(args[0], sdk.LifecycleRule{
+			Prefix:                    prefix,
+			ExpirationDays:            expire,
+			DeletedRetentionDays:      retain,
+			AbortIncompleteUploadDays: abort,
+			Enabled:                   true,
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("[SUCCESS] Lifecycle rule %s added to bucket %s\n", rule.ID, args[0])
+	},
+}
+
+var storageLifecycleListCmd = &cobra.Command{
+	Use:   "list [bucket]",
+	Short: "List the lifecycle rules of a bucket",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		rules, err := client.ListLifecycleRules(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(rules, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header([]string{"ID", "PREFIX", "EXPIRE DAYS", "DELETED RETENTION", "ABORT UPLOADS", "ENABLED"})
+		for _, r := range rules {
+			table.Append([]string{
+				r.ID,
+				r.Prefix,
+				fmt.Sprintf("%d", r.ExpirationDays),
+				fmt.Sprintf("%d", r.DeletedRetentionDays),
+				fmt.Sprintf("%d", r.AbortIncompleteUploadDays),
+				fmt.Sprintf("%t", r.Enabled),
+			})
+		}
+		table.Render()
+	},
+}
+
+var storageLifecycleRmCmd = &cobra.Command{
+	Use:   "rm [bucket] [rule-id]",
+	Short: "Remove a lifecycle rule",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		if err := client.DeleteLifecycleRule(args[0], args[1]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Println("[SUCCESS] Lifecycle rule removed")
+	},
+}
+
+// readMetadata parses repeated --meta key=value flags, splitting each on its
+// first '=' so values may contain commas and equals signs.
+func readMetadata(cmd *cobra.Command) (map[string]string, error) {
+	pairs, _ := cmd.Flags().GetStringArray("meta")
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	metadata := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid metadata %q, expected key=value", pair)
+		}
+		metadata[key] = value
+	}
+	return metadata, nil
+}
+
+// readCustomerKey loads the 32-byte key named by --sse-key-file, if any.
+func readCustomerKey(cmd *cobra.Command) ([]byte, error) {
+	path, _ := cmd.Flags().GetString("sse-key-file")
+	if path == "" {
+		return nil, nil
+	}
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key file must contain exactly 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+var storageEncryptionCmd = &cobra.Command{
+	Use:   "encryption",
+	Short: "Manage bucket default encryption",
+}
+
+var storageEncryptionEnableCmd = &cobra.Command{
+	Use:   "enable [bucket]",
+	Short: "Encrypt new objects of a bucket with server-managed keys",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		if _, err := client.PutBucketEncryption(args[0], sdk.EncryptionAES256); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("[SUCCESS] Default encryption enabled for bucket %s\n", args[0])
+	},
+}
+
+var storageEncryptionGetCmd = &cobra.Command{
+	Use:   "get [bucket]",
+	Short: "Show the default encryption of a bucket",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		cfg, err := client.GetBucketEncryption(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("Bucket %s: %s\n", cfg.Bucket, cfg.Algorithm)
+	},
+}
+
+var storageEncryptionDisableCmd = &cobra.Command{
+	Use:   "disable [bucket]",
+	Short: "Stop encrypting new objects of a bucket by default",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		if err := client.DeleteBucketEncryption(args[0]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("[SUCCESS] Default encryption disabled for bucket %s\n", args[0])
+	},
+}
+
+var storageNotifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Manage bucket event notifications",
+}
+
+var storageNotifyAddCmd = &cobra.Command{
+	Use:   "add [bucket]",
+	Short: "Send object events of a bucket to a function or a webhook",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		events, _ := cmd.Flags().GetStringSlice("events")
+		prefix, _ := cmd.Flags().GetString("prefix")
+		suffix, _ := cmd.Flags().GetString("suffix")
+		function, _ := cmd.Flags().GetString("function")
+		webhook, _ := cmd.Flags().GetString("webhook")
+
+		client := getClient()
+		n, err := client.CreateBucketNotification(args[0], sdk.BucketNotification{
+			Events:     events,
+			Prefix:     prefix,
+			Suffix:     suffix,
+			FunctionID: function,
+			WebhookURL: webhook,
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("[SUCCESS] Notification %s added to bucket %s\n", n.ID, args[0])
+		if n.Secret != "" {
+			fmt.Printf("Webhook signing secret: %s\n", n.Secret)
+		}
+	},
+}
+
+var storageNotifyListCmd = &cobra.Command{
+	Use:   "list [bucket]",
+	Short: "List the event notifications of a bucket",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		notifications, err := client.ListBucketNotifications(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(notifications, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header([]string{"ID", "EVENTS", "PREFIX", "SUFFIX", "TARGET"})
+		for _, n := range notifications {
+			events := strings.Join(n.Events, ",")
+			if events == "" {
+				events = "*"
+			}
+			target := n.WebhookURL
+			if n.FunctionID != "" {
+				target = "function:" + n.FunctionID
+			}
+			table.Append([]string{n.ID, events, n.Prefix, n.Suffix, target})
+		}
+		table.Render()
+	},
+}
+
+var storageNotifyRmCmd = &cobra.Command{
+	Use:   "rm [bucket] [notification-id]",
+	Short: "Remove an event notification",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		if err := client.DeleteBucketNotification(args[0], args[1]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Println("[SUCCESS] Notification removed")
+	},
+}
+
+var storageWebsiteCmd = &cobra.Command{
+	Use:   "website",
+	Short: "Manage static website hosting for buckets",
+}
+
+var storageWebsiteEnableCmd = &cobra.Command{
+	Use:   "enable [bucket]",
+	Short: "Serve a bucket as a public static website",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		index, _ := cmd.Flags().GetString("index")
+		errorDoc, _ := cmd.Flags().GetString("error")
+		maxAge, _ := cmd.Flags().GetInt("cache-max-age")
+
+		client := getClient()
+		site, err := client.PutBucketWebsite(args[0], sdk.BucketWebsite{
+			IndexDocument: index,
+			ErrorDocument: errorDoc,
+			CacheMaxAge:   maxAge,
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("[SUCCESS] Bucket %s is served as a website\n", site.Bucket)
+		fmt.Printf("URL: %s/website/%s/\n", apiURL, site.Bucket)
+	},
+}
+
+var storageWebsiteGetCmd = &cobra.Command{
+	Use:   "get [bucket]",
+	Short: "Show the website configuration of a bucket",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		site, err := client.GetBucketWebsite(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if outputJSON {
+			data, _ := json.MarshalIndent(site, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		fmt.Printf("Bucket:         %s\n", site.Bucket)
+		fmt.Printf("Index document: %s\n", site.IndexDocument)
+		fmt.Printf("Error document: %s\n", site.ErrorDocument)
+		fmt.Printf("Cache max-age:  %ds\n", site.CacheMaxAge)
+	},
+}
+
+var storageWebsiteDisableCmd = &cobra.Command{
+	Use:   "disable [bucket]",
+	Short: "Stop serving a bucket as a website",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := getClient()
+		if err := client.DeleteBucketWebsite(args[0]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("[SUCCESS] Website disabled for bucket %s\n", args[0])
+	},
+}
+
 func init() {
 	storageCmd.AddCommand(storageListCmd)
 	storageCmd.AddCommand(storageUploadCmd)
 	storageCmd.AddCommand(storageDownloadCmd)
 	storageCmd.AddCommand(storageDeleteCmd)
+	storageCmd.AddCommand(storagePresignCmd)
+	storageCmd.AddCommand(storageLifecycleCmd)
+	storageCmd.AddCommand(storageEncryptionCmd)
+	storageCmd.AddCommand(storageNotifyCmd)
+	storageCmd.AddCommand(storageWebsiteCmd)
+
+	storageWebsiteCmd.AddCommand(storageWebsiteEnableCmd)
+	storageWebsiteCmd.AddCommand(storageWebsiteGetCmd)
+	storageWebsiteCmd.AddCommand(storageWebsiteDisableCmd)
+
+	storageWebsiteEnableCmd.Flags().String("index", "index.html", "Document served for / and paths ending in /")
+	storageWebsiteEnableCmd.Flags().String("error", "", "Object served with status 404 for missing paths")
+	storageWebsiteEnableCmd.Flags().Int("cache-max-age", 300, "Cache-Control max-age in seconds")
+
+	storageNotifyCmd.AddCommand(storageNotifyAddCmd)
+	storageNotifyCmd.AddCommand(storageNotifyListCmd)
+	storageNotifyCmd.AddCommand(storageNotifyRmCmd)
+
+	storageNotifyAddCmd.Flags().StringSlice("events", nil, "Event types to send: ObjectCreated, ObjectDeleted (default all)")
+	storageNotifyAddCmd.Flags().String("prefix", "", "Only send events for keys with this prefix")
+	storageNotifyAddCmd.Flags().String("suffix", "", "Only send events for keys with this suffix")
+	storageNotifyAddCmd.Flags().String("function", "", "ID of the function to invoke")
+	storageNotifyAddCmd.Flags().String("webhook", "", "URL to POST events to")
+
+	storageEncryptionCmd.AddCommand(storageEncryptionEnableCmd)
+	storageEncryptionCmd.AddCommand(storageEncryptionGetCmd)
+	storageEncryptionCmd.AddCommand(storageEncryptionDisableCmd)
+
+	storageLifecycleCmd.AddCommand(storageLifecycleAddCmd)
+	storageLifecycleCmd.AddCommand(storageLifecycleListCmd)
+	storageLifecycleCmd.AddCommand(storageLifecycleRmCmd)
+
+	storageLifecycleAddCmd.Flags().String("prefix", "", "Only apply to keys with this prefix")
+	storageLifecycleAddCmd.Flags().Int("expire-days", 0, "Delete objects this many days after upload")
+	storageLifecycleAddCmd.Flags().Int("deleted-retention-days", 0, "Purge deleted objects after this many days (default 30)")
+	storageLifecycleAddCmd.Flags().Int("abort-upload-days", 0, "Abort incomplete multipart uploads after this many days (default 1)")
 
 	storageUploadCmd.Flags().String("key", "", "Custom key for the object")
+	storageUploadCmd.Flags().Int64("part-size", 16, "Part size in MB; larger files use multipart upload")
+	storageUploadCmd.Flags().Int("concurrency", sdk.DefaultUploadConcurrency, "Number of parts uploaded in parallel")
+	storageUploadCmd.Flags().String("resume", "", "Resume an interrupted multipart upload by ID")
+	storageUploadCmd.Flags().String("content-type", "", "Content type of the object (detected when omitted)")
+	storageUploadCmd.Flags().StringArray("meta", nil, "User metadata as key=value (repeatable)")
+	storageUploadCmd.Flags().Bool("encrypt", false, "Encrypt the object with server-managed keys")
+	storageUploadCmd.Flags().String("sse-key-file", "", "Encrypt the object with the 32-byte key in this file")
+
+	storageDownloadCmd.Flags().String("sse-key-file", "", "Key file the object was encrypted with")
+
+	storagePresignCmd.Flags().String("method", "GET", "HTTP method the URL is valid for (GET or PUT)")
+	storagePresignCmd.Flags().Duration("expires", 15*time.Minute, "How long the URL stays valid (max 168h)")
+	storagePresignCmd.Flags().Int64("max-size", 0, "Maximum upload size in bytes for PUT URLs (0 = no limit)")
 }
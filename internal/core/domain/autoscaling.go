@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,6 +12,7 @@ const (
 	MaxInstancesHardLimit  = 20 // Maximum instances per scaling group to prevent resource exhaustion
 	MaxScalingGroupsPerVPC = 5  // Maximum scaling groups per VPC
 	MinCooldownSeconds     = 60 // Minimum cooldown to prevent rapid thrashing
+	MaxScheduledActions    = 20 // Maximum scheduled actions per scaling group
 )
 
 type ScalingGroupStatus string
@@ -23,40 +25,376 @@ const (
 )
 
 type ScalingGroup struct {
-	ID             uuid.UUID          `json:"id"`
-	UserID         uuid.UUID          `json:"user_id"`
-	IdempotencyKey string             `json:"idempotency_key,omitempty"`
-	Name           string             `json:"name"`
-	VpcID          uuid.UUID          `json:"vpc_id"`
-	LoadBalancerID *uuid.UUID         `json:"load_balancer_id,omitempty"`
-	Image          string             `json:"image"`
-	Ports          string             `json:"ports,omitempty"`
-	MinInstances   int                `json:"min_instances"`
-	MaxInstances   int                `json:"max_instances"`
-	DesiredCount   int                `json:"desired_count"`
-	CurrentCount   int                `json:"current_count"`
-	Status         ScalingGroupStatus `json:"status"`
-	FailureCount   int                `json:"failure_count"`
-	LastFailureAt  *time.Time         `json:"last_failure_at,omitempty"`
-	Version        int                `json:"version"`
-	CreatedAt      time.Time          `json:"created_at"`
-	UpdatedAt      time.Time          `json:"updated_at"`
+	ID             uuid.UUID  `json:"id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	IdempotencyKey string     `json:"idempotency_key,omitempty"`
+	Name           string     `json:"name"`
+	VpcID          uuid.UUID  `json:"vpc_id"`
+	LoadBalancerID *uuid.UUID `json:"load_balancer_id,omitempty"`
+	// Image and Ports are those of the launch template version when the
+	// group has one.
+	Image string `json:"image"`
+	Ports string `json:"ports,omitempty"`
+	// LaunchTemplateID and LaunchTemplateVersion pin the template new
+	// instances are launched from. Groups created from an image have none.
+	LaunchTemplateID      *uuid.UUID         `json:"launch_template_id,omitempty"`
+	LaunchTemplateVersion int                `json:"launch_template_version,omitempty"`
+	MinInstances          int                `json:"min_instances"`
+	MaxInstances          int                `json:"max_instances"`
+	DesiredCount          int                `json:"desired_count"`
+	CurrentCount          int                `json:"current_count"`
+	Status                ScalingGroupStatus `json:"status"`
+	FailureCount          int                `json:"failure_count"`
+	LastFailureAt         *time.Time         `json:"last_failure_at,omitempty"`
+	// HealthCheckType decides when an instance is unhealthy and replaced.
+	// Instances are not checked during the grace period after launch.
+	HealthCheckType           string `json:"health_check_type"`
+	HealthCheckGracePeriodSec int    `json:"health_check_grace_period_sec"`
+	// SuspendedProcesses are the worker behaviours paused for the group.
+	SuspendedProcesses []ScalingProcess `json:"suspended_processes,omitempty"`
+	Version            int              `json:"version"`
+	CreatedAt          time.Time        `json:"created_at"`
+	UpdatedAt          time.Time        `json:"updated_at"`
 }
 
+// ScalingProcess is a behaviour of the auto-scaling worker that can be
+// suspended for a group, for instance while investigating an incident.
+type ScalingProcess string
+
+const (
+	// ScalingProcessLaunch launches instances up to the desired count.
+	ScalingProcessLaunch ScalingProcess = "LAUNCH"
+	// ScalingProcessTerminate terminates instances above the desired count.
+	ScalingProcessTerminate ScalingProcess = "TERMINATE"
+	// ScalingProcessReplaceUnhealthy replaces instances failing their health
+	// checks.
+	ScalingProcessReplaceUnhealthy ScalingProcess = "REPLACE_UNHEALTHY"
+	// ScalingProcessScheduledActions runs the scheduled actions that are due.
+	ScalingProcessScheduledActions ScalingProcess = "SCHEDULED_ACTIONS"
+)
+
+// ScalingProcesses lists the processes that can be suspended.
+var ScalingProcesses = []ScalingProcess{
+	ScalingProcessLaunch,
+	ScalingProcessTerminate,
+	ScalingProcessReplaceUnhealthy,
+	ScalingProcessScheduledActions,
+}
+
+// IsSuspended reports whether the process is suspended for the group.
+func (g *ScalingGroup) IsSuspended(process ScalingProcess) bool {
+	return slices.Contains(g.SuspendedProcesses, process)
+}
+
+// Health check types of scaling groups.
+const (
+	// ScalingHealthCheckContainer treats instances whose container is no
+	// longer running as unhealthy.
+	ScalingHealthCheckContainer = "container"
+	// ScalingHealthCheckLB also treats instances the group's load balancer
+	// reports unhealthy as unhealthy.
+	ScalingHealthCheckLB = "lb"
+)
+
+// Defaults and bounds of the health check grace period.
+const (
+	DefaultHealthCheckGracePeriodSec = 300
+	MaxHealthCheckGracePeriodSec     = 7200
+)
+
+// Metrics a scaling policy can track. Each is averaged over the instances of
+// the group.
+const (
+	// ScalingMetricCPU is the CPU usage in percent of one core.
+	ScalingMetricCPU = "cpu"
+	// ScalingMetricMemory is the memory usage in percent of the limit.
+	ScalingMetricMemory = "memory"
+	// ScalingMetricNetworkIn and ScalingMetricNetworkOut are the network
+	// throughput in bytes per second.
+	ScalingMetricNetworkIn  = "network_in"
+	ScalingMetricNetworkOut = "network_out"
+	// ScalingMetricLBRequestCount is the number of requests per minute the
+	// group's load balancer sends to each instance.
+	ScalingMetricLBRequestCount = "lb_request_count"
+	// ScalingMetricCustom is an application metric pushed through the custom
+	// metrics API, named by the policy's MetricName.
+	ScalingMetricCustom = "custom"
+)
+
+// Kinds of scaling policies.
+const (
+	// ScalingPolicyTargetTracking sizes the group so that the metric stays at
+	// the target: the desired count grows and shrinks in proportion to
+	// metric/target.
+	ScalingPolicyTargetTracking = "target_tracking"
+	// ScalingPolicyStep adds or removes the instances of the band the metric
+	// falls in.
+	ScalingPolicyStep = "step"
+)
+
+// Defaults and bounds of scaling policy settings.
+const (
+	DefaultPolicyCooldownSeconds    = 300
+	DefaultEvaluationPeriodSeconds  = 60
+	MaxEvaluationPeriodSeconds      = 3600
+	MaxScalingSteps                 = 10
+	DefaultScaleInThresholdOfTarget = 0.9
+)
+
 type ScalingPolicy struct {
-	ID             uuid.UUID  `json:"id"`
-	ScalingGroupID uuid.UUID  `json:"scaling_group_id"`
-	Name           string     `json:"name"`
-	MetricType     string     `json:"metric_type"` // "cpu" | "memory"
-	TargetValue    float64    `json:"target_value"`
-	ScaleOutStep   int        `json:"scale_out_step"`
-	ScaleInStep    int        `json:"scale_in_step"`
-	CooldownSec    int        `json:"cooldown_sec"`
-	LastScaledAt   *time.Time `json:"last_scaled_at,omitempty"`
+	ID             uuid.UUID `json:"id"`
+	ScalingGroupID uuid.UUID `json:"scaling_group_id"`
+	Name           string    `json:"name"`
+	PolicyType     string    `json:"policy_type"`
+	MetricType     string    `json:"metric_type"`
+	// MetricName names the custom metric of a "custom" policy.
+	MetricName string `json:"metric_name,omitempty"`
+	// TargetValue and ScaleInThreshold apply to target tracking: the group
+	// scales in only once the metric drops below the threshold, which keeps
+	// it from flapping around the target.
+	TargetValue      float64 `json:"target_value,omitempty"`
+	ScaleInThreshold float64 `json:"scale_in_threshold,omitempty"`
+	// Steps are the bands of a step policy.
+	Steps []ScalingStep `json:"steps,omitempty"`
+	// EvaluationPeriodSec is how far back the metric is averaged.
+	EvaluationPeriodSec int `json:"evaluation_period_sec"`
+	// ScaleOutCooldownSec is the wait between scale-outs. ScaleInCooldownSec
+	// is the wait after any scaling action before scaling in.
+	ScaleOutCooldownSec int        `json:"scale_out_cooldown_sec"`
+	ScaleInCooldownSec  int        `json:"scale_in_cooldown_sec"`
+	LastScaledAt        *time.Time `json:"last_scaled_at,omitempty"`
+	LastScaleOutAt      *time.Time `json:"last_scale_out_at,omitempty"`
+}
+
+// ScalingStep is a band of a step policy: while the metric is at least
+// LowerBound and below UpperBound, the group changes by Adjustment instances,
+// positive to scale out and negative to scale in. A missing bound leaves the
+// band open on that side.
+type ScalingStep struct {
+	LowerBound *float64 `json:"lower_bound,omitempty"`
+	UpperBound *float64 `json:"upper_bound,omitempty"`
+	Adjustment int      `json:"adjustment"`
+}
+
+// Contains reports whether the metric value falls in the band.
+func (s ScalingStep) Contains(value float64) bool {
+	return (s.LowerBound == nil || value >= *s.LowerBound) && (s.UpperBound == nil || value < *s.UpperBound)
+}
+
+// ScheduledAction resizes a group on a cron schedule, for load that follows
+// the clock. Sizes left unset keep their current value.
+type ScheduledAction struct {
+	ID             uuid.UUID `json:"id"`
+	ScalingGroupID uuid.UUID `json:"scaling_group_id"`
+	Name           string    `json:"name"`
+	// Schedule is a five-field cron expression evaluated in TimeZone, an
+	// IANA time zone name.
+	Schedule     string     `json:"schedule"`
+	TimeZone     string     `json:"time_zone"`
+	MinInstances *int       `json:"min_instances,omitempty"`
+	MaxInstances *int       `json:"max_instances,omitempty"`
+	DesiredCount *int       `json:"desired_count,omitempty"`
+	NextRunAt    time.Time  `json:"next_run_at"`
+	LastRunAt    *time.Time `json:"last_run_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
 }
 
 type ScalingGroupInstance struct {
 	ScalingGroupID uuid.UUID `json:"scaling_group_id"`
 	InstanceID     uuid.UUID `json:"instance_id"`
 	JoinedAt       time.Time `json:"joined_at"`
+	// DrainUntil is set on instances being scaled in: they no longer count
+	// towards the group and are terminated once their load balancer target
+	// has drained.
+	DrainUntil *time.Time `json:"drain_until,omitempty"`
+	// LifecycleState is InService unless the instance awaits a lifecycle
+	// action, until LifecycleTimeoutAt at the latest.
+	LifecycleState     LifecycleState `json:"lifecycle_state"`
+	LifecycleTimeoutAt *time.Time     `json:"lifecycle_timeout_at,omitempty"`
+	// LifecycleResult is set once the lifecycle action is completed; the
+	// worker acts on it on its next tick.
+	LifecycleResult LifecycleActionResult `json:"lifecycle_result,omitempty"`
+}
+
+// LaunchConfig is what the instances of a group are launched from: a launch
+// template version, or an image and ports.
+type LaunchConfig struct {
+	LaunchTemplateID      *uuid.UUID `json:"launch_template_id,omitempty"`
+	LaunchTemplateVersion int        `json:"launch_template_version,omitempty"`
+	Image                 string     `json:"image"`
+	Ports                 string     `json:"ports,omitempty"`
+}
+
+// LaunchConfig returns the launch configuration of the group.
+func (g *ScalingGroup) LaunchConfig() LaunchConfig {
+	return LaunchConfig{
+		LaunchTemplateID:      g.LaunchTemplateID,
+		LaunchTemplateVersion: g.LaunchTemplateVersion,
+		Image:                 g.Image,
+		Ports:                 g.Ports,
+	}
+}
+
+// SetLaunchConfig replaces the launch configuration of the group.
+func (g *ScalingGroup) SetLaunchConfig(cfg LaunchConfig) {
+	g.LaunchTemplateID = cfg.LaunchTemplateID
+	g.LaunchTemplateVersion = cfg.LaunchTemplateVersion
+	g.Image = cfg.Image
+	g.Ports = cfg.Ports
+}
+
+type InstanceRefreshStatus string
+
+const (
+	InstanceRefreshInProgress  InstanceRefreshStatus = "IN_PROGRESS"
+	InstanceRefreshPaused      InstanceRefreshStatus = "PAUSED"
+	InstanceRefreshRollingBack InstanceRefreshStatus = "ROLLING_BACK"
+	InstanceRefreshSuccessful  InstanceRefreshStatus = "SUCCESSFUL"
+	InstanceRefreshCancelled   InstanceRefreshStatus = "CANCELLED"
+	InstanceRefreshFailed      InstanceRefreshStatus = "FAILED"
+	InstanceRefreshRolledBack  InstanceRefreshStatus = "ROLLED_BACK"
+)
+
+// Ended reports whether the refresh has stopped for good.
+func (s InstanceRefreshStatus) Ended() bool {
+	switch s {
+	case InstanceRefreshInProgress, InstanceRefreshPaused, InstanceRefreshRollingBack:
+		return false
+	}
+	return true
+}
+
+// Defaults and bounds of instance refresh settings.
+const (
+	DefaultMinHealthyPercentage         = 90
+	DefaultRefreshHealthCheckTimeoutSec = 600
+	MinRefreshHealthCheckTimeoutSec     = 60
+	MaxRefreshHealthCheckTimeoutSec     = 3600
+)
+
+// InstanceRefresh replaces the instances of a group in batches, so that they
+// run its current launch configuration. Each batch takes instances out of
+// service and waits for their replacements to turn healthy before the next.
+type InstanceRefresh struct {
+	ID             uuid.UUID             `json:"id"`
+	ScalingGroupID uuid.UUID             `json:"scaling_group_id"`
+	Status         InstanceRefreshStatus `json:"status"`
+	StatusReason   string                `json:"status_reason,omitempty"`
+	// MinHealthyPercentage is the share of the desired count kept in
+	// service while a batch is replaced. When it leaves no instance to take
+	// out of service, as at 100, each replacement is launched above the
+	// desired count and turns healthy before the instance it replaces is
+	// terminated.
+	MinHealthyPercentage int `json:"min_healthy_percentage"`
+	// HealthCheckTimeoutSec is how long the replacements of a batch have to
+	// turn healthy before the refresh fails.
+	HealthCheckTimeoutSec int `json:"health_check_timeout_sec"`
+	InstancesToReplace    int `json:"instances_to_replace"`
+	InstancesReplaced     int `json:"instances_replaced"`
+	// PendingInstanceIDs are the instances still to be replaced.
+	PendingInstanceIDs []uuid.UUID `json:"pending_instance_ids"`
+	// BatchStartedAt is set while the replacements of a batch are awaited.
+	BatchStartedAt *time.Time `json:"batch_started_at,omitempty"`
+	// RollbackConfig is the launch configuration of the group before the
+	// refresh. Only refreshes started with a new configuration have one and
+	// can roll back when they fail.
+	RollbackConfig *LaunchConfig `json:"rollback_config,omitempty"`
+	StartedAt      time.Time     `json:"started_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	EndedAt        *time.Time    `json:"ended_at,omitempty"`
+}
+
+// BatchSize returns how many instances the next batch replaces, keeping at
+// least MinHealthyPercentage of the desired count in service. It is zero
+// when no instance may leave service first.
+func (r *InstanceRefresh) BatchSize(desired int) int {
+	keep := (desired*r.MinHealthyPercentage + 99) / 100
+	n := desired - keep
+	if n < 0 {
+		n = 0
+	}
+	if n > len(r.PendingInstanceIDs) {
+		n = len(r.PendingInstanceIDs)
+	}
+	return n
+}
+
+// LaunchesFirst reports whether the replacements must be launched above the
+// desired count before the instances they replace leave service.
+func (r *InstanceRefresh) LaunchesFirst(desired int) bool {
+	return len(r.PendingInstanceIDs) > 0 && r.BatchSize(desired) == 0
+}
+
+// LifecycleTransition is the point in an instance's life a hook pauses at.
+type LifecycleTransition string
+
+const (
+	LifecycleTransitionLaunching   LifecycleTransition = "LAUNCHING"
+	LifecycleTransitionTerminating LifecycleTransition = "TERMINATING"
+)
+
+// LifecycleState is where an instance of a group is in its lifecycle.
+type LifecycleState string
+
+const (
+	LifecycleStateInService       LifecycleState = "InService"
+	LifecycleStatePendingWait     LifecycleState = "Pending:Wait"
+	LifecycleStateTerminatingWait LifecycleState = "Terminating:Wait"
+)
+
+// LifecycleActionResult decides what happens to a waiting instance. Launches
+// that are abandoned are terminated; terminations go ahead either way.
+type LifecycleActionResult string
+
+const (
+	LifecycleActionContinue LifecycleActionResult = "CONTINUE"
+	LifecycleActionAbandon  LifecycleActionResult = "ABANDON"
+)
+
+// Defaults and bounds of lifecycle hook timeouts.
+const (
+	DefaultLifecycleHeartbeatTimeoutSec = 3600
+	MinLifecycleHeartbeatTimeoutSec     = 30
+	MaxLifecycleHeartbeatTimeoutSec     = 7200
+)
+
+// LifecycleHook holds instances of a group in a wait state when they launch
+// or terminate, for example to warm caches or flush queues, until the
+// lifecycle action is completed or HeartbeatTimeoutSec passes.
+type LifecycleHook struct {
+	ID                  uuid.UUID           `json:"id"`
+	ScalingGroupID      uuid.UUID           `json:"scaling_group_id"`
+	Name                string              `json:"name"`
+	Transition          LifecycleTransition `json:"transition"`
+	HeartbeatTimeoutSec int                 `json:"heartbeat_timeout_sec"`
+	// DefaultResult applies when the action is not completed in time.
+	DefaultResult LifecycleActionResult `json:"default_result"`
+	CreatedAt     time.Time             `json:"created_at"`
+}
+
+type ScalingActivityStatus string
+
+const (
+	ScalingActivitySuccessful ScalingActivityStatus = "SUCCESSFUL"
+	ScalingActivityFailed     ScalingActivityStatus = "FAILED"
+)
+
+// ScalingActivityRetention is how long scaling activities are kept.
+const ScalingActivityRetention = 42 * 24 * time.Hour
+
+// ScalingActivity records a change to the capacity of a group: a new desired
+// count, or instances launched or terminated to reach it.
+type ScalingActivity struct {
+	ID             uuid.UUID `json:"id"`
+	ScalingGroupID uuid.UUID `json:"scaling_group_id"`
+	Description    string    `json:"description"`
+	// Cause is why the activity happened, such as the policy or scheduled
+	// action behind it.
+	Cause         string                `json:"cause"`
+	FromCapacity  int                   `json:"from_capacity"`
+	ToCapacity    int                   `json:"to_capacity"`
+	Status        ScalingActivityStatus `json:"status"`
+	StatusMessage string                `json:"status_message,omitempty"`
+	StartedAt     time.Time             `json:"started_at"`
+	EndedAt       time.Time             `json:"ended_at"`
 }
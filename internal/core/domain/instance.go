@@ -31,9 +31,14 @@ type Instance struct {
 	Status      InstanceStatus `json:"status"`
 	Ports       string         `json:"ports,omitempty"`
 	VpcID       *uuid.UUID     `json:"vpc_id,omitempty"`
-	Version     int            `json:"version"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
+	// LaunchTemplateID and LaunchTemplateVersion record the template the
+	// instance was launched from, if any.
+	LaunchTemplateID      *uuid.UUID `json:"launch_template_id,omitempty"`
+	LaunchTemplateVersion int        `json:"launch_template_version,omitempty"`
+	InstanceType          string     `json:"instance_type,omitempty"`
+	Version               int        `json:"version"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
 }
 
 type InstanceStats struct {
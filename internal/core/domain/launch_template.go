@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Limits of launch templates.
+const (
+	MaxLaunchTemplateVersions = 100
+	MaxLaunchTemplateEnvVars  = 50
+	MaxUserDataBytes          = 16 * 1024
+)
+
+// LaunchTemplate is a named, versioned instance configuration. Versions are
+// immutable: changing the configuration adds a version.
+type LaunchTemplate struct {
+	ID            uuid.UUID `json:"id"`
+	UserID        uuid.UUID `json:"user_id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description,omitempty"`
+	LatestVersion int       `json:"latest_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// LaunchTemplateVersion is the configuration instances are launched with.
+type LaunchTemplateVersion struct {
+	TemplateID uuid.UUID `json:"template_id"`
+	Version    int       `json:"version"`
+	Image      string    `json:"image"`
+	Ports      string    `json:"ports,omitempty"`
+	// InstanceType names an entry of InstanceTypes. Instances without a type
+	// run without resource limits.
+	InstanceType string            `json:"instance_type,omitempty"`
+	Env          map[string]string `json:"env,omitempty"`
+	// UserData is a shell script run in the instance once it has started.
+	UserData string             `json:"user_data,omitempty"`
+	Volumes  []VolumeAttachment `json:"volumes,omitempty"`
+	// VpcID is the network the instances join. There are no subnets; a VPC
+	// is a single network.
+	VpcID     *uuid.UUID `json:"vpc_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// InstanceType is a size of instance, enforced as container limits.
+type InstanceType struct {
+	Name     string  `json:"name"`
+	CPUs     float64 `json:"cpus"`
+	MemoryMB int64   `json:"memory_mb"`
+}
+
+// InstanceTypes are the instance types launch templates can choose from.
+var InstanceTypes = map[string]InstanceType{
+	"nano":   {Name: "nano", CPUs: 0.25, MemoryMB: 256},
+	"micro":  {Name: "micro", CPUs: 0.5, MemoryMB: 512},
+	"small":  {Name: "small", CPUs: 1, MemoryMB: 1024},
+	"medium": {Name: "medium", CPUs: 2, MemoryMB: 2048},
+	"large":  {Name: "large", CPUs: 4, MemoryMB: 4096},
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LBAccessLogEntry is one HTTP request or TCP session handled by a load
+// balancer proxy.
+type LBAccessLogEntry struct {
+	Time         time.Time `json:"time"`
+	Protocol     string    `json:"protocol"` // "http" | "tcp"
+	ListenerPort int       `json:"listener_port"`
+	ClientIP     string    `json:"client_ip"`
+	Method       string    `json:"method,omitempty"`
+	Host         string    `json:"host,omitempty"`
+	Path         string    `json:"path,omitempty"`
+	// Status is the HTTP status code, or for TCP the nginx session status.
+	Status    int   `json:"status"`
+	BytesSent int64 `json:"bytes_sent"`
+	// DurationSeconds is the request time, or for TCP the session time.
+	DurationSeconds float64 `json:"duration_seconds"`
+	UpstreamAddr    string  `json:"upstream_addr,omitempty"`
+	// InstanceID is the target that served the request; nil when no target
+	// was reached.
+	InstanceID *uuid.UUID `json:"instance_id,omitempty"`
+}
+
+// AccessLogConfig controls shipping of load balancer access logs to a
+// storage bucket. Logs are collected for metrics either way.
+type AccessLogConfig struct {
+	Enabled bool   `json:"enabled"`
+	Bucket  string `json:"bucket,omitempty"`
+	// Prefix is prepended to the object keys of shipped logs.
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// LBLatencyBuckets are the upper bounds, in seconds, of the request latency
+// histogram. Requests slower than the last bound fall into an overflow bucket.
+var LBLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// LBRequestStats aggregates the requests a load balancer handled in one
+// minute for one target.
+type LBRequestStats struct {
+	LBID uuid.UUID `json:"lb_id"`
+	// InstanceID is nil for requests that reached no target.
+	InstanceID *uuid.UUID `json:"instance_id,omitempty"`
+	Minute     time.Time  `json:"minute"`
+	Requests   int64      `json:"requests"`
+	Status2xx  int64      `json:"status_2xx"`
+	Status3xx  int64      `json:"status_3xx"`
+	Status4xx  int64      `json:"status_4xx"`
+	Status5xx  int64      `json:"status_5xx"`
+	BytesSent  int64      `json:"bytes_sent"`
+	// LatencySum is the total request time in seconds.
+	LatencySum float64 `json:"latency_sum"`
+	// LatencyCounts holds one count per LBLatencyBuckets bound plus the
+	// overflow bucket; counts are not cumulative.
+	LatencyCounts []int64 `json:"latency_counts"`
+}
+
+// LBMetricsSummary describes the traffic of a load balancer or of one of its
+// targets over a window.
+type LBMetricsSummary struct {
+	Requests          int64   `json:"requests"`
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	BytesSent         int64   `json:"bytes_sent"`
+	Status2xx         int64   `json:"status_2xx"`
+	Status3xx         int64   `json:"status_3xx"`
+	Status4xx         int64   `json:"status_4xx"`
+	Status5xx         int64   `json:"status_5xx"`
+	// Rate4xx and Rate5xx are the fractions of requests with such a status.
+	Rate4xx      float64           `json:"rate_4xx"`
+	Rate5xx      float64           `json:"rate_5xx"`
+	LatencyAvgMs float64           `json:"latency_avg_ms"`
+	LatencyP50Ms float64           `json:"latency_p50_ms"`
+	LatencyP90Ms float64           `json:"latency_p90_ms"`
+	LatencyP99Ms float64           `json:"latency_p99_ms"`
+	Latency      []LBLatencyBucket `json:"latency_histogram"`
+}
+
+// LBLatencyBucket counts the requests that took at most LeSeconds and more
+// than the previous bound. The overflow bucket has a LeSeconds of 0.
+type LBLatencyBucket struct {
+	LeSeconds float64 `json:"le_seconds"`
+	Count     int64   `json:"count"`
+}
+
+type LBTargetMetrics struct {
+	InstanceID uuid.UUID `json:"instance_id"`
+	LBMetricsSummary
+}
+
+// LBMetrics is the traffic of a load balancer since a point in time.
+type LBMetrics struct {
+	LBID    uuid.UUID         `json:"lb_id"`
+	Since   time.Time         `json:"since"`
+	Total   LBMetricsSummary  `json:"total"`
+	Targets []LBTargetMetrics `json:"targets"`
+}
@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// LeaderLease elects the API replica that runs a singleton background worker.
+// The holder renews the lease well before it expires; once it has expired,
+// another replica takes it over.
+type LeaderLease struct {
+	Name       string    `json:"name"`
+	HolderID   string    `json:"holder_id"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	RenewedAt  time.Time `json:"renewed_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
@@ -22,25 +22,194 @@ type LoadBalancer struct {
 	Name           string    `json:"name"`
 	VpcID          uuid.UUID `json:"vpc_id"`
 	Port           int       `json:"port"`
-	Algorithm      string    `json:"algorithm"` // "round-robin" | "least-conn"
+	Algorithm      string    `json:"algorithm"` // one of the LBAlgorithm constants
 	Status         LBStatus  `json:"status"`
 	Version        int       `json:"version"`
 	CreatedAt      time.Time `json:"created_at"`
+
+	HealthCheck HealthCheckConfig `json:"health_check"`
+	// DeregistrationDelaySeconds is how long a deregistered target keeps
+	// serving in-flight requests before it is removed.
+	DeregistrationDelaySeconds int             `json:"deregistration_delay_seconds"`
+	AccessLogs                 AccessLogConfig `json:"access_logs"`
+	// HashHeader is the request header hashed by the header-hash algorithm.
+	HashHeader string       `json:"hash_header,omitempty"`
+	Stickiness LBStickiness `json:"stickiness"`
+
+	// Listeners are the configured listeners with their routing rules. They
+	// are loaded on demand and not stored with the load balancer itself.
+	Listeners []*LBListener `json:"listeners,omitempty"`
+}
+
+// Load balancing algorithms. Round-robin honors target weights, as nginx
+// does; weighted names that behaviour explicitly. Least-conn picks the target
+// with the fewest active connections relative to its weight. The hash
+// algorithms send requests with the same client IP or header value to the
+// same target, spreading keys over a consistent hash ring weighted like the
+// targets, so adding or removing a target only moves its share of keys.
+const (
+	LBAlgorithmRoundRobin   = "round-robin"
+	LBAlgorithmWeighted     = "weighted"
+	LBAlgorithmLeastConn    = "least-conn"
+	LBAlgorithmSourceIPHash = "source-ip-hash"
+	LBAlgorithmHeaderHash   = "header-hash"
+)
+
+// LBBalancing selects how a load balancer spreads requests over targets.
+type LBBalancing struct {
+	Algorithm  string       `json:"algorithm"`
+	HashHeader string       `json:"hash_header,omitempty"`
+	Stickiness LBStickiness `json:"stickiness"`
+}
+
+// LBStickiness pins the clients of http and https listeners to a target.
+// The first response sets the LBStickyCookie cookie to a random session key,
+// which is then hashed like the key of a hash algorithm in every target
+// group, so a session keeps its targets until the cookie expires or its
+// target leaves the group. Stickiness takes precedence over the algorithm.
+type LBStickiness struct {
+	Enabled    bool `json:"enabled"`
+	TTLSeconds int  `json:"ttl_seconds,omitempty"`
+}
+
+// LBStickyCookie is the cookie holding the session key of sticky sessions.
+const LBStickyCookie = "thecloud_lb"
+
+const (
+	DefaultStickinessTTL = 86400
+	MaxStickinessTTL     = 7 * 86400
+)
+
+// LBUpdate changes the settings of a load balancer. Nil fields are left as
+// they are.
+type LBUpdate struct {
+	Name       *string       `json:"name,omitempty"`
+	Algorithm  *string       `json:"algorithm,omitempty"`
+	HashHeader *string       `json:"hash_header,omitempty"`
+	Stickiness *LBStickiness `json:"stickiness,omitempty"`
 }
 
 type LBTarget struct {
-	ID         uuid.UUID `json:"id"`
-	LBID       uuid.UUID `json:"lb_id"`
-	InstanceID uuid.UUID `json:"instance_id"`
-	Port       int       `json:"port"`
-	Weight     int       `json:"weight"`
-	Health     string    `json:"health"` // "healthy" | "unhealthy" | "unknown"
+	ID          uuid.UUID `json:"id"`
+	LBID        uuid.UUID `json:"lb_id"`
+	InstanceID  uuid.UUID `json:"instance_id"`
+	TargetGroup string    `json:"target_group"`
+	Port        int       `json:"port"`
+	Weight      int       `json:"weight"`
+	Health      string    `json:"health"` // "healthy" | "unhealthy" | "unknown" | "draining"
+	// DrainingSince is set once the target is deregistered.
+	DrainingSince *time.Time `json:"draining_since,omitempty"`
 }
 
+// DefaultTargetGroup receives traffic that no routing rule claims. Targets
+// registered without a group belong to it.
+const DefaultTargetGroup = "default"
+
+const (
+	LBProtocolHTTP  = "http"
+	LBProtocolHTTPS = "https"
+	LBProtocolTCP   = "tcp"
+)
+
+// LBListener accepts traffic on a port of the load balancer. HTTP and HTTPS
+// listeners route requests to target groups by host and path; TCP listeners
+// forward connections to their default target group.
+type LBListener struct {
+	ID       uuid.UUID `json:"id"`
+	LBID     uuid.UUID `json:"lb_id"`
+	Port     int       `json:"port"`
+	Protocol string    `json:"protocol"`
+	// CertificateSecret names the secret holding the PEM certificate chain and
+	// private key of an https listener.
+	CertificateSecret string `json:"certificate_secret,omitempty"`
+	// RedirectPort makes an http listener answer every request with a
+	// permanent redirect to https on that port instead of proxying it.
+	RedirectPort       int              `json:"redirect_port,omitempty"`
+	DefaultTargetGroup string           `json:"default_target_group"`
+	Rules              []*LBRoutingRule `json:"rules,omitempty"`
+	CreatedAt          time.Time        `json:"created_at"`
+
+	// Certificate is the PEM content of CertificateSecret, resolved when the
+	// proxy is configured.
+	Certificate []byte `json:"-"`
+}
+
+// LBRoutingRule sends the requests of a listener that match its host and path
+// prefix to a target group. Among matching rules the most specific wins: an
+// exact host beats a wildcard host ("*.example.com"), which beats no host, and
+// then the longest path prefix wins.
+type LBRoutingRule struct {
+	ID          uuid.UUID `json:"id"`
+	ListenerID  uuid.UUID `json:"listener_id"`
+	Host        string    `json:"host,omitempty"`
+	PathPrefix  string    `json:"path_prefix"`
+	TargetGroup string    `json:"target_group"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ServedListeners returns the listeners the proxy serves: the configured ones
+// and, unless one of them uses the load balancer's own port, a plain HTTP
+// listener on that port for the default target group.
+func (lb *LoadBalancer) ServedListeners() []*LBListener {
+	for _, l := range lb.Listeners {
+		if l.Port == lb.Port {
+			return lb.Listeners
+		}
+	}
+	implicit := &LBListener{
+		LBID:               lb.ID,
+		Port:               lb.Port,
+		Protocol:           LBProtocolHTTP,
+		DefaultTargetGroup: DefaultTargetGroup,
+	}
+	return append([]*LBListener{implicit}, lb.Listeners...)
+}
+
+// Target health states.
+const (
+	TargetHealthUnknown   = "unknown"
+	TargetHealthHealthy   = "healthy"
+	TargetHealthUnhealthy = "unhealthy"
+	// TargetHealthDraining marks a deregistered target that receives no new
+	// requests and is removed once the deregistration delay has passed.
+	TargetHealthDraining = "draining"
+)
+
+const (
+	DefaultDeregistrationDelay = 30
+	MaxDeregistrationDelay     = 3600
+)
+
+// HealthCheckConfig controls how the targets of a load balancer are probed.
+// A target turns healthy after HealthyThreshold consecutive successful probes
+// and unhealthy after UnhealthyThreshold consecutive failures.
 type HealthCheckConfig struct {
-	Path               string `json:"path"`
+	// Protocol is "http" to request Path, or "tcp" to only open a connection.
+	Protocol string `json:"protocol"`
+	Path     string `json:"path,omitempty"`
+	// StatusCodes lists the response codes that count as healthy, as codes and
+	// ranges such as "200,204,300-399".
+	StatusCodes        string `json:"status_codes,omitempty"`
 	IntervalSeconds    int    `json:"interval_seconds"`
 	TimeoutSeconds     int    `json:"timeout_seconds"`
 	HealthyThreshold   int    `json:"healthy_threshold"`
 	UnhealthyThreshold int    `json:"unhealthy_threshold"`
 }
+
+const (
+	HealthCheckProtocolHTTP = "http"
+	HealthCheckProtocolTCP  = "tcp"
+)
+
+// DefaultHealthCheck is applied to new load balancers.
+func DefaultHealthCheck() HealthCheckConfig {
+	return HealthCheckConfig{
+		Protocol:           HealthCheckProtocolHTTP,
+		Path:               "/",
+		StatusCodes:        "200-399",
+		IntervalSeconds:    10,
+		TimeoutSeconds:     5,
+		HealthyThreshold:   3,
+		UnhealthyThreshold: 2,
+	}
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InstanceMetric is one sample of the resource usage of an instance. CPU is
+// in percent of one core, as docker stats reports it, so a container busy on
+// two cores reads 200. The network counters are totals since the container
+// started.
+type InstanceMetric struct {
+	InstanceID       uuid.UUID `json:"instance_id"`
+	CPUPercent       float64   `json:"cpu_percent"`
+	MemoryBytes      int64     `json:"memory_bytes"`
+	MemoryLimitBytes int64     `json:"memory_limit_bytes"`
+	NetworkRxBytes   int64     `json:"network_rx_bytes"`
+	NetworkTxBytes   int64     `json:"network_tx_bytes"`
+	RecordedAt       time.Time `json:"recorded_at"`
+}
+
+// Resolutions of the instance metric rollups. Raw samples are downsampled to
+// 5 minute buckets, which are downsampled to hourly buckets in turn.
+const (
+	MetricsResolution5m = 5 * time.Minute
+	MetricsResolution1h = time.Hour
+)
+
+// CustomMetric is a data point of an application metric. Data points without
+// an instance describe the whole application, such as the length of a queue
+// its instances consume.
+type CustomMetric struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	Name       string     `json:"name"`
+	Value      float64    `json:"value"`
+	InstanceID *uuid.UUID `json:"instance_id,omitempty"`
+	Timestamp  time.Time  `json:"timestamp"`
+}
+
+const (
+	// MaxCustomMetricsPerRequest caps the data points of one push.
+	MaxCustomMetricsPerRequest = 1000
+	// CustomMetricRetention is how long custom data points are kept.
+	CustomMetricRetention = 24 * time.Hour
+)
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bucket event types.
+const (
+	EventObjectCreated = "ObjectCreated"
+	EventObjectDeleted = "ObjectDeleted"
+)
+
+// BucketNotification sends the events of a bucket's objects whose key matches
+// Prefix and Suffix to a function or a webhook. Exactly one target is set.
+type BucketNotification struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	Bucket string    `json:"bucket"`
+	// Events lists the event types to deliver; empty means all.
+	Events     []string   `json:"events,omitempty"`
+	Prefix     string     `json:"prefix,omitempty"`
+	Suffix     string     `json:"suffix,omitempty"`
+	FunctionID *uuid.UUID `json:"function_id,omitempty"`
+	WebhookURL string     `json:"webhook_url,omitempty"`
+	// Secret signs webhook deliveries; receivers check X-Cloud-Signature with it.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BucketEvent describes a change to an object. It is the payload delivered to
+// notification targets.
+type BucketEvent struct {
+	ID          uuid.UUID `json:"id"`
+	Type        string    `json:"type"`
+	UserID      uuid.UUID `json:"user_id"`
+	Bucket      string    `json:"bucket"`
+	Key         string    `json:"key"`
+	SizeBytes   int64     `json:"size_bytes,omitempty"`
+	ETag        string    `json:"etag,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending DeliveryStatus = "PENDING"
+	DeliveryFailed  DeliveryStatus = "FAILED"
+)
+
+// NotificationDelivery is an event queued for a notification target. Delivered
+// events are removed; events that exhaust their retries stay FAILED.
+type NotificationDelivery struct {
+	ID             uuid.UUID      `json:"id"`
+	NotificationID uuid.UUID      `json:"notification_id"`
+	UserID         uuid.UUID      `json:"user_id"`
+	Event          BucketEvent    `json:"event"`
+	Status         DeliveryStatus `json:"status"`
+	Attempts       int            `json:"attempts"`
+	NextAttemptAt  time.Time      `json:"next_attempt_at"`
+	LastError      string         `json:"last_error,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
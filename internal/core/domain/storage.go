@@ -7,13 +7,177 @@ import (
 )
 
 type Object struct {
-	ID          uuid.UUID  `json:"id"`
-	UserID      uuid.UUID  `json:"user_id"`
-	ARN         string     `json:"arn"`
-	Bucket      string     `json:"bucket"`
-	Key         string     `json:"key"`
-	SizeBytes   int64      `json:"size_bytes"`
-	ContentType string     `json:"content_type"`
-	CreatedAt   time.Time  `json:"created_at"`
-	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	ARN         string    `json:"arn"`
+	Bucket      string    `json:"bucket"`
+	Key         string    `json:"key"`
+	SizeBytes   int64     `json:"size_bytes"`
+	ContentType string    `json:"content_type"`
+	// ETag is the hex MD5 of the object content; SHA256 is its hex SHA-256.
+	ETag     string            `json:"etag"`
+	SHA256   string            `json:"sha256"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Encryption is the server-side encryption mode of the stored bytes, empty
+	// for plaintext. EncryptedKey is the object's data key wrapped by the owner's
+	// derived key or the customer-provided key.
+	Encryption   string `json:"encryption,omitempty"`
+	EncryptedKey string `json:"-"`
+	// StorageKey locates this version's bytes in the file store. It is empty
+	// for objects written before versions had their own storage keys, whose
+	// bytes live at Bucket/Key.
+	StorageKey string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+}
+
+// ObjectVersion is a non-current version of an object: bytes replaced by a
+// newer upload of the same key, kept until its retention window passes.
+type ObjectVersion struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	Bucket       string
+	Key          string
+	StorageKey   string
+	SizeBytes    int64
+	NoncurrentAt time.Time
+}
+
+// Server-side encryption modes.
+const (
+	// EncryptionAES256 encrypts with keys managed by the server.
+	EncryptionAES256 = "AES256"
+	// EncryptionCustomer encrypts with a key the client supplies on every request.
+	// The key itself is never stored.
+	EncryptionCustomer = "SSE-C"
+)
+
+// ObjectOptions carries client-supplied attributes stored with an object.
+// An empty ContentType is detected from the key extension or the content.
+type ObjectOptions struct {
+	ContentType string
+	Metadata    map[string]string
+	// Encryption requests a server-side encryption mode; empty applies the
+	// bucket default. CustomerKey is the 32-byte key for EncryptionCustomer.
+	Encryption  string
+	CustomerKey []byte
+}
+
+// ReadOptions carries client-supplied values needed to read an object.
+type ReadOptions struct {
+	// CustomerKey decrypts objects stored with EncryptionCustomer.
+	CustomerKey []byte
+}
+
+// BucketEncryption is the default server-side encryption applied to objects
+// uploaded to a bucket without an explicit encryption mode.
+type BucketEncryption struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Bucket    string    `json:"bucket"`
+	Algorithm string    `json:"algorithm"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BucketWebsite serves the objects of a bucket as a static website without
+// authentication. Website bucket names are unique across users so that each
+// site has its own hostname.
+type BucketWebsite struct {
+	UserID uuid.UUID `json:"user_id"`
+	Bucket string    `json:"bucket"`
+	// IndexDocument is served for the site root and for paths ending in "/".
+	IndexDocument string `json:"index_document"`
+	// ErrorDocument is the key served with status 404 for missing objects;
+	// empty returns a plain 404.
+	ErrorDocument string `json:"error_document,omitempty"`
+	// CacheMaxAge is the max-age in seconds of the Cache-Control header.
+	CacheMaxAge int       `json:"cache_max_age"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+const (
+	DefaultWebsiteIndexDocument = "index.html"
+	DefaultWebsiteCacheMaxAge   = 300
+)
+
+// Bucket is a named container of objects. Buckets are implicit: they exist
+// as long as they hold at least one object.
+type Bucket struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MultipartUpload tracks an in-progress upload whose parts are staged
+// separately and assembled into a single object on completion.
+type MultipartUpload struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	Bucket string    `json:"bucket"`
+	Key    string    `json:"key"`
+	// ContentType and Metadata are applied to the object on completion.
+	ContentType string            `json:"content_type,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	// Encryption is resolved when the upload starts; staged parts are encrypted too.
+	Encryption string    `json:"encryption,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Part is a single chunk of a multipart upload.
+type Part struct {
+	UploadID   uuid.UUID `json:"upload_id"`
+	PartNumber int       `json:"part_number"`
+	SizeBytes  int64     `json:"size_bytes"`
+	ETag       string    `json:"etag"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CompletedPart names a part to include when a multipart upload is completed.
+// ETag must match the one returned when the part was uploaded.
+type CompletedPart struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+}
+
+// PresignedURL grants temporary access to a single object without an API key.
+// The URL is signed for one HTTP method and expires at ExpiresAt.
+type PresignedURL struct {
+	URL              string    `json:"url"`
+	Method           string    `json:"method"`
+	UserID           uuid.UUID `json:"user_id"`
+	Bucket           string    `json:"bucket"`
+	Key              string    `json:"key"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	MaxContentLength int64     `json:"max_content_length,omitempty"`
+}
+
+// Query parameters carried by a pre-signed URL.
+const (
+	PresignUserParam             = "X-Cloud-User"
+	PresignExpiresParam          = "X-Cloud-Expires"
+	PresignMaxContentLengthParam = "X-Cloud-Max-Content-Length"
+	PresignSignatureParam        = "X-Cloud-Signature"
+)
+
+// LifecycleRule automates clean-up of a bucket's objects whose key starts with Prefix.
+// A zero day count disables that action.
+type LifecycleRule struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	Bucket string    `json:"bucket"`
+	Prefix string    `json:"prefix"`
+	// ExpirationDays deletes objects this many days after they were written.
+	ExpirationDays int `json:"expiration_days,omitempty"`
+	// DeletedRetentionDays purges deleted objects (the only non-current copies
+	// kept, as objects are not versioned) this many days after deletion.
+	DeletedRetentionDays int `json:"deleted_retention_days,omitempty"`
+	// AbortIncompleteUploadDays aborts multipart uploads left incomplete this long.
+	AbortIncompleteUploadDays int       `json:"abort_incomplete_upload_days,omitempty"`
+	Enabled                   bool      `json:"enabled"`
+	CreatedAt                 time.Time `json:"created_at"`
+}
+
+// ScrubReport summarises one integrity pass over a FileStore.
+type ScrubReport struct {
+	Objects       int `json:"objects"`
+	Repaired      int `json:"repaired"`
+	Unrecoverable int `json:"unrecoverable"`
 }
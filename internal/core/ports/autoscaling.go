@@ -17,35 +17,196 @@ type AutoScalingRepository interface {
 	ListAllGroups(ctx context.Context) ([]*domain.ScalingGroup, error)
 	CountGroupsByVPC(ctx context.Context, vpcID uuid.UUID) (int, error)
 	UpdateGroup(ctx context.Context, group *domain.ScalingGroup) error
+	// UpdateGroupLaunchTemplate saves the launch template, image and ports of
+	// the group.
+	UpdateGroupLaunchTemplate(ctx context.Context, group *domain.ScalingGroup) error
+	// UpdateGroupSettings saves the name, load balancer, image, sizes and
+	// health check of the group, failing with Conflict if it changed since
+	// it was read.
+	UpdateGroupSettings(ctx context.Context, group *domain.ScalingGroup) error
+	UpdateGroupSuspendedProcesses(ctx context.Context, group *domain.ScalingGroup) error
 	DeleteGroup(ctx context.Context, id uuid.UUID) error
 
 	// Policies
 	CreatePolicy(ctx context.Context, policy *domain.ScalingPolicy) error
 	GetPoliciesForGroup(ctx context.Context, groupID uuid.UUID) ([]*domain.ScalingPolicy, error)
 	GetAllPolicies(ctx context.Context, groupIDs []uuid.UUID) (map[uuid.UUID][]*domain.ScalingPolicy, error)
-	UpdatePolicyLastScaled(ctx context.Context, policyID uuid.UUID, t time.Time) error
+	// UpdatePolicyLastScaled records a scaling action of a policy; scale-outs
+	// also restart the scale-out cooldown.
+	UpdatePolicyLastScaled(ctx context.Context, policyID uuid.UUID, t time.Time, scaleOut bool) error
 	DeletePolicy(ctx context.Context, id uuid.UUID) error
 
+	// Scheduled Actions
+	CreateScheduledAction(ctx context.Context, action *domain.ScheduledAction) error
+	ListScheduledActions(ctx context.Context, groupID uuid.UUID) ([]*domain.ScheduledAction, error)
+	DeleteScheduledAction(ctx context.Context, id uuid.UUID) error
+	// GetDueScheduledActions fetches the actions of the groups whose next run
+	// is at or before now, ordered by their next run.
+	GetDueScheduledActions(ctx context.Context, groupIDs []uuid.UUID, now time.Time) (map[uuid.UUID][]*domain.ScheduledAction, error)
+	UpdateScheduledActionRun(ctx context.Context, id uuid.UUID, lastRunAt, nextRunAt time.Time) error
+
+	// Instance Refreshes
+	CreateInstanceRefresh(ctx context.Context, refresh *domain.InstanceRefresh) error
+	// GetLatestInstanceRefresh fetches the most recently started refresh of
+	// the group.
+	GetLatestInstanceRefresh(ctx context.Context, groupID uuid.UUID) (*domain.InstanceRefresh, error)
+	// GetRunningInstanceRefreshes fetches the refreshes of the groups that are
+	// in progress or rolling back.
+	GetRunningInstanceRefreshes(ctx context.Context, groupIDs []uuid.UUID) (map[uuid.UUID]*domain.InstanceRefresh, error)
+	// UpdateInstanceRefresh saves the refresh if its status is still from and
+	// fails with Conflict otherwise.
+	UpdateInstanceRefresh(ctx context.Context, refresh *domain.InstanceRefresh, from domain.InstanceRefreshStatus) error
+
+	// Lifecycle Hooks
+	CreateLifecycleHook(ctx context.Context, hook *domain.LifecycleHook) error
+	ListLifecycleHooks(ctx context.Context, groupID uuid.UUID) ([]*domain.LifecycleHook, error)
+	DeleteLifecycleHook(ctx context.Context, id uuid.UUID) error
+	GetAllLifecycleHooks(ctx context.Context, groupIDs []uuid.UUID) (map[uuid.UUID][]*domain.LifecycleHook, error)
+	// SetInstanceLifecycleState moves an instance to a lifecycle state,
+	// clearing its drain deadline and any earlier result.
+	SetInstanceLifecycleState(ctx context.Context, groupID, instanceID uuid.UUID, state domain.LifecycleState, timeoutAt *time.Time) error
+	// CompleteLifecycleAction records the result for an instance awaiting a
+	// lifecycle action and fails with NotFound if it is not waiting.
+	CompleteLifecycleAction(ctx context.Context, groupID, instanceID uuid.UUID, result domain.LifecycleActionResult) error
+	// GetAllWaitingInstances fetches the instances awaiting a lifecycle
+	// action.
+	GetAllWaitingInstances(ctx context.Context, groupIDs []uuid.UUID) (map[uuid.UUID][]domain.ScalingGroupInstance, error)
+
+	// Activities
+	CreateActivity(ctx context.Context, activity *domain.ScalingActivity) error
+	// ListActivities returns the latest activities of the group, newest
+	// first.
+	ListActivities(ctx context.Context, groupID uuid.UUID, limit int) ([]*domain.ScalingActivity, error)
+	DeleteActivitiesBefore(ctx context.Context, before time.Time) error
+
 	// Group Instances
 	AddInstanceToGroup(ctx context.Context, groupID, instanceID uuid.UUID) error
 	RemoveInstanceFromGroup(ctx context.Context, groupID, instanceID uuid.UUID) error
 	GetInstancesInGroup(ctx context.Context, groupID uuid.UUID) ([]uuid.UUID, error)
-	// GetAllScalingGroupInstances fetches instances for multiple groups in one batch query to prevent N+1
+	// GetAllScalingGroupInstances fetches instances for multiple groups in one batch query to prevent N+1.
+	// Draining instances and those awaiting a terminating lifecycle action are left out.
 	GetAllScalingGroupInstances(ctx context.Context, groupIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error)
+	// MarkInstanceDraining takes an instance out of its group's count until it is terminated.
+	MarkInstanceDraining(ctx context.Context, groupID, instanceID uuid.UUID, until time.Time) error
+	GetAllDrainingInstances(ctx context.Context, groupIDs []uuid.UUID) (map[uuid.UUID][]domain.ScalingGroupInstance, error)
 
 	// Metrics
+	// GetAverageCPU returns the CPU usage in percent. It fails with NotFound if
+	// the instances reported no samples since since.
 	GetAverageCPU(ctx context.Context, instanceIDs []uuid.UUID, since time.Time) (float64, error)
+	// GetAverageMemory returns the memory usage in percent of the limit. It
+	// fails with NotFound if there are no samples with a limit.
+	GetAverageMemory(ctx context.Context, instanceIDs []uuid.UUID, since time.Time) (float64, error)
+	// GetAverageNetworkThroughput returns the bytes per second received and
+	// sent by an instance on average.
+	GetAverageNetworkThroughput(ctx context.Context, instanceIDs []uuid.UUID, since time.Time) (rx, tx float64, err error)
+	// GetRequestCount returns the requests a load balancer sent to the
+	// instances in the minutes from from up to to.
+	GetRequestCount(ctx context.Context, lbID uuid.UUID, instanceIDs []uuid.UUID, from, to time.Time) (int64, error)
+	// GetAverageCustomMetric averages a user's custom metric over the data
+	// points of the instances and those without an instance. It fails with
+	// NotFound if there are none.
+	GetAverageCustomMetric(ctx context.Context, userID uuid.UUID, name string, instanceIDs []uuid.UUID, since time.Time) (float64, error)
+}
+
+// CreateScalingGroupParams describes a new scaling group. Its instances are
+// launched either from Image and Ports or from a launch template version; the
+// VPC defaults to the template's.
+type CreateScalingGroupParams struct {
+	Name                  string
+	VpcID                 uuid.UUID
+	Image                 string
+	Ports                 string
+	LaunchTemplateID      *uuid.UUID
+	LaunchTemplateVersion int
+	MinInstances          int
+	MaxInstances          int
+	DesiredCount          int
+	LoadBalancerID        *uuid.UUID
+	// HealthCheckType defaults to domain.ScalingHealthCheckContainer and
+	// HealthCheckGracePeriodSec to domain.DefaultHealthCheckGracePeriodSec.
+	HealthCheckType           string
+	HealthCheckGracePeriodSec *int
+	IdempotencyKey            string
+}
+
+// UpdateScalingGroupParams changes the settings it sets and leaves the others
+// as they are. A LoadBalancerID of uuid.Nil detaches the load balancer.
+type UpdateScalingGroupParams struct {
+	Name                      *string
+	Image                     *string
+	MinInstances              *int
+	MaxInstances              *int
+	DesiredCount              *int
+	LoadBalancerID            *uuid.UUID
+	HealthCheckType           *string
+	HealthCheckGracePeriodSec *int
+}
+
+// StartInstanceRefreshParams describes an instance refresh. A new launch
+// template version or image, if given, is applied to the group first and
+// restored if the refresh fails.
+type StartInstanceRefreshParams struct {
+	// MinHealthyPercentage defaults to domain.DefaultMinHealthyPercentage
+	// when nil.
+	MinHealthyPercentage  *int
+	HealthCheckTimeoutSec int
+	LaunchTemplateID      *uuid.UUID
+	LaunchTemplateVersion int
+	Image                 string
 }
 
 type AutoScalingService interface {
-	CreateGroup(ctx context.Context, name string, vpcID uuid.UUID, image string, ports string, min, max, desired int, lbID *uuid.UUID, idempotencyKey string) (*domain.ScalingGroup, error)
+	CreateGroup(ctx context.Context, params CreateScalingGroupParams) (*domain.ScalingGroup, error)
 	GetGroup(ctx context.Context, id uuid.UUID) (*domain.ScalingGroup, error)
 	ListGroups(ctx context.Context) ([]*domain.ScalingGroup, error)
 	DeleteGroup(ctx context.Context, id uuid.UUID) error
 	SetDesiredCapacity(ctx context.Context, groupID uuid.UUID, desired int) error
+	// UpdateGroup changes the settings of the group. A desired count left
+	// unset is moved into the new bounds, and instances in service move to a
+	// new load balancer.
+	UpdateGroup(ctx context.Context, id uuid.UUID, params UpdateScalingGroupParams) (*domain.ScalingGroup, error)
+	// SuspendProcesses pauses worker processes for the group, all of them
+	// when none are given. ResumeProcesses undoes it.
+	SuspendProcesses(ctx context.Context, id uuid.UUID, processes []domain.ScalingProcess) (*domain.ScalingGroup, error)
+	ResumeProcesses(ctx context.Context, id uuid.UUID, processes []domain.ScalingProcess) (*domain.ScalingGroup, error)
+	// SetLaunchTemplate moves the group to a launch template version, the
+	// latest for 0. Instances launched from then on use it; running ones are
+	// left alone.
+	SetLaunchTemplate(ctx context.Context, groupID, templateID uuid.UUID, version int) (*domain.ScalingGroup, error)
 
-	CreatePolicy(ctx context.Context, groupID uuid.UUID, name, metricType string, targetValue float64, scaleOut, scaleIn, cooldownSec int) (*domain.ScalingPolicy, error)
+	// StartInstanceRefresh starts replacing the instances of the group; a
+	// group has one refresh at a time.
+	StartInstanceRefresh(ctx context.Context, groupID uuid.UUID, params StartInstanceRefreshParams) (*domain.InstanceRefresh, error)
+	// GetInstanceRefresh returns the latest refresh of the group.
+	GetInstanceRefresh(ctx context.Context, groupID uuid.UUID) (*domain.InstanceRefresh, error)
+	PauseInstanceRefresh(ctx context.Context, groupID uuid.UUID) (*domain.InstanceRefresh, error)
+	ResumeInstanceRefresh(ctx context.Context, groupID uuid.UUID) (*domain.InstanceRefresh, error)
+	// CancelInstanceRefresh stops the refresh, leaving the instances replaced
+	// so far in place.
+	CancelInstanceRefresh(ctx context.Context, groupID uuid.UUID) (*domain.InstanceRefresh, error)
+
+	// CreateLifecycleHook adds a hook to the group; a group has at most one
+	// hook per transition.
+	CreateLifecycleHook(ctx context.Context, groupID uuid.UUID, hook *domain.LifecycleHook) (*domain.LifecycleHook, error)
+	ListLifecycleHooks(ctx context.Context, groupID uuid.UUID) ([]*domain.LifecycleHook, error)
+	DeleteLifecycleHook(ctx context.Context, id uuid.UUID) error
+	// CompleteLifecycleAction releases an instance from its wait state.
+	CompleteLifecycleAction(ctx context.Context, groupID, instanceID uuid.UUID, result domain.LifecycleActionResult) error
+	// ListActivities returns up to limit of the latest scaling activities
+	// of the group, newest first.
+	ListActivities(ctx context.Context, groupID uuid.UUID, limit int) ([]*domain.ScalingActivity, error)
+
+	// CreatePolicy validates the policy, fills in defaults for the settings
+	// left unset and adds it to the group.
+	CreatePolicy(ctx context.Context, groupID uuid.UUID, policy *domain.ScalingPolicy) (*domain.ScalingPolicy, error)
 	DeletePolicy(ctx context.Context, id uuid.UUID) error
+
+	// CreateScheduledAction validates the cron schedule and time zone of the
+	// action and adds it to the group.
+	CreateScheduledAction(ctx context.Context, groupID uuid.UUID, action *domain.ScheduledAction) (*domain.ScheduledAction, error)
+	ListScheduledActions(ctx context.Context, groupID uuid.UUID) ([]*domain.ScheduledAction, error)
+	DeleteScheduledAction(ctx context.Context, id uuid.UUID) error
 }
 
 // Clock interface allows mocking time in tests
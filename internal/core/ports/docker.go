@@ -18,9 +18,24 @@ type RunTaskOptions struct {
 	Binds           []string
 }
 
+// CreateContainerOptions configures a long-running container. Zero limits
+// leave the container unlimited.
+type CreateContainerOptions struct {
+	Name        string
+	Image       string
+	Ports       []string
+	NetworkID   string
+	VolumeBinds []string
+	Env         []string
+	Cmd         []string
+	MemoryMB    int64
+	CPUs        float64
+}
+
 // DockerClient defines the interface for interacting with the container engine.
 type DockerClient interface {
 	CreateContainer(ctx context.Context, name, image string, ports []string, networkID string, volumeBinds []string, env []string, cmd []string) (string, error)
+	CreateContainerWithOptions(ctx context.Context, opts CreateContainerOptions) (string, error)
 	StopContainer(ctx context.Context, containerID string) error
 	RemoveContainer(ctx context.Context, containerID string) error
 	GetLogs(ctx context.Context, containerID string) (io.ReadCloser, error)
@@ -32,5 +47,7 @@ type DockerClient interface {
 	DeleteVolume(ctx context.Context, name string) error
 	RunTask(ctx context.Context, opts RunTaskOptions) (string, error)
 	WaitContainer(ctx context.Context, containerID string) (int64, error)
+	// IsContainerRunning reports whether the container exists and is running.
+	IsContainerRunning(ctx context.Context, containerID string) (bool, error)
 	Exec(ctx context.Context, containerID string, cmd []string) (string, error)
 }
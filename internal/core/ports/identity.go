@@ -10,10 +10,13 @@ import (
 type IdentityRepository interface {
 	CreateApiKey(ctx context.Context, apiKey *domain.ApiKey) error
 	GetApiKeyByKey(ctx context.Context, key string) (*domain.ApiKey, error)
+	GetApiKeyByID(ctx context.Context, id uuid.UUID) (*domain.ApiKey, error)
 	// list, delete etc can be added later
 }
 
 type IdentityService interface {
 	CreateKey(ctx context.Context, userID uuid.UUID, name string) (*domain.ApiKey, error)
 	ValidateApiKey(ctx context.Context, key string) (*domain.ApiKey, error)
+	// GetApiKeyByID looks up a key by its ID, which doubles as the S3 access key ID.
+	GetApiKeyByID(ctx context.Context, id uuid.UUID) (*domain.ApiKey, error)
 }
@@ -13,6 +13,8 @@ type InstanceRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Instance, error)
 	GetByName(ctx context.Context, name string) (*domain.Instance, error)
 	List(ctx context.Context) ([]*domain.Instance, error)
+	// ListAll returns the instances of all users, for background workers.
+	ListAll(ctx context.Context) ([]*domain.Instance, error)
 	Update(ctx context.Context, instance *domain.Instance) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
@@ -20,9 +22,16 @@ type InstanceRepository interface {
 // InstanceService defines the business logic interface.
 type InstanceService interface {
 	LaunchInstance(ctx context.Context, name, image, ports string, vpcID *uuid.UUID, volumes []domain.VolumeAttachment) (*domain.Instance, error)
+	// LaunchInstanceFromTemplate launches an instance with the configuration
+	// of a launch template version.
+	LaunchInstanceFromTemplate(ctx context.Context, name string, template *domain.LaunchTemplateVersion) (*domain.Instance, error)
 	StopInstance(ctx context.Context, idOrName string) error
 	ListInstances(ctx context.Context) ([]*domain.Instance, error)
 	GetInstance(ctx context.Context, idOrName string) (*domain.Instance, error)
+	// RefreshInstanceStatus returns the instance after checking its
+	// container; a running instance whose container has exited is marked
+	// ERROR.
+	RefreshInstanceStatus(ctx context.Context, idOrName string) (*domain.Instance, error)
 	GetInstanceLogs(ctx context.Context, idOrName string) (string, error)
 	GetInstanceStats(ctx context.Context, idOrName string) (*domain.InstanceStats, error)
 	TerminateInstance(ctx context.Context, idOrName string) error
@@ -0,0 +1,38 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+)
+
+type LaunchTemplateRepository interface {
+	// Create stores a template with its first version.
+	Create(ctx context.Context, template *domain.LaunchTemplate, version *domain.LaunchTemplateVersion) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.LaunchTemplate, error)
+	List(ctx context.Context) ([]*domain.LaunchTemplate, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	// CountGroupsUsing counts the scaling groups launching from the template.
+	CountGroupsUsing(ctx context.Context, id uuid.UUID) (int, error)
+
+	// CreateVersion stores the version as the template's next one and sets
+	// its number.
+	CreateVersion(ctx context.Context, version *domain.LaunchTemplateVersion) error
+	// GetVersion fetches a version of the template, the latest for 0.
+	GetVersion(ctx context.Context, templateID uuid.UUID, version int) (*domain.LaunchTemplateVersion, error)
+	ListVersions(ctx context.Context, templateID uuid.UUID) ([]*domain.LaunchTemplateVersion, error)
+}
+
+type LaunchTemplateService interface {
+	CreateTemplate(ctx context.Context, name, description string, version *domain.LaunchTemplateVersion) (*domain.LaunchTemplate, error)
+	GetTemplate(ctx context.Context, id uuid.UUID) (*domain.LaunchTemplate, error)
+	ListTemplates(ctx context.Context) ([]*domain.LaunchTemplate, error)
+	// DeleteTemplate fails with Conflict while scaling groups use the template.
+	DeleteTemplate(ctx context.Context, id uuid.UUID) error
+
+	CreateVersion(ctx context.Context, templateID uuid.UUID, version *domain.LaunchTemplateVersion) (*domain.LaunchTemplateVersion, error)
+	// GetVersion fetches a version of the template, the latest for 0.
+	GetVersion(ctx context.Context, templateID uuid.UUID, version int) (*domain.LaunchTemplateVersion, error)
+	ListVersions(ctx context.Context, templateID uuid.UUID) ([]*domain.LaunchTemplateVersion, error)
+}
@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/poyrazk/thecloud/internal/core/domain"
+)
+
+// LeaseRepository stores leader leases. Lease times come from the database
+// clock, so the replicas need not agree on the time.
+type LeaseRepository interface {
+	// AcquireLease takes or renews the lease for the holder for ttl, unless
+	// another holder's lease is still valid, and returns the lease as it
+	// stands.
+	AcquireLease(ctx context.Context, name, holderID string, ttl time.Duration) (*domain.LeaderLease, error)
+	// ReleaseLease expires the lease if the holder still holds it.
+	ReleaseLease(ctx context.Context, name, holderID string) error
+}
@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/poyrazk/thecloud/internal/core/domain"
@@ -18,24 +19,72 @@ type LBRepository interface {
 
 	AddTarget(ctx context.Context, target *domain.LBTarget) error
 	RemoveTarget(ctx context.Context, lbID, instanceID uuid.UUID) error
+	// DrainTarget marks a target as draining since the given time.
+	DrainTarget(ctx context.Context, lbID, instanceID uuid.UUID, since time.Time) error
 	ListTargets(ctx context.Context, lbID uuid.UUID) ([]*domain.LBTarget, error)
+	// UpdateTargetHealth leaves draining targets untouched.
 	UpdateTargetHealth(ctx context.Context, lbID, instanceID uuid.UUID, health string) error
 	GetTargetsForInstance(ctx context.Context, instanceID uuid.UUID) ([]*domain.LBTarget, error)
+
+	CreateListener(ctx context.Context, listener *domain.LBListener) error
+	// ListListeners returns the listeners of a load balancer with their routing rules.
+	ListListeners(ctx context.Context, lbID uuid.UUID) ([]*domain.LBListener, error)
+	DeleteListener(ctx context.Context, lbID, listenerID uuid.UUID) error
+	CreateRoutingRule(ctx context.Context, rule *domain.LBRoutingRule) error
+	DeleteRoutingRule(ctx context.Context, listenerID, ruleID uuid.UUID) error
+
+	SaveRequestStats(ctx context.Context, stats []*domain.LBRequestStats) error
+	// ListRequestStats returns the stats rows of a load balancer from the given minute on.
+	ListRequestStats(ctx context.Context, lbID uuid.UUID, since time.Time) ([]*domain.LBRequestStats, error)
+	// DeleteRequestStatsBefore prunes the stats of all load balancers.
+	DeleteRequestStatsBefore(ctx context.Context, before time.Time) error
 }
 
 type LBService interface {
-	Create(ctx context.Context, name string, vpcID uuid.UUID, port int, algo string, idempotencyKey string) (*domain.LoadBalancer, error)
+	// Create registers a load balancer; an empty algorithm means round-robin.
+	Create(ctx context.Context, name string, vpcID uuid.UUID, port int, balancing domain.LBBalancing, idempotencyKey string) (*domain.LoadBalancer, error)
 	Get(ctx context.Context, id uuid.UUID) (*domain.LoadBalancer, error)
 	List(ctx context.Context) ([]*domain.LoadBalancer, error)
+	// UpdateLB changes the name, algorithm, hash header or stickiness.
+	UpdateLB(ctx context.Context, id uuid.UUID, update domain.LBUpdate) (*domain.LoadBalancer, error)
 	Delete(ctx context.Context, id uuid.UUID) error
+	// UpdateHealthCheck replaces the target health check settings; zero values
+	// take the defaults.
+	UpdateHealthCheck(ctx context.Context, id uuid.UUID, cfg domain.HealthCheckConfig) (*domain.LoadBalancer, error)
 
-	AddTarget(ctx context.Context, lbID, instanceID uuid.UUID, port int, weight int) error
-	RemoveTarget(ctx context.Context, lbID, instanceID uuid.UUID) error
+	// AddTarget registers an instance in a target group; an empty group means
+	// domain.DefaultTargetGroup.
+	AddTarget(ctx context.Context, lbID, instanceID uuid.UUID, port int, weight int, targetGroup string) error
+	// RemoveTarget deregisters a target. The target stops receiving new
+	// requests and is removed at the returned time, once the deregistration
+	// delay of the load balancer has passed.
+	RemoveTarget(ctx context.Context, lbID, instanceID uuid.UUID) (time.Time, error)
 	ListTargets(ctx context.Context, lbID uuid.UUID) ([]*domain.LBTarget, error)
+	// SetDeregistrationDelay sets how long deregistered targets drain.
+	SetDeregistrationDelay(ctx context.Context, id uuid.UUID, seconds int) (*domain.LoadBalancer, error)
+	// SetAccessLogs configures shipping of access logs to a bucket.
+	SetAccessLogs(ctx context.Context, id uuid.UUID, cfg domain.AccessLogConfig) (*domain.LoadBalancer, error)
+	// GetMetrics returns the traffic of a load balancer and its targets over
+	// the given window.
+	GetMetrics(ctx context.Context, id uuid.UUID, window time.Duration) (*domain.LBMetrics, error)
+
+	AddListener(ctx context.Context, lbID uuid.UUID, listener domain.LBListener) (*domain.LBListener, error)
+	ListListeners(ctx context.Context, lbID uuid.UUID) ([]*domain.LBListener, error)
+	RemoveListener(ctx context.Context, lbID, listenerID uuid.UUID) error
+	AddRoutingRule(ctx context.Context, lbID, listenerID uuid.UUID, rule domain.LBRoutingRule) (*domain.LBRoutingRule, error)
+	RemoveRoutingRule(ctx context.Context, lbID, listenerID, ruleID uuid.UUID) error
 }
 
+// LBProxyAdapter runs the data plane of load balancers. The load balancer
+// passed in carries its listeners, with certificates resolved.
 type LBProxyAdapter interface {
 	DeployProxy(ctx context.Context, lb *domain.LoadBalancer, targets []*domain.LBTarget) (string, error)
 	RemoveProxy(ctx context.Context, lbID uuid.UUID) error
 	UpdateProxyConfig(ctx context.Context, lb *domain.LoadBalancer, targets []*domain.LBTarget) error
+	// CollectAccessLogs returns the access log entries the proxy wrote since
+	// the previous call, with the target that served each one resolved.
+	CollectAccessLogs(ctx context.Context, lbID uuid.UUID) ([]domain.LBAccessLogEntry, error)
+	// TargetAddress returns the address the proxy reaches a target on, so
+	// health checks probe the same path as the traffic.
+	TargetAddress(ctx context.Context, lb *domain.LoadBalancer, t *domain.LBTarget) (string, error)
 }
@@ -0,0 +1,28 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/poyrazk/thecloud/internal/core/domain"
+)
+
+// MetricsRepository stores instance metric samples and their rollups.
+type MetricsRepository interface {
+	SaveInstanceMetrics(ctx context.Context, samples []*domain.InstanceMetric) error
+	// RollupInstanceMetrics aggregates the data of the source resolution, 0
+	// for raw samples, between from and to into buckets of the given
+	// resolution. Rolling up the same window again replaces its buckets.
+	RollupInstanceMetrics(ctx context.Context, source, resolution time.Duration, from, to time.Time) error
+	// DeleteInstanceMetricsBefore prunes the data of a resolution, 0 for raw
+	// samples.
+	DeleteInstanceMetricsBefore(ctx context.Context, resolution time.Duration, before time.Time) error
+
+	SaveCustomMetrics(ctx context.Context, metrics []*domain.CustomMetric) error
+	DeleteCustomMetricsBefore(ctx context.Context, before time.Time) error
+}
+
+type MetricsService interface {
+	// PutCustomMetrics stores data points of the caller's application metrics.
+	PutCustomMetrics(ctx context.Context, metrics []*domain.CustomMetric) error
+}
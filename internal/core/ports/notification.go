@@ -0,0 +1,37 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+)
+
+type NotificationRepository interface {
+	Create(ctx context.Context, n *domain.BucketNotification) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.BucketNotification, error)
+	ListByBucket(ctx context.Context, bucket string) ([]*domain.BucketNotification, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	CreateDelivery(ctx context.Context, d *domain.NotificationDelivery) error
+	// ClaimDueDeliveries returns pending deliveries of all users due at or before now
+	// and moves their next attempt to leaseUntil, so concurrent workers never pick
+	// the same delivery and a worker that dies mid-batch leaves it to be retried.
+	ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*domain.NotificationDelivery, error)
+	UpdateDelivery(ctx context.Context, d *domain.NotificationDelivery) error
+	DeleteDelivery(ctx context.Context, id uuid.UUID) error
+}
+
+// BucketEventPublisher receives the object events of the storage service.
+// Publishing never fails the storage operation that caused the event.
+type BucketEventPublisher interface {
+	Publish(ctx context.Context, event *domain.BucketEvent)
+}
+
+type NotificationService interface {
+	BucketEventPublisher
+	CreateNotification(ctx context.Context, bucket string, n domain.BucketNotification) (*domain.BucketNotification, error)
+	ListNotifications(ctx context.Context, bucket string) ([]*domain.BucketNotification, error)
+	DeleteNotification(ctx context.Context, bucket string, id uuid.UUID) error
+}
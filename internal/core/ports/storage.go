@@ -3,7 +3,10 @@ package ports
 import (
 	"context"
 	"io"
+	"net/url"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/poyrazk/thecloud/internal/core/domain"
 )
 
@@ -12,6 +15,43 @@ type StorageRepository interface {
 	GetMeta(ctx context.Context, bucket, key string) (*domain.Object, error)
 	List(ctx context.Context, bucket string) ([]*domain.Object, error)
 	SoftDelete(ctx context.Context, bucket, key string) error
+	ListBuckets(ctx context.Context) ([]*domain.Bucket, error)
+
+	// Multipart uploads
+	CreateMultipartUpload(ctx context.Context, upload *domain.MultipartUpload) error
+	GetMultipartUpload(ctx context.Context, id uuid.UUID) (*domain.MultipartUpload, error)
+	DeleteMultipartUpload(ctx context.Context, id uuid.UUID) error
+	SavePart(ctx context.Context, part *domain.Part) error
+	ListParts(ctx context.Context, uploadID uuid.UUID) ([]*domain.Part, error)
+	// ListStaleMultipartUploads returns uploads of all users created before the given time.
+	ListStaleMultipartUploads(ctx context.Context, olderThan time.Time) ([]*domain.MultipartUpload, error)
+
+	// Lifecycle rules and garbage collection
+	CreateLifecycleRule(ctx context.Context, rule *domain.LifecycleRule) error
+	ListLifecycleRules(ctx context.Context, bucket string) ([]*domain.LifecycleRule, error)
+	DeleteLifecycleRule(ctx context.Context, id uuid.UUID) error
+	// ListAllLifecycleRules returns the enabled rules of all users.
+	ListAllLifecycleRules(ctx context.Context) ([]*domain.LifecycleRule, error)
+	// ListDeletedObjects returns objects of all users soft-deleted before the given time.
+	ListDeletedObjects(ctx context.Context, deletedBefore time.Time) ([]*domain.Object, error)
+	// HardDelete removes a soft-deleted object row. It fails with ObjectNotFound if the
+	// object was re-uploaded in the meantime.
+	HardDelete(ctx context.Context, id uuid.UUID) error
+	// ListNoncurrentVersions returns versions of all users replaced before the given time.
+	ListNoncurrentVersions(ctx context.Context, replacedBefore time.Time) ([]*domain.ObjectVersion, error)
+	DeleteNoncurrentVersion(ctx context.Context, id uuid.UUID) error
+
+	// Bucket default encryption
+	PutBucketEncryption(ctx context.Context, cfg *domain.BucketEncryption) error
+	GetBucketEncryption(ctx context.Context, bucket string) (*domain.BucketEncryption, error)
+	DeleteBucketEncryption(ctx context.Context, bucket string) error
+
+	// Bucket websites
+	PutBucketWebsite(ctx context.Context, cfg *domain.BucketWebsite) error
+	GetBucketWebsite(ctx context.Context, bucket string) (*domain.BucketWebsite, error)
+	DeleteBucketWebsite(ctx context.Context, bucket string) error
+	// FindBucketWebsite looks up the website of a bucket regardless of its owner.
+	FindBucketWebsite(ctx context.Context, bucket string) (*domain.BucketWebsite, error)
 }
 
 type FileStore interface {
@@ -20,9 +60,45 @@ type FileStore interface {
 	Delete(ctx context.Context, bucket, key string) error
 }
 
+// ScrubbingFileStore is a FileStore that keeps redundant copies and can verify
+// and repair them.
+type ScrubbingFileStore interface {
+	FileStore
+	Scrub(ctx context.Context) (*domain.ScrubReport, error)
+}
+
 type StorageService interface {
-	Upload(ctx context.Context, bucket, key string, r io.Reader) (*domain.Object, error)
-	Download(ctx context.Context, bucket, key string) (io.ReadCloser, *domain.Object, error)
+	Upload(ctx context.Context, bucket, key string, r io.Reader, opts domain.ObjectOptions) (*domain.Object, error)
+	Download(ctx context.Context, bucket, key string, opts domain.ReadOptions) (io.ReadCloser, *domain.Object, error)
 	ListObjects(ctx context.Context, bucket string) ([]*domain.Object, error)
 	DeleteObject(ctx context.Context, bucket, key string) error
+	ListBuckets(ctx context.Context) ([]*domain.Bucket, error)
+
+	InitiateMultipartUpload(ctx context.Context, bucket, key string, opts domain.ObjectOptions) (*domain.MultipartUpload, error)
+	UploadPart(ctx context.Context, bucket, key string, uploadID uuid.UUID, partNumber int, r io.Reader) (*domain.Part, error)
+	ListParts(ctx context.Context, bucket, key string, uploadID uuid.UUID) ([]*domain.Part, error)
+	// CompleteMultipartUpload assembles the listed parts, in ascending part
+	// number order, and discards any other staged parts.
+	CompleteMultipartUpload(ctx context.Context, bucket, key string, uploadID uuid.UUID, parts []domain.CompletedPart) (*domain.Object, error)
+	AbortMultipartUpload(ctx context.Context, bucket, key string, uploadID uuid.UUID) error
+
+	// PresignURL mints a signed URL for the caller's object. A maxContentLength of 0 means no limit.
+	PresignURL(ctx context.Context, bucket, key, method string, expiresIn time.Duration, maxContentLength int64) (*domain.PresignedURL, error)
+	// VerifyPresignedURL checks the signature query parameters of a request and returns the grant it carries.
+	VerifyPresignedURL(ctx context.Context, method, bucket, key string, query url.Values) (*domain.PresignedURL, error)
+
+	CreateLifecycleRule(ctx context.Context, bucket string, rule domain.LifecycleRule) (*domain.LifecycleRule, error)
+	ListLifecycleRules(ctx context.Context, bucket string) ([]*domain.LifecycleRule, error)
+	DeleteLifecycleRule(ctx context.Context, bucket string, id uuid.UUID) error
+
+	PutBucketEncryption(ctx context.Context, bucket, algorithm string) (*domain.BucketEncryption, error)
+	GetBucketEncryption(ctx context.Context, bucket string) (*domain.BucketEncryption, error)
+	DeleteBucketEncryption(ctx context.Context, bucket string) error
+
+	PutBucketWebsite(ctx context.Context, bucket string, cfg domain.BucketWebsite) (*domain.BucketWebsite, error)
+	GetBucketWebsite(ctx context.Context, bucket string) (*domain.BucketWebsite, error)
+	DeleteBucketWebsite(ctx context.Context, bucket string) error
+	// ResolveWebsite returns the website served for a bucket name, for
+	// unauthenticated requests.
+	ResolveWebsite(ctx context.Context, bucket string) (*domain.BucketWebsite, error)
 }
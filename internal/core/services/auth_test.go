@@ -60,6 +60,13 @@ func (m *MockIdentityService) ValidateApiKey(ctx context.Context, key string) (*
 	}
 	return args.Get(0).(*domain.ApiKey), args.Error(1)
 }
+func (m *MockIdentityService) GetApiKeyByID(ctx context.Context, id uuid.UUID) (*domain.ApiKey, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ApiKey), args.Error(1)
+}
 
 func TestAuthService_Register_Success(t *testing.T) {
 	userRepo := new(MockUserRepo)
@@ -3,6 +3,9 @@ package services
 import (
 	"context"
 	"fmt"
+	"math"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,40 +13,73 @@ import (
 	"github.com/poyrazk/thecloud/internal/core/domain"
 	"github.com/poyrazk/thecloud/internal/core/ports"
 	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/poyrazk/thecloud/pkg/cron"
 )
 
 type AutoScalingService struct {
-	repo    ports.AutoScalingRepository
-	vpcRepo ports.VpcRepository
+	repo         ports.AutoScalingRepository
+	vpcRepo      ports.VpcRepository
+	templateRepo ports.LaunchTemplateRepository
+	lbSvc        ports.LBService
+	eventSvc     ports.EventService
+	clock        ports.Clock
 }
 
-func NewAutoScalingService(repo ports.AutoScalingRepository, vpcRepo ports.VpcRepository) *AutoScalingService {
+func NewAutoScalingService(repo ports.AutoScalingRepository, vpcRepo ports.VpcRepository, templateRepo ports.LaunchTemplateRepository, lbSvc ports.LBService, eventSvc ports.EventService, clock ports.Clock) *AutoScalingService {
 	return &AutoScalingService{
-		repo:    repo,
-		vpcRepo: vpcRepo,
+		repo:         repo,
+		vpcRepo:      vpcRepo,
+		templateRepo: templateRepo,
+		lbSvc:        lbSvc,
+		eventSvc:     eventSvc,
+		clock:        clock,
 	}
 }
 
-func (s *AutoScalingService) CreateGroup(ctx context.Context, name string, vpcID uuid.UUID, image string, ports string, min, max, desired int, lbID *uuid.UUID, idempotencyKey string) (*domain.ScalingGroup, error) {
+func (s *AutoScalingService) CreateGroup(ctx context.Context, params ports.CreateScalingGroupParams) (*domain.ScalingGroup, error) {
 	// Idempotency check
-	if idempotencyKey != "" {
-		if existing, err := s.repo.GetGroupByIdempotencyKey(ctx, idempotencyKey); err == nil && existing != nil {
+	if params.IdempotencyKey != "" {
+		if existing, err := s.repo.GetGroupByIdempotencyKey(ctx, params.IdempotencyKey); err == nil && existing != nil {
 			return existing, nil
 		}
 	}
 
 	// Validation
-	if max > domain.MaxInstancesHardLimit {
-		return nil, errors.New(errors.InvalidInput, fmt.Sprintf("max_instances cannot exceed %d", domain.MaxInstancesHardLimit))
+	min, max, desired := params.MinInstances, params.MaxInstances, params.DesiredCount
+	if err := validateGroupSize(min, max, desired); err != nil {
+		return nil, err
 	}
-	if min < 0 {
-		return nil, errors.New(errors.InvalidInput, "min_instances cannot be negative")
+
+	checkType, grace := params.HealthCheckType, domain.DefaultHealthCheckGracePeriodSec
+	if checkType == "" {
+		checkType = domain.ScalingHealthCheckContainer
 	}
-	if min > max {
-		return nil, errors.New(errors.InvalidInput, "min_instances cannot be greater than max_instances")
+	if params.HealthCheckGracePeriodSec != nil {
+		grace = *params.HealthCheckGracePeriodSec
 	}
-	if desired < min || desired > max {
-		return nil, errors.New(errors.InvalidInput, "desired_count must be between min and max instances")
+	if err := validateHealthCheck(checkType, grace, params.LoadBalancerID); err != nil {
+		return nil, err
+	}
+
+	vpcID, image, ports := params.VpcID, params.Image, params.Ports
+	var template *domain.LaunchTemplateVersion
+	if params.LaunchTemplateID != nil {
+		if image != "" || ports != "" {
+			return nil, errors.New(errors.InvalidInput, "image and ports come from the launch template")
+		}
+		var err error
+		if template, err = s.groupTemplate(ctx, *params.LaunchTemplateID, params.LaunchTemplateVersion, vpcID); err != nil {
+			return nil, err
+		}
+		if vpcID == uuid.Nil {
+			vpcID = *template.VpcID
+		}
+		image, ports = template.Image, template.Ports
+	} else if image == "" {
+		return nil, errors.New(errors.InvalidInput, "image or launch template is required")
+	}
+	if vpcID == uuid.Nil {
+		return nil, errors.New(errors.InvalidInput, "vpc_id is required")
 	}
 
 	// Check VPC exists
@@ -63,10 +99,10 @@ func (s *AutoScalingService) CreateGroup(ctx context.Context, name string, vpcID
 	group := &domain.ScalingGroup{
 		ID:             uuid.New(),
 		UserID:         appcontext.UserIDFromContext(ctx),
-		IdempotencyKey: idempotencyKey,
-		Name:           name,
+		IdempotencyKey: params.IdempotencyKey,
+		Name:           params.Name,
 		VpcID:          vpcID,
-		LoadBalancerID: lbID,
+		LoadBalancerID: params.LoadBalancerID,
 		Image:          image,
 		Ports:          ports,
 		MinInstances:   min,
@@ -75,8 +111,16 @@ func (s *AutoScalingService) CreateGroup(ctx context.Context, name string, vpcID
 		CurrentCount:   0, // Worker will spawn these
 		Status:         domain.ScalingGroupStatusActive,
 		Version:        1,
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
+		CreatedAt:      s.clock.Now(),
+		UpdatedAt:      s.clock.Now(),
+
+		HealthCheckType:           checkType,
+		HealthCheckGracePeriodSec: grace,
+	}
+
+	if template != nil {
+		group.LaunchTemplateID = &template.TemplateID
+		group.LaunchTemplateVersion = template.Version
 	}
 
 	if err := s.repo.CreateGroup(ctx, group); err != nil {
@@ -86,6 +130,65 @@ func (s *AutoScalingService) CreateGroup(ctx context.Context, name string, vpcID
 	return group, nil
 }
 
+// validateGroupSize checks the sizes of a group against each other and the
+// hard limits.
+func validateGroupSize(min, max, desired int) error {
+	if max > domain.MaxInstancesHardLimit {
+		return errors.New(errors.InvalidInput, fmt.Sprintf("max_instances cannot exceed %d", domain.MaxInstancesHardLimit))
+	}
+	if min < 0 {
+		return errors.New(errors.InvalidInput, "min_instances cannot be negative")
+	}
+	if min > max {
+		return errors.New(errors.InvalidInput, "min_instances cannot be greater than max_instances")
+	}
+	if desired < min || desired > max {
+		return errors.New(errors.InvalidInput, "desired_count must be between min and max instances")
+	}
+	return nil
+}
+
+// validateHealthCheck checks the health check settings of a group with the
+// given load balancer.
+func validateHealthCheck(checkType string, grace int, lbID *uuid.UUID) error {
+	switch checkType {
+	case domain.ScalingHealthCheckContainer:
+	case domain.ScalingHealthCheckLB:
+		if lbID == nil {
+			return errors.New(errors.InvalidInput, "lb health checks require a load balancer")
+		}
+	default:
+		return errors.New(errors.InvalidInput, fmt.Sprintf("health_check_type must be %q or %q", domain.ScalingHealthCheckContainer, domain.ScalingHealthCheckLB))
+	}
+	if grace < 0 || grace > domain.MaxHealthCheckGracePeriodSec {
+		return errors.New(errors.InvalidInput, fmt.Sprintf("health_check_grace_period_sec must be between 0 and %d", domain.MaxHealthCheckGracePeriodSec))
+	}
+	return nil
+}
+
+// groupTemplate fetches a launch template version for a group in the VPC,
+// which may be unset if the template names one.
+func (s *AutoScalingService) groupTemplate(ctx context.Context, templateID uuid.UUID, version int, vpcID uuid.UUID) (*domain.LaunchTemplateVersion, error) {
+	if version < 0 {
+		return nil, errors.New(errors.InvalidInput, "launch template version cannot be negative")
+	}
+	template, err := s.templateRepo.GetVersion(ctx, templateID, version)
+	if err != nil {
+		return nil, err
+	}
+	// A volume can only be attached to one instance at a time.
+	if len(template.Volumes) > 0 {
+		return nil, errors.New(errors.InvalidInput, "launch templates with volumes cannot be used by scaling groups")
+	}
+	switch {
+	case template.VpcID == nil && vpcID == uuid.Nil:
+		return nil, errors.New(errors.InvalidInput, "vpc_id is required when the launch template has no VPC")
+	case template.VpcID != nil && vpcID != uuid.Nil && *template.VpcID != vpcID:
+		return nil, errors.New(errors.InvalidInput, "launch template belongs to a different VPC than the group")
+	}
+	return template, nil
+}
+
 func (s *AutoScalingService) GetGroup(ctx context.Context, id uuid.UUID) (*domain.ScalingGroup, error) {
 	return s.repo.GetGroupByID(ctx, id)
 }
@@ -122,32 +225,745 @@ func (s *AutoScalingService) SetDesiredCapacity(ctx context.Context, groupID uui
 	return s.repo.UpdateGroup(ctx, group)
 }
 
-func (s *AutoScalingService) CreatePolicy(ctx context.Context, groupID uuid.UUID, name, metricType string, targetValue float64, scaleOut, scaleIn, cooldownSec int) (*domain.ScalingPolicy, error) {
-	if _, err := s.repo.GetGroupByID(ctx, groupID); err != nil {
+func (s *AutoScalingService) UpdateGroup(ctx context.Context, id uuid.UUID, params ports.UpdateScalingGroupParams) (*domain.ScalingGroup, error) {
+	group, err := s.repo.GetGroupByID(ctx, id)
+	if err != nil {
 		return nil, err
 	}
+	if group.Status == domain.ScalingGroupStatusDeleting {
+		return nil, errors.New(errors.Conflict, "scaling group is being deleted")
+	}
 
-	if cooldownSec < domain.MinCooldownSeconds {
-		return nil, errors.New(errors.InvalidInput, fmt.Sprintf("cooldown must be at least %d seconds", domain.MinCooldownSeconds))
+	if params.Name != nil {
+		if *params.Name == "" {
+			return nil, errors.New(errors.InvalidInput, "name cannot be empty")
+		}
+		group.Name = *params.Name
 	}
 
-	policy := &domain.ScalingPolicy{
-		ID:             uuid.New(),
-		ScalingGroupID: groupID,
-		Name:           name,
-		MetricType:     metricType,
-		TargetValue:    targetValue,
-		ScaleOutStep:   scaleOut,
-		ScaleInStep:    scaleIn,
-		CooldownSec:    cooldownSec,
+	if params.Image != nil && *params.Image != group.Image {
+		if group.LaunchTemplateID != nil {
+			return nil, errors.New(errors.InvalidInput, "image comes from the launch template")
+		}
+		if *params.Image == "" {
+			return nil, errors.New(errors.InvalidInput, "image cannot be empty")
+		}
+		// A refresh restores the configuration it started from if it fails.
+		if err := s.checkNoActiveRefresh(ctx, id); err != nil {
+			return nil, err
+		}
+		group.Image = *params.Image
+	}
+
+	min, max := group.MinInstances, group.MaxInstances
+	if params.MinInstances != nil {
+		min = *params.MinInstances
+	}
+	if params.MaxInstances != nil {
+		max = *params.MaxInstances
+	}
+	desired := clampDesired(&domain.ScalingGroup{MinInstances: min, MaxInstances: max}, group.DesiredCount)
+	if params.DesiredCount != nil {
+		desired = *params.DesiredCount
+	}
+	if err := validateGroupSize(min, max, desired); err != nil {
+		return nil, err
+	}
+	group.MinInstances, group.MaxInstances, group.DesiredCount = min, max, desired
+
+	if params.HealthCheckType != nil {
+		group.HealthCheckType = *params.HealthCheckType
+	}
+	if params.HealthCheckGracePeriodSec != nil {
+		group.HealthCheckGracePeriodSec = *params.HealthCheckGracePeriodSec
+	}
+	prev := group.LoadBalancerID
+	if params.LoadBalancerID != nil {
+		group.LoadBalancerID = nil
+		if *params.LoadBalancerID != uuid.Nil {
+			lbID := *params.LoadBalancerID
+			group.LoadBalancerID = &lbID
+		}
+	}
+	if err := validateHealthCheck(group.HealthCheckType, group.HealthCheckGracePeriodSec, group.LoadBalancerID); err != nil {
+		return nil, err
+	}
+	if !sameLB(prev, group.LoadBalancerID) {
+		if err := s.moveTargets(ctx, group, prev); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.UpdateGroupSettings(ctx, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func sameLB(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// moveTargets registers the instances of the group in service with its new
+// load balancer and deregisters them from the previous one. Both steps skip
+// what is already done, so a failed update can be retried.
+func (s *AutoScalingService) moveTargets(ctx context.Context, group *domain.ScalingGroup, prev *uuid.UUID) error {
+	if group.LoadBalancerID != nil {
+		lb, err := s.lbSvc.Get(ctx, *group.LoadBalancerID)
+		if err != nil {
+			return err
+		}
+		if lb.VpcID != group.VpcID {
+			return errors.New(errors.InvalidInput, "load balancer belongs to a different VPC than the group")
+		}
+	} else {
+		policies, err := s.repo.GetPoliciesForGroup(ctx, group.ID)
+		if err != nil {
+			return err
+		}
+		for _, policy := range policies {
+			if policy.MetricType == domain.ScalingMetricLBRequestCount {
+				return errors.New(errors.InvalidInput, fmt.Sprintf("policy %s scales on lb_request_count and needs a load balancer", policy.Name))
+			}
+		}
+	}
+
+	instanceIDs, err := s.instancesInService(ctx, group.ID)
+	if err != nil {
+		return err
+	}
+
+	if group.LoadBalancerID != nil {
+		targets, err := s.lbSvc.ListTargets(ctx, *group.LoadBalancerID)
+		if err != nil {
+			return err
+		}
+		registered := make(map[uuid.UUID]bool, len(targets))
+		for _, t := range targets {
+			registered[t.InstanceID] = true
+		}
+		for _, id := range instanceIDs {
+			if registered[id] {
+				continue
+			}
+			if err := s.lbSvc.AddTarget(ctx, *group.LoadBalancerID, id, 80, 1, ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	if prev != nil {
+		for _, id := range instanceIDs {
+			if _, err := s.lbSvc.RemoveTarget(ctx, *prev, id); err != nil && !errors.Is(err, errors.NotFound) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// instancesInService returns the instances of the group that are neither
+// draining nor awaiting a lifecycle action.
+func (s *AutoScalingService) instancesInService(ctx context.Context, groupID uuid.UUID) ([]uuid.UUID, error) {
+	groupIDs := []uuid.UUID{groupID}
+	instances, err := s.repo.GetAllScalingGroupInstances(ctx, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	waiting, err := s.repo.GetAllWaitingInstances(ctx, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	waitingIDs := make([]uuid.UUID, len(waiting[groupID]))
+	for i, inst := range waiting[groupID] {
+		waitingIDs[i] = inst.InstanceID
+	}
+	return subtractIDs(instances[groupID], waitingIDs), nil
+}
+
+func (s *AutoScalingService) SuspendProcesses(ctx context.Context, id uuid.UUID, processes []domain.ScalingProcess) (*domain.ScalingGroup, error) {
+	return s.setSuspendedProcesses(ctx, id, processes, true)
+}
+
+func (s *AutoScalingService) ResumeProcesses(ctx context.Context, id uuid.UUID, processes []domain.ScalingProcess) (*domain.ScalingGroup, error) {
+	return s.setSuspendedProcesses(ctx, id, processes, false)
+}
+
+func (s *AutoScalingService) setSuspendedProcesses(ctx context.Context, id uuid.UUID, processes []domain.ScalingProcess, suspend bool) (*domain.ScalingGroup, error) {
+	if len(processes) == 0 {
+		processes = domain.ScalingProcesses
+	}
+	for _, p := range processes {
+		if !slices.Contains(domain.ScalingProcesses, p) {
+			return nil, errors.New(errors.InvalidInput, fmt.Sprintf("unknown scaling process %q", p))
+		}
 	}
 
+	group, err := s.repo.GetGroupByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if group.Status == domain.ScalingGroupStatusDeleting {
+		return nil, errors.New(errors.Conflict, "scaling group is being deleted")
+	}
+
+	// Kept in the order of domain.ScalingProcesses.
+	var suspended []domain.ScalingProcess
+	for _, p := range domain.ScalingProcesses {
+		requested := slices.Contains(processes, p)
+		if (suspend && requested) || (group.IsSuspended(p) && !requested) {
+			suspended = append(suspended, p)
+		}
+	}
+	group.SuspendedProcesses = suspended
+	if err := s.repo.UpdateGroupSuspendedProcesses(ctx, group); err != nil {
+		return nil, err
+	}
+
+	event := "AUTOSCALING_PROCESSES_RESUMED"
+	if suspend {
+		event = "AUTOSCALING_PROCESSES_SUSPENDED"
+	}
+	_ = s.eventSvc.RecordEvent(ctx, event, group.ID.String(), "SCALING_GROUP", map[string]interface{}{
+		"processes": processes,
+	})
+	return group, nil
+}
+
+func (s *AutoScalingService) SetLaunchTemplate(ctx context.Context, groupID, templateID uuid.UUID, version int) (*domain.ScalingGroup, error) {
+	group, err := s.repo.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group.Status == domain.ScalingGroupStatusDeleting {
+		return nil, errors.New(errors.Conflict, "scaling group is being deleted")
+	}
+	// A refresh restores the configuration it started from if it fails.
+	if err := s.checkNoActiveRefresh(ctx, groupID); err != nil {
+		return nil, err
+	}
+
+	template, err := s.groupTemplate(ctx, templateID, version, group.VpcID)
+	if err != nil {
+		return nil, err
+	}
+
+	group.LaunchTemplateID = &template.TemplateID
+	group.LaunchTemplateVersion = template.Version
+	group.Image = template.Image
+	group.Ports = template.Ports
+	if err := s.repo.UpdateGroupLaunchTemplate(ctx, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (s *AutoScalingService) CreatePolicy(ctx context.Context, groupID uuid.UUID, policy *domain.ScalingPolicy) (*domain.ScalingPolicy, error) {
+	group, err := s.repo.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := normalizePolicy(policy); err != nil {
+		return nil, err
+	}
+	if err := validatePolicyMetric(group, policy.MetricType, policy.MetricName, policy.TargetValue); err != nil {
+		return nil, err
+	}
+
+	policy.ID = uuid.New()
+	policy.ScalingGroupID = groupID
+	policy.LastScaledAt = nil
+	policy.LastScaleOutAt = nil
+
 	if err := s.repo.CreatePolicy(ctx, policy); err != nil {
 		return nil, err
 	}
 	return policy, nil
 }
 
+// normalizePolicy fills in the defaults of a policy and validates everything
+// but its metric.
+func normalizePolicy(p *domain.ScalingPolicy) error {
+	if p.PolicyType == "" {
+		p.PolicyType = domain.ScalingPolicyTargetTracking
+	}
+	if p.EvaluationPeriodSec == 0 {
+		p.EvaluationPeriodSec = domain.DefaultEvaluationPeriodSeconds
+	}
+	if p.ScaleOutCooldownSec == 0 {
+		p.ScaleOutCooldownSec = domain.DefaultPolicyCooldownSeconds
+	}
+	if p.ScaleInCooldownSec == 0 {
+		p.ScaleInCooldownSec = domain.DefaultPolicyCooldownSeconds
+	}
+
+	if p.ScaleOutCooldownSec < domain.MinCooldownSeconds || p.ScaleInCooldownSec < domain.MinCooldownSeconds {
+		return errors.New(errors.InvalidInput, fmt.Sprintf("cooldown must be at least %d seconds", domain.MinCooldownSeconds))
+	}
+	// Request stats are kept per minute, so periods are whole minutes.
+	if p.EvaluationPeriodSec < 60 || p.EvaluationPeriodSec > domain.MaxEvaluationPeriodSeconds || p.EvaluationPeriodSec%60 != 0 {
+		return errors.New(errors.InvalidInput, fmt.Sprintf("evaluation period must be a whole number of minutes up to %d seconds", domain.MaxEvaluationPeriodSeconds))
+	}
+
+	switch p.PolicyType {
+	case domain.ScalingPolicyTargetTracking:
+		if len(p.Steps) > 0 {
+			return errors.New(errors.InvalidInput, "steps apply to step policies only")
+		}
+		if p.TargetValue <= 0 || math.IsInf(p.TargetValue, 0) || math.IsNaN(p.TargetValue) {
+			return errors.New(errors.InvalidInput, "target value must be positive")
+		}
+		if p.ScaleInThreshold == 0 {
+			p.ScaleInThreshold = p.TargetValue * domain.DefaultScaleInThresholdOfTarget
+		}
+		if p.ScaleInThreshold < 0 || p.ScaleInThreshold >= p.TargetValue {
+			return errors.New(errors.InvalidInput, "scale-in threshold must be below the target value")
+		}
+	case domain.ScalingPolicyStep:
+		if p.TargetValue != 0 || p.ScaleInThreshold != 0 {
+			return errors.New(errors.InvalidInput, "target value and scale-in threshold apply to target tracking policies only")
+		}
+		return validateSteps(p.Steps)
+	default:
+		return errors.New(errors.InvalidInput, fmt.Sprintf("unsupported policy type %q", p.PolicyType))
+	}
+	return nil
+}
+
+// validateSteps checks that the bands of a step policy are ordered and do not
+// overlap. Only the first band may be open below and only the last above.
+func validateSteps(steps []domain.ScalingStep) error {
+	if len(steps) == 0 || len(steps) > domain.MaxScalingSteps {
+		return errors.New(errors.InvalidInput, fmt.Sprintf("step policies need 1 to %d steps", domain.MaxScalingSteps))
+	}
+	for i, st := range steps {
+		if st.Adjustment == 0 {
+			return errors.New(errors.InvalidInput, "step adjustment cannot be 0")
+		}
+		if st.LowerBound != nil && st.UpperBound != nil && *st.LowerBound >= *st.UpperBound {
+			return errors.New(errors.InvalidInput, "step lower bound must be below its upper bound")
+		}
+		if i > 0 {
+			prev := steps[i-1]
+			if prev.UpperBound == nil || st.LowerBound == nil || *st.LowerBound < *prev.UpperBound {
+				return errors.New(errors.InvalidInput, "steps must be in ascending order and must not overlap")
+			}
+		}
+	}
+	return nil
+}
+
+func validatePolicyMetric(group *domain.ScalingGroup, metricType, metricName string, targetValue float64) error {
+	switch metricType {
+	case domain.ScalingMetricCPU, domain.ScalingMetricNetworkIn, domain.ScalingMetricNetworkOut:
+	case domain.ScalingMetricMemory:
+		if targetValue > 100 {
+			return errors.New(errors.InvalidInput, "memory target cannot exceed 100 percent")
+		}
+	case domain.ScalingMetricLBRequestCount:
+		if group.LoadBalancerID == nil {
+			return errors.New(errors.InvalidInput, "lb_request_count policies need a group with a load balancer")
+		}
+	case domain.ScalingMetricCustom:
+		if !customMetricName.MatchString(metricName) {
+			return errors.New(errors.InvalidInput, "custom policies need a valid metric_name")
+		}
+	default:
+		return errors.New(errors.InvalidInput, fmt.Sprintf("unsupported metric type %q", metricType))
+	}
+
+	if metricType != domain.ScalingMetricCustom && metricName != "" {
+		return errors.New(errors.InvalidInput, "metric_name is only valid for custom policies")
+	}
+	return nil
+}
+
 func (s *AutoScalingService) DeletePolicy(ctx context.Context, id uuid.UUID) error {
 	return s.repo.DeletePolicy(ctx, id)
 }
+
+func (s *AutoScalingService) CreateScheduledAction(ctx context.Context, groupID uuid.UUID, action *domain.ScheduledAction) (*domain.ScheduledAction, error) {
+	if _, err := s.repo.GetGroupByID(ctx, groupID); err != nil {
+		return nil, err
+	}
+
+	if action.TimeZone == "" {
+		action.TimeZone = "UTC"
+	}
+	loc, err := time.LoadLocation(action.TimeZone)
+	if err != nil {
+		return nil, errors.New(errors.InvalidInput, fmt.Sprintf("unknown time zone %q", action.TimeZone))
+	}
+	schedule, err := cron.Parse(action.Schedule)
+	if err != nil {
+		return nil, errors.New(errors.InvalidInput, err.Error())
+	}
+	if err := validateScheduledSizes(action); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.ListScheduledActions(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= domain.MaxScheduledActions {
+		return nil, errors.New(errors.ResourceLimitExceeded, fmt.Sprintf("group already has %d scheduled actions (max: %d)", len(existing), domain.MaxScheduledActions))
+	}
+
+	now := s.clock.Now()
+	next := schedule.Next(now.In(loc))
+	if next.IsZero() {
+		return nil, errors.New(errors.InvalidInput, "schedule never matches a date")
+	}
+
+	action.ID = uuid.New()
+	action.ScalingGroupID = groupID
+	action.NextRunAt = next
+	action.LastRunAt = nil
+	action.CreatedAt = now
+
+	if err := s.repo.CreateScheduledAction(ctx, action); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// validateScheduledSizes checks the sizes an action sets against each other
+// and the hard limit. How they fit the sizes it leaves alone is only known
+// when it runs.
+func validateScheduledSizes(action *domain.ScheduledAction) error {
+	if action.MinInstances == nil && action.MaxInstances == nil && action.DesiredCount == nil {
+		return errors.New(errors.InvalidInput, "scheduled action must set min_instances, max_instances or desired_count")
+	}
+	for _, size := range []*int{action.MinInstances, action.MaxInstances, action.DesiredCount} {
+		if size != nil && (*size < 0 || *size > domain.MaxInstancesHardLimit) {
+			return errors.New(errors.InvalidInput, fmt.Sprintf("instance counts must be between 0 and %d", domain.MaxInstancesHardLimit))
+		}
+	}
+	if action.MinInstances != nil && action.MaxInstances != nil && *action.MinInstances > *action.MaxInstances {
+		return errors.New(errors.InvalidInput, "min_instances cannot be greater than max_instances")
+	}
+	if action.DesiredCount != nil {
+		if (action.MinInstances != nil && *action.DesiredCount < *action.MinInstances) ||
+			(action.MaxInstances != nil && *action.DesiredCount > *action.MaxInstances) {
+			return errors.New(errors.InvalidInput, "desired_count must be between min and max instances")
+		}
+	}
+	return nil
+}
+
+func (s *AutoScalingService) ListScheduledActions(ctx context.Context, groupID uuid.UUID) ([]*domain.ScheduledAction, error) {
+	if _, err := s.repo.GetGroupByID(ctx, groupID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListScheduledActions(ctx, groupID)
+}
+
+func (s *AutoScalingService) DeleteScheduledAction(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteScheduledAction(ctx, id)
+}
+
+func (s *AutoScalingService) CreateLifecycleHook(ctx context.Context, groupID uuid.UUID, hook *domain.LifecycleHook) (*domain.LifecycleHook, error) {
+	if _, err := s.repo.GetGroupByID(ctx, groupID); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(hook.Name) == "" {
+		return nil, errors.New(errors.InvalidInput, "lifecycle hook name is required")
+	}
+	switch hook.Transition {
+	case domain.LifecycleTransitionLaunching, domain.LifecycleTransitionTerminating:
+	default:
+		return nil, errors.New(errors.InvalidInput, "transition must be LAUNCHING or TERMINATING")
+	}
+	if hook.HeartbeatTimeoutSec == 0 {
+		hook.HeartbeatTimeoutSec = domain.DefaultLifecycleHeartbeatTimeoutSec
+	}
+	if hook.HeartbeatTimeoutSec < domain.MinLifecycleHeartbeatTimeoutSec || hook.HeartbeatTimeoutSec > domain.MaxLifecycleHeartbeatTimeoutSec {
+		return nil, errors.New(errors.InvalidInput, fmt.Sprintf("heartbeat timeout must be between %d and %d seconds",
+			domain.MinLifecycleHeartbeatTimeoutSec, domain.MaxLifecycleHeartbeatTimeoutSec))
+	}
+	if hook.DefaultResult == "" {
+		hook.DefaultResult = domain.LifecycleActionAbandon
+	}
+	if err := validateLifecycleResult(hook.DefaultResult); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.ListLifecycleHooks(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range existing {
+		if h.Transition == hook.Transition {
+			return nil, errors.New(errors.Conflict, fmt.Sprintf("group already has the %s hook %s", h.Transition, h.Name))
+		}
+		if h.Name == hook.Name {
+			return nil, errors.New(errors.Conflict, fmt.Sprintf("lifecycle hook %s already exists", h.Name))
+		}
+	}
+
+	hook.ID = uuid.New()
+	hook.ScalingGroupID = groupID
+	hook.CreatedAt = s.clock.Now()
+	if err := s.repo.CreateLifecycleHook(ctx, hook); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func validateLifecycleResult(result domain.LifecycleActionResult) error {
+	switch result {
+	case domain.LifecycleActionContinue, domain.LifecycleActionAbandon:
+		return nil
+	}
+	return errors.New(errors.InvalidInput, "result must be CONTINUE or ABANDON")
+}
+
+func (s *AutoScalingService) ListLifecycleHooks(ctx context.Context, groupID uuid.UUID) ([]*domain.LifecycleHook, error) {
+	if _, err := s.repo.GetGroupByID(ctx, groupID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListLifecycleHooks(ctx, groupID)
+}
+
+// DeleteLifecycleHook removes the hook. Instances still waiting on it carry
+// on as if the action had been completed with CONTINUE.
+func (s *AutoScalingService) DeleteLifecycleHook(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteLifecycleHook(ctx, id)
+}
+
+// CompleteLifecycleAction records the result; the worker launches or
+// terminates the instance on its next tick.
+func (s *AutoScalingService) CompleteLifecycleAction(ctx context.Context, groupID, instanceID uuid.UUID, result domain.LifecycleActionResult) error {
+	if err := validateLifecycleResult(result); err != nil {
+		return err
+	}
+	if _, err := s.repo.GetGroupByID(ctx, groupID); err != nil {
+		return err
+	}
+	if err := s.repo.CompleteLifecycleAction(ctx, groupID, instanceID, result); err != nil {
+		return err
+	}
+
+	_ = s.eventSvc.RecordEvent(ctx, "AUTOSCALING_LIFECYCLE_ACTION_COMPLETED", groupID.String(), "SCALING_GROUP", map[string]interface{}{
+		"instance_id": instanceID.String(),
+		"result":      string(result),
+	})
+	return nil
+}
+
+// Bounds of the number of activities returned at once.
+const (
+	defaultActivitiesLimit = 50
+	maxActivitiesLimit     = 500
+)
+
+func (s *AutoScalingService) ListActivities(ctx context.Context, groupID uuid.UUID, limit int) ([]*domain.ScalingActivity, error) {
+	if limit == 0 {
+		limit = defaultActivitiesLimit
+	}
+	if limit < 0 || limit > maxActivitiesLimit {
+		return nil, errors.New(errors.InvalidInput, fmt.Sprintf("limit must be between 1 and %d", maxActivitiesLimit))
+	}
+	if _, err := s.repo.GetGroupByID(ctx, groupID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListActivities(ctx, groupID, limit)
+}
+
+func (s *AutoScalingService) StartInstanceRefresh(ctx context.Context, groupID uuid.UUID, params ports.StartInstanceRefreshParams) (*domain.InstanceRefresh, error) {
+	group, err := s.repo.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group.Status == domain.ScalingGroupStatusDeleting {
+		return nil, errors.New(errors.Conflict, "scaling group is being deleted")
+	}
+
+	minHealthy := domain.DefaultMinHealthyPercentage
+	if params.MinHealthyPercentage != nil {
+		minHealthy = *params.MinHealthyPercentage
+	}
+	if minHealthy < 0 || minHealthy > 100 {
+		return nil, errors.New(errors.InvalidInput, "min_healthy_percentage must be between 0 and 100")
+	}
+	timeout := params.HealthCheckTimeoutSec
+	if timeout == 0 {
+		timeout = domain.DefaultRefreshHealthCheckTimeoutSec
+	}
+	if timeout < domain.MinRefreshHealthCheckTimeoutSec || timeout > domain.MaxRefreshHealthCheckTimeoutSec {
+		return nil, errors.New(errors.InvalidInput, fmt.Sprintf("health_check_timeout_sec must be between %d and %d", domain.MinRefreshHealthCheckTimeoutSec, domain.MaxRefreshHealthCheckTimeoutSec))
+	}
+
+	if err := s.checkNoActiveRefresh(ctx, groupID); err != nil {
+		return nil, err
+	}
+	cfg, err := s.refreshLaunchConfig(ctx, group, params)
+	if err != nil {
+		return nil, err
+	}
+
+	instances, err := s.repo.GetAllScalingGroupInstances(ctx, []uuid.UUID{group.ID})
+	if err != nil {
+		return nil, err
+	}
+	pending := instances[group.ID]
+
+	now := s.clock.Now()
+	refresh := &domain.InstanceRefresh{
+		ID:                    uuid.New(),
+		ScalingGroupID:        group.ID,
+		Status:                domain.InstanceRefreshInProgress,
+		MinHealthyPercentage:  minHealthy,
+		HealthCheckTimeoutSec: timeout,
+		InstancesToReplace:    len(pending),
+		PendingInstanceIDs:    pending,
+		StartedAt:             now,
+		UpdatedAt:             now,
+	}
+	if len(pending) == 0 {
+		refresh.Status = domain.InstanceRefreshSuccessful
+		refresh.StatusReason = "no instances to replace"
+		refresh.EndedAt = &now
+	}
+	if refresh.LaunchesFirst(group.DesiredCount) && group.DesiredCount >= group.MaxInstances {
+		return nil, errors.New(errors.InvalidInput, fmt.Sprintf(
+			"min_healthy_percentage %d keeps every instance in service, so replacements launch above the desired count, but the group is at its max_instances of %d; lower min_healthy_percentage or raise max_instances",
+			minHealthy, group.MaxInstances))
+	}
+
+	if cfg != nil {
+		previous := group.LaunchConfig()
+		refresh.RollbackConfig = &previous
+		group.SetLaunchConfig(*cfg)
+		if err := s.repo.UpdateGroupLaunchTemplate(ctx, group); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.CreateInstanceRefresh(ctx, refresh); err != nil {
+		if cfg != nil {
+			group.SetLaunchConfig(*refresh.RollbackConfig)
+			_ = s.repo.UpdateGroupLaunchTemplate(ctx, group)
+		}
+		return nil, err
+	}
+
+	_ = s.eventSvc.RecordEvent(ctx, "AUTOSCALING_REFRESH_STARTED", group.ID.String(), "SCALING_GROUP", map[string]interface{}{
+		"refresh_id":             refresh.ID.String(),
+		"instances_to_replace":   refresh.InstancesToReplace,
+		"min_healthy_percentage": refresh.MinHealthyPercentage,
+	})
+	return refresh, nil
+}
+
+func (s *AutoScalingService) checkNoActiveRefresh(ctx context.Context, groupID uuid.UUID) error {
+	latest, err := s.repo.GetLatestInstanceRefresh(ctx, groupID)
+	if err != nil {
+		if errors.Is(err, errors.NotFound) {
+			return nil
+		}
+		return err
+	}
+	if !latest.Status.Ended() {
+		return errors.New(errors.Conflict, "scaling group has an instance refresh in progress")
+	}
+	return nil
+}
+
+// refreshLaunchConfig resolves the launch configuration a refresh moves the
+// group to, or nil to keep the group's.
+func (s *AutoScalingService) refreshLaunchConfig(ctx context.Context, group *domain.ScalingGroup, params ports.StartInstanceRefreshParams) (*domain.LaunchConfig, error) {
+	image := strings.TrimSpace(params.Image)
+	switch {
+	case params.LaunchTemplateID != nil && image != "":
+		return nil, errors.New(errors.InvalidInput, "give either a launch template or an image")
+	case params.LaunchTemplateID != nil:
+		template, err := s.groupTemplate(ctx, *params.LaunchTemplateID, params.LaunchTemplateVersion, group.VpcID)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.LaunchConfig{
+			LaunchTemplateID:      &template.TemplateID,
+			LaunchTemplateVersion: template.Version,
+			Image:                 template.Image,
+			Ports:                 template.Ports,
+		}, nil
+	case image != "":
+		if group.LaunchTemplateID != nil {
+			return nil, errors.New(errors.InvalidInput, "scaling group launches from a launch template; give a template version instead of an image")
+		}
+		return &domain.LaunchConfig{Image: image, Ports: group.Ports}, nil
+	case params.LaunchTemplateVersion != 0:
+		return nil, errors.New(errors.InvalidInput, "launch_template_version requires launch_template_id")
+	}
+	return nil, nil
+}
+
+func (s *AutoScalingService) GetInstanceRefresh(ctx context.Context, groupID uuid.UUID) (*domain.InstanceRefresh, error) {
+	return s.repo.GetLatestInstanceRefresh(ctx, groupID)
+}
+
+func (s *AutoScalingService) PauseInstanceRefresh(ctx context.Context, groupID uuid.UUID) (*domain.InstanceRefresh, error) {
+	return s.updateInstanceRefresh(ctx, groupID, "AUTOSCALING_REFRESH_PAUSED", func(refresh *domain.InstanceRefresh) error {
+		if refresh.Status != domain.InstanceRefreshInProgress {
+			return errors.New(errors.Conflict, fmt.Sprintf("only a refresh in progress can be paused, this one is %s", refresh.Status))
+		}
+		refresh.Status = domain.InstanceRefreshPaused
+		return nil
+	})
+}
+
+func (s *AutoScalingService) ResumeInstanceRefresh(ctx context.Context, groupID uuid.UUID) (*domain.InstanceRefresh, error) {
+	return s.updateInstanceRefresh(ctx, groupID, "AUTOSCALING_REFRESH_RESUMED", func(refresh *domain.InstanceRefresh) error {
+		if refresh.Status != domain.InstanceRefreshPaused {
+			return errors.New(errors.Conflict, fmt.Sprintf("only a paused refresh can be resumed, this one is %s", refresh.Status))
+		}
+		refresh.Status = domain.InstanceRefreshInProgress
+		// The replacements of the current batch get the full timeout again.
+		if refresh.BatchStartedAt != nil {
+			now := s.clock.Now()
+			refresh.BatchStartedAt = &now
+		}
+		return nil
+	})
+}
+
+func (s *AutoScalingService) CancelInstanceRefresh(ctx context.Context, groupID uuid.UUID) (*domain.InstanceRefresh, error) {
+	return s.updateInstanceRefresh(ctx, groupID, "AUTOSCALING_REFRESH_CANCELLED", func(refresh *domain.InstanceRefresh) error {
+		if refresh.Status.Ended() {
+			return errors.New(errors.Conflict, fmt.Sprintf("instance refresh already ended as %s", refresh.Status))
+		}
+		now := s.clock.Now()
+		refresh.Status = domain.InstanceRefreshCancelled
+		refresh.StatusReason = "cancelled by user"
+		refresh.BatchStartedAt = nil
+		refresh.EndedAt = &now
+		return nil
+	})
+}
+
+// updateInstanceRefresh applies a status change to the latest refresh of the
+// group. It fails with Conflict if the worker changed the refresh meanwhile.
+func (s *AutoScalingService) updateInstanceRefresh(ctx context.Context, groupID uuid.UUID, event string, apply func(*domain.InstanceRefresh) error) (*domain.InstanceRefresh, error) {
+	refresh, err := s.repo.GetLatestInstanceRefresh(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	from := refresh.Status
+	if err := apply(refresh); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateInstanceRefresh(ctx, refresh, from); err != nil {
+		return nil, err
+	}
+
+	_ = s.eventSvc.RecordEvent(ctx, event, groupID.String(), "SCALING_GROUP", map[string]interface{}{
+		"refresh_id": refresh.ID.String(),
+	})
+	return refresh, nil
+}
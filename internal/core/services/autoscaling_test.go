@@ -3,33 +3,37 @@ package services_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
 	"github.com/poyrazk/thecloud/internal/core/services"
+	"github.com/poyrazk/thecloud/internal/errors"
 )
 
 func TestCreateGroup_SecurityLimits(t *testing.T) {
 	mockRepo := new(MockAutoScalingRepo)
 	mockVpcRepo := new(MockVpcRepo)
-	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo)
+	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo, new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{})
 	ctx := context.Background()
 	vpcID := uuid.New()
 
 	mockVpcRepo.On("GetByID", ctx, vpcID).Return(&domain.VPC{ID: vpcID}, nil)
 
 	t.Run("ExceedsMaxInstances", func(t *testing.T) {
-		_, err := svc.CreateGroup(ctx, "test", vpcID, "img", "80:80", 1, 1000, 1, nil, "")
+		_, err := svc.CreateGroup(ctx, ports.CreateScalingGroupParams{Name: "test", VpcID: vpcID, Image: "img", Ports: "80:80", MinInstances: 1, MaxInstances: 1000, DesiredCount: 1})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "max_instances cannot exceed")
 	})
 
 	t.Run("ExceedsVPCLimit", func(t *testing.T) {
 		mockRepo.On("CountGroupsByVPC", ctx, vpcID).Return(10, nil)
-		_, err := svc.CreateGroup(ctx, "test", vpcID, "img", "80:80", 1, 5, 1, nil, "")
+		_, err := svc.CreateGroup(ctx, ports.CreateScalingGroupParams{Name: "test", VpcID: vpcID, Image: "img", Ports: "80:80", MinInstances: 1, MaxInstances: 5, DesiredCount: 1})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "VPC already has")
 	})
@@ -38,7 +42,7 @@ func TestCreateGroup_SecurityLimits(t *testing.T) {
 func TestCreateGroup_Success(t *testing.T) {
 	mockRepo := new(MockAutoScalingRepo)
 	mockVpcRepo := new(MockVpcRepo)
-	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo)
+	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo, new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{})
 	ctx := context.Background()
 	vpcID := uuid.New()
 
@@ -46,7 +50,7 @@ func TestCreateGroup_Success(t *testing.T) {
 	mockRepo.On("CountGroupsByVPC", ctx, vpcID).Return(0, nil)
 	mockRepo.On("CreateGroup", ctx, mock.AnythingOfType("*domain.ScalingGroup")).Return(nil)
 
-	group, err := svc.CreateGroup(ctx, "my-asg", vpcID, "nginx", "80:80", 1, 5, 2, nil, "")
+	group, err := svc.CreateGroup(ctx, ports.CreateScalingGroupParams{Name: "my-asg", VpcID: vpcID, Image: "nginx", Ports: "80:80", MinInstances: 1, MaxInstances: 5, DesiredCount: 2})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, group)
@@ -54,20 +58,22 @@ func TestCreateGroup_Success(t *testing.T) {
 	assert.Equal(t, 1, group.MinInstances)
 	assert.Equal(t, 5, group.MaxInstances)
 	assert.Equal(t, 2, group.DesiredCount)
+	assert.Equal(t, domain.ScalingHealthCheckContainer, group.HealthCheckType)
+	assert.Equal(t, domain.DefaultHealthCheckGracePeriodSec, group.HealthCheckGracePeriodSec)
 	mockRepo.AssertExpectations(t)
 }
 
 func TestCreateGroup_Idempotency(t *testing.T) {
 	mockRepo := new(MockAutoScalingRepo)
 	mockVpcRepo := new(MockVpcRepo)
-	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo)
+	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo, new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{})
 	ctx := context.Background()
 	vpcID := uuid.New()
 
 	existingGroup := &domain.ScalingGroup{ID: uuid.New(), Name: "existing", IdempotencyKey: "key123"}
 	mockRepo.On("GetGroupByIdempotencyKey", ctx, "key123").Return(existingGroup, nil)
 
-	group, err := svc.CreateGroup(ctx, "new-name", vpcID, "nginx", "80:80", 1, 5, 2, nil, "key123")
+	group, err := svc.CreateGroup(ctx, ports.CreateScalingGroupParams{Name: "new-name", VpcID: vpcID, Image: "nginx", Ports: "80:80", MinInstances: 1, MaxInstances: 5, DesiredCount: 2, IdempotencyKey: "key123"})
 
 	assert.NoError(t, err)
 	assert.Equal(t, existingGroup.ID, group.ID)
@@ -77,33 +83,43 @@ func TestCreateGroup_Idempotency(t *testing.T) {
 func TestCreateGroup_ValidationErrors(t *testing.T) {
 	mockRepo := new(MockAutoScalingRepo)
 	mockVpcRepo := new(MockVpcRepo)
-	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo)
+	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo, new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{})
 	ctx := context.Background()
 	vpcID := uuid.New()
 
 	t.Run("NegativeMin", func(t *testing.T) {
-		_, err := svc.CreateGroup(ctx, "test", vpcID, "img", "", -1, 5, 1, nil, "")
+		_, err := svc.CreateGroup(ctx, ports.CreateScalingGroupParams{Name: "test", VpcID: vpcID, Image: "img", MinInstances: -1, MaxInstances: 5, DesiredCount: 1})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "cannot be negative")
 	})
 
 	t.Run("MinGreaterThanMax", func(t *testing.T) {
-		_, err := svc.CreateGroup(ctx, "test", vpcID, "img", "", 5, 2, 3, nil, "")
+		_, err := svc.CreateGroup(ctx, ports.CreateScalingGroupParams{Name: "test", VpcID: vpcID, Image: "img", MinInstances: 5, MaxInstances: 2, DesiredCount: 3})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "cannot be greater than max")
 	})
 
 	t.Run("DesiredOutOfRange", func(t *testing.T) {
-		_, err := svc.CreateGroup(ctx, "test", vpcID, "img", "", 2, 5, 10, nil, "")
+		_, err := svc.CreateGroup(ctx, ports.CreateScalingGroupParams{Name: "test", VpcID: vpcID, Image: "img", MinInstances: 2, MaxInstances: 5, DesiredCount: 10})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "between min and max")
 	})
+
+	t.Run("LBHealthCheckWithoutLoadBalancer", func(t *testing.T) {
+		_, err := svc.CreateGroup(ctx, ports.CreateScalingGroupParams{Name: "test", VpcID: vpcID, Image: "img", MaxInstances: 5, DesiredCount: 1, HealthCheckType: domain.ScalingHealthCheckLB})
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+	})
+
+	t.Run("UnknownHealthCheck", func(t *testing.T) {
+		_, err := svc.CreateGroup(ctx, ports.CreateScalingGroupParams{Name: "test", VpcID: vpcID, Image: "img", MaxInstances: 5, DesiredCount: 1, HealthCheckType: "http"})
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+	})
 }
 
 func TestDeleteGroup_Success(t *testing.T) {
 	mockRepo := new(MockAutoScalingRepo)
 	mockVpcRepo := new(MockVpcRepo)
-	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo)
+	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo, new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{})
 	ctx := context.Background()
 	groupID := uuid.New()
 
@@ -122,7 +138,7 @@ func TestDeleteGroup_Success(t *testing.T) {
 func TestSetDesiredCapacity_Success(t *testing.T) {
 	mockRepo := new(MockAutoScalingRepo)
 	mockVpcRepo := new(MockVpcRepo)
-	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo)
+	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo, new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{})
 	ctx := context.Background()
 	groupID := uuid.New()
 
@@ -141,7 +157,7 @@ func TestSetDesiredCapacity_Success(t *testing.T) {
 func TestSetDesiredCapacity_OutOfRange(t *testing.T) {
 	mockRepo := new(MockAutoScalingRepo)
 	mockVpcRepo := new(MockVpcRepo)
-	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo)
+	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo, new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{})
 	ctx := context.Background()
 	groupID := uuid.New()
 
@@ -154,44 +170,400 @@ func TestSetDesiredCapacity_OutOfRange(t *testing.T) {
 	assert.Contains(t, err.Error(), "must be between")
 }
 
+func TestUpdateGroup(t *testing.T) {
+	ctx := context.Background()
+	vpcID := uuid.New()
+	groupID := uuid.New()
+	intPtr := func(v int) *int { return &v }
+	strPtr := func(v string) *string { return &v }
+
+	setup := func(group *domain.ScalingGroup) (*services.AutoScalingService, *MockAutoScalingRepo, *MockLBService) {
+		mockRepo := new(MockAutoScalingRepo)
+		lbSvc := new(MockLBService)
+		if group.HealthCheckType == "" {
+			group.HealthCheckType = domain.ScalingHealthCheckContainer
+		}
+		mockRepo.On("GetGroupByID", ctx, groupID).Return(group, nil)
+		return services.NewAutoScalingService(mockRepo, new(MockVpcRepo), new(MockLaunchTemplateRepo), lbSvc, new(MockEventService), ports.RealClock{}), mockRepo, lbSvc
+	}
+
+	t.Run("ChangesNameAndSizes", func(t *testing.T) {
+		svc, mockRepo, _ := setup(&domain.ScalingGroup{ID: groupID, VpcID: vpcID, Name: "web", Image: "nginx", MinInstances: 1, MaxInstances: 5, DesiredCount: 4})
+		mockRepo.On("UpdateGroupSettings", ctx, mock.AnythingOfType("*domain.ScalingGroup")).Return(nil)
+
+		group, err := svc.UpdateGroup(ctx, groupID, ports.UpdateScalingGroupParams{Name: strPtr("api"), MinInstances: intPtr(2), MaxInstances: intPtr(3)})
+
+		require.NoError(t, err)
+		assert.Equal(t, "api", group.Name)
+		assert.Equal(t, 2, group.MinInstances)
+		assert.Equal(t, 3, group.MaxInstances)
+		// The desired count moves into the new bounds.
+		assert.Equal(t, 3, group.DesiredCount)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("EnforcesHardLimit", func(t *testing.T) {
+		svc, mockRepo, _ := setup(&domain.ScalingGroup{ID: groupID, VpcID: vpcID, MinInstances: 1, MaxInstances: 5, DesiredCount: 1})
+
+		_, err := svc.UpdateGroup(ctx, groupID, ports.UpdateScalingGroupParams{MaxInstances: intPtr(domain.MaxInstancesHardLimit + 1)})
+
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+		mockRepo.AssertNotCalled(t, "UpdateGroupSettings", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RejectsImageOfTemplateGroup", func(t *testing.T) {
+		templateID := uuid.New()
+		svc, _, _ := setup(&domain.ScalingGroup{ID: groupID, VpcID: vpcID, Image: "nginx", LaunchTemplateID: &templateID, MaxInstances: 1})
+
+		_, err := svc.UpdateGroup(ctx, groupID, ports.UpdateScalingGroupParams{Image: strPtr("httpd")})
+
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+	})
+
+	t.Run("RejectsDeletingGroup", func(t *testing.T) {
+		svc, _, _ := setup(&domain.ScalingGroup{ID: groupID, Status: domain.ScalingGroupStatusDeleting})
+
+		_, err := svc.UpdateGroup(ctx, groupID, ports.UpdateScalingGroupParams{Name: strPtr("api")})
+
+		assert.True(t, errors.Is(err, errors.Conflict))
+	})
+
+	t.Run("MovesInstancesToNewLoadBalancer", func(t *testing.T) {
+		oldLB, newLB := uuid.New(), uuid.New()
+		inst1, inst2, pending := uuid.New(), uuid.New(), uuid.New()
+		svc, mockRepo, lbSvc := setup(&domain.ScalingGroup{ID: groupID, VpcID: vpcID, LoadBalancerID: &oldLB, MinInstances: 1, MaxInstances: 5, DesiredCount: 3})
+
+		lbSvc.On("Get", ctx, newLB).Return(&domain.LoadBalancer{ID: newLB, VpcID: vpcID}, nil)
+		mockRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: {inst1, inst2, pending}}, nil)
+		mockRepo.On("GetAllWaitingInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{
+			groupID: {{InstanceID: pending, LifecycleState: domain.LifecycleStatePendingWait}},
+		}, nil)
+		// inst1 made it over on an earlier, failed attempt.
+		lbSvc.On("ListTargets", ctx, newLB).Return([]*domain.LBTarget{{InstanceID: inst1}}, nil)
+		lbSvc.On("AddTarget", ctx, newLB, inst2, 80, 1, "").Return(nil).Once()
+		lbSvc.On("RemoveTarget", ctx, oldLB, inst1).Return(time.Time{}, errors.New(errors.NotFound, "target not found"))
+		lbSvc.On("RemoveTarget", ctx, oldLB, inst2).Return(time.Now().Add(time.Minute), nil)
+		mockRepo.On("UpdateGroupSettings", ctx, mock.MatchedBy(func(g *domain.ScalingGroup) bool {
+			return g.LoadBalancerID != nil && *g.LoadBalancerID == newLB
+		})).Return(nil)
+
+		_, err := svc.UpdateGroup(ctx, groupID, ports.UpdateScalingGroupParams{LoadBalancerID: &newLB})
+
+		require.NoError(t, err)
+		lbSvc.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("RejectsLoadBalancerInOtherVPC", func(t *testing.T) {
+		lbID := uuid.New()
+		svc, mockRepo, lbSvc := setup(&domain.ScalingGroup{ID: groupID, VpcID: vpcID, MaxInstances: 1})
+		lbSvc.On("Get", ctx, lbID).Return(&domain.LoadBalancer{ID: lbID, VpcID: uuid.New()}, nil)
+
+		_, err := svc.UpdateGroup(ctx, groupID, ports.UpdateScalingGroupParams{LoadBalancerID: &lbID})
+
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+		mockRepo.AssertNotCalled(t, "UpdateGroupSettings", mock.Anything, mock.Anything)
+	})
+
+	t.Run("KeepsLoadBalancerForRequestCountPolicies", func(t *testing.T) {
+		lbID := uuid.New()
+		svc, mockRepo, _ := setup(&domain.ScalingGroup{ID: groupID, VpcID: vpcID, LoadBalancerID: &lbID, MaxInstances: 1})
+		mockRepo.On("GetPoliciesForGroup", ctx, groupID).Return([]*domain.ScalingPolicy{{Name: "rps", MetricType: domain.ScalingMetricLBRequestCount}}, nil)
+		detach := uuid.Nil
+
+		_, err := svc.UpdateGroup(ctx, groupID, ports.UpdateScalingGroupParams{LoadBalancerID: &detach})
+
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+		mockRepo.AssertNotCalled(t, "UpdateGroupSettings", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ChangesHealthCheck", func(t *testing.T) {
+		lbID := uuid.New()
+		svc, mockRepo, _ := setup(&domain.ScalingGroup{ID: groupID, VpcID: vpcID, LoadBalancerID: &lbID, MaxInstances: 1})
+		mockRepo.On("UpdateGroupSettings", ctx, mock.AnythingOfType("*domain.ScalingGroup")).Return(nil)
+
+		group, err := svc.UpdateGroup(ctx, groupID, ports.UpdateScalingGroupParams{HealthCheckType: strPtr(domain.ScalingHealthCheckLB), HealthCheckGracePeriodSec: intPtr(60)})
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.ScalingHealthCheckLB, group.HealthCheckType)
+		assert.Equal(t, 60, group.HealthCheckGracePeriodSec)
+	})
+
+	t.Run("KeepsLoadBalancerForLBHealthCheck", func(t *testing.T) {
+		lbID := uuid.New()
+		svc, mockRepo, lbSvc := setup(&domain.ScalingGroup{ID: groupID, VpcID: vpcID, LoadBalancerID: &lbID, MaxInstances: 1, HealthCheckType: domain.ScalingHealthCheckLB})
+		detach := uuid.Nil
+
+		_, err := svc.UpdateGroup(ctx, groupID, ports.UpdateScalingGroupParams{LoadBalancerID: &detach})
+
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+		lbSvc.AssertNotCalled(t, "RemoveTarget", mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "UpdateGroupSettings", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RejectsLongGracePeriod", func(t *testing.T) {
+		svc, mockRepo, _ := setup(&domain.ScalingGroup{ID: groupID, VpcID: vpcID, MaxInstances: 1})
+
+		_, err := svc.UpdateGroup(ctx, groupID, ports.UpdateScalingGroupParams{HealthCheckGracePeriodSec: intPtr(domain.MaxHealthCheckGracePeriodSec + 1)})
+
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+		mockRepo.AssertNotCalled(t, "UpdateGroupSettings", mock.Anything, mock.Anything)
+	})
+}
+
+func TestSuspendResumeProcesses(t *testing.T) {
+	ctx := context.Background()
+	groupID := uuid.New()
+
+	setup := func(suspended ...domain.ScalingProcess) (*services.AutoScalingService, *MockAutoScalingRepo) {
+		mockRepo := new(MockAutoScalingRepo)
+		eventSvc := new(MockEventService)
+		mockRepo.On("GetGroupByID", ctx, groupID).Return(&domain.ScalingGroup{ID: groupID, SuspendedProcesses: suspended}, nil)
+		mockRepo.On("UpdateGroupSuspendedProcesses", ctx, mock.AnythingOfType("*domain.ScalingGroup")).Return(nil)
+		eventSvc.On("RecordEvent", ctx, mock.Anything, groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil)
+		return services.NewAutoScalingService(mockRepo, new(MockVpcRepo), new(MockLaunchTemplateRepo), new(MockLBService), eventSvc, ports.RealClock{}), mockRepo
+	}
+
+	t.Run("SuspendAddsProcesses", func(t *testing.T) {
+		svc, _ := setup(domain.ScalingProcessScheduledActions)
+
+		group, err := svc.SuspendProcesses(ctx, groupID, []domain.ScalingProcess{domain.ScalingProcessLaunch})
+
+		require.NoError(t, err)
+		assert.Equal(t, []domain.ScalingProcess{domain.ScalingProcessLaunch, domain.ScalingProcessScheduledActions}, group.SuspendedProcesses)
+	})
+
+	t.Run("SuspendAllByDefault", func(t *testing.T) {
+		svc, _ := setup()
+
+		group, err := svc.SuspendProcesses(ctx, groupID, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.ScalingProcesses, group.SuspendedProcesses)
+	})
+
+	t.Run("ResumeRemovesProcesses", func(t *testing.T) {
+		svc, _ := setup(domain.ScalingProcessLaunch, domain.ScalingProcessTerminate)
+
+		group, err := svc.ResumeProcesses(ctx, groupID, []domain.ScalingProcess{domain.ScalingProcessTerminate})
+
+		require.NoError(t, err)
+		assert.Equal(t, []domain.ScalingProcess{domain.ScalingProcessLaunch}, group.SuspendedProcesses)
+	})
+
+	t.Run("RejectsUnknownProcess", func(t *testing.T) {
+		svc, mockRepo := setup()
+
+		_, err := svc.SuspendProcesses(ctx, groupID, []domain.ScalingProcess{"REBOOT"})
+
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+		mockRepo.AssertNotCalled(t, "UpdateGroupSuspendedProcesses", mock.Anything, mock.Anything)
+	})
+}
+
 func TestCreatePolicy_Success(t *testing.T) {
 	mockRepo := new(MockAutoScalingRepo)
 	mockVpcRepo := new(MockVpcRepo)
-	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo)
+	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo, new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{})
 	ctx := context.Background()
 	groupID := uuid.New()
 
 	mockRepo.On("GetGroupByID", ctx, groupID).Return(&domain.ScalingGroup{ID: groupID}, nil)
 	mockRepo.On("CreatePolicy", ctx, mock.AnythingOfType("*domain.ScalingPolicy")).Return(nil)
 
-	policy, err := svc.CreatePolicy(ctx, groupID, "cpu-high", "cpu", 70.0, 1, 1, 300)
+	policy, err := svc.CreatePolicy(ctx, groupID, &domain.ScalingPolicy{Name: "cpu-high", MetricType: "cpu", TargetValue: 70.0})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, policy)
 	assert.Equal(t, "cpu-high", policy.Name)
 	assert.Equal(t, 70.0, policy.TargetValue)
+	// Unset settings get their defaults.
+	assert.Equal(t, domain.ScalingPolicyTargetTracking, policy.PolicyType)
+	assert.Equal(t, 63.0, policy.ScaleInThreshold)
+	assert.Equal(t, domain.DefaultEvaluationPeriodSeconds, policy.EvaluationPeriodSec)
+	assert.Equal(t, domain.DefaultPolicyCooldownSeconds, policy.ScaleOutCooldownSec)
+	assert.Equal(t, domain.DefaultPolicyCooldownSeconds, policy.ScaleInCooldownSec)
 	mockRepo.AssertExpectations(t)
 }
 
 func TestCreatePolicy_CooldownTooLow(t *testing.T) {
 	mockRepo := new(MockAutoScalingRepo)
 	mockVpcRepo := new(MockVpcRepo)
-	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo)
+	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo, new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{})
 	ctx := context.Background()
 	groupID := uuid.New()
 
 	mockRepo.On("GetGroupByID", ctx, groupID).Return(&domain.ScalingGroup{ID: groupID}, nil)
 
-	_, err := svc.CreatePolicy(ctx, groupID, "cpu-high", "cpu", 70.0, 1, 1, 10) // Too low cooldown
+	_, err := svc.CreatePolicy(ctx, groupID, &domain.ScalingPolicy{Name: "cpu-high", MetricType: "cpu", TargetValue: 70.0, ScaleOutCooldownSec: 10}) // Too low cooldown
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "cooldown must be at least")
 }
 
+func TestCreatePolicy_MetricValidation(t *testing.T) {
+	ctx := context.Background()
+	groupID := uuid.New()
+	lbID := uuid.New()
+
+	newSvc := func(group *domain.ScalingGroup) (*services.AutoScalingService, *MockAutoScalingRepo) {
+		mockRepo := new(MockAutoScalingRepo)
+		mockRepo.On("GetGroupByID", ctx, groupID).Return(group, nil)
+		mockRepo.On("CreatePolicy", ctx, mock.AnythingOfType("*domain.ScalingPolicy")).Return(nil)
+		return services.NewAutoScalingService(mockRepo, new(MockVpcRepo), new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{}), mockRepo
+	}
+
+	valid := []struct {
+		metricType, metricName string
+		target                 float64
+	}{
+		{domain.ScalingMetricMemory, "", 75},
+		{domain.ScalingMetricNetworkIn, "", 5e6},
+		{domain.ScalingMetricNetworkOut, "", 5e6},
+		{domain.ScalingMetricLBRequestCount, "", 1000},
+		{domain.ScalingMetricCustom, "queue_depth", 10},
+		{domain.ScalingMetricCPU, "", 150}, // two cores of CPU
+	}
+	for _, c := range valid {
+		svc, _ := newSvc(&domain.ScalingGroup{ID: groupID, LoadBalancerID: &lbID})
+		policy, err := svc.CreatePolicy(ctx, groupID, &domain.ScalingPolicy{Name: "p", MetricType: c.metricType, MetricName: c.metricName, TargetValue: c.target})
+		require.NoError(t, err, c.metricType)
+		assert.Equal(t, c.metricName, policy.MetricName)
+	}
+
+	invalid := []struct {
+		metricType, metricName string
+		target                 float64
+		group                  *domain.ScalingGroup
+	}{
+		{"disk", "", 50, &domain.ScalingGroup{ID: groupID}},
+		{domain.ScalingMetricMemory, "", 120, &domain.ScalingGroup{ID: groupID}},
+		{domain.ScalingMetricCPU, "", -5, &domain.ScalingGroup{ID: groupID}},
+		{domain.ScalingMetricLBRequestCount, "", 1000, &domain.ScalingGroup{ID: groupID}},
+		{domain.ScalingMetricCustom, "", 10, &domain.ScalingGroup{ID: groupID}},
+		{domain.ScalingMetricCustom, "queue depth", 10, &domain.ScalingGroup{ID: groupID}},
+		{domain.ScalingMetricCPU, "queue_depth", 50, &domain.ScalingGroup{ID: groupID}},
+	}
+	for _, c := range invalid {
+		svc, mockRepo := newSvc(c.group)
+		_, err := svc.CreatePolicy(ctx, groupID, &domain.ScalingPolicy{Name: "p", MetricType: c.metricType, MetricName: c.metricName, TargetValue: c.target})
+		assert.True(t, errors.Is(err, errors.InvalidInput), "%+v", c)
+		mockRepo.AssertNotCalled(t, "CreatePolicy", mock.Anything, mock.Anything)
+	}
+}
+
+func TestCreatePolicy_Settings(t *testing.T) {
+	ctx := context.Background()
+	groupID := uuid.New()
+	bound := func(v float64) *float64 { return &v }
+
+	newSvc := func() (*services.AutoScalingService, *MockAutoScalingRepo) {
+		mockRepo := new(MockAutoScalingRepo)
+		mockRepo.On("GetGroupByID", ctx, groupID).Return(&domain.ScalingGroup{ID: groupID}, nil)
+		mockRepo.On("CreatePolicy", ctx, mock.AnythingOfType("*domain.ScalingPolicy")).Return(nil)
+		return services.NewAutoScalingService(mockRepo, new(MockVpcRepo), new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{}), mockRepo
+	}
+
+	valid := []*domain.ScalingPolicy{
+		{MetricType: "cpu", TargetValue: 60, ScaleInThreshold: 30, EvaluationPeriodSec: 300, ScaleOutCooldownSec: 60, ScaleInCooldownSec: 900},
+		{PolicyType: domain.ScalingPolicyStep, MetricType: "cpu", Steps: []domain.ScalingStep{
+			{UpperBound: bound(20), Adjustment: -1},
+			{LowerBound: bound(70), UpperBound: bound(90), Adjustment: 1},
+			{LowerBound: bound(90), Adjustment: 3},
+		}},
+	}
+	for _, p := range valid {
+		svc, _ := newSvc()
+		p.Name = "p"
+		_, err := svc.CreatePolicy(ctx, groupID, p)
+		require.NoError(t, err, "%+v", p)
+	}
+
+	invalid := map[string]*domain.ScalingPolicy{
+		"unknown type":          {PolicyType: "simple", MetricType: "cpu", TargetValue: 60},
+		"no target":             {MetricType: "cpu"},
+		"threshold over target": {MetricType: "cpu", TargetValue: 60, ScaleInThreshold: 60},
+		"steps on tracking":     {MetricType: "cpu", TargetValue: 60, Steps: []domain.ScalingStep{{Adjustment: 1}}},
+		"period too long":       {MetricType: "cpu", TargetValue: 60, EvaluationPeriodSec: 7200},
+		"period not minutes":    {MetricType: "cpu", TargetValue: 60, EvaluationPeriodSec: 90},
+		"scale-in cooldown":     {MetricType: "cpu", TargetValue: 60, ScaleInCooldownSec: 30},
+		"no steps":              {PolicyType: domain.ScalingPolicyStep, MetricType: "cpu"},
+		"target on step":        {PolicyType: domain.ScalingPolicyStep, MetricType: "cpu", TargetValue: 60, Steps: []domain.ScalingStep{{LowerBound: bound(80), Adjustment: 1}}},
+		"zero adjustment":       {PolicyType: domain.ScalingPolicyStep, MetricType: "cpu", Steps: []domain.ScalingStep{{LowerBound: bound(80)}}},
+		"empty band":            {PolicyType: domain.ScalingPolicyStep, MetricType: "cpu", Steps: []domain.ScalingStep{{LowerBound: bound(80), UpperBound: bound(80), Adjustment: 1}}},
+		"overlapping bands": {PolicyType: domain.ScalingPolicyStep, MetricType: "cpu", Steps: []domain.ScalingStep{
+			{LowerBound: bound(70), UpperBound: bound(90), Adjustment: 1},
+			{LowerBound: bound(80), Adjustment: 2},
+		}},
+		"open band in the middle": {PolicyType: domain.ScalingPolicyStep, MetricType: "cpu", Steps: []domain.ScalingStep{
+			{LowerBound: bound(70), Adjustment: 1},
+			{LowerBound: bound(90), Adjustment: 2},
+		}},
+	}
+	for name, p := range invalid {
+		svc, mockRepo := newSvc()
+		p.Name = "p"
+		_, err := svc.CreatePolicy(ctx, groupID, p)
+		assert.True(t, errors.Is(err, errors.InvalidInput), name)
+		mockRepo.AssertNotCalled(t, "CreatePolicy", mock.Anything, mock.Anything)
+	}
+}
+
+func TestCreateScheduledAction(t *testing.T) {
+	ctx := context.Background()
+	groupID := uuid.New()
+	intPtr := func(v int) *int { return &v }
+	// A Friday, 10:00 in Istanbul
+	now := time.Date(2026, 3, 6, 7, 0, 0, 0, time.UTC)
+
+	newSvc := func() (*services.AutoScalingService, *MockAutoScalingRepo) {
+		mockRepo := new(MockAutoScalingRepo)
+		mockRepo.On("GetGroupByID", ctx, groupID).Return(&domain.ScalingGroup{ID: groupID}, nil)
+		mockRepo.On("ListScheduledActions", ctx, groupID).Return([]*domain.ScheduledAction{}, nil)
+		mockRepo.On("CreateScheduledAction", ctx, mock.AnythingOfType("*domain.ScheduledAction")).Return(nil)
+		clock := new(MockClock)
+		clock.On("Now").Return(now)
+		return services.NewAutoScalingService(mockRepo, new(MockVpcRepo), new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), clock), mockRepo
+	}
+
+	svc, mockRepo := newSvc()
+	action, err := svc.CreateScheduledAction(ctx, groupID, &domain.ScheduledAction{
+		Name: "morning", Schedule: "0 8 * * MON-FRI", TimeZone: "Europe/Istanbul", MinInstances: intPtr(4), DesiredCount: intPtr(6),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, groupID, action.ScalingGroupID)
+	assert.Equal(t, now, action.CreatedAt)
+	// The next weekday morning is Monday, 08:00 in Istanbul
+	assert.True(t, time.Date(2026, 3, 9, 5, 0, 0, 0, time.UTC).Equal(action.NextRunAt), action.NextRunAt)
+	mockRepo.AssertExpectations(t)
+
+	svc, _ = newSvc()
+	action, err = svc.CreateScheduledAction(ctx, groupID, &domain.ScheduledAction{Name: "night", Schedule: "0 22 * * *", DesiredCount: intPtr(1)})
+	require.NoError(t, err)
+	assert.Equal(t, "UTC", action.TimeZone)
+
+	invalid := map[string]*domain.ScheduledAction{
+		"bad schedule":      {Schedule: "0 25 * * *", DesiredCount: intPtr(1)},
+		"never matches":     {Schedule: "0 0 30 2 *", DesiredCount: intPtr(1)},
+		"bad time zone":     {Schedule: "0 8 * * *", TimeZone: "Mars/Olympus", DesiredCount: intPtr(1)},
+		"no sizes":          {Schedule: "0 8 * * *"},
+		"min above max":     {Schedule: "0 8 * * *", MinInstances: intPtr(5), MaxInstances: intPtr(2)},
+		"desired below min": {Schedule: "0 8 * * *", MinInstances: intPtr(3), DesiredCount: intPtr(2)},
+		"over hard limit":   {Schedule: "0 8 * * *", MaxInstances: intPtr(domain.MaxInstancesHardLimit + 1)},
+	}
+	for name, a := range invalid {
+		svc, mockRepo := newSvc()
+		a.Name = "a"
+		_, err := svc.CreateScheduledAction(ctx, groupID, a)
+		assert.True(t, errors.Is(err, errors.InvalidInput), name)
+		mockRepo.AssertNotCalled(t, "CreateScheduledAction", mock.Anything, mock.Anything)
+	}
+}
+
 func TestListGroups(t *testing.T) {
 	mockRepo := new(MockAutoScalingRepo)
 	mockVpcRepo := new(MockVpcRepo)
-	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo)
+	svc := services.NewAutoScalingService(mockRepo, mockVpcRepo, new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{})
 	ctx := context.Background()
 
 	groups := []*domain.ScalingGroup{{Name: "asg1"}, {Name: "asg2"}}
@@ -203,3 +575,399 @@ func TestListGroups(t *testing.T) {
 	assert.Len(t, result, 2)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestCreateGroup_FromLaunchTemplate(t *testing.T) {
+	ctx := context.Background()
+	vpcID := uuid.New()
+	templateID := uuid.New()
+	template := &domain.LaunchTemplateVersion{TemplateID: templateID, Version: 3, Image: "nginx", Ports: "80:80", VpcID: &vpcID}
+
+	t.Run("TakesImagePortsAndVPCFromTemplate", func(t *testing.T) {
+		mockRepo := new(MockAutoScalingRepo)
+		mockVpcRepo := new(MockVpcRepo)
+		templateRepo := new(MockLaunchTemplateRepo)
+		svc := services.NewAutoScalingService(mockRepo, mockVpcRepo, templateRepo, new(MockLBService), new(MockEventService), ports.RealClock{})
+
+		templateRepo.On("GetVersion", ctx, templateID, 0).Return(template, nil)
+		mockVpcRepo.On("GetByID", ctx, vpcID).Return(&domain.VPC{ID: vpcID}, nil)
+		mockRepo.On("CountGroupsByVPC", ctx, vpcID).Return(0, nil)
+		mockRepo.On("CreateGroup", ctx, mock.AnythingOfType("*domain.ScalingGroup")).Return(nil)
+
+		group, err := svc.CreateGroup(ctx, ports.CreateScalingGroupParams{
+			Name: "web", LaunchTemplateID: &templateID, MinInstances: 1, MaxInstances: 3, DesiredCount: 1,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, vpcID, group.VpcID)
+		assert.Equal(t, "nginx", group.Image)
+		assert.Equal(t, "80:80", group.Ports)
+		assert.Equal(t, templateID, *group.LaunchTemplateID)
+		assert.Equal(t, 3, group.LaunchTemplateVersion)
+	})
+
+	t.Run("RejectsOtherVPC", func(t *testing.T) {
+		templateRepo := new(MockLaunchTemplateRepo)
+		svc := services.NewAutoScalingService(new(MockAutoScalingRepo), new(MockVpcRepo), templateRepo, new(MockLBService), new(MockEventService), ports.RealClock{})
+		templateRepo.On("GetVersion", ctx, templateID, 3).Return(template, nil)
+
+		_, err := svc.CreateGroup(ctx, ports.CreateScalingGroupParams{
+			Name: "web", VpcID: uuid.New(), LaunchTemplateID: &templateID, LaunchTemplateVersion: 3, MinInstances: 1, MaxInstances: 3, DesiredCount: 1,
+		})
+
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+	})
+
+	t.Run("RejectsVolumes", func(t *testing.T) {
+		templateRepo := new(MockLaunchTemplateRepo)
+		svc := services.NewAutoScalingService(new(MockAutoScalingRepo), new(MockVpcRepo), templateRepo, new(MockLBService), new(MockEventService), ports.RealClock{})
+		withVolume := *template
+		withVolume.Volumes = []domain.VolumeAttachment{{VolumeIDOrName: "data", MountPath: "/data"}}
+		templateRepo.On("GetVersion", ctx, templateID, 0).Return(&withVolume, nil)
+
+		_, err := svc.CreateGroup(ctx, ports.CreateScalingGroupParams{
+			Name: "web", LaunchTemplateID: &templateID, MinInstances: 1, MaxInstances: 3, DesiredCount: 1,
+		})
+
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+	})
+
+	t.Run("RejectsImageWithTemplate", func(t *testing.T) {
+		svc := services.NewAutoScalingService(new(MockAutoScalingRepo), new(MockVpcRepo), new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{})
+
+		_, err := svc.CreateGroup(ctx, ports.CreateScalingGroupParams{
+			Name: "web", Image: "nginx", LaunchTemplateID: &templateID, MinInstances: 1, MaxInstances: 3, DesiredCount: 1,
+		})
+
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+	})
+}
+
+func TestSetLaunchTemplate(t *testing.T) {
+	ctx := context.Background()
+	vpcID := uuid.New()
+	groupID := uuid.New()
+	templateID := uuid.New()
+
+	mockRepo := new(MockAutoScalingRepo)
+	templateRepo := new(MockLaunchTemplateRepo)
+	svc := services.NewAutoScalingService(mockRepo, new(MockVpcRepo), templateRepo, new(MockLBService), new(MockEventService), ports.RealClock{})
+
+	group := &domain.ScalingGroup{ID: groupID, VpcID: vpcID, Image: "nginx:1.26", LaunchTemplateID: &templateID, LaunchTemplateVersion: 1}
+	mockRepo.On("GetGroupByID", ctx, groupID).Return(group, nil)
+	mockRepo.On("GetLatestInstanceRefresh", ctx, groupID).Return(&domain.InstanceRefresh{Status: domain.InstanceRefreshSuccessful}, nil)
+	templateRepo.On("GetVersion", ctx, templateID, 0).Return(&domain.LaunchTemplateVersion{TemplateID: templateID, Version: 2, Image: "nginx:1.27", Ports: "80:80"}, nil)
+	mockRepo.On("UpdateGroupLaunchTemplate", ctx, mock.MatchedBy(func(g *domain.ScalingGroup) bool {
+		return g.LaunchTemplateVersion == 2 && g.Image == "nginx:1.27" && g.Ports == "80:80"
+	})).Return(nil)
+
+	updated, err := svc.SetLaunchTemplate(ctx, groupID, templateID, 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.LaunchTemplateVersion)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "UpdateGroup", mock.Anything, mock.Anything)
+}
+
+func TestStartInstanceRefresh(t *testing.T) {
+	ctx := context.Background()
+	vpcID := uuid.New()
+	groupID := uuid.New()
+	templateID := uuid.New()
+	intPtr := func(v int) *int { return &v }
+
+	newGroup := func() *domain.ScalingGroup {
+		return &domain.ScalingGroup{ID: groupID, VpcID: vpcID, Image: "nginx:1.26", Ports: "80:80", LaunchTemplateID: &templateID, LaunchTemplateVersion: 1, DesiredCount: 2, MaxInstances: 4}
+	}
+	noRefresh := errors.New(errors.NotFound, "scaling group has no instance refresh")
+
+	t.Run("MovesToTemplateVersionAndKeepsRollback", func(t *testing.T) {
+		mockRepo, templateRepo, eventSvc := new(MockAutoScalingRepo), new(MockLaunchTemplateRepo), new(MockEventService)
+		svc := services.NewAutoScalingService(mockRepo, new(MockVpcRepo), templateRepo, new(MockLBService), eventSvc, ports.RealClock{})
+		instances := []uuid.UUID{uuid.New(), uuid.New()}
+
+		mockRepo.On("GetGroupByID", ctx, groupID).Return(newGroup(), nil)
+		mockRepo.On("GetLatestInstanceRefresh", ctx, groupID).Return(nil, noRefresh)
+		templateRepo.On("GetVersion", ctx, templateID, 2).Return(&domain.LaunchTemplateVersion{TemplateID: templateID, Version: 2, Image: "nginx:1.27", Ports: "80:80"}, nil)
+		mockRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: instances}, nil)
+		mockRepo.On("UpdateGroupLaunchTemplate", ctx, mock.MatchedBy(func(g *domain.ScalingGroup) bool {
+			return g.LaunchTemplateVersion == 2 && g.Image == "nginx:1.27"
+		})).Return(nil).Once()
+		mockRepo.On("CreateInstanceRefresh", ctx, mock.MatchedBy(func(r *domain.InstanceRefresh) bool {
+			return r.Status == domain.InstanceRefreshInProgress && r.InstancesToReplace == 2 && r.MinHealthyPercentage == 50 &&
+				r.HealthCheckTimeoutSec == domain.DefaultRefreshHealthCheckTimeoutSec &&
+				r.RollbackConfig != nil && r.RollbackConfig.LaunchTemplateVersion == 1 && r.RollbackConfig.Image == "nginx:1.26"
+		})).Return(nil).Once()
+		eventSvc.On("RecordEvent", ctx, "AUTOSCALING_REFRESH_STARTED", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Once()
+
+		refresh, err := svc.StartInstanceRefresh(ctx, groupID, ports.StartInstanceRefreshParams{
+			MinHealthyPercentage: intPtr(50), LaunchTemplateID: &templateID, LaunchTemplateVersion: 2,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, instances, refresh.PendingInstanceIDs)
+		mockRepo.AssertExpectations(t)
+		eventSvc.AssertExpectations(t)
+	})
+
+	t.Run("ReplacesWithCurrentConfigWithoutRollback", func(t *testing.T) {
+		mockRepo, eventSvc := new(MockAutoScalingRepo), new(MockEventService)
+		svc := services.NewAutoScalingService(mockRepo, new(MockVpcRepo), new(MockLaunchTemplateRepo), new(MockLBService), eventSvc, ports.RealClock{})
+
+		mockRepo.On("GetGroupByID", ctx, groupID).Return(newGroup(), nil)
+		mockRepo.On("GetLatestInstanceRefresh", ctx, groupID).Return(&domain.InstanceRefresh{Status: domain.InstanceRefreshCancelled}, nil)
+		mockRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: {uuid.New()}}, nil)
+		mockRepo.On("CreateInstanceRefresh", ctx, mock.MatchedBy(func(r *domain.InstanceRefresh) bool {
+			return r.RollbackConfig == nil && r.MinHealthyPercentage == domain.DefaultMinHealthyPercentage
+		})).Return(nil).Once()
+		eventSvc.On("RecordEvent", ctx, "AUTOSCALING_REFRESH_STARTED", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Once()
+
+		_, err := svc.StartInstanceRefresh(ctx, groupID, ports.StartInstanceRefreshParams{})
+
+		require.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "UpdateGroupLaunchTemplate", mock.Anything, mock.Anything)
+	})
+
+	t.Run("EmptyGroupSucceedsRightAway", func(t *testing.T) {
+		mockRepo, eventSvc := new(MockAutoScalingRepo), new(MockEventService)
+		svc := services.NewAutoScalingService(mockRepo, new(MockVpcRepo), new(MockLaunchTemplateRepo), new(MockLBService), eventSvc, ports.RealClock{})
+
+		mockRepo.On("GetGroupByID", ctx, groupID).Return(newGroup(), nil)
+		mockRepo.On("GetLatestInstanceRefresh", ctx, groupID).Return(nil, noRefresh)
+		mockRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{}, nil)
+		mockRepo.On("CreateInstanceRefresh", ctx, mock.Anything).Return(nil).Once()
+		eventSvc.On("RecordEvent", ctx, "AUTOSCALING_REFRESH_STARTED", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Once()
+
+		refresh, err := svc.StartInstanceRefresh(ctx, groupID, ports.StartInstanceRefreshParams{})
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.InstanceRefreshSuccessful, refresh.Status)
+		assert.NotNil(t, refresh.EndedAt)
+	})
+
+	t.Run("RejectsLaunchingFirstAtMax", func(t *testing.T) {
+		mockRepo := new(MockAutoScalingRepo)
+		svc := services.NewAutoScalingService(mockRepo, new(MockVpcRepo), new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{})
+		group := newGroup()
+		group.DesiredCount, group.MaxInstances = 1, 1
+
+		mockRepo.On("GetGroupByID", ctx, groupID).Return(group, nil)
+		mockRepo.On("GetLatestInstanceRefresh", ctx, groupID).Return(nil, noRefresh)
+		mockRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: {uuid.New()}}, nil)
+
+		_, err := svc.StartInstanceRefresh(ctx, groupID, ports.StartInstanceRefreshParams{MinHealthyPercentage: intPtr(100)})
+
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+		mockRepo.AssertNotCalled(t, "CreateInstanceRefresh", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RejectsSecondRefresh", func(t *testing.T) {
+		mockRepo := new(MockAutoScalingRepo)
+		svc := services.NewAutoScalingService(mockRepo, new(MockVpcRepo), new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{})
+
+		mockRepo.On("GetGroupByID", ctx, groupID).Return(newGroup(), nil)
+		mockRepo.On("GetLatestInstanceRefresh", ctx, groupID).Return(&domain.InstanceRefresh{Status: domain.InstanceRefreshPaused}, nil)
+
+		_, err := svc.StartInstanceRefresh(ctx, groupID, ports.StartInstanceRefreshParams{})
+
+		assert.True(t, errors.Is(err, errors.Conflict))
+	})
+
+	t.Run("InvalidSettings", func(t *testing.T) {
+		tests := []ports.StartInstanceRefreshParams{
+			{MinHealthyPercentage: intPtr(101)},
+			{MinHealthyPercentage: intPtr(-1)},
+			{HealthCheckTimeoutSec: 10},
+			{Image: "nginx:1.27"}, // the group has a launch template
+			{LaunchTemplateVersion: 2},
+		}
+		for _, params := range tests {
+			mockRepo := new(MockAutoScalingRepo)
+			svc := services.NewAutoScalingService(mockRepo, new(MockVpcRepo), new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{})
+			mockRepo.On("GetGroupByID", ctx, groupID).Return(newGroup(), nil)
+			mockRepo.On("GetLatestInstanceRefresh", ctx, groupID).Return(nil, noRefresh)
+
+			_, err := svc.StartInstanceRefresh(ctx, groupID, params)
+
+			assert.True(t, errors.Is(err, errors.InvalidInput), "params %+v", params)
+		}
+	})
+}
+
+func TestInstanceRefreshControls(t *testing.T) {
+	ctx := context.Background()
+	groupID := uuid.New()
+
+	setup := func(status domain.InstanceRefreshStatus) (*services.AutoScalingService, *MockAutoScalingRepo, *MockEventService, *domain.InstanceRefresh) {
+		mockRepo, eventSvc := new(MockAutoScalingRepo), new(MockEventService)
+		batchStarted := time.Now().Add(-time.Hour)
+		refresh := &domain.InstanceRefresh{ID: uuid.New(), ScalingGroupID: groupID, Status: status, BatchStartedAt: &batchStarted}
+		mockRepo.On("GetLatestInstanceRefresh", ctx, groupID).Return(refresh, nil)
+		eventSvc.On("RecordEvent", ctx, mock.Anything, groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Maybe()
+		return services.NewAutoScalingService(mockRepo, new(MockVpcRepo), new(MockLaunchTemplateRepo), new(MockLBService), eventSvc, ports.RealClock{}), mockRepo, eventSvc, refresh
+	}
+
+	t.Run("Pause", func(t *testing.T) {
+		svc, mockRepo, eventSvc, _ := setup(domain.InstanceRefreshInProgress)
+		mockRepo.On("UpdateInstanceRefresh", ctx, mock.Anything, domain.InstanceRefreshInProgress).Return(nil).Once()
+
+		refresh, err := svc.PauseInstanceRefresh(ctx, groupID)
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.InstanceRefreshPaused, refresh.Status)
+		eventSvc.AssertCalled(t, "RecordEvent", ctx, "AUTOSCALING_REFRESH_PAUSED", groupID.String(), "SCALING_GROUP", mock.Anything)
+	})
+
+	t.Run("PauseWhileRollingBack", func(t *testing.T) {
+		svc, mockRepo, _, _ := setup(domain.InstanceRefreshRollingBack)
+
+		_, err := svc.PauseInstanceRefresh(ctx, groupID)
+
+		assert.True(t, errors.Is(err, errors.Conflict))
+		mockRepo.AssertNotCalled(t, "UpdateInstanceRefresh", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ResumeRestartsBatchTimeout", func(t *testing.T) {
+		svc, mockRepo, _, _ := setup(domain.InstanceRefreshPaused)
+		mockRepo.On("UpdateInstanceRefresh", ctx, mock.Anything, domain.InstanceRefreshPaused).Return(nil).Once()
+
+		refresh, err := svc.ResumeInstanceRefresh(ctx, groupID)
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.InstanceRefreshInProgress, refresh.Status)
+		assert.WithinDuration(t, time.Now(), *refresh.BatchStartedAt, time.Minute)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		svc, mockRepo, _, _ := setup(domain.InstanceRefreshPaused)
+		mockRepo.On("UpdateInstanceRefresh", ctx, mock.Anything, domain.InstanceRefreshPaused).Return(nil).Once()
+
+		refresh, err := svc.CancelInstanceRefresh(ctx, groupID)
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.InstanceRefreshCancelled, refresh.Status)
+		assert.NotNil(t, refresh.EndedAt)
+	})
+
+	t.Run("CancelEnded", func(t *testing.T) {
+		svc, _, _, _ := setup(domain.InstanceRefreshSuccessful)
+
+		_, err := svc.CancelInstanceRefresh(ctx, groupID)
+
+		assert.True(t, errors.Is(err, errors.Conflict))
+	})
+
+	t.Run("ConcurrentChange", func(t *testing.T) {
+		svc, mockRepo, eventSvc, _ := setup(domain.InstanceRefreshInProgress)
+		mockRepo.On("UpdateInstanceRefresh", ctx, mock.Anything, domain.InstanceRefreshInProgress).Return(errors.New(errors.Conflict, "instance refresh was changed concurrently")).Once()
+
+		_, err := svc.PauseInstanceRefresh(ctx, groupID)
+
+		assert.True(t, errors.Is(err, errors.Conflict))
+		eventSvc.AssertNotCalled(t, "RecordEvent", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestCreateLifecycleHook(t *testing.T) {
+	ctx := context.Background()
+	groupID := uuid.New()
+
+	setup := func(existing ...*domain.LifecycleHook) (*services.AutoScalingService, *MockAutoScalingRepo) {
+		mockRepo := new(MockAutoScalingRepo)
+		mockRepo.On("GetGroupByID", ctx, groupID).Return(&domain.ScalingGroup{ID: groupID}, nil)
+		mockRepo.On("ListLifecycleHooks", ctx, groupID).Return(existing, nil).Maybe()
+		return services.NewAutoScalingService(mockRepo, new(MockVpcRepo), new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{}), mockRepo
+	}
+
+	t.Run("FillsInDefaults", func(t *testing.T) {
+		svc, mockRepo := setup()
+		mockRepo.On("CreateLifecycleHook", ctx, mock.Anything).Return(nil).Once()
+
+		hook, err := svc.CreateLifecycleHook(ctx, groupID, &domain.LifecycleHook{Name: "warm-cache", Transition: domain.LifecycleTransitionLaunching})
+
+		require.NoError(t, err)
+		assert.Equal(t, groupID, hook.ScalingGroupID)
+		assert.Equal(t, domain.DefaultLifecycleHeartbeatTimeoutSec, hook.HeartbeatTimeoutSec)
+		assert.Equal(t, domain.LifecycleActionAbandon, hook.DefaultResult)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("OneHookPerTransition", func(t *testing.T) {
+		svc, _ := setup(&domain.LifecycleHook{Name: "flush-queue", Transition: domain.LifecycleTransitionTerminating})
+
+		_, err := svc.CreateLifecycleHook(ctx, groupID, &domain.LifecycleHook{Name: "drain", Transition: domain.LifecycleTransitionTerminating})
+
+		assert.True(t, errors.Is(err, errors.Conflict))
+	})
+
+	t.Run("InvalidSettings", func(t *testing.T) {
+		tests := []*domain.LifecycleHook{
+			{Transition: domain.LifecycleTransitionLaunching},
+			{Name: "a", Transition: "STARTING"},
+			{Name: "a", Transition: domain.LifecycleTransitionLaunching, HeartbeatTimeoutSec: 10},
+			{Name: "a", Transition: domain.LifecycleTransitionLaunching, HeartbeatTimeoutSec: 7201},
+			{Name: "a", Transition: domain.LifecycleTransitionLaunching, DefaultResult: "RETRY"},
+		}
+		for _, hook := range tests {
+			svc, mockRepo := setup()
+
+			_, err := svc.CreateLifecycleHook(ctx, groupID, hook)
+
+			assert.True(t, errors.Is(err, errors.InvalidInput), "hook %+v", hook)
+			mockRepo.AssertNotCalled(t, "CreateLifecycleHook", mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestCompleteLifecycleAction(t *testing.T) {
+	ctx := context.Background()
+	groupID := uuid.New()
+	instID := uuid.New()
+
+	t.Run("RecordsTheResult", func(t *testing.T) {
+		mockRepo, eventSvc := new(MockAutoScalingRepo), new(MockEventService)
+		svc := services.NewAutoScalingService(mockRepo, new(MockVpcRepo), new(MockLaunchTemplateRepo), new(MockLBService), eventSvc, ports.RealClock{})
+		mockRepo.On("GetGroupByID", ctx, groupID).Return(&domain.ScalingGroup{ID: groupID}, nil)
+		mockRepo.On("CompleteLifecycleAction", ctx, groupID, instID, domain.LifecycleActionContinue).Return(nil).Once()
+		eventSvc.On("RecordEvent", ctx, "AUTOSCALING_LIFECYCLE_ACTION_COMPLETED", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Once()
+
+		err := svc.CompleteLifecycleAction(ctx, groupID, instID, domain.LifecycleActionContinue)
+
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		eventSvc.AssertExpectations(t)
+	})
+
+	t.Run("InvalidResult", func(t *testing.T) {
+		svc := services.NewAutoScalingService(new(MockAutoScalingRepo), new(MockVpcRepo), new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{})
+
+		err := svc.CompleteLifecycleAction(ctx, groupID, instID, "RETRY")
+
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+	})
+}
+
+func TestListActivities(t *testing.T) {
+	ctx := context.Background()
+	groupID := uuid.New()
+
+	t.Run("DefaultLimit", func(t *testing.T) {
+		mockRepo := new(MockAutoScalingRepo)
+		svc := services.NewAutoScalingService(mockRepo, new(MockVpcRepo), new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{})
+		activities := []*domain.ScalingActivity{{ID: uuid.New(), ScalingGroupID: groupID, Status: domain.ScalingActivitySuccessful}}
+		mockRepo.On("GetGroupByID", ctx, groupID).Return(&domain.ScalingGroup{ID: groupID}, nil)
+		mockRepo.On("ListActivities", ctx, groupID, 50).Return(activities, nil).Once()
+
+		result, err := svc.ListActivities(ctx, groupID, 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, activities, result)
+	})
+
+	t.Run("InvalidLimit", func(t *testing.T) {
+		svc := services.NewAutoScalingService(new(MockAutoScalingRepo), new(MockVpcRepo), new(MockLaunchTemplateRepo), new(MockLBService), new(MockEventService), ports.RealClock{})
+
+		_, err := svc.ListActivities(ctx, groupID, 501)
+
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
 	"strings"
 	"sync"
 	"time"
@@ -12,16 +13,20 @@ import (
 	appcontext "github.com/poyrazk/thecloud/internal/core/context"
 	"github.com/poyrazk/thecloud/internal/core/domain"
 	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/errors"
 	"github.com/poyrazk/thecloud/internal/platform"
+	"github.com/poyrazk/thecloud/pkg/cron"
 )
 
 type AutoScalingWorker struct {
 	repo         ports.AutoScalingRepository
+	templateRepo ports.LaunchTemplateRepository
 	instanceSvc  ports.InstanceService
 	lbSvc        ports.LBService
 	eventSvc     ports.EventService
 	clock        ports.Clock
 	tickInterval time.Duration
+	lastPruned   time.Time
 }
 
 const (
@@ -32,6 +37,7 @@ const (
 
 func NewAutoScalingWorker(
 	repo ports.AutoScalingRepository,
+	templateRepo ports.LaunchTemplateRepository,
 	instanceSvc ports.InstanceService,
 	lbSvc ports.LBService,
 	eventSvc ports.EventService,
@@ -39,6 +45,7 @@ func NewAutoScalingWorker(
 ) *AutoScalingWorker {
 	return &AutoScalingWorker{
 		repo:         repo,
+		templateRepo: templateRepo,
 		instanceSvc:  instanceSvc,
 		lbSvc:        lbSvc,
 		eventSvc:     eventSvc,
@@ -90,18 +97,58 @@ func (w *AutoScalingWorker) Evaluate(ctx context.Context) {
 		return
 	}
 
+	drainingByGroup, err := w.repo.GetAllDrainingInstances(ctx, groupIDs)
+	if err != nil {
+		log.Printf("AutoScaling: failed to fetch draining instances: %v", err)
+		return
+	}
+
+	// Scheduled actions that fail to load are retried on the next tick; the
+	// groups are still reconciled meanwhile.
+	actionsByGroup, err := w.repo.GetDueScheduledActions(ctx, groupIDs, w.clock.Now())
+	if err != nil {
+		log.Printf("AutoScaling: failed to fetch scheduled actions: %v", err)
+	}
+	refreshes, err := w.repo.GetRunningInstanceRefreshes(ctx, groupIDs)
+	if err != nil {
+		log.Printf("AutoScaling: failed to fetch instance refreshes: %v", err)
+	}
+
+	hooksByGroup, err := w.repo.GetAllLifecycleHooks(ctx, groupIDs)
+	if err != nil {
+		log.Printf("AutoScaling: failed to fetch lifecycle hooks: %v", err)
+		return
+	}
+	waitingByGroup, err := w.repo.GetAllWaitingInstances(ctx, groupIDs)
+	if err != nil {
+		log.Printf("AutoScaling: failed to fetch instances awaiting lifecycle actions: %v", err)
+		return
+	}
+
+	w.pruneActivities(ctx)
+
 	for _, group := range groups {
 		// Wrap context with group's UserID for scoped service calls
 		gCtx := appcontext.WithUserID(ctx, group.UserID)
 
+		// Deleting a group skips its lifecycle hooks.
+		hooks := hooksByGroup[group.ID]
 		if group.Status == domain.ScalingGroupStatusDeleting {
-			w.cleanupGroup(gCtx, group, instancesByGroup[group.ID])
+			hooks = nil
+		}
+
+		draining := w.finishDraining(gCtx, group, drainingByGroup[group.ID], lifecycleHook(hooks, domain.LifecycleTransitionTerminating))
+		instances, launching, terminating := w.completeLifecycleActions(gCtx, group, waitingByGroup[group.ID], hooks, instancesByGroup[group.ID])
+
+		if group.Status == domain.ScalingGroupStatusDeleting {
+			w.cleanupGroup(gCtx, group, instances, draining+terminating)
 			continue
 		}
 
+		instances = w.replaceUnhealthyInstances(gCtx, group, instances, launching, hooks)
+
 		// Calculate current count from actual instances, not just DB field
 		// DB field `CurrentCount` is kept in sync but source of truth is the link table
-		instances := instancesByGroup[group.ID]
 		if len(instances) != group.CurrentCount {
 			// Reconciliation: update group count if mismatched
 			group.CurrentCount = len(instances)
@@ -110,13 +157,484 @@ func (w *AutoScalingWorker) Evaluate(ctx context.Context) {
 
 		platform.AutoScalingCurrentInstances.WithLabelValues(group.ID.String()).Set(float64(group.CurrentCount))
 
-		w.reconcileInstances(gCtx, group, instances)
+		// Actions that came due while suspended run once on resume, as after
+		// downtime.
+		if !group.IsSuspended(domain.ScalingProcessScheduledActions) {
+			for _, action := range actionsByGroup[group.ID] {
+				w.runScheduledAction(gCtx, group, action)
+			}
+		}
+		if refresh := refreshes[group.ID]; refresh != nil {
+			instances = w.advanceInstanceRefresh(gCtx, group, refresh, instances, launching, hooks)
+		}
+		w.reconcileInstances(gCtx, group, instances, hooks)
 		w.evaluatePolicies(gCtx, group, instances, policiesByGroup[group.ID])
 	}
 }
 
-func (w *AutoScalingWorker) cleanupGroup(ctx context.Context, group *domain.ScalingGroup, instanceIDs []uuid.UUID) {
+// runScheduledAction applies the sizes of a due action to the group and
+// schedules its next run. Sizes the action leaves unset keep their value,
+// and the desired count is moved into the new bounds.
+func (w *AutoScalingWorker) runScheduledAction(ctx context.Context, group *domain.ScalingGroup, action *domain.ScheduledAction) {
+	now := w.clock.Now()
+	loc, err := time.LoadLocation(action.TimeZone)
+	if err != nil {
+		log.Printf("AutoScaling: scheduled action %s has an unknown time zone %q: %v", action.Name, action.TimeZone, err)
+		return
+	}
+	schedule, err := cron.Parse(action.Schedule)
+	if err != nil {
+		log.Printf("AutoScaling: scheduled action %s has an invalid schedule: %v", action.Name, err)
+		return
+	}
+
+	min, max, desired := group.MinInstances, group.MaxInstances, group.DesiredCount
+	if action.MinInstances != nil {
+		min = *action.MinInstances
+	}
+	if action.MaxInstances != nil {
+		max = *action.MaxInstances
+	}
+	if action.DesiredCount != nil {
+		desired = *action.DesiredCount
+	}
+	desired = clampDesired(&domain.ScalingGroup{MinInstances: min, MaxInstances: max}, desired)
+
+	if min > max {
+		log.Printf("AutoScaling: skipping scheduled action %s of group %s: min %d above max %d", action.Name, group.Name, min, max)
+	} else {
+		prevMin, prevMax, prevDesired := group.MinInstances, group.MaxInstances, group.DesiredCount
+		group.MinInstances, group.MaxInstances, group.DesiredCount = min, max, desired
+		err := w.repo.UpdateGroup(ctx, group)
+		w.recordActivity(ctx, group, fmt.Sprintf("Setting min %d, max %d and desired %d instances", min, max, desired),
+			fmt.Sprintf("scheduled action %s", action.Name), prevDesired, desired, now, err)
+		if err != nil {
+			log.Printf("AutoScaling: failed to run scheduled action %s of group %s: %v", action.Name, group.Name, err)
+			group.MinInstances, group.MaxInstances, group.DesiredCount = prevMin, prevMax, prevDesired
+			return
+		}
+		log.Printf("AutoScaling: Group %s resized by scheduled action %s (min %d, max %d, desired %d)", group.Name, action.Name, min, max, desired)
+		_ = w.eventSvc.RecordEvent(ctx, "AUTOSCALING_SCHEDULED_ACTION", group.ID.String(), "SCALING_GROUP", map[string]interface{}{
+			"action":        action.Name,
+			"min_instances": min,
+			"max_instances": max,
+			"desired_count": desired,
+		})
+	}
+
+	// Runs missed while the worker was down are not made up; the next run
+	// is the first one after now.
+	next := schedule.Next(now.In(loc))
+	if err := w.repo.UpdateScheduledActionRun(ctx, action.ID, now, next); err != nil {
+		log.Printf("AutoScaling: failed to schedule the next run of action %s: %v", action.Name, err)
+	}
+}
+
+// advanceInstanceRefresh moves a running refresh on by one step and returns
+// the instances of the group still in service. Once the group is back at its
+// desired count with every replacement healthy, the next batch is taken out
+// of service; reconciliation launches its replacements from the group's
+// launch configuration. When the min healthy percentage keeps every instance
+// in service, the next instance to replace is left out of the count instead,
+// so its replacement launches above the desired count, and it is taken out
+// of service once the replacement is healthy.
+func (w *AutoScalingWorker) advanceInstanceRefresh(ctx context.Context, group *domain.ScalingGroup, refresh *domain.InstanceRefresh, instanceIDs, launching []uuid.UUID, hooks []*domain.LifecycleHook) []uuid.UUID {
+	from := refresh.Status
+	now := w.clock.Now()
+
+	// A refresh needs both launches and terminations. It holds while either
+	// is suspended, and the batch gets its full timeout once they resume.
+	if group.IsSuspended(domain.ScalingProcessLaunch) || group.IsSuspended(domain.ScalingProcessTerminate) {
+		if refresh.BatchStartedAt != nil {
+			refresh.BatchStartedAt = &now
+			w.saveInstanceRefresh(ctx, refresh, from)
+		}
+		return instanceIDs
+	}
+
+	// Instances that left the group some other way need no replacing.
+	refresh.PendingInstanceIDs = intersectIDs(refresh.PendingInstanceIDs, instanceIDs)
+
+	inService := instanceIDs
+	batchSize := refresh.BatchSize(group.DesiredCount)
+	if refresh.LaunchesFirst(group.DesiredCount) {
+		if group.DesiredCount >= group.MaxInstances {
+			w.failInstanceRefresh(ctx, group, refresh, instanceIDs, fmt.Sprintf(
+				"min healthy percentage %d keeps every instance in service and the group is at its maximum of %d instances, so no replacement can be launched first",
+				refresh.MinHealthyPercentage, group.MaxInstances))
+			w.saveInstanceRefresh(ctx, refresh, from)
+			return instanceIDs
+		}
+		inService = subtractIDs(instanceIDs, refresh.PendingInstanceIDs[:1])
+		batchSize = 1
+	}
+
+	ready, err := w.refreshReady(ctx, group, refresh, inService, launching)
+	if err != nil {
+		log.Printf("AutoScaling: failed to check the replacements of group %s: %v", group.Name, err)
+	}
+	if !ready {
+		timeout := time.Duration(refresh.HealthCheckTimeoutSec) * time.Second
+		if refresh.BatchStartedAt == nil && len(inService) < len(instanceIDs) {
+			// The replacement launched above the desired count gets its
+			// timeout from now.
+			refresh.BatchStartedAt = &now
+		} else if refresh.BatchStartedAt != nil && now.Sub(*refresh.BatchStartedAt) >= timeout {
+			w.failInstanceRefresh(ctx, group, refresh, instanceIDs, fmt.Sprintf("replacement instances were not healthy within %d seconds", refresh.HealthCheckTimeoutSec))
+			w.saveInstanceRefresh(ctx, refresh, from)
+			return instanceIDs
+		}
+		w.saveInstanceRefresh(ctx, refresh, from)
+		return inService
+	}
+	refresh.BatchStartedAt = nil
+
+	if len(refresh.PendingInstanceIDs) == 0 {
+		w.finishInstanceRefresh(ctx, group, refresh)
+		w.saveInstanceRefresh(ctx, refresh, from)
+		return instanceIDs
+	}
+
+	batch := refresh.PendingInstanceIDs[:batchSize]
+	hook := lifecycleHook(hooks, domain.LifecycleTransitionTerminating)
+	var replaced []uuid.UUID
+	for _, id := range batch {
+		if err := w.scaleIn(ctx, group, id, hook); err != nil {
+			log.Printf("AutoScaling: failed to take instance %s out of service for refresh: %v", id, err)
+			continue
+		}
+		replaced = append(replaced, id)
+	}
+	refresh.PendingInstanceIDs = subtractIDs(refresh.PendingInstanceIDs, replaced)
+	refresh.BatchStartedAt = &now
+	w.saveInstanceRefresh(ctx, refresh, from)
+
+	replacedIDs := make([]string, len(replaced))
+	for i, id := range replaced {
+		replacedIDs[i] = id.String()
+	}
+	log.Printf("AutoScaling: Group %s refresh replacing %d instances (%d left)", group.Name, len(replaced), len(refresh.PendingInstanceIDs))
+	if len(replaced) > 0 {
+		w.recordActivity(ctx, group, fmt.Sprintf("Replacing instances (%d in batch)", len(replaced)), fmt.Sprintf("instance refresh %s", refresh.ID),
+			len(instanceIDs), len(instanceIDs)-len(replaced), now, nil)
+	}
+	_ = w.eventSvc.RecordEvent(ctx, "AUTOSCALING_REFRESH_BATCH", group.ID.String(), "SCALING_GROUP", map[string]interface{}{
+		"refresh_id":           refresh.ID.String(),
+		"instance_ids":         replacedIDs,
+		"instances_replaced":   refresh.InstancesToReplace - len(refresh.PendingInstanceIDs),
+		"instances_to_replace": refresh.InstancesToReplace,
+	})
+	return subtractIDs(inService, replaced)
+}
+
+// refreshReady reports whether the group is at its desired count with every
+// instance not awaiting replacement healthy: healthy in its load balancer,
+// or running for groups without one. Instances awaiting a launching
+// lifecycle action are not ready yet.
+func (w *AutoScalingWorker) refreshReady(ctx context.Context, group *domain.ScalingGroup, refresh *domain.InstanceRefresh, instanceIDs, launching []uuid.UUID) (bool, error) {
+	if len(instanceIDs) < group.DesiredCount || len(launching) > 0 {
+		return false, nil
+	}
+
+	var targetHealth map[uuid.UUID]string
+	if group.LoadBalancerID != nil {
+		targets, err := w.lbSvc.ListTargets(ctx, *group.LoadBalancerID)
+		if err != nil {
+			return false, err
+		}
+		targetHealth = make(map[uuid.UUID]string, len(targets))
+		for _, t := range targets {
+			targetHealth[t.InstanceID] = t.Health
+		}
+	}
+
+	for _, id := range subtractIDs(instanceIDs, refresh.PendingInstanceIDs) {
+		if targetHealth != nil {
+			if targetHealth[id] != domain.TargetHealthHealthy {
+				return false, nil
+			}
+			continue
+		}
+		inst, err := w.instanceSvc.GetInstance(ctx, id.String())
+		if err != nil {
+			return false, err
+		}
+		if inst.Status != domain.StatusRunning {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// replaceUnhealthyInstances takes the unhealthy instances of the group out of
+// service and returns the others, so that reconciliation launches their
+// replacements. Instances in their grace period or awaiting a launching
+// lifecycle action are not checked, and none are replaced while the group is
+// in failure backoff, as their replacements could not be launched.
+func (w *AutoScalingWorker) replaceUnhealthyInstances(ctx context.Context, group *domain.ScalingGroup, instanceIDs, launching []uuid.UUID, hooks []*domain.LifecycleHook) []uuid.UUID {
+	if group.IsSuspended(domain.ScalingProcessReplaceUnhealthy) || w.shouldSkipDueToFailures(group) {
+		return instanceIDs
+	}
+
+	var targetHealth map[uuid.UUID]string
+	if group.HealthCheckType == domain.ScalingHealthCheckLB && group.LoadBalancerID != nil {
+		targets, err := w.lbSvc.ListTargets(ctx, *group.LoadBalancerID)
+		if err != nil {
+			// The containers are still checked.
+			log.Printf("AutoScaling: failed to fetch target health of group %s: %v", group.Name, err)
+		}
+		targetHealth = make(map[uuid.UUID]string, len(targets))
+		for _, t := range targets {
+			targetHealth[t.InstanceID] = t.Health
+		}
+	}
+
+	now := w.clock.Now()
+	grace := time.Duration(group.HealthCheckGracePeriodSec) * time.Second
+	hook := lifecycleHook(hooks, domain.LifecycleTransitionTerminating)
+	var replaced []uuid.UUID
+	for _, id := range subtractIDs(instanceIDs, launching) {
+		var reason string
+		inst, err := w.instanceSvc.RefreshInstanceStatus(ctx, id.String())
+		switch {
+		case errors.Is(err, errors.NotFound):
+			reason = "instance no longer exists"
+		case err != nil:
+			log.Printf("AutoScaling: failed to check health of instance %s: %v", id, err)
+			continue
+		case now.Sub(inst.CreatedAt) < grace:
+			continue
+		case inst.Status == domain.StatusError || inst.Status == domain.StatusStopped || inst.Status == domain.StatusDeleted:
+			reason = fmt.Sprintf("instance is %s", inst.Status)
+		case targetHealth[id] == domain.TargetHealthUnhealthy:
+			reason = "load balancer health check failing"
+		default:
+			continue
+		}
+
+		log.Printf("AutoScaling: instance %s of group %s is unhealthy: %s", id, group.Name, reason)
+		_ = w.eventSvc.RecordEvent(ctx, "AUTOSCALING_UNHEALTHY_INSTANCE", group.ID.String(), "SCALING_GROUP", map[string]interface{}{
+			"instance_id": id.String(),
+			"reason":      reason,
+		})
+		current := len(instanceIDs) - len(replaced)
+		err = w.scaleIn(ctx, group, id, hook)
+		w.recordActivity(ctx, group, fmt.Sprintf("Terminating unhealthy instance %s", id), reason, current, current-1, now, err)
+		if err != nil {
+			log.Printf("AutoScaling: failed to take unhealthy instance %s out of group %s: %v", id, group.Name, err)
+			continue
+		}
+		replaced = append(replaced, id)
+	}
+	return subtractIDs(instanceIDs, replaced)
+}
+
+// failInstanceRefresh rolls the group back to the launch configuration the
+// refresh started from, replacing the instances launched since. Refreshes
+// without one, or failing to roll back, end as failed.
+func (w *AutoScalingWorker) failInstanceRefresh(ctx context.Context, group *domain.ScalingGroup, refresh *domain.InstanceRefresh, instanceIDs []uuid.UUID, reason string) {
+	log.Printf("AutoScaling: Group %s refresh failed: %s", group.Name, reason)
+	refresh.StatusReason = reason
+	refresh.BatchStartedAt = nil
+
+	if refresh.Status == domain.InstanceRefreshInProgress && refresh.RollbackConfig != nil {
+		current := group.LaunchConfig()
+		group.SetLaunchConfig(*refresh.RollbackConfig)
+		if err := w.repo.UpdateGroupLaunchTemplate(ctx, group); err != nil {
+			log.Printf("AutoScaling: failed to roll back the launch configuration of group %s: %v", group.Name, err)
+			group.SetLaunchConfig(current)
+		} else {
+			replacements := subtractIDs(instanceIDs, refresh.PendingInstanceIDs)
+			refresh.Status = domain.InstanceRefreshRollingBack
+			refresh.InstancesToReplace = len(replacements)
+			refresh.PendingInstanceIDs = replacements
+			_ = w.eventSvc.RecordEvent(ctx, "AUTOSCALING_REFRESH_ROLLBACK", group.ID.String(), "SCALING_GROUP", map[string]interface{}{
+				"refresh_id": refresh.ID.String(),
+				"reason":     reason,
+			})
+			return
+		}
+	}
+
+	now := w.clock.Now()
+	refresh.Status = domain.InstanceRefreshFailed
+	refresh.EndedAt = &now
+	_ = w.eventSvc.RecordEvent(ctx, "AUTOSCALING_REFRESH_FAILED", group.ID.String(), "SCALING_GROUP", map[string]interface{}{
+		"refresh_id": refresh.ID.String(),
+		"reason":     reason,
+	})
+}
+
+func (w *AutoScalingWorker) finishInstanceRefresh(ctx context.Context, group *domain.ScalingGroup, refresh *domain.InstanceRefresh) {
+	now := w.clock.Now()
+	refresh.EndedAt = &now
+	event := "AUTOSCALING_REFRESH_SUCCEEDED"
+	if refresh.Status == domain.InstanceRefreshRollingBack {
+		refresh.Status = domain.InstanceRefreshRolledBack
+		event = "AUTOSCALING_REFRESH_ROLLED_BACK"
+	} else {
+		refresh.Status = domain.InstanceRefreshSuccessful
+	}
+
+	log.Printf("AutoScaling: Group %s refresh ended as %s", group.Name, refresh.Status)
+	_ = w.eventSvc.RecordEvent(ctx, event, group.ID.String(), "SCALING_GROUP", map[string]interface{}{
+		"refresh_id":           refresh.ID.String(),
+		"instances_to_replace": refresh.InstancesToReplace,
+	})
+}
+
+// saveInstanceRefresh stores the progress of a refresh. A refresh paused or
+// cancelled meanwhile is left as the user set it.
+func (w *AutoScalingWorker) saveInstanceRefresh(ctx context.Context, refresh *domain.InstanceRefresh, from domain.InstanceRefreshStatus) {
+	if err := w.repo.UpdateInstanceRefresh(ctx, refresh, from); err != nil {
+		log.Printf("AutoScaling: failed to save instance refresh %s: %v", refresh.ID, err)
+	}
+}
+
+func intersectIDs(ids, keep []uuid.UUID) []uuid.UUID {
+	set := make(map[uuid.UUID]bool, len(keep))
+	for _, id := range keep {
+		set[id] = true
+	}
+	var result []uuid.UUID
+	for _, id := range ids {
+		if set[id] {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func subtractIDs(ids, remove []uuid.UUID) []uuid.UUID {
+	set := make(map[uuid.UUID]bool, len(remove))
+	for _, id := range remove {
+		set[id] = true
+	}
+	var result []uuid.UUID
+	for _, id := range ids {
+		if !set[id] {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// completeLifecycleActions acts on the instances awaiting a lifecycle action
+// once the action is completed, times out or its hook is removed. It returns
+// the instances of the group still in service, those still awaiting a
+// launching action and how many still await a terminating one.
+func (w *AutoScalingWorker) completeLifecycleActions(ctx context.Context, group *domain.ScalingGroup, waiting []domain.ScalingGroupInstance, hooks []*domain.LifecycleHook, instanceIDs []uuid.UUID) ([]uuid.UUID, []uuid.UUID, int) {
+	now := w.clock.Now()
+	var launching []uuid.UUID
+	terminating := 0
+	for _, inst := range waiting {
+		transition := domain.LifecycleTransitionLaunching
+		if inst.LifecycleState == domain.LifecycleStateTerminatingWait {
+			transition = domain.LifecycleTransitionTerminating
+		}
+		hook := lifecycleHook(hooks, transition)
+
+		result := inst.LifecycleResult
+		cause := fmt.Sprintf("lifecycle action completed with %s", result)
+		switch {
+		case result != "":
+		case hook == nil:
+			result, cause = domain.LifecycleActionContinue, "lifecycle hook removed"
+		case inst.LifecycleTimeoutAt != nil && !now.Before(*inst.LifecycleTimeoutAt):
+			result, cause = hook.DefaultResult, fmt.Sprintf("lifecycle hook %s timed out, defaulting to %s", hook.Name, hook.DefaultResult)
+		default:
+			if transition == domain.LifecycleTransitionTerminating {
+				terminating++
+			} else {
+				launching = append(launching, inst.InstanceID)
+			}
+			continue
+		}
+
+		if transition == domain.LifecycleTransitionTerminating {
+			if err := w.terminateInstance(ctx, group, inst.InstanceID); err != nil {
+				log.Printf("AutoScaling: failed to terminate instance %s after its lifecycle action: %v", inst.InstanceID, err)
+				terminating++
+			}
+			continue
+		}
+
+		if result == domain.LifecycleActionAbandon {
+			// An abandoned launch counts as a failed one, so hooks that keep
+			// abandoning put the group in backoff.
+			err := w.terminateInstance(ctx, group, inst.InstanceID)
+			w.recordActivity(ctx, group, fmt.Sprintf("Terminating instance %s", inst.InstanceID), cause,
+				len(instanceIDs), len(instanceIDs)-1, now, err)
+			if err != nil {
+				log.Printf("AutoScaling: failed to terminate abandoned instance %s: %v", inst.InstanceID, err)
+				continue
+			}
+			instanceIDs = subtractIDs(instanceIDs, []uuid.UUID{inst.InstanceID})
+			w.recordFailure(ctx, group)
+			continue
+		}
+
+		if err := w.repo.SetInstanceLifecycleState(ctx, group.ID, inst.InstanceID, domain.LifecycleStateInService, nil); err != nil {
+			log.Printf("AutoScaling: failed to put instance %s in service: %v", inst.InstanceID, err)
+			launching = append(launching, inst.InstanceID)
+			continue
+		}
+		log.Printf("AutoScaling: instance %s of group %s in service (%s)", inst.InstanceID, group.Name, cause)
+		w.registerTarget(ctx, group, inst.InstanceID)
+	}
+	return instanceIDs, launching, terminating
+}
+
+// awaitLifecycleAction holds an instance in a wait state until its lifecycle
+// action is completed or the hook times out. It reports whether the
+// instance waits.
+func (w *AutoScalingWorker) awaitLifecycleAction(ctx context.Context, group *domain.ScalingGroup, instanceID uuid.UUID, hook *domain.LifecycleHook, state domain.LifecycleState) bool {
+	timeout := w.clock.Now().Add(time.Duration(hook.HeartbeatTimeoutSec) * time.Second)
+	if err := w.repo.SetInstanceLifecycleState(ctx, group.ID, instanceID, state, &timeout); err != nil {
+		log.Printf("AutoScaling: failed to hold instance %s for lifecycle hook %s: %v", instanceID, hook.Name, err)
+		return false
+	}
+	log.Printf("AutoScaling: instance %s of group %s in %s until %s", instanceID, group.Name, state, timeout.Format(time.RFC3339))
+	_ = w.eventSvc.RecordEvent(ctx, "AUTOSCALING_LIFECYCLE_WAIT", group.ID.String(), "SCALING_GROUP", map[string]interface{}{
+		"instance_id":     instanceID.String(),
+		"lifecycle_hook":  hook.Name,
+		"lifecycle_state": string(state),
+		"timeout_at":      timeout,
+	})
+	return true
+}
+
+func lifecycleHook(hooks []*domain.LifecycleHook, transition domain.LifecycleTransition) *domain.LifecycleHook {
+	for _, hook := range hooks {
+		if hook.Transition == transition {
+			return hook
+		}
+	}
+	return nil
+}
+
+// finishDraining terminates scaled-in instances whose load balancer target
+// has drained and returns how many are still draining.
+func (w *AutoScalingWorker) finishDraining(ctx context.Context, group *domain.ScalingGroup, draining []domain.ScalingGroupInstance, hook *domain.LifecycleHook) int {
+	remaining := 0
+	for _, inst := range draining {
+		if inst.DrainUntil != nil && w.clock.Now().Before(*inst.DrainUntil) {
+			remaining++
+			continue
+		}
+		if err := w.retireInstance(ctx, group, inst.InstanceID, hook); err != nil {
+			log.Printf("AutoScaling: failed to terminate drained instance %s: %v", inst.InstanceID, err)
+			remaining++
+		}
+	}
+	return remaining
+}
+
+func (w *AutoScalingWorker) cleanupGroup(ctx context.Context, group *domain.ScalingGroup, instanceIDs []uuid.UUID, draining int) {
 	if len(instanceIDs) == 0 {
+		if draining > 0 {
+			return
+		}
 		// All instances gone, delete the group record
 		if err := w.repo.DeleteGroup(ctx, group.ID); err != nil {
 			log.Printf("AutoScaling: failed to delete group record %s: %v", group.ID, err)
@@ -135,7 +653,7 @@ func (w *AutoScalingWorker) cleanupGroup(ctx context.Context, group *domain.Scal
 	}
 }
 
-func (w *AutoScalingWorker) reconcileInstances(ctx context.Context, group *domain.ScalingGroup, instanceIDs []uuid.UUID) {
+func (w *AutoScalingWorker) reconcileInstances(ctx context.Context, group *domain.ScalingGroup, instanceIDs []uuid.UUID, hooks []*domain.LifecycleHook) {
 	// 1. Check if we need to scale out to meet Desired/Min
 	current := len(instanceIDs)
 
@@ -150,6 +668,9 @@ func (w *AutoScalingWorker) reconcileInstances(ctx context.Context, group *domai
 	}
 
 	if current < group.DesiredCount {
+		if group.IsSuspended(domain.ScalingProcessLaunch) {
+			return
+		}
 		// Check failure backoff before trying to scale out
 		if w.shouldSkipDueToFailures(group) {
 			return
@@ -157,95 +678,248 @@ func (w *AutoScalingWorker) reconcileInstances(ctx context.Context, group *domai
 
 		needed := group.DesiredCount - current
 		log.Printf("AutoScaling: Group %s needs %d more instances (Current: %d, Desired: %d)", group.Name, needed, current, group.DesiredCount)
+		started := w.clock.Now()
+		hook := lifecycleHook(hooks, domain.LifecycleTransitionLaunching)
+		launched := 0
+		var launchErr error
 		for i := 0; i < needed; i++ {
-			if err := w.scaleOut(ctx, group, nil); err != nil {
+			if err := w.scaleOut(ctx, group, hook); err != nil {
 				log.Printf("AutoScaling: failed to scale out group %s: %v", group.Name, err)
 				w.recordFailure(ctx, group)
+				launchErr = err
 				break
 			} else {
+				launched++
 				w.resetFailures(ctx, group)
 			}
 		}
+		w.recordActivity(ctx, group, fmt.Sprintf("Launching instances (%d needed)", needed),
+			fmt.Sprintf("the group has %d of %d desired instances", current, group.DesiredCount), current, current+launched, started, launchErr)
 	} else if current > group.DesiredCount {
+		if group.IsSuspended(domain.ScalingProcessTerminate) {
+			return
+		}
 		excess := current - group.DesiredCount
 		log.Printf("AutoScaling: Group %s has %d excess instances", group.Name, excess)
+		started := w.clock.Now()
+		hook := lifecycleHook(hooks, domain.LifecycleTransitionTerminating)
+		removed := 0
+		var removeErr error
 		for i := 0; i < excess; i++ {
 			// Remove oldest first? Or random?
 			// For simplicity: last one.
 			targetID := instanceIDs[len(instanceIDs)-1-i]
-			if err := w.scaleIn(ctx, group, targetID, nil); err != nil {
+			if err := w.scaleIn(ctx, group, targetID, hook); err != nil {
 				log.Printf("AutoScaling: failed to scale in group %s: %v", group.Name, err)
+				removeErr = err
 				break
 			}
+			removed++
 		}
+		w.recordActivity(ctx, group, fmt.Sprintf("Terminating instances (%d excess)", excess),
+			fmt.Sprintf("the group has %d instances, %d desired", current, group.DesiredCount), current, current-removed, started, removeErr)
 	}
 }
 
+// evaluatePolicies sets the desired count the policies ask for. Any policy
+// can scale the group out, to the largest count asked for, but the group only
+// scales in once every policy with data agrees, to the largest count they ask
+// for, so one busy metric is never starved by an idle one.
 func (w *AutoScalingWorker) evaluatePolicies(ctx context.Context, group *domain.ScalingGroup, instanceIDs []uuid.UUID, policies []*domain.ScalingPolicy) {
 	if len(policies) == 0 {
 		return
 	}
 
-	// We only support 'cpu' metric type for now
-	avgCPU, err := w.repo.GetAverageCPU(ctx, instanceIDs, w.clock.Now().Add(-1*time.Minute))
-	if err != nil {
-		log.Printf("AutoScaling: failed to get metrics for group %s: %v", group.ID, err)
-		return
+	// Policies tracking the same metric over the same period share one query.
+	values := make(map[string]float64)
+	for _, policy := range policies {
+		key := metricKey(policy)
+		if _, ok := values[key]; ok {
+			continue
+		}
+		value, err := w.metricValue(ctx, group, instanceIDs, policy)
+		if errors.Is(err, errors.NotFound) {
+			// No samples in the period, such as instances that have not
+			// reported yet: the policy has no data and sits this one out.
+			continue
+		}
+		if err != nil {
+			log.Printf("AutoScaling: failed to get %s metric for group %s: %v", policy.MetricType, group.ID, err)
+			continue
+		}
+		values[key] = value
 	}
 
+	now := w.clock.Now()
+	current := group.CurrentCount
+	var outPolicies, inPolicies []*domain.ScalingPolicy
+	outDesired, inDesired := current, 0
+	allScaleIn := true
 	for _, policy := range policies {
-		// Cooldown check
-		if policy.LastScaledAt != nil {
-			if w.clock.Now().Sub(*policy.LastScaledAt) < time.Duration(policy.CooldownSec)*time.Second {
+		value, ok := values[metricKey(policy)]
+		if !ok {
+			continue
+		}
+
+		desired := clampDesired(group, policyDesiredCount(policy, current, value))
+		switch {
+		case desired > current:
+			allScaleIn = false
+			if coolingDown(policy.LastScaleOutAt, policy.ScaleOutCooldownSec, now) {
+				continue
+			}
+			log.Printf("AutoScaling: Policy %s asks for %d instances (%s %.2f)", policy.Name, desired, policy.MetricType, value)
+			outPolicies = append(outPolicies, policy)
+			if desired > outDesired {
+				outDesired = desired
+			}
+		case desired < current:
+			if coolingDown(policy.LastScaledAt, policy.ScaleInCooldownSec, now) {
+				allScaleIn = false
 				continue
 			}
+			inPolicies = append(inPolicies, policy)
+			if desired > inDesired {
+				inDesired = desired
+			}
+		default:
+			allScaleIn = false
+		}
+	}
+
+	switch {
+	case len(outPolicies) > 0:
+		// A scale-out still launching already asks for more.
+		if outDesired <= group.DesiredCount {
+			return
+		}
+		log.Printf("AutoScaling: Group %s scaling out to %d instances", group.Name, outDesired)
+		w.applyPolicies(ctx, group, outDesired, outPolicies, true)
+	case len(inPolicies) > 0 && allScaleIn:
+		if inDesired >= group.DesiredCount {
+			return
 		}
+		log.Printf("AutoScaling: Group %s scaling in to %d instances", group.Name, inDesired)
+		w.applyPolicies(ctx, group, inDesired, inPolicies, false)
+	}
+}
 
-		if policy.MetricType == "cpu" {
-			if avgCPU > policy.TargetValue {
-				// Scale Out
-				if group.CurrentCount < group.MaxInstances {
-					log.Printf("AutoScaling: Policy %s triggered Scale Out (CPU %.2f > %.2f)", policy.Name, avgCPU, policy.TargetValue)
-					// Calculate new desired
-					newDesired := group.CurrentCount + policy.ScaleOutStep
-					if newDesired > group.MaxInstances {
-						newDesired = group.MaxInstances
-					}
-					group.DesiredCount = newDesired
-					_ = w.repo.UpdateGroup(ctx, group)
-					// Next tick will reconcile
-
-					// Update policy last scaled
-					_ = w.repo.UpdatePolicyLastScaled(ctx, policy.ID, w.clock.Now())
-					return // Only trigger one policy per tick per group to avoid conflicts
-				}
-			} else if avgCPU < (policy.TargetValue - 10.0) { // arbitrary 10% buffer for scale in
-				// Scale In
-				if group.CurrentCount > group.MinInstances {
-					log.Printf("AutoScaling: Policy %s triggered Scale In (CPU %.2f < %.2f)", policy.Name, avgCPU, policy.TargetValue-10.0)
-					newDesired := group.CurrentCount - policy.ScaleInStep
-					if newDesired < group.MinInstances {
-						newDesired = group.MinInstances
-					}
-					group.DesiredCount = newDesired
-					_ = w.repo.UpdateGroup(ctx, group)
-
-					_ = w.repo.UpdatePolicyLastScaled(ctx, policy.ID, w.clock.Now())
-					return
-				}
+func (w *AutoScalingWorker) applyPolicies(ctx context.Context, group *domain.ScalingGroup, desired int, policies []*domain.ScalingPolicy, scaleOut bool) {
+	names := make([]string, len(policies))
+	for i, policy := range policies {
+		names[i] = policy.Name
+	}
+	prev := group.DesiredCount
+	group.DesiredCount = desired
+	err := w.repo.UpdateGroup(ctx, group)
+	w.recordActivity(ctx, group, fmt.Sprintf("Changing the desired capacity from %d to %d", prev, desired),
+		fmt.Sprintf("policy %s", strings.Join(names, ", ")), prev, desired, w.clock.Now(), err)
+	if err != nil {
+		log.Printf("AutoScaling: failed to update desired count of group %s: %v", group.Name, err)
+		group.DesiredCount = prev
+		return
+	}
+	// Next tick will reconcile
+	for _, policy := range policies {
+		_ = w.repo.UpdatePolicyLastScaled(ctx, policy.ID, w.clock.Now(), scaleOut)
+	}
+}
+
+// policyDesiredCount returns the instance count a policy asks for, which is
+// current when the metric calls for no change.
+func policyDesiredCount(policy *domain.ScalingPolicy, current int, value float64) int {
+	if policy.PolicyType == domain.ScalingPolicyStep {
+		for _, step := range policy.Steps {
+			if step.Contains(value) {
+				return current + step.Adjustment
 			}
 		}
+		return current
+	}
+
+	if value <= policy.TargetValue && value >= policy.ScaleInThreshold {
+		return current
 	}
+	// An empty group with load on a group-wide metric needs an instance to
+	// start from.
+	base := current
+	if base == 0 {
+		base = 1
+	}
+	// The small epsilon keeps float noise from rounding up a count that
+	// exactly meets the target.
+	desired := int(math.Ceil(float64(base)*value/policy.TargetValue - 1e-9))
+	if value > policy.TargetValue && desired <= current {
+		desired = current + 1
+	}
+	return desired
 }
 
-func (w *AutoScalingWorker) scaleOut(ctx context.Context, group *domain.ScalingGroup, policy *domain.ScalingPolicy) error {
+func clampDesired(group *domain.ScalingGroup, desired int) int {
+	if desired > group.MaxInstances {
+		return group.MaxInstances
+	}
+	if desired < group.MinInstances {
+		return group.MinInstances
+	}
+	return desired
+}
+
+func coolingDown(last *time.Time, cooldownSec int, now time.Time) bool {
+	return last != nil && now.Sub(*last) < time.Duration(cooldownSec)*time.Second
+}
+
+func metricKey(policy *domain.ScalingPolicy) string {
+	return fmt.Sprintf("%s/%s/%d", policy.MetricType, policy.MetricName, policy.EvaluationPeriodSec)
+}
+
+// metricValue returns the value of the metric a policy tracks over its
+// evaluation period.
+func (w *AutoScalingWorker) metricValue(ctx context.Context, group *domain.ScalingGroup, instanceIDs []uuid.UUID, policy *domain.ScalingPolicy) (float64, error) {
+	now := w.clock.Now()
+	period := time.Duration(policy.EvaluationPeriodSec) * time.Second
+	if period <= 0 {
+		period = domain.DefaultEvaluationPeriodSeconds * time.Second
+	}
+	since := now.Add(-period)
+
+	switch policy.MetricType {
+	case domain.ScalingMetricCPU:
+		return w.repo.GetAverageCPU(ctx, instanceIDs, since)
+	case domain.ScalingMetricMemory:
+		return w.repo.GetAverageMemory(ctx, instanceIDs, since)
+	case domain.ScalingMetricNetworkIn, domain.ScalingMetricNetworkOut:
+		rx, tx, err := w.repo.GetAverageNetworkThroughput(ctx, instanceIDs, since)
+		if policy.MetricType == domain.ScalingMetricNetworkIn {
+			return rx, err
+		}
+		return tx, err
+	case domain.ScalingMetricLBRequestCount:
+		if group.LoadBalancerID == nil || len(instanceIDs) == 0 {
+			return 0, nil
+		}
+		// Request stats are kept per minute; only count complete minutes.
+		to := now.Truncate(time.Minute)
+		from := to.Add(-period)
+		count, err := w.repo.GetRequestCount(ctx, *group.LoadBalancerID, instanceIDs, from, to)
+		if err != nil {
+			return 0, err
+		}
+		return float64(count) / float64(len(instanceIDs)) / period.Minutes(), nil
+	case domain.ScalingMetricCustom:
+		return w.repo.GetAverageCustomMetric(ctx, group.UserID, policy.MetricName, instanceIDs, since)
+	default:
+		return 0, fmt.Errorf("unsupported metric type %q", policy.MetricType)
+	}
+}
+
+// scaleOut launches an instance into the group. With a launching hook the
+// instance awaits its lifecycle action before it joins the load balancer.
+func (w *AutoScalingWorker) scaleOut(ctx context.Context, group *domain.ScalingGroup, hook *domain.LifecycleHook) error {
 	// Create instance
 	name := fmt.Sprintf("%s-%d", group.Name, w.clock.Now().UnixNano()) // Unique name
 
-	// Use dynamic ports to avoid conflicts on the same host
-	dynamicPorts := toDynamicPorts(group.Ports)
-
-	inst, err := w.instanceSvc.LaunchInstance(ctx, name, group.Image, dynamicPorts, &group.VpcID, nil)
+	inst, err := w.launchInstance(ctx, group, name)
 	if err != nil {
 		return err
 	}
@@ -255,12 +929,8 @@ func (w *AutoScalingWorker) scaleOut(ctx context.Context, group *domain.ScalingG
 		return err
 	}
 
-	// Add to LB
-	if group.LoadBalancerID != nil {
-		if err := w.lbSvc.AddTarget(ctx, *group.LoadBalancerID, inst.ID, 80, 1); err != nil {
-			log.Printf("AutoScaling: failed to add instance to LB: %v", err)
-			// Continue, don't fail the whole scale out.
-		}
+	if hook == nil || !w.awaitLifecycleAction(ctx, group, inst.ID, hook, domain.LifecycleStatePendingWait) {
+		w.registerTarget(ctx, group, inst.ID)
 	}
 
 	platform.AutoScalingScaleOutEvents.Inc()
@@ -272,14 +942,47 @@ func (w *AutoScalingWorker) scaleOut(ctx context.Context, group *domain.ScalingG
 	return nil
 }
 
-func (w *AutoScalingWorker) scaleIn(ctx context.Context, group *domain.ScalingGroup, instanceID uuid.UUID, policy *domain.ScalingPolicy) error {
-	// Remove from LB
+func (w *AutoScalingWorker) registerTarget(ctx context.Context, group *domain.ScalingGroup, instanceID uuid.UUID) {
+	if group.LoadBalancerID == nil {
+		return
+	}
+	if err := w.lbSvc.AddTarget(ctx, *group.LoadBalancerID, instanceID, 80, 1, ""); err != nil {
+		log.Printf("AutoScaling: failed to add instance to LB: %v", err)
+		// Continue, don't fail the whole scale out.
+	}
+}
+
+// scaleIn takes an instance out of the group. With a terminating hook it
+// awaits its lifecycle action, after draining, before it is terminated.
+func (w *AutoScalingWorker) scaleIn(ctx context.Context, group *domain.ScalingGroup, instanceID uuid.UUID, hook *domain.LifecycleHook) error {
+	// Deregister from the LB. The instance keeps serving its in-flight
+	// requests until the target has drained and is terminated afterwards.
 	if group.LoadBalancerID != nil {
-		if err := w.lbSvc.RemoveTarget(ctx, *group.LoadBalancerID, instanceID); err != nil {
+		drainUntil, err := w.lbSvc.RemoveTarget(ctx, *group.LoadBalancerID, instanceID)
+		if err != nil {
 			log.Printf("AutoScaling: failed to remove instance from LB: %v", err)
+		} else if !drainUntil.IsZero() && drainUntil.After(w.clock.Now()) {
+			if err := w.repo.MarkInstanceDraining(ctx, group.ID, instanceID, drainUntil); err != nil {
+				return err
+			}
+			log.Printf("AutoScaling: instance %s draining until %s", instanceID, drainUntil.Format(time.RFC3339))
+			return nil
 		}
 	}
 
+	return w.retireInstance(ctx, group, instanceID, hook)
+}
+
+// retireInstance terminates an instance out of service, holding it in
+// Terminating:Wait first when the group has a terminating hook.
+func (w *AutoScalingWorker) retireInstance(ctx context.Context, group *domain.ScalingGroup, instanceID uuid.UUID, hook *domain.LifecycleHook) error {
+	if hook != nil && w.awaitLifecycleAction(ctx, group, instanceID, hook, domain.LifecycleStateTerminatingWait) {
+		return nil
+	}
+	return w.terminateInstance(ctx, group, instanceID)
+}
+
+func (w *AutoScalingWorker) terminateInstance(ctx context.Context, group *domain.ScalingGroup, instanceID uuid.UUID) error {
 	// Remove from group
 	if err := w.repo.RemoveInstanceFromGroup(ctx, group.ID, instanceID); err != nil {
 		return err
@@ -300,6 +1003,24 @@ func (w *AutoScalingWorker) scaleIn(ctx context.Context, group *domain.ScalingGr
 	return nil
 }
 
+// launchInstance launches an instance of the group from its launch template
+// version, or its image for groups without a template.
+func (w *AutoScalingWorker) launchInstance(ctx context.Context, group *domain.ScalingGroup, name string) (*domain.Instance, error) {
+	if group.LaunchTemplateID == nil {
+		// Use dynamic ports to avoid conflicts on the same host
+		return w.instanceSvc.LaunchInstance(ctx, name, group.Image, toDynamicPorts(group.Ports), &group.VpcID, nil)
+	}
+
+	template, err := w.templateRepo.GetVersion(ctx, *group.LaunchTemplateID, group.LaunchTemplateVersion)
+	if err != nil {
+		return nil, err
+	}
+	spec := *template
+	spec.Ports = toDynamicPorts(template.Ports)
+	spec.VpcID = &group.VpcID
+	return w.instanceSvc.LaunchInstanceFromTemplate(ctx, name, &spec)
+}
+
 func toDynamicPorts(ports string) string {
 	if ports == "" {
 		return ""
@@ -348,3 +1069,40 @@ func (w *AutoScalingWorker) resetFailures(ctx context.Context, group *domain.Sca
 		_ = w.repo.UpdateGroup(ctx, group)
 	}
 }
+
+// recordActivity stores a scaling activity that started at started and ends
+// now, failed if err is set.
+func (w *AutoScalingWorker) recordActivity(ctx context.Context, group *domain.ScalingGroup, description, cause string, from, to int, started time.Time, err error) {
+	activity := &domain.ScalingActivity{
+		ID:             uuid.New(),
+		ScalingGroupID: group.ID,
+		Description:    description,
+		Cause:          cause,
+		FromCapacity:   from,
+		ToCapacity:     to,
+		Status:         domain.ScalingActivitySuccessful,
+		StartedAt:      started,
+		EndedAt:        w.clock.Now(),
+	}
+	if err != nil {
+		activity.Status = domain.ScalingActivityFailed
+		activity.StatusMessage = err.Error()
+	}
+	if err := w.repo.CreateActivity(ctx, activity); err != nil {
+		log.Printf("AutoScaling: failed to record activity of group %s: %v", group.Name, err)
+	}
+}
+
+// pruneActivities deletes the activities past their retention, at most once
+// an hour.
+func (w *AutoScalingWorker) pruneActivities(ctx context.Context) {
+	now := w.clock.Now()
+	if now.Sub(w.lastPruned) < time.Hour {
+		return
+	}
+	if err := w.repo.DeleteActivitiesBefore(ctx, now.Add(-domain.ScalingActivityRetention)); err != nil {
+		log.Printf("AutoScaling: failed to prune activities: %v", err)
+		return
+	}
+	w.lastPruned = now
+}
@@ -2,6 +2,8 @@ package services_test
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -9,6 +11,8 @@ import (
 	appcontext "github.com/poyrazk/thecloud/internal/core/context"
 	"github.com/poyrazk/thecloud/internal/core/domain"
 	"github.com/poyrazk/thecloud/internal/core/services"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -21,7 +25,7 @@ func TestAutoScalingWorker_Logic(t *testing.T) {
 
 	t.Run("Scale Out when current < desired", func(t *testing.T) {
 		asgRepo, instSvc, lbSvc, eventSvc, clock := newMockWorkerDeps()
-		worker := services.NewAutoScalingWorker(asgRepo, instSvc, lbSvc, eventSvc, clock)
+		worker := services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock)
 
 		group := &domain.ScalingGroup{
 			ID:             groupID,
@@ -40,6 +44,7 @@ func TestAutoScalingWorker_Logic(t *testing.T) {
 
 		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
 		asgRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: instances}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
 		asgRepo.On("GetAllPolicies", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{groupID: {}}, nil).Once()
 
 		clock.On("Now").Return(now).Maybe()
@@ -53,7 +58,7 @@ func TestAutoScalingWorker_Logic(t *testing.T) {
 		}), groupID, newInstID).Return(nil).Once()
 		lbSvc.On("AddTarget", mock.MatchedBy(func(ctx context.Context) bool {
 			return appcontext.UserIDFromContext(ctx) == group.UserID
-		}), lbID, newInstID, 80, 1).Return(nil).Once()
+		}), lbID, newInstID, 80, 1, "").Return(nil).Once()
 		eventSvc.On("RecordEvent", mock.MatchedBy(func(ctx context.Context) bool {
 			return appcontext.UserIDFromContext(ctx) == group.UserID
 		}), "AUTOSCALING_SCALE_OUT", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Once()
@@ -68,9 +73,60 @@ func TestAutoScalingWorker_Logic(t *testing.T) {
 		lbSvc.AssertExpectations(t)
 	})
 
+	t.Run("Scale Out from launch template", func(t *testing.T) {
+		asgRepo, instSvc, lbSvc, eventSvc, clock := newMockWorkerDeps()
+		templateRepo := new(MockLaunchTemplateRepo)
+		worker := services.NewAutoScalingWorker(asgRepo, templateRepo, instSvc, lbSvc, eventSvc, clock)
+
+		templateID := uuid.New()
+		group := &domain.ScalingGroup{
+			ID:                    groupID,
+			Name:                  "test-asg",
+			VpcID:                 vpcID,
+			Image:                 "nginx",
+			Ports:                 "80:80",
+			LaunchTemplateID:      &templateID,
+			LaunchTemplateVersion: 2,
+			MinInstances:          1,
+			MaxInstances:          5,
+			DesiredCount:          1,
+			CurrentCount:          0,
+		}
+		template := &domain.LaunchTemplateVersion{
+			TemplateID:   templateID,
+			Version:      2,
+			Image:        "nginx",
+			Ports:        "80:80",
+			InstanceType: "small",
+			Env:          map[string]string{"MODE": "prod"},
+		}
+
+		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
+		asgRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
+		asgRepo.On("GetAllPolicies", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{}, nil).Once()
+		clock.On("Now").Return(now).Maybe()
+
+		newInstID := uuid.New()
+		templateRepo.On("GetVersion", mock.Anything, templateID, 2).Return(template, nil).Once()
+		instSvc.On("LaunchInstanceFromTemplate", mock.Anything, mock.Anything, mock.MatchedBy(func(spec *domain.LaunchTemplateVersion) bool {
+			return spec.Ports == "0:80" && *spec.VpcID == vpcID && spec.InstanceType == "small" && spec.Env["MODE"] == "prod"
+		})).Return(&domain.Instance{ID: newInstID}, nil).Once()
+		asgRepo.On("AddInstanceToGroup", mock.Anything, groupID, newInstID).Return(nil).Once()
+		eventSvc.On("RecordEvent", mock.Anything, "AUTOSCALING_SCALE_OUT", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Once()
+		asgRepo.On("UpdateGroup", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+		worker.Evaluate(ctx)
+
+		instSvc.AssertExpectations(t)
+		templateRepo.AssertExpectations(t)
+		// The template itself is left as it is.
+		assert.Equal(t, "80:80", template.Ports)
+	})
+
 	t.Run("Scale In when current > desired", func(t *testing.T) {
 		asgRepo, instSvc, lbSvc, eventSvc, clock := newMockWorkerDeps()
-		worker := services.NewAutoScalingWorker(asgRepo, instSvc, lbSvc, eventSvc, clock)
+		worker := services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock)
 
 		instID1 := uuid.New()
 		instID2 := uuid.New()
@@ -87,13 +143,15 @@ func TestAutoScalingWorker_Logic(t *testing.T) {
 
 		instances := []uuid.UUID{instID1, instID2}
 
+		clock.On("Now").Return(now).Maybe()
 		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
 		asgRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: instances}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
 		asgRepo.On("GetAllPolicies", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{groupID: {}}, nil).Once()
 
 		lbSvc.On("RemoveTarget", mock.MatchedBy(func(ctx context.Context) bool {
 			return appcontext.UserIDFromContext(ctx) == group.UserID
-		}), lbID, instID2).Return(nil).Once()
+		}), lbID, instID2).Return(time.Time{}, nil).Once()
 		asgRepo.On("RemoveInstanceFromGroup", mock.MatchedBy(func(ctx context.Context) bool {
 			return appcontext.UserIDFromContext(ctx) == group.UserID
 		}), groupID, instID2).Return(nil).Once()
@@ -113,7 +171,7 @@ func TestAutoScalingWorker_Logic(t *testing.T) {
 
 	t.Run("Policy trigger scale out", func(t *testing.T) {
 		asgRepo, instSvc, lbSvc, eventSvc, clock := newMockWorkerDeps()
-		worker := services.NewAutoScalingWorker(asgRepo, instSvc, lbSvc, eventSvc, clock)
+		worker := services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock)
 
 		group := &domain.ScalingGroup{
 			ID:           groupID,
@@ -124,16 +182,18 @@ func TestAutoScalingWorker_Logic(t *testing.T) {
 		}
 		instanceIDs := []uuid.UUID{uuid.New()}
 		policy := &domain.ScalingPolicy{
-			ID:           uuid.New(),
-			Name:         "cpu-high",
-			MetricType:   "cpu",
-			TargetValue:  70.0,
-			ScaleOutStep: 1,
-			CooldownSec:  300,
+			ID:                  uuid.New(),
+			Name:                "cpu-high",
+			MetricType:          "cpu",
+			TargetValue:         70.0,
+			ScaleInThreshold:    60.0,
+			ScaleOutCooldownSec: 300,
+			ScaleInCooldownSec:  300,
 		}
 
 		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
 		asgRepo.On("GetAllScalingGroupInstances", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: instanceIDs}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
 		asgRepo.On("GetAllPolicies", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{groupID: {policy}}, nil).Once()
 
 		clock.On("Now").Return(now).Maybe()
@@ -148,7 +208,7 @@ func TestAutoScalingWorker_Logic(t *testing.T) {
 		})).Return(nil).Once()
 		asgRepo.On("UpdatePolicyLastScaled", mock.MatchedBy(func(ctx context.Context) bool {
 			return appcontext.UserIDFromContext(ctx) == group.UserID
-		}), policy.ID, mock.Anything).Return(nil).Once()
+		}), policy.ID, mock.Anything, true).Return(nil).Once()
 
 		worker.Evaluate(ctx)
 
@@ -157,7 +217,7 @@ func TestAutoScalingWorker_Logic(t *testing.T) {
 
 	t.Run("Policy trigger scale in", func(t *testing.T) {
 		asgRepo, instSvc, lbSvc, eventSvc, clock := newMockWorkerDeps()
-		worker := services.NewAutoScalingWorker(asgRepo, instSvc, lbSvc, eventSvc, clock)
+		worker := services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock)
 
 		group := &domain.ScalingGroup{
 			ID:           groupID,
@@ -168,21 +228,23 @@ func TestAutoScalingWorker_Logic(t *testing.T) {
 		}
 		instanceIDs := []uuid.UUID{uuid.New(), uuid.New()}
 		policy := &domain.ScalingPolicy{
-			ID:          uuid.New(),
-			MetricType:  "cpu",
-			TargetValue: 70.0,
-			ScaleInStep: 1,
-			CooldownSec: 300,
+			ID:                  uuid.New(),
+			MetricType:          "cpu",
+			TargetValue:         70.0,
+			ScaleInThreshold:    60.0,
+			ScaleOutCooldownSec: 300,
+			ScaleInCooldownSec:  300,
 		}
 
 		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
 		asgRepo.On("GetAllScalingGroupInstances", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: instanceIDs}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
 		asgRepo.On("GetAllPolicies", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{groupID: {policy}}, nil).Once()
 
 		clock.On("Now").Return(now).Maybe()
 		asgRepo.On("GetAverageCPU", mock.MatchedBy(func(ctx context.Context) bool {
 			return appcontext.UserIDFromContext(ctx) == group.UserID
-		}), instanceIDs, mock.Anything).Return(40.0, nil).Once()
+		}), instanceIDs, mock.Anything).Return(30.0, nil).Once()
 
 		asgRepo.On("UpdateGroup", mock.MatchedBy(func(ctx context.Context) bool {
 			return appcontext.UserIDFromContext(ctx) == group.UserID
@@ -191,7 +253,7 @@ func TestAutoScalingWorker_Logic(t *testing.T) {
 		})).Return(nil).Once()
 		asgRepo.On("UpdatePolicyLastScaled", mock.MatchedBy(func(ctx context.Context) bool {
 			return appcontext.UserIDFromContext(ctx) == group.UserID
-		}), policy.ID, mock.Anything).Return(nil).Once()
+		}), policy.ID, mock.Anything, false).Return(nil).Once()
 
 		worker.Evaluate(ctx)
 
@@ -200,7 +262,7 @@ func TestAutoScalingWorker_Logic(t *testing.T) {
 
 	t.Run("Policy skipped due to cooldown", func(t *testing.T) {
 		asgRepo, instSvc, lbSvc, eventSvc, clock := newMockWorkerDeps()
-		worker := services.NewAutoScalingWorker(asgRepo, instSvc, lbSvc, eventSvc, clock)
+		worker := services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock)
 
 		lastScaled := now.Add(-1 * time.Minute)
 		group := &domain.ScalingGroup{
@@ -212,26 +274,24 @@ func TestAutoScalingWorker_Logic(t *testing.T) {
 		}
 		instanceIDs := []uuid.UUID{uuid.New()}
 		policy := &domain.ScalingPolicy{
-			ID:           uuid.New(),
-			MetricType:   "cpu",
-			TargetValue:  70.0,
-			ScaleOutStep: 1,
-			CooldownSec:  300, // 5 min
-			LastScaledAt: &lastScaled,
+			ID:                  uuid.New(),
+			MetricType:          "cpu",
+			TargetValue:         70.0,
+			ScaleInThreshold:    60.0,
+			ScaleOutCooldownSec: 300, // 5 min
+			ScaleInCooldownSec:  300,
+			LastScaledAt:        &lastScaled,
+			LastScaleOutAt:      &lastScaled,
 		}
 
 		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
 		asgRepo.On("GetAllScalingGroupInstances", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: instanceIDs}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
 		asgRepo.On("GetAllPolicies", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{groupID: {policy}}, nil).Once()
 
 		clock.On("Now").Return(now).Maybe()
-		// No GetAverageCPU should be called because cooldown skips it
-		// Actually, current implementation in evaluatePolicies:
-		// for _, policy := range policies {
-		//    if cooldown... continue
-		// }
-		// Wait, GetAverageCPU is called BEFORE the loop.
-		// So it WILL be called, but the policy evaluation loop will skip the action.
+		// The metric is read before the cooldown is checked, but the
+		// policy takes no action.
 		asgRepo.On("GetAverageCPU", mock.MatchedBy(func(ctx context.Context) bool {
 			return appcontext.UserIDFromContext(ctx) == group.UserID
 		}), instanceIDs, mock.Anything).Return(80.0, nil).Once()
@@ -244,8 +304,245 @@ func TestAutoScalingWorker_Logic(t *testing.T) {
 	})
 }
 
+func TestAutoScalingWorker_PolicyMetrics(t *testing.T) {
+	ctx := context.Background()
+	groupID := uuid.New()
+	lbID := uuid.New()
+	now := time.Date(2026, 1, 2, 12, 0, 30, 0, time.UTC)
+	instanceIDs := []uuid.UUID{uuid.New(), uuid.New()}
+
+	setup := func(policies ...*domain.ScalingPolicy) (*services.AutoScalingWorker, *MockAutoScalingRepo, *domain.ScalingGroup) {
+		asgRepo, instSvc, lbSvc, eventSvc, clock := newMockWorkerDeps()
+		group := &domain.ScalingGroup{
+			ID:             groupID,
+			UserID:         uuid.New(),
+			LoadBalancerID: &lbID,
+			CurrentCount:   2,
+			DesiredCount:   2,
+			MinInstances:   1,
+			MaxInstances:   5,
+		}
+		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
+		asgRepo.On("GetAllScalingGroupInstances", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: instanceIDs}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
+		asgRepo.On("GetAllPolicies", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{groupID: policies}, nil).Once()
+		clock.On("Now").Return(now).Maybe()
+		return services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock), asgRepo, group
+	}
+	desired := func(n int) interface{} {
+		return mock.MatchedBy(func(g *domain.ScalingGroup) bool { return g.DesiredCount == n })
+	}
+	tracking := func(metricType string, target, scaleIn float64) *domain.ScalingPolicy {
+		return &domain.ScalingPolicy{
+			ID:                  uuid.New(),
+			PolicyType:          domain.ScalingPolicyTargetTracking,
+			MetricType:          metricType,
+			TargetValue:         target,
+			ScaleInThreshold:    scaleIn,
+			EvaluationPeriodSec: 60,
+			ScaleOutCooldownSec: 300,
+			ScaleInCooldownSec:  300,
+		}
+	}
+
+	t.Run("memory policy scales out", func(t *testing.T) {
+		policy := tracking(domain.ScalingMetricMemory, 70, 63)
+		worker, asgRepo, _ := setup(policy)
+		asgRepo.On("GetAverageMemory", mock.Anything, instanceIDs, now.Add(-time.Minute)).Return(85.0, nil).Once()
+		// 2 instances at 85% hold 3 instances at 70%, rounded up.
+		asgRepo.On("UpdateGroup", mock.Anything, desired(3)).Return(nil).Once()
+		asgRepo.On("UpdatePolicyLastScaled", mock.Anything, policy.ID, now, true).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		asgRepo.AssertNotCalled(t, "GetAverageCPU", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("network policy scales in below its threshold", func(t *testing.T) {
+		policy := tracking(domain.ScalingMetricNetworkOut, 1000, 900)
+		worker, asgRepo, _ := setup(policy)
+		asgRepo.On("GetAverageNetworkThroughput", mock.Anything, instanceIDs, now.Add(-time.Minute)).Return(5000.0, 400.0, nil).Once()
+		asgRepo.On("UpdateGroup", mock.Anything, desired(1)).Return(nil).Once()
+		asgRepo.On("UpdatePolicyLastScaled", mock.Anything, policy.ID, now, false).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+	})
+
+	t.Run("request count is per target and minute", func(t *testing.T) {
+		policy := tracking(domain.ScalingMetricLBRequestCount, 500, 450)
+		worker, asgRepo, _ := setup(policy)
+		minute := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+		// 1200 requests over two targets in the last complete minute.
+		asgRepo.On("GetRequestCount", mock.Anything, lbID, instanceIDs, minute.Add(-time.Minute), minute).Return(int64(1200), nil).Once()
+		asgRepo.On("UpdateGroup", mock.Anything, desired(3)).Return(nil).Once()
+		asgRepo.On("UpdatePolicyLastScaled", mock.Anything, policy.ID, now, true).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+	})
+
+	t.Run("custom policy without data is skipped", func(t *testing.T) {
+		custom := tracking(domain.ScalingMetricCustom, 10, 9)
+		custom.MetricName = "queue_depth"
+		cpu := tracking(domain.ScalingMetricCPU, 70, 63)
+		worker, asgRepo, group := setup(custom, cpu)
+		asgRepo.On("GetAverageCustomMetric", mock.Anything, group.UserID, "queue_depth", instanceIDs, now.Add(-time.Minute)).
+			Return(0.0, errors.New(errors.NotFound, "no data points")).Once()
+		asgRepo.On("GetAverageCPU", mock.Anything, instanceIDs, now.Add(-time.Minute)).Return(65.0, nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		asgRepo.AssertNotCalled(t, "UpdateGroup", mock.Anything, mock.Anything)
+	})
+
+	t.Run("step policy applies the band holding the value", func(t *testing.T) {
+		bound := func(v float64) *float64 { return &v }
+		policy := &domain.ScalingPolicy{
+			ID:         uuid.New(),
+			PolicyType: domain.ScalingPolicyStep,
+			MetricType: domain.ScalingMetricCPU,
+			Steps: []domain.ScalingStep{
+				{UpperBound: bound(20), Adjustment: -1},
+				{LowerBound: bound(70), UpperBound: bound(90), Adjustment: 1},
+				{LowerBound: bound(90), Adjustment: 3},
+			},
+			EvaluationPeriodSec: 60,
+			ScaleOutCooldownSec: 300,
+			ScaleInCooldownSec:  300,
+		}
+		worker, asgRepo, _ := setup(policy)
+		asgRepo.On("GetAverageCPU", mock.Anything, instanceIDs, now.Add(-time.Minute)).Return(95.0, nil).Once()
+		// 2 + 3 is capped at the group maximum.
+		asgRepo.On("UpdateGroup", mock.Anything, desired(5)).Return(nil).Once()
+		asgRepo.On("UpdatePolicyLastScaled", mock.Anything, policy.ID, now, true).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+	})
+
+	t.Run("evaluation period sets the metric window", func(t *testing.T) {
+		policy := tracking(domain.ScalingMetricCPU, 70, 63)
+		policy.EvaluationPeriodSec = 300
+		worker, asgRepo, _ := setup(policy)
+		asgRepo.On("GetAverageCPU", mock.Anything, instanceIDs, now.Add(-5*time.Minute)).Return(65.0, nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		asgRepo.AssertNotCalled(t, "UpdateGroup", mock.Anything, mock.Anything)
+	})
+
+	t.Run("scale in waits for every policy", func(t *testing.T) {
+		cpu := tracking(domain.ScalingMetricCPU, 70, 63)
+		memory := tracking(domain.ScalingMetricMemory, 70, 63)
+		worker, asgRepo, _ := setup(cpu, memory)
+		asgRepo.On("GetAverageCPU", mock.Anything, instanceIDs, mock.Anything).Return(10.0, nil).Once()
+		asgRepo.On("GetAverageMemory", mock.Anything, instanceIDs, mock.Anything).Return(65.0, nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		asgRepo.AssertNotCalled(t, "UpdateGroup", mock.Anything, mock.Anything)
+	})
+
+	t.Run("empty metric window does not scale in", func(t *testing.T) {
+		policy := tracking(domain.ScalingMetricCPU, 70, 63)
+		worker, asgRepo, _ := setup(policy)
+		asgRepo.On("GetAverageCPU", mock.Anything, instanceIDs, mock.Anything).
+			Return(0.0, errors.New(errors.NotFound, "no data points for metric cpu")).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		asgRepo.AssertNotCalled(t, "UpdateGroup", mock.Anything, mock.Anything)
+	})
+
+	t.Run("policies without data do not hold back scale in", func(t *testing.T) {
+		cpu := tracking(domain.ScalingMetricCPU, 70, 63)
+		memory := tracking(domain.ScalingMetricMemory, 70, 63)
+		worker, asgRepo, _ := setup(cpu, memory)
+		asgRepo.On("GetAverageCPU", mock.Anything, instanceIDs, mock.Anything).Return(10.0, nil).Once()
+		asgRepo.On("GetAverageMemory", mock.Anything, instanceIDs, mock.Anything).
+			Return(0.0, errors.New(errors.NotFound, "no data points for metric memory")).Once()
+		asgRepo.On("UpdateGroup", mock.Anything, desired(1)).Return(nil).Once()
+		asgRepo.On("UpdatePolicyLastScaled", mock.Anything, cpu.ID, now, false).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+	})
+
+	t.Run("scale out wins over scale in", func(t *testing.T) {
+		cpu := tracking(domain.ScalingMetricCPU, 70, 63)
+		memory := tracking(domain.ScalingMetricMemory, 70, 63)
+		worker, asgRepo, _ := setup(cpu, memory)
+		asgRepo.On("GetAverageCPU", mock.Anything, instanceIDs, mock.Anything).Return(10.0, nil).Once()
+		asgRepo.On("GetAverageMemory", mock.Anything, instanceIDs, mock.Anything).Return(140.0, nil).Once()
+		asgRepo.On("UpdateGroup", mock.Anything, desired(4)).Return(nil).Once()
+		asgRepo.On("UpdatePolicyLastScaled", mock.Anything, memory.ID, now, true).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+	})
+
+	t.Run("scale-in cooldown does not hold back scale out", func(t *testing.T) {
+		lastScaled := now.Add(-2 * time.Minute)
+		policy := tracking(domain.ScalingMetricCPU, 70, 63)
+		policy.ScaleOutCooldownSec = 60
+		policy.ScaleInCooldownSec = 600
+		policy.LastScaledAt = &lastScaled
+		policy.LastScaleOutAt = &lastScaled
+		worker, asgRepo, _ := setup(policy)
+		asgRepo.On("GetAverageCPU", mock.Anything, instanceIDs, mock.Anything).Return(90.0, nil).Once()
+		asgRepo.On("UpdateGroup", mock.Anything, desired(3)).Return(nil).Once()
+		asgRepo.On("UpdatePolicyLastScaled", mock.Anything, policy.ID, now, true).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+
+		// The same policy may not scale in yet.
+		worker, asgRepo, _ = setup(policy)
+		asgRepo.On("GetAverageCPU", mock.Anything, instanceIDs, mock.Anything).Return(10.0, nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		asgRepo.AssertNotCalled(t, "UpdateGroup", mock.Anything, mock.Anything)
+	})
+}
+
+// newMockWorkerDeps returns the worker dependencies with no scheduled actions
+// due.
 func newMockWorkerDeps() (*MockAutoScalingRepo, *MockInstanceService, *MockLBService, *MockEventService, *MockClock) {
-	return new(MockAutoScalingRepo), new(MockInstanceService), new(MockLBService), new(MockEventService), new(MockClock)
+	asgRepo := new(MockAutoScalingRepo)
+	asgRepo.On("GetDueScheduledActions", mock.Anything, mock.Anything, mock.Anything).Return(map[uuid.UUID][]*domain.ScheduledAction{}, nil).Maybe()
+	asgRepo.On("GetRunningInstanceRefreshes", mock.Anything, mock.Anything).Return(map[uuid.UUID]*domain.InstanceRefresh{}, nil).Maybe()
+	allowNoLifecycleHooks(asgRepo)
+	instSvc := new(MockInstanceService)
+	allowHealthyInstances(instSvc)
+	return asgRepo, instSvc, new(MockLBService), new(MockEventService), new(MockClock)
+}
+
+// allowHealthyInstances reports every instance running.
+func allowHealthyInstances(instSvc *MockInstanceService) {
+	instSvc.On("RefreshInstanceStatus", mock.Anything, mock.Anything).Return(&domain.Instance{Status: domain.StatusRunning}, nil).Maybe()
+}
+
+// allowNoLifecycleHooks sets the repository up for groups without lifecycle
+// hooks and accepts any activities.
+func allowNoLifecycleHooks(asgRepo *MockAutoScalingRepo) {
+	asgRepo.On("GetAllLifecycleHooks", mock.Anything, mock.Anything).Return(map[uuid.UUID][]*domain.LifecycleHook{}, nil).Maybe()
+	asgRepo.On("GetAllWaitingInstances", mock.Anything, mock.Anything).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Maybe()
+	asgRepo.On("CreateActivity", mock.Anything, mock.Anything).Return(nil).Maybe()
+	asgRepo.On("DeleteActivitiesBefore", mock.Anything, mock.Anything).Return(nil).Maybe()
 }
 
 func TestAutoScalingWorker_CleanupGroup(t *testing.T) {
@@ -256,7 +553,7 @@ func TestAutoScalingWorker_CleanupGroup(t *testing.T) {
 
 	t.Run("Cleanup group deletes all instances then group", func(t *testing.T) {
 		asgRepo, instSvc, lbSvc, eventSvc, clock := newMockWorkerDeps()
-		worker := services.NewAutoScalingWorker(asgRepo, instSvc, lbSvc, eventSvc, clock)
+		worker := services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock)
 
 		instID := uuid.New()
 		group := &domain.ScalingGroup{
@@ -269,12 +566,13 @@ func TestAutoScalingWorker_CleanupGroup(t *testing.T) {
 
 		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
 		asgRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: instances}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
 		asgRepo.On("GetAllPolicies", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{groupID: {}}, nil).Once()
 
 		clock.On("Now").Return(now).Maybe()
 
 		// Expect cleanup: RemoveTarget, RemoveInstanceFromGroup, TerminateInstance
-		lbSvc.On("RemoveTarget", mock.Anything, lbID, instID).Return(nil).Once()
+		lbSvc.On("RemoveTarget", mock.Anything, lbID, instID).Return(time.Time{}, nil).Once()
 		asgRepo.On("RemoveInstanceFromGroup", mock.Anything, groupID, instID).Return(nil).Once()
 		instSvc.On("TerminateInstance", mock.Anything, instID.String()).Return(nil).Once()
 		eventSvc.On("RecordEvent", mock.Anything, "AUTOSCALING_SCALE_IN", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Once()
@@ -288,7 +586,7 @@ func TestAutoScalingWorker_CleanupGroup(t *testing.T) {
 
 	t.Run("Cleanup group deletes record when no instances left", func(t *testing.T) {
 		asgRepo, instSvc, lbSvc, eventSvc, clock := newMockWorkerDeps()
-		worker := services.NewAutoScalingWorker(asgRepo, instSvc, lbSvc, eventSvc, clock)
+		worker := services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock)
 
 		group := &domain.ScalingGroup{
 			ID:     groupID,
@@ -298,6 +596,7 @@ func TestAutoScalingWorker_CleanupGroup(t *testing.T) {
 
 		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
 		asgRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: {}}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
 		asgRepo.On("GetAllPolicies", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{groupID: {}}, nil).Once()
 
 		// Expect group deletion
@@ -319,7 +618,7 @@ func TestAutoScalingWorker_FailureBackoff(t *testing.T) {
 
 	t.Run("Skip scale out due to failure backoff", func(t *testing.T) {
 		asgRepo, instSvc, lbSvc, eventSvc, clock := newMockWorkerDeps()
-		worker := services.NewAutoScalingWorker(asgRepo, instSvc, lbSvc, eventSvc, clock)
+		worker := services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock)
 
 		group := &domain.ScalingGroup{
 			ID:            groupID,
@@ -334,6 +633,7 @@ func TestAutoScalingWorker_FailureBackoff(t *testing.T) {
 
 		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
 		asgRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: {uuid.New()}}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
 		asgRepo.On("GetAllPolicies", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{groupID: {}}, nil).Once()
 
 		clock.On("Now").Return(now).Maybe()
@@ -347,7 +647,7 @@ func TestAutoScalingWorker_FailureBackoff(t *testing.T) {
 
 	t.Run("Resume scaling after backoff expires", func(t *testing.T) {
 		asgRepo, instSvc, lbSvc, eventSvc, clock := newMockWorkerDeps()
-		worker := services.NewAutoScalingWorker(asgRepo, instSvc, lbSvc, eventSvc, clock)
+		worker := services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock)
 
 		tenMinutesAgo := now.Add(-10 * time.Minute) // Past the 5 min backoff
 		group := &domain.ScalingGroup{
@@ -366,6 +666,7 @@ func TestAutoScalingWorker_FailureBackoff(t *testing.T) {
 		instID := uuid.New()
 		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
 		asgRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: {instID}}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
 		asgRepo.On("GetAllPolicies", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{groupID: {}}, nil).Once()
 
 		clock.On("Now").Return(now).Maybe()
@@ -390,7 +691,7 @@ func TestAutoScalingWorker_ReconcileDesiredBounds(t *testing.T) {
 
 	t.Run("Force desired to min when below", func(t *testing.T) {
 		asgRepo, instSvc, lbSvc, eventSvc, clock := newMockWorkerDeps()
-		worker := services.NewAutoScalingWorker(asgRepo, instSvc, lbSvc, eventSvc, clock)
+		worker := services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock)
 
 		group := &domain.ScalingGroup{
 			ID:           groupID,
@@ -403,6 +704,7 @@ func TestAutoScalingWorker_ReconcileDesiredBounds(t *testing.T) {
 
 		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
 		asgRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: {uuid.New()}}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
 		asgRepo.On("GetAllPolicies", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{groupID: {}}, nil).Once()
 
 		clock.On("Now").Return(now).Maybe()
@@ -426,7 +728,7 @@ func TestAutoScalingWorker_ReconcileDesiredBounds(t *testing.T) {
 
 	t.Run("Force desired to max when above", func(t *testing.T) {
 		asgRepo, instSvc, lbSvc, eventSvc, clock := newMockWorkerDeps()
-		worker := services.NewAutoScalingWorker(asgRepo, instSvc, lbSvc, eventSvc, clock)
+		worker := services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock)
 
 		group := &domain.ScalingGroup{
 			ID:           groupID,
@@ -439,6 +741,7 @@ func TestAutoScalingWorker_ReconcileDesiredBounds(t *testing.T) {
 
 		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
 		asgRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: instances}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
 		asgRepo.On("GetAllPolicies", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{groupID: {}}, nil).Once()
 
 		clock.On("Now").Return(now).Maybe()
@@ -456,3 +759,697 @@ func TestAutoScalingWorker_ReconcileDesiredBounds(t *testing.T) {
 		instSvc.AssertNotCalled(t, "TerminateInstance", mock.Anything, mock.Anything)
 	})
 }
+
+func TestAutoScalingWorker_ConnectionDraining(t *testing.T) {
+	ctx := context.Background()
+	groupID := uuid.New()
+	lbID := uuid.New()
+	now := time.Now()
+
+	t.Run("Scale in waits for the target to drain", func(t *testing.T) {
+		asgRepo, instSvc, lbSvc, eventSvc, clock := newMockWorkerDeps()
+		worker := services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock)
+
+		keep, excess := uuid.New(), uuid.New()
+		group := &domain.ScalingGroup{
+			ID: groupID, Name: "drain-asg", LoadBalancerID: &lbID,
+			MinInstances: 1, MaxInstances: 5, DesiredCount: 1, CurrentCount: 2,
+		}
+		drainUntil := now.Add(30 * time.Second)
+
+		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
+		asgRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: {keep, excess}}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
+		asgRepo.On("GetAllPolicies", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{}, nil).Once()
+		clock.On("Now").Return(now).Maybe()
+
+		lbSvc.On("RemoveTarget", mock.Anything, lbID, excess).Return(drainUntil, nil).Once()
+		asgRepo.On("MarkInstanceDraining", mock.Anything, groupID, excess, drainUntil).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		lbSvc.AssertExpectations(t)
+		instSvc.AssertNotCalled(t, "TerminateInstance", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Drained instances are terminated", func(t *testing.T) {
+		asgRepo, instSvc, lbSvc, eventSvc, clock := newMockWorkerDeps()
+		worker := services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock)
+
+		keep, drained, draining := uuid.New(), uuid.New(), uuid.New()
+		past, future := now.Add(-time.Second), now.Add(time.Minute)
+		group := &domain.ScalingGroup{
+			ID: groupID, Name: "drain-asg", LoadBalancerID: &lbID,
+			MinInstances: 1, MaxInstances: 5, DesiredCount: 1, CurrentCount: 1,
+		}
+
+		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
+		asgRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: {keep}}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{groupID: {
+			{ScalingGroupID: groupID, InstanceID: drained, DrainUntil: &past},
+			{ScalingGroupID: groupID, InstanceID: draining, DrainUntil: &future},
+		}}, nil).Once()
+		asgRepo.On("GetAllPolicies", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{}, nil).Once()
+		clock.On("Now").Return(now).Maybe()
+
+		asgRepo.On("RemoveInstanceFromGroup", mock.Anything, groupID, drained).Return(nil).Once()
+		instSvc.On("TerminateInstance", mock.Anything, drained.String()).Return(nil).Once()
+		eventSvc.On("RecordEvent", mock.Anything, "AUTOSCALING_SCALE_IN", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		instSvc.AssertExpectations(t)
+		instSvc.AssertNotCalled(t, "TerminateInstance", mock.Anything, draining.String())
+	})
+}
+
+func TestAutoScalingWorker_ScheduledActions(t *testing.T) {
+	ctx := context.Background()
+	groupID := uuid.New()
+	// Friday 08:00 in New York.
+	now := time.Date(2026, 1, 2, 13, 0, 0, 0, time.UTC)
+	intPtr := func(v int) *int { return &v }
+
+	setup := func(group *domain.ScalingGroup, actions ...*domain.ScheduledAction) (*services.AutoScalingWorker, *MockAutoScalingRepo, *MockEventService) {
+		asgRepo, instSvc, lbSvc, eventSvc, clock := new(MockAutoScalingRepo), new(MockInstanceService), new(MockLBService), new(MockEventService), new(MockClock)
+		allowHealthyInstances(instSvc)
+		instances := make([]uuid.UUID, group.CurrentCount)
+		for i := range instances {
+			instances[i] = uuid.New()
+		}
+		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
+		asgRepo.On("GetAllScalingGroupInstances", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: instances}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
+		asgRepo.On("GetAllPolicies", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{}, nil).Once()
+		asgRepo.On("GetDueScheduledActions", mock.Anything, []uuid.UUID{groupID}, now).Return(map[uuid.UUID][]*domain.ScheduledAction{groupID: actions}, nil).Once()
+		asgRepo.On("GetRunningInstanceRefreshes", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID]*domain.InstanceRefresh{}, nil).Once()
+		allowNoLifecycleHooks(asgRepo)
+		clock.On("Now").Return(now).Maybe()
+		eventSvc.On("RecordEvent", mock.Anything, "AUTOSCALING_SCHEDULED_ACTION", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Maybe()
+		return services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock), asgRepo, eventSvc
+	}
+
+	t.Run("resizes the group and schedules the next run", func(t *testing.T) {
+		// Already at the new size, so the resize launches nothing.
+		group := &domain.ScalingGroup{ID: groupID, Name: "web", MinInstances: 1, MaxInstances: 10, DesiredCount: 2, CurrentCount: 6}
+		action := &domain.ScheduledAction{
+			ID: uuid.New(), Name: "morning", Schedule: "0 8 * * MON-FRI", TimeZone: "America/New_York",
+			MinInstances: intPtr(4), DesiredCount: intPtr(6),
+		}
+		worker, asgRepo, eventSvc := setup(group, action)
+
+		asgRepo.On("UpdateGroup", mock.Anything, mock.MatchedBy(func(g *domain.ScalingGroup) bool {
+			return g.MinInstances == 4 && g.MaxInstances == 10 && g.DesiredCount == 6
+		})).Return(nil)
+		// Monday 08:00 in New York.
+		asgRepo.On("UpdateScheduledActionRun", mock.Anything, action.ID, now, mock.MatchedBy(func(next time.Time) bool {
+			return next.Equal(time.Date(2026, 1, 5, 13, 0, 0, 0, time.UTC))
+		})).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		eventSvc.AssertCalled(t, "RecordEvent", mock.Anything, "AUTOSCALING_SCHEDULED_ACTION", groupID.String(), "SCALING_GROUP", mock.Anything)
+	})
+
+	t.Run("later actions win and desired follows the bounds", func(t *testing.T) {
+		group := &domain.ScalingGroup{ID: groupID, Name: "web", MinInstances: 1, MaxInstances: 10, DesiredCount: 1, CurrentCount: 3}
+		morning := &domain.ScheduledAction{ID: uuid.New(), Name: "morning", Schedule: "0 8 * * *", TimeZone: "UTC", DesiredCount: intPtr(6)}
+		capped := &domain.ScheduledAction{ID: uuid.New(), Name: "cap", Schedule: "0 13 * * *", TimeZone: "UTC", MaxInstances: intPtr(3)}
+		worker, asgRepo, _ := setup(group, morning, capped)
+
+		asgRepo.On("UpdateGroup", mock.Anything, mock.Anything).Return(nil)
+		asgRepo.On("UpdateScheduledActionRun", mock.Anything, morning.ID, now, mock.Anything).Return(nil).Once()
+		asgRepo.On("UpdateScheduledActionRun", mock.Anything, capped.ID, now, mock.Anything).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		assert.Equal(t, 3, group.MaxInstances)
+		assert.Equal(t, 3, group.DesiredCount)
+		asgRepo.AssertExpectations(t)
+	})
+
+	t.Run("conflicting sizes are skipped but rescheduled", func(t *testing.T) {
+		group := &domain.ScalingGroup{ID: groupID, Name: "web", MinInstances: 1, MaxInstances: 3, DesiredCount: 2, CurrentCount: 2}
+		action := &domain.ScheduledAction{ID: uuid.New(), Name: "bad", Schedule: "0 13 * * *", TimeZone: "UTC", MinInstances: intPtr(5)}
+		worker, asgRepo, _ := setup(group, action)
+
+		asgRepo.On("UpdateScheduledActionRun", mock.Anything, action.ID, now, time.Date(2026, 1, 3, 13, 0, 0, 0, time.UTC)).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		asgRepo.AssertNotCalled(t, "UpdateGroup", mock.Anything, mock.Anything)
+		assert.Equal(t, 1, group.MinInstances)
+	})
+}
+
+func TestAutoScalingWorker_SuspendedProcesses(t *testing.T) {
+	ctx := context.Background()
+	groupID := uuid.New()
+	now := time.Now()
+
+	setup := func(group *domain.ScalingGroup, actions []*domain.ScheduledAction, refresh *domain.InstanceRefresh) (*services.AutoScalingWorker, *MockAutoScalingRepo, *MockInstanceService) {
+		asgRepo, instSvc, lbSvc, eventSvc, clock := new(MockAutoScalingRepo), new(MockInstanceService), new(MockLBService), new(MockEventService), new(MockClock)
+		allowHealthyInstances(instSvc)
+		instances := make([]uuid.UUID, group.CurrentCount)
+		for i := range instances {
+			instances[i] = uuid.New()
+		}
+		refreshes := map[uuid.UUID]*domain.InstanceRefresh{}
+		if refresh != nil {
+			refreshes[groupID] = refresh
+		}
+		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
+		asgRepo.On("GetAllScalingGroupInstances", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: instances}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
+		asgRepo.On("GetAllPolicies", mock.Anything, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{}, nil).Once()
+		asgRepo.On("GetDueScheduledActions", mock.Anything, []uuid.UUID{groupID}, now).Return(map[uuid.UUID][]*domain.ScheduledAction{groupID: actions}, nil).Once()
+		asgRepo.On("GetRunningInstanceRefreshes", mock.Anything, []uuid.UUID{groupID}).Return(refreshes, nil).Once()
+		allowNoLifecycleHooks(asgRepo)
+		clock.On("Now").Return(now).Maybe()
+		return services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock), asgRepo, instSvc
+	}
+
+	t.Run("launch suspended keeps the group short", func(t *testing.T) {
+		group := &domain.ScalingGroup{ID: groupID, Name: "web", Image: "nginx", MinInstances: 1, MaxInstances: 5, DesiredCount: 3, CurrentCount: 1,
+			SuspendedProcesses: []domain.ScalingProcess{domain.ScalingProcessLaunch}}
+		worker, _, instSvc := setup(group, nil, nil)
+
+		worker.Evaluate(ctx)
+
+		instSvc.AssertNotCalled(t, "LaunchInstance", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("terminate suspended keeps excess instances", func(t *testing.T) {
+		group := &domain.ScalingGroup{ID: groupID, Name: "web", MinInstances: 1, MaxInstances: 5, DesiredCount: 1, CurrentCount: 3,
+			SuspendedProcesses: []domain.ScalingProcess{domain.ScalingProcessTerminate}}
+		worker, asgRepo, instSvc := setup(group, nil, nil)
+
+		worker.Evaluate(ctx)
+
+		instSvc.AssertNotCalled(t, "TerminateInstance", mock.Anything, mock.Anything)
+		asgRepo.AssertNotCalled(t, "RemoveInstanceFromGroup", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("scheduled actions suspended stay due", func(t *testing.T) {
+		group := &domain.ScalingGroup{ID: groupID, Name: "web", MinInstances: 1, MaxInstances: 5, DesiredCount: 2, CurrentCount: 2,
+			SuspendedProcesses: []domain.ScalingProcess{domain.ScalingProcessScheduledActions}}
+		desired := 4
+		action := &domain.ScheduledAction{ID: uuid.New(), Name: "peak", Schedule: "0 * * * *", TimeZone: "UTC", DesiredCount: &desired}
+		worker, asgRepo, _ := setup(group, []*domain.ScheduledAction{action}, nil)
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertNotCalled(t, "UpdateScheduledActionRun", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		asgRepo.AssertNotCalled(t, "UpdateGroup", mock.Anything, mock.Anything)
+		assert.Equal(t, 2, group.DesiredCount)
+	})
+
+	t.Run("refresh holds and restarts the batch timeout", func(t *testing.T) {
+		group := &domain.ScalingGroup{ID: groupID, Name: "web", MinInstances: 1, MaxInstances: 5, DesiredCount: 2, CurrentCount: 2,
+			SuspendedProcesses: []domain.ScalingProcess{domain.ScalingProcessLaunch}}
+		batchStarted := now.Add(-time.Hour)
+		refresh := &domain.InstanceRefresh{ID: uuid.New(), ScalingGroupID: groupID, Status: domain.InstanceRefreshInProgress,
+			MinHealthyPercentage: 50, HealthCheckTimeoutSec: 600, InstancesToReplace: 2, BatchStartedAt: &batchStarted}
+		worker, asgRepo, _ := setup(group, nil, refresh)
+		asgRepo.On("UpdateInstanceRefresh", mock.Anything, mock.MatchedBy(func(r *domain.InstanceRefresh) bool {
+			return r.Status == domain.InstanceRefreshInProgress && r.BatchStartedAt.Equal(now)
+		}), domain.InstanceRefreshInProgress).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+	})
+}
+
+func TestAutoScalingWorker_InstanceRefresh(t *testing.T) {
+	ctx := context.Background()
+	groupID := uuid.New()
+	vpcID := uuid.New()
+	now := time.Now()
+
+	setup := func(group *domain.ScalingGroup, instances []uuid.UUID, refresh *domain.InstanceRefresh) (*services.AutoScalingWorker, *MockAutoScalingRepo, *MockInstanceService, *MockLBService, *MockEventService) {
+		asgRepo, instSvc, lbSvc, eventSvc, clock := new(MockAutoScalingRepo), new(MockInstanceService), new(MockLBService), new(MockEventService), new(MockClock)
+		allowHealthyInstances(instSvc)
+		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
+		asgRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: instances}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
+		asgRepo.On("GetAllPolicies", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{}, nil).Once()
+		asgRepo.On("GetDueScheduledActions", ctx, []uuid.UUID{groupID}, now).Return(map[uuid.UUID][]*domain.ScheduledAction{}, nil).Once()
+		asgRepo.On("GetRunningInstanceRefreshes", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID]*domain.InstanceRefresh{groupID: refresh}, nil).Once()
+		allowNoLifecycleHooks(asgRepo)
+		clock.On("Now").Return(now).Maybe()
+		return services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock), asgRepo, instSvc, lbSvc, eventSvc
+	}
+
+	t.Run("Replaces a batch within the min healthy percentage", func(t *testing.T) {
+		old := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+		group := &domain.ScalingGroup{ID: groupID, Name: "web", VpcID: vpcID, Image: "nginx:1.27", MinInstances: 1, MaxInstances: 8, DesiredCount: 4, CurrentCount: 4}
+		refresh := &domain.InstanceRefresh{
+			ID: uuid.New(), ScalingGroupID: groupID, Status: domain.InstanceRefreshInProgress,
+			MinHealthyPercentage: 50, HealthCheckTimeoutSec: 600, InstancesToReplace: 4, PendingInstanceIDs: old,
+		}
+		worker, asgRepo, instSvc, _, eventSvc := setup(group, old, refresh)
+
+		// Half of the group is replaced at once.
+		for _, id := range old[:2] {
+			asgRepo.On("RemoveInstanceFromGroup", mock.Anything, groupID, id).Return(nil).Once()
+			instSvc.On("TerminateInstance", mock.Anything, id.String()).Return(nil).Once()
+		}
+		eventSvc.On("RecordEvent", mock.Anything, "AUTOSCALING_SCALE_IN", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Twice()
+		asgRepo.On("UpdateInstanceRefresh", mock.Anything, mock.MatchedBy(func(r *domain.InstanceRefresh) bool {
+			return len(r.PendingInstanceIDs) == 2 && r.PendingInstanceIDs[0] == old[2] && r.BatchStartedAt != nil
+		}), domain.InstanceRefreshInProgress).Return(nil).Once()
+		eventSvc.On("RecordEvent", mock.Anything, "AUTOSCALING_REFRESH_BATCH", groupID.String(), "SCALING_GROUP", mock.MatchedBy(func(meta map[string]interface{}) bool {
+			return meta["instances_replaced"] == 2 && meta["instances_to_replace"] == 4
+		})).Return(nil).Once()
+
+		// Reconciliation launches the replacements in the same tick.
+		instSvc.On("LaunchInstance", mock.Anything, mock.Anything, "nginx:1.27", "", &vpcID, mock.Anything).Return(&domain.Instance{ID: uuid.New()}, nil).Twice()
+		asgRepo.On("AddInstanceToGroup", mock.Anything, groupID, mock.Anything).Return(nil).Twice()
+		eventSvc.On("RecordEvent", mock.Anything, "AUTOSCALING_SCALE_OUT", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Twice()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		instSvc.AssertExpectations(t)
+		eventSvc.AssertExpectations(t)
+	})
+
+	t.Run("Launches the replacement first at 100 percent", func(t *testing.T) {
+		oldID := uuid.New()
+		group := &domain.ScalingGroup{ID: groupID, Name: "web", VpcID: vpcID, Image: "nginx:1.27", MinInstances: 1, MaxInstances: 2, DesiredCount: 1, CurrentCount: 1}
+		refresh := &domain.InstanceRefresh{
+			ID: uuid.New(), ScalingGroupID: groupID, Status: domain.InstanceRefreshInProgress,
+			MinHealthyPercentage: 100, HealthCheckTimeoutSec: 600, InstancesToReplace: 1, PendingInstanceIDs: []uuid.UUID{oldID},
+		}
+		worker, asgRepo, instSvc, _, eventSvc := setup(group, []uuid.UUID{oldID}, refresh)
+
+		// The old instance stays in service while the replacement launches.
+		asgRepo.On("UpdateInstanceRefresh", mock.Anything, mock.MatchedBy(func(r *domain.InstanceRefresh) bool {
+			return len(r.PendingInstanceIDs) == 1 && r.BatchStartedAt != nil && r.BatchStartedAt.Equal(now)
+		}), domain.InstanceRefreshInProgress).Return(nil).Once()
+		instSvc.On("LaunchInstance", mock.Anything, mock.Anything, "nginx:1.27", "", &vpcID, mock.Anything).Return(&domain.Instance{ID: uuid.New()}, nil).Once()
+		asgRepo.On("AddInstanceToGroup", mock.Anything, groupID, mock.Anything).Return(nil).Once()
+		eventSvc.On("RecordEvent", mock.Anything, "AUTOSCALING_SCALE_OUT", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		instSvc.AssertExpectations(t)
+		instSvc.AssertNotCalled(t, "TerminateInstance", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Terminates the old instance once its replacement is healthy at 100 percent", func(t *testing.T) {
+		oldID, newID := uuid.New(), uuid.New()
+		batchStarted := now.Add(-time.Minute)
+		group := &domain.ScalingGroup{ID: groupID, Name: "web", VpcID: vpcID, Image: "nginx:1.27", MinInstances: 1, MaxInstances: 2, DesiredCount: 1, CurrentCount: 2}
+		refresh := &domain.InstanceRefresh{
+			ID: uuid.New(), ScalingGroupID: groupID, Status: domain.InstanceRefreshInProgress,
+			MinHealthyPercentage: 100, HealthCheckTimeoutSec: 600, InstancesToReplace: 1, PendingInstanceIDs: []uuid.UUID{oldID},
+			BatchStartedAt: &batchStarted,
+		}
+		worker, asgRepo, instSvc, _, eventSvc := setup(group, []uuid.UUID{oldID, newID}, refresh)
+
+		instSvc.On("GetInstance", mock.Anything, newID.String()).Return(&domain.Instance{ID: newID, Status: domain.StatusRunning}, nil).Once()
+		asgRepo.On("RemoveInstanceFromGroup", mock.Anything, groupID, oldID).Return(nil).Once()
+		instSvc.On("TerminateInstance", mock.Anything, oldID.String()).Return(nil).Once()
+		eventSvc.On("RecordEvent", mock.Anything, "AUTOSCALING_SCALE_IN", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Once()
+		asgRepo.On("UpdateInstanceRefresh", mock.Anything, mock.MatchedBy(func(r *domain.InstanceRefresh) bool {
+			return len(r.PendingInstanceIDs) == 0
+		}), domain.InstanceRefreshInProgress).Return(nil).Once()
+		eventSvc.On("RecordEvent", mock.Anything, "AUTOSCALING_REFRESH_BATCH", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		instSvc.AssertExpectations(t)
+		instSvc.AssertNotCalled(t, "LaunchInstance", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Fails at 100 percent when the group is at its maximum", func(t *testing.T) {
+		oldID := uuid.New()
+		group := &domain.ScalingGroup{ID: groupID, Name: "web", VpcID: vpcID, Image: "nginx:1.27", MinInstances: 1, MaxInstances: 1, DesiredCount: 1, CurrentCount: 1}
+		refresh := &domain.InstanceRefresh{
+			ID: uuid.New(), ScalingGroupID: groupID, Status: domain.InstanceRefreshInProgress,
+			MinHealthyPercentage: 100, HealthCheckTimeoutSec: 600, InstancesToReplace: 1, PendingInstanceIDs: []uuid.UUID{oldID},
+		}
+		worker, asgRepo, instSvc, _, eventSvc := setup(group, []uuid.UUID{oldID}, refresh)
+
+		asgRepo.On("UpdateInstanceRefresh", mock.Anything, mock.MatchedBy(func(r *domain.InstanceRefresh) bool {
+			return r.Status == domain.InstanceRefreshFailed && strings.Contains(r.StatusReason, "maximum")
+		}), domain.InstanceRefreshInProgress).Return(nil).Once()
+		eventSvc.On("RecordEvent", mock.Anything, "AUTOSCALING_REFRESH_FAILED", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		instSvc.AssertNotCalled(t, "TerminateInstance", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Waits for the replacements to turn healthy", func(t *testing.T) {
+		lbID := uuid.New()
+		oldID, newID := uuid.New(), uuid.New()
+		batchStarted := now.Add(-time.Minute)
+		group := &domain.ScalingGroup{ID: groupID, Name: "web", VpcID: vpcID, LoadBalancerID: &lbID, Image: "nginx:1.27", MinInstances: 1, MaxInstances: 4, DesiredCount: 2, CurrentCount: 2}
+		refresh := &domain.InstanceRefresh{
+			ID: uuid.New(), ScalingGroupID: groupID, Status: domain.InstanceRefreshInProgress,
+			MinHealthyPercentage: 50, HealthCheckTimeoutSec: 600, InstancesToReplace: 2, PendingInstanceIDs: []uuid.UUID{oldID},
+			BatchStartedAt: &batchStarted,
+		}
+		worker, asgRepo, instSvc, lbSvc, _ := setup(group, []uuid.UUID{oldID, newID}, refresh)
+
+		lbSvc.On("ListTargets", mock.Anything, lbID).Return([]*domain.LBTarget{
+			{InstanceID: oldID, Health: domain.TargetHealthHealthy},
+			{InstanceID: newID, Health: domain.TargetHealthUnknown},
+		}, nil).Once()
+		asgRepo.On("UpdateInstanceRefresh", mock.Anything, mock.MatchedBy(func(r *domain.InstanceRefresh) bool {
+			return r.Status == domain.InstanceRefreshInProgress && len(r.PendingInstanceIDs) == 1 && r.BatchStartedAt.Equal(batchStarted)
+		}), domain.InstanceRefreshInProgress).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		lbSvc.AssertNotCalled(t, "RemoveTarget", mock.Anything, mock.Anything, mock.Anything)
+		instSvc.AssertNotCalled(t, "TerminateInstance", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rolls back when the replacements stay unhealthy", func(t *testing.T) {
+		oldID, newID := uuid.New(), uuid.New()
+		batchStarted := now.Add(-11 * time.Minute)
+		group := &domain.ScalingGroup{ID: groupID, Name: "web", VpcID: vpcID, Image: "nginx:1.27", Ports: "80:80", MinInstances: 1, MaxInstances: 4, DesiredCount: 2, CurrentCount: 2}
+		refresh := &domain.InstanceRefresh{
+			ID: uuid.New(), ScalingGroupID: groupID, Status: domain.InstanceRefreshInProgress,
+			MinHealthyPercentage: 50, HealthCheckTimeoutSec: 600, InstancesToReplace: 2, PendingInstanceIDs: []uuid.UUID{oldID},
+			BatchStartedAt: &batchStarted,
+			RollbackConfig: &domain.LaunchConfig{Image: "nginx:1.26", Ports: "80:80"},
+		}
+		worker, asgRepo, instSvc, _, eventSvc := setup(group, []uuid.UUID{oldID, newID}, refresh)
+
+		instSvc.On("GetInstance", mock.Anything, newID.String()).Return(&domain.Instance{ID: newID, Status: domain.StatusError}, nil).Once()
+		asgRepo.On("UpdateGroupLaunchTemplate", mock.Anything, mock.MatchedBy(func(g *domain.ScalingGroup) bool {
+			return g.Image == "nginx:1.26"
+		})).Return(nil).Once()
+		asgRepo.On("UpdateInstanceRefresh", mock.Anything, mock.MatchedBy(func(r *domain.InstanceRefresh) bool {
+			return r.Status == domain.InstanceRefreshRollingBack && r.InstancesToReplace == 1 &&
+				len(r.PendingInstanceIDs) == 1 && r.PendingInstanceIDs[0] == newID && r.BatchStartedAt == nil
+		}), domain.InstanceRefreshInProgress).Return(nil).Once()
+		eventSvc.On("RecordEvent", mock.Anything, "AUTOSCALING_REFRESH_ROLLBACK", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		eventSvc.AssertExpectations(t)
+		instSvc.AssertNotCalled(t, "TerminateInstance", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Fails without a configuration to roll back to", func(t *testing.T) {
+		oldID, newID := uuid.New(), uuid.New()
+		batchStarted := now.Add(-11 * time.Minute)
+		group := &domain.ScalingGroup{ID: groupID, Name: "web", VpcID: vpcID, Image: "nginx:1.27", MinInstances: 1, MaxInstances: 4, DesiredCount: 2, CurrentCount: 2}
+		refresh := &domain.InstanceRefresh{
+			ID: uuid.New(), ScalingGroupID: groupID, Status: domain.InstanceRefreshInProgress,
+			MinHealthyPercentage: 50, HealthCheckTimeoutSec: 600, InstancesToReplace: 2, PendingInstanceIDs: []uuid.UUID{oldID},
+			BatchStartedAt: &batchStarted,
+		}
+		worker, asgRepo, instSvc, _, eventSvc := setup(group, []uuid.UUID{oldID, newID}, refresh)
+
+		instSvc.On("GetInstance", mock.Anything, newID.String()).Return(&domain.Instance{ID: newID, Status: domain.StatusStarting}, nil).Once()
+		asgRepo.On("UpdateInstanceRefresh", mock.Anything, mock.MatchedBy(func(r *domain.InstanceRefresh) bool {
+			return r.Status == domain.InstanceRefreshFailed && r.EndedAt != nil && r.StatusReason != ""
+		}), domain.InstanceRefreshInProgress).Return(nil).Once()
+		eventSvc.On("RecordEvent", mock.Anything, "AUTOSCALING_REFRESH_FAILED", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		asgRepo.AssertNotCalled(t, "UpdateGroupLaunchTemplate", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Completes once every instance is replaced", func(t *testing.T) {
+		newIDs := []uuid.UUID{uuid.New(), uuid.New()}
+		batchStarted := now.Add(-time.Minute)
+		group := &domain.ScalingGroup{ID: groupID, Name: "web", VpcID: vpcID, Image: "nginx:1.27", MinInstances: 1, MaxInstances: 4, DesiredCount: 2, CurrentCount: 2}
+		refresh := &domain.InstanceRefresh{
+			ID: uuid.New(), ScalingGroupID: groupID, Status: domain.InstanceRefreshRollingBack,
+			MinHealthyPercentage: 50, HealthCheckTimeoutSec: 600, InstancesToReplace: 2, BatchStartedAt: &batchStarted,
+		}
+		worker, asgRepo, instSvc, _, eventSvc := setup(group, newIDs, refresh)
+
+		for _, id := range newIDs {
+			instSvc.On("GetInstance", mock.Anything, id.String()).Return(&domain.Instance{ID: id, Status: domain.StatusRunning}, nil).Once()
+		}
+		asgRepo.On("UpdateInstanceRefresh", mock.Anything, mock.MatchedBy(func(r *domain.InstanceRefresh) bool {
+			return r.Status == domain.InstanceRefreshRolledBack && r.EndedAt != nil && r.BatchStartedAt == nil
+		}), domain.InstanceRefreshRollingBack).Return(nil).Once()
+		eventSvc.On("RecordEvent", mock.Anything, "AUTOSCALING_REFRESH_ROLLED_BACK", groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		instSvc.AssertExpectations(t)
+		eventSvc.AssertExpectations(t)
+	})
+}
+
+func TestAutoScalingWorker_LifecycleHooks(t *testing.T) {
+	ctx := context.Background()
+	groupID := uuid.New()
+	vpcID := uuid.New()
+	lbID := uuid.New()
+	now := time.Now()
+	launchHook := &domain.LifecycleHook{ID: uuid.New(), ScalingGroupID: groupID, Name: "warm-cache", Transition: domain.LifecycleTransitionLaunching, HeartbeatTimeoutSec: 300, DefaultResult: domain.LifecycleActionAbandon}
+	terminateHook := &domain.LifecycleHook{ID: uuid.New(), ScalingGroupID: groupID, Name: "flush-queue", Transition: domain.LifecycleTransitionTerminating, HeartbeatTimeoutSec: 600, DefaultResult: domain.LifecycleActionContinue}
+
+	setup := func(group *domain.ScalingGroup, instances []uuid.UUID, waiting []domain.ScalingGroupInstance, hooks ...*domain.LifecycleHook) (*services.AutoScalingWorker, *MockAutoScalingRepo, *MockInstanceService, *MockLBService) {
+		asgRepo, instSvc, lbSvc, eventSvc, clock := new(MockAutoScalingRepo), new(MockInstanceService), new(MockLBService), new(MockEventService), new(MockClock)
+		allowHealthyInstances(instSvc)
+		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
+		asgRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: instances}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
+		asgRepo.On("GetAllPolicies", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{}, nil).Once()
+		asgRepo.On("GetDueScheduledActions", ctx, []uuid.UUID{groupID}, now).Return(map[uuid.UUID][]*domain.ScheduledAction{}, nil).Once()
+		asgRepo.On("GetRunningInstanceRefreshes", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID]*domain.InstanceRefresh{}, nil).Once()
+		asgRepo.On("GetAllLifecycleHooks", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.LifecycleHook{groupID: hooks}, nil).Once()
+		asgRepo.On("GetAllWaitingInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{groupID: waiting}, nil).Once()
+		asgRepo.On("DeleteActivitiesBefore", ctx, now.Add(-domain.ScalingActivityRetention)).Return(nil).Once()
+		asgRepo.On("UpdateGroup", mock.Anything, mock.Anything).Return(nil).Maybe()
+		clock.On("Now").Return(now).Maybe()
+		eventSvc.On("RecordEvent", mock.Anything, mock.Anything, groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Maybe()
+		return services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock), asgRepo, instSvc, lbSvc
+	}
+	newGroup := func(current, desired int) *domain.ScalingGroup {
+		return &domain.ScalingGroup{ID: groupID, Name: "web", VpcID: vpcID, LoadBalancerID: &lbID, Image: "nginx", Ports: "80:80", MinInstances: 0, MaxInstances: 5, CurrentCount: current, DesiredCount: desired}
+	}
+
+	t.Run("Holds launched instances until the action completes", func(t *testing.T) {
+		worker, asgRepo, instSvc, lbSvc := setup(newGroup(0, 1), nil, nil, launchHook)
+		newInstID := uuid.New()
+		timeout := now.Add(300 * time.Second)
+		instSvc.On("LaunchInstance", mock.Anything, mock.Anything, "nginx", "0:80", &vpcID, []domain.VolumeAttachment(nil)).Return(&domain.Instance{ID: newInstID}, nil).Once()
+		asgRepo.On("AddInstanceToGroup", mock.Anything, groupID, newInstID).Return(nil).Once()
+		asgRepo.On("SetInstanceLifecycleState", mock.Anything, groupID, newInstID, domain.LifecycleStatePendingWait, &timeout).Return(nil).Once()
+		asgRepo.On("CreateActivity", mock.Anything, mock.MatchedBy(func(a *domain.ScalingActivity) bool {
+			return a.FromCapacity == 0 && a.ToCapacity == 1 && a.Status == domain.ScalingActivitySuccessful && a.Cause == "the group has 0 of 1 desired instances"
+		})).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		lbSvc.AssertNotCalled(t, "AddTarget", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Puts the instance in service on CONTINUE", func(t *testing.T) {
+		instID := uuid.New()
+		waiting := []domain.ScalingGroupInstance{{ScalingGroupID: groupID, InstanceID: instID, LifecycleState: domain.LifecycleStatePendingWait, LifecycleTimeoutAt: ptrTime(now.Add(time.Minute)), LifecycleResult: domain.LifecycleActionContinue}}
+		worker, asgRepo, _, lbSvc := setup(newGroup(1, 1), []uuid.UUID{instID}, waiting, launchHook)
+		asgRepo.On("SetInstanceLifecycleState", mock.Anything, groupID, instID, domain.LifecycleStateInService, (*time.Time)(nil)).Return(nil).Once()
+		lbSvc.On("AddTarget", mock.Anything, lbID, instID, 80, 1, "").Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		lbSvc.AssertExpectations(t)
+	})
+
+	t.Run("Keeps waiting before the timeout", func(t *testing.T) {
+		instID := uuid.New()
+		waiting := []domain.ScalingGroupInstance{{ScalingGroupID: groupID, InstanceID: instID, LifecycleState: domain.LifecycleStatePendingWait, LifecycleTimeoutAt: ptrTime(now.Add(time.Minute))}}
+		worker, asgRepo, instSvc, lbSvc := setup(newGroup(1, 1), []uuid.UUID{instID}, waiting, launchHook)
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertNotCalled(t, "SetInstanceLifecycleState", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		instSvc.AssertNotCalled(t, "TerminateInstance", mock.Anything, mock.Anything)
+		lbSvc.AssertNotCalled(t, "AddTarget", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Terminates the instance when the hook times out with ABANDON", func(t *testing.T) {
+		instID := uuid.New()
+		waiting := []domain.ScalingGroupInstance{{ScalingGroupID: groupID, InstanceID: instID, LifecycleState: domain.LifecycleStatePendingWait, LifecycleTimeoutAt: ptrTime(now.Add(-time.Second))}}
+		group := newGroup(1, 0)
+		worker, asgRepo, instSvc, _ := setup(group, []uuid.UUID{instID}, waiting, launchHook)
+		asgRepo.On("RemoveInstanceFromGroup", mock.Anything, groupID, instID).Return(nil).Once()
+		instSvc.On("TerminateInstance", mock.Anything, instID.String()).Return(nil).Once()
+		asgRepo.On("CreateActivity", mock.Anything, mock.MatchedBy(func(a *domain.ScalingActivity) bool {
+			return a.Cause == "lifecycle hook warm-cache timed out, defaulting to ABANDON" && a.FromCapacity == 1 && a.ToCapacity == 0
+		})).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		instSvc.AssertExpectations(t)
+		// Abandoned launches count towards the failure backoff.
+		assert.Equal(t, 1, group.FailureCount)
+	})
+
+	t.Run("Holds scaled-in instances before terminating them", func(t *testing.T) {
+		keep, remove := uuid.New(), uuid.New()
+		group := newGroup(2, 1)
+		group.LoadBalancerID = nil
+		worker, asgRepo, instSvc, _ := setup(group, []uuid.UUID{keep, remove}, nil, terminateHook)
+		timeout := now.Add(600 * time.Second)
+		asgRepo.On("SetInstanceLifecycleState", mock.Anything, groupID, remove, domain.LifecycleStateTerminatingWait, &timeout).Return(nil).Once()
+		asgRepo.On("CreateActivity", mock.Anything, mock.MatchedBy(func(a *domain.ScalingActivity) bool {
+			return a.FromCapacity == 2 && a.ToCapacity == 1
+		})).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		instSvc.AssertNotCalled(t, "TerminateInstance", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Terminates once the hook is removed", func(t *testing.T) {
+		instID := uuid.New()
+		waiting := []domain.ScalingGroupInstance{{ScalingGroupID: groupID, InstanceID: instID, LifecycleState: domain.LifecycleStateTerminatingWait, LifecycleTimeoutAt: ptrTime(now.Add(time.Hour))}}
+		worker, asgRepo, instSvc, _ := setup(newGroup(0, 0), nil, waiting)
+		asgRepo.On("RemoveInstanceFromGroup", mock.Anything, groupID, instID).Return(nil).Once()
+		instSvc.On("TerminateInstance", mock.Anything, instID.String()).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		instSvc.AssertExpectations(t)
+	})
+}
+
+func TestAutoScalingWorker_ReplaceUnhealthy(t *testing.T) {
+	ctx := context.Background()
+	groupID := uuid.New()
+	vpcID := uuid.New()
+	lbID := uuid.New()
+	now := time.Now()
+	launchedAt := now.Add(-time.Hour)
+
+	setup := func(group *domain.ScalingGroup, instances []uuid.UUID) (*services.AutoScalingWorker, *MockAutoScalingRepo, *MockInstanceService, *MockLBService, *MockEventService) {
+		asgRepo, instSvc, lbSvc, eventSvc, clock := new(MockAutoScalingRepo), new(MockInstanceService), new(MockLBService), new(MockEventService), new(MockClock)
+		asgRepo.On("ListAllGroups", ctx).Return([]*domain.ScalingGroup{group}, nil).Once()
+		asgRepo.On("GetAllScalingGroupInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]uuid.UUID{groupID: instances}, nil).Once()
+		asgRepo.On("GetAllDrainingInstances", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Once()
+		asgRepo.On("GetAllPolicies", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID][]*domain.ScalingPolicy{}, nil).Once()
+		asgRepo.On("GetDueScheduledActions", ctx, []uuid.UUID{groupID}, now).Return(map[uuid.UUID][]*domain.ScheduledAction{}, nil).Once()
+		asgRepo.On("GetRunningInstanceRefreshes", ctx, []uuid.UUID{groupID}).Return(map[uuid.UUID]*domain.InstanceRefresh{}, nil).Once()
+		asgRepo.On("DeleteActivitiesBefore", ctx, now.Add(-domain.ScalingActivityRetention)).Return(nil).Once()
+		asgRepo.On("GetAllLifecycleHooks", mock.Anything, mock.Anything).Return(map[uuid.UUID][]*domain.LifecycleHook{}, nil).Maybe()
+		asgRepo.On("GetAllWaitingInstances", mock.Anything, mock.Anything).Return(map[uuid.UUID][]domain.ScalingGroupInstance{}, nil).Maybe()
+		asgRepo.On("UpdateGroup", mock.Anything, mock.Anything).Return(nil).Maybe()
+		clock.On("Now").Return(now).Maybe()
+		eventSvc.On("RecordEvent", mock.Anything, mock.Anything, groupID.String(), "SCALING_GROUP", mock.Anything).Return(nil).Maybe()
+		return services.NewAutoScalingWorker(asgRepo, new(MockLaunchTemplateRepo), instSvc, lbSvc, eventSvc, clock), asgRepo, instSvc, lbSvc, eventSvc
+	}
+	newGroup := func(current int) *domain.ScalingGroup {
+		return &domain.ScalingGroup{ID: groupID, Name: "web", VpcID: vpcID, Image: "nginx", Ports: "80:80", MaxInstances: 5, CurrentCount: current, DesiredCount: current,
+			HealthCheckType: domain.ScalingHealthCheckContainer, HealthCheckGracePeriodSec: 300}
+	}
+
+	t.Run("Replaces instances whose container exited", func(t *testing.T) {
+		healthy, crashed, replacement := uuid.New(), uuid.New(), uuid.New()
+		worker, asgRepo, instSvc, _, eventSvc := setup(newGroup(2), []uuid.UUID{healthy, crashed})
+		instSvc.On("RefreshInstanceStatus", mock.Anything, healthy.String()).Return(&domain.Instance{ID: healthy, Status: domain.StatusRunning, CreatedAt: launchedAt}, nil).Once()
+		instSvc.On("RefreshInstanceStatus", mock.Anything, crashed.String()).Return(&domain.Instance{ID: crashed, Status: domain.StatusError, CreatedAt: launchedAt}, nil).Once()
+		asgRepo.On("RemoveInstanceFromGroup", mock.Anything, groupID, crashed).Return(nil).Once()
+		instSvc.On("TerminateInstance", mock.Anything, crashed.String()).Return(nil).Once()
+		instSvc.On("LaunchInstance", mock.Anything, mock.Anything, "nginx", "0:80", &vpcID, []domain.VolumeAttachment(nil)).Return(&domain.Instance{ID: replacement}, nil).Once()
+		asgRepo.On("AddInstanceToGroup", mock.Anything, groupID, replacement).Return(nil).Once()
+		asgRepo.On("CreateActivity", mock.Anything, mock.MatchedBy(func(a *domain.ScalingActivity) bool {
+			return a.Description == fmt.Sprintf("Terminating unhealthy instance %s", crashed) && a.Cause == "instance is ERROR" && a.FromCapacity == 2 && a.ToCapacity == 1
+		})).Return(nil).Once()
+		asgRepo.On("CreateActivity", mock.Anything, mock.MatchedBy(func(a *domain.ScalingActivity) bool {
+			return a.FromCapacity == 1 && a.ToCapacity == 2
+		})).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		instSvc.AssertExpectations(t)
+		eventSvc.AssertCalled(t, "RecordEvent", mock.Anything, "AUTOSCALING_UNHEALTHY_INSTANCE", groupID.String(), "SCALING_GROUP", map[string]interface{}{
+			"instance_id": crashed.String(),
+			"reason":      "instance is ERROR",
+		})
+	})
+
+	t.Run("Replaces instances failing load balancer health checks", func(t *testing.T) {
+		healthy, failing := uuid.New(), uuid.New()
+		group := newGroup(2)
+		group.LoadBalancerID = &lbID
+		group.HealthCheckType = domain.ScalingHealthCheckLB
+		group.SuspendedProcesses = []domain.ScalingProcess{domain.ScalingProcessLaunch}
+		worker, asgRepo, instSvc, lbSvc, _ := setup(group, []uuid.UUID{healthy, failing})
+		instSvc.On("RefreshInstanceStatus", mock.Anything, mock.Anything).Return(&domain.Instance{Status: domain.StatusRunning, CreatedAt: launchedAt}, nil)
+		lbSvc.On("ListTargets", mock.Anything, lbID).Return([]*domain.LBTarget{
+			{InstanceID: healthy, Health: domain.TargetHealthHealthy},
+			{InstanceID: failing, Health: domain.TargetHealthUnhealthy},
+		}, nil).Once()
+		lbSvc.On("RemoveTarget", mock.Anything, lbID, failing).Return(time.Time{}, nil).Once()
+		asgRepo.On("RemoveInstanceFromGroup", mock.Anything, groupID, failing).Return(nil).Once()
+		instSvc.On("TerminateInstance", mock.Anything, failing.String()).Return(nil).Once()
+		asgRepo.On("CreateActivity", mock.Anything, mock.MatchedBy(func(a *domain.ScalingActivity) bool {
+			return a.Cause == "load balancer health check failing"
+		})).Return(nil).Once()
+
+		worker.Evaluate(ctx)
+
+		asgRepo.AssertExpectations(t)
+		lbSvc.AssertExpectations(t)
+		instSvc.AssertNotCalled(t, "TerminateInstance", mock.Anything, healthy.String())
+	})
+
+	t.Run("Spares instances in their grace period", func(t *testing.T) {
+		instID := uuid.New()
+		worker, _, instSvc, _, _ := setup(newGroup(1), []uuid.UUID{instID})
+		instSvc.On("RefreshInstanceStatus", mock.Anything, instID.String()).Return(&domain.Instance{ID: instID, Status: domain.StatusError, CreatedAt: now.Add(-time.Minute)}, nil).Once()
+
+		worker.Evaluate(ctx)
+
+		instSvc.AssertNotCalled(t, "TerminateInstance", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Skips checks while suspended", func(t *testing.T) {
+		group := newGroup(1)
+		group.SuspendedProcesses = []domain.ScalingProcess{domain.ScalingProcessReplaceUnhealthy}
+		worker, _, instSvc, _, _ := setup(group, []uuid.UUID{uuid.New()})
+
+		worker.Evaluate(ctx)
+
+		instSvc.AssertNotCalled(t, "RefreshInstanceStatus", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Skips checks in failure backoff", func(t *testing.T) {
+		group := newGroup(1)
+		group.FailureCount = 5
+		group.LastFailureAt = ptrTime(now.Add(-time.Minute))
+		worker, _, instSvc, _, _ := setup(group, []uuid.UUID{uuid.New()})
+
+		worker.Evaluate(ctx)
+
+		instSvc.AssertNotCalled(t, "RefreshInstanceStatus", mock.Anything, mock.Anything)
+	})
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
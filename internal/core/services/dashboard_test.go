@@ -45,6 +45,14 @@ func (m *mockInstanceRepo) List(ctx context.Context) ([]*domain.Instance, error)
 	return args.Get(0).([]*domain.Instance), args.Error(1)
 }
 
+func (m *mockInstanceRepo) ListAll(ctx context.Context) ([]*domain.Instance, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Instance), args.Error(1)
+}
+
 func (m *mockInstanceRepo) Update(ctx context.Context, instance *domain.Instance) error {
 	args := m.Called(ctx, instance)
 	return args.Error(0)
@@ -52,6 +52,10 @@ func (m *MockDockerClient) CreateContainer(ctx context.Context, name, image stri
 	args := m.Called(ctx, name, image, ports, networkID, volumeBinds, env, cmd)
 	return args.String(0), args.Error(1)
 }
+func (m *MockDockerClient) CreateContainerWithOptions(ctx context.Context, opts ports.CreateContainerOptions) (string, error) {
+	args := m.Called(ctx, opts)
+	return args.String(0), args.Error(1)
+}
 func (m *MockDockerClient) StopContainer(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -102,6 +106,10 @@ func (m *MockDockerClient) WaitContainer(ctx context.Context, id string) (int64,
 	args := m.Called(ctx, id)
 	return int64(args.Int(0)), args.Error(1)
 }
+func (m *MockDockerClient) IsContainerRunning(ctx context.Context, id string) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
 func (m *MockDockerClient) Exec(ctx context.Context, containerID string, cmd []string) (string, error) {
 	args := m.Called(ctx, containerID, cmd)
 	return args.String(0), args.Error(1)
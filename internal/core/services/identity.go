@@ -43,6 +43,10 @@ func (s *IdentityService) CreateKey(ctx context.Context, userID uuid.UUID, name
 	return apiKey, nil
 }
 
+func (s *IdentityService) GetApiKeyByID(ctx context.Context, id uuid.UUID) (*domain.ApiKey, error) {
+	return s.repo.GetApiKeyByID(ctx, id)
+}
+
 func (s *IdentityService) ValidateApiKey(ctx context.Context, key string) (*domain.ApiKey, error) {
 	apiKey, err := s.repo.GetApiKeyByKey(ctx, key)
 	if err != nil {
@@ -28,6 +28,14 @@ func (m *MockIdentityRepo) GetApiKeyByKey(ctx context.Context, key string) (*dom
 	return args.Get(0).(*domain.ApiKey), args.Error(1)
 }
 
+func (m *MockIdentityRepo) GetApiKeyByID(ctx context.Context, id uuid.UUID) (*domain.ApiKey, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ApiKey), args.Error(1)
+}
+
 func TestIdentityService_CreateKey_Success(t *testing.T) {
 	repo := new(MockIdentityRepo)
 	svc := services.NewIdentityService(repo)
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -38,24 +39,52 @@ func NewInstanceService(repo ports.InstanceRepository, vpcRepo ports.VpcReposito
 }
 
 func (s *InstanceService) LaunchInstance(ctx context.Context, name, image, ports string, vpcID *uuid.UUID, volumes []domain.VolumeAttachment) (*domain.Instance, error) {
-	// 1. Validate ports if provided
-	portList, err := s.parseAndValidatePorts(ports)
+	return s.launch(ctx, name, &domain.LaunchTemplateVersion{
+		Image:   image,
+		Ports:   ports,
+		VpcID:   vpcID,
+		Volumes: volumes,
+	})
+}
+
+func (s *InstanceService) LaunchInstanceFromTemplate(ctx context.Context, name string, template *domain.LaunchTemplateVersion) (*domain.Instance, error) {
+	return s.launch(ctx, name, template)
+}
+
+// launch launches an instance with the configuration of spec, which only
+// names a template if the instance comes from one.
+func (s *InstanceService) launch(ctx context.Context, name string, spec *domain.LaunchTemplateVersion) (*domain.Instance, error) {
+	// 1. Validate ports and instance type
+	portList, err := parseAndValidatePorts(spec.Ports)
 	if err != nil {
 		return nil, err
 	}
+	var instanceType domain.InstanceType
+	if spec.InstanceType != "" {
+		var ok bool
+		if instanceType, ok = domain.InstanceTypes[spec.InstanceType]; !ok {
+			return nil, errors.New(errors.InvalidInput, fmt.Sprintf("unknown instance type %q", spec.InstanceType))
+		}
+	}
 
 	// 2. Create domain entity
 	inst := &domain.Instance{
-		ID:        uuid.New(),
-		UserID:    appcontext.UserIDFromContext(ctx),
-		Name:      name,
-		Image:     image,
-		Status:    domain.StatusStarting,
-		Ports:     ports,
-		VpcID:     vpcID,
-		Version:   1,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:           uuid.New(),
+		UserID:       appcontext.UserIDFromContext(ctx),
+		Name:         name,
+		Image:        spec.Image,
+		Status:       domain.StatusStarting,
+		Ports:        spec.Ports,
+		VpcID:        spec.VpcID,
+		InstanceType: spec.InstanceType,
+		Version:      1,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if spec.TemplateID != uuid.Nil {
+		templateID := spec.TemplateID
+		inst.LaunchTemplateID = &templateID
+		inst.LaunchTemplateVersion = spec.Version
 	}
 
 	// 3. Persist to DB first (Pending state)
@@ -67,10 +96,10 @@ func (s *InstanceService) LaunchInstance(ctx context.Context, name, image, ports
 	dockerName := fmt.Sprintf("thecloud-%s", inst.ID.String()[:8])
 
 	networkID := ""
-	if vpcID != nil {
-		vpc, err := s.vpcRepo.GetByID(ctx, *vpcID)
+	if spec.VpcID != nil {
+		vpc, err := s.vpcRepo.GetByID(ctx, *spec.VpcID)
 		if err != nil {
-			s.logger.Error("failed to get VPC", "vpc_id", vpcID, "error", err)
+			s.logger.Error("failed to get VPC", "vpc_id", spec.VpcID, "error", err)
 			return nil, err
 		}
 		networkID = vpc.NetworkID
@@ -79,7 +108,7 @@ func (s *InstanceService) LaunchInstance(ctx context.Context, name, image, ports
 	// 5. Process volume attachments
 	var volumeBinds []string
 	var attachedVolumes []*domain.Volume
-	for _, va := range volumes {
+	for _, va := range spec.Volumes {
 		vol, err := s.getVolumeByIDOrName(ctx, va.VolumeIDOrName)
 		if err != nil {
 			s.logger.Error("failed to get volume", "volume", va.VolumeIDOrName, "error", err)
@@ -93,9 +122,18 @@ func (s *InstanceService) LaunchInstance(ctx context.Context, name, image, ports
 		attachedVolumes = append(attachedVolumes, vol)
 	}
 
-	containerID, err := s.docker.CreateContainer(ctx, dockerName, image, portList, networkID, volumeBinds, nil, nil)
+	containerID, err := s.docker.CreateContainerWithOptions(ctx, ports.CreateContainerOptions{
+		Name:        dockerName,
+		Image:       spec.Image,
+		Ports:       portList,
+		NetworkID:   networkID,
+		VolumeBinds: volumeBinds,
+		Env:         envList(spec.Env),
+		MemoryMB:    instanceType.MemoryMB,
+		CPUs:        instanceType.CPUs,
+	})
 	if err != nil {
-		s.logger.Error("failed to create docker container", "name", dockerName, "image", image, "error", err)
+		s.logger.Error("failed to create docker container", "name", dockerName, "image", spec.Image, "error", err)
 		inst.Status = domain.StatusError
 		if err := s.repo.Update(ctx, inst); err != nil {
 			s.logger.Error("failed to update instance status after docker create failure", "instance_id", inst.ID, "error", err)
@@ -125,10 +163,51 @@ func (s *InstanceService) LaunchInstance(ctx context.Context, name, image, ports
 	// 6. Update volume statuses
 	s.updateVolumesAfterLaunch(ctx, attachedVolumes, inst.ID)
 
+	if spec.UserData != "" {
+		s.runUserData(ctx, inst, spec.UserData)
+	}
+
 	return inst, nil
 }
 
-func (s *InstanceService) parseAndValidatePorts(ports string) ([]string, error) {
+// userDataTimeout bounds how long a user data script may run.
+const userDataTimeout = 10 * time.Minute
+
+// runUserData runs the user data script of a new instance in the background,
+// so that slow scripts do not hold up the launch.
+func (s *InstanceService) runUserData(ctx context.Context, inst *domain.Instance, script string) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, userDataTimeout)
+		defer cancel()
+
+		if _, err := s.docker.Exec(ctx, inst.ContainerID, []string{"/bin/sh", "-c", script}); err != nil {
+			s.logger.Error("user data failed", "instance_id", inst.ID, "error", err)
+			_ = s.eventSvc.RecordEvent(ctx, "INSTANCE_USER_DATA_FAILED", inst.ID.String(), "INSTANCE", map[string]interface{}{
+				"name":  inst.Name,
+				"error": err.Error(),
+			})
+			return
+		}
+		s.logger.Info("user data finished", "instance_id", inst.ID)
+	}()
+}
+
+// envList turns environment variables into KEY=value pairs, sorted so that
+// containers are created the same way every time.
+func envList(env map[string]string) []string {
+	if len(env) == 0 {
+		return nil
+	}
+	list := make([]string, 0, len(env))
+	for k, v := range env {
+		list = append(list, k+"="+v)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func parseAndValidatePorts(ports string) ([]string, error) {
 	if ports == "" {
 		return nil, nil
 	}
@@ -219,6 +298,31 @@ func (s *InstanceService) GetInstance(ctx context.Context, idOrName string) (*do
 	return s.repo.GetByName(ctx, idOrName)
 }
 
+func (s *InstanceService) RefreshInstanceStatus(ctx context.Context, idOrName string) (*domain.Instance, error) {
+	inst, err := s.GetInstance(ctx, idOrName)
+	if err != nil {
+		return nil, err
+	}
+	if inst.Status != domain.StatusRunning || inst.ContainerID == "" {
+		return inst, nil
+	}
+
+	running, err := s.docker.IsContainerRunning(ctx, inst.ContainerID)
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to inspect container", err)
+	}
+	if running {
+		return inst, nil
+	}
+
+	s.logger.Warn("instance container is no longer running", "instance_id", inst.ID, "container_id", inst.ContainerID)
+	inst.Status = domain.StatusError
+	if err := s.repo.Update(ctx, inst); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
 func (s *InstanceService) GetInstanceLogs(ctx context.Context, idOrName string) (string, error) {
 	inst, err := s.GetInstance(ctx, idOrName)
 	if err != nil {
@@ -4,8 +4,10 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	appcontext "github.com/poyrazk/thecloud/internal/core/context"
@@ -46,6 +48,11 @@ func (m *MockRepo) List(ctx context.Context) ([]*domain.Instance, error) {
 	return args.Get(0).([]*domain.Instance), args.Error(1)
 }
 
+func (m *MockRepo) ListAll(ctx context.Context) ([]*domain.Instance, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*domain.Instance), args.Error(1)
+}
+
 func (m *MockRepo) Update(ctx context.Context, inst *domain.Instance) error {
 	args := m.Called(ctx, inst)
 	return args.Error(0)
@@ -100,6 +107,11 @@ func (m *MockDocker) CreateContainer(ctx context.Context, name, image string, po
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockDocker) CreateContainerWithOptions(ctx context.Context, opts ports.CreateContainerOptions) (string, error) {
+	args := m.Called(ctx, opts)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockDocker) StopContainer(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -175,6 +187,11 @@ func (m *MockDocker) WaitContainer(ctx context.Context, containerID string) (int
 	return int64(args.Int(0)), args.Error(1)
 }
 
+func (m *MockDocker) IsContainerRunning(ctx context.Context, containerID string) (bool, error) {
+	args := m.Called(ctx, containerID)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockDocker) Exec(ctx context.Context, containerID string, cmd []string) (string, error) {
 	args := m.Called(ctx, containerID, cmd)
 	return args.String(0), args.Error(1)
@@ -241,14 +258,17 @@ func TestLaunchInstance_Success(t *testing.T) {
 	ctx := context.Background()
 	name := "test-inst"
 	image := "alpine"
-	ports := "8080:80"
+	portSpec := "8080:80"
 
 	repo.On("Create", ctx, mock.AnythingOfType("*domain.Instance")).Return(nil)
-	docker.On("CreateContainer", ctx, mock.Anything, image, []string{"8080:80"}, "", []string(nil), []string(nil), []string(nil)).Return("container-123", nil)
+	docker.On("CreateContainerWithOptions", ctx, mock.MatchedBy(func(opts ports.CreateContainerOptions) bool {
+		return opts.Image == image && reflect.DeepEqual(opts.Ports, []string{"8080:80"}) && opts.NetworkID == "" &&
+			opts.VolumeBinds == nil && opts.Env == nil && opts.Cmd == nil && opts.MemoryMB == 0 && opts.CPUs == 0
+	})).Return("container-123", nil)
 	repo.On("Update", ctx, mock.AnythingOfType("*domain.Instance")).Return(nil)
 	eventSvc.On("RecordEvent", ctx, "INSTANCE_LAUNCH", mock.Anything, "INSTANCE", mock.Anything).Return(nil)
 
-	inst, err := svc.LaunchInstance(ctx, name, image, ports, nil, nil)
+	inst, err := svc.LaunchInstance(ctx, name, image, portSpec, nil, nil)
 
 	assert.NoError(t, err)
 	assert.Equal(t, name, inst.Name)
@@ -275,7 +295,9 @@ func TestLaunchInstance_PropagatesUserID(t *testing.T) {
 	repo.On("Create", ctx, mock.MatchedBy(func(inst *domain.Instance) bool {
 		return inst.UserID == expectedUserID
 	})).Return(nil)
-	docker.On("CreateContainer", ctx, mock.Anything, image, []string(nil), "", []string(nil), []string(nil), []string(nil)).Return("container-456", nil)
+	docker.On("CreateContainerWithOptions", ctx, mock.MatchedBy(func(opts ports.CreateContainerOptions) bool {
+		return opts.Image == image && opts.Ports == nil && opts.NetworkID == ""
+	})).Return("container-456", nil)
 	repo.On("Update", ctx, mock.AnythingOfType("*domain.Instance")).Return(nil)
 	eventSvc.On("RecordEvent", ctx, "INSTANCE_LAUNCH", mock.Anything, "INSTANCE", mock.Anything).Return(nil)
 
@@ -286,6 +308,68 @@ func TestLaunchInstance_PropagatesUserID(t *testing.T) {
 	repo.AssertExpectations(t)
 }
 
+func TestLaunchInstanceFromTemplate(t *testing.T) {
+	repo := new(MockRepo)
+	vpcRepo := new(MockVpcRepo)
+	volumeRepo := new(MockVolumeRepo)
+	docker := new(MockDocker)
+	eventSvc := new(MockEventService)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := NewInstanceService(repo, vpcRepo, volumeRepo, docker, eventSvc, logger)
+
+	ctx := context.Background()
+	vpcID := uuid.New()
+	template := &domain.LaunchTemplateVersion{
+		TemplateID:   uuid.New(),
+		Version:      3,
+		Image:        "nginx",
+		Ports:        "0:80",
+		InstanceType: "small",
+		Env:          map[string]string{"MODE": "prod", "DEBUG": "0"},
+		UserData:     "echo hello > /tmp/hello",
+		VpcID:        &vpcID,
+	}
+
+	repo.On("Create", ctx, mock.MatchedBy(func(inst *domain.Instance) bool {
+		return *inst.LaunchTemplateID == template.TemplateID && inst.LaunchTemplateVersion == 3 && inst.InstanceType == "small"
+	})).Return(nil)
+	vpcRepo.On("GetByID", ctx, vpcID).Return(&domain.VPC{ID: vpcID, NetworkID: "net-1"}, nil)
+	docker.On("CreateContainerWithOptions", ctx, mock.MatchedBy(func(opts ports.CreateContainerOptions) bool {
+		return opts.Image == "nginx" && reflect.DeepEqual(opts.Ports, []string{"0:80"}) && opts.NetworkID == "net-1" &&
+			reflect.DeepEqual(opts.Env, []string{"DEBUG=0", "MODE=prod"}) && opts.MemoryMB == 1024 && opts.CPUs == 1
+	})).Return("container-789", nil)
+	repo.On("Update", ctx, mock.AnythingOfType("*domain.Instance")).Return(nil)
+	eventSvc.On("RecordEvent", ctx, "INSTANCE_LAUNCH", mock.Anything, "INSTANCE", mock.Anything).Return(nil)
+
+	ran := make(chan struct{})
+	docker.On("Exec", mock.Anything, "container-789", []string{"/bin/sh", "-c", template.UserData}).
+		Run(func(mock.Arguments) { close(ran) }).
+		Return("", nil)
+
+	inst, err := svc.LaunchInstanceFromTemplate(ctx, "web-1", template)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "container-789", inst.ContainerID)
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("user data was not run")
+	}
+	docker.AssertExpectations(t)
+}
+
+func TestLaunchInstanceFromTemplate_UnknownInstanceType(t *testing.T) {
+	repo := new(MockRepo)
+	docker := new(MockDocker)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := NewInstanceService(repo, new(MockVpcRepo), new(MockVolumeRepo), docker, new(MockEventService), logger)
+
+	_, err := svc.LaunchInstanceFromTemplate(context.Background(), "web-1", &domain.LaunchTemplateVersion{Image: "nginx", InstanceType: "huge"})
+
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
 func TestTerminateInstance_Success(t *testing.T) {
 	repo := new(MockRepo)
 	vpcRepo := new(MockVpcRepo)
@@ -354,9 +438,7 @@ func TestTerminateInstance_RemoveContainerFails_DoesNotReleaseVolumes(t *testing
 }
 
 func TestParseAndValidatePorts_RejectsInvalidPort(t *testing.T) {
-	svc := &InstanceService{}
-
-	_, err := svc.parseAndValidatePorts("80abc:90")
+	_, err := parseAndValidatePorts("80abc:90")
 
 	assert.Error(t, err)
 }
@@ -405,6 +487,58 @@ func TestGetInstance_ByName(t *testing.T) {
 	repo.AssertExpectations(t)
 }
 
+func TestRefreshInstanceStatus(t *testing.T) {
+	setup := func() (*InstanceService, *MockRepo, *MockDocker) {
+		repo := new(MockRepo)
+		docker := new(MockDocker)
+		logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		svc := NewInstanceService(repo, new(MockVpcRepo), new(MockVolumeRepo), docker, new(MockEventService), logger)
+		return svc, repo, docker
+	}
+	ctx := context.Background()
+
+	t.Run("ContainerRunning", func(t *testing.T) {
+		svc, repo, docker := setup()
+		inst := &domain.Instance{ID: uuid.New(), Status: domain.StatusRunning, ContainerID: "c1"}
+		repo.On("GetByID", ctx, inst.ID).Return(inst, nil)
+		docker.On("IsContainerRunning", ctx, "c1").Return(true, nil)
+
+		result, err := svc.RefreshInstanceStatus(ctx, inst.ID.String())
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.StatusRunning, result.Status)
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ContainerExited", func(t *testing.T) {
+		svc, repo, docker := setup()
+		inst := &domain.Instance{ID: uuid.New(), Status: domain.StatusRunning, ContainerID: "c1"}
+		repo.On("GetByID", ctx, inst.ID).Return(inst, nil)
+		docker.On("IsContainerRunning", ctx, "c1").Return(false, nil)
+		repo.On("Update", ctx, mock.MatchedBy(func(i *domain.Instance) bool {
+			return i.Status == domain.StatusError
+		})).Return(nil)
+
+		result, err := svc.RefreshInstanceStatus(ctx, inst.ID.String())
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.StatusError, result.Status)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("NotRunning", func(t *testing.T) {
+		svc, repo, docker := setup()
+		inst := &domain.Instance{ID: uuid.New(), Status: domain.StatusStopped, ContainerID: "c1"}
+		repo.On("GetByID", ctx, inst.ID).Return(inst, nil)
+
+		result, err := svc.RefreshInstanceStatus(ctx, inst.ID.String())
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.StatusStopped, result.Status)
+		docker.AssertNotCalled(t, "IsContainerRunning", mock.Anything, mock.Anything)
+	})
+}
+
 func TestListInstances(t *testing.T) {
 	repo := new(MockRepo)
 	vpcRepo := new(MockVpcRepo)
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/errors"
+)
+
+var (
+	launchTemplateName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+	envVarName         = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+const maxTemplateImageLength = 256
+
+type LaunchTemplateService struct {
+	repo    ports.LaunchTemplateRepository
+	vpcRepo ports.VpcRepository
+}
+
+func NewLaunchTemplateService(repo ports.LaunchTemplateRepository, vpcRepo ports.VpcRepository) *LaunchTemplateService {
+	return &LaunchTemplateService{
+		repo:    repo,
+		vpcRepo: vpcRepo,
+	}
+}
+
+func (s *LaunchTemplateService) CreateTemplate(ctx context.Context, name, description string, version *domain.LaunchTemplateVersion) (*domain.LaunchTemplate, error) {
+	if !launchTemplateName.MatchString(name) {
+		return nil, errors.New(errors.InvalidInput, "name must be 1-64 letters, digits, hyphens and underscores")
+	}
+	if err := s.validateVersion(ctx, version); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &domain.LaunchTemplate{
+		ID:            uuid.New(),
+		UserID:        appcontext.UserIDFromContext(ctx),
+		Name:          name,
+		Description:   description,
+		LatestVersion: 1,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	version.TemplateID = template.ID
+	version.Version = 1
+	version.CreatedAt = now
+
+	if err := s.repo.Create(ctx, template, version); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *LaunchTemplateService) GetTemplate(ctx context.Context, id uuid.UUID) (*domain.LaunchTemplate, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *LaunchTemplateService) ListTemplates(ctx context.Context) ([]*domain.LaunchTemplate, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *LaunchTemplateService) DeleteTemplate(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return err
+	}
+	count, err := s.repo.CountGroupsUsing(ctx, id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New(errors.Conflict, fmt.Sprintf("launch template is used by %d scaling groups", count))
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *LaunchTemplateService) CreateVersion(ctx context.Context, templateID uuid.UUID, version *domain.LaunchTemplateVersion) (*domain.LaunchTemplateVersion, error) {
+	template, err := s.repo.GetByID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template.LatestVersion >= domain.MaxLaunchTemplateVersions {
+		return nil, errors.New(errors.ResourceLimitExceeded, fmt.Sprintf("launch template already has %d versions (max: %d)", template.LatestVersion, domain.MaxLaunchTemplateVersions))
+	}
+	if err := s.validateVersion(ctx, version); err != nil {
+		return nil, err
+	}
+
+	version.TemplateID = templateID
+	version.CreatedAt = time.Now()
+	if err := s.repo.CreateVersion(ctx, version); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+func (s *LaunchTemplateService) GetVersion(ctx context.Context, templateID uuid.UUID, version int) (*domain.LaunchTemplateVersion, error) {
+	if version < 0 {
+		return nil, errors.New(errors.InvalidInput, "version cannot be negative")
+	}
+	return s.repo.GetVersion(ctx, templateID, version)
+}
+
+func (s *LaunchTemplateService) ListVersions(ctx context.Context, templateID uuid.UUID) ([]*domain.LaunchTemplateVersion, error) {
+	if _, err := s.repo.GetByID(ctx, templateID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListVersions(ctx, templateID)
+}
+
+// validateVersion checks the configuration of a template version. Volumes
+// are looked up when instances launch.
+func (s *LaunchTemplateService) validateVersion(ctx context.Context, v *domain.LaunchTemplateVersion) error {
+	v.Image = strings.TrimSpace(v.Image)
+	if v.Image == "" {
+		return errors.New(errors.InvalidInput, "image is required")
+	}
+	if len(v.Image) > maxTemplateImageLength {
+		return errors.New(errors.InvalidInput, fmt.Sprintf("image name too long (max %d characters)", maxTemplateImageLength))
+	}
+	if _, err := parseAndValidatePorts(v.Ports); err != nil {
+		return err
+	}
+	if v.InstanceType != "" {
+		if _, ok := domain.InstanceTypes[v.InstanceType]; !ok {
+			return errors.New(errors.InvalidInput, fmt.Sprintf("unknown instance type %q", v.InstanceType))
+		}
+	}
+
+	if len(v.Env) > domain.MaxLaunchTemplateEnvVars {
+		return errors.New(errors.InvalidInput, fmt.Sprintf("at most %d environment variables allowed", domain.MaxLaunchTemplateEnvVars))
+	}
+	for name := range v.Env {
+		if !envVarName.MatchString(name) {
+			return errors.New(errors.InvalidInput, fmt.Sprintf("invalid environment variable name %q", name))
+		}
+	}
+	if len(v.UserData) > domain.MaxUserDataBytes {
+		return errors.New(errors.InvalidInput, fmt.Sprintf("user data cannot exceed %d bytes", domain.MaxUserDataBytes))
+	}
+
+	for _, va := range v.Volumes {
+		if strings.TrimSpace(va.VolumeIDOrName) == "" {
+			return errors.New(errors.InvalidInput, "volume_id is required for volume attachment")
+		}
+		if !strings.HasPrefix(va.MountPath, "/") {
+			return errors.New(errors.InvalidInput, "mount_path must be an absolute path starting with /")
+		}
+	}
+
+	if v.VpcID != nil {
+		if _, err := s.vpcRepo.GetByID(ctx, *v.VpcID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
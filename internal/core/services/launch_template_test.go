@@ -0,0 +1,121 @@
+package services_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/services"
+	"github.com/poyrazk/thecloud/internal/errors"
+)
+
+func TestCreateLaunchTemplate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		repo := new(MockLaunchTemplateRepo)
+		vpcRepo := new(MockVpcRepo)
+		svc := services.NewLaunchTemplateService(repo, vpcRepo)
+		vpcID := uuid.New()
+
+		vpcRepo.On("GetByID", ctx, vpcID).Return(&domain.VPC{ID: vpcID}, nil)
+		repo.On("Create", ctx, mock.AnythingOfType("*domain.LaunchTemplate"), mock.MatchedBy(func(v *domain.LaunchTemplateVersion) bool {
+			return v.Version == 1 && v.Image == "nginx"
+		})).Return(nil)
+
+		template, err := svc.CreateTemplate(ctx, "web", "web servers", &domain.LaunchTemplateVersion{
+			Image:        " nginx ",
+			Ports:        "80:80",
+			InstanceType: "small",
+			Env:          map[string]string{"MODE": "prod"},
+			UserData:     "echo ready",
+			VpcID:        &vpcID,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, template.LatestVersion)
+		repo.AssertExpectations(t)
+	})
+
+	invalid := map[string]*domain.LaunchTemplateVersion{
+		"NoImage":         {Image: " "},
+		"BadPorts":        {Image: "nginx", Ports: "80"},
+		"UnknownType":     {Image: "nginx", InstanceType: "huge"},
+		"BadEnvName":      {Image: "nginx", Env: map[string]string{"MY-VAR": "1"}},
+		"UserDataTooLong": {Image: "nginx", UserData: strings.Repeat("x", domain.MaxUserDataBytes+1)},
+		"RelativeMount":   {Image: "nginx", Volumes: []domain.VolumeAttachment{{VolumeIDOrName: "data", MountPath: "data"}}},
+	}
+	for name, v := range invalid {
+		t.Run(name, func(t *testing.T) {
+			repo := new(MockLaunchTemplateRepo)
+			svc := services.NewLaunchTemplateService(repo, new(MockVpcRepo))
+
+			_, err := svc.CreateTemplate(ctx, "web", "", v)
+
+			assert.Error(t, err)
+			repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("BadName", func(t *testing.T) {
+		svc := services.NewLaunchTemplateService(new(MockLaunchTemplateRepo), new(MockVpcRepo))
+
+		_, err := svc.CreateTemplate(ctx, "web servers", "", &domain.LaunchTemplateVersion{Image: "nginx"})
+
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+	})
+}
+
+func TestCreateLaunchTemplateVersion(t *testing.T) {
+	ctx := context.Background()
+	templateID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		repo := new(MockLaunchTemplateRepo)
+		svc := services.NewLaunchTemplateService(repo, new(MockVpcRepo))
+
+		repo.On("GetByID", ctx, templateID).Return(&domain.LaunchTemplate{ID: templateID, LatestVersion: 1}, nil)
+		repo.On("CreateVersion", ctx, mock.MatchedBy(func(v *domain.LaunchTemplateVersion) bool {
+			return v.TemplateID == templateID && v.Image == "nginx:1.27"
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.LaunchTemplateVersion).Version = 2
+		}).Return(nil)
+
+		v, err := svc.CreateVersion(ctx, templateID, &domain.LaunchTemplateVersion{Image: "nginx:1.27"})
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, v.Version)
+	})
+
+	t.Run("VersionLimit", func(t *testing.T) {
+		repo := new(MockLaunchTemplateRepo)
+		svc := services.NewLaunchTemplateService(repo, new(MockVpcRepo))
+
+		repo.On("GetByID", ctx, templateID).Return(&domain.LaunchTemplate{ID: templateID, LatestVersion: domain.MaxLaunchTemplateVersions}, nil)
+
+		_, err := svc.CreateVersion(ctx, templateID, &domain.LaunchTemplateVersion{Image: "nginx"})
+
+		assert.True(t, errors.Is(err, errors.ResourceLimitExceeded))
+	})
+}
+
+func TestDeleteLaunchTemplate_InUse(t *testing.T) {
+	ctx := context.Background()
+	templateID := uuid.New()
+	repo := new(MockLaunchTemplateRepo)
+	svc := services.NewLaunchTemplateService(repo, new(MockVpcRepo))
+
+	repo.On("GetByID", ctx, templateID).Return(&domain.LaunchTemplate{ID: templateID}, nil)
+	repo.On("CountGroupsUsing", ctx, templateID).Return(1, nil)
+
+	err := svc.DeleteTemplate(ctx, templateID)
+
+	assert.True(t, errors.Is(err, errors.Conflict))
+	repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+)
+
+// hashHeaderName limits hashed headers to names nginx can address as
+// $http_<name>.
+var hashHeaderName = regexp.MustCompile(`^[A-Za-z0-9]+(-[A-Za-z0-9]+)*$`)
+
+// UpdateLB changes the name or the balancing settings of a load balancer.
+// Switching away from header-hash drops the hash header unless the update
+// sets one. The worker pushes the new settings to the proxy on its next tick.
+func (s *LBService) UpdateLB(ctx context.Context, id uuid.UUID, update domain.LBUpdate) (*domain.LoadBalancer, error) {
+	lb, err := s.lbRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" {
+			return nil, errors.New(errors.InvalidInput, "name must not be empty")
+		}
+		lb.Name = name
+	}
+
+	balancing := domain.LBBalancing{Algorithm: lb.Algorithm, HashHeader: lb.HashHeader, Stickiness: lb.Stickiness}
+	if update.Algorithm != nil {
+		balancing.Algorithm = *update.Algorithm
+		if balancing.Algorithm != domain.LBAlgorithmHeaderHash && update.HashHeader == nil {
+			balancing.HashHeader = ""
+		}
+	}
+	if update.HashHeader != nil {
+		balancing.HashHeader = *update.HashHeader
+	}
+	if update.Stickiness != nil {
+		balancing.Stickiness = *update.Stickiness
+	}
+	if balancing, err = normalizeBalancing(balancing); err != nil {
+		return nil, err
+	}
+	lb.Algorithm = balancing.Algorithm
+	lb.HashHeader = balancing.HashHeader
+	lb.Stickiness = balancing.Stickiness
+
+	if err := s.lbRepo.Update(ctx, lb); err != nil {
+		return nil, err
+	}
+	return lb, nil
+}
+
+func normalizeBalancing(b domain.LBBalancing) (domain.LBBalancing, error) {
+	if b.Algorithm == "" {
+		b.Algorithm = domain.LBAlgorithmRoundRobin
+	}
+	switch b.Algorithm {
+	case domain.LBAlgorithmRoundRobin, domain.LBAlgorithmWeighted, domain.LBAlgorithmLeastConn, domain.LBAlgorithmSourceIPHash:
+		if b.HashHeader != "" {
+			return b, errors.New(errors.InvalidInput, "hash header only applies to the header-hash algorithm")
+		}
+	case domain.LBAlgorithmHeaderHash:
+		if !hashHeaderName.MatchString(b.HashHeader) {
+			return b, errors.New(errors.InvalidInput, "header-hash needs a hash header of letters, digits and -")
+		}
+	default:
+		return b, errors.New(errors.InvalidInput, "algorithm must be round-robin, weighted, least-conn, source-ip-hash or header-hash")
+	}
+
+	if !b.Stickiness.Enabled {
+		b.Stickiness.TTLSeconds = 0
+		return b, nil
+	}
+	if b.Stickiness.TTLSeconds == 0 {
+		b.Stickiness.TTLSeconds = domain.DefaultStickinessTTL
+	}
+	if b.Stickiness.TTLSeconds < 1 || b.Stickiness.TTLSeconds > domain.MaxStickinessTTL {
+		return b, errors.New(errors.InvalidInput, fmt.Sprintf("stickiness ttl must be between 1 and %d seconds", domain.MaxStickinessTTL))
+	}
+	return b, nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeBalancing(t *testing.T) {
+	b, err := normalizeBalancing(domain.LBBalancing{Stickiness: domain.LBStickiness{Enabled: true}})
+	require.NoError(t, err)
+	assert.Equal(t, domain.LBAlgorithmRoundRobin, b.Algorithm)
+	assert.Equal(t, domain.DefaultStickinessTTL, b.Stickiness.TTLSeconds)
+
+	b, err = normalizeBalancing(domain.LBBalancing{Stickiness: domain.LBStickiness{TTLSeconds: 60}})
+	require.NoError(t, err)
+	assert.Zero(t, b.Stickiness.TTLSeconds)
+
+	_, err = normalizeBalancing(domain.LBBalancing{Algorithm: domain.LBAlgorithmHeaderHash, HashHeader: "X-Tenant-ID"})
+	assert.NoError(t, err)
+
+	invalid := []domain.LBBalancing{
+		{Algorithm: "random"},
+		{Algorithm: domain.LBAlgorithmHeaderHash},
+		{Algorithm: domain.LBAlgorithmHeaderHash, HashHeader: "X_Tenant"},
+		{Algorithm: domain.LBAlgorithmSourceIPHash, HashHeader: "X-Tenant-ID"},
+		{Stickiness: domain.LBStickiness{Enabled: true, TTLSeconds: -1}},
+		{Stickiness: domain.LBStickiness{Enabled: true, TTLSeconds: domain.MaxStickinessTTL + 1}},
+	}
+	for _, c := range invalid {
+		_, err := normalizeBalancing(c)
+		assert.True(t, errors.Is(err, errors.InvalidInput), "%+v", c)
+	}
+}
+
+func TestLBService_UpdateLB(t *testing.T) {
+	ctx := context.Background()
+	lbID := uuid.New()
+	str := func(s string) *string { return &s }
+
+	newSvc := func() (*LBService, *mockLBRepo) {
+		lbRepo := new(mockLBRepo)
+		lbRepo.On("GetByID", ctx, lbID).Return(&domain.LoadBalancer{
+			ID:         lbID,
+			Name:       "web",
+			Algorithm:  domain.LBAlgorithmHeaderHash,
+			HashHeader: "X-Tenant-ID",
+		}, nil)
+		return NewLBService(lbRepo, new(mockVpcRepo), new(mockInstanceRepo), new(mockSecretService)), lbRepo
+	}
+
+	t.Run("changes only the given fields", func(t *testing.T) {
+		svc, lbRepo := newSvc()
+		lbRepo.On("Update", ctx, mock.MatchedBy(func(lb *domain.LoadBalancer) bool {
+			return lb.Name == "web" && lb.HashHeader == "X-Tenant-ID" && lb.Stickiness.TTLSeconds == 300
+		})).Return(nil).Once()
+
+		lb, err := svc.UpdateLB(ctx, lbID, domain.LBUpdate{Stickiness: &domain.LBStickiness{Enabled: true, TTLSeconds: 300}})
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.LBAlgorithmHeaderHash, lb.Algorithm)
+		lbRepo.AssertExpectations(t)
+	})
+
+	t.Run("switching algorithm drops the hash header", func(t *testing.T) {
+		svc, lbRepo := newSvc()
+		lbRepo.On("Update", ctx, mock.MatchedBy(func(lb *domain.LoadBalancer) bool {
+			return lb.Algorithm == domain.LBAlgorithmSourceIPHash && lb.HashHeader == "" && lb.Name == "api"
+		})).Return(nil).Once()
+
+		_, err := svc.UpdateLB(ctx, lbID, domain.LBUpdate{Name: str("api"), Algorithm: str(domain.LBAlgorithmSourceIPHash)})
+
+		require.NoError(t, err)
+		lbRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects invalid settings", func(t *testing.T) {
+		svc, lbRepo := newSvc()
+
+		_, err := svc.UpdateLB(ctx, lbID, domain.LBUpdate{HashHeader: str("")})
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+		_, err = svc.UpdateLB(ctx, lbID, domain.LBUpdate{Name: str(" ")})
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+		lbRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+)
+
+const (
+	// The LB worker ticks every 5 seconds, so shorter intervals are not honoured.
+	minHealthCheckInterval = 5
+	// healthProbeConcurrency caps the probes of one load balancer in flight
+	// at once, so unreachable targets cost about one timeout per tick.
+	healthProbeConcurrency = 16
+)
+
+// UpdateHealthCheck replaces the health check settings of a load balancer.
+// Zero values take the defaults of domain.DefaultHealthCheck.
+func (s *LBService) UpdateHealthCheck(ctx context.Context, lbID uuid.UUID, cfg domain.HealthCheckConfig) (*domain.LoadBalancer, error) {
+	cfg, err := normalizeHealthCheck(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	lb, err := s.lbRepo.GetByID(ctx, lbID)
+	if err != nil {
+		return nil, err
+	}
+	lb.HealthCheck = cfg
+	if err := s.lbRepo.Update(ctx, lb); err != nil {
+		return nil, err
+	}
+	return lb, nil
+}
+
+func normalizeHealthCheck(cfg domain.HealthCheckConfig) (domain.HealthCheckConfig, error) {
+	def := domain.DefaultHealthCheck()
+	if cfg.Protocol == "" {
+		cfg.Protocol = def.Protocol
+	}
+	switch cfg.Protocol {
+	case domain.HealthCheckProtocolHTTP:
+		if cfg.Path == "" {
+			cfg.Path = def.Path
+		}
+		if !strings.HasPrefix(cfg.Path, "/") || strings.ContainsAny(cfg.Path, " \t\r\n") {
+			return cfg, errors.New(errors.InvalidInput, "health check path must start with / and contain no whitespace")
+		}
+		if cfg.StatusCodes == "" {
+			cfg.StatusCodes = def.StatusCodes
+		}
+		if _, err := parseStatusCodes(cfg.StatusCodes); err != nil {
+			return cfg, errors.New(errors.InvalidInput, err.Error())
+		}
+	case domain.HealthCheckProtocolTCP:
+		if cfg.Path != "" || cfg.StatusCodes != "" {
+			return cfg, errors.New(errors.InvalidInput, "tcp health checks take no path or status codes")
+		}
+	default:
+		return cfg, errors.New(errors.InvalidInput, "health check protocol must be http or tcp")
+	}
+
+	if cfg.IntervalSeconds == 0 {
+		cfg.IntervalSeconds = def.IntervalSeconds
+	}
+	if cfg.TimeoutSeconds == 0 {
+		cfg.TimeoutSeconds = def.TimeoutSeconds
+	}
+	if cfg.HealthyThreshold == 0 {
+		cfg.HealthyThreshold = def.HealthyThreshold
+	}
+	if cfg.UnhealthyThreshold == 0 {
+		cfg.UnhealthyThreshold = def.UnhealthyThreshold
+	}
+
+	if cfg.IntervalSeconds < minHealthCheckInterval || cfg.IntervalSeconds > 300 {
+		return cfg, errors.New(errors.InvalidInput, fmt.Sprintf("health check interval must be between %d and 300 seconds", minHealthCheckInterval))
+	}
+	if cfg.TimeoutSeconds < 1 || cfg.TimeoutSeconds >= cfg.IntervalSeconds {
+		return cfg, errors.New(errors.InvalidInput, "health check timeout must be at least 1 second and shorter than the interval")
+	}
+	if cfg.HealthyThreshold < 1 || cfg.HealthyThreshold > 10 || cfg.UnhealthyThreshold < 1 || cfg.UnhealthyThreshold > 10 {
+		return cfg, errors.New(errors.InvalidInput, "health check thresholds must be between 1 and 10")
+	}
+	return cfg, nil
+}
+
+// parseStatusCodes parses a list such as "200,204,300-399" into ranges.
+func parseStatusCodes(s string) ([][2]int, error) {
+	var ranges [][2]int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		lo, hi, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid status code %q", part)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(hi); err != nil {
+				return nil, fmt.Errorf("invalid status code %q", part)
+			}
+		}
+		if from < 100 || to > 599 || from > to {
+			return nil, fmt.Errorf("status codes must be between 100 and 599: %q", part)
+		}
+		ranges = append(ranges, [2]int{from, to})
+	}
+	return ranges, nil
+}
+
+func statusMatches(codes string, status int) bool {
+	ranges, err := parseStatusCodes(codes)
+	if err != nil {
+		return false
+	}
+	for _, r := range ranges {
+		if status >= r[0] && status <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// probeTarget runs one health check against addr ("host:port").
+func probeTarget(ctx context.Context, addr string, cfg domain.HealthCheckConfig) bool {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if cfg.Protocol == domain.HealthCheckProtocolTCP {
+		conn, err := net.DialTimeout("tcp", addr, timeout)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+cfg.Path, nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("User-Agent", "thecloud-lb-healthcheck")
+	// Redirects are judged by their own status code.
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return statusMatches(cfg.StatusCodes, resp.StatusCode)
+}
+
+// healthStreak counts consecutive probe results of one target.
+type healthStreak struct {
+	successes int
+	failures  int
+}
+
+// record applies a probe result and returns the resulting health state.
+// A target only changes state after the configured number of consecutive
+// results in the other direction.
+func (h *healthStreak) record(current string, ok bool, cfg domain.HealthCheckConfig) string {
+	if ok {
+		h.successes++
+		h.failures = 0
+		if current != domain.TargetHealthHealthy && h.successes >= cfg.HealthyThreshold {
+			return domain.TargetHealthHealthy
+		}
+	} else {
+		h.failures++
+		h.successes = 0
+		if current != domain.TargetHealthUnhealthy && h.failures >= cfg.UnhealthyThreshold {
+			return domain.TargetHealthUnhealthy
+		}
+	}
+	return current
+}
+
+// routableTargets drops draining and unhealthy targets from the proxy
+// configuration. If every remaining target of a group is unhealthy the group
+// keeps all of them, so that a failing health check does not take the whole
+// group offline.
+func routableTargets(targets []*domain.LBTarget) []*domain.LBTarget {
+	live := make(map[string]bool)
+	for _, t := range targets {
+		if t.Health != domain.TargetHealthUnhealthy && t.Health != domain.TargetHealthDraining {
+			live[t.TargetGroup] = true
+		}
+	}
+
+	res := make([]*domain.LBTarget, 0, len(targets))
+	for _, t := range targets {
+		switch {
+		case t.Health == domain.TargetHealthDraining:
+		case t.Health != domain.TargetHealthUnhealthy || !live[t.TargetGroup]:
+			res = append(res, t)
+		}
+	}
+	return res
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeHealthCheck(t *testing.T) {
+	cfg, err := normalizeHealthCheck(domain.HealthCheckConfig{Path: "/healthz"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.HealthCheckProtocolHTTP, cfg.Protocol)
+	assert.Equal(t, "/healthz", cfg.Path)
+	assert.Equal(t, "200-399", cfg.StatusCodes)
+	assert.Equal(t, 10, cfg.IntervalSeconds)
+
+	invalid := []domain.HealthCheckConfig{
+		{Protocol: "udp"},
+		{Path: "healthz"},
+		{StatusCodes: "2xx"},
+		{StatusCodes: "300-200"},
+		{Protocol: domain.HealthCheckProtocolTCP, Path: "/"},
+		{IntervalSeconds: 1},
+		{IntervalSeconds: 10, TimeoutSeconds: 10},
+		{HealthyThreshold: 11},
+	}
+	for _, c := range invalid {
+		_, err := normalizeHealthCheck(c)
+		assert.True(t, errors.Is(err, errors.InvalidInput), "%+v", c)
+	}
+}
+
+func TestStatusMatches(t *testing.T) {
+	assert.True(t, statusMatches("200-399", 302))
+	assert.True(t, statusMatches("200, 204", 204))
+	assert.False(t, statusMatches("200,204", 201))
+	assert.False(t, statusMatches("200-299", 500))
+}
+
+func TestHealthStreak(t *testing.T) {
+	cfg := domain.HealthCheckConfig{HealthyThreshold: 3, UnhealthyThreshold: 2}
+	var h healthStreak
+
+	state := domain.TargetHealthUnknown
+	state = h.record(state, true, cfg)
+	state = h.record(state, true, cfg)
+	assert.Equal(t, domain.TargetHealthUnknown, state)
+	state = h.record(state, true, cfg)
+	assert.Equal(t, domain.TargetHealthHealthy, state)
+
+	// A single failure does not flip a healthy target.
+	state = h.record(state, false, cfg)
+	assert.Equal(t, domain.TargetHealthHealthy, state)
+	state = h.record(state, true, cfg)
+	state = h.record(state, false, cfg)
+	assert.Equal(t, domain.TargetHealthHealthy, state)
+	state = h.record(state, false, cfg)
+	assert.Equal(t, domain.TargetHealthUnhealthy, state)
+}
+
+func TestRoutableTargets(t *testing.T) {
+	healthy := &domain.LBTarget{TargetGroup: "web", Health: domain.TargetHealthHealthy}
+	sick := &domain.LBTarget{TargetGroup: "web", Health: domain.TargetHealthUnhealthy}
+	unknown := &domain.LBTarget{TargetGroup: "web", Health: domain.TargetHealthUnknown}
+	allSick := &domain.LBTarget{TargetGroup: "api", Health: domain.TargetHealthUnhealthy}
+	draining := &domain.LBTarget{TargetGroup: "api", Health: domain.TargetHealthDraining}
+
+	got := routableTargets([]*domain.LBTarget{healthy, sick, unknown, allSick, draining})
+
+	assert.Equal(t, []*domain.LBTarget{healthy, unknown, allSick}, got)
+}
+
+func TestProbeTarget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			w.WriteHeader(http.StatusOK)
+		case "/moved":
+			http.Redirect(w, r, "/healthz", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+	ctx := context.Background()
+
+	cfg := domain.DefaultHealthCheck()
+	cfg.Path = "/healthz"
+	assert.True(t, probeTarget(ctx, addr, cfg))
+
+	cfg.Path = "/down"
+	assert.False(t, probeTarget(ctx, addr, cfg))
+
+	cfg.Path = "/moved"
+	cfg.StatusCodes = "200"
+	assert.False(t, probeTarget(ctx, addr, cfg), "redirects are not followed")
+
+	tcp := domain.HealthCheckConfig{Protocol: domain.HealthCheckProtocolTCP, TimeoutSeconds: 1}
+	assert.True(t, probeTarget(ctx, addr, tcp))
+}
+
+type fakeTargetProxy struct {
+	ports.LBProxyAdapter
+	addrs map[uuid.UUID]string
+}
+
+func (p *fakeTargetProxy) TargetAddress(ctx context.Context, lb *domain.LoadBalancer, t *domain.LBTarget) (string, error) {
+	addr, ok := p.addrs[t.InstanceID]
+	if !ok {
+		return "", fmt.Errorf("no address for %s", t.InstanceID)
+	}
+	return addr, nil
+}
+
+func TestLBWorker_CheckLBHealth(t *testing.T) {
+	ctx := context.Background()
+	lbRepo := new(mockLBRepo)
+	instID := uuid.New()
+	// Targets are probed where the proxy reaches them, such as the VPC address
+	proxy := &fakeTargetProxy{addrs: map[uuid.UUID]string{instID: "10.10.0.5:80"}}
+	w := NewLBWorker(lbRepo, proxy, nil, nil)
+
+	lb := &domain.LoadBalancer{ID: uuid.New(), HealthCheck: domain.HealthCheckConfig{
+		Protocol: domain.HealthCheckProtocolHTTP, Path: "/", StatusCodes: "200",
+		IntervalSeconds: 5, TimeoutSeconds: 1, HealthyThreshold: 2, UnhealthyThreshold: 2,
+	}}
+	target := &domain.LBTarget{ID: uuid.New(), LBID: lb.ID, InstanceID: instID, Port: 80, Health: domain.TargetHealthHealthy}
+	lbRepo.On("ListTargets", ctx, lb.ID).Return([]*domain.LBTarget{target}, nil)
+
+	var probed []string
+	w.probe = func(_ context.Context, addr string, _ domain.HealthCheckConfig) bool {
+		probed = append(probed, addr)
+		return false
+	}
+
+	w.checkLBHealth(ctx, lb)
+	// Checks run at most once per interval.
+	w.checkLBHealth(ctx, lb)
+	assert.Equal(t, []string{"10.10.0.5:80"}, probed)
+	lbRepo.AssertNotCalled(t, "UpdateTargetHealth", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	lbRepo.On("UpdateTargetHealth", ctx, lb.ID, instID, domain.TargetHealthUnhealthy).Return(nil).Once()
+	w.lastChecked[lb.ID] = time.Now().Add(-time.Minute)
+	w.checkLBHealth(ctx, lb)
+
+	assert.Len(t, probed, 2)
+	lbRepo.AssertExpectations(t)
+}
+
+func TestLBWorker_ProbesTargetsConcurrently(t *testing.T) {
+	ctx := context.Background()
+	lbRepo := new(mockLBRepo)
+	proxy := &fakeTargetProxy{addrs: map[uuid.UUID]string{}}
+	w := NewLBWorker(lbRepo, proxy, nil, nil)
+
+	lb := &domain.LoadBalancer{ID: uuid.New(), HealthCheck: domain.DefaultHealthCheck()}
+	var targets []*domain.LBTarget
+	for i := 0; i < 3; i++ {
+		instID := uuid.New()
+		proxy.addrs[instID] = fmt.Sprintf("10.10.0.%d:80", i+5)
+		targets = append(targets, &domain.LBTarget{ID: uuid.New(), LBID: lb.ID, InstanceID: instID, Port: 80, Health: domain.TargetHealthHealthy})
+	}
+	lbRepo.On("ListTargets", ctx, lb.ID).Return(targets, nil)
+
+	// Each probe only succeeds once every target is being probed at the
+	// same time, as slow targets must not hold up the others.
+	var started sync.WaitGroup
+	started.Add(len(targets))
+	all := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(all)
+	}()
+	w.probe = func(context.Context, string, domain.HealthCheckConfig) bool {
+		started.Done()
+		select {
+		case <-all:
+			return true
+		case <-time.After(time.Second):
+			return false
+		}
+	}
+
+	w.checkLBHealth(ctx, lb)
+
+	for _, target := range targets {
+		assert.Equal(t, 1, w.streaks[lb.ID][target.ID].successes)
+	}
+	lbRepo.AssertNotCalled(t, "UpdateTargetHealth", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLBService_UpdateHealthCheck(t *testing.T) {
+	ctx := context.Background()
+	lbRepo := new(mockLBRepo)
+	svc := NewLBService(lbRepo, new(mockVpcRepo), new(mockInstanceRepo), new(mockSecretService))
+	lbID := uuid.New()
+
+	lbRepo.On("GetByID", ctx, lbID).Return(&domain.LoadBalancer{ID: lbID, HealthCheck: domain.DefaultHealthCheck()}, nil)
+	lbRepo.On("Update", ctx, mock.MatchedBy(func(lb *domain.LoadBalancer) bool {
+		return lb.HealthCheck.Protocol == domain.HealthCheckProtocolTCP && lb.HealthCheck.IntervalSeconds == 30
+	})).Return(nil).Once()
+
+	lb, err := svc.UpdateHealthCheck(ctx, lbID, domain.HealthCheckConfig{Protocol: domain.HealthCheckProtocolTCP, IntervalSeconds: 30})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, lb.HealthCheck.HealthyThreshold)
+	lbRepo.AssertExpectations(t)
+}
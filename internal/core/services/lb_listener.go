@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+)
+
+var (
+	// Names and patterns end up in the proxy configuration, so they are held
+	// to strict character sets.
+	targetGroupName = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,62}[a-z0-9])?$`)
+	ruleHost        = regexp.MustCompile(`^(\*\.)?[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+	rulePathPrefix  = regexp.MustCompile(`^/[A-Za-z0-9._~%/-]*$`)
+
+	errInvalidTargetGroup = errors.New(errors.InvalidInput, "target group names use lowercase letters, digits and - (at most 64 characters)")
+)
+
+func (s *LBService) AddListener(ctx context.Context, lbID uuid.UUID, listener domain.LBListener) (*domain.LBListener, error) {
+	lb, err := s.lbRepo.GetByID(ctx, lbID)
+	if err != nil {
+		return nil, err
+	}
+
+	if listener.Port < 1 || listener.Port > 65535 {
+		return nil, errors.New(errors.InvalidInput, "listener port must be between 1 and 65535")
+	}
+	if listener.DefaultTargetGroup == "" {
+		listener.DefaultTargetGroup = domain.DefaultTargetGroup
+	}
+	if !targetGroupName.MatchString(listener.DefaultTargetGroup) {
+		return nil, errInvalidTargetGroup
+	}
+
+	switch listener.Protocol {
+	case domain.LBProtocolHTTPS:
+		if listener.CertificateSecret == "" {
+			return nil, errors.New(errors.InvalidInput, "https listeners need a certificate secret")
+		}
+		if err := s.checkCertificate(ctx, listener.CertificateSecret); err != nil {
+			return nil, err
+		}
+	case domain.LBProtocolHTTP, domain.LBProtocolTCP:
+		if listener.CertificateSecret != "" {
+			return nil, errors.New(errors.InvalidInput, "only https listeners take a certificate")
+		}
+	default:
+		return nil, errors.New(errors.InvalidInput, "listener protocol must be http, https or tcp")
+	}
+
+	if listener.RedirectPort != 0 {
+		if listener.Protocol != domain.LBProtocolHTTP {
+			return nil, errors.New(errors.InvalidInput, "only http listeners can redirect to https")
+		}
+		if listener.RedirectPort < 1 || listener.RedirectPort > 65535 {
+			return nil, errors.New(errors.InvalidInput, "redirect port must be between 1 and 65535")
+		}
+	}
+
+	existing, err := s.lbRepo.ListListeners(ctx, lb.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range existing {
+		if l.Port == listener.Port {
+			return nil, errors.New(errors.Conflict, fmt.Sprintf("load balancer already listens on port %d", listener.Port))
+		}
+	}
+
+	l := &domain.LBListener{
+		ID:                 uuid.New(),
+		LBID:               lb.ID,
+		Port:               listener.Port,
+		Protocol:           listener.Protocol,
+		CertificateSecret:  listener.CertificateSecret,
+		RedirectPort:       listener.RedirectPort,
+		DefaultTargetGroup: listener.DefaultTargetGroup,
+		CreatedAt:          time.Now(),
+	}
+	if err := s.lbRepo.CreateListener(ctx, l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// checkCertificate verifies that a secret holds a PEM certificate chain and
+// its private key.
+func (s *LBService) checkCertificate(ctx context.Context, name string) error {
+	secret, err := s.secretSvc.GetSecretByName(ctx, name)
+	if err != nil {
+		return errors.Wrap(errors.NotFound, "certificate secret not found", err)
+	}
+	pem := []byte(secret.EncryptedValue)
+	if _, err := tls.X509KeyPair(pem, pem); err != nil {
+		return errors.New(errors.InvalidInput, "certificate secret must hold a PEM certificate chain and private key: "+err.Error())
+	}
+	return nil
+}
+
+func (s *LBService) ListListeners(ctx context.Context, lbID uuid.UUID) ([]*domain.LBListener, error) {
+	if _, err := s.lbRepo.GetByID(ctx, lbID); err != nil {
+		return nil, err
+	}
+	return s.lbRepo.ListListeners(ctx, lbID)
+}
+
+func (s *LBService) RemoveListener(ctx context.Context, lbID, listenerID uuid.UUID) error {
+	if _, err := s.lbRepo.GetByID(ctx, lbID); err != nil {
+		return err
+	}
+	return s.lbRepo.DeleteListener(ctx, lbID, listenerID)
+}
+
+func (s *LBService) AddRoutingRule(ctx context.Context, lbID, listenerID uuid.UUID, rule domain.LBRoutingRule) (*domain.LBRoutingRule, error) {
+	listener, err := s.getListener(ctx, lbID, listenerID)
+	if err != nil {
+		return nil, err
+	}
+	if listener.Protocol == domain.LBProtocolTCP {
+		return nil, errors.New(errors.InvalidInput, "tcp listeners do not support routing rules")
+	}
+	if listener.RedirectPort != 0 {
+		return nil, errors.New(errors.InvalidInput, "listener redirects all requests to https")
+	}
+
+	host := strings.ToLower(rule.Host)
+	if host != "" && !ruleHost.MatchString(host) {
+		return nil, errors.New(errors.InvalidInput, "host must be a hostname, optionally starting with *.")
+	}
+	path := rule.PathPrefix
+	if path == "" {
+		path = "/"
+	}
+	if !rulePathPrefix.MatchString(path) {
+		return nil, errors.New(errors.InvalidInput, "path prefix must start with / and contain only letters, digits and . _ ~ % / -")
+	}
+	if host == "" && path == "/" {
+		return nil, errors.New(errors.InvalidInput, "a rule needs a host or a path prefix other than /; use the listener's default target group instead")
+	}
+	if !targetGroupName.MatchString(rule.TargetGroup) {
+		return nil, errInvalidTargetGroup
+	}
+
+	for _, r := range listener.Rules {
+		if r.Host == host && r.PathPrefix == path {
+			return nil, errors.New(errors.Conflict, "listener already has a rule for this host and path")
+		}
+	}
+
+	r := &domain.LBRoutingRule{
+		ID:          uuid.New(),
+		ListenerID:  listener.ID,
+		Host:        host,
+		PathPrefix:  path,
+		TargetGroup: rule.TargetGroup,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.lbRepo.CreateRoutingRule(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (s *LBService) RemoveRoutingRule(ctx context.Context, lbID, listenerID, ruleID uuid.UUID) error {
+	if _, err := s.getListener(ctx, lbID, listenerID); err != nil {
+		return err
+	}
+	return s.lbRepo.DeleteRoutingRule(ctx, listenerID, ruleID)
+}
+
+// getListener returns a listener of one of the caller's load balancers.
+func (s *LBService) getListener(ctx context.Context, lbID, listenerID uuid.UUID) (*domain.LBListener, error) {
+	listeners, err := s.ListListeners(ctx, lbID)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range listeners {
+		if l.ID == listenerID {
+			return l, nil
+		}
+	}
+	return nil, errors.New(errors.NotFound, "listener not found")
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func selfSignedPEM(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})) +
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestLBService_AddListener(t *testing.T) {
+	ctx := context.Background()
+	lbID := uuid.New()
+
+	setup := func() (*LBService, *mockLBRepo, *mockSecretService) {
+		lbRepo := new(mockLBRepo)
+		secretSvc := new(mockSecretService)
+		lbRepo.On("GetByID", ctx, lbID).Return(&domain.LoadBalancer{ID: lbID, Port: 80}, nil)
+		return NewLBService(lbRepo, new(mockVpcRepo), new(mockInstanceRepo), secretSvc), lbRepo, secretSvc
+	}
+
+	t.Run("https with a valid certificate", func(t *testing.T) {
+		svc, lbRepo, secretSvc := setup()
+		secretSvc.On("GetSecretByName", ctx, "site-cert").Return(&domain.Secret{EncryptedValue: selfSignedPEM(t)}, nil)
+		lbRepo.On("ListListeners", ctx, lbID).Return([]*domain.LBListener{}, nil)
+		lbRepo.On("CreateListener", ctx, mock.Anything).Return(nil)
+
+		l, err := svc.AddListener(ctx, lbID, domain.LBListener{Port: 443, Protocol: domain.LBProtocolHTTPS, CertificateSecret: "site-cert"})
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.DefaultTargetGroup, l.DefaultTargetGroup)
+		lbRepo.AssertExpectations(t)
+	})
+
+	t.Run("https rejects a secret that is not a key pair", func(t *testing.T) {
+		svc, _, secretSvc := setup()
+		secretSvc.On("GetSecretByName", ctx, "junk").Return(&domain.Secret{EncryptedValue: "not a certificate"}, nil)
+
+		_, err := svc.AddListener(ctx, lbID, domain.LBListener{Port: 443, Protocol: domain.LBProtocolHTTPS, CertificateSecret: "junk"})
+
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+	})
+
+	t.Run("https requires a certificate", func(t *testing.T) {
+		svc, _, _ := setup()
+		_, err := svc.AddListener(ctx, lbID, domain.LBListener{Port: 443, Protocol: domain.LBProtocolHTTPS})
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+	})
+
+	t.Run("redirect only on http", func(t *testing.T) {
+		svc, _, _ := setup()
+		_, err := svc.AddListener(ctx, lbID, domain.LBListener{Port: 9000, Protocol: domain.LBProtocolTCP, RedirectPort: 443})
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+	})
+
+	t.Run("unknown protocol", func(t *testing.T) {
+		svc, _, _ := setup()
+		_, err := svc.AddListener(ctx, lbID, domain.LBListener{Port: 9000, Protocol: "udp"})
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+	})
+
+	t.Run("duplicate port", func(t *testing.T) {
+		svc, lbRepo, _ := setup()
+		lbRepo.On("ListListeners", ctx, lbID).Return([]*domain.LBListener{{Port: 8080, Protocol: domain.LBProtocolHTTP}}, nil)
+
+		_, err := svc.AddListener(ctx, lbID, domain.LBListener{Port: 8080, Protocol: domain.LBProtocolTCP})
+
+		assert.True(t, errors.Is(err, errors.Conflict))
+	})
+}
+
+func TestLBService_AddRoutingRule(t *testing.T) {
+	ctx := context.Background()
+	lbID := uuid.New()
+	httpID := uuid.New()
+	tcpID := uuid.New()
+
+	lbRepo := new(mockLBRepo)
+	lbRepo.On("GetByID", ctx, lbID).Return(&domain.LoadBalancer{ID: lbID}, nil)
+	lbRepo.On("ListListeners", ctx, lbID).Return([]*domain.LBListener{
+		{ID: httpID, Port: 80, Protocol: domain.LBProtocolHTTP, Rules: []*domain.LBRoutingRule{
+			{Host: "", PathPrefix: "/api/", TargetGroup: "api"},
+		}},
+		{ID: tcpID, Port: 5432, Protocol: domain.LBProtocolTCP},
+	}, nil)
+	lbRepo.On("CreateRoutingRule", ctx, mock.Anything).Return(nil)
+	svc := NewLBService(lbRepo, new(mockVpcRepo), new(mockInstanceRepo), new(mockSecretService))
+
+	t.Run("host rule is normalised", func(t *testing.T) {
+		r, err := svc.AddRoutingRule(ctx, lbID, httpID, domain.LBRoutingRule{Host: "*.Example.com", TargetGroup: "tenants"})
+		require.NoError(t, err)
+		assert.Equal(t, "*.example.com", r.Host)
+		assert.Equal(t, "/", r.PathPrefix)
+	})
+
+	cases := []struct {
+		name     string
+		listener uuid.UUID
+		rule     domain.LBRoutingRule
+		errType  errors.Type
+	}{
+		{"tcp listener", tcpID, domain.LBRoutingRule{PathPrefix: "/x", TargetGroup: "api"}, errors.InvalidInput},
+		{"unknown listener", uuid.New(), domain.LBRoutingRule{PathPrefix: "/x", TargetGroup: "api"}, errors.NotFound},
+		{"relative path", httpID, domain.LBRoutingRule{PathPrefix: "api", TargetGroup: "api"}, errors.InvalidInput},
+		{"config injection", httpID, domain.LBRoutingRule{PathPrefix: "/a { return 200; }", TargetGroup: "api"}, errors.InvalidInput},
+		{"bad host", httpID, domain.LBRoutingRule{Host: "a b", TargetGroup: "api"}, errors.InvalidInput},
+		{"bad group", httpID, domain.LBRoutingRule{PathPrefix: "/x", TargetGroup: "API"}, errors.InvalidInput},
+		{"matches everything", httpID, domain.LBRoutingRule{TargetGroup: "api"}, errors.InvalidInput},
+		{"duplicate", httpID, domain.LBRoutingRule{PathPrefix: "/api/", TargetGroup: "other"}, errors.Conflict},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := svc.AddRoutingRule(ctx, lbID, tc.listener, tc.rule)
+			assert.True(t, errors.Is(err, tc.errType), "got %v", err)
+		})
+	}
+}
+
+type fakeConfigProxy struct {
+	ports.LBProxyAdapter
+	pushed []string
+	closed int
+}
+
+func (p *fakeConfigProxy) Close() {
+	p.closed++
+}
+
+func (p *fakeConfigProxy) UpdateProxyConfig(ctx context.Context, lb *domain.LoadBalancer, targets []*domain.LBTarget) error {
+	p.pushed = append(p.pushed, string(lb.Listeners[0].Certificate))
+	return nil
+}
+
+func TestLBWorker_PushesRotatedCertificate(t *testing.T) {
+	ctx := context.Background()
+	lb := &domain.LoadBalancer{ID: uuid.New(), Status: domain.LBStatusActive}
+	lbRepo := new(mockLBRepo)
+	lbRepo.On("ListListeners", ctx, lb.ID).Return([]*domain.LBListener{
+		{Port: 443, Protocol: domain.LBProtocolHTTPS, CertificateSecret: "site-cert"},
+	}, nil)
+	lbRepo.On("ListTargets", ctx, lb.ID).Return([]*domain.LBTarget{}, nil)
+	secretSvc := new(mockSecretService)
+	proxy := &fakeConfigProxy{}
+	w := NewLBWorker(lbRepo, proxy, secretSvc, nil)
+
+	oldID, newID := uuid.New(), uuid.New()
+	secretSvc.On("ListSecrets", ctx).Return([]*domain.Secret{{ID: oldID, Name: "site-cert"}}, nil).Twice()
+	secretSvc.On("GetSecretByName", ctx, "site-cert").Return(&domain.Secret{ID: oldID, EncryptedValue: "old"}, nil).Once()
+
+	// An unchanged secret is read and pushed once
+	w.updateLB(ctx, lb)
+	w.updateLB(ctx, lb)
+	assert.Equal(t, []string{"old"}, proxy.pushed)
+
+	// Replacing the secret pushes the new certificate
+	secretSvc.On("ListSecrets", ctx).Return([]*domain.Secret{{ID: newID, Name: "site-cert"}}, nil).Once()
+	secretSvc.On("GetSecretByName", ctx, "site-cert").Return(&domain.Secret{ID: newID, EncryptedValue: "new"}, nil).Once()
+	w.updateLB(ctx, lb)
+	assert.Equal(t, []string{"old", "new"}, proxy.pushed)
+	secretSvc.AssertExpectations(t)
+}
+
+func TestLBWorker_ClosesLocalProxiesWhenStopped(t *testing.T) {
+	ctx := context.Background()
+	lb := &domain.LoadBalancer{ID: uuid.New(), Status: domain.LBStatusActive}
+	lbRepo := new(mockLBRepo)
+	lbRepo.On("ListListeners", ctx, lb.ID).Return([]*domain.LBListener{
+		{Port: 443, Protocol: domain.LBProtocolHTTPS, CertificateSecret: "site-cert"},
+	}, nil)
+	lbRepo.On("ListTargets", ctx, lb.ID).Return([]*domain.LBTarget{}, nil)
+	secretID := uuid.New()
+	secretSvc := new(mockSecretService)
+	secretSvc.On("ListSecrets", ctx).Return([]*domain.Secret{{ID: secretID, Name: "site-cert"}}, nil)
+	secretSvc.On("GetSecretByName", ctx, "site-cert").Return(&domain.Secret{ID: secretID, EncryptedValue: "cert"}, nil)
+	proxy := &fakeConfigProxy{}
+	w := NewLBWorker(lbRepo, proxy, secretSvc, nil)
+
+	w.updateLB(ctx, lb)
+
+	// Losing leadership stops the worker, which closes the listeners.
+	stopped, cancel := context.WithCancel(ctx)
+	cancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	w.Run(stopped, &wg)
+	assert.Equal(t, 1, proxy.closed)
+
+	// Leading again pushes the configuration anew.
+	w.updateLB(ctx, lb)
+	assert.Equal(t, []string{"cert", "cert"}, proxy.pushed)
+}
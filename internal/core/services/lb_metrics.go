@@ -0,0 +1,366 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/poyrazk/thecloud/internal/platform"
+)
+
+const (
+	// accessLogShipInterval is how often buffered access logs are written to
+	// the log bucket of a load balancer, as one object per interval.
+	accessLogShipInterval = time.Minute
+	// maxPendingLogEntries caps the buffer of a load balancer whose logs
+	// cannot be shipped; the oldest entries are dropped first.
+	maxPendingLogEntries = 100000
+
+	// DefaultMetricsWindow is used when GetMetrics is called without a window.
+	DefaultMetricsWindow = time.Hour
+	// requestStatsRetention bounds both the metrics window and how long
+	// request stats are kept.
+	requestStatsRetention = 24 * time.Hour
+)
+
+func (s *LBService) SetAccessLogs(ctx context.Context, id uuid.UUID, cfg domain.AccessLogConfig) (*domain.LoadBalancer, error) {
+	cfg.Bucket = strings.TrimSpace(cfg.Bucket)
+	cfg.Prefix = strings.TrimLeft(strings.TrimSpace(cfg.Prefix), "/")
+	if cfg.Enabled && cfg.Bucket == "" {
+		return nil, errors.New(errors.InvalidInput, "a bucket is required to ship access logs")
+	}
+	if strings.Contains(cfg.Bucket, "/") {
+		return nil, errors.New(errors.InvalidInput, "invalid bucket name")
+	}
+
+	lb, err := s.lbRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	lb.AccessLogs = cfg
+	if err := s.lbRepo.Update(ctx, lb); err != nil {
+		return nil, err
+	}
+	return lb, nil
+}
+
+func (s *LBService) GetMetrics(ctx context.Context, id uuid.UUID, window time.Duration) (*domain.LBMetrics, error) {
+	if window == 0 {
+		window = DefaultMetricsWindow
+	}
+	if window < time.Minute || window > requestStatsRetention {
+		return nil, errors.New(errors.InvalidInput, fmt.Sprintf("window must be between 1m and %s", requestStatsRetention))
+	}
+
+	// Ownership check; stats rows carry no user.
+	if _, err := s.lbRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	since := time.Now().Add(-window).Truncate(time.Minute)
+	stats, err := s.lbRepo.ListRequestStats(ctx, id, since)
+	if err != nil {
+		return nil, err
+	}
+
+	byTarget := make(map[uuid.UUID][]*domain.LBRequestStats)
+	for _, st := range stats {
+		if st.InstanceID != nil {
+			byTarget[*st.InstanceID] = append(byTarget[*st.InstanceID], st)
+		}
+	}
+
+	m := &domain.LBMetrics{
+		LBID:    id,
+		Since:   since,
+		Total:   summarizeRequestStats(stats, window),
+		Targets: make([]domain.LBTargetMetrics, 0, len(byTarget)),
+	}
+	for instID, st := range byTarget {
+		m.Targets = append(m.Targets, domain.LBTargetMetrics{
+			InstanceID:       instID,
+			LBMetricsSummary: summarizeRequestStats(st, window),
+		})
+	}
+	sort.Slice(m.Targets, func(i, j int) bool {
+		return m.Targets[i].InstanceID.String() < m.Targets[j].InstanceID.String()
+	})
+	return m, nil
+}
+
+// processAccessLogs collects the access logs of every active load balancer,
+// records them as metrics and ships them to the log bucket if configured.
+func (w *LBWorker) processAccessLogs(ctx context.Context) {
+	lbs, err := w.lbRepo.ListAll(ctx)
+	if err != nil {
+		return
+	}
+
+	for _, lb := range lbs {
+		if lb.Status != domain.LBStatusActive {
+			continue
+		}
+		gCtx := appcontext.WithUserID(ctx, lb.UserID)
+		w.collectAccessLogs(gCtx, lb)
+		w.shipAccessLogs(gCtx, lb, false)
+		// Targets can be removed by the API as well as by the drain loop, so
+		// the registered set is checked here rather than at each removal.
+		if targets, err := w.lbRepo.ListTargets(gCtx, lb.ID); err == nil {
+			w.pruneTargetSeries(lb.ID, targets)
+		}
+	}
+
+	if time.Since(w.lastPruned) >= time.Hour {
+		if err := w.lbRepo.DeleteRequestStatsBefore(ctx, time.Now().Add(-requestStatsRetention)); err != nil {
+			log.Printf("Worker: failed to prune LB request stats: %v", err)
+		} else {
+			w.lastPruned = time.Now()
+		}
+	}
+}
+
+func (w *LBWorker) collectAccessLogs(ctx context.Context, lb *domain.LoadBalancer) {
+	entries, err := w.proxyAdapter.CollectAccessLogs(ctx, lb.ID)
+	if err != nil {
+		log.Printf("Worker: failed to collect access logs of LB %s: %v", lb.ID, err)
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	lbID := lb.ID.String()
+	for _, e := range entries {
+		platform.LBRequestsTotal.WithLabelValues(lbID, statusClass(e.Status)).Inc()
+		platform.LBRequestDuration.WithLabelValues(lbID).Observe(e.DurationSeconds)
+		if e.InstanceID != nil {
+			platform.LBTargetRequestsTotal.WithLabelValues(lbID, e.InstanceID.String()).Inc()
+			if w.targetSeries[lb.ID] == nil {
+				w.targetSeries[lb.ID] = make(map[uuid.UUID]bool)
+			}
+			w.targetSeries[lb.ID][*e.InstanceID] = true
+		}
+	}
+	if err := w.lbRepo.SaveRequestStats(ctx, aggregateAccessLogs(lb.ID, entries)); err != nil {
+		log.Printf("Worker: failed to save request stats of LB %s: %v", lb.ID, err)
+	}
+
+	if !lb.AccessLogs.Enabled {
+		return
+	}
+	pending := append(w.pendingLogs[lb.ID], entries...)
+	if over := len(pending) - maxPendingLogEntries; over > 0 {
+		log.Printf("Worker: dropping %d unshipped access log entries of LB %s", over, lb.ID)
+		pending = pending[over:]
+	}
+	w.pendingLogs[lb.ID] = pending
+}
+
+// shipAccessLogs writes the buffered entries of a load balancer as one JSON
+// lines object under <prefix><lb-id>/YYYY/MM/DD/ in its log bucket, at most
+// once per accessLogShipInterval unless forced.
+func (w *LBWorker) shipAccessLogs(ctx context.Context, lb *domain.LoadBalancer, force bool) {
+	pending := w.pendingLogs[lb.ID]
+	if !lb.AccessLogs.Enabled {
+		delete(w.pendingLogs, lb.ID)
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+	last, ok := w.lastShipped[lb.ID]
+	if !ok {
+		// Start the interval at the first buffered entry.
+		w.lastShipped[lb.ID] = time.Now()
+		last = time.Now()
+	}
+	if !force && time.Since(last) < accessLogShipInterval {
+		return
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range pending {
+		_ = enc.Encode(e)
+	}
+	now := time.Now().UTC()
+	key := fmt.Sprintf("%s%s/%s/%s.log", lb.AccessLogs.Prefix, lb.ID, now.Format("2006/01/02"), now.Format("20060102T150405Z"))
+	_, err := w.storageSvc.Upload(ctx, lb.AccessLogs.Bucket, key, &buf, domain.ObjectOptions{ContentType: "application/x-ndjson"})
+	if err != nil {
+		platform.LBAccessLogsShipped.WithLabelValues("error").Inc()
+		log.Printf("Worker: failed to ship access logs of LB %s to bucket %s: %v", lb.ID, lb.AccessLogs.Bucket, err)
+		return
+	}
+	platform.LBAccessLogsShipped.WithLabelValues("success").Inc()
+	delete(w.pendingLogs, lb.ID)
+	w.lastShipped[lb.ID] = time.Now()
+}
+
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "other"
+	}
+	return fmt.Sprintf("%dxx", status/100)
+}
+
+// aggregateAccessLogs folds access log entries into one stats row per target
+// and minute.
+func aggregateAccessLogs(lbID uuid.UUID, entries []domain.LBAccessLogEntry) []*domain.LBRequestStats {
+	type key struct {
+		instance uuid.UUID
+		minute   time.Time
+	}
+	rows := make(map[key]*domain.LBRequestStats)
+	var order []key
+
+	for _, e := range entries {
+		k := key{minute: e.Time.UTC().Truncate(time.Minute)}
+		if e.InstanceID != nil {
+			k.instance = *e.InstanceID
+		}
+		st, ok := rows[k]
+		if !ok {
+			st = &domain.LBRequestStats{
+				LBID:          lbID,
+				InstanceID:    e.InstanceID,
+				Minute:        k.minute,
+				LatencyCounts: make([]int64, len(domain.LBLatencyBuckets)+1),
+			}
+			rows[k] = st
+			order = append(order, k)
+		}
+
+		st.Requests++
+		st.BytesSent += e.BytesSent
+		st.LatencySum += e.DurationSeconds
+		st.LatencyCounts[latencyBucket(e.DurationSeconds)]++
+		switch e.Status / 100 {
+		case 2:
+			st.Status2xx++
+		case 3:
+			st.Status3xx++
+		case 4:
+			st.Status4xx++
+		case 5:
+			st.Status5xx++
+		}
+	}
+
+	res := make([]*domain.LBRequestStats, 0, len(order))
+	for _, k := range order {
+		res = append(res, rows[k])
+	}
+	return res
+}
+
+func latencyBucket(seconds float64) int {
+	return sort.SearchFloat64s(domain.LBLatencyBuckets, seconds)
+}
+
+func summarizeRequestStats(stats []*domain.LBRequestStats, window time.Duration) domain.LBMetricsSummary {
+	var sum domain.LBMetricsSummary
+	counts := make([]int64, len(domain.LBLatencyBuckets)+1)
+	var latencySum float64
+
+	for _, st := range stats {
+		sum.Requests += st.Requests
+		sum.BytesSent += st.BytesSent
+		sum.Status2xx += st.Status2xx
+		sum.Status3xx += st.Status3xx
+		sum.Status4xx += st.Status4xx
+		sum.Status5xx += st.Status5xx
+		latencySum += st.LatencySum
+		for i, c := range st.LatencyCounts {
+			if i < len(counts) {
+				counts[i] += c
+			}
+		}
+	}
+
+	sum.Latency = make([]domain.LBLatencyBucket, len(counts))
+	for i, c := range counts {
+		b := domain.LBLatencyBucket{Count: c}
+		if i < len(domain.LBLatencyBuckets) {
+			b.LeSeconds = domain.LBLatencyBuckets[i]
+		}
+		sum.Latency[i] = b
+	}
+	if sum.Requests == 0 {
+		return sum
+	}
+
+	n := float64(sum.Requests)
+	sum.RequestsPerSecond = round(n / window.Seconds())
+	sum.Rate4xx = round(float64(sum.Status4xx) / n)
+	sum.Rate5xx = round(float64(sum.Status5xx) / n)
+	sum.LatencyAvgMs = round(latencySum / n * 1000)
+	sum.LatencyP50Ms = round(latencyQuantile(counts, 0.50) * 1000)
+	sum.LatencyP90Ms = round(latencyQuantile(counts, 0.90) * 1000)
+	sum.LatencyP99Ms = round(latencyQuantile(counts, 0.99) * 1000)
+	return sum
+}
+
+// latencyQuantile estimates a quantile from histogram counts by linear
+// interpolation within the bucket it falls into, like Prometheus'
+// histogram_quantile. The overflow bucket reports the largest bound.
+func latencyQuantile(counts []int64, q float64) float64 {
+	var total int64
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := q * float64(total)
+	var seen float64
+	for i, c := range counts {
+		if seen+float64(c) < rank || c == 0 {
+			seen += float64(c)
+			continue
+		}
+		if i == len(domain.LBLatencyBuckets) {
+			return domain.LBLatencyBuckets[i-1]
+		}
+		lower := 0.0
+		if i > 0 {
+			lower = domain.LBLatencyBuckets[i-1]
+		}
+		upper := domain.LBLatencyBuckets[i]
+		return lower + (upper-lower)*(rank-seen)/float64(c)
+	}
+	return domain.LBLatencyBuckets[len(domain.LBLatencyBuckets)-1]
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+// pruneTargetSeries deletes the per-target request series of instances that
+// are no longer registered with the load balancer.
+func (w *LBWorker) pruneTargetSeries(lbID uuid.UUID, targets []*domain.LBTarget) {
+	series := w.targetSeries[lbID]
+	if len(series) == 0 {
+		return
+	}
+	registered := make(map[uuid.UUID]bool, len(targets))
+	for _, t := range targets {
+		registered[t.InstanceID] = true
+	}
+	for id := range series {
+		if !registered[id] {
+			platform.LBTargetRequestsTotal.DeleteLabelValues(lbID.String(), id.String())
+			delete(series, id)
+		}
+	}
+}
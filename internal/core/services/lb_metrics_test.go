@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/poyrazk/thecloud/internal/platform"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLogProxy struct {
+	ports.LBProxyAdapter
+	entries []domain.LBAccessLogEntry
+}
+
+func (p *fakeLogProxy) CollectAccessLogs(ctx context.Context, lbID uuid.UUID) ([]domain.LBAccessLogEntry, error) {
+	entries := p.entries
+	p.entries = nil
+	return entries, nil
+}
+
+type fakeLogBucket struct {
+	ports.StorageService
+	bucket, key, body string
+}
+
+func (s *fakeLogBucket) Upload(ctx context.Context, bucket, key string, r io.Reader, opts domain.ObjectOptions) (*domain.Object, error) {
+	data, _ := io.ReadAll(r)
+	s.bucket, s.key, s.body = bucket, key, string(data)
+	return &domain.Object{Bucket: bucket, Key: key}, nil
+}
+
+func TestAggregateAccessLogs(t *testing.T) {
+	lbID := uuid.New()
+	instA, instB := uuid.New(), uuid.New()
+	minute := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	stats := aggregateAccessLogs(lbID, []domain.LBAccessLogEntry{
+		{Time: minute.Add(time.Second), Status: 200, DurationSeconds: 0.004, BytesSent: 100, InstanceID: &instA},
+		{Time: minute.Add(2 * time.Second), Status: 502, DurationSeconds: 0.3, BytesSent: 50, InstanceID: &instA},
+		{Time: minute.Add(3 * time.Second), Status: 404, DurationSeconds: 20, InstanceID: &instB},
+		{Time: minute.Add(61 * time.Second), Status: 200, DurationSeconds: 0.01, InstanceID: &instA},
+		{Time: minute.Add(4 * time.Second), Status: 503},
+	})
+
+	require.Len(t, stats, 4)
+	a := stats[0]
+	assert.Equal(t, instA, *a.InstanceID)
+	assert.Equal(t, minute, a.Minute)
+	assert.Equal(t, int64(2), a.Requests)
+	assert.Equal(t, int64(1), a.Status2xx)
+	assert.Equal(t, int64(1), a.Status5xx)
+	assert.Equal(t, int64(150), a.BytesSent)
+	assert.Equal(t, int64(1), a.LatencyCounts[0]) // <= 5ms
+	assert.Equal(t, int64(1), a.LatencyCounts[6]) // <= 500ms
+	assert.Equal(t, int64(1), stats[1].LatencyCounts[len(domain.LBLatencyBuckets)])
+	assert.Equal(t, int64(1), stats[1].Status4xx)
+	assert.Equal(t, minute.Add(time.Minute), stats[2].Minute)
+	assert.Nil(t, stats[3].InstanceID)
+}
+
+func TestSummarizeRequestStats(t *testing.T) {
+	counts := make([]int64, len(domain.LBLatencyBuckets)+1)
+	counts[3] = 90 // 25-50ms
+	counts[5] = 10 // 100-250ms
+	stats := []*domain.LBRequestStats{{
+		Requests: 100, Status2xx: 80, Status4xx: 15, Status5xx: 5,
+		LatencySum: 5, LatencyCounts: counts,
+	}}
+
+	sum := summarizeRequestStats(stats, time.Minute)
+
+	assert.Equal(t, int64(100), sum.Requests)
+	assert.InDelta(t, 1.667, sum.RequestsPerSecond, 0.001)
+	assert.Equal(t, 0.15, sum.Rate4xx)
+	assert.Equal(t, 0.05, sum.Rate5xx)
+	assert.Equal(t, 50.0, sum.LatencyAvgMs)
+	assert.InDelta(t, 38.889, sum.LatencyP50Ms, 0.001)
+	assert.InDelta(t, 50, sum.LatencyP90Ms, 0.001)
+	assert.InDelta(t, 235, sum.LatencyP99Ms, 0.001)
+	assert.Len(t, sum.Latency, len(domain.LBLatencyBuckets)+1)
+	assert.Equal(t, 0.05, sum.Latency[3].LeSeconds)
+	assert.Equal(t, int64(90), sum.Latency[3].Count)
+
+	empty := summarizeRequestStats(nil, time.Minute)
+	assert.Zero(t, empty.Requests)
+	assert.Zero(t, empty.LatencyP99Ms)
+}
+
+func TestLBService_GetMetrics(t *testing.T) {
+	ctx := context.Background()
+	lbID := uuid.New()
+	instA, instB := uuid.New(), uuid.New()
+	lbRepo := new(mockLBRepo)
+	svc := NewLBService(lbRepo, new(mockVpcRepo), new(mockInstanceRepo), new(mockSecretService))
+
+	lbRepo.On("GetByID", ctx, lbID).Return(&domain.LoadBalancer{ID: lbID}, nil)
+	lbRepo.On("ListRequestStats", ctx, lbID, mock.Anything).Return([]*domain.LBRequestStats{
+		{InstanceID: &instA, Requests: 3, Status2xx: 3},
+		{InstanceID: &instB, Requests: 1, Status5xx: 1},
+		{InstanceID: &instA, Requests: 1, Status4xx: 1},
+		{Requests: 2, Status5xx: 2},
+	}, nil)
+
+	m, err := svc.GetMetrics(ctx, lbID, 15*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), m.Total.Requests)
+	assert.Equal(t, int64(3), m.Total.Status5xx)
+	assert.Len(t, m.Targets, 2)
+	for _, tm := range m.Targets {
+		if tm.InstanceID == instA {
+			assert.Equal(t, int64(4), tm.Requests)
+			assert.Equal(t, 0.25, tm.Rate4xx)
+		} else {
+			assert.Equal(t, 1.0, tm.Rate5xx)
+		}
+	}
+	assert.WithinDuration(t, time.Now().Add(-15*time.Minute), m.Since, time.Minute)
+
+	_, err = svc.GetMetrics(ctx, lbID, 48*time.Hour)
+	assert.True(t, errors.Is(err, errors.InvalidInput))
+}
+
+func TestLBService_SetAccessLogs(t *testing.T) {
+	ctx := context.Background()
+	lbID := uuid.New()
+	lbRepo := new(mockLBRepo)
+	svc := NewLBService(lbRepo, new(mockVpcRepo), new(mockInstanceRepo), new(mockSecretService))
+
+	_, err := svc.SetAccessLogs(ctx, lbID, domain.AccessLogConfig{Enabled: true})
+	assert.True(t, errors.Is(err, errors.InvalidInput))
+
+	lbRepo.On("GetByID", ctx, lbID).Return(&domain.LoadBalancer{ID: lbID}, nil)
+	lbRepo.On("Update", ctx, mock.Anything).Return(nil)
+
+	lb, err := svc.SetAccessLogs(ctx, lbID, domain.AccessLogConfig{Enabled: true, Bucket: "logs", Prefix: "/lb/"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.AccessLogConfig{Enabled: true, Bucket: "logs", Prefix: "lb/"}, lb.AccessLogs)
+}
+
+func TestLBWorker_ProcessAccessLogs(t *testing.T) {
+	ctx := context.Background()
+	lbRepo := new(mockLBRepo)
+	proxy := &fakeLogProxy{}
+	bucket := &fakeLogBucket{}
+	w := NewLBWorker(lbRepo, proxy, nil, bucket)
+
+	instID := uuid.New()
+	lb := &domain.LoadBalancer{
+		ID:         uuid.New(),
+		Status:     domain.LBStatusActive,
+		AccessLogs: domain.AccessLogConfig{Enabled: true, Bucket: "logs", Prefix: "lb/"},
+	}
+	proxy.entries = []domain.LBAccessLogEntry{
+		{Time: time.Now(), Status: 200, Path: "/a", InstanceID: &instID},
+		{Time: time.Now(), Status: 500, Path: "/b", InstanceID: &instID},
+	}
+
+	lbRepo.On("ListAll", ctx).Return([]*domain.LoadBalancer{lb}, nil)
+	lbRepo.On("SaveRequestStats", mock.Anything, mock.MatchedBy(func(stats []*domain.LBRequestStats) bool {
+		return len(stats) == 1 && stats[0].Requests == 2 && stats[0].Status5xx == 1
+	})).Return(nil).Once()
+	lbRepo.On("DeleteRequestStatsBefore", ctx, mock.Anything).Return(nil).Once()
+	lbRepo.On("ListTargets", mock.Anything, lb.ID).Return([]*domain.LBTarget{{LBID: lb.ID, InstanceID: instID}}, nil).Once()
+
+	w.processAccessLogs(ctx)
+
+	lbRepo.AssertExpectations(t)
+	// Logs are buffered until the ship interval has passed.
+	assert.Empty(t, bucket.key)
+	assert.Len(t, w.pendingLogs[lb.ID], 2)
+	assert.True(t, w.targetSeries[lb.ID][instID])
+	assert.InDelta(t, 2, testutil.ToFloat64(platform.LBTargetRequestsTotal.WithLabelValues(lb.ID.String(), instID.String())), 0)
+
+	// The target is deregistered, so its series goes away.
+	lbRepo.On("ListTargets", mock.Anything, lb.ID).Return([]*domain.LBTarget{}, nil).Once()
+
+	w.lastShipped[lb.ID] = time.Now().Add(-accessLogShipInterval)
+	w.processAccessLogs(ctx)
+
+	assert.Equal(t, "logs", bucket.bucket)
+	assert.Regexp(t, `^lb/`+lb.ID.String()+`/\d{4}/\d{2}/\d{2}/\d{8}T\d{6}Z\.log$`, bucket.key)
+	assert.Contains(t, bucket.body, `"path":"/a"`)
+	assert.Contains(t, bucket.body, `"path":"/b"`)
+	assert.Empty(t, w.pendingLogs[lb.ID])
+	assert.Empty(t, w.targetSeries[lb.ID])
+	assert.False(t, platform.LBTargetRequestsTotal.DeleteLabelValues(lb.ID.String(), instID.String()))
+}
@@ -2,28 +2,63 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log"
-	"net"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	appcontext "github.com/poyrazk/thecloud/internal/core/context"
 	"github.com/poyrazk/thecloud/internal/core/domain"
 	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/platform"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type LBWorker struct {
 	lbRepo       ports.LBRepository
-	instanceRepo ports.InstanceRepository
 	proxyAdapter ports.LBProxyAdapter
+	secretSvc    ports.SecretService
+	storageSvc   ports.StorageService
+	// applied holds a fingerprint of the configuration last pushed to each
+	// proxy so unchanged load balancers are not reloaded on every tick.
+	applied map[uuid.UUID]string
+	// certificates holds the certificate resolved for each HTTPS listener, by
+	// load balancer and port, so unchanged secrets are not read on every tick.
+	certificates map[uuid.UUID]map[int]cachedCertificate
+
+	probe       func(ctx context.Context, addr string, cfg domain.HealthCheckConfig) bool
+	lastChecked map[uuid.UUID]time.Time
+	// streaks holds the consecutive probe results of each target, by load balancer.
+	streaks map[uuid.UUID]map[uuid.UUID]*healthStreak
+
+	// pendingLogs buffers access log entries until they are shipped to the
+	// load balancer's log bucket.
+	pendingLogs map[uuid.UUID][]domain.LBAccessLogEntry
+	lastShipped map[uuid.UUID]time.Time
+	lastPruned  time.Time
+	// targetSeries holds the instance IDs with a per-target request series,
+	// by load balancer, so the series of deregistered targets can be deleted.
+	targetSeries map[uuid.UUID]map[uuid.UUID]bool
 }
 
-func NewLBWorker(lbRepo ports.LBRepository, instanceRepo ports.InstanceRepository, proxyAdapter ports.LBProxyAdapter) *LBWorker {
+func NewLBWorker(lbRepo ports.LBRepository, proxyAdapter ports.LBProxyAdapter, secretSvc ports.SecretService, storageSvc ports.StorageService) *LBWorker {
 	return &LBWorker{
 		lbRepo:       lbRepo,
-		instanceRepo: instanceRepo,
 		proxyAdapter: proxyAdapter,
+		secretSvc:    secretSvc,
+		storageSvc:   storageSvc,
+		applied:      make(map[uuid.UUID]string),
+		certificates: make(map[uuid.UUID]map[int]cachedCertificate),
+		probe:        probeTarget,
+		lastChecked:  make(map[uuid.UUID]time.Time),
+		streaks:      make(map[uuid.UUID]map[uuid.UUID]*healthStreak),
+		pendingLogs:  make(map[uuid.UUID][]domain.LBAccessLogEntry),
+		lastShipped:  make(map[uuid.UUID]time.Time),
+		targetSeries: make(map[uuid.UUID]map[uuid.UUID]bool),
 	}
 }
 
@@ -38,16 +73,31 @@ func (w *LBWorker) Run(ctx context.Context, wg *sync.WaitGroup) {
 		select {
 		case <-ctx.Done():
 			log.Println("Load Balancer Worker stopping")
+			w.releaseProxies()
 			return
 		case <-ticker.C:
 			w.processCreatingLBs(ctx)
 			w.processDeletingLBs(ctx)
-			w.processActiveLBs(ctx)
 			w.processHealthChecks(ctx)
+			w.processDrainingTargets(ctx)
+			w.processActiveLBs(ctx)
+			w.processAccessLogs(ctx)
 		}
 	}
 }
 
+// releaseProxies stops an in-process data plane, which must not keep serving
+// once this replica stops leading, and forgets what was pushed so the
+// configuration is applied afresh if it leads again. Proxies that run apart
+// from the API, such as the nginx containers, are left to the next leader.
+func (w *LBWorker) releaseProxies() {
+	if closer, ok := w.proxyAdapter.(interface{ Close() }); ok {
+		closer.Close()
+	}
+	w.applied = make(map[uuid.UUID]string)
+	w.certificates = make(map[uuid.UUID]map[int]cachedCertificate)
+}
+
 func (w *LBWorker) processCreatingLBs(ctx context.Context) {
 	lbs, err := w.lbRepo.ListAll(ctx)
 	if err != nil {
@@ -80,17 +130,23 @@ func (w *LBWorker) processDeletingLBs(ctx context.Context) {
 func (w *LBWorker) deployLB(ctx context.Context, lb *domain.LoadBalancer) {
 	log.Printf("Worker: deploying LB %s", lb.ID)
 
-	targets, err := w.lbRepo.ListTargets(ctx, lb.ID)
+	targets, err := w.loadProxyConfig(ctx, lb)
 	if err != nil {
-		log.Printf("Worker: failed to list targets for LB %s: %v", lb.ID, err)
+		log.Printf("Worker: failed to load configuration for LB %s: %v", lb.ID, err)
 		return
 	}
+	if err := w.resolveCertificates(ctx, lb); err != nil {
+		log.Printf("Worker: failed to resolve certificates for LB %s: %v", lb.ID, err)
+		return
+	}
+	fingerprint := configFingerprint(lb, targets)
 
 	_, err = w.proxyAdapter.DeployProxy(ctx, lb, targets)
 	if err != nil {
 		log.Printf("Worker: failed to deploy proxy for LB %s: %v", lb.ID, err)
 		return
 	}
+	w.applied[lb.ID] = fingerprint
 
 	lb.Status = domain.LBStatusActive
 	if err := w.lbRepo.Update(ctx, lb); err != nil {
@@ -103,10 +159,24 @@ func (w *LBWorker) deployLB(ctx context.Context, lb *domain.LoadBalancer) {
 func (w *LBWorker) cleanupLB(ctx context.Context, lb *domain.LoadBalancer) {
 	log.Printf("Worker: cleaning up LB %s", lb.ID)
 
+	// Flush the last access logs before the proxy and its log files go away.
+	w.collectAccessLogs(ctx, lb)
+	w.shipAccessLogs(ctx, lb, true)
+
 	err := w.proxyAdapter.RemoveProxy(ctx, lb.ID)
 	if err != nil {
 		log.Printf("Worker: failed to remove proxy for LB %s: %v", lb.ID, err)
 	}
+	delete(w.applied, lb.ID)
+	delete(w.certificates, lb.ID)
+	delete(w.lastChecked, lb.ID)
+	delete(w.streaks, lb.ID)
+	delete(w.pendingLogs, lb.ID)
+	delete(w.lastShipped, lb.ID)
+	platform.LBRequestsTotal.DeletePartialMatch(prometheus.Labels{"lb_id": lb.ID.String()})
+	platform.LBRequestDuration.DeleteLabelValues(lb.ID.String())
+	platform.LBTargetRequestsTotal.DeletePartialMatch(prometheus.Labels{"lb_id": lb.ID.String()})
+	delete(w.targetSeries, lb.ID)
 
 	if err := w.lbRepo.Delete(ctx, lb.ID); err != nil {
 		log.Printf("Worker: failed to delete LB %s from DB: %v", lb.ID, err)
@@ -124,137 +194,220 @@ func (w *LBWorker) processActiveLBs(ctx context.Context) {
 	for _, lb := range lbs {
 		if lb.Status == domain.LBStatusActive {
 			gCtx := appcontext.WithUserID(ctx, lb.UserID)
-			targets, err := w.lbRepo.ListTargets(gCtx, lb.ID)
-			if err != nil {
-				continue
-			}
-			// Update configuration (e.g. if targets changed)
-			if err := w.proxyAdapter.UpdateProxyConfig(gCtx, lb, targets); err != nil {
-				log.Printf("Worker: failed to update proxy config for LB %s: %v", lb.ID, err)
-			}
+			w.updateLB(gCtx, lb)
 		}
 	}
 }
 
-func (w *LBWorker) processHealthChecks(ctx context.Context) {
-	lbs, err := w.lbRepo.ListAll(ctx)
+// updateLB pushes the configuration of an active load balancer to its proxy
+// when listeners, rules or targets changed since the last push.
+func (w *LBWorker) updateLB(ctx context.Context, lb *domain.LoadBalancer) {
+	targets, err := w.loadProxyConfig(ctx, lb)
 	if err != nil {
 		return
 	}
+	if err := w.resolveCertificates(ctx, lb); err != nil {
+		log.Printf("Worker: failed to resolve certificates for LB %s: %v", lb.ID, err)
+		return
+	}
+	fingerprint := configFingerprint(lb, targets)
+	if w.applied[lb.ID] == fingerprint {
+		return
+	}
 
-	for _, lb := range lbs {
-		if lb.Status == domain.LBStatusActive {
-			gCtx := appcontext.WithUserID(ctx, lb.UserID)
-			w.checkLBHealth(gCtx, lb)
-		}
+	if err := w.proxyAdapter.UpdateProxyConfig(ctx, lb, targets); err != nil {
+		log.Printf("Worker: failed to update proxy config for LB %s: %v", lb.ID, err)
+		return
 	}
+	w.applied[lb.ID] = fingerprint
 }
 
-func (w *LBWorker) checkLBHealth(ctx context.Context, lb *domain.LoadBalancer) {
+// loadProxyConfig attaches the listeners of a load balancer and returns the
+// targets that should receive traffic.
+func (w *LBWorker) loadProxyConfig(ctx context.Context, lb *domain.LoadBalancer) ([]*domain.LBTarget, error) {
+	listeners, err := w.lbRepo.ListListeners(ctx, lb.ID)
+	if err != nil {
+		return nil, err
+	}
+	lb.Listeners = listeners
 	targets, err := w.lbRepo.ListTargets(ctx, lb.ID)
 	if err != nil {
-		return
+		return nil, err
 	}
+	return routableTargets(targets), nil
+}
 
-	changed := false
-	for _, t := range targets {
-		inst, err := w.instanceRepo.GetByID(ctx, t.InstanceID)
-		if err != nil {
+// cachedCertificate is the certificate of a listener and the ID of the secret
+// it was read from. Secrets are replaced rather than updated, so a new ID means
+// the certificate was rotated.
+type cachedCertificate struct {
+	secretID uuid.UUID
+	pem      []byte
+}
+
+// resolveCertificates attaches the certificate of every HTTPS listener. Reading
+// a secret's value is audited, so secrets are read again only when the listing,
+// which is not audited, shows they were replaced.
+func (w *LBWorker) resolveCertificates(ctx context.Context, lb *domain.LoadBalancer) error {
+	var secretIDs map[string]uuid.UUID
+	resolved := make(map[int]cachedCertificate)
+	for _, l := range lb.Listeners {
+		if l.Protocol != domain.LBProtocolHTTPS {
 			continue
 		}
-
-		// Simple TCP check to container name (Nginx uses this in the same network)
-		// But here we are outside the network.
-		// Wait, if we use container names, they are only resolvable inside the docker network.
-		// For host-based health check, we'd need to know the host port.
-		// For simplicity, let's assume we can reach the container port if we are on the same bridge or if we use the container IP.
-		// In a real simulator, we might want to "exec" a ping inside the LB container.
-
-		status := "unhealthy"
-		// This is a naive check. In a real system, we'd use the proxy status or internal probes.
-		// For this simulator, we'll try to connect to the mapped host port if available.
-		// (Assuming ports are mapped as "hostPort:containerPort")
-		hostPort := ""
-		if inst.Ports != "" {
-			// Find the port mapping for t.Port
-			mappings := parsePorts(inst.Ports)
-			for h, c := range mappings {
-				if c == t.Port {
-					hostPort = h
-					break
-				}
+		if secretIDs == nil {
+			secrets, err := w.secretSvc.ListSecrets(ctx)
+			if err != nil {
+				return err
+			}
+			secretIDs = make(map[string]uuid.UUID, len(secrets))
+			for _, s := range secrets {
+				secretIDs[s.Name] = s.ID
 			}
 		}
 
-		if hostPort != "" {
-			conn, err := net.DialTimeout("tcp", "localhost:"+hostPort, 2*time.Second)
-			if err == nil {
-				status = "healthy"
-				conn.Close()
+		cert, ok := w.certificates[lb.ID][l.Port]
+		if !ok || cert.secretID != secretIDs[l.CertificateSecret] {
+			secret, err := w.secretSvc.GetSecretByName(ctx, l.CertificateSecret)
+			if err != nil {
+				return fmt.Errorf("listener %d: %w", l.Port, err)
 			}
+			cert = cachedCertificate{secretID: secret.ID, pem: []byte(secret.EncryptedValue)}
 		}
+		l.Certificate = cert.pem
+		resolved[l.Port] = cert
+	}
+	w.certificates[lb.ID] = resolved
+	return nil
+}
 
-		if t.Health != status {
-			_ = w.lbRepo.UpdateTargetHealth(ctx, lb.ID, t.InstanceID, status)
-			changed = true
+// configFingerprint identifies the configuration pushed to a proxy. Listener
+// certificates are not serialized, so their hashes are added to catch rotations.
+func configFingerprint(lb *domain.LoadBalancer, targets []*domain.LBTarget) string {
+	certificates := make([]string, len(lb.Listeners))
+	for i, l := range lb.Listeners {
+		if len(l.Certificate) > 0 {
+			sum := sha256.Sum256(l.Certificate)
+			certificates[i] = hex.EncodeToString(sum[:])
 		}
 	}
+	data, _ := json.Marshal(struct {
+		Port         int
+		Algorithm    string
+		HashHeader   string
+		Stickiness   domain.LBStickiness
+		Listeners    []*domain.LBListener
+		Certificates []string
+		Targets      []*domain.LBTarget
+	}{lb.Port, lb.Algorithm, lb.HashHeader, lb.Stickiness, lb.Listeners, certificates, targets})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// processDrainingTargets removes deregistered targets whose deregistration
+// delay has passed. They were already dropped from the proxy when they
+// started draining, so in-flight requests had the delay to complete.
+func (w *LBWorker) processDrainingTargets(ctx context.Context) {
+	lbs, err := w.lbRepo.ListAll(ctx)
+	if err != nil {
+		return
+	}
 
-	if changed {
-		// If health changed, we might want to update the proxy config if it supports it,
-		// or just let Nginx handle it (Nginx Open Source doesn't have active health checks easily).
-		// For now, we just update the DB so the CLI can show it.
-		log.Printf("Worker: health changed for LB %s", lb.ID)
-		// w.proxyAdapter.UpdateProxyConfig(ctx, lb, targets) // Optional
+	for _, lb := range lbs {
+		if lb.Status != domain.LBStatusActive {
+			continue
+		}
+		gCtx := appcontext.WithUserID(ctx, lb.UserID)
+		targets, err := w.lbRepo.ListTargets(gCtx, lb.ID)
+		if err != nil {
+			continue
+		}
+		delay := time.Duration(lb.DeregistrationDelaySeconds) * time.Second
+		for _, t := range targets {
+			if t.DrainingSince == nil || time.Since(*t.DrainingSince) < delay {
+				continue
+			}
+			if err := w.lbRepo.RemoveTarget(gCtx, lb.ID, t.InstanceID); err != nil {
+				log.Printf("Worker: failed to remove drained target %s from LB %s: %v", t.InstanceID, lb.ID, err)
+				continue
+			}
+			log.Printf("Worker: target %s drained from LB %s", t.InstanceID, lb.ID)
+		}
 	}
 }
 
-func parsePorts(ports string) map[string]int {
-	res := make(map[string]int)
-	pairs := splitCommas(ports)
-	for _, p := range pairs {
-		parts := splitColons(p)
-		if len(parts) == 2 {
-			var hPort string
-			var cPort int
-			_, _ = fmt.Sscanf(parts[0], "%s", &hPort)
-			_, _ = fmt.Sscanf(parts[1], "%d", &cPort)
-			res[hPort] = cPort
+func (w *LBWorker) processHealthChecks(ctx context.Context) {
+	lbs, err := w.lbRepo.ListAll(ctx)
+	if err != nil {
+		return
+	}
+
+	for _, lb := range lbs {
+		if lb.Status == domain.LBStatusActive {
+			gCtx := appcontext.WithUserID(ctx, lb.UserID)
+			w.checkLBHealth(gCtx, lb)
 		}
 	}
-	return res
 }
 
-func splitCommas(s string) []string {
-	var res []string
-	current := ""
-	for _, r := range s {
-		if r == ',' {
-			res = append(res, current)
-			current = ""
-		} else {
-			current += string(r)
-		}
+func (w *LBWorker) checkLBHealth(ctx context.Context, lb *domain.LoadBalancer) {
+	cfg := lb.HealthCheck
+	if cfg.Protocol == "" {
+		cfg = domain.DefaultHealthCheck()
 	}
-	if current != "" {
-		res = append(res, current)
+	interval := time.Duration(cfg.IntervalSeconds) * time.Second
+	if last, ok := w.lastChecked[lb.ID]; ok && time.Since(last) < interval {
+		return
 	}
-	return res
-}
+	w.lastChecked[lb.ID] = time.Now()
 
-func splitColons(s string) []string {
-	var res []string
-	current := ""
-	for _, r := range s {
-		if r == ':' {
-			res = append(res, current)
-			current = ""
-		} else {
-			current += string(r)
+	targets, err := w.lbRepo.ListTargets(ctx, lb.ID)
+	if err != nil {
+		return
+	}
+
+	// Probes run concurrently; the streaks are only touched once all are in.
+	results := make([]bool, len(targets))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, healthProbeConcurrency)
+	for i, t := range targets {
+		if t.Health == domain.TargetHealthDraining {
+			continue
 		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, t *domain.LBTarget) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if addr, err := w.proxyAdapter.TargetAddress(ctx, lb, t); err == nil {
+				results[i] = w.probe(ctx, addr, cfg)
+			}
+		}(i, t)
 	}
-	if current != "" {
-		res = append(res, current)
+	wg.Wait()
+
+	previous := w.streaks[lb.ID]
+	streaks := make(map[uuid.UUID]*healthStreak, len(targets))
+	w.streaks[lb.ID] = streaks
+
+	for i, t := range targets {
+		if t.Health == domain.TargetHealthDraining {
+			continue
+		}
+		streak := previous[t.ID]
+		if streak == nil {
+			streak = &healthStreak{}
+		}
+		streaks[t.ID] = streak
+
+		status := streak.record(t.Health, results[i], cfg)
+		if status != t.Health {
+			if err := w.lbRepo.UpdateTargetHealth(ctx, lb.ID, t.InstanceID, status); err != nil {
+				log.Printf("Worker: failed to update health of target %s on LB %s: %v", t.InstanceID, lb.ID, err)
+				continue
+			}
+			// The next config push drops or restores the target.
+			log.Printf("Worker: target %s on LB %s is now %s", t.InstanceID, lb.ID, status)
+		}
 	}
-	return res
 }
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/platform"
+)
+
+const (
+	// A crashed leader is replaced within leaseTTL plus leaseRenewInterval;
+	// one that shuts down releases its lease and is replaced within
+	// leaseRenewInterval.
+	leaseTTL            = 15 * time.Second
+	leaseRenewInterval  = 5 * time.Second
+	leaseReleaseTimeout = 5 * time.Second
+)
+
+// BackgroundWorker is a worker that runs until its context is cancelled.
+type BackgroundWorker interface {
+	Run(ctx context.Context, wg *sync.WaitGroup)
+}
+
+// LeaderElector runs a worker on exactly one API replica, the holder of the
+// worker's lease. Every replica tries to take the lease; the holder renews it
+// and runs the worker until it loses the lease or shuts down.
+type LeaderElector struct {
+	repo     ports.LeaseRepository
+	name     string
+	holderID string
+	worker   BackgroundWorker
+	clock    ports.Clock
+
+	mu      sync.RWMutex
+	lease   *domain.LeaderLease
+	leading bool
+	// validUntil is when the lease expires at the latest, by the local clock.
+	validUntil time.Time
+	// expiry cancels the running worker at validUntil, in case the lease
+	// runs out before a renewal notices.
+	expiry *time.Timer
+}
+
+func NewLeaderElector(repo ports.LeaseRepository, name, holderID string, worker BackgroundWorker, clock ports.Clock) *LeaderElector {
+	return &LeaderElector{
+		repo:     repo,
+		name:     name,
+		holderID: holderID,
+		worker:   worker,
+		clock:    clock,
+	}
+}
+
+func (e *LeaderElector) Run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	ticker := time.NewTicker(leaseRenewInterval)
+	defer ticker.Stop()
+
+	var workerWg sync.WaitGroup
+	var workerCtx context.Context
+	var stopWorker context.CancelFunc
+	stop := func() {
+		if stopWorker != nil {
+			stopWorker()
+			workerWg.Wait()
+			stopWorker = nil
+			e.mu.Lock()
+			e.expiry.Stop()
+			e.expiry = nil
+			e.mu.Unlock()
+		}
+	}
+
+	for {
+		leading := e.Elect(ctx)
+		// A worker past its deadline lost the lease before it was renewed,
+		// and starts afresh if this replica leads again.
+		if stopWorker != nil && (!leading || workerCtx.Err() != nil) {
+			log.Printf("Leader election: %s lost %s", e.holderID, e.name)
+			stop()
+		}
+		if leading && stopWorker == nil {
+			log.Printf("Leader election: %s leads %s", e.holderID, e.name)
+			workerCtx, stopWorker = e.startWorker(ctx, &workerWg)
+		}
+
+		select {
+		case <-ctx.Done():
+			stop()
+			e.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// startWorker runs the worker until the returned function cancels it or the
+// lease expires, whichever comes first. Renewals push the deadline back.
+func (e *LeaderElector) startWorker(ctx context.Context, wg *sync.WaitGroup) (context.Context, context.CancelFunc) {
+	workerCtx, cancel := context.WithCancel(ctx)
+	e.mu.Lock()
+	e.expiry = time.AfterFunc(e.validUntil.Sub(e.clock.Now()), cancel)
+	e.mu.Unlock()
+	wg.Add(1)
+	go e.worker.Run(workerCtx, wg)
+	return workerCtx, cancel
+}
+
+// Elect takes or renews the lease and reports whether this replica leads. A
+// leader that cannot reach the database keeps leading while its lease is
+// sure to outlast the next renewal, as no other replica can take it over
+// before it expires.
+func (e *LeaderElector) Elect(ctx context.Context) bool {
+	started := e.clock.Now()
+	acquireCtx, cancel := context.WithTimeout(ctx, leaseRenewInterval)
+	lease, err := e.repo.AcquireLease(acquireCtx, e.name, e.holderID, leaseTTL)
+	cancel()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err != nil {
+		log.Printf("Leader election: failed to renew %s: %v", e.name, err)
+		e.leading = e.leading && e.clock.Now().Add(leaseRenewInterval).Before(e.validUntil)
+	} else {
+		e.lease = lease
+		e.leading = lease.HolderID == e.holderID
+		if e.leading {
+			// The database starts the lease no earlier than the request.
+			e.validUntil = started.Add(leaseTTL)
+			if e.expiry != nil {
+				e.expiry.Reset(e.validUntil.Sub(e.clock.Now()))
+			}
+		}
+	}
+	e.setMetric()
+	return e.leading
+}
+
+// release hands the lease over to the next replica right away.
+func (e *LeaderElector) release() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.leading {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), leaseReleaseTimeout)
+	defer cancel()
+	if err := e.repo.ReleaseLease(ctx, e.name, e.holderID); err != nil {
+		log.Printf("Leader election: failed to release %s: %v", e.name, err)
+	}
+	e.leading = false
+	e.lease = nil
+	e.setMetric()
+}
+
+func (e *LeaderElector) setMetric() {
+	value := 0.0
+	if e.leading {
+		value = 1
+	}
+	platform.LeaderElected.WithLabelValues(e.name, e.holderID).Set(value)
+}
+
+// Name is the name of the lease.
+func (e *LeaderElector) Name() string {
+	return e.name
+}
+
+// IsLeader reports whether this replica runs the worker.
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.leading
+}
+
+// Leader returns the lease as last seen, nil before the first election.
+func (e *LeaderElector) Leader() *domain.LeaderLease {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.lease
+}
@@ -0,0 +1,173 @@
+package services_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/services"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLeaseRepo struct{ mock.Mock }
+
+func (m *MockLeaseRepo) AcquireLease(ctx context.Context, name, holderID string, ttl time.Duration) (*domain.LeaderLease, error) {
+	args := m.Called(ctx, name, holderID, ttl)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LeaderLease), args.Error(1)
+}
+
+func (m *MockLeaseRepo) ReleaseLease(ctx context.Context, name, holderID string) error {
+	return m.Called(ctx, name, holderID).Error(0)
+}
+
+// stubWorker signals when it starts and stops.
+type stubWorker struct {
+	started chan struct{}
+	stopped chan struct{}
+}
+
+func newStubWorker() *stubWorker {
+	return &stubWorker{started: make(chan struct{}), stopped: make(chan struct{})}
+}
+
+func (w *stubWorker) Run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	close(w.started)
+	<-ctx.Done()
+	close(w.stopped)
+}
+
+func TestLeaderElector_Elect(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("Leads once it holds the lease", func(t *testing.T) {
+		repo, clock := new(MockLeaseRepo), new(MockClock)
+		clock.On("Now").Return(now)
+		repo.On("AcquireLease", mock.Anything, "lb-worker", "replica-a", 15*time.Second).Return(&domain.LeaderLease{Name: "lb-worker", HolderID: "replica-a"}, nil)
+		elector := services.NewLeaderElector(repo, "lb-worker", "replica-a", newStubWorker(), clock)
+
+		assert.True(t, elector.Elect(ctx))
+		assert.True(t, elector.IsLeader())
+		assert.Equal(t, "replica-a", elector.Leader().HolderID)
+	})
+
+	t.Run("Stands by while another replica holds the lease", func(t *testing.T) {
+		repo, clock := new(MockLeaseRepo), new(MockClock)
+		clock.On("Now").Return(now)
+		repo.On("AcquireLease", mock.Anything, "lb-worker", "replica-b", mock.Anything).Return(&domain.LeaderLease{Name: "lb-worker", HolderID: "replica-a"}, nil)
+		elector := services.NewLeaderElector(repo, "lb-worker", "replica-b", newStubWorker(), clock)
+
+		assert.False(t, elector.Elect(ctx))
+		assert.Equal(t, "replica-a", elector.Leader().HolderID)
+	})
+
+	t.Run("Keeps leading through a database outage until the lease could expire", func(t *testing.T) {
+		repo, clock := new(MockLeaseRepo), new(MockClock)
+		repo.On("AcquireLease", mock.Anything, "lb-worker", "replica-a", mock.Anything).Return(&domain.LeaderLease{Name: "lb-worker", HolderID: "replica-a"}, nil).Once()
+		repo.On("AcquireLease", mock.Anything, "lb-worker", "replica-a", mock.Anything).Return(nil, errors.New(errors.Internal, "connection refused"))
+		clock.On("Now").Return(now).Once()
+		clock.On("Now").Return(now.Add(5 * time.Second)).Twice()
+		clock.On("Now").Return(now.Add(11 * time.Second)).Twice()
+		elector := services.NewLeaderElector(repo, "lb-worker", "replica-a", newStubWorker(), clock)
+
+		assert.True(t, elector.Elect(ctx))
+		assert.True(t, elector.Elect(ctx))
+		// The lease expires within the next renewal.
+		assert.False(t, elector.Elect(ctx))
+	})
+
+	t.Run("Never leads on errors alone", func(t *testing.T) {
+		repo, clock := new(MockLeaseRepo), new(MockClock)
+		clock.On("Now").Return(now)
+		repo.On("AcquireLease", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New(errors.Internal, "connection refused"))
+		elector := services.NewLeaderElector(repo, "lb-worker", "replica-a", newStubWorker(), clock)
+
+		assert.False(t, elector.Elect(ctx))
+		assert.Nil(t, elector.Leader())
+	})
+}
+
+func TestLeaderElector_Run(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Runs the worker while leading and releases the lease on shutdown", func(t *testing.T) {
+		repo, clock := new(MockLeaseRepo), new(MockClock)
+		clock.On("Now").Return(now)
+		repo.On("AcquireLease", mock.Anything, "autoscaling-worker", "replica-a", mock.Anything).Return(&domain.LeaderLease{HolderID: "replica-a"}, nil)
+		repo.On("ReleaseLease", mock.Anything, "autoscaling-worker", "replica-a").Return(nil).Once()
+		worker := newStubWorker()
+		elector := services.NewLeaderElector(repo, "autoscaling-worker", "replica-a", worker, clock)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		go elector.Run(ctx, wg)
+
+		<-worker.started
+		cancel()
+		wg.Wait()
+
+		<-worker.stopped
+		repo.AssertExpectations(t)
+		assert.False(t, elector.IsLeader())
+	})
+
+	t.Run("Stops the worker when the lease runs out before it is renewed", func(t *testing.T) {
+		repo, clock := new(MockLeaseRepo), new(MockClock)
+		// The lease is taken at now and the worker starts just before it
+		// expires.
+		clock.On("Now").Return(now).Once()
+		clock.On("Now").Return(now.Add(15*time.Second - 50*time.Millisecond))
+		repo.On("AcquireLease", mock.Anything, "autoscaling-worker", "replica-a", mock.Anything).Return(&domain.LeaderLease{HolderID: "replica-a"}, nil)
+		repo.On("ReleaseLease", mock.Anything, "autoscaling-worker", "replica-a").Return(nil).Maybe()
+		worker := newStubWorker()
+		elector := services.NewLeaderElector(repo, "autoscaling-worker", "replica-a", worker, clock)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		go elector.Run(ctx, wg)
+
+		<-worker.started
+		select {
+		case <-worker.stopped:
+		case <-time.After(time.Second):
+			t.Fatal("worker kept running past the lease")
+		}
+		cancel()
+		wg.Wait()
+	})
+
+	t.Run("Does not run the worker on standby", func(t *testing.T) {
+		repo, clock := new(MockLeaseRepo), new(MockClock)
+		clock.On("Now").Return(now)
+		elected := make(chan struct{})
+		repo.On("AcquireLease", mock.Anything, "autoscaling-worker", "replica-b", mock.Anything).Return(&domain.LeaderLease{HolderID: "replica-a"}, nil).Once().Run(func(mock.Arguments) { close(elected) })
+		worker := newStubWorker()
+		elector := services.NewLeaderElector(repo, "autoscaling-worker", "replica-b", worker, clock)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		go elector.Run(ctx, wg)
+
+		<-elected
+		cancel()
+		wg.Wait()
+
+		select {
+		case <-worker.started:
+			t.Fatal("worker started on a standby replica")
+		default:
+		}
+		repo.AssertNotCalled(t, "ReleaseLease", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
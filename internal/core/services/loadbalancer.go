@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,17 +16,19 @@ type LBService struct {
 	lbRepo       ports.LBRepository
 	vpcRepo      ports.VpcRepository
 	instanceRepo ports.InstanceRepository
+	secretSvc    ports.SecretService
 }
 
-func NewLBService(lbRepo ports.LBRepository, vpcRepo ports.VpcRepository, instanceRepo ports.InstanceRepository) *LBService {
+func NewLBService(lbRepo ports.LBRepository, vpcRepo ports.VpcRepository, instanceRepo ports.InstanceRepository, secretSvc ports.SecretService) *LBService {
 	return &LBService{
 		lbRepo:       lbRepo,
 		vpcRepo:      vpcRepo,
 		instanceRepo: instanceRepo,
+		secretSvc:    secretSvc,
 	}
 }
 
-func (s *LBService) Create(ctx context.Context, name string, vpcID uuid.UUID, port int, algo string, idempotencyKey string) (*domain.LoadBalancer, error) {
+func (s *LBService) Create(ctx context.Context, name string, vpcID uuid.UUID, port int, balancing domain.LBBalancing, idempotencyKey string) (*domain.LoadBalancer, error) {
 	// Check if already created via idempotency key
 	if idempotencyKey != "" {
 		existing, err := s.lbRepo.GetByIdempotencyKey(ctx, idempotencyKey)
@@ -34,15 +37,15 @@ func (s *LBService) Create(ctx context.Context, name string, vpcID uuid.UUID, po
 		}
 	}
 
-	// Validate VPC exists
-	_, err := s.vpcRepo.GetByID(ctx, vpcID)
+	balancing, err := normalizeBalancing(balancing)
 	if err != nil {
-		return nil, errors.Wrap(errors.NotFound, "VPC not found", err)
+		return nil, err
 	}
 
-	// Set default algorithm
-	if algo == "" {
-		algo = "round-robin"
+	// Validate VPC exists
+	_, err = s.vpcRepo.GetByID(ctx, vpcID)
+	if err != nil {
+		return nil, errors.Wrap(errors.NotFound, "VPC not found", err)
 	}
 
 	lb := &domain.LoadBalancer{
@@ -52,10 +55,15 @@ func (s *LBService) Create(ctx context.Context, name string, vpcID uuid.UUID, po
 		Name:           name,
 		VpcID:          vpcID,
 		Port:           port,
-		Algorithm:      algo,
+		Algorithm:      balancing.Algorithm,
+		HashHeader:     balancing.HashHeader,
+		Stickiness:     balancing.Stickiness,
 		Status:         domain.LBStatusCreating,
 		Version:        1,
 		CreatedAt:      time.Now(),
+		HealthCheck:    domain.DefaultHealthCheck(),
+
+		DeregistrationDelaySeconds: domain.DefaultDeregistrationDelay,
 	}
 
 	if err := s.lbRepo.Create(ctx, lb); err != nil {
@@ -66,7 +74,15 @@ func (s *LBService) Create(ctx context.Context, name string, vpcID uuid.UUID, po
 }
 
 func (s *LBService) Get(ctx context.Context, id uuid.UUID) (*domain.LoadBalancer, error) {
-	return s.lbRepo.GetByID(ctx, id)
+	lb, err := s.lbRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if lb.Listeners, err = s.lbRepo.ListListeners(ctx, id); err != nil {
+		return nil, err
+	}
+	return lb, nil
 }
 
 func (s *LBService) List(ctx context.Context) ([]*domain.LoadBalancer, error) {
@@ -87,7 +103,7 @@ func (s *LBService) Delete(ctx context.Context, id uuid.UUID) error {
 	return s.lbRepo.Update(ctx, lb)
 }
 
-func (s *LBService) AddTarget(ctx context.Context, lbID, instanceID uuid.UUID, port int, weight int) error {
+func (s *LBService) AddTarget(ctx context.Context, lbID, instanceID uuid.UUID, port int, weight int, targetGroup string) error {
 	// Get LB
 	lb, err := s.lbRepo.GetByID(ctx, lbID)
 	if err != nil {
@@ -109,20 +125,78 @@ func (s *LBService) AddTarget(ctx context.Context, lbID, instanceID uuid.UUID, p
 		weight = 1
 	}
 
+	if targetGroup == "" {
+		targetGroup = domain.DefaultTargetGroup
+	}
+	if !targetGroupName.MatchString(targetGroup) {
+		return errInvalidTargetGroup
+	}
+
 	target := &domain.LBTarget{
-		ID:         uuid.New(),
-		LBID:       lbID,
-		InstanceID: instanceID,
-		Port:       port,
-		Weight:     weight,
-		Health:     "unknown",
+		ID:          uuid.New(),
+		LBID:        lbID,
+		InstanceID:  instanceID,
+		TargetGroup: targetGroup,
+		Port:        port,
+		Weight:      weight,
+		Health:      domain.TargetHealthUnknown,
 	}
 
 	return s.lbRepo.AddTarget(ctx, target)
 }
 
-func (s *LBService) RemoveTarget(ctx context.Context, lbID, instanceID uuid.UUID) error {
-	return s.lbRepo.RemoveTarget(ctx, lbID, instanceID)
+func (s *LBService) RemoveTarget(ctx context.Context, lbID, instanceID uuid.UUID) (time.Time, error) {
+	lb, err := s.lbRepo.GetByID(ctx, lbID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	targets, err := s.lbRepo.ListTargets(ctx, lbID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var target *domain.LBTarget
+	for _, t := range targets {
+		if t.InstanceID == instanceID {
+			target = t
+			break
+		}
+	}
+	if target == nil {
+		return time.Time{}, errors.New(errors.NotFound, "target not found")
+	}
+
+	delay := time.Duration(lb.DeregistrationDelaySeconds) * time.Second
+	if target.DrainingSince != nil {
+		return target.DrainingSince.Add(delay), nil
+	}
+
+	now := time.Now()
+	if delay == 0 {
+		return now, s.lbRepo.RemoveTarget(ctx, lbID, instanceID)
+	}
+	// The worker drops the target from the proxy now and removes it once
+	// the delay has passed.
+	if err := s.lbRepo.DrainTarget(ctx, lbID, instanceID, now); err != nil {
+		return time.Time{}, err
+	}
+	return now.Add(delay), nil
+}
+
+func (s *LBService) SetDeregistrationDelay(ctx context.Context, id uuid.UUID, seconds int) (*domain.LoadBalancer, error) {
+	if seconds < 0 || seconds > domain.MaxDeregistrationDelay {
+		return nil, errors.New(errors.InvalidInput, fmt.Sprintf("deregistration delay must be between 0 and %d seconds", domain.MaxDeregistrationDelay))
+	}
+
+	lb, err := s.lbRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	lb.DeregistrationDelaySeconds = seconds
+	if err := s.lbRepo.Update(ctx, lb); err != nil {
+		return nil, err
+	}
+	return lb, nil
 }
 
 func (s *LBService) ListTargets(ctx context.Context, lbID uuid.UUID) ([]*domain.LBTarget, error) {
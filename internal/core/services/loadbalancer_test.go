@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	appcontext "github.com/poyrazk/thecloud/internal/core/context"
@@ -74,6 +75,29 @@ func (m *mockLBRepo) RemoveTarget(ctx context.Context, lbID, instanceID uuid.UUI
 	return args.Error(0)
 }
 
+func (m *mockLBRepo) DrainTarget(ctx context.Context, lbID, instanceID uuid.UUID, since time.Time) error {
+	args := m.Called(ctx, lbID, instanceID, since)
+	return args.Error(0)
+}
+
+func (m *mockLBRepo) SaveRequestStats(ctx context.Context, stats []*domain.LBRequestStats) error {
+	args := m.Called(ctx, stats)
+	return args.Error(0)
+}
+
+func (m *mockLBRepo) ListRequestStats(ctx context.Context, lbID uuid.UUID, since time.Time) ([]*domain.LBRequestStats, error) {
+	args := m.Called(ctx, lbID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.LBRequestStats), args.Error(1)
+}
+
+func (m *mockLBRepo) DeleteRequestStatsBefore(ctx context.Context, before time.Time) error {
+	args := m.Called(ctx, before)
+	return args.Error(0)
+}
+
 func (m *mockLBRepo) ListTargets(ctx context.Context, lbID uuid.UUID) ([]*domain.LBTarget, error) {
 	args := m.Called(ctx, lbID)
 	if args.Get(0) == nil {
@@ -95,17 +119,86 @@ func (m *mockLBRepo) GetTargetsForInstance(ctx context.Context, instanceID uuid.
 	return args.Get(0).([]*domain.LBTarget), args.Error(1)
 }
 
+func (m *mockLBRepo) CreateListener(ctx context.Context, listener *domain.LBListener) error {
+	args := m.Called(ctx, listener)
+	return args.Error(0)
+}
+
+func (m *mockLBRepo) ListListeners(ctx context.Context, lbID uuid.UUID) ([]*domain.LBListener, error) {
+	args := m.Called(ctx, lbID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.LBListener), args.Error(1)
+}
+
+func (m *mockLBRepo) DeleteListener(ctx context.Context, lbID, listenerID uuid.UUID) error {
+	args := m.Called(ctx, lbID, listenerID)
+	return args.Error(0)
+}
+
+func (m *mockLBRepo) CreateRoutingRule(ctx context.Context, rule *domain.LBRoutingRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *mockLBRepo) DeleteRoutingRule(ctx context.Context, listenerID, ruleID uuid.UUID) error {
+	args := m.Called(ctx, listenerID, ruleID)
+	return args.Error(0)
+}
+
+type mockSecretService struct {
+	mock.Mock
+}
+
+func (m *mockSecretService) CreateSecret(ctx context.Context, name, value, description string) (*domain.Secret, error) {
+	args := m.Called(ctx, name, value, description)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Secret), args.Error(1)
+}
+
+func (m *mockSecretService) GetSecret(ctx context.Context, id uuid.UUID) (*domain.Secret, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Secret), args.Error(1)
+}
+
+func (m *mockSecretService) GetSecretByName(ctx context.Context, name string) (*domain.Secret, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Secret), args.Error(1)
+}
+
+func (m *mockSecretService) ListSecrets(ctx context.Context) ([]*domain.Secret, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Secret), args.Error(1)
+}
+
+func (m *mockSecretService) DeleteSecret(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func TestLBService_Create(t *testing.T) {
 	lbRepo := new(mockLBRepo)
 	vpcRepo := new(mockVpcRepo)
 	instRepo := new(mockInstanceRepo)
-	svc := NewLBService(lbRepo, vpcRepo, instRepo)
+	svc := NewLBService(lbRepo, vpcRepo, instRepo, new(mockSecretService))
 
 	ctx := context.Background()
 	vpcID := uuid.New()
 	name := "test-lb"
 	port := 80
-	algo := "round-robin"
+	algo := domain.LBBalancing{Algorithm: domain.LBAlgorithmRoundRobin}
 
 	t.Run("successful creation", func(t *testing.T) {
 		lbRepo.On("GetByIdempotencyKey", ctx, "key1").Return(nil, errors.New(errors.NotFound, "not found")).Once()
@@ -146,13 +239,22 @@ func TestLBService_Create(t *testing.T) {
 		assert.True(t, errors.Is(err, errors.NotFound))
 		vpcRepo.AssertExpectations(t)
 	})
+
+	t.Run("invalid algorithm", func(t *testing.T) {
+		lbRepo.On("GetByIdempotencyKey", ctx, "key4").Return(nil, errors.New(errors.NotFound, "not found")).Once()
+
+		lb, err := svc.Create(ctx, name, vpcID, port, domain.LBBalancing{Algorithm: "random"}, "key4")
+
+		assert.Nil(t, lb)
+		assert.True(t, errors.Is(err, errors.InvalidInput))
+	})
 }
 
 func TestLBService_PropagatesUserID(t *testing.T) {
 	lbRepo := new(mockLBRepo)
 	vpcRepo := new(mockVpcRepo)
 	instRepo := new(mockInstanceRepo)
-	svc := NewLBService(lbRepo, vpcRepo, instRepo)
+	svc := NewLBService(lbRepo, vpcRepo, instRepo, new(mockSecretService))
 
 	expectedUserID := uuid.New()
 	ctx := appcontext.WithUserID(context.Background(), expectedUserID)
@@ -165,7 +267,7 @@ func TestLBService_PropagatesUserID(t *testing.T) {
 		return lb.UserID == expectedUserID
 	})).Return(nil).Once()
 
-	lb, err := svc.Create(ctx, name, vpcID, 80, "round-robin", "key3")
+	lb, err := svc.Create(ctx, name, vpcID, 80, domain.LBBalancing{}, "key3")
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedUserID, lb.UserID)
@@ -176,7 +278,7 @@ func TestLBService_AddTarget(t *testing.T) {
 	lbRepo := new(mockLBRepo)
 	vpcRepo := new(mockVpcRepo)
 	instRepo := new(mockInstanceRepo)
-	svc := NewLBService(lbRepo, vpcRepo, instRepo)
+	svc := NewLBService(lbRepo, vpcRepo, instRepo, new(mockSecretService))
 
 	ctx := context.Background()
 	lbID := uuid.New()
@@ -188,7 +290,7 @@ func TestLBService_AddTarget(t *testing.T) {
 		instRepo.On("GetByID", ctx, instID).Return(&domain.Instance{ID: instID, VpcID: &vpcID}, nil).Once()
 		lbRepo.On("AddTarget", ctx, mock.Anything).Return(nil).Once()
 
-		err := svc.AddTarget(ctx, lbID, instID, 80, 1)
+		err := svc.AddTarget(ctx, lbID, instID, 80, 1, "")
 
 		assert.NoError(t, err)
 		lbRepo.AssertExpectations(t)
@@ -200,7 +302,7 @@ func TestLBService_AddTarget(t *testing.T) {
 		lbRepo.On("GetByID", ctx, lbID).Return(&domain.LoadBalancer{ID: lbID, VpcID: vpcID}, nil).Once()
 		instRepo.On("GetByID", ctx, instID).Return(&domain.Instance{ID: instID, VpcID: &otherVpcID}, nil).Once()
 
-		err := svc.AddTarget(ctx, lbID, instID, 80, 1)
+		err := svc.AddTarget(ctx, lbID, instID, 80, 1, "")
 
 		assert.Error(t, err)
 		assert.Equal(t, errors.ErrLBCrossVPC, err)
@@ -213,7 +315,7 @@ func TestLBService_Delete_Success(t *testing.T) {
 	lbRepo := new(mockLBRepo)
 	vpcRepo := new(mockVpcRepo)
 	instRepo := new(mockInstanceRepo)
-	svc := NewLBService(lbRepo, vpcRepo, instRepo)
+	svc := NewLBService(lbRepo, vpcRepo, instRepo, new(mockSecretService))
 
 	ctx := context.Background()
 	lbID := uuid.New()
@@ -230,3 +332,80 @@ func TestLBService_Delete_Success(t *testing.T) {
 	assert.NoError(t, err)
 	lbRepo.AssertExpectations(t)
 }
+
+func TestLBService_RemoveTargetDrains(t *testing.T) {
+	ctx := context.Background()
+	lbID := uuid.New()
+	instID := uuid.New()
+
+	setup := func(delay int, target *domain.LBTarget) (*LBService, *mockLBRepo) {
+		lbRepo := new(mockLBRepo)
+		lbRepo.On("GetByID", ctx, lbID).Return(&domain.LoadBalancer{ID: lbID, DeregistrationDelaySeconds: delay}, nil)
+		lbRepo.On("ListTargets", ctx, lbID).Return([]*domain.LBTarget{target}, nil)
+		return NewLBService(lbRepo, new(mockVpcRepo), new(mockInstanceRepo), new(mockSecretService)), lbRepo
+	}
+
+	t.Run("marks the target draining", func(t *testing.T) {
+		svc, lbRepo := setup(30, &domain.LBTarget{InstanceID: instID, Health: domain.TargetHealthHealthy})
+		lbRepo.On("DrainTarget", ctx, lbID, instID, mock.Anything).Return(nil).Once()
+
+		removeAt, err := svc.RemoveTarget(ctx, lbID, instID)
+
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(30*time.Second), removeAt, time.Second)
+		lbRepo.AssertExpectations(t)
+		lbRepo.AssertNotCalled(t, "RemoveTarget", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("removes at once without a delay", func(t *testing.T) {
+		svc, lbRepo := setup(0, &domain.LBTarget{InstanceID: instID, Health: domain.TargetHealthHealthy})
+		lbRepo.On("RemoveTarget", ctx, lbID, instID).Return(nil).Once()
+
+		_, err := svc.RemoveTarget(ctx, lbID, instID)
+
+		assert.NoError(t, err)
+		lbRepo.AssertExpectations(t)
+	})
+
+	t.Run("keeps the original deadline", func(t *testing.T) {
+		since := time.Now().Add(-10 * time.Second)
+		svc, lbRepo := setup(30, &domain.LBTarget{InstanceID: instID, Health: domain.TargetHealthDraining, DrainingSince: &since})
+
+		removeAt, err := svc.RemoveTarget(ctx, lbID, instID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, since.Add(30*time.Second), removeAt)
+		lbRepo.AssertNotCalled(t, "DrainTarget", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown target", func(t *testing.T) {
+		svc, _ := setup(30, &domain.LBTarget{InstanceID: uuid.New()})
+
+		_, err := svc.RemoveTarget(ctx, lbID, instID)
+
+		assert.True(t, errors.Is(err, errors.NotFound))
+	})
+}
+
+func TestLBWorker_ProcessDrainingTargets(t *testing.T) {
+	ctx := context.Background()
+	lbRepo := new(mockLBRepo)
+	w := NewLBWorker(lbRepo, nil, nil, nil)
+
+	lb := &domain.LoadBalancer{ID: uuid.New(), Status: domain.LBStatusActive, DeregistrationDelaySeconds: 30}
+	done, pending := uuid.New(), uuid.New()
+	long, recent := time.Now().Add(-time.Minute), time.Now().Add(-time.Second)
+
+	lbRepo.On("ListAll", ctx).Return([]*domain.LoadBalancer{lb}, nil)
+	lbRepo.On("ListTargets", mock.Anything, lb.ID).Return([]*domain.LBTarget{
+		{InstanceID: done, Health: domain.TargetHealthDraining, DrainingSince: &long},
+		{InstanceID: pending, Health: domain.TargetHealthDraining, DrainingSince: &recent},
+		{InstanceID: uuid.New(), Health: domain.TargetHealthHealthy},
+	}, nil)
+	lbRepo.On("RemoveTarget", mock.Anything, lb.ID, done).Return(nil).Once()
+
+	w.processDrainingTargets(ctx)
+
+	lbRepo.AssertExpectations(t)
+	lbRepo.AssertNumberOfCalls(t, "RemoveTarget", 1)
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"time"
+
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/errors"
+)
+
+// customMetricName matches names like "queue_depth" or "orders.pending".
+var customMetricName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]{0,127}$`)
+
+// customMetricMaxSkew bounds how far a data point's timestamp may lie in the
+// future, to tolerate clock drift between the application and the API.
+const customMetricMaxSkew = 5 * time.Minute
+
+type MetricsService struct {
+	repo         ports.MetricsRepository
+	instanceRepo ports.InstanceRepository
+}
+
+func NewMetricsService(repo ports.MetricsRepository, instanceRepo ports.InstanceRepository) *MetricsService {
+	return &MetricsService{repo: repo, instanceRepo: instanceRepo}
+}
+
+func (s *MetricsService) PutCustomMetrics(ctx context.Context, metrics []*domain.CustomMetric) error {
+	if len(metrics) == 0 {
+		return errors.New(errors.InvalidInput, "no metrics given")
+	}
+	if len(metrics) > domain.MaxCustomMetricsPerRequest {
+		return errors.New(errors.InvalidInput, fmt.Sprintf("at most %d metrics can be put at once", domain.MaxCustomMetricsPerRequest))
+	}
+
+	userID := appcontext.UserIDFromContext(ctx)
+	now := time.Now()
+	checked := map[string]bool{}
+	for _, m := range metrics {
+		if !customMetricName.MatchString(m.Name) {
+			return errors.New(errors.InvalidInput, fmt.Sprintf("invalid metric name %q", m.Name))
+		}
+		if math.IsNaN(m.Value) || math.IsInf(m.Value, 0) {
+			return errors.New(errors.InvalidInput, fmt.Sprintf("invalid value for metric %s", m.Name))
+		}
+		if m.Timestamp.IsZero() {
+			m.Timestamp = now
+		}
+		if m.Timestamp.After(now.Add(customMetricMaxSkew)) || m.Timestamp.Before(now.Add(-domain.CustomMetricRetention)) {
+			return errors.New(errors.InvalidInput, fmt.Sprintf("timestamp of metric %s is out of range", m.Name))
+		}
+		// The repository scopes instances to the caller.
+		if m.InstanceID != nil && !checked[m.InstanceID.String()] {
+			if _, err := s.instanceRepo.GetByID(ctx, *m.InstanceID); err != nil {
+				return err
+			}
+			checked[m.InstanceID.String()] = true
+		}
+		m.UserID = userID
+	}
+
+	return s.repo.SaveCustomMetrics(ctx, metrics)
+}
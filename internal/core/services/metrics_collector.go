@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+)
+
+const (
+	defaultMetricsInterval       = 30 * time.Second
+	defaultMetricsRollupInterval = 5 * time.Minute
+	// metricsSampleTimeout bounds one stats call. Docker waits for a second
+	// sample to compute the CPU delta, so a call takes at least a second.
+	metricsSampleTimeout = 10 * time.Second
+	// metricsSampleConcurrency caps the stats calls in flight at once.
+	metricsSampleConcurrency = 16
+)
+
+// Default retention of the instance metrics by resolution, 0 being the raw
+// samples.
+var defaultMetricsRetention = map[time.Duration]time.Duration{
+	0:                          24 * time.Hour,
+	domain.MetricsResolution5m: 7 * 24 * time.Hour,
+	domain.MetricsResolution1h: 90 * 24 * time.Hour,
+}
+
+// MetricsCollector samples the resource usage of every running instance into
+// the metrics history that dashboards and auto-scaling policies read. It also
+// downsamples older samples into rollups and prunes data past its retention.
+type MetricsCollector struct {
+	instanceRepo   ports.InstanceRepository
+	docker         ports.DockerClient
+	repo           ports.MetricsRepository
+	clock          ports.Clock
+	interval       time.Duration
+	rollupInterval time.Duration
+	retention      map[time.Duration]time.Duration
+	lastRollup     time.Time
+}
+
+func NewMetricsCollector(instanceRepo ports.InstanceRepository, docker ports.DockerClient, repo ports.MetricsRepository, clock ports.Clock) *MetricsCollector {
+	return &MetricsCollector{
+		instanceRepo:   instanceRepo,
+		docker:         docker,
+		repo:           repo,
+		clock:          clock,
+		interval:       defaultMetricsInterval,
+		rollupInterval: defaultMetricsRollupInterval,
+		retention:      defaultMetricsRetention,
+	}
+}
+
+func (c *MetricsCollector) Run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	log.Println("Metrics Collector started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Metrics Collector stopping")
+			return
+		case <-ticker.C:
+			c.Collect(ctx)
+			if now := c.clock.Now(); now.Sub(c.lastRollup) >= c.rollupInterval {
+				c.Rollup(ctx)
+				c.lastRollup = now
+			}
+		}
+	}
+}
+
+// Collect takes one sample of every running instance and stores them.
+// Instances whose stats cannot be read are skipped until the next pass.
+func (c *MetricsCollector) Collect(ctx context.Context) {
+	instances, err := c.instanceRepo.ListAll(ctx)
+	if err != nil {
+		log.Printf("Metrics: failed to list instances: %v", err)
+		return
+	}
+
+	now := c.clock.Now()
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		samples []*domain.InstanceMetric
+	)
+	sem := make(chan struct{}, metricsSampleConcurrency)
+	for _, inst := range instances {
+		if inst.Status != domain.StatusRunning || inst.ContainerID == "" {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(inst *domain.Instance) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			sample, err := c.sample(ctx, inst.ContainerID)
+			if err != nil {
+				log.Printf("Metrics: failed to read stats of instance %s: %v", inst.ID, err)
+				return
+			}
+			sample.InstanceID = inst.ID
+			sample.RecordedAt = now
+			mu.Lock()
+			samples = append(samples, sample)
+			mu.Unlock()
+		}(inst)
+	}
+	wg.Wait()
+
+	if len(samples) == 0 {
+		return
+	}
+	if err := c.repo.SaveInstanceMetrics(ctx, samples); err != nil {
+		log.Printf("Metrics: failed to save %d samples: %v", len(samples), err)
+	}
+}
+
+func (c *MetricsCollector) sample(ctx context.Context, containerID string) (*domain.InstanceMetric, error) {
+	ctx, cancel := context.WithTimeout(ctx, metricsSampleTimeout)
+	defer cancel()
+
+	stream, err := c.docker.GetContainerStats(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	return parseContainerStats(stream)
+}
+
+// Rollup downsamples the recent raw samples into 5 minute buckets and those
+// into hourly buckets, then prunes each resolution and the custom metrics
+// past their retention. The windows reach back far enough to cover a missed
+// pass, and only end at complete buckets, so a bucket is rolled up again
+// until it is complete.
+func (c *MetricsCollector) Rollup(ctx context.Context) {
+	now := c.clock.Now()
+
+	to5m := now.Truncate(domain.MetricsResolution5m)
+	if err := c.repo.RollupInstanceMetrics(ctx, 0, domain.MetricsResolution5m, to5m.Add(-time.Hour), to5m); err != nil {
+		log.Printf("Metrics: failed to roll up raw samples: %v", err)
+	}
+	to1h := now.Truncate(domain.MetricsResolution1h)
+	if err := c.repo.RollupInstanceMetrics(ctx, domain.MetricsResolution5m, domain.MetricsResolution1h, to1h.Add(-6*time.Hour), to1h); err != nil {
+		log.Printf("Metrics: failed to roll up 5m buckets: %v", err)
+	}
+
+	for resolution, keep := range c.retention {
+		if err := c.repo.DeleteInstanceMetricsBefore(ctx, resolution, now.Add(-keep)); err != nil {
+			log.Printf("Metrics: failed to prune metrics of resolution %s: %v", resolution, err)
+		}
+	}
+	if err := c.repo.DeleteCustomMetricsBefore(ctx, now.Add(-domain.CustomMetricRetention)); err != nil {
+		log.Printf("Metrics: failed to prune custom metrics: %v", err)
+	}
+}
+
+// containerStats is the part of the docker stats response the collector
+// reads.
+type containerStats struct {
+	CPUStats    cpuStats `json:"cpu_stats"`
+	PreCPUStats cpuStats `json:"precpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+	Networks map[string]struct {
+		RxBytes uint64 `json:"rx_bytes"`
+		TxBytes uint64 `json:"tx_bytes"`
+	} `json:"networks"`
+}
+
+type cpuStats struct {
+	CPUUsage struct {
+		TotalUsage  uint64   `json:"total_usage"`
+		PercpuUsage []uint64 `json:"percpu_usage"`
+	} `json:"cpu_usage"`
+	SystemCPUUsage uint64 `json:"system_cpu_usage"`
+	OnlineCPUs     uint32 `json:"online_cpus"`
+}
+
+// parseContainerStats computes a sample the way `docker stats` does: CPU in
+// percent of one core, and memory without the reclaimable page cache.
+func parseContainerStats(r io.Reader) (*domain.InstanceMetric, error) {
+	var stats containerStats
+	if err := json.NewDecoder(r).Decode(&stats); err != nil {
+		return nil, err
+	}
+
+	m := &domain.InstanceMetric{}
+
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemCPUUsage) - float64(stats.PreCPUStats.SystemCPUUsage)
+	if cpuDelta > 0 && systemDelta > 0 {
+		cpus := float64(stats.CPUStats.OnlineCPUs)
+		if cpus == 0 {
+			cpus = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+		}
+		if cpus == 0 {
+			cpus = 1
+		}
+		m.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+
+	// cgroup v2 reports the cache as inactive_file, v1 as total_inactive_file.
+	usage := stats.MemoryStats.Usage
+	cache, ok := stats.MemoryStats.Stats["inactive_file"]
+	if !ok {
+		cache = stats.MemoryStats.Stats["total_inactive_file"]
+	}
+	if cache < usage {
+		usage -= cache
+	}
+	m.MemoryBytes = int64(usage)
+	m.MemoryLimitBytes = int64(stats.MemoryStats.Limit)
+
+	for _, n := range stats.Networks {
+		m.NetworkRxBytes += int64(n.RxBytes)
+		m.NetworkTxBytes += int64(n.TxBytes)
+	}
+	return m, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockMetricsRepo struct {
+	mock.Mock
+}
+
+func (m *mockMetricsRepo) SaveInstanceMetrics(ctx context.Context, samples []*domain.InstanceMetric) error {
+	return m.Called(ctx, samples).Error(0)
+}
+
+func (m *mockMetricsRepo) RollupInstanceMetrics(ctx context.Context, source, resolution time.Duration, from, to time.Time) error {
+	return m.Called(ctx, source, resolution, from, to).Error(0)
+}
+
+func (m *mockMetricsRepo) DeleteInstanceMetricsBefore(ctx context.Context, resolution time.Duration, before time.Time) error {
+	return m.Called(ctx, resolution, before).Error(0)
+}
+
+func (m *mockMetricsRepo) SaveCustomMetrics(ctx context.Context, metrics []*domain.CustomMetric) error {
+	return m.Called(ctx, metrics).Error(0)
+}
+
+func (m *mockMetricsRepo) DeleteCustomMetricsBefore(ctx context.Context, before time.Time) error {
+	return m.Called(ctx, before).Error(0)
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+const cgroupV2Stats = `{
+	"cpu_stats": {"cpu_usage": {"total_usage": 3000000000}, "system_cpu_usage": 20000000000, "online_cpus": 4},
+	"precpu_stats": {"cpu_usage": {"total_usage": 1000000000}, "system_cpu_usage": 16000000000},
+	"memory_stats": {"usage": 300, "limit": 1000, "stats": {"inactive_file": 100}},
+	"networks": {"eth0": {"rx_bytes": 10, "tx_bytes": 20}, "eth1": {"rx_bytes": 1, "tx_bytes": 2}}
+}`
+
+func TestParseContainerStats(t *testing.T) {
+	m, err := parseContainerStats(strings.NewReader(cgroupV2Stats))
+	require.NoError(t, err)
+	// 2s of CPU over 4s of system time on 4 cores is two cores busy.
+	assert.InDelta(t, 200.0, m.CPUPercent, 0.001)
+	assert.Equal(t, int64(200), m.MemoryBytes)
+	assert.Equal(t, int64(1000), m.MemoryLimitBytes)
+	assert.Equal(t, int64(11), m.NetworkRxBytes)
+	assert.Equal(t, int64(22), m.NetworkTxBytes)
+
+	// cgroup v1 without online_cpus: cores from percpu_usage, total_inactive_file cache.
+	m, err = parseContainerStats(strings.NewReader(`{
+		"cpu_stats": {"cpu_usage": {"total_usage": 200, "percpu_usage": [100, 100]}, "system_cpu_usage": 1000},
+		"precpu_stats": {"cpu_usage": {"total_usage": 100}, "system_cpu_usage": 800},
+		"memory_stats": {"usage": 500, "stats": {"total_inactive_file": 50}}
+	}`))
+	require.NoError(t, err)
+	assert.InDelta(t, 100.0, m.CPUPercent, 0.001)
+	assert.Equal(t, int64(450), m.MemoryBytes)
+
+	_, err = parseContainerStats(strings.NewReader("not json"))
+	assert.Error(t, err)
+}
+
+func TestMetricsCollector_Collect(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	instanceRepo := new(mockInstanceRepo)
+	docker := new(MockDocker)
+	repo := new(mockMetricsRepo)
+	collector := NewMetricsCollector(instanceRepo, docker, repo, fixedClock(now))
+
+	running := &domain.Instance{ID: uuid.New(), Status: domain.StatusRunning, ContainerID: "c1"}
+	failing := &domain.Instance{ID: uuid.New(), Status: domain.StatusRunning, ContainerID: "c2"}
+	stopped := &domain.Instance{ID: uuid.New(), Status: domain.StatusStopped, ContainerID: "c3"}
+	starting := &domain.Instance{ID: uuid.New(), Status: domain.StatusRunning}
+	instanceRepo.On("ListAll", ctx).Return([]*domain.Instance{running, failing, stopped, starting}, nil)
+	docker.On("GetContainerStats", mock.Anything, "c1").Return(io.NopCloser(strings.NewReader(cgroupV2Stats)), nil)
+	docker.On("GetContainerStats", mock.Anything, "c2").Return(nil, fmt.Errorf("no such container"))
+	repo.On("SaveInstanceMetrics", ctx, mock.MatchedBy(func(samples []*domain.InstanceMetric) bool {
+		return len(samples) == 1 && samples[0].InstanceID == running.ID && samples[0].RecordedAt.Equal(now) && samples[0].MemoryBytes == 200
+	})).Return(nil).Once()
+
+	collector.Collect(ctx)
+
+	repo.AssertExpectations(t)
+	docker.AssertNotCalled(t, "GetContainerStats", mock.Anything, "c3")
+}
+
+func TestMetricsCollector_Rollup(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 2, 12, 7, 30, 0, time.UTC)
+	repo := new(mockMetricsRepo)
+	collector := NewMetricsCollector(new(mockInstanceRepo), new(MockDocker), repo, fixedClock(now))
+
+	to5m := time.Date(2026, 1, 2, 12, 5, 0, 0, time.UTC)
+	to1h := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	repo.On("RollupInstanceMetrics", ctx, time.Duration(0), domain.MetricsResolution5m, to5m.Add(-time.Hour), to5m).Return(nil).Once()
+	repo.On("RollupInstanceMetrics", ctx, domain.MetricsResolution5m, domain.MetricsResolution1h, to1h.Add(-6*time.Hour), to1h).Return(nil).Once()
+	repo.On("DeleteInstanceMetricsBefore", ctx, time.Duration(0), now.Add(-24*time.Hour)).Return(nil).Once()
+	repo.On("DeleteInstanceMetricsBefore", ctx, domain.MetricsResolution5m, now.Add(-7*24*time.Hour)).Return(nil).Once()
+	repo.On("DeleteInstanceMetricsBefore", ctx, domain.MetricsResolution1h, now.Add(-90*24*time.Hour)).Return(nil).Once()
+	repo.On("DeleteCustomMetricsBefore", ctx, now.Add(-24*time.Hour)).Return(nil).Once()
+
+	collector.Rollup(ctx)
+
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsService_PutCustomMetrics(t *testing.T) {
+	userID := uuid.New()
+	ctx := appcontext.WithUserID(context.Background(), userID)
+	instanceID := uuid.New()
+
+	t.Run("stores data points of the caller", func(t *testing.T) {
+		repo := new(mockMetricsRepo)
+		instanceRepo := new(mockInstanceRepo)
+		svc := NewMetricsService(repo, instanceRepo)
+		instanceRepo.On("GetByID", ctx, instanceID).Return(&domain.Instance{ID: instanceID}, nil).Once()
+		repo.On("SaveCustomMetrics", ctx, mock.MatchedBy(func(metrics []*domain.CustomMetric) bool {
+			return len(metrics) == 3 && metrics[0].UserID == userID && !metrics[0].Timestamp.IsZero()
+		})).Return(nil).Once()
+
+		err := svc.PutCustomMetrics(ctx, []*domain.CustomMetric{
+			{Name: "queue_depth", Value: 12},
+			{Name: "orders.pending", Value: 3, InstanceID: &instanceID},
+			{Name: "orders.pending", Value: 4, InstanceID: &instanceID, Timestamp: time.Now().Add(-time.Minute)},
+		})
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+		instanceRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects another user's instance", func(t *testing.T) {
+		repo := new(mockMetricsRepo)
+		instanceRepo := new(mockInstanceRepo)
+		svc := NewMetricsService(repo, instanceRepo)
+		instanceRepo.On("GetByID", ctx, instanceID).Return(nil, errors.New(errors.NotFound, "instance not found"))
+
+		err := svc.PutCustomMetrics(ctx, []*domain.CustomMetric{{Name: "queue_depth", Value: 1, InstanceID: &instanceID}})
+
+		assert.True(t, errors.Is(err, errors.NotFound))
+		repo.AssertNotCalled(t, "SaveCustomMetrics", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects invalid data points", func(t *testing.T) {
+		svc := NewMetricsService(new(mockMetricsRepo), new(mockInstanceRepo))
+		invalid := [][]*domain.CustomMetric{
+			nil,
+			{{Name: "queue depth", Value: 1}},
+			{{Name: "1queue", Value: 1}},
+			{{Name: "queue", Value: 1, Timestamp: time.Now().Add(time.Hour)}},
+			{{Name: "queue", Value: 1, Timestamp: time.Now().Add(-48 * time.Hour)}},
+			make([]*domain.CustomMetric, domain.MaxCustomMetricsPerRequest+1),
+		}
+		for _, metrics := range invalid {
+			err := svc.PutCustomMetrics(ctx, metrics)
+			assert.True(t, errors.Is(err, errors.InvalidInput))
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/errors"
+)
+
+// NotificationService manages bucket notifications and queues the matching
+// object events for delivery by the NotificationWorker.
+type NotificationService struct {
+	repo   ports.NotificationRepository
+	fnSvc  ports.FunctionService
+	logger *slog.Logger
+}
+
+func NewNotificationService(repo ports.NotificationRepository, fnSvc ports.FunctionService, logger *slog.Logger) *NotificationService {
+	return &NotificationService{
+		repo:   repo,
+		fnSvc:  fnSvc,
+		logger: logger,
+	}
+}
+
+func (s *NotificationService) CreateNotification(ctx context.Context, bucket string, n domain.BucketNotification) (*domain.BucketNotification, error) {
+	userID := appcontext.UserIDFromContext(ctx)
+	if err := validateBucketName(bucket); err != nil {
+		return nil, err
+	}
+	for _, e := range n.Events {
+		if e != domain.EventObjectCreated && e != domain.EventObjectDeleted {
+			return nil, errors.New(errors.InvalidInput, "unsupported event type "+e)
+		}
+	}
+
+	switch {
+	case n.FunctionID != nil && n.WebhookURL != "":
+		return nil, errors.New(errors.InvalidInput, "a notification targets either a function or a webhook")
+	case n.FunctionID != nil:
+		f, err := s.fnSvc.GetFunction(ctx, *n.FunctionID)
+		if err != nil || f.UserID != userID {
+			return nil, errors.New(errors.NotFound, "function not found")
+		}
+	case n.WebhookURL != "":
+		u, err := url.Parse(n.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, errors.New(errors.InvalidInput, "webhook_url must be an absolute http or https URL")
+		}
+	default:
+		return nil, errors.New(errors.InvalidInput, "function_id or webhook_url is required")
+	}
+
+	secret := ""
+	if n.WebhookURL != "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to generate webhook secret", err)
+		}
+		secret = hex.EncodeToString(b)
+	}
+
+	notification := &domain.BucketNotification{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Bucket:     bucket,
+		Events:     n.Events,
+		Prefix:     n.Prefix,
+		Suffix:     n.Suffix,
+		FunctionID: n.FunctionID,
+		WebhookURL: n.WebhookURL,
+		Secret:     secret,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.repo.Create(ctx, notification); err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
+
+func (s *NotificationService) ListNotifications(ctx context.Context, bucket string) ([]*domain.BucketNotification, error) {
+	return s.repo.ListByBucket(ctx, bucket)
+}
+
+func (s *NotificationService) DeleteNotification(ctx context.Context, bucket string, id uuid.UUID) error {
+	n, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n.Bucket != bucket {
+		return errors.New(errors.NotFound, "notification not found")
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// Publish queues event for every notification of its bucket that matches it.
+func (s *NotificationService) Publish(ctx context.Context, event *domain.BucketEvent) {
+	notifications, err := s.repo.ListByBucket(ctx, event.Bucket)
+	if err != nil {
+		s.logger.Error("failed to list bucket notifications", "bucket", event.Bucket, "error", err)
+		return
+	}
+
+	for _, n := range notifications {
+		if !notificationMatches(n, event) {
+			continue
+		}
+		d := &domain.NotificationDelivery{
+			ID:             uuid.New(),
+			NotificationID: n.ID,
+			UserID:         n.UserID,
+			Event:          *event,
+			Status:         domain.DeliveryPending,
+			NextAttemptAt:  event.Time,
+			CreatedAt:      time.Now(),
+		}
+		if err := s.repo.CreateDelivery(ctx, d); err != nil {
+			s.logger.Error("failed to queue bucket event", "notification", n.ID, "key", event.Key, "error", err)
+		}
+	}
+}
+
+func notificationMatches(n *domain.BucketNotification, event *domain.BucketEvent) bool {
+	if len(n.Events) > 0 && !slices.Contains(n.Events, event.Type) {
+		return false
+	}
+	return strings.HasPrefix(event.Key, n.Prefix) && strings.HasSuffix(event.Key, n.Suffix)
+}
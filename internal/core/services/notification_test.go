@@ -0,0 +1,324 @@
+package services_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/services"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newNotificationService() (*services.NotificationService, *MockNotificationRepo, *MockFunctionService) {
+	repo := new(MockNotificationRepo)
+	fnSvc := new(MockFunctionService)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return services.NewNotificationService(repo, fnSvc, logger), repo, fnSvc
+}
+
+func TestCreateNotification_Webhook(t *testing.T) {
+	svc, repo, _ := newNotificationService()
+	ctx := appcontext.WithUserID(context.Background(), uuid.New())
+
+	repo.On("Create", ctx, mock.AnythingOfType("*domain.BucketNotification")).Return(nil)
+
+	n, err := svc.CreateNotification(ctx, "photos", domain.BucketNotification{
+		Events:     []string{domain.EventObjectCreated},
+		Suffix:     ".jpg",
+		WebhookURL: "https://example.com/hook",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "photos", n.Bucket)
+	assert.Equal(t, ".jpg", n.Suffix)
+	assert.Len(t, n.Secret, 64)
+	repo.AssertExpectations(t)
+}
+
+func TestCreateNotification_Validation(t *testing.T) {
+	fnID := uuid.New()
+	tests := []struct {
+		name string
+		n    domain.BucketNotification
+	}{
+		{"no target", domain.BucketNotification{}},
+		{"both targets", domain.BucketNotification{FunctionID: &fnID, WebhookURL: "https://example.com"}},
+		{"relative url", domain.BucketNotification{WebhookURL: "/hook"}},
+		{"bad scheme", domain.BucketNotification{WebhookURL: "ftp://example.com/hook"}},
+		{"unknown event", domain.BucketNotification{Events: []string{"ObjectRestored"}, WebhookURL: "https://example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _ := newNotificationService()
+			_, err := svc.CreateNotification(context.Background(), "b", tt.n)
+			assert.True(t, errors.Is(err, errors.InvalidInput))
+			repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCreateNotification_OtherUsersFunction(t *testing.T) {
+	svc, repo, fnSvc := newNotificationService()
+	ctx := appcontext.WithUserID(context.Background(), uuid.New())
+	fnID := uuid.New()
+
+	fnSvc.On("GetFunction", ctx, fnID).Return(&domain.Function{ID: fnID, UserID: uuid.New()}, nil)
+
+	_, err := svc.CreateNotification(ctx, "b", domain.BucketNotification{FunctionID: &fnID})
+
+	assert.True(t, errors.Is(err, errors.NotFound))
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestPublish_QueuesMatchingNotifications(t *testing.T) {
+	svc, repo, _ := newNotificationService()
+	ctx := context.Background()
+
+	images := &domain.BucketNotification{ID: uuid.New(), Bucket: "b", Prefix: "images/", WebhookURL: "https://example.com"}
+	deletes := &domain.BucketNotification{ID: uuid.New(), Bucket: "b", Events: []string{domain.EventObjectDeleted}, WebhookURL: "https://example.com"}
+	logs := &domain.BucketNotification{ID: uuid.New(), Bucket: "b", Suffix: ".log", WebhookURL: "https://example.com"}
+	repo.On("ListByBucket", ctx, "b").Return([]*domain.BucketNotification{images, deletes, logs}, nil)
+
+	var queued []uuid.UUID
+	repo.On("CreateDelivery", ctx, mock.AnythingOfType("*domain.NotificationDelivery")).
+		Run(func(args mock.Arguments) {
+			d := args.Get(1).(*domain.NotificationDelivery)
+			assert.Equal(t, domain.DeliveryPending, d.Status)
+			queued = append(queued, d.NotificationID)
+		}).Return(nil)
+
+	svc.Publish(ctx, &domain.BucketEvent{ID: uuid.New(), Type: domain.EventObjectCreated, Bucket: "b", Key: "images/cat.jpg", Time: time.Now()})
+
+	assert.Equal(t, []uuid.UUID{images.ID}, queued)
+}
+
+func TestStorageService_PublishesObjectEvents(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	events := new(MockBucketEventPublisher)
+	svc := services.NewStorageService(repo, store, events)
+	ctx := context.Background()
+
+	store.On("Write", ctx, ".objects", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			_, _ = io.ReadAll(args.Get(3).(io.Reader))
+		}).Return(int64(2), nil)
+	repo.On("GetBucketEncryption", ctx, "b").Return(nil, errNoBucketEncryption)
+	repo.On("SaveMeta", ctx, mock.AnythingOfType("*domain.Object")).Return(nil)
+	repo.On("SoftDelete", ctx, "b", "k").Return(nil)
+
+	var types []string
+	events.On("Publish", ctx, mock.AnythingOfType("*domain.BucketEvent")).
+		Run(func(args mock.Arguments) {
+			e := args.Get(1).(*domain.BucketEvent)
+			assert.Equal(t, "k", e.Key)
+			types = append(types, e.Type)
+		})
+
+	_, err := svc.Upload(ctx, "b", "k", strings.NewReader("hi"), domain.ObjectOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, svc.DeleteObject(ctx, "b", "k"))
+
+	assert.Equal(t, []string{domain.EventObjectCreated, domain.EventObjectDeleted}, types)
+}
+
+func newDelivery(n *domain.BucketNotification, attempts int) *domain.NotificationDelivery {
+	return &domain.NotificationDelivery{
+		ID:             uuid.New(),
+		NotificationID: n.ID,
+		UserID:         n.UserID,
+		Event:          domain.BucketEvent{ID: uuid.New(), Type: domain.EventObjectCreated, Bucket: n.Bucket, Key: "a.txt"},
+		Status:         domain.DeliveryPending,
+		Attempts:       attempts,
+	}
+}
+
+func TestNotificationWorker_WebhookDelivered(t *testing.T) {
+	var gotType, gotSignature string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotType = r.Header.Get("X-Cloud-Event-Type")
+		gotSignature = r.Header.Get("X-Cloud-Signature")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	repo := new(MockNotificationRepo)
+	clock := new(MockClock)
+	worker := services.NewNotificationWorker(repo, new(MockFunctionService), clock)
+	worker.AllowLoopbackWebhooks()
+	ctx := context.Background()
+	now := time.Now()
+
+	n := &domain.BucketNotification{ID: uuid.New(), UserID: uuid.New(), Bucket: "b", WebhookURL: server.URL, Secret: "s3cret"}
+	d := newDelivery(n, 0)
+	clock.On("Now").Return(now)
+	repo.On("ClaimDueDeliveries", ctx, now, now.Add(5*time.Minute), mock.Anything).Return([]*domain.NotificationDelivery{d}, nil)
+	repo.On("GetByID", mock.Anything, n.ID).Return(n, nil)
+	repo.On("DeleteDelivery", mock.Anything, d.ID).Return(nil)
+
+	worker.DeliverDue(ctx)
+
+	assert.Equal(t, domain.EventObjectCreated, gotType)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(gotBody)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), gotSignature)
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "UpdateDelivery", mock.Anything, mock.Anything)
+}
+
+func TestNotificationWorker_WebhookRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	repo := new(MockNotificationRepo)
+	clock := new(MockClock)
+	worker := services.NewNotificationWorker(repo, new(MockFunctionService), clock)
+	worker.AllowLoopbackWebhooks()
+	ctx := context.Background()
+	now := time.Now()
+
+	n := &domain.BucketNotification{ID: uuid.New(), UserID: uuid.New(), Bucket: "b", WebhookURL: server.URL}
+	retry := newDelivery(n, 1)
+	last := newDelivery(n, 4)
+	clock.On("Now").Return(now)
+	repo.On("ClaimDueDeliveries", ctx, now, now.Add(5*time.Minute), mock.Anything).Return([]*domain.NotificationDelivery{retry, last}, nil)
+	repo.On("GetByID", mock.Anything, n.ID).Return(n, nil)
+	repo.On("UpdateDelivery", mock.Anything, mock.AnythingOfType("*domain.NotificationDelivery")).Return(nil)
+
+	worker.DeliverDue(ctx)
+
+	assert.Equal(t, 2, retry.Attempts)
+	assert.Equal(t, domain.DeliveryPending, retry.Status)
+	assert.Equal(t, now.Add(time.Minute), retry.NextAttemptAt)
+	assert.Contains(t, retry.LastError, "500")
+
+	assert.Equal(t, 5, last.Attempts)
+	assert.Equal(t, domain.DeliveryFailed, last.Status)
+	repo.AssertNotCalled(t, "DeleteDelivery", mock.Anything, mock.Anything)
+}
+
+func TestNotificationWorker_RefusesInternalWebhooks(t *testing.T) {
+	hit := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	for _, target := range []string{server.URL, "http://169.254.169.254/latest/meta-data/", "http://10.0.0.1/"} {
+		t.Run(target, func(t *testing.T) {
+			repo := new(MockNotificationRepo)
+			clock := new(MockClock)
+			worker := services.NewNotificationWorker(repo, new(MockFunctionService), clock)
+			ctx := context.Background()
+			now := time.Now()
+
+			n := &domain.BucketNotification{ID: uuid.New(), UserID: uuid.New(), Bucket: "b", WebhookURL: target}
+			d := newDelivery(n, 0)
+			clock.On("Now").Return(now)
+			repo.On("ClaimDueDeliveries", ctx, now, now.Add(5*time.Minute), mock.Anything).Return([]*domain.NotificationDelivery{d}, nil)
+			repo.On("GetByID", mock.Anything, n.ID).Return(n, nil)
+			repo.On("UpdateDelivery", mock.Anything, d).Return(nil)
+
+			worker.DeliverDue(ctx)
+
+			assert.Equal(t, 1, d.Attempts)
+			assert.Contains(t, d.LastError, "not allowed")
+		})
+	}
+	assert.False(t, hit)
+}
+
+func TestNotificationWorker_DoesNotFollowRedirects(t *testing.T) {
+	followed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			followed = true
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		http.Redirect(w, r, "/internal", http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	repo := new(MockNotificationRepo)
+	clock := new(MockClock)
+	worker := services.NewNotificationWorker(repo, new(MockFunctionService), clock)
+	worker.AllowLoopbackWebhooks()
+	ctx := context.Background()
+	now := time.Now()
+
+	n := &domain.BucketNotification{ID: uuid.New(), UserID: uuid.New(), Bucket: "b", WebhookURL: server.URL}
+	d := newDelivery(n, 0)
+	clock.On("Now").Return(now)
+	repo.On("ClaimDueDeliveries", ctx, now, now.Add(5*time.Minute), mock.Anything).Return([]*domain.NotificationDelivery{d}, nil)
+	repo.On("GetByID", mock.Anything, n.ID).Return(n, nil)
+	repo.On("UpdateDelivery", mock.Anything, d).Return(nil)
+
+	worker.DeliverDue(ctx)
+
+	assert.False(t, followed)
+	assert.Contains(t, d.LastError, "307")
+}
+
+func TestNotificationWorker_InvokesFunction(t *testing.T) {
+	repo := new(MockNotificationRepo)
+	fnSvc := new(MockFunctionService)
+	clock := new(MockClock)
+	worker := services.NewNotificationWorker(repo, fnSvc, clock)
+	ctx := context.Background()
+	now := time.Now()
+
+	fnID := uuid.New()
+	n := &domain.BucketNotification{ID: uuid.New(), UserID: uuid.New(), Bucket: "b", FunctionID: &fnID}
+	d := newDelivery(n, 0)
+	clock.On("Now").Return(now)
+	repo.On("ClaimDueDeliveries", ctx, now, now.Add(5*time.Minute), mock.Anything).Return([]*domain.NotificationDelivery{d}, nil)
+	repo.On("GetByID", mock.Anything, n.ID).Return(n, nil)
+	fnSvc.On("InvokeFunction", mock.MatchedBy(func(c context.Context) bool {
+		return appcontext.UserIDFromContext(c) == n.UserID
+	}), fnID, mock.Anything, false).Return(&domain.Invocation{Status: "SUCCESS"}, nil)
+	repo.On("DeleteDelivery", mock.Anything, d.ID).Return(nil)
+
+	worker.DeliverDue(ctx)
+
+	fnSvc.AssertExpectations(t)
+	repo.AssertExpectations(t)
+}
+
+func TestNotificationWorker_DropsRemovedNotification(t *testing.T) {
+	repo := new(MockNotificationRepo)
+	clock := new(MockClock)
+	worker := services.NewNotificationWorker(repo, new(MockFunctionService), clock)
+	ctx := context.Background()
+	now := time.Now()
+
+	n := &domain.BucketNotification{ID: uuid.New(), UserID: uuid.New(), Bucket: "b"}
+	d := newDelivery(n, 0)
+	clock.On("Now").Return(now)
+	repo.On("ClaimDueDeliveries", ctx, now, now.Add(5*time.Minute), mock.Anything).Return([]*domain.NotificationDelivery{d}, nil)
+	repo.On("GetByID", mock.Anything, n.ID).Return(nil, errors.New(errors.NotFound, "notification not found"))
+	repo.On("DeleteDelivery", mock.Anything, d.ID).Return(nil)
+
+	worker.DeliverDue(ctx)
+
+	repo.AssertExpectations(t)
+}
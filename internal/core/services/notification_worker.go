@@ -0,0 +1,235 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/poyrazk/thecloud/internal/platform"
+)
+
+const (
+	defaultNotificationInterval = 5 * time.Second
+	notificationBatchSize       = 100
+	notificationConcurrency     = 8
+	// maxDeliveryAttempts is how often an event is tried before it is marked FAILED.
+	// Retries back off exponentially from deliveryBackoff.
+	maxDeliveryAttempts = 5
+	deliveryBackoff     = 30 * time.Second
+	webhookTimeout      = 10 * time.Second
+	// deliveryLease hides claimed deliveries from other workers while a batch is
+	// attempted. It outlasts a full batch of timed-out webhooks.
+	deliveryLease = 5 * time.Minute
+)
+
+// nonPublicPrefixes are special-purpose ranges that netip does not classify as
+// private but that never reach a public webhook receiver.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+// NotificationWorker delivers queued bucket events to functions and webhooks.
+// Delivery is at least once: targets can deduplicate on the event ID.
+type NotificationWorker struct {
+	repo         ports.NotificationRepository
+	fnSvc        ports.FunctionService
+	client       *http.Client
+	clock        ports.Clock
+	tickInterval time.Duration
+}
+
+func NewNotificationWorker(repo ports.NotificationRepository, fnSvc ports.FunctionService, clock ports.Clock) *NotificationWorker {
+	return &NotificationWorker{
+		repo:         repo,
+		fnSvc:        fnSvc,
+		client:       newWebhookClient(publicAddress),
+		clock:        clock,
+		tickInterval: defaultNotificationInterval,
+	}
+}
+
+func (w *NotificationWorker) Run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	ticker := time.NewTicker(w.tickInterval)
+	defer ticker.Stop()
+
+	log.Println("Notification Worker started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Notification Worker stopping")
+			return
+		case <-ticker.C:
+			w.DeliverDue(ctx)
+		}
+	}
+}
+
+// DeliverDue attempts every delivery that is due, a few at a time.
+func (w *NotificationWorker) DeliverDue(ctx context.Context) {
+	now := w.clock.Now()
+	deliveries, err := w.repo.ClaimDueDeliveries(ctx, now, now.Add(deliveryLease), notificationBatchSize)
+	if err != nil {
+		log.Printf("Notifications: failed to claim due deliveries: %v", err)
+		return
+	}
+
+	sem := make(chan struct{}, notificationConcurrency)
+	var wg sync.WaitGroup
+	for _, d := range deliveries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(d *domain.NotificationDelivery) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			w.deliver(ctx, d)
+		}(d)
+	}
+	wg.Wait()
+}
+
+func (w *NotificationWorker) deliver(ctx context.Context, d *domain.NotificationDelivery) {
+	uCtx := appcontext.WithUserID(ctx, d.UserID)
+
+	n, err := w.repo.GetByID(uCtx, d.NotificationID)
+	if err != nil {
+		if errors.Is(err, errors.NotFound) {
+			// The notification was removed; its queued events go with it
+			_ = w.repo.DeleteDelivery(uCtx, d.ID)
+		}
+		return
+	}
+
+	payload, err := json.Marshal(d.Event)
+	if err != nil {
+		return
+	}
+
+	target := "webhook"
+	if n.FunctionID != nil {
+		target = "function"
+		err = w.invokeFunction(uCtx, *n.FunctionID, payload)
+	} else {
+		err = w.postWebhook(ctx, n, &d.Event, payload)
+	}
+
+	if err == nil {
+		platform.StorageNotificationsDelivered.WithLabelValues(target, "success").Inc()
+		if err := w.repo.DeleteDelivery(uCtx, d.ID); err != nil {
+			log.Printf("Notifications: failed to remove delivered event %s: %v", d.ID, err)
+		}
+		return
+	}
+
+	d.Attempts++
+	d.LastError = err.Error()
+	if d.Attempts >= maxDeliveryAttempts {
+		d.Status = domain.DeliveryFailed
+		platform.StorageNotificationsDelivered.WithLabelValues(target, "failed").Inc()
+		log.Printf("Notifications: giving up on %s event for %s/%s after %d attempts: %v", d.Event.Type, d.Event.Bucket, d.Event.Key, d.Attempts, err)
+	} else {
+		d.NextAttemptAt = w.clock.Now().Add(deliveryBackoff << (d.Attempts - 1))
+		platform.StorageNotificationsDelivered.WithLabelValues(target, "retry").Inc()
+	}
+	if err := w.repo.UpdateDelivery(uCtx, d); err != nil {
+		log.Printf("Notifications: failed to update delivery %s: %v", d.ID, err)
+	}
+}
+
+func (w *NotificationWorker) invokeFunction(ctx context.Context, id uuid.UUID, payload []byte) error {
+	invocation, err := w.fnSvc.InvokeFunction(ctx, id, payload, false)
+	if err != nil {
+		return err
+	}
+	if invocation.Status != "SUCCESS" {
+		return fmt.Errorf("function invocation %s exited with status %d", invocation.ID, invocation.StatusCode)
+	}
+	return nil
+}
+
+func (w *NotificationWorker) postWebhook(ctx context.Context, n *domain.BucketNotification, event *domain.BucketEvent, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, []byte(n.Secret))
+	mac.Write(payload)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Cloud-Event-Type", event.Type)
+	req.Header.Set("X-Cloud-Event-Id", event.ID.String())
+	req.Header.Set("X-Cloud-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// newWebhookClient returns the client for user supplied webhook URLs. It only
+// connects to addresses allowed by allow, checked on the resolved address at
+// dial time so a DNS record cannot be switched to an internal address after
+// validation, uses no proxy and does not follow redirects.
+func newWebhookClient(allow func(netip.Addr) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !allow(addr.Addr().Unmap()) {
+				return fmt.Errorf("webhook address %s is not allowed", addr.Addr())
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: webhookTimeout},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// publicAddress reports whether addr is publicly routable. Loopback, link-local
+// (including the 169.254.169.254 metadata endpoint), private and container
+// network ranges are refused.
+func publicAddress(addr netip.Addr) bool {
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
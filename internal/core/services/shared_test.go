@@ -53,6 +53,18 @@ func (m *MockAutoScalingRepo) UpdateGroup(ctx context.Context, group *domain.Sca
 	args := m.Called(ctx, group)
 	return args.Error(0)
 }
+func (m *MockAutoScalingRepo) UpdateGroupLaunchTemplate(ctx context.Context, group *domain.ScalingGroup) error {
+	args := m.Called(ctx, group)
+	return args.Error(0)
+}
+func (m *MockAutoScalingRepo) UpdateGroupSettings(ctx context.Context, group *domain.ScalingGroup) error {
+	args := m.Called(ctx, group)
+	return args.Error(0)
+}
+func (m *MockAutoScalingRepo) UpdateGroupSuspendedProcesses(ctx context.Context, group *domain.ScalingGroup) error {
+	args := m.Called(ctx, group)
+	return args.Error(0)
+}
 func (m *MockAutoScalingRepo) DeleteGroup(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -75,14 +87,114 @@ func (m *MockAutoScalingRepo) GetAllPolicies(ctx context.Context, groupIDs []uui
 	}
 	return args.Get(0).(map[uuid.UUID][]*domain.ScalingPolicy), args.Error(1)
 }
-func (m *MockAutoScalingRepo) UpdatePolicyLastScaled(ctx context.Context, policyID uuid.UUID, t time.Time) error {
-	args := m.Called(ctx, policyID, t)
+func (m *MockAutoScalingRepo) UpdatePolicyLastScaled(ctx context.Context, policyID uuid.UUID, t time.Time, scaleOut bool) error {
+	args := m.Called(ctx, policyID, t, scaleOut)
 	return args.Error(0)
 }
 func (m *MockAutoScalingRepo) DeletePolicy(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
+func (m *MockAutoScalingRepo) CreateScheduledAction(ctx context.Context, action *domain.ScheduledAction) error {
+	args := m.Called(ctx, action)
+	return args.Error(0)
+}
+func (m *MockAutoScalingRepo) ListScheduledActions(ctx context.Context, groupID uuid.UUID) ([]*domain.ScheduledAction, error) {
+	args := m.Called(ctx, groupID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ScheduledAction), args.Error(1)
+}
+func (m *MockAutoScalingRepo) DeleteScheduledAction(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockAutoScalingRepo) GetDueScheduledActions(ctx context.Context, groupIDs []uuid.UUID, now time.Time) (map[uuid.UUID][]*domain.ScheduledAction, error) {
+	args := m.Called(ctx, groupIDs, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID][]*domain.ScheduledAction), args.Error(1)
+}
+func (m *MockAutoScalingRepo) UpdateScheduledActionRun(ctx context.Context, id uuid.UUID, lastRunAt, nextRunAt time.Time) error {
+	args := m.Called(ctx, id, lastRunAt, nextRunAt)
+	return args.Error(0)
+}
+func (m *MockAutoScalingRepo) CreateInstanceRefresh(ctx context.Context, refresh *domain.InstanceRefresh) error {
+	args := m.Called(ctx, refresh)
+	return args.Error(0)
+}
+func (m *MockAutoScalingRepo) GetLatestInstanceRefresh(ctx context.Context, groupID uuid.UUID) (*domain.InstanceRefresh, error) {
+	args := m.Called(ctx, groupID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.InstanceRefresh), args.Error(1)
+}
+func (m *MockAutoScalingRepo) GetRunningInstanceRefreshes(ctx context.Context, groupIDs []uuid.UUID) (map[uuid.UUID]*domain.InstanceRefresh, error) {
+	args := m.Called(ctx, groupIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID]*domain.InstanceRefresh), args.Error(1)
+}
+func (m *MockAutoScalingRepo) UpdateInstanceRefresh(ctx context.Context, refresh *domain.InstanceRefresh, from domain.InstanceRefreshStatus) error {
+	args := m.Called(ctx, refresh, from)
+	return args.Error(0)
+}
+func (m *MockAutoScalingRepo) CreateLifecycleHook(ctx context.Context, hook *domain.LifecycleHook) error {
+	args := m.Called(ctx, hook)
+	return args.Error(0)
+}
+func (m *MockAutoScalingRepo) ListLifecycleHooks(ctx context.Context, groupID uuid.UUID) ([]*domain.LifecycleHook, error) {
+	args := m.Called(ctx, groupID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.LifecycleHook), args.Error(1)
+}
+func (m *MockAutoScalingRepo) DeleteLifecycleHook(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockAutoScalingRepo) GetAllLifecycleHooks(ctx context.Context, groupIDs []uuid.UUID) (map[uuid.UUID][]*domain.LifecycleHook, error) {
+	args := m.Called(ctx, groupIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID][]*domain.LifecycleHook), args.Error(1)
+}
+func (m *MockAutoScalingRepo) SetInstanceLifecycleState(ctx context.Context, groupID, instanceID uuid.UUID, state domain.LifecycleState, timeoutAt *time.Time) error {
+	args := m.Called(ctx, groupID, instanceID, state, timeoutAt)
+	return args.Error(0)
+}
+func (m *MockAutoScalingRepo) CompleteLifecycleAction(ctx context.Context, groupID, instanceID uuid.UUID, result domain.LifecycleActionResult) error {
+	args := m.Called(ctx, groupID, instanceID, result)
+	return args.Error(0)
+}
+func (m *MockAutoScalingRepo) GetAllWaitingInstances(ctx context.Context, groupIDs []uuid.UUID) (map[uuid.UUID][]domain.ScalingGroupInstance, error) {
+	args := m.Called(ctx, groupIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID][]domain.ScalingGroupInstance), args.Error(1)
+}
+func (m *MockAutoScalingRepo) CreateActivity(ctx context.Context, activity *domain.ScalingActivity) error {
+	args := m.Called(ctx, activity)
+	return args.Error(0)
+}
+func (m *MockAutoScalingRepo) ListActivities(ctx context.Context, groupID uuid.UUID, limit int) ([]*domain.ScalingActivity, error) {
+	args := m.Called(ctx, groupID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ScalingActivity), args.Error(1)
+}
+func (m *MockAutoScalingRepo) DeleteActivitiesBefore(ctx context.Context, before time.Time) error {
+	args := m.Called(ctx, before)
+	return args.Error(0)
+}
 func (m *MockAutoScalingRepo) AddInstanceToGroup(ctx context.Context, groupID, instanceID uuid.UUID) error {
 	args := m.Called(ctx, groupID, instanceID)
 	return args.Error(0)
@@ -105,10 +217,37 @@ func (m *MockAutoScalingRepo) GetAllScalingGroupInstances(ctx context.Context, g
 	}
 	return args.Get(0).(map[uuid.UUID][]uuid.UUID), args.Error(1)
 }
+func (m *MockAutoScalingRepo) MarkInstanceDraining(ctx context.Context, groupID, instanceID uuid.UUID, until time.Time) error {
+	args := m.Called(ctx, groupID, instanceID, until)
+	return args.Error(0)
+}
+func (m *MockAutoScalingRepo) GetAllDrainingInstances(ctx context.Context, groupIDs []uuid.UUID) (map[uuid.UUID][]domain.ScalingGroupInstance, error) {
+	args := m.Called(ctx, groupIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID][]domain.ScalingGroupInstance), args.Error(1)
+}
 func (m *MockAutoScalingRepo) GetAverageCPU(ctx context.Context, instanceIDs []uuid.UUID, since time.Time) (float64, error) {
 	args := m.Called(ctx, instanceIDs, since)
 	return args.Get(0).(float64), args.Error(1)
 }
+func (m *MockAutoScalingRepo) GetAverageMemory(ctx context.Context, instanceIDs []uuid.UUID, since time.Time) (float64, error) {
+	args := m.Called(ctx, instanceIDs, since)
+	return args.Get(0).(float64), args.Error(1)
+}
+func (m *MockAutoScalingRepo) GetAverageNetworkThroughput(ctx context.Context, instanceIDs []uuid.UUID, since time.Time) (float64, float64, error) {
+	args := m.Called(ctx, instanceIDs, since)
+	return args.Get(0).(float64), args.Get(1).(float64), args.Error(2)
+}
+func (m *MockAutoScalingRepo) GetRequestCount(ctx context.Context, lbID uuid.UUID, instanceIDs []uuid.UUID, from, to time.Time) (int64, error) {
+	args := m.Called(ctx, lbID, instanceIDs, from, to)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockAutoScalingRepo) GetAverageCustomMetric(ctx context.Context, userID uuid.UUID, name string, instanceIDs []uuid.UUID, since time.Time) (float64, error) {
+	args := m.Called(ctx, userID, name, instanceIDs, since)
+	return args.Get(0).(float64), args.Error(1)
+}
 
 // MockInstanceService
 type MockInstanceService struct{ mock.Mock }
@@ -120,6 +259,13 @@ func (m *MockInstanceService) LaunchInstance(ctx context.Context, name, image, p
 	}
 	return args.Get(0).(*domain.Instance), args.Error(1)
 }
+func (m *MockInstanceService) LaunchInstanceFromTemplate(ctx context.Context, name string, template *domain.LaunchTemplateVersion) (*domain.Instance, error) {
+	args := m.Called(ctx, name, template)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Instance), args.Error(1)
+}
 func (m *MockInstanceService) StopInstance(ctx context.Context, idOrName string) error {
 	args := m.Called(ctx, idOrName)
 	return args.Error(0)
@@ -142,6 +288,14 @@ func (m *MockInstanceService) GetInstanceLogs(ctx context.Context, idOrName stri
 	args := m.Called(ctx, idOrName)
 	return args.String(0), args.Error(1)
 }
+func (m *MockInstanceService) RefreshInstanceStatus(ctx context.Context, idOrName string) (*domain.Instance, error) {
+	args := m.Called(ctx, idOrName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Instance), args.Error(1)
+}
+
 func (m *MockInstanceService) GetInstanceStats(ctx context.Context, idOrName string) (*domain.InstanceStats, error) {
 	args := m.Called(ctx, idOrName)
 	if args.Get(0) == nil {
@@ -157,8 +311,8 @@ func (m *MockInstanceService) TerminateInstance(ctx context.Context, idOrName st
 // MockLBService
 type MockLBService struct{ mock.Mock }
 
-func (m *MockLBService) Create(ctx context.Context, name string, vpcID uuid.UUID, port int, algo string, idempotencyKey string) (*domain.LoadBalancer, error) {
-	args := m.Called(ctx, name, vpcID, port, algo, idempotencyKey)
+func (m *MockLBService) Create(ctx context.Context, name string, vpcID uuid.UUID, port int, balancing domain.LBBalancing, idempotencyKey string) (*domain.LoadBalancer, error) {
+	args := m.Called(ctx, name, vpcID, port, balancing, idempotencyKey)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -178,17 +332,45 @@ func (m *MockLBService) List(ctx context.Context) ([]*domain.LoadBalancer, error
 	}
 	return args.Get(0).([]*domain.LoadBalancer), args.Error(1)
 }
+func (m *MockLBService) UpdateLB(ctx context.Context, id uuid.UUID, update domain.LBUpdate) (*domain.LoadBalancer, error) {
+	args := m.Called(ctx, id, update)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LoadBalancer), args.Error(1)
+}
 func (m *MockLBService) Delete(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
-func (m *MockLBService) AddTarget(ctx context.Context, lbID, instanceID uuid.UUID, port, weight int) error {
-	args := m.Called(ctx, lbID, instanceID, port, weight)
+func (m *MockLBService) AddTarget(ctx context.Context, lbID, instanceID uuid.UUID, port, weight int, targetGroup string) error {
+	args := m.Called(ctx, lbID, instanceID, port, weight, targetGroup)
 	return args.Error(0)
 }
-func (m *MockLBService) RemoveTarget(ctx context.Context, lbID, instanceID uuid.UUID) error {
+func (m *MockLBService) RemoveTarget(ctx context.Context, lbID, instanceID uuid.UUID) (time.Time, error) {
 	args := m.Called(ctx, lbID, instanceID)
-	return args.Error(0)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+func (m *MockLBService) SetDeregistrationDelay(ctx context.Context, id uuid.UUID, seconds int) (*domain.LoadBalancer, error) {
+	args := m.Called(ctx, id, seconds)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LoadBalancer), args.Error(1)
+}
+func (m *MockLBService) SetAccessLogs(ctx context.Context, id uuid.UUID, cfg domain.AccessLogConfig) (*domain.LoadBalancer, error) {
+	args := m.Called(ctx, id, cfg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LoadBalancer), args.Error(1)
+}
+func (m *MockLBService) GetMetrics(ctx context.Context, id uuid.UUID, window time.Duration) (*domain.LBMetrics, error) {
+	args := m.Called(ctx, id, window)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LBMetrics), args.Error(1)
 }
 func (m *MockLBService) ListTargets(ctx context.Context, lbID uuid.UUID) ([]*domain.LBTarget, error) {
 	args := m.Called(ctx, lbID)
@@ -197,6 +379,42 @@ func (m *MockLBService) ListTargets(ctx context.Context, lbID uuid.UUID) ([]*dom
 	}
 	return args.Get(0).([]*domain.LBTarget), args.Error(1)
 }
+func (m *MockLBService) UpdateHealthCheck(ctx context.Context, id uuid.UUID, cfg domain.HealthCheckConfig) (*domain.LoadBalancer, error) {
+	args := m.Called(ctx, id, cfg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LoadBalancer), args.Error(1)
+}
+func (m *MockLBService) AddListener(ctx context.Context, lbID uuid.UUID, listener domain.LBListener) (*domain.LBListener, error) {
+	args := m.Called(ctx, lbID, listener)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LBListener), args.Error(1)
+}
+func (m *MockLBService) ListListeners(ctx context.Context, lbID uuid.UUID) ([]*domain.LBListener, error) {
+	args := m.Called(ctx, lbID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.LBListener), args.Error(1)
+}
+func (m *MockLBService) RemoveListener(ctx context.Context, lbID, listenerID uuid.UUID) error {
+	args := m.Called(ctx, lbID, listenerID)
+	return args.Error(0)
+}
+func (m *MockLBService) AddRoutingRule(ctx context.Context, lbID, listenerID uuid.UUID, rule domain.LBRoutingRule) (*domain.LBRoutingRule, error) {
+	args := m.Called(ctx, lbID, listenerID, rule)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LBRoutingRule), args.Error(1)
+}
+func (m *MockLBService) RemoveRoutingRule(ctx context.Context, lbID, listenerID, ruleID uuid.UUID) error {
+	args := m.Called(ctx, lbID, listenerID, ruleID)
+	return args.Error(0)
+}
 
 // MockEventService
 type MockEventService struct{ mock.Mock }
@@ -221,6 +439,54 @@ func (m *MockClock) Now() time.Time {
 	return args.Get(0).(time.Time)
 }
 
+// MockLaunchTemplateRepo
+type MockLaunchTemplateRepo struct{ mock.Mock }
+
+func (m *MockLaunchTemplateRepo) Create(ctx context.Context, template *domain.LaunchTemplate, version *domain.LaunchTemplateVersion) error {
+	args := m.Called(ctx, template, version)
+	return args.Error(0)
+}
+func (m *MockLaunchTemplateRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.LaunchTemplate, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LaunchTemplate), args.Error(1)
+}
+func (m *MockLaunchTemplateRepo) List(ctx context.Context) ([]*domain.LaunchTemplate, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.LaunchTemplate), args.Error(1)
+}
+func (m *MockLaunchTemplateRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockLaunchTemplateRepo) CountGroupsUsing(ctx context.Context, id uuid.UUID) (int, error) {
+	args := m.Called(ctx, id)
+	return args.Int(0), args.Error(1)
+}
+func (m *MockLaunchTemplateRepo) CreateVersion(ctx context.Context, version *domain.LaunchTemplateVersion) error {
+	args := m.Called(ctx, version)
+	return args.Error(0)
+}
+func (m *MockLaunchTemplateRepo) GetVersion(ctx context.Context, templateID uuid.UUID, version int) (*domain.LaunchTemplateVersion, error) {
+	args := m.Called(ctx, templateID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LaunchTemplateVersion), args.Error(1)
+}
+func (m *MockLaunchTemplateRepo) ListVersions(ctx context.Context, templateID uuid.UUID) ([]*domain.LaunchTemplateVersion, error) {
+	args := m.Called(ctx, templateID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.LaunchTemplateVersion), args.Error(1)
+}
+
 // MockVpcRepo
 type MockVpcRepo struct{ mock.Mock }
 
@@ -282,6 +548,127 @@ func (m *MockStorageRepo) SoftDelete(ctx context.Context, bucket, key string) er
 	args := m.Called(ctx, bucket, key)
 	return args.Error(0)
 }
+func (m *MockStorageRepo) ListBuckets(ctx context.Context) ([]*domain.Bucket, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Bucket), args.Error(1)
+}
+func (m *MockStorageRepo) CreateMultipartUpload(ctx context.Context, upload *domain.MultipartUpload) error {
+	args := m.Called(ctx, upload)
+	return args.Error(0)
+}
+func (m *MockStorageRepo) GetMultipartUpload(ctx context.Context, id uuid.UUID) (*domain.MultipartUpload, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.MultipartUpload), args.Error(1)
+}
+func (m *MockStorageRepo) DeleteMultipartUpload(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockStorageRepo) SavePart(ctx context.Context, part *domain.Part) error {
+	args := m.Called(ctx, part)
+	return args.Error(0)
+}
+func (m *MockStorageRepo) ListParts(ctx context.Context, uploadID uuid.UUID) ([]*domain.Part, error) {
+	args := m.Called(ctx, uploadID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Part), args.Error(1)
+}
+func (m *MockStorageRepo) ListStaleMultipartUploads(ctx context.Context, olderThan time.Time) ([]*domain.MultipartUpload, error) {
+	args := m.Called(ctx, olderThan)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.MultipartUpload), args.Error(1)
+}
+func (m *MockStorageRepo) CreateLifecycleRule(ctx context.Context, rule *domain.LifecycleRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+func (m *MockStorageRepo) ListLifecycleRules(ctx context.Context, bucket string) ([]*domain.LifecycleRule, error) {
+	args := m.Called(ctx, bucket)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.LifecycleRule), args.Error(1)
+}
+func (m *MockStorageRepo) DeleteLifecycleRule(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockStorageRepo) ListAllLifecycleRules(ctx context.Context) ([]*domain.LifecycleRule, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.LifecycleRule), args.Error(1)
+}
+func (m *MockStorageRepo) ListDeletedObjects(ctx context.Context, deletedBefore time.Time) ([]*domain.Object, error) {
+	args := m.Called(ctx, deletedBefore)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Object), args.Error(1)
+}
+func (m *MockStorageRepo) HardDelete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockStorageRepo) ListNoncurrentVersions(ctx context.Context, replacedBefore time.Time) ([]*domain.ObjectVersion, error) {
+	args := m.Called(ctx, replacedBefore)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ObjectVersion), args.Error(1)
+}
+func (m *MockStorageRepo) DeleteNoncurrentVersion(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockStorageRepo) PutBucketEncryption(ctx context.Context, cfg *domain.BucketEncryption) error {
+	args := m.Called(ctx, cfg)
+	return args.Error(0)
+}
+func (m *MockStorageRepo) GetBucketEncryption(ctx context.Context, bucket string) (*domain.BucketEncryption, error) {
+	args := m.Called(ctx, bucket)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BucketEncryption), args.Error(1)
+}
+func (m *MockStorageRepo) DeleteBucketEncryption(ctx context.Context, bucket string) error {
+	args := m.Called(ctx, bucket)
+	return args.Error(0)
+}
+func (m *MockStorageRepo) PutBucketWebsite(ctx context.Context, cfg *domain.BucketWebsite) error {
+	args := m.Called(ctx, cfg)
+	return args.Error(0)
+}
+func (m *MockStorageRepo) GetBucketWebsite(ctx context.Context, bucket string) (*domain.BucketWebsite, error) {
+	args := m.Called(ctx, bucket)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BucketWebsite), args.Error(1)
+}
+func (m *MockStorageRepo) DeleteBucketWebsite(ctx context.Context, bucket string) error {
+	args := m.Called(ctx, bucket)
+	return args.Error(0)
+}
+func (m *MockStorageRepo) FindBucketWebsite(ctx context.Context, bucket string) (*domain.BucketWebsite, error) {
+	args := m.Called(ctx, bucket)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BucketWebsite), args.Error(1)
+}
 
 // MockFileStore
 type MockFileStore struct {
@@ -355,3 +742,92 @@ func (m *MockVolumeRepo) Delete(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
+
+// MockNotificationRepo
+type MockNotificationRepo struct{ mock.Mock }
+
+func (m *MockNotificationRepo) Create(ctx context.Context, n *domain.BucketNotification) error {
+	return m.Called(ctx, n).Error(0)
+}
+func (m *MockNotificationRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.BucketNotification, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BucketNotification), args.Error(1)
+}
+func (m *MockNotificationRepo) ListByBucket(ctx context.Context, bucket string) ([]*domain.BucketNotification, error) {
+	args := m.Called(ctx, bucket)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.BucketNotification), args.Error(1)
+}
+func (m *MockNotificationRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+func (m *MockNotificationRepo) CreateDelivery(ctx context.Context, d *domain.NotificationDelivery) error {
+	return m.Called(ctx, d).Error(0)
+}
+func (m *MockNotificationRepo) ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*domain.NotificationDelivery, error) {
+	args := m.Called(ctx, now, leaseUntil, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.NotificationDelivery), args.Error(1)
+}
+func (m *MockNotificationRepo) UpdateDelivery(ctx context.Context, d *domain.NotificationDelivery) error {
+	return m.Called(ctx, d).Error(0)
+}
+func (m *MockNotificationRepo) DeleteDelivery(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+// MockFunctionService
+type MockFunctionService struct{ mock.Mock }
+
+func (m *MockFunctionService) CreateFunction(ctx context.Context, name, runtime, handler string, code []byte) (*domain.Function, error) {
+	args := m.Called(ctx, name, runtime, handler, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Function), args.Error(1)
+}
+func (m *MockFunctionService) GetFunction(ctx context.Context, id uuid.UUID) (*domain.Function, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Function), args.Error(1)
+}
+func (m *MockFunctionService) ListFunctions(ctx context.Context) ([]*domain.Function, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Function), args.Error(1)
+}
+func (m *MockFunctionService) DeleteFunction(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+func (m *MockFunctionService) InvokeFunction(ctx context.Context, id uuid.UUID, payload []byte, async bool) (*domain.Invocation, error) {
+	args := m.Called(ctx, id, payload, async)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Invocation), args.Error(1)
+}
+func (m *MockFunctionService) GetFunctionLogs(ctx context.Context, id uuid.UUID, limit int) ([]*domain.Invocation, error) {
+	args := m.Called(ctx, id, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Invocation), args.Error(1)
+}
+
+// MockBucketEventPublisher
+type MockBucketEventPublisher struct{ mock.Mock }
+
+func (m *MockBucketEventPublisher) Publish(ctx context.Context, event *domain.BucketEvent) {
+	m.Called(ctx, event)
+}
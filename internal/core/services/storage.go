@@ -1,63 +1,242 @@
 package services
 
 import (
+	"bufio"
 	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	appcontext "github.com/poyrazk/thecloud/internal/core/context"
 	"github.com/poyrazk/thecloud/internal/core/domain"
 	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/poyrazk/thecloud/pkg/crypto"
+)
+
+const (
+	// multipartBucket is the internal FileStore bucket where parts are staged
+	// until the upload is completed or aborted.
+	multipartBucket = ".multipart"
+	// objectsBucket is the internal FileStore bucket holding object bytes, one
+	// location per version, so purging a version never touches a newer upload
+	// of the same key.
+	objectsBucket = ".objects"
+	maxPartNumber = 10000
+
+	defaultContentType = "application/octet-stream"
+	// sniffLen is the number of bytes http.DetectContentType considers.
+	sniffLen = 512
+	// maxMetadataSize caps the combined size of user metadata keys and values.
+	maxMetadataSize = 2 * 1024
 )
 
 type StorageService struct {
-	repo  ports.StorageRepository
-	store ports.FileStore
+	repo          ports.StorageRepository
+	store         ports.FileStore
+	events        ports.BucketEventPublisher
+	signingKey    []byte
+	encryptionKey []byte
 }
 
-func NewStorageService(repo ports.StorageRepository, store ports.FileStore) *StorageService {
+// NewStorageService creates the storage service. events may be nil when bucket
+// notifications are not wired up.
+func NewStorageService(repo ports.StorageRepository, store ports.FileStore, events ports.BucketEventPublisher) *StorageService {
 	return &StorageService{
-		repo:  repo,
-		store: store,
+		repo:          repo,
+		store:         store,
+		events:        events,
+		signingKey:    presignSigningKey(),
+		encryptionKey: storageEncryptionKey(),
 	}
 }
 
-func (s *StorageService) Upload(ctx context.Context, bucket, key string, r io.Reader) (*domain.Object, error) {
-	// 1. Write file to store
-	size, err := s.store.Write(ctx, bucket, key, r)
+func (s *StorageService) Upload(ctx context.Context, bucket, key string, r io.Reader, opts domain.ObjectOptions) (*domain.Object, error) {
+	if err := validateBucketName(bucket); err != nil {
+		return nil, err
+	}
+	metadata, err := normalizeMetadata(opts.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	encryption, err := s.resolveEncryption(ctx, bucket, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Write file to store, hashing and sniffing the plaintext on the way through
+	contentType, r := detectContentType(key, opts.ContentType, r)
+	digest := newContentDigest()
+	body, wrappedKey, err := s.sealObject(ctx, encryption, opts.CustomerKey, io.TeeReader(r, digest))
 	if err != nil {
 		return nil, err
 	}
+	storageKey := newStorageKey(bucket)
+	if _, err := s.store.Write(ctx, objectsBucket, storageKey, body); err != nil {
+		return nil, err
+	}
 
 	// 2. Prepare metadata
+	obj := newObject(ctx, bucket, key, contentType, metadata, digest)
+	obj.Encryption, obj.EncryptedKey = encryption, wrappedKey
+	obj.StorageKey = storageKey
+
+	// 3. Save metadata
+	if err := s.repo.SaveMeta(ctx, obj); err != nil {
+		// Cleanup file if DB save fails
+		_ = s.store.Delete(ctx, objectsBucket, storageKey)
+		return nil, err
+	}
+
+	s.publish(ctx, domain.EventObjectCreated, obj)
+	return obj, nil
+}
+
+// validateBucketName rejects empty and dot-prefixed bucket names. Names starting
+// with '.' are reserved for internal data such as staged multipart parts, which
+// live in the same FileStore namespace as user buckets.
+func validateBucketName(bucket string) error {
+	if bucket == "" {
+		return errors.New(errors.InvalidInput, "bucket is required")
+	}
+	if strings.HasPrefix(bucket, ".") {
+		return errors.New(errors.InvalidInput, "bucket names starting with '.' are reserved")
+	}
+	return nil
+}
+
+// publish reports a change of obj to the bucket's notifications.
+func (s *StorageService) publish(ctx context.Context, eventType string, obj *domain.Object) {
+	if s.events == nil {
+		return
+	}
+	s.events.Publish(ctx, &domain.BucketEvent{
+		ID:          uuid.New(),
+		Type:        eventType,
+		UserID:      appcontext.UserIDFromContext(ctx),
+		Bucket:      obj.Bucket,
+		Key:         obj.Key,
+		SizeBytes:   obj.SizeBytes,
+		ETag:        obj.ETag,
+		ContentType: obj.ContentType,
+		Time:        time.Now(),
+	})
+}
+
+func newObject(ctx context.Context, bucket, key, contentType string, metadata map[string]string, digest *contentDigest) *domain.Object {
 	obj := &domain.Object{
-		ID:        uuid.New(),
-		UserID:    appcontext.UserIDFromContext(ctx),
-		Bucket:    bucket,
-		Key:       key,
-		SizeBytes: size,
-		// In a real system we'd detect Content-Type
-		ContentType: "application/octet-stream",
+		ID:          uuid.New(),
+		UserID:      appcontext.UserIDFromContext(ctx),
+		Bucket:      bucket,
+		Key:         key,
+		SizeBytes:   digest.size,
+		ContentType: contentType,
+		ETag:        hex.EncodeToString(digest.md5.Sum(nil)),
+		SHA256:      hex.EncodeToString(digest.sha256.Sum(nil)),
+		Metadata:    metadata,
 		CreatedAt:   time.Now(),
 	}
 
 	// Generate ARN
 	// arn:thecloud:storage:local:default:object/<bucket>/<key>
 	obj.ARN = fmt.Sprintf("arn:thecloud:storage:local:default:object/%s/%s", bucket, key)
+	return obj
+}
 
-	// 3. Save metadata
-	if err := s.repo.SaveMeta(ctx, obj); err != nil {
-		// Cleanup file if DB save fails
-		_ = s.store.Delete(ctx, bucket, key)
-		return nil, err
+// newStorageKey returns a fresh location in objectsBucket for the bytes of a
+// new version of an object in bucket.
+func newStorageKey(bucket string) string {
+	return bucket + "/" + uuid.New().String()
+}
+
+// objectLocation returns the FileStore bucket and key holding the bytes of an
+// object version. Versions without a storage key predate per-version locations.
+func objectLocation(bucket, key, storageKey string) (string, string) {
+	if storageKey == "" {
+		return bucket, key
 	}
+	return objectsBucket, storageKey
+}
 
-	return obj, nil
+// contentDigest computes the size and the MD5 and SHA-256 checksums of an object
+// as it is written.
+type contentDigest struct {
+	size   int64
+	md5    hash.Hash
+	sha256 hash.Hash
 }
 
-func (s *StorageService) Download(ctx context.Context, bucket, key string) (io.ReadCloser, *domain.Object, error) {
+func newContentDigest() *contentDigest {
+	return &contentDigest{md5: md5.New(), sha256: sha256.New()}
+}
+
+func (d *contentDigest) Write(p []byte) (int, error) {
+	d.size += int64(len(p))
+	d.md5.Write(p)
+	d.sha256.Write(p)
+	return len(p), nil
+}
+
+// detectContentType returns the content type supplied by the client, else one derived
+// from the key extension, else one sniffed from the first 512 bytes of the content.
+// The returned reader must be used in place of r since sniffing consumes from it.
+func detectContentType(key, contentType string, r io.Reader) (string, io.Reader) {
+	if contentType != "" && contentType != defaultContentType {
+		return contentType, r
+	}
+	if ct := mime.TypeByExtension(path.Ext(key)); ct != "" {
+		return ct, r
+	}
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, _ := br.Peek(sniffLen)
+	return http.DetectContentType(head), br
+}
+
+// normalizeMetadata lower-cases user metadata keys and enforces the size limit.
+func normalizeMetadata(metadata map[string]string) (map[string]string, error) {
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+
+	normalized := make(map[string]string, len(metadata))
+	size := 0
+	for k, v := range metadata {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if !validMetadataKey(k) {
+			return nil, errors.New(errors.InvalidInput, fmt.Sprintf("invalid metadata key %q", k))
+		}
+		normalized[k] = v
+		size += len(k) + len(v)
+	}
+	if size > maxMetadataSize {
+		return nil, errors.New(errors.InvalidInput, fmt.Sprintf("user metadata exceeds %d bytes", maxMetadataSize))
+	}
+	return normalized, nil
+}
+
+func validMetadataKey(k string) bool {
+	if k == "" {
+		return false
+	}
+	for _, r := range k {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *StorageService) Download(ctx context.Context, bucket, key string, opts domain.ReadOptions) (io.ReadCloser, *domain.Object, error) {
 	// 1. Get metadata
 	obj, err := s.repo.GetMeta(ctx, bucket, key)
 	if err != nil {
@@ -65,11 +244,18 @@ func (s *StorageService) Download(ctx context.Context, bucket, key string) (io.R
 	}
 
 	// 2. Open file
-	reader, err := s.store.Read(ctx, bucket, key)
+	storeBucket, storeKey := objectLocation(obj.Bucket, obj.Key, obj.StorageKey)
+	stored, err := s.store.Read(ctx, storeBucket, storeKey)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	// 3. Decrypt on the fly
+	reader, err := s.openObject(obj, stored, opts)
+	if err != nil {
+		_ = stored.Close()
+		return nil, nil, err
+	}
 	return reader, obj, nil
 }
 
@@ -77,6 +263,10 @@ func (s *StorageService) ListObjects(ctx context.Context, bucket string) ([]*dom
 	return s.repo.List(ctx, bucket)
 }
 
+func (s *StorageService) ListBuckets(ctx context.Context) ([]*domain.Bucket, error) {
+	return s.repo.ListBuckets(ctx)
+}
+
 func (s *StorageService) DeleteObject(ctx context.Context, bucket, key string) error {
 	// 1. Soft delete in DB
 	if err := s.repo.SoftDelete(ctx, bucket, key); err != nil {
@@ -85,5 +275,260 @@ func (s *StorageService) DeleteObject(ctx context.Context, bucket, key string) e
 
 	// Note: We don't delete from FileStore yet because it's a "soft delete".
 	// A background job could clean up Filesystem objects with deleted_at set.
+	s.publish(ctx, domain.EventObjectDeleted, &domain.Object{Bucket: bucket, Key: key})
+	return nil
+}
+
+func (s *StorageService) InitiateMultipartUpload(ctx context.Context, bucket, key string, opts domain.ObjectOptions) (*domain.MultipartUpload, error) {
+	if bucket == "" || key == "" {
+		return nil, errors.New(errors.InvalidInput, "bucket and key are required")
+	}
+	if err := validateBucketName(bucket); err != nil {
+		return nil, err
+	}
+
+	metadata, err := normalizeMetadata(opts.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parts are staged and reassembled server-side, which a key the server never
+	// keeps cannot support
+	if opts.Encryption == domain.EncryptionCustomer || opts.CustomerKey != nil {
+		return nil, errors.New(errors.InvalidInput, "customer-provided keys are not supported for multipart uploads")
+	}
+	encryption, err := s.resolveEncryption(ctx, bucket, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	upload := &domain.MultipartUpload{
+		ID:          uuid.New(),
+		UserID:      appcontext.UserIDFromContext(ctx),
+		Bucket:      bucket,
+		Key:         key,
+		ContentType: opts.ContentType,
+		Metadata:    metadata,
+		Encryption:  encryption,
+		CreatedAt:   time.Now(),
+	}
+
+	if err := s.repo.CreateMultipartUpload(ctx, upload); err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+func (s *StorageService) UploadPart(ctx context.Context, bucket, key string, uploadID uuid.UUID, partNumber int, r io.Reader) (*domain.Part, error) {
+	if partNumber < 1 || partNumber > maxPartNumber {
+		return nil, errors.New(errors.InvalidInput, fmt.Sprintf("part number must be between 1 and %d", maxPartNumber))
+	}
+
+	upload, err := s.getUpload(ctx, bucket, key, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := newContentDigest()
+	body := io.Reader(io.TeeReader(r, digest))
+	if upload.Encryption != "" {
+		// Staged parts are encrypted directly under the user key; each stream
+		// derives its own subkey
+		userKey, err := s.userKey(upload.UserID)
+		if err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to derive encryption key", err)
+		}
+		if body, err = crypto.NewEncryptReader(body, userKey); err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to encrypt part", err)
+		}
+	}
+	if _, err := s.store.Write(ctx, multipartBucket, partKey(uploadID, partNumber), body); err != nil {
+		return nil, err
+	}
+
+	part := &domain.Part{
+		UploadID:   uploadID,
+		PartNumber: partNumber,
+		SizeBytes:  digest.size,
+		ETag:       hex.EncodeToString(digest.md5.Sum(nil)),
+		CreatedAt:  time.Now(),
+	}
+
+	if err := s.repo.SavePart(ctx, part); err != nil {
+		_ = s.store.Delete(ctx, multipartBucket, partKey(uploadID, partNumber))
+		return nil, err
+	}
+	return part, nil
+}
+
+func (s *StorageService) ListParts(ctx context.Context, bucket, key string, uploadID uuid.UUID) ([]*domain.Part, error) {
+	if _, err := s.getUpload(ctx, bucket, key, uploadID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListParts(ctx, uploadID)
+}
+
+func (s *StorageService) CompleteMultipartUpload(ctx context.Context, bucket, key string, uploadID uuid.UUID, completed []domain.CompletedPart) (*domain.Object, error) {
+	if len(completed) == 0 {
+		return nil, errors.New(errors.InvalidInput, "at least one part is required")
+	}
+
+	upload, err := s.getUpload(ctx, bucket, key, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	staged, err := s.repo.ListParts(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := selectParts(staged, completed)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stream parts in order into the final object without buffering them in memory
+	reader := &partsReader{ctx: ctx, store: s.store, uploadID: uploadID, parts: parts}
+	if upload.Encryption != "" {
+		if reader.key, err = s.userKey(upload.UserID); err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to derive encryption key", err)
+		}
+	}
+	contentType, r := detectContentType(upload.Key, upload.ContentType, reader)
+	digest := newContentDigest()
+	body, wrappedKey, err := s.sealObject(ctx, upload.Encryption, nil, io.TeeReader(r, digest))
+	if err != nil {
+		_ = reader.Close()
+		return nil, err
+	}
+	storageKey := newStorageKey(upload.Bucket)
+	_, err = s.store.Write(ctx, objectsBucket, storageKey, body)
+	_ = reader.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	obj := newObject(ctx, upload.Bucket, upload.Key, contentType, upload.Metadata, digest)
+	obj.Encryption, obj.EncryptedKey = upload.Encryption, wrappedKey
+	obj.StorageKey = storageKey
+	if err := s.repo.SaveMeta(ctx, obj); err != nil {
+		_ = s.store.Delete(ctx, objectsBucket, storageKey)
+		return nil, err
+	}
+
+	s.publish(ctx, domain.EventObjectCreated, obj)
+
+	// Staged parts left out of the list, e.g. from an earlier attempt with a
+	// different part size, are discarded with the rest
+	if err := s.removeUpload(ctx, uploadID, staged); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (s *StorageService) AbortMultipartUpload(ctx context.Context, bucket, key string, uploadID uuid.UUID) error {
+	if _, err := s.getUpload(ctx, bucket, key, uploadID); err != nil {
+		return err
+	}
+
+	parts, err := s.repo.ListParts(ctx, uploadID)
+	if err != nil {
+		return err
+	}
+	return s.removeUpload(ctx, uploadID, parts)
+}
+
+// getUpload loads an upload owned by the caller and checks it targets bucket/key.
+func (s *StorageService) getUpload(ctx context.Context, bucket, key string, uploadID uuid.UUID) (*domain.MultipartUpload, error) {
+	upload, err := s.repo.GetMultipartUpload(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Bucket != bucket || upload.Key != key {
+		return nil, errors.New(errors.NotFound, "multipart upload not found")
+	}
+	return upload, nil
+}
+
+// removeUpload deletes staged part files and the upload record (parts cascade).
+func (s *StorageService) removeUpload(ctx context.Context, uploadID uuid.UUID, parts []*domain.Part) error {
+	for _, p := range parts {
+		_ = s.store.Delete(ctx, multipartBucket, partKey(uploadID, p.PartNumber))
+	}
+	return s.repo.DeleteMultipartUpload(ctx, uploadID)
+}
+
+// selectParts matches the client's part list against the staged parts. The list
+// must be in ascending part number order and every ETag must match, so a
+// resumed upload can never pick up a stale part.
+func selectParts(staged []*domain.Part, completed []domain.CompletedPart) ([]*domain.Part, error) {
+	byNumber := make(map[int]*domain.Part, len(staged))
+	for _, p := range staged {
+		byNumber[p.PartNumber] = p
+	}
+
+	parts := make([]*domain.Part, 0, len(completed))
+	for i, c := range completed {
+		if i > 0 && c.PartNumber <= completed[i-1].PartNumber {
+			return nil, errors.New(errors.InvalidPartOrder, "parts must be listed in ascending part number order")
+		}
+		p, ok := byNumber[c.PartNumber]
+		if !ok || p.ETag != strings.Trim(c.ETag, `"`) {
+			return nil, errors.New(errors.InvalidPart, fmt.Sprintf("part %d was not uploaded or its ETag does not match", c.PartNumber))
+		}
+		parts = append(parts, p)
+	}
+	return parts, nil
+}
+
+func partKey(uploadID uuid.UUID, partNumber int) string {
+	return fmt.Sprintf("%s/%05d", uploadID, partNumber)
+}
+
+// partsReader concatenates staged parts, opening each one only when it is reached.
+// Parts are decrypted with key when it is set.
+type partsReader struct {
+	ctx      context.Context
+	store    ports.FileStore
+	uploadID uuid.UUID
+	parts    []*domain.Part
+	key      []byte
+	current  io.ReadCloser
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.parts) == 0 {
+				return 0, io.EOF
+			}
+			rc, err := r.store.Read(r.ctx, multipartBucket, partKey(r.uploadID, r.parts[0].PartNumber))
+			if err != nil {
+				return 0, err
+			}
+			r.current = rc
+			if r.key != nil {
+				r.current = &decryptingReader{Reader: crypto.NewDecryptReader(rc, r.key), Closer: rc}
+			}
+			r.parts = r.parts[1:]
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			_ = r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *partsReader) Close() error {
+	if r.current != nil {
+		return r.current.Close()
+	}
 	return nil
 }
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	stderrors "errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/poyrazk/thecloud/pkg/crypto"
+)
+
+// customerKeySize is the length of a customer-provided AES-256 key.
+const customerKeySize = 32
+
+// storageEncryptionKey returns the master key from which per-user object keys
+// are derived, or nil when STORAGE_ENCRYPTION_KEY is unset. Without it,
+// server-side encryption cannot be enabled.
+func storageEncryptionKey() []byte {
+	key := os.Getenv("STORAGE_ENCRYPTION_KEY")
+	if key == "" {
+		return nil
+	}
+	return []byte(key)
+}
+
+// requireEncryptionKey refuses server-side encryption with a service key when
+// no STORAGE_ENCRYPTION_KEY is configured.
+func (s *StorageService) requireEncryptionKey() error {
+	if len(s.encryptionKey) == 0 {
+		return errors.New(errors.InvalidInput, "server-side encryption is not available: STORAGE_ENCRYPTION_KEY is not set")
+	}
+	return nil
+}
+
+func (s *StorageService) PutBucketEncryption(ctx context.Context, bucket, algorithm string) (*domain.BucketEncryption, error) {
+	if err := validateBucketName(bucket); err != nil {
+		return nil, err
+	}
+	if algorithm != domain.EncryptionAES256 {
+		return nil, errors.New(errors.InvalidInput, "bucket default encryption must be "+domain.EncryptionAES256)
+	}
+	if err := s.requireEncryptionKey(); err != nil {
+		return nil, err
+	}
+
+	cfg := &domain.BucketEncryption{
+		UserID:    appcontext.UserIDFromContext(ctx),
+		Bucket:    bucket,
+		Algorithm: algorithm,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.PutBucketEncryption(ctx, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (s *StorageService) GetBucketEncryption(ctx context.Context, bucket string) (*domain.BucketEncryption, error) {
+	return s.repo.GetBucketEncryption(ctx, bucket)
+}
+
+func (s *StorageService) DeleteBucketEncryption(ctx context.Context, bucket string) error {
+	return s.repo.DeleteBucketEncryption(ctx, bucket)
+}
+
+// resolveEncryption returns the encryption mode for a new object: the requested
+// one, else the bucket default, else none.
+func (s *StorageService) resolveEncryption(ctx context.Context, bucket string, opts domain.ObjectOptions) (string, error) {
+	mode := opts.Encryption
+	if mode == "" && opts.CustomerKey != nil {
+		mode = domain.EncryptionCustomer
+	}
+
+	switch mode {
+	case domain.EncryptionAES256:
+		if err := s.requireEncryptionKey(); err != nil {
+			return "", err
+		}
+		return mode, nil
+	case domain.EncryptionCustomer:
+		if len(opts.CustomerKey) != customerKeySize {
+			return "", errors.New(errors.InvalidInput, "customer-provided key must be 256 bits")
+		}
+		return mode, nil
+	case "":
+	default:
+		return "", errors.New(errors.InvalidInput, "unsupported server-side encryption "+mode)
+	}
+
+	cfg, err := s.repo.GetBucketEncryption(ctx, bucket)
+	if err != nil {
+		if errors.Is(err, errors.NotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	if err := s.requireEncryptionKey(); err != nil {
+		return "", err
+	}
+	return cfg.Algorithm, nil
+}
+
+// userKey derives the key that wraps the data keys of a user's objects.
+func (s *StorageService) userKey(userID uuid.UUID) ([]byte, error) {
+	if len(s.encryptionKey) == 0 {
+		return nil, stderrors.New("STORAGE_ENCRYPTION_KEY is not set")
+	}
+	return crypto.DeriveKey(s.encryptionKey, userID[:])
+}
+
+// sealObject wraps r so that it yields the encrypted object body under a fresh
+// data key, and returns the wrapped data key to store with the object. With no
+// mode, r is returned unchanged.
+func (s *StorageService) sealObject(ctx context.Context, mode string, customerKey []byte, r io.Reader) (io.Reader, string, error) {
+	if mode == "" {
+		return r, "", nil
+	}
+
+	wrapKey := customerKey
+	if mode != domain.EncryptionCustomer {
+		var err error
+		if wrapKey, err = s.userKey(appcontext.UserIDFromContext(ctx)); err != nil {
+			return nil, "", errors.Wrap(errors.Internal, "failed to derive encryption key", err)
+		}
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, "", errors.Wrap(errors.Internal, "failed to generate data key", err)
+	}
+	wrapped, err := crypto.Encrypt(dataKey, wrapKey)
+	if err != nil {
+		return nil, "", errors.Wrap(errors.Internal, "failed to wrap data key", err)
+	}
+	sealed, err := crypto.NewEncryptReader(r, dataKey)
+	if err != nil {
+		return nil, "", errors.Wrap(errors.Internal, "failed to encrypt object", err)
+	}
+	return sealed, wrapped, nil
+}
+
+// openObject returns a reader of the plaintext of obj given its stored bytes.
+func (s *StorageService) openObject(obj *domain.Object, stored io.ReadCloser, opts domain.ReadOptions) (io.ReadCloser, error) {
+	if obj.Encryption == "" {
+		return stored, nil
+	}
+
+	var wrapKey []byte
+	if obj.Encryption == domain.EncryptionCustomer {
+		if opts.CustomerKey == nil {
+			return nil, errors.New(errors.InvalidInput, "object is encrypted with a customer-provided key")
+		}
+		wrapKey = opts.CustomerKey
+	} else {
+		var err error
+		if wrapKey, err = s.userKey(obj.UserID); err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to derive encryption key", err)
+		}
+	}
+
+	dataKey, err := crypto.Decrypt(obj.EncryptedKey, wrapKey)
+	if err != nil {
+		if obj.Encryption == domain.EncryptionCustomer {
+			return nil, errors.New(errors.Forbidden, "the customer-provided key does not match the object")
+		}
+		return nil, errors.Wrap(errors.Internal, "failed to unwrap data key", err)
+	}
+	return &decryptingReader{Reader: crypto.NewDecryptReader(stored, dataKey), Closer: stored}, nil
+}
+
+// decryptingReader reports tampered ciphertext as an internal error rather than
+// passing the crypto error through.
+type decryptingReader struct {
+	io.Reader
+	io.Closer
+}
+
+func (r *decryptingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err != nil && stderrors.Is(err, crypto.ErrStreamCorrupted) {
+		return n, errors.Wrap(errors.Internal, "stored object failed integrity check", err)
+	}
+	return n, err
+}
@@ -0,0 +1,194 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/services"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testEncryptionKey = "test-storage-encryption-key"
+
+// uploadCapturingBytes uploads content and returns the object with the bytes
+// that reached the file store.
+func uploadCapturingBytes(t *testing.T, svc *services.StorageService, repo *MockStorageRepo, store *MockFileStore, ctx context.Context, content string, opts domain.ObjectOptions) (*domain.Object, []byte) {
+	t.Helper()
+	var stored []byte
+	store.On("Write", ctx, ".objects", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			stored, _ = io.ReadAll(args.Get(3).(io.Reader))
+		}).Return(int64(0), nil).Once()
+	repo.On("SaveMeta", ctx, mock.AnythingOfType("*domain.Object")).Return(nil).Once()
+
+	obj, err := svc.Upload(ctx, "b", "k", strings.NewReader(content), opts)
+	require.NoError(t, err)
+	return obj, stored
+}
+
+func downloadStored(svc *services.StorageService, repo *MockStorageRepo, store *MockFileStore, ctx context.Context, obj *domain.Object, stored []byte, opts domain.ReadOptions) (string, error) {
+	repo.On("GetMeta", ctx, "b", "k").Return(obj, nil).Once()
+	store.On("Read", ctx, ".objects", obj.StorageKey).Return(io.NopCloser(bytes.NewReader(stored)), nil).Once()
+
+	r, _, err := svc.Download(ctx, "b", "k", opts)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	return string(data), err
+}
+
+func TestStorageUpload_ServerSideEncryption(t *testing.T) {
+	t.Setenv("STORAGE_ENCRYPTION_KEY", testEncryptionKey)
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+	ctx := appcontext.WithUserID(context.Background(), uuid.New())
+
+	content := strings.Repeat("confidential ", 10000)
+	obj, stored := uploadCapturingBytes(t, svc, repo, store, ctx, content, domain.ObjectOptions{Encryption: domain.EncryptionAES256})
+
+	assert.Equal(t, domain.EncryptionAES256, obj.Encryption)
+	assert.NotEmpty(t, obj.EncryptedKey)
+	assert.Equal(t, int64(len(content)), obj.SizeBytes)
+	assert.NotContains(t, string(stored), "confidential")
+
+	data, err := downloadStored(svc, repo, store, ctx, obj, stored, domain.ReadOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+}
+
+func TestStorageUpload_BucketDefaultEncryption(t *testing.T) {
+	t.Setenv("STORAGE_ENCRYPTION_KEY", testEncryptionKey)
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+	ctx := appcontext.WithUserID(context.Background(), uuid.New())
+
+	repo.On("GetBucketEncryption", ctx, "b").Return(&domain.BucketEncryption{Bucket: "b", Algorithm: domain.EncryptionAES256}, nil)
+	obj, stored := uploadCapturingBytes(t, svc, repo, store, ctx, "secret", domain.ObjectOptions{})
+
+	assert.Equal(t, domain.EncryptionAES256, obj.Encryption)
+	assert.NotContains(t, string(stored), "secret")
+}
+
+func TestStorageUpload_CustomerKey(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+	ctx := appcontext.WithUserID(context.Background(), uuid.New())
+
+	customerKey := bytes.Repeat([]byte{7}, 32)
+	obj, stored := uploadCapturingBytes(t, svc, repo, store, ctx, "secret", domain.ObjectOptions{CustomerKey: customerKey})
+	assert.Equal(t, domain.EncryptionCustomer, obj.Encryption)
+
+	_, err := downloadStored(svc, repo, store, ctx, obj, stored, domain.ReadOptions{})
+	assert.True(t, errors.Is(err, errors.InvalidInput))
+
+	_, err = downloadStored(svc, repo, store, ctx, obj, stored, domain.ReadOptions{CustomerKey: bytes.Repeat([]byte{8}, 32)})
+	assert.True(t, errors.Is(err, errors.Forbidden))
+
+	data, err := downloadStored(svc, repo, store, ctx, obj, stored, domain.ReadOptions{CustomerKey: customerKey})
+	require.NoError(t, err)
+	assert.Equal(t, "secret", data)
+}
+
+func TestStorageUpload_InvalidEncryption(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+	ctx := context.Background()
+
+	_, err := svc.Upload(ctx, "b", "k", strings.NewReader("x"), domain.ObjectOptions{Encryption: "ROT13"})
+	assert.True(t, errors.Is(err, errors.InvalidInput))
+
+	_, err = svc.Upload(ctx, "b", "k", strings.NewReader("x"), domain.ObjectOptions{CustomerKey: []byte("short")})
+	assert.True(t, errors.Is(err, errors.InvalidInput))
+
+	_, err = svc.InitiateMultipartUpload(ctx, "b", "k", domain.ObjectOptions{CustomerKey: bytes.Repeat([]byte{7}, 32)})
+	assert.True(t, errors.Is(err, errors.InvalidInput))
+	store.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestStorageEncryption_RequiresKey(t *testing.T) {
+	t.Setenv("STORAGE_ENCRYPTION_KEY", "")
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+	ctx := appcontext.WithUserID(context.Background(), uuid.New())
+
+	_, err := svc.PutBucketEncryption(ctx, "b", domain.EncryptionAES256)
+	assert.True(t, errors.Is(err, errors.InvalidInput))
+
+	_, err = svc.Upload(ctx, "b", "k", strings.NewReader("x"), domain.ObjectOptions{Encryption: domain.EncryptionAES256})
+	assert.True(t, errors.Is(err, errors.InvalidInput))
+
+	// A bucket default set before the key was removed does not fall back to plaintext.
+	repo.On("GetBucketEncryption", ctx, "b").Return(&domain.BucketEncryption{Bucket: "b", Algorithm: domain.EncryptionAES256}, nil)
+	_, err = svc.Upload(ctx, "b", "k", strings.NewReader("x"), domain.ObjectOptions{})
+	assert.True(t, errors.Is(err, errors.InvalidInput))
+
+	repo.AssertNotCalled(t, "PutBucketEncryption", mock.Anything, mock.Anything)
+	store.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestStorageMultipartUpload_Encrypted(t *testing.T) {
+	t.Setenv("STORAGE_ENCRYPTION_KEY", testEncryptionKey)
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+	ctx := appcontext.WithUserID(context.Background(), uuid.New())
+
+	uploadID := uuid.New()
+	upload := &domain.MultipartUpload{ID: uploadID, UserID: appcontext.UserIDFromContext(ctx), Bucket: "b", Key: "k", Encryption: domain.EncryptionAES256}
+	repo.On("GetMultipartUpload", ctx, uploadID).Return(upload, nil)
+	repo.On("SavePart", ctx, mock.AnythingOfType("*domain.Part")).Return(nil)
+
+	staged := map[string][]byte{}
+	store.On("Write", ctx, ".multipart", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			staged[args.String(2)], _ = io.ReadAll(args.Get(3).(io.Reader))
+		}).Return(int64(0), nil)
+
+	var parts []*domain.Part
+	for i, body := range []string{"hello ", "world"} {
+		part, err := svc.UploadPart(ctx, "b", "k", uploadID, i+1, strings.NewReader(body))
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(body)), part.SizeBytes)
+		parts = append(parts, part)
+	}
+	for key, data := range staged {
+		assert.NotContains(t, string(data), "hello", key)
+		store.On("Read", ctx, ".multipart", key).Return(io.NopCloser(bytes.NewReader(data)), nil)
+	}
+
+	var stored []byte
+	repo.On("ListParts", ctx, uploadID).Return(parts, nil)
+	store.On("Write", ctx, ".objects", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			stored, _ = io.ReadAll(args.Get(3).(io.Reader))
+		}).Return(int64(0), nil)
+	repo.On("SaveMeta", ctx, mock.AnythingOfType("*domain.Object")).Return(nil)
+	store.On("Delete", ctx, ".multipart", mock.Anything).Return(nil)
+	repo.On("DeleteMultipartUpload", ctx, uploadID).Return(nil)
+
+	completed := []domain.CompletedPart{{PartNumber: 1, ETag: parts[0].ETag}, {PartNumber: 2, ETag: parts[1].ETag}}
+	obj, err := svc.CompleteMultipartUpload(ctx, "b", "k", uploadID, completed)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), obj.SizeBytes)
+	assert.Equal(t, "5eb63bbbe01eeed093cb22bb8f5acdc3", obj.ETag) // md5("hello world")
+
+	data, err := downloadStored(svc, repo, store, ctx, obj, stored, domain.ReadOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", data)
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+)
+
+func (s *StorageService) CreateLifecycleRule(ctx context.Context, bucket string, rule domain.LifecycleRule) (*domain.LifecycleRule, error) {
+	if err := validateBucketName(bucket); err != nil {
+		return nil, err
+	}
+	if rule.ExpirationDays < 0 || rule.DeletedRetentionDays < 0 || rule.AbortIncompleteUploadDays < 0 {
+		return nil, errors.New(errors.InvalidInput, "lifecycle day counts cannot be negative")
+	}
+	if rule.ExpirationDays == 0 && rule.DeletedRetentionDays == 0 && rule.AbortIncompleteUploadDays == 0 {
+		return nil, errors.New(errors.InvalidInput, "lifecycle rule must define at least one action")
+	}
+
+	rule.ID = uuid.New()
+	rule.UserID = appcontext.UserIDFromContext(ctx)
+	rule.Bucket = bucket
+	rule.CreatedAt = time.Now()
+
+	if err := s.repo.CreateLifecycleRule(ctx, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (s *StorageService) ListLifecycleRules(ctx context.Context, bucket string) ([]*domain.LifecycleRule, error) {
+	return s.repo.ListLifecycleRules(ctx, bucket)
+}
+
+func (s *StorageService) DeleteLifecycleRule(ctx context.Context, bucket string, id uuid.UUID) error {
+	rules, err := s.repo.ListLifecycleRules(ctx, bucket)
+	if err != nil {
+		return err
+	}
+	for _, r := range rules {
+		if r.ID == id {
+			return s.repo.DeleteLifecycleRule(ctx, id)
+		}
+	}
+	return errors.New(errors.NotFound, "lifecycle rule not found")
+}
+
+// lifecycleMatches reports whether the rule applies to an object of userID.
+func lifecycleMatches(rule *domain.LifecycleRule, userID uuid.UUID, bucket, key string) bool {
+	return rule.Enabled && rule.UserID == userID && rule.Bucket == bucket && strings.HasPrefix(key, rule.Prefix)
+}
+
+// lifecycleDays returns the shortest non-zero day count that the matching rules set
+// through days, or fallback when no matching rule sets one.
+func lifecycleDays(rules []*domain.LifecycleRule, userID uuid.UUID, bucket, key string, days func(*domain.LifecycleRule) int, fallback time.Duration) time.Duration {
+	result := time.Duration(0)
+	for _, r := range rules {
+		if d := days(r); d > 0 && lifecycleMatches(r, userID, bucket, key) {
+			if ttl := time.Duration(d) * 24 * time.Hour; result == 0 || ttl < result {
+				result = ttl
+			}
+		}
+	}
+	if result == 0 {
+		return fallback
+	}
+	return result
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+)
+
+const (
+	defaultPresignExpiry = 15 * time.Minute
+	maxPresignExpiry     = 7 * 24 * time.Hour
+)
+
+// presignSigningKey returns the HMAC key used to sign pre-signed URLs. The
+// config requires STORAGE_SIGNING_KEY outside development; without it, a
+// random key is used, so URLs only hold on the process that signed them.
+func presignSigningKey() []byte {
+	if key := os.Getenv("STORAGE_SIGNING_KEY"); key != "" {
+		return []byte(key)
+	}
+	key := make([]byte, 32)
+	_, _ = rand.Read(key) // crypto/rand does not return errors since Go 1.24
+	return key
+}
+
+func (s *StorageService) PresignURL(ctx context.Context, bucket, key, method string, expiresIn time.Duration, maxContentLength int64) (*domain.PresignedURL, error) {
+	if bucket == "" || key == "" {
+		return nil, errors.New(errors.InvalidInput, "bucket and key are required")
+	}
+	if err := validateBucketName(bucket); err != nil {
+		return nil, err
+	}
+
+	method = strings.ToUpper(method)
+	switch method {
+	case "":
+		method = http.MethodGet
+	case http.MethodGet, http.MethodPut:
+	default:
+		return nil, errors.New(errors.InvalidInput, "method must be GET or PUT")
+	}
+
+	if expiresIn == 0 {
+		expiresIn = defaultPresignExpiry
+	}
+	if expiresIn < 0 || expiresIn > maxPresignExpiry {
+		return nil, errors.New(errors.InvalidInput, fmt.Sprintf("expiry must be between 1s and %s", maxPresignExpiry))
+	}
+	if maxContentLength < 0 {
+		return nil, errors.New(errors.InvalidInput, "max content length cannot be negative")
+	}
+	if maxContentLength > 0 && method != http.MethodPut {
+		return nil, errors.New(errors.InvalidInput, "max content length only applies to PUT")
+	}
+
+	// Downloads are only presigned for objects that exist
+	if method == http.MethodGet {
+		if _, err := s.repo.GetMeta(ctx, bucket, key); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &domain.PresignedURL{
+		Method:           method,
+		UserID:           appcontext.UserIDFromContext(ctx),
+		Bucket:           bucket,
+		Key:              key,
+		ExpiresAt:        time.Now().Add(expiresIn).Truncate(time.Second),
+		MaxContentLength: maxContentLength,
+	}
+
+	query := url.Values{}
+	query.Set(domain.PresignUserParam, p.UserID.String())
+	query.Set(domain.PresignExpiresParam, strconv.FormatInt(p.ExpiresAt.Unix(), 10))
+	if maxContentLength > 0 {
+		query.Set(domain.PresignMaxContentLengthParam, strconv.FormatInt(maxContentLength, 10))
+	}
+	query.Set(domain.PresignSignatureParam, s.signPresigned(p))
+
+	p.URL = fmt.Sprintf("/storage/%s/%s?%s", url.PathEscape(bucket), url.PathEscape(key), query.Encode())
+	return p, nil
+}
+
+func (s *StorageService) VerifyPresignedURL(ctx context.Context, method, bucket, key string, query url.Values) (*domain.PresignedURL, error) {
+	sig, err := hex.DecodeString(query.Get(domain.PresignSignatureParam))
+	if err != nil || len(sig) == 0 {
+		return nil, errors.New(errors.Forbidden, "invalid signature")
+	}
+
+	userID, err := uuid.Parse(query.Get(domain.PresignUserParam))
+	if err != nil {
+		return nil, errors.New(errors.Forbidden, "invalid signature")
+	}
+
+	expires, err := strconv.ParseInt(query.Get(domain.PresignExpiresParam), 10, 64)
+	if err != nil {
+		return nil, errors.New(errors.Forbidden, "invalid signature")
+	}
+
+	var maxContentLength int64
+	if v := query.Get(domain.PresignMaxContentLengthParam); v != "" {
+		maxContentLength, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || maxContentLength <= 0 {
+			return nil, errors.New(errors.Forbidden, "invalid signature")
+		}
+	}
+
+	p := &domain.PresignedURL{
+		Method:           strings.ToUpper(method),
+		UserID:           userID,
+		Bucket:           bucket,
+		Key:              key,
+		ExpiresAt:        time.Unix(expires, 0),
+		MaxContentLength: maxContentLength,
+	}
+
+	expected, _ := hex.DecodeString(s.signPresigned(p))
+	if !hmac.Equal(sig, expected) {
+		return nil, errors.New(errors.Forbidden, "invalid signature")
+	}
+	if !time.Now().Before(p.ExpiresAt) {
+		return nil, errors.New(errors.Forbidden, "pre-signed url has expired")
+	}
+	return p, nil
+}
+
+// signPresigned computes the hex HMAC-SHA256 over every field of the grant,
+// so changing the method, object, owner, expiry or size limit invalidates it.
+func (s *StorageService) signPresigned(p *domain.PresignedURL) string {
+	canonical := strings.Join([]string{
+		p.Method,
+		url.PathEscape(p.Bucket),
+		url.PathEscape(p.Key),
+		p.UserID.String(),
+		strconv.FormatInt(p.ExpiresAt.Unix(), 10),
+		strconv.FormatInt(p.MaxContentLength, 10),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
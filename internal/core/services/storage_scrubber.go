@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/platform"
+)
+
+const defaultScrubInterval = time.Hour
+
+// ScrubWorker periodically verifies object replicas and repairs the ones that
+// are missing or corrupted.
+type ScrubWorker struct {
+	store        ports.ScrubbingFileStore
+	tickInterval time.Duration
+}
+
+func NewScrubWorker(store ports.ScrubbingFileStore) *ScrubWorker {
+	return &ScrubWorker{
+		store:        store,
+		tickInterval: defaultScrubInterval,
+	}
+}
+
+func (w *ScrubWorker) Run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	ticker := time.NewTicker(w.tickInterval)
+	defer ticker.Stop()
+
+	log.Println("Scrub Worker started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Scrub Worker stopping")
+			return
+		case <-ticker.C:
+			w.Scrub(ctx)
+		}
+	}
+}
+
+// Scrub runs one verification pass and records its outcome.
+func (w *ScrubWorker) Scrub(ctx context.Context) {
+	report, err := w.store.Scrub(ctx)
+	if err != nil {
+		log.Printf("Storage: scrub failed: %v", err)
+		return
+	}
+
+	platform.StorageScrubRepairs.Add(float64(report.Repaired))
+	platform.StorageScrubUnrecoverable.Set(float64(report.Unrecoverable))
+	if report.Repaired > 0 || report.Unrecoverable > 0 {
+		log.Printf("Storage: scrubbed %d objects, repaired %d, unrecoverable %d", report.Objects, report.Repaired, report.Unrecoverable)
+	}
+}
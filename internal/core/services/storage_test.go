@@ -3,21 +3,29 @@ package services_test
 import (
 	"context"
 	"io"
+	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	appcontext "github.com/poyrazk/thecloud/internal/core/context"
 	"github.com/poyrazk/thecloud/internal/core/domain"
 	"github.com/poyrazk/thecloud/internal/core/services"
+	"github.com/poyrazk/thecloud/internal/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+// errNoBucketEncryption is returned by the repository for buckets without default encryption.
+var errNoBucketEncryption = errors.New(errors.NotFound, "bucket encryption is not configured")
+
 func TestStorageUpload_Success(t *testing.T) {
 	repo := new(MockStorageRepo)
 	store := new(MockFileStore)
-	svc := services.NewStorageService(repo, store)
+	svc := services.NewStorageService(repo, store, nil)
 
 	ctx := appcontext.WithUserID(context.Background(), uuid.New())
 	bucket := "test-bucket"
@@ -25,36 +33,118 @@ func TestStorageUpload_Success(t *testing.T) {
 	content := "hello world"
 	reader := strings.NewReader(content)
 
-	store.On("Write", ctx, bucket, key, reader).Return(int64(len(content)), nil)
+	var storageKey string
+	store.On("Write", ctx, ".objects", mock.MatchedBy(func(k string) bool { return strings.HasPrefix(k, bucket+"/") }), mock.Anything).
+		Run(func(args mock.Arguments) {
+			storageKey = args.String(2)
+			_, _ = io.ReadAll(args.Get(3).(io.Reader))
+		}).Return(int64(len(content)), nil)
+	repo.On("GetBucketEncryption", ctx, bucket).Return(nil, errNoBucketEncryption)
 	repo.On("SaveMeta", ctx, mock.AnythingOfType("*domain.Object")).Return(nil)
 
-	obj, err := svc.Upload(ctx, bucket, key, reader)
+	obj, err := svc.Upload(ctx, bucket, key, reader, domain.ObjectOptions{Metadata: map[string]string{"Owner": "alice"}})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, obj)
 	assert.Equal(t, bucket, obj.Bucket)
 	assert.Equal(t, key, obj.Key)
 	assert.Equal(t, int64(len(content)), obj.SizeBytes)
+	assert.Equal(t, "5eb63bbbe01eeed093cb22bb8f5acdc3", obj.ETag) // md5("hello world")
+	assert.Equal(t, "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", obj.SHA256)
+	assert.Equal(t, "text/plain; charset=utf-8", obj.ContentType)
+	assert.Equal(t, map[string]string{"owner": "alice"}, obj.Metadata)
+	assert.Equal(t, storageKey, obj.StorageKey)
 
 	repo.AssertExpectations(t)
 	store.AssertExpectations(t)
 }
 
+func TestStorageUpload_ContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		given    string
+		content  string
+		expected string
+	}{
+		{"explicit", "data.bin", "image/png", "x", "image/png"},
+		{"extension", "site/style.css", "", "body {}", "text/css; charset=utf-8"},
+		{"sniffed", "noext", "application/octet-stream", "%PDF-1.7", "application/pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockStorageRepo)
+			store := new(MockFileStore)
+			svc := services.NewStorageService(repo, store, nil)
+			ctx := context.Background()
+
+			var written string
+			store.On("Write", ctx, ".objects", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) {
+					data, _ := io.ReadAll(args.Get(3).(io.Reader))
+					written = string(data)
+				}).Return(int64(len(tt.content)), nil)
+			repo.On("GetBucketEncryption", ctx, "b").Return(nil, errNoBucketEncryption)
+			repo.On("SaveMeta", ctx, mock.AnythingOfType("*domain.Object")).Return(nil)
+
+			obj, err := svc.Upload(ctx, "b", tt.key, strings.NewReader(tt.content), domain.ObjectOptions{ContentType: tt.given})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, obj.ContentType)
+			assert.Equal(t, tt.content, written) // sniffing must not consume content
+		})
+	}
+}
+
+func TestStorageUpload_InvalidMetadata(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+
+	_, err := svc.Upload(context.Background(), "b", "k", strings.NewReader("x"), domain.ObjectOptions{Metadata: map[string]string{"bad key": "v"}})
+	assert.Error(t, err)
+
+	_, err = svc.Upload(context.Background(), "b", "k", strings.NewReader("x"), domain.ObjectOptions{Metadata: map[string]string{"big": strings.Repeat("a", 4096)}})
+	assert.Error(t, err)
+
+	store.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestStorageUpload_ReservedBucket(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+	ctx := context.Background()
+
+	_, err := svc.Upload(ctx, ".multipart", uuid.New().String()+"/00001", strings.NewReader("x"), domain.ObjectOptions{})
+	assert.True(t, errors.Is(err, errors.InvalidInput))
+
+	_, err = svc.InitiateMultipartUpload(ctx, ".multipart", "k", domain.ObjectOptions{})
+	assert.True(t, errors.Is(err, errors.InvalidInput))
+
+	_, err = svc.PresignURL(ctx, ".multipart", "k", http.MethodPut, 0, 0)
+	assert.True(t, errors.Is(err, errors.InvalidInput))
+
+	store.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "CreateMultipartUpload", mock.Anything, mock.Anything)
+}
+
 func TestStorageDownload_Success(t *testing.T) {
 	repo := new(MockStorageRepo)
 	store := new(MockFileStore)
-	svc := services.NewStorageService(repo, store)
+	svc := services.NewStorageService(repo, store, nil)
 
 	ctx := context.Background()
 	bucket := "test-bucket"
 	key := "test-key"
-	meta := &domain.Object{Bucket: bucket, Key: key}
+	meta := &domain.Object{Bucket: bucket, Key: key, StorageKey: bucket + "/v1"}
 	content := io.NopCloser(strings.NewReader("data"))
 
 	repo.On("GetMeta", ctx, bucket, key).Return(meta, nil)
-	store.On("Read", ctx, bucket, key).Return(content, nil)
+	store.On("Read", ctx, ".objects", bucket+"/v1").Return(content, nil)
 
-	r, obj, err := svc.Download(ctx, bucket, key)
+	r, obj, err := svc.Download(ctx, bucket, key, domain.ReadOptions{})
 
 	assert.NoError(t, err)
 	assert.Equal(t, meta, obj)
@@ -64,10 +154,26 @@ func TestStorageDownload_Success(t *testing.T) {
 	store.AssertExpectations(t)
 }
 
+func TestStorageDownload_LegacyLocation(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+
+	ctx := context.Background()
+	// Objects written before per-version storage keys keep their bytes at bucket/key
+	repo.On("GetMeta", ctx, "b", "k").Return(&domain.Object{Bucket: "b", Key: "k"}, nil)
+	store.On("Read", ctx, "b", "k").Return(io.NopCloser(strings.NewReader("data")), nil)
+
+	_, _, err := svc.Download(ctx, "b", "k", domain.ReadOptions{})
+
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
 func TestStorageDelete_Success(t *testing.T) {
 	repo := new(MockStorageRepo)
 	store := new(MockFileStore)
-	svc := services.NewStorageService(repo, store)
+	svc := services.NewStorageService(repo, store, nil)
 
 	ctx := context.Background()
 	bucket := "test-bucket"
@@ -84,7 +190,7 @@ func TestStorageDelete_Success(t *testing.T) {
 func TestStorageList_Success(t *testing.T) {
 	repo := new(MockStorageRepo)
 	store := new(MockFileStore)
-	svc := services.NewStorageService(repo, store)
+	svc := services.NewStorageService(repo, store, nil)
 
 	ctx := context.Background()
 	bucket := "test-bucket"
@@ -98,3 +204,282 @@ func TestStorageList_Success(t *testing.T) {
 	assert.Equal(t, expected, list)
 	repo.AssertExpectations(t)
 }
+
+func TestStorageUploadPart_Success(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+
+	ctx := context.Background()
+	uploadID := uuid.New()
+	upload := &domain.MultipartUpload{ID: uploadID, Bucket: "b", Key: "k"}
+
+	repo.On("GetMultipartUpload", ctx, uploadID).Return(upload, nil)
+	store.On("Write", ctx, ".multipart", uploadID.String()+"/00002", mock.Anything).
+		Run(func(args mock.Arguments) {
+			_, _ = io.ReadAll(args.Get(3).(io.Reader))
+		}).Return(int64(5), nil)
+	repo.On("SavePart", ctx, mock.AnythingOfType("*domain.Part")).Return(nil)
+
+	part, err := svc.UploadPart(ctx, "b", "k", uploadID, 2, strings.NewReader("hello"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, part.PartNumber)
+	assert.Equal(t, int64(5), part.SizeBytes)
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", part.ETag) // md5("hello")
+	repo.AssertExpectations(t)
+	store.AssertExpectations(t)
+}
+
+func TestStorageUploadPart_InvalidPartNumber(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+
+	_, err := svc.UploadPart(context.Background(), "b", "k", uuid.New(), 0, strings.NewReader("x"))
+
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "GetMultipartUpload", mock.Anything, mock.Anything)
+}
+
+func TestStorageUploadPart_WrongKey(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+
+	ctx := context.Background()
+	uploadID := uuid.New()
+	repo.On("GetMultipartUpload", ctx, uploadID).Return(&domain.MultipartUpload{ID: uploadID, Bucket: "b", Key: "other"}, nil)
+
+	_, err := svc.UploadPart(ctx, "b", "k", uploadID, 1, strings.NewReader("x"))
+
+	assert.Error(t, err)
+	store.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestStorageCompleteMultipartUpload_Success(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+
+	ctx := context.Background()
+	uploadID := uuid.New()
+	upload := &domain.MultipartUpload{ID: uploadID, Bucket: "b", Key: "k"}
+	parts := []*domain.Part{
+		{UploadID: uploadID, PartNumber: 1, ETag: "e1"},
+		{UploadID: uploadID, PartNumber: 2, ETag: "e2"},
+		// Left over from an earlier attempt with a smaller part size
+		{UploadID: uploadID, PartNumber: 3, ETag: "e3"},
+	}
+
+	repo.On("GetMultipartUpload", ctx, uploadID).Return(upload, nil)
+	repo.On("ListParts", ctx, uploadID).Return(parts, nil)
+	store.On("Read", ctx, ".multipart", uploadID.String()+"/00001").Return(io.NopCloser(strings.NewReader("hello ")), nil)
+	store.On("Read", ctx, ".multipart", uploadID.String()+"/00002").Return(io.NopCloser(strings.NewReader("world")), nil)
+
+	var assembled string
+	store.On("Write", ctx, ".objects", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			data, _ := io.ReadAll(args.Get(3).(io.Reader))
+			assembled = string(data)
+		}).Return(int64(11), nil)
+	repo.On("SaveMeta", ctx, mock.AnythingOfType("*domain.Object")).Return(nil)
+	store.On("Delete", ctx, ".multipart", mock.Anything).Return(nil)
+	repo.On("DeleteMultipartUpload", ctx, uploadID).Return(nil)
+
+	completed := []domain.CompletedPart{{PartNumber: 1, ETag: `"e1"`}, {PartNumber: 2, ETag: "e2"}}
+	obj, err := svc.CompleteMultipartUpload(ctx, "b", "k", uploadID, completed)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", assembled)
+	assert.Equal(t, int64(11), obj.SizeBytes)
+	assert.Equal(t, "5eb63bbbe01eeed093cb22bb8f5acdc3", obj.ETag)
+	store.AssertNotCalled(t, "Read", ctx, ".multipart", uploadID.String()+"/00003")
+	store.AssertCalled(t, "Delete", ctx, ".multipart", uploadID.String()+"/00003")
+	store.AssertNumberOfCalls(t, "Delete", 3)
+	repo.AssertExpectations(t)
+}
+
+func TestStorageCompleteMultipartUpload_NoParts(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+
+	_, err := svc.CompleteMultipartUpload(context.Background(), "b", "k", uuid.New(), nil)
+
+	assert.True(t, errors.Is(err, errors.InvalidInput))
+	store.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestStorageCompleteMultipartUpload_RejectsPartList(t *testing.T) {
+	ctx := context.Background()
+	uploadID := uuid.New()
+	staged := []*domain.Part{
+		{UploadID: uploadID, PartNumber: 1, ETag: "e1"},
+		{UploadID: uploadID, PartNumber: 2, ETag: "e2"},
+	}
+
+	tests := []struct {
+		name    string
+		parts   []domain.CompletedPart
+		errType errors.Type
+	}{
+		{"unknown part", []domain.CompletedPart{{PartNumber: 1, ETag: "e1"}, {PartNumber: 4, ETag: "e4"}}, errors.InvalidPart},
+		{"mismatched etag", []domain.CompletedPart{{PartNumber: 1, ETag: "e1"}, {PartNumber: 2, ETag: "stale"}}, errors.InvalidPart},
+		{"out of order", []domain.CompletedPart{{PartNumber: 2, ETag: "e2"}, {PartNumber: 1, ETag: "e1"}}, errors.InvalidPartOrder},
+		{"duplicate", []domain.CompletedPart{{PartNumber: 1, ETag: "e1"}, {PartNumber: 1, ETag: "e1"}}, errors.InvalidPartOrder},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockStorageRepo)
+			store := new(MockFileStore)
+			svc := services.NewStorageService(repo, store, nil)
+			repo.On("GetMultipartUpload", ctx, uploadID).Return(&domain.MultipartUpload{ID: uploadID, Bucket: "b", Key: "k"}, nil)
+			repo.On("ListParts", ctx, uploadID).Return(staged, nil)
+
+			_, err := svc.CompleteMultipartUpload(ctx, "b", "k", uploadID, tt.parts)
+
+			assert.True(t, errors.Is(err, tt.errType), "got %v", err)
+			store.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			repo.AssertNotCalled(t, "DeleteMultipartUpload", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestStorageAbortMultipartUpload_Success(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+
+	ctx := context.Background()
+	uploadID := uuid.New()
+	repo.On("GetMultipartUpload", ctx, uploadID).Return(&domain.MultipartUpload{ID: uploadID, Bucket: "b", Key: "k"}, nil)
+	repo.On("ListParts", ctx, uploadID).Return([]*domain.Part{{UploadID: uploadID, PartNumber: 1}}, nil)
+	store.On("Delete", ctx, ".multipart", uploadID.String()+"/00001").Return(nil)
+	repo.On("DeleteMultipartUpload", ctx, uploadID).Return(nil)
+
+	err := svc.AbortMultipartUpload(ctx, "b", "k", uploadID)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	store.AssertExpectations(t)
+}
+
+func TestStoragePresignURL_RoundTrip(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+
+	userID := uuid.New()
+	ctx := appcontext.WithUserID(context.Background(), userID)
+	repo.On("GetMeta", ctx, "b", "k").Return(&domain.Object{Bucket: "b", Key: "k"}, nil)
+
+	p, err := svc.PresignURL(ctx, "b", "k", "get", time.Minute, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodGet, p.Method)
+
+	u, err := url.Parse(p.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "/storage/b/k", u.Path)
+
+	grant, err := svc.VerifyPresignedURL(context.Background(), http.MethodGet, "b", "k", u.Query())
+	assert.NoError(t, err)
+	assert.Equal(t, userID, grant.UserID)
+
+	// The signature is bound to the method and the object
+	_, err = svc.VerifyPresignedURL(context.Background(), http.MethodPut, "b", "k", u.Query())
+	assert.Error(t, err)
+	_, err = svc.VerifyPresignedURL(context.Background(), http.MethodGet, "b", "other", u.Query())
+	assert.Error(t, err)
+}
+
+func TestStoragePresignURL_TamperedLimit(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+
+	ctx := appcontext.WithUserID(context.Background(), uuid.New())
+	p, err := svc.PresignURL(ctx, "b", "k", http.MethodPut, time.Minute, 1024)
+	assert.NoError(t, err)
+
+	u, _ := url.Parse(p.URL)
+	q := u.Query()
+	grant, err := svc.VerifyPresignedURL(context.Background(), http.MethodPut, "b", "k", q)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1024), grant.MaxContentLength)
+
+	q.Set(domain.PresignMaxContentLengthParam, "999999")
+	_, err = svc.VerifyPresignedURL(context.Background(), http.MethodPut, "b", "k", q)
+	assert.Error(t, err)
+
+	q.Del(domain.PresignMaxContentLengthParam)
+	_, err = svc.VerifyPresignedURL(context.Background(), http.MethodPut, "b", "k", q)
+	assert.Error(t, err)
+}
+
+func TestStoragePresignURL_Expired(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+
+	ctx := appcontext.WithUserID(context.Background(), uuid.New())
+	p, err := svc.PresignURL(ctx, "b", "k", http.MethodPut, time.Second, 0)
+	assert.NoError(t, err)
+
+	u, _ := url.Parse(p.URL)
+	q := u.Query()
+	q.Set(domain.PresignExpiresParam, strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10))
+
+	_, err = svc.VerifyPresignedURL(context.Background(), http.MethodPut, "b", "k", q)
+	assert.Error(t, err)
+}
+
+func TestStoragePresignURL_InvalidOptions(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+	ctx := context.Background()
+
+	_, err := svc.PresignURL(ctx, "b", "k", http.MethodDelete, time.Minute, 0)
+	assert.Error(t, err)
+	_, err = svc.PresignURL(ctx, "b", "k", http.MethodPut, 8*24*time.Hour, 0)
+	assert.Error(t, err)
+	_, err = svc.PresignURL(ctx, "b", "k", http.MethodGet, time.Minute, 10)
+	assert.Error(t, err)
+}
+
+func TestStorageCreateLifecycleRule(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+
+	userID := uuid.New()
+	ctx := appcontext.WithUserID(context.Background(), userID)
+	repo.On("CreateLifecycleRule", ctx, mock.AnythingOfType("*domain.LifecycleRule")).Return(nil)
+
+	rule, err := svc.CreateLifecycleRule(ctx, "logs", domain.LifecycleRule{Prefix: "tmp/", ExpirationDays: 7, Enabled: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "logs", rule.Bucket)
+	assert.Equal(t, userID, rule.UserID)
+
+	_, err = svc.CreateLifecycleRule(ctx, "logs", domain.LifecycleRule{Prefix: "tmp/"})
+	assert.Error(t, err)
+	_, err = svc.CreateLifecycleRule(ctx, "logs", domain.LifecycleRule{ExpirationDays: -1})
+	assert.Error(t, err)
+	repo.AssertNumberOfCalls(t, "CreateLifecycleRule", 1)
+}
+
+func TestStorageDeleteLifecycleRule_OtherBucket(t *testing.T) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	svc := services.NewStorageService(repo, store, nil)
+
+	ctx := context.Background()
+	repo.On("ListLifecycleRules", ctx, "b").Return([]*domain.LifecycleRule{{ID: uuid.New(), Bucket: "b"}}, nil)
+
+	err := svc.DeleteLifecycleRule(ctx, "b", uuid.New())
+
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "DeleteLifecycleRule", mock.Anything, mock.Anything)
+}
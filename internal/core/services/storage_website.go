@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+)
+
+// maxWebsiteCacheMaxAge caps the Cache-Control max-age of website responses at a year.
+const maxWebsiteCacheMaxAge = 365 * 24 * 60 * 60
+
+// websiteBucketName matches bucket names that are valid DNS labels, so every
+// website can also be served on its own hostname.
+var websiteBucketName = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+func (s *StorageService) PutBucketWebsite(ctx context.Context, bucket string, cfg domain.BucketWebsite) (*domain.BucketWebsite, error) {
+	if !websiteBucketName.MatchString(bucket) {
+		return nil, errors.New(errors.InvalidInput, "website buckets need a lowercase DNS-compatible name (a-z, 0-9 and -)")
+	}
+	if cfg.IndexDocument == "" {
+		cfg.IndexDocument = domain.DefaultWebsiteIndexDocument
+	}
+	if strings.Contains(cfg.IndexDocument, "/") {
+		return nil, errors.New(errors.InvalidInput, "index document must be a file name without /")
+	}
+	if strings.HasPrefix(cfg.ErrorDocument, "/") {
+		return nil, errors.New(errors.InvalidInput, "error document must be an object key without a leading /")
+	}
+	if cfg.CacheMaxAge < 0 || cfg.CacheMaxAge > maxWebsiteCacheMaxAge {
+		return nil, errors.New(errors.InvalidInput, "cache max-age must be between 0 and 31536000 seconds")
+	}
+
+	website := &domain.BucketWebsite{
+		UserID:        appcontext.UserIDFromContext(ctx),
+		Bucket:        bucket,
+		IndexDocument: cfg.IndexDocument,
+		ErrorDocument: cfg.ErrorDocument,
+		CacheMaxAge:   cfg.CacheMaxAge,
+		CreatedAt:     time.Now(),
+	}
+	if err := s.repo.PutBucketWebsite(ctx, website); err != nil {
+		return nil, err
+	}
+	return website, nil
+}
+
+func (s *StorageService) GetBucketWebsite(ctx context.Context, bucket string) (*domain.BucketWebsite, error) {
+	return s.repo.GetBucketWebsite(ctx, bucket)
+}
+
+func (s *StorageService) DeleteBucketWebsite(ctx context.Context, bucket string) error {
+	return s.repo.DeleteBucketWebsite(ctx, bucket)
+}
+
+func (s *StorageService) ResolveWebsite(ctx context.Context, bucket string) (*domain.BucketWebsite, error) {
+	return s.repo.FindBucketWebsite(ctx, bucket)
+}
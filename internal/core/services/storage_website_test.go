@@ -0,0 +1,58 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/services"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPutBucketWebsite_Defaults(t *testing.T) {
+	repo := new(MockStorageRepo)
+	svc := services.NewStorageService(repo, new(MockFileStore), nil)
+	userID := uuid.New()
+	ctx := appcontext.WithUserID(context.Background(), userID)
+
+	repo.On("PutBucketWebsite", ctx, mock.AnythingOfType("*domain.BucketWebsite")).Return(nil)
+
+	site, err := svc.PutBucketWebsite(ctx, "docs", domain.BucketWebsite{ErrorDocument: "404.html", CacheMaxAge: 60})
+
+	assert.NoError(t, err)
+	assert.Equal(t, userID, site.UserID)
+	assert.Equal(t, "index.html", site.IndexDocument)
+	assert.Equal(t, "404.html", site.ErrorDocument)
+	assert.Equal(t, 60, site.CacheMaxAge)
+	repo.AssertExpectations(t)
+}
+
+func TestPutBucketWebsite_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		bucket string
+		cfg    domain.BucketWebsite
+	}{
+		{"uppercase bucket", "Docs", domain.BucketWebsite{}},
+		{"dotted bucket", "docs.example", domain.BucketWebsite{}},
+		{"nested index", "docs", domain.BucketWebsite{IndexDocument: "pages/index.html"}},
+		{"absolute error document", "docs", domain.BucketWebsite{ErrorDocument: "/404.html"}},
+		{"negative max-age", "docs", domain.BucketWebsite{CacheMaxAge: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockStorageRepo)
+			svc := services.NewStorageService(repo, new(MockFileStore), nil)
+
+			_, err := svc.PutBucketWebsite(context.Background(), tt.bucket, tt.cfg)
+
+			assert.True(t, errors.Is(err, errors.InvalidInput))
+			repo.AssertNotCalled(t, "PutBucketWebsite", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/platform"
+)
+
+const (
+	defaultStorageGCInterval = 10 * time.Minute
+	// defaultMultipartUploadTTL is how long an upload may stay incomplete before it is aborted.
+	defaultMultipartUploadTTL = 24 * time.Hour
+	// defaultDeletedRetention is how long deleted objects keep their bytes when no
+	// lifecycle rule sets a retention window.
+	defaultDeletedRetention = 30 * 24 * time.Hour
+)
+
+// StorageWorker applies bucket lifecycle rules: it expires objects, purges the
+// bytes of deleted objects and non-current versions and aborts abandoned
+// multipart uploads.
+type StorageWorker struct {
+	repo             ports.StorageRepository
+	store            ports.FileStore
+	storageSvc       ports.StorageService
+	clock            ports.Clock
+	tickInterval     time.Duration
+	uploadTTL        time.Duration
+	deletedRetention time.Duration
+}
+
+func NewStorageWorker(repo ports.StorageRepository, store ports.FileStore, storageSvc ports.StorageService, clock ports.Clock) *StorageWorker {
+	return &StorageWorker{
+		repo:             repo,
+		store:            store,
+		storageSvc:       storageSvc,
+		clock:            clock,
+		tickInterval:     defaultStorageGCInterval,
+		uploadTTL:        defaultMultipartUploadTTL,
+		deletedRetention: defaultDeletedRetention,
+	}
+}
+
+func (w *StorageWorker) Run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	ticker := time.NewTicker(w.tickInterval)
+	defer ticker.Stop()
+
+	log.Println("Storage Worker started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Storage Worker stopping")
+			return
+		case <-ticker.C:
+			w.ApplyLifecycle(ctx)
+		}
+	}
+}
+
+// ApplyLifecycle runs one garbage collection pass over all users' buckets.
+func (w *StorageWorker) ApplyLifecycle(ctx context.Context) {
+	rules, err := w.repo.ListAllLifecycleRules(ctx)
+	if err != nil {
+		log.Printf("Storage: failed to list lifecycle rules: %v", err)
+		return
+	}
+
+	w.ExpireObjects(ctx, rules)
+	w.PurgeDeletedObjects(ctx, rules)
+	w.PurgeNoncurrentVersions(ctx, rules)
+	w.CleanupStaleUploads(ctx, rules)
+}
+
+// ExpireObjects deletes objects older than the expiration of a matching rule.
+// Expired objects are soft-deleted like any other and purged later.
+func (w *StorageWorker) ExpireObjects(ctx context.Context, rules []*domain.LifecycleRule) {
+	type bucketRef struct {
+		userID string
+		bucket string
+	}
+	seen := map[bucketRef]bool{}
+	now := w.clock.Now()
+
+	for _, rule := range rules {
+		ref := bucketRef{rule.UserID.String(), rule.Bucket}
+		if rule.ExpirationDays == 0 || seen[ref] {
+			continue
+		}
+		seen[ref] = true
+
+		uCtx := appcontext.WithUserID(ctx, rule.UserID)
+		objects, err := w.repo.List(uCtx, rule.Bucket)
+		if err != nil {
+			log.Printf("Storage: failed to list objects of bucket %s: %v", rule.Bucket, err)
+			continue
+		}
+
+		for _, obj := range objects {
+			ttl := lifecycleDays(rules, rule.UserID, obj.Bucket, obj.Key, func(r *domain.LifecycleRule) int { return r.ExpirationDays }, 0)
+			if ttl == 0 || obj.CreatedAt.After(now.Add(-ttl)) {
+				continue
+			}
+			if err := w.storageSvc.DeleteObject(uCtx, obj.Bucket, obj.Key); err != nil {
+				log.Printf("Storage: failed to expire %s/%s: %v", obj.Bucket, obj.Key, err)
+				continue
+			}
+			platform.StorageObjectsExpired.Inc()
+			log.Printf("Storage: expired %s/%s", obj.Bucket, obj.Key)
+		}
+	}
+}
+
+// PurgeDeletedObjects removes the rows and bytes of objects deleted longer ago
+// than their retention window.
+func (w *StorageWorker) PurgeDeletedObjects(ctx context.Context, rules []*domain.LifecycleRule) {
+	retention := func(r *domain.LifecycleRule) int { return r.DeletedRetentionDays }
+	now := w.clock.Now()
+
+	objects, err := w.repo.ListDeletedObjects(ctx, now.Add(-minLifecycleWindow(rules, retention, w.deletedRetention)))
+	if err != nil {
+		log.Printf("Storage: failed to list deleted objects: %v", err)
+		return
+	}
+
+	for _, obj := range objects {
+		ttl := lifecycleDays(rules, obj.UserID, obj.Bucket, obj.Key, retention, w.deletedRetention)
+		if obj.DeletedAt == nil || obj.DeletedAt.After(now.Add(-ttl)) {
+			continue
+		}
+
+		// The row is only dropped while still deleted, and the bytes are removed
+		// from this version's own location, so a re-upload of the key is never touched
+		if err := w.repo.HardDelete(ctx, obj.ID); err != nil {
+			continue
+		}
+		storeBucket, storeKey := objectLocation(obj.Bucket, obj.Key, obj.StorageKey)
+		if err := w.store.Delete(ctx, storeBucket, storeKey); err != nil {
+			log.Printf("Storage: failed to delete bytes of %s/%s: %v", obj.Bucket, obj.Key, err)
+			continue
+		}
+		platform.StorageObjectsPurged.Inc()
+		platform.StorageReclaimedBytes.WithLabelValues("deleted_object").Add(float64(obj.SizeBytes))
+	}
+}
+
+// PurgeNoncurrentVersions removes the bytes of versions replaced by a newer
+// upload longer ago than the deleted-object retention window.
+func (w *StorageWorker) PurgeNoncurrentVersions(ctx context.Context, rules []*domain.LifecycleRule) {
+	retention := func(r *domain.LifecycleRule) int { return r.DeletedRetentionDays }
+	now := w.clock.Now()
+
+	versions, err := w.repo.ListNoncurrentVersions(ctx, now.Add(-minLifecycleWindow(rules, retention, w.deletedRetention)))
+	if err != nil {
+		log.Printf("Storage: failed to list noncurrent versions: %v", err)
+		return
+	}
+
+	for _, v := range versions {
+		ttl := lifecycleDays(rules, v.UserID, v.Bucket, v.Key, retention, w.deletedRetention)
+		if v.NoncurrentAt.After(now.Add(-ttl)) {
+			continue
+		}
+
+		// New uploads never write to a version's location, so its bytes can go
+		// before its row and a failed pass is simply retried
+		storeBucket, storeKey := objectLocation(v.Bucket, v.Key, v.StorageKey)
+		if err := w.store.Delete(ctx, storeBucket, storeKey); err != nil {
+			log.Printf("Storage: failed to delete noncurrent version of %s/%s: %v", v.Bucket, v.Key, err)
+			continue
+		}
+		if err := w.repo.DeleteNoncurrentVersion(ctx, v.ID); err != nil {
+			log.Printf("Storage: failed to drop noncurrent version %s: %v", v.ID, err)
+			continue
+		}
+		platform.StorageReclaimedBytes.WithLabelValues("noncurrent_version").Add(float64(v.SizeBytes))
+	}
+}
+
+// CleanupStaleUploads aborts multipart uploads older than the upload TTL, or the
+// abort window of a matching lifecycle rule.
+func (w *StorageWorker) CleanupStaleUploads(ctx context.Context, rules []*domain.LifecycleRule) {
+	abortDays := func(r *domain.LifecycleRule) int { return r.AbortIncompleteUploadDays }
+	now := w.clock.Now()
+
+	uploads, err := w.repo.ListStaleMultipartUploads(ctx, now.Add(-minLifecycleWindow(rules, abortDays, w.uploadTTL)))
+	if err != nil {
+		log.Printf("Storage: failed to list stale uploads: %v", err)
+		return
+	}
+
+	for _, u := range uploads {
+		ttl := lifecycleDays(rules, u.UserID, u.Bucket, u.Key, abortDays, w.uploadTTL)
+		if u.CreatedAt.After(now.Add(-ttl)) {
+			continue
+		}
+
+		uCtx := appcontext.WithUserID(ctx, u.UserID)
+		var size int64
+		if parts, err := w.repo.ListParts(uCtx, u.ID); err == nil {
+			for _, p := range parts {
+				size += p.SizeBytes
+			}
+		}
+
+		if err := w.storageSvc.AbortMultipartUpload(uCtx, u.Bucket, u.Key, u.ID); err != nil {
+			log.Printf("Storage: failed to abort stale upload %s: %v", u.ID, err)
+			continue
+		}
+		platform.StorageReclaimedBytes.WithLabelValues("incomplete_upload").Add(float64(size))
+		log.Printf("Storage: aborted stale upload %s (%s/%s)", u.ID, u.Bucket, u.Key)
+	}
+}
+
+// minLifecycleWindow returns the shortest window any rule or the default allows,
+// which bounds the candidates fetched from the repository.
+func minLifecycleWindow(rules []*domain.LifecycleRule, days func(*domain.LifecycleRule) int, fallback time.Duration) time.Duration {
+	window := fallback
+	for _, r := range rules {
+		if d := days(r); d > 0 {
+			window = min(window, time.Duration(d)*24*time.Hour)
+		}
+	}
+	return window
+}
@@ -0,0 +1,126 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/services"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestStorageWorker() (*services.StorageWorker, *MockStorageRepo, *MockFileStore, time.Time) {
+	repo := new(MockStorageRepo)
+	store := new(MockFileStore)
+	clock := new(MockClock)
+	svc := services.NewStorageService(repo, store, nil)
+
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	clock.On("Now").Return(now)
+	return services.NewStorageWorker(repo, store, svc, clock), repo, store, now
+}
+
+func TestStorageWorker_CleanupStaleUploads(t *testing.T) {
+	worker, repo, store, now := newTestStorageWorker()
+
+	uploadID := uuid.New()
+	stale := &domain.MultipartUpload{ID: uploadID, UserID: uuid.New(), Bucket: "b", Key: "k", CreatedAt: now.Add(-48 * time.Hour)}
+
+	repo.On("ListStaleMultipartUploads", mock.Anything, now.Add(-24*time.Hour)).Return([]*domain.MultipartUpload{stale}, nil)
+	repo.On("GetMultipartUpload", mock.Anything, uploadID).Return(stale, nil)
+	repo.On("ListParts", mock.Anything, uploadID).Return([]*domain.Part{{UploadID: uploadID, PartNumber: 1, SizeBytes: 10}}, nil)
+	store.On("Delete", mock.Anything, ".multipart", uploadID.String()+"/00001").Return(nil)
+	repo.On("DeleteMultipartUpload", mock.Anything, uploadID).Return(nil)
+
+	worker.CleanupStaleUploads(context.Background(), nil)
+
+	repo.AssertExpectations(t)
+	store.AssertExpectations(t)
+}
+
+func TestStorageWorker_CleanupStaleUploadsRuleKeepsLonger(t *testing.T) {
+	worker, repo, _, now := newTestStorageWorker()
+
+	userID := uuid.New()
+	rules := []*domain.LifecycleRule{{UserID: userID, Bucket: "b", Prefix: "big/", AbortIncompleteUploadDays: 7, Enabled: true}}
+	upload := &domain.MultipartUpload{ID: uuid.New(), UserID: userID, Bucket: "b", Key: "big/file", CreatedAt: now.Add(-48 * time.Hour)}
+
+	repo.On("ListStaleMultipartUploads", mock.Anything, now.Add(-24*time.Hour)).Return([]*domain.MultipartUpload{upload}, nil)
+
+	worker.CleanupStaleUploads(context.Background(), rules)
+
+	repo.AssertNotCalled(t, "DeleteMultipartUpload", mock.Anything, mock.Anything)
+}
+
+func TestStorageWorker_ExpireObjects(t *testing.T) {
+	worker, repo, _, now := newTestStorageWorker()
+
+	userID := uuid.New()
+	rules := []*domain.LifecycleRule{{UserID: userID, Bucket: "logs", Prefix: "tmp/", ExpirationDays: 7, Enabled: true}}
+	objects := []*domain.Object{
+		{Bucket: "logs", Key: "tmp/old.log", CreatedAt: now.Add(-8 * 24 * time.Hour)},
+		{Bucket: "logs", Key: "tmp/new.log", CreatedAt: now.Add(-24 * time.Hour)},
+		{Bucket: "logs", Key: "keep/old.log", CreatedAt: now.Add(-30 * 24 * time.Hour)},
+	}
+
+	repo.On("List", mock.Anything, "logs").Return(objects, nil)
+	repo.On("SoftDelete", mock.Anything, "logs", "tmp/old.log").Return(nil)
+
+	worker.ExpireObjects(context.Background(), rules)
+
+	repo.AssertExpectations(t)
+	repo.AssertNumberOfCalls(t, "SoftDelete", 1)
+}
+
+func TestStorageWorker_PurgeDeletedObjects(t *testing.T) {
+	worker, repo, store, now := newTestStorageWorker()
+
+	userID := uuid.New()
+	rules := []*domain.LifecycleRule{{UserID: userID, Bucket: "b", DeletedRetentionDays: 1, Enabled: true}}
+	deletedAt := now.Add(-2 * 24 * time.Hour)
+	purge := &domain.Object{ID: uuid.New(), UserID: userID, Bucket: "b", Key: "gone", StorageKey: "b/v1", SizeBytes: 100, DeletedAt: &deletedAt}
+	// Another user's object falls back to the 30 day default retention
+	keep := &domain.Object{ID: uuid.New(), UserID: uuid.New(), Bucket: "b", Key: "other", DeletedAt: &deletedAt}
+	// Re-uploaded between listing and purge
+	revived := &domain.Object{ID: uuid.New(), UserID: userID, Bucket: "b", Key: "revived", DeletedAt: &deletedAt}
+
+	repo.On("ListDeletedObjects", mock.Anything, now.Add(-24*time.Hour)).Return([]*domain.Object{purge, keep, revived}, nil)
+	repo.On("HardDelete", mock.Anything, purge.ID).Return(nil)
+	repo.On("HardDelete", mock.Anything, revived.ID).Return(errors.New(errors.ObjectNotFound, "object not found or no longer deleted"))
+	store.On("Delete", mock.Anything, ".objects", "b/v1").Return(nil)
+
+	worker.PurgeDeletedObjects(context.Background(), rules)
+
+	repo.AssertExpectations(t)
+	store.AssertExpectations(t)
+	store.AssertNumberOfCalls(t, "Delete", 1)
+	repo.AssertNotCalled(t, "HardDelete", mock.Anything, keep.ID)
+}
+
+func TestStorageWorker_PurgeNoncurrentVersions(t *testing.T) {
+	worker, repo, store, now := newTestStorageWorker()
+
+	userID := uuid.New()
+	rules := []*domain.LifecycleRule{{UserID: userID, Bucket: "b", DeletedRetentionDays: 1, Enabled: true}}
+	replaced := &domain.ObjectVersion{ID: uuid.New(), UserID: userID, Bucket: "b", Key: "k", StorageKey: "b/v1", SizeBytes: 100, NoncurrentAt: now.Add(-2 * 24 * time.Hour)}
+	// Written before per-version storage keys, so its bytes are at bucket/key
+	legacy := &domain.ObjectVersion{ID: uuid.New(), UserID: userID, Bucket: "b", Key: "old", NoncurrentAt: now.Add(-2 * 24 * time.Hour)}
+	recent := &domain.ObjectVersion{ID: uuid.New(), UserID: userID, Bucket: "b", Key: "k", StorageKey: "b/v2", NoncurrentAt: now.Add(-time.Hour)}
+	// Another user's version falls back to the 30 day default retention
+	keep := &domain.ObjectVersion{ID: uuid.New(), UserID: uuid.New(), Bucket: "b", Key: "k", StorageKey: "b/v3", NoncurrentAt: now.Add(-2 * 24 * time.Hour)}
+
+	repo.On("ListNoncurrentVersions", mock.Anything, now.Add(-24*time.Hour)).Return([]*domain.ObjectVersion{replaced, legacy, recent, keep}, nil)
+	store.On("Delete", mock.Anything, ".objects", "b/v1").Return(nil)
+	store.On("Delete", mock.Anything, "b", "old").Return(nil)
+	repo.On("DeleteNoncurrentVersion", mock.Anything, replaced.ID).Return(nil)
+	repo.On("DeleteNoncurrentVersion", mock.Anything, legacy.ID).Return(nil)
+
+	worker.PurgeNoncurrentVersions(context.Background(), rules)
+
+	repo.AssertExpectations(t)
+	store.AssertExpectations(t)
+	store.AssertNumberOfCalls(t, "Delete", 2)
+}
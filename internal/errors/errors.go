@@ -19,6 +19,10 @@ const (
 	BucketNotFound Type = "BUCKET_NOT_FOUND"
 	ObjectNotFound Type = "OBJECT_NOT_FOUND"
 	ObjectTooLarge Type = "OBJECT_TOO_LARGE"
+	InvalidRange   Type = "INVALID_RANGE"
+	// InvalidPart and InvalidPartOrder reject the part list of a multipart completion.
+	InvalidPart      Type = "INVALID_PART"
+	InvalidPartOrder Type = "INVALID_PART_ORDER"
 
 	// Networking Errors
 	InvalidPortFormat  Type = "INVALID_PORT_FORMAT"
@@ -1,10 +1,13 @@
 package httphandlers
 
 import (
+	"context"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
 	"github.com/poyrazk/thecloud/internal/core/ports"
 	"github.com/poyrazk/thecloud/internal/errors"
 	"github.com/poyrazk/thecloud/pkg/httputil"
@@ -18,15 +21,22 @@ func NewAutoScalingHandler(svc ports.AutoScalingService) *AutoScalingHandler {
 	return &AutoScalingHandler{svc: svc}
 }
 
+// CreateGroupRequest takes either an image and ports or a launch template.
+// The VPC defaults to the template's.
 type CreateGroupRequest struct {
-	Name           string     `json:"name" binding:"required"`
-	VpcID          uuid.UUID  `json:"vpc_id" binding:"required"`
-	LoadBalancerID *uuid.UUID `json:"load_balancer_id"`
-	Image          string     `json:"image" binding:"required"`
-	Ports          string     `json:"ports"`
-	MinInstances   int        `json:"min_instances"` // 0 is valid
-	MaxInstances   int        `json:"max_instances" binding:"required"`
-	DesiredCount   int        `json:"desired_count" binding:"required"`
+	Name                  string     `json:"name" binding:"required"`
+	VpcID                 uuid.UUID  `json:"vpc_id"`
+	LoadBalancerID        *uuid.UUID `json:"load_balancer_id"`
+	Image                 string     `json:"image"`
+	Ports                 string     `json:"ports"`
+	LaunchTemplateID      *uuid.UUID `json:"launch_template_id"`
+	LaunchTemplateVersion int        `json:"launch_template_version"` // 0 is the latest
+	MinInstances          int        `json:"min_instances"`           // 0 is valid
+	MaxInstances          int        `json:"max_instances" binding:"required"`
+	DesiredCount          int        `json:"desired_count" binding:"required"`
+	// HealthCheckType is "container" (default) or "lb".
+	HealthCheckType           string `json:"health_check_type"`
+	HealthCheckGracePeriodSec *int   `json:"health_check_grace_period_sec"` // default 300
 }
 
 // CreateGroup creates a new scaling group
@@ -48,9 +58,21 @@ func (h *AutoScalingHandler) CreateGroup(c *gin.Context) {
 		return
 	}
 
-	key := c.GetHeader("Idempotency-Key")
-
-	group, err := h.svc.CreateGroup(c.Request.Context(), req.Name, req.VpcID, req.Image, req.Ports, req.MinInstances, req.MaxInstances, req.DesiredCount, req.LoadBalancerID, key)
+	group, err := h.svc.CreateGroup(c.Request.Context(), ports.CreateScalingGroupParams{
+		Name:                      req.Name,
+		VpcID:                     req.VpcID,
+		Image:                     req.Image,
+		Ports:                     req.Ports,
+		LaunchTemplateID:          req.LaunchTemplateID,
+		LaunchTemplateVersion:     req.LaunchTemplateVersion,
+		MinInstances:              req.MinInstances,
+		MaxInstances:              req.MaxInstances,
+		DesiredCount:              req.DesiredCount,
+		LoadBalancerID:            req.LoadBalancerID,
+		HealthCheckType:           req.HealthCheckType,
+		HealthCheckGracePeriodSec: req.HealthCheckGracePeriodSec,
+		IdempotencyKey:            c.GetHeader("Idempotency-Key"),
+	})
 	if err != nil {
 		httputil.Error(c, err)
 		return
@@ -127,13 +149,194 @@ func (h *AutoScalingHandler) DeleteGroup(c *gin.Context) {
 	httputil.Success(c, http.StatusNoContent, nil)
 }
 
+// UpdateGroupRequest changes the fields it sets and leaves the others as they
+// are.
+type UpdateGroupRequest struct {
+	Name         *string `json:"name"`
+	Image        *string `json:"image"`
+	MinInstances *int    `json:"min_instances"`
+	MaxInstances *int    `json:"max_instances"`
+	DesiredCount *int    `json:"desired_count"`
+	// LoadBalancerID attaches a load balancer; "" detaches it.
+	LoadBalancerID            *string `json:"load_balancer_id"`
+	HealthCheckType           *string `json:"health_check_type"`
+	HealthCheckGracePeriodSec *int    `json:"health_check_grace_period_sec"`
+}
+
+// UpdateGroup changes the settings of a scaling group
+// @Summary Update a scaling group
+// @Description Changes the name, image, sizes, load balancer or health check of an auto-scaling group; omitted fields are kept. Instances in service move to a new load balancer.
+// @Tags autoscaling
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ASG ID"
+// @Param request body UpdateGroupRequest true "Fields to change"
+// @Success 200 {object} domain.ScalingGroup
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Failure 409 {object} httputil.Response
+// @Router /autoscaling/groups/{id} [patch]
+func (h *AutoScalingHandler) UpdateGroup(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid group id"))
+		return
+	}
+
+	var req UpdateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid request body"))
+		return
+	}
+
+	params := ports.UpdateScalingGroupParams{
+		Name:                      req.Name,
+		Image:                     req.Image,
+		MinInstances:              req.MinInstances,
+		MaxInstances:              req.MaxInstances,
+		DesiredCount:              req.DesiredCount,
+		HealthCheckType:           req.HealthCheckType,
+		HealthCheckGracePeriodSec: req.HealthCheckGracePeriodSec,
+	}
+	if req.LoadBalancerID != nil {
+		lbID := uuid.Nil
+		if *req.LoadBalancerID != "" {
+			if lbID, err = uuid.Parse(*req.LoadBalancerID); err != nil {
+				httputil.Error(c, errors.New(errors.InvalidInput, "invalid load balancer id"))
+				return
+			}
+		}
+		params.LoadBalancerID = &lbID
+	}
+
+	group, err := h.svc.UpdateGroup(c.Request.Context(), id, params)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusOK, group)
+}
+
+// ScalingProcessesRequest names the processes to suspend or resume; none
+// means all of them.
+type ScalingProcessesRequest struct {
+	Processes []domain.ScalingProcess `json:"processes"`
+}
+
+// SuspendProcesses pauses worker processes of a scaling group
+// @Summary Suspend scaling processes
+// @Description Pauses LAUNCH, TERMINATE, REPLACE_UNHEALTHY or SCHEDULED_ACTIONS for an auto-scaling group, all of them when none are given
+// @Tags autoscaling
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ASG ID"
+// @Param request body ScalingProcessesRequest false "Processes"
+// @Success 200 {object} domain.ScalingGroup
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Router /autoscaling/groups/{id}/suspend [post]
+func (h *AutoScalingHandler) SuspendProcesses(c *gin.Context) {
+	h.scalingProcessesAction(c, h.svc.SuspendProcesses)
+}
+
+// ResumeProcesses resumes suspended worker processes of a scaling group
+// @Summary Resume scaling processes
+// @Description Resumes suspended processes of an auto-scaling group, all of them when none are given
+// @Tags autoscaling
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ASG ID"
+// @Param request body ScalingProcessesRequest false "Processes"
+// @Success 200 {object} domain.ScalingGroup
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Router /autoscaling/groups/{id}/resume [post]
+func (h *AutoScalingHandler) ResumeProcesses(c *gin.Context) {
+	h.scalingProcessesAction(c, h.svc.ResumeProcesses)
+}
+
+func (h *AutoScalingHandler) scalingProcessesAction(c *gin.Context, action func(context.Context, uuid.UUID, []domain.ScalingProcess) (*domain.ScalingGroup, error)) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid group id"))
+		return
+	}
+
+	var req ScalingProcessesRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			httputil.Error(c, errors.New(errors.InvalidInput, err.Error()))
+			return
+		}
+	}
+
+	group, err := action(c.Request.Context(), id, req.Processes)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusOK, group)
+}
+
+type SetLaunchTemplateRequest struct {
+	LaunchTemplateID uuid.UUID `json:"launch_template_id" binding:"required"`
+	Version          int       `json:"version"` // 0 is the latest
+}
+
+// SetLaunchTemplate moves a scaling group to a launch template version
+// @Summary Set the launch template of a scaling group
+// @Description Moves an auto-scaling group to a launch template version. New instances use it; running ones are kept.
+// @Tags autoscaling
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ASG ID"
+// @Param request body SetLaunchTemplateRequest true "Launch template"
+// @Success 200 {object} domain.ScalingGroup
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Router /autoscaling/groups/{id}/launch-template [put]
+func (h *AutoScalingHandler) SetLaunchTemplate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid group id"))
+		return
+	}
+
+	var req SetLaunchTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, err.Error()))
+		return
+	}
+
+	group, err := h.svc.SetLaunchTemplate(c.Request.Context(), id, req.LaunchTemplateID, req.Version)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusOK, group)
+}
+
 type CreateASPolicyRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	MetricType  string  `json:"metric_type" binding:"required"`
-	TargetValue float64 `json:"target_value" binding:"required"`
-	ScaleOut    int     `json:"scale_out_step" binding:"required"`
-	ScaleIn     int     `json:"scale_in_step" binding:"required"`
-	CooldownSec int     `json:"cooldown_sec" binding:"required"`
+	Name                string               `json:"name" binding:"required"`
+	PolicyType          string               `json:"policy_type"` // target_tracking (default) or step
+	MetricType          string               `json:"metric_type" binding:"required"`
+	MetricName          string               `json:"metric_name"`
+	TargetValue         float64              `json:"target_value"`
+	ScaleInThreshold    float64              `json:"scale_in_threshold"`
+	Steps               []domain.ScalingStep `json:"steps"`
+	EvaluationPeriodSec int                  `json:"evaluation_period_sec"`
+	// CooldownSec applies to both directions unless the separate cooldowns
+	// are given.
+	CooldownSec         int `json:"cooldown_sec"`
+	ScaleOutCooldownSec int `json:"scale_out_cooldown_sec"`
+	ScaleInCooldownSec  int `json:"scale_in_cooldown_sec"`
 }
 
 // CreatePolicy creates a new scaling policy
@@ -161,7 +364,26 @@ func (h *AutoScalingHandler) CreatePolicy(c *gin.Context) {
 		return
 	}
 
-	policy, err := h.svc.CreatePolicy(c.Request.Context(), id, req.Name, req.MetricType, req.TargetValue, req.ScaleOut, req.ScaleIn, req.CooldownSec)
+	policy := &domain.ScalingPolicy{
+		Name:                req.Name,
+		PolicyType:          req.PolicyType,
+		MetricType:          req.MetricType,
+		MetricName:          req.MetricName,
+		TargetValue:         req.TargetValue,
+		ScaleInThreshold:    req.ScaleInThreshold,
+		Steps:               req.Steps,
+		EvaluationPeriodSec: req.EvaluationPeriodSec,
+		ScaleOutCooldownSec: req.CooldownSec,
+		ScaleInCooldownSec:  req.CooldownSec,
+	}
+	if req.ScaleOutCooldownSec != 0 {
+		policy.ScaleOutCooldownSec = req.ScaleOutCooldownSec
+	}
+	if req.ScaleInCooldownSec != 0 {
+		policy.ScaleInCooldownSec = req.ScaleInCooldownSec
+	}
+
+	policy, err = h.svc.CreatePolicy(c.Request.Context(), id, policy)
 	if err != nil {
 		httputil.Error(c, err)
 		return
@@ -194,3 +416,401 @@ func (h *AutoScalingHandler) DeletePolicy(c *gin.Context) {
 
 	httputil.Success(c, http.StatusNoContent, nil)
 }
+
+type CreateScheduledActionRequest struct {
+	Name         string `json:"name" binding:"required"`
+	Schedule     string `json:"schedule" binding:"required"` // cron expression, e.g. "0 8 * * MON-FRI"
+	TimeZone     string `json:"time_zone"`                   // IANA name, UTC by default
+	MinInstances *int   `json:"min_instances"`
+	MaxInstances *int   `json:"max_instances"`
+	DesiredCount *int   `json:"desired_count"`
+}
+
+// CreateScheduledAction schedules a resize of a scaling group
+// @Summary Create a scheduled action
+// @Description Resizes an auto-scaling group on a cron schedule
+// @Tags autoscaling
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ASG ID"
+// @Param request body CreateScheduledActionRequest true "Scheduled action creation request"
+// @Success 201 {object} domain.ScheduledAction
+// @Failure 400 {object} httputil.Response
+// @Router /autoscaling/groups/{id}/schedules [post]
+func (h *AutoScalingHandler) CreateScheduledAction(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid group id"))
+		return
+	}
+
+	var req CreateScheduledActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, err.Error()))
+		return
+	}
+
+	action, err := h.svc.CreateScheduledAction(c.Request.Context(), id, &domain.ScheduledAction{
+		Name:         req.Name,
+		Schedule:     req.Schedule,
+		TimeZone:     req.TimeZone,
+		MinInstances: req.MinInstances,
+		MaxInstances: req.MaxInstances,
+		DesiredCount: req.DesiredCount,
+	})
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusCreated, action)
+}
+
+// ListScheduledActions returns the scheduled actions of a scaling group
+// @Summary List scheduled actions
+// @Description Gets the scheduled actions of an auto-scaling group, next run first
+// @Tags autoscaling
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ASG ID"
+// @Success 200 {array} domain.ScheduledAction
+// @Failure 404 {object} httputil.Response
+// @Router /autoscaling/groups/{id}/schedules [get]
+func (h *AutoScalingHandler) ListScheduledActions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid group id"))
+		return
+	}
+
+	actions, err := h.svc.ListScheduledActions(c.Request.Context(), id)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusOK, actions)
+}
+
+// DeleteScheduledAction deletes a scheduled action
+// @Summary Delete a scheduled action
+// @Description Removes a scheduled action
+// @Tags autoscaling
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Scheduled action ID"
+// @Success 204
+// @Failure 404 {object} httputil.Response
+// @Router /autoscaling/schedules/{id} [delete]
+func (h *AutoScalingHandler) DeleteScheduledAction(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid scheduled action id"))
+		return
+	}
+
+	if err := h.svc.DeleteScheduledAction(c.Request.Context(), id); err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusNoContent, nil)
+}
+
+type StartInstanceRefreshRequest struct {
+	MinHealthyPercentage  *int       `json:"min_healthy_percentage"` // default 90
+	HealthCheckTimeoutSec int        `json:"health_check_timeout_sec"`
+	LaunchTemplateID      *uuid.UUID `json:"launch_template_id"`
+	LaunchTemplateVersion int        `json:"launch_template_version"` // 0 is the latest
+	Image                 string     `json:"image"`
+}
+
+// StartInstanceRefresh starts replacing the instances of a scaling group
+// @Summary Start an instance refresh
+// @Description Replaces the instances of an auto-scaling group in batches, keeping the minimum healthy percentage in service. A launch template version or image moves the group to it first and is rolled back if the replacements never turn healthy.
+// @Tags autoscaling
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ASG ID"
+// @Param request body StartInstanceRefreshRequest false "Refresh settings"
+// @Success 202 {object} domain.InstanceRefresh
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Failure 409 {object} httputil.Response
+// @Router /autoscaling/groups/{id}/refresh [post]
+func (h *AutoScalingHandler) StartInstanceRefresh(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid group id"))
+		return
+	}
+
+	var req StartInstanceRefreshRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			httputil.Error(c, errors.New(errors.InvalidInput, err.Error()))
+			return
+		}
+	}
+
+	refresh, err := h.svc.StartInstanceRefresh(c.Request.Context(), id, ports.StartInstanceRefreshParams{
+		MinHealthyPercentage:  req.MinHealthyPercentage,
+		HealthCheckTimeoutSec: req.HealthCheckTimeoutSec,
+		LaunchTemplateID:      req.LaunchTemplateID,
+		LaunchTemplateVersion: req.LaunchTemplateVersion,
+		Image:                 req.Image,
+	})
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusAccepted, refresh)
+}
+
+// GetInstanceRefresh returns the progress of the latest instance refresh
+// @Summary Get the instance refresh of a scaling group
+// @Description Gets the latest instance refresh of an auto-scaling group and its progress
+// @Tags autoscaling
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ASG ID"
+// @Success 200 {object} domain.InstanceRefresh
+// @Failure 404 {object} httputil.Response
+// @Router /autoscaling/groups/{id}/refresh [get]
+func (h *AutoScalingHandler) GetInstanceRefresh(c *gin.Context) {
+	h.instanceRefreshAction(c, h.svc.GetInstanceRefresh)
+}
+
+// PauseInstanceRefresh pauses an instance refresh
+// @Summary Pause an instance refresh
+// @Description Stops replacing instances until the refresh is resumed
+// @Tags autoscaling
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ASG ID"
+// @Success 200 {object} domain.InstanceRefresh
+// @Failure 404 {object} httputil.Response
+// @Failure 409 {object} httputil.Response
+// @Router /autoscaling/groups/{id}/refresh/pause [post]
+func (h *AutoScalingHandler) PauseInstanceRefresh(c *gin.Context) {
+	h.instanceRefreshAction(c, h.svc.PauseInstanceRefresh)
+}
+
+// ResumeInstanceRefresh resumes a paused instance refresh
+// @Summary Resume an instance refresh
+// @Tags autoscaling
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ASG ID"
+// @Success 200 {object} domain.InstanceRefresh
+// @Failure 404 {object} httputil.Response
+// @Failure 409 {object} httputil.Response
+// @Router /autoscaling/groups/{id}/refresh/resume [post]
+func (h *AutoScalingHandler) ResumeInstanceRefresh(c *gin.Context) {
+	h.instanceRefreshAction(c, h.svc.ResumeInstanceRefresh)
+}
+
+// CancelInstanceRefresh cancels an instance refresh
+// @Summary Cancel an instance refresh
+// @Description Stops the refresh. Instances already replaced are kept and the launch configuration is not rolled back.
+// @Tags autoscaling
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ASG ID"
+// @Success 200 {object} domain.InstanceRefresh
+// @Failure 404 {object} httputil.Response
+// @Failure 409 {object} httputil.Response
+// @Router /autoscaling/groups/{id}/refresh/cancel [post]
+func (h *AutoScalingHandler) CancelInstanceRefresh(c *gin.Context) {
+	h.instanceRefreshAction(c, h.svc.CancelInstanceRefresh)
+}
+
+func (h *AutoScalingHandler) instanceRefreshAction(c *gin.Context, action func(context.Context, uuid.UUID) (*domain.InstanceRefresh, error)) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid group id"))
+		return
+	}
+
+	refresh, err := action(c.Request.Context(), id)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusOK, refresh)
+}
+
+type CreateLifecycleHookRequest struct {
+	Name                string                       `json:"name" binding:"required"`
+	Transition          domain.LifecycleTransition   `json:"transition" binding:"required"` // LAUNCHING or TERMINATING
+	HeartbeatTimeoutSec int                          `json:"heartbeat_timeout_sec"`         // default 3600
+	DefaultResult       domain.LifecycleActionResult `json:"default_result"`                // default ABANDON
+}
+
+// CreateLifecycleHook adds a lifecycle hook to a scaling group
+// @Summary Create a lifecycle hook
+// @Description Holds instances of an auto-scaling group in Pending:Wait when they launch, or Terminating:Wait before they terminate, until the lifecycle action is completed or the heartbeat timeout passes. A group has at most one hook per transition.
+// @Tags autoscaling
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ASG ID"
+// @Param request body CreateLifecycleHookRequest true "Lifecycle hook"
+// @Success 201 {object} domain.LifecycleHook
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Failure 409 {object} httputil.Response
+// @Router /autoscaling/groups/{id}/lifecycle-hooks [post]
+func (h *AutoScalingHandler) CreateLifecycleHook(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid group id"))
+		return
+	}
+
+	var req CreateLifecycleHookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, err.Error()))
+		return
+	}
+
+	hook, err := h.svc.CreateLifecycleHook(c.Request.Context(), id, &domain.LifecycleHook{
+		Name:                req.Name,
+		Transition:          req.Transition,
+		HeartbeatTimeoutSec: req.HeartbeatTimeoutSec,
+		DefaultResult:       req.DefaultResult,
+	})
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusCreated, hook)
+}
+
+// ListLifecycleHooks returns the lifecycle hooks of a scaling group
+// @Summary List lifecycle hooks
+// @Tags autoscaling
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ASG ID"
+// @Success 200 {array} domain.LifecycleHook
+// @Failure 404 {object} httputil.Response
+// @Router /autoscaling/groups/{id}/lifecycle-hooks [get]
+func (h *AutoScalingHandler) ListLifecycleHooks(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid group id"))
+		return
+	}
+
+	hooks, err := h.svc.ListLifecycleHooks(c.Request.Context(), id)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusOK, hooks)
+}
+
+// DeleteLifecycleHook deletes a lifecycle hook
+// @Summary Delete a lifecycle hook
+// @Description Removes a lifecycle hook. Instances waiting on it carry on as if the action had been completed with CONTINUE.
+// @Tags autoscaling
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Lifecycle hook ID"
+// @Success 204
+// @Failure 404 {object} httputil.Response
+// @Router /autoscaling/lifecycle-hooks/{id} [delete]
+func (h *AutoScalingHandler) DeleteLifecycleHook(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid lifecycle hook id"))
+		return
+	}
+
+	if err := h.svc.DeleteLifecycleHook(c.Request.Context(), id); err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusNoContent, nil)
+}
+
+type CompleteLifecycleActionRequest struct {
+	InstanceID uuid.UUID                    `json:"instance_id" binding:"required"`
+	Result     domain.LifecycleActionResult `json:"result" binding:"required"` // CONTINUE or ABANDON
+}
+
+// CompleteLifecycleAction releases an instance from its lifecycle wait state
+// @Summary Complete a lifecycle action
+// @Description Releases an instance waiting on a lifecycle hook. A launching instance goes in service on CONTINUE and is terminated on ABANDON; a terminating instance is terminated either way.
+// @Tags autoscaling
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ASG ID"
+// @Param request body CompleteLifecycleActionRequest true "Result"
+// @Success 204
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Router /autoscaling/groups/{id}/lifecycle-actions [post]
+func (h *AutoScalingHandler) CompleteLifecycleAction(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid group id"))
+		return
+	}
+
+	var req CompleteLifecycleActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, err.Error()))
+		return
+	}
+
+	if err := h.svc.CompleteLifecycleAction(c.Request.Context(), id, req.InstanceID, req.Result); err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusNoContent, nil)
+}
+
+// ListActivities returns the scaling activity history of a scaling group
+// @Summary List scaling activities
+// @Description Gets the latest scaling activities of an auto-scaling group, newest first. Activities are kept for 42 days.
+// @Tags autoscaling
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ASG ID"
+// @Param limit query int false "Maximum activities to return (default 50, max 500)"
+// @Success 200 {array} domain.ScalingActivity
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Router /autoscaling/groups/{id}/activities [get]
+func (h *AutoScalingHandler) ListActivities(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid group id"))
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "limit must be a number"))
+		return
+	}
+
+	activities, err := h.svc.ListActivities(c.Request.Context(), id, limit)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusOK, activities)
+}
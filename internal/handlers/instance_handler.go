@@ -20,11 +20,12 @@ const (
 )
 
 type InstanceHandler struct {
-	svc ports.InstanceService
+	svc         ports.InstanceService
+	templateSvc ports.LaunchTemplateService
 }
 
-func NewInstanceHandler(svc ports.InstanceService) *InstanceHandler {
-	return &InstanceHandler{svc: svc}
+func NewInstanceHandler(svc ports.InstanceService, templateSvc ports.LaunchTemplateService) *InstanceHandler {
+	return &InstanceHandler{svc: svc, templateSvc: templateSvc}
 }
 
 type VolumeAttachmentRequest struct {
@@ -32,12 +33,16 @@ type VolumeAttachmentRequest struct {
 	MountPath string `json:"mount_path"`
 }
 
+// LaunchRequest takes either an image with its settings or a launch
+// template, which carries them all.
 type LaunchRequest struct {
-	Name    string                    `json:"name" binding:"required"`
-	Image   string                    `json:"image" binding:"required"`
-	Ports   string                    `json:"ports"`
-	VpcID   string                    `json:"vpc_id"`
-	Volumes []VolumeAttachmentRequest `json:"volumes"`
+	Name                  string                    `json:"name" binding:"required"`
+	Image                 string                    `json:"image"`
+	Ports                 string                    `json:"ports"`
+	VpcID                 string                    `json:"vpc_id"`
+	Volumes               []VolumeAttachmentRequest `json:"volumes"`
+	LaunchTemplateID      *uuid.UUID                `json:"launch_template_id"`
+	LaunchTemplateVersion int                       `json:"launch_template_version"` // 0 is the latest
 }
 
 // validateLaunchRequest performs custom validation beyond struct tags
@@ -51,6 +56,16 @@ func validateLaunchRequest(req *LaunchRequest) error {
 		return errors.New(errors.InvalidInput, "name must contain only alphanumeric characters, hyphens, and underscores")
 	}
 
+	if req.LaunchTemplateID != nil {
+		if req.Image != "" || req.Ports != "" || req.VpcID != "" || len(req.Volumes) > 0 {
+			return errors.New(errors.InvalidInput, "image, ports, vpc_id and volumes come from the launch template")
+		}
+		if req.LaunchTemplateVersion < 0 {
+			return errors.New(errors.InvalidInput, "launch_template_version cannot be negative")
+		}
+		return nil
+	}
+
 	// Validate image
 	req.Image = strings.TrimSpace(req.Image)
 	if req.Image == "" {
@@ -90,7 +105,7 @@ func isValidResourceName(name string) bool {
 
 // Launch launches a new instance
 // @Summary Launch a new instance
-// @Description Creates and starts a new compute instance with optional volumes and VPC
+// @Description Creates and starts a new compute instance with optional volumes and VPC, or from a launch template
 // @Tags instances
 // @Accept json
 // @Produce json
@@ -113,6 +128,21 @@ func (h *InstanceHandler) Launch(c *gin.Context) {
 		return
 	}
 
+	if req.LaunchTemplateID != nil {
+		template, err := h.templateSvc.GetVersion(c.Request.Context(), *req.LaunchTemplateID, req.LaunchTemplateVersion)
+		if err != nil {
+			httputil.Error(c, err)
+			return
+		}
+		inst, err := h.svc.LaunchInstanceFromTemplate(c.Request.Context(), req.Name, template)
+		if err != nil {
+			httputil.Error(c, err)
+			return
+		}
+		httputil.Success(c, http.StatusCreated, inst)
+		return
+	}
+
 	var vpcUUID *uuid.UUID
 	if req.VpcID != "" {
 		id, err := uuid.Parse(req.VpcID)
@@ -11,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -27,6 +28,14 @@ func (m *instanceServiceMock) LaunchInstance(ctx context.Context, name, image, p
 	return args.Get(0).(*domain.Instance), args.Error(1)
 }
 
+func (m *instanceServiceMock) LaunchInstanceFromTemplate(ctx context.Context, name string, template *domain.LaunchTemplateVersion) (*domain.Instance, error) {
+	args := m.Called(ctx, name, template)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Instance), args.Error(1)
+}
+
 func (m *instanceServiceMock) StopInstance(ctx context.Context, idOrName string) error {
 	args := m.Called(ctx, idOrName)
 	return args.Error(0)
@@ -50,6 +59,14 @@ func (m *instanceServiceMock) GetInstanceLogs(ctx context.Context, idOrName stri
 	return args.String(0), args.Error(1)
 }
 
+func (m *instanceServiceMock) RefreshInstanceStatus(ctx context.Context, idOrName string) (*domain.Instance, error) {
+	args := m.Called(ctx, idOrName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Instance), args.Error(1)
+}
+
 func (m *instanceServiceMock) GetInstanceStats(ctx context.Context, idOrName string) (*domain.InstanceStats, error) {
 	args := m.Called(ctx, idOrName)
 	if args.Get(0) == nil {
@@ -66,7 +83,7 @@ func (m *instanceServiceMock) TerminateInstance(ctx context.Context, idOrName st
 func TestInstanceHandler_LaunchRejectsEmptyImage(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSvc := new(instanceServiceMock)
-	handler := NewInstanceHandler(mockSvc)
+	handler := NewInstanceHandler(mockSvc, nil)
 	r := gin.New()
 	r.POST("/instances", handler.Launch)
 
@@ -87,3 +104,60 @@ func TestInstanceHandler_LaunchRejectsEmptyImage(t *testing.T) {
 	assert.Equal(t, "INVALID_INPUT", wrapper.Error.Type)
 	mockSvc.AssertNotCalled(t, "LaunchInstance", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
+
+type launchTemplateServiceMock struct {
+	mock.Mock
+	ports.LaunchTemplateService
+}
+
+func (m *launchTemplateServiceMock) GetVersion(ctx context.Context, templateID uuid.UUID, version int) (*domain.LaunchTemplateVersion, error) {
+	args := m.Called(ctx, templateID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LaunchTemplateVersion), args.Error(1)
+}
+
+func TestInstanceHandler_LaunchFromTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(instanceServiceMock)
+	templateSvc := new(launchTemplateServiceMock)
+	handler := NewInstanceHandler(mockSvc, templateSvc)
+	r := gin.New()
+	r.POST("/instances", handler.Launch)
+
+	templateID := uuid.New()
+	template := &domain.LaunchTemplateVersion{TemplateID: templateID, Version: 2, Image: "nginx"}
+	templateSvc.On("GetVersion", mock.Anything, templateID, 2).Return(template, nil)
+	mockSvc.On("LaunchInstanceFromTemplate", mock.Anything, "web-1", template).Return(&domain.Instance{ID: uuid.New(), Name: "web-1"}, nil)
+
+	body := `{"name":"web-1","launch_template_id":"` + templateID.String() + `","launch_template_version":2}`
+	req := httptest.NewRequest(http.MethodPost, "/instances", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockSvc.AssertExpectations(t)
+	mockSvc.AssertNotCalled(t, "LaunchInstance", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestInstanceHandler_LaunchFromTemplateRejectsImage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(instanceServiceMock)
+	templateSvc := new(launchTemplateServiceMock)
+	handler := NewInstanceHandler(mockSvc, templateSvc)
+	r := gin.New()
+	r.POST("/instances", handler.Launch)
+
+	body := `{"name":"web-1","image":"nginx","launch_template_id":"` + uuid.New().String() + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/instances", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	templateSvc.AssertNotCalled(t, "GetVersion", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,239 @@
+package httphandlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/poyrazk/thecloud/pkg/httputil"
+)
+
+type LaunchTemplateHandler struct {
+	svc ports.LaunchTemplateService
+}
+
+func NewLaunchTemplateHandler(svc ports.LaunchTemplateService) *LaunchTemplateHandler {
+	return &LaunchTemplateHandler{svc: svc}
+}
+
+// LaunchTemplateVersionRequest is the instance configuration of a template
+// version.
+type LaunchTemplateVersionRequest struct {
+	Image        string                    `json:"image" binding:"required"`
+	Ports        string                    `json:"ports"`
+	InstanceType string                    `json:"instance_type"`
+	Env          map[string]string         `json:"env"`
+	UserData     string                    `json:"user_data"`
+	Volumes      []VolumeAttachmentRequest `json:"volumes"`
+	VpcID        *uuid.UUID                `json:"vpc_id"`
+}
+
+func (r LaunchTemplateVersionRequest) toDomain() *domain.LaunchTemplateVersion {
+	v := &domain.LaunchTemplateVersion{
+		Image:        r.Image,
+		Ports:        r.Ports,
+		InstanceType: r.InstanceType,
+		Env:          r.Env,
+		UserData:     r.UserData,
+		VpcID:        r.VpcID,
+	}
+	for _, va := range r.Volumes {
+		v.Volumes = append(v.Volumes, domain.VolumeAttachment{
+			VolumeIDOrName: va.VolumeID,
+			MountPath:      va.MountPath,
+		})
+	}
+	return v
+}
+
+type CreateLaunchTemplateRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	LaunchTemplateVersionRequest
+}
+
+// Create creates a launch template
+// @Summary Create a launch template
+// @Description Creates a launch template with its first version
+// @Tags launch-templates
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body CreateLaunchTemplateRequest true "Launch template"
+// @Success 201 {object} domain.LaunchTemplate
+// @Failure 400 {object} httputil.Response
+// @Router /launch-templates [post]
+func (h *LaunchTemplateHandler) Create(c *gin.Context) {
+	var req CreateLaunchTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, err.Error()))
+		return
+	}
+
+	template, err := h.svc.CreateTemplate(c.Request.Context(), req.Name, req.Description, req.toDomain())
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusCreated, template)
+}
+
+// List returns all launch templates
+// @Summary List launch templates
+// @Tags launch-templates
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} domain.LaunchTemplate
+// @Router /launch-templates [get]
+func (h *LaunchTemplateHandler) List(c *gin.Context) {
+	templates, err := h.svc.ListTemplates(c.Request.Context())
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+	httputil.Success(c, http.StatusOK, templates)
+}
+
+// Get returns a launch template
+// @Summary Get a launch template
+// @Tags launch-templates
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Launch template ID"
+// @Success 200 {object} domain.LaunchTemplate
+// @Failure 404 {object} httputil.Response
+// @Router /launch-templates/{id} [get]
+func (h *LaunchTemplateHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid launch template id"))
+		return
+	}
+
+	template, err := h.svc.GetTemplate(c.Request.Context(), id)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+	httputil.Success(c, http.StatusOK, template)
+}
+
+// Delete deletes a launch template
+// @Summary Delete a launch template
+// @Description Deletes a launch template and its versions. Templates used by scaling groups cannot be deleted.
+// @Tags launch-templates
+// @Security ApiKeyAuth
+// @Param id path string true "Launch template ID"
+// @Success 204
+// @Failure 404 {object} httputil.Response
+// @Failure 409 {object} httputil.Response
+// @Router /launch-templates/{id} [delete]
+func (h *LaunchTemplateHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid launch template id"))
+		return
+	}
+
+	if err := h.svc.DeleteTemplate(c.Request.Context(), id); err != nil {
+		httputil.Error(c, err)
+		return
+	}
+	httputil.Success(c, http.StatusNoContent, nil)
+}
+
+// CreateVersion adds a version to a launch template
+// @Summary Create a launch template version
+// @Description Adds a version to a launch template. Versions cannot be changed once created.
+// @Tags launch-templates
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Launch template ID"
+// @Param request body LaunchTemplateVersionRequest true "Version"
+// @Success 201 {object} domain.LaunchTemplateVersion
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Router /launch-templates/{id}/versions [post]
+func (h *LaunchTemplateHandler) CreateVersion(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid launch template id"))
+		return
+	}
+
+	var req LaunchTemplateVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, err.Error()))
+		return
+	}
+
+	version, err := h.svc.CreateVersion(c.Request.Context(), id, req.toDomain())
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+	httputil.Success(c, http.StatusCreated, version)
+}
+
+// ListVersions returns the versions of a launch template
+// @Summary List launch template versions
+// @Tags launch-templates
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Launch template ID"
+// @Success 200 {array} domain.LaunchTemplateVersion
+// @Failure 404 {object} httputil.Response
+// @Router /launch-templates/{id}/versions [get]
+func (h *LaunchTemplateHandler) ListVersions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid launch template id"))
+		return
+	}
+
+	versions, err := h.svc.ListVersions(c.Request.Context(), id)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+	httputil.Success(c, http.StatusOK, versions)
+}
+
+// GetVersion returns a launch template version
+// @Summary Get a launch template version
+// @Tags launch-templates
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Launch template ID"
+// @Param version path string true "Version number or latest"
+// @Success 200 {object} domain.LaunchTemplateVersion
+// @Failure 404 {object} httputil.Response
+// @Router /launch-templates/{id}/versions/{version} [get]
+func (h *LaunchTemplateHandler) GetVersion(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid launch template id"))
+		return
+	}
+
+	version := 0
+	if v := c.Param("version"); v != "latest" {
+		if version, err = strconv.Atoi(v); err != nil || version <= 0 {
+			httputil.Error(c, errors.New(errors.InvalidInput, "version must be a positive number or latest"))
+			return
+		}
+	}
+
+	v, err := h.svc.GetVersion(c.Request.Context(), id, version)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+	httputil.Success(c, http.StatusOK, v)
+}
@@ -2,9 +2,11 @@ package httphandlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
 	"github.com/poyrazk/thecloud/internal/core/ports"
 	"github.com/poyrazk/thecloud/internal/errors"
 	"github.com/poyrazk/thecloud/pkg/httputil"
@@ -23,12 +25,59 @@ type CreateLBRequest struct {
 	VpcID     string `json:"vpc_id" binding:"required"`
 	Port      int    `json:"port" binding:"required"`
 	Algorithm string `json:"algorithm"`
+	// HashHeader is required by the header-hash algorithm.
+	HashHeader string              `json:"hash_header"`
+	Stickiness domain.LBStickiness `json:"stickiness"`
+}
+
+// UpdateLBRequest changes the fields it sets and leaves the others as they are.
+type UpdateLBRequest struct {
+	Name       *string              `json:"name"`
+	Algorithm  *string              `json:"algorithm"`
+	HashHeader *string              `json:"hash_header"`
+	Stickiness *domain.LBStickiness `json:"stickiness"`
 }
 
 type AddTargetRequest struct {
 	InstanceID string `json:"instance_id" binding:"required"`
 	Port       int    `json:"port" binding:"required"`
 	Weight     int    `json:"weight"`
+	// TargetGroup defaults to the "default" group.
+	TargetGroup string `json:"target_group"`
+}
+
+type UpdateHealthCheckRequest struct {
+	Protocol           string `json:"protocol"`
+	Path               string `json:"path"`
+	StatusCodes        string `json:"status_codes"`
+	IntervalSeconds    int    `json:"interval_seconds"`
+	TimeoutSeconds     int    `json:"timeout_seconds"`
+	HealthyThreshold   int    `json:"healthy_threshold"`
+	UnhealthyThreshold int    `json:"unhealthy_threshold"`
+}
+
+type DeregistrationDelayRequest struct {
+	Seconds *int `json:"seconds" binding:"required"`
+}
+
+type AccessLogsRequest struct {
+	Enabled bool   `json:"enabled"`
+	Bucket  string `json:"bucket"`
+	Prefix  string `json:"prefix"`
+}
+
+type AddListenerRequest struct {
+	Port               int    `json:"port" binding:"required"`
+	Protocol           string `json:"protocol" binding:"required"`
+	CertificateSecret  string `json:"certificate_secret"`
+	RedirectPort       int    `json:"redirect_port"`
+	DefaultTargetGroup string `json:"default_target_group"`
+}
+
+type AddRoutingRuleRequest struct {
+	Host        string `json:"host"`
+	PathPrefix  string `json:"path_prefix"`
+	TargetGroup string `json:"target_group" binding:"required"`
 }
 
 // Create creates a load balancer
@@ -58,7 +107,8 @@ func (h *LBHandler) Create(c *gin.Context) {
 
 	idempotencyKey := c.GetHeader("Idempotency-Key")
 
-	lb, err := h.svc.Create(c.Request.Context(), req.Name, vpcID, req.Port, req.Algorithm, idempotencyKey)
+	balancing := domain.LBBalancing{Algorithm: req.Algorithm, HashHeader: req.HashHeader, Stickiness: req.Stickiness}
+	lb, err := h.svc.Create(c.Request.Context(), req.Name, vpcID, req.Port, balancing, idempotencyKey)
 	if err != nil {
 		httputil.Error(c, err)
 		return
@@ -110,6 +160,45 @@ func (h *LBHandler) Get(c *gin.Context) {
 	httputil.Success(c, http.StatusOK, lb)
 }
 
+// Update changes the settings of a load balancer
+// @Summary Update a load balancer
+// @Description Changes the name, algorithm, hash header or sticky sessions of a load balancer; omitted fields are kept
+// @Tags loadbalancers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "LB ID"
+// @Param request body UpdateLBRequest true "Fields to change"
+// @Success 200 {object} domain.LoadBalancer
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Router /lb/{id} [patch]
+func (h *LBHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid id format"))
+		return
+	}
+
+	var req UpdateLBRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid request body"))
+		return
+	}
+
+	lb, err := h.svc.UpdateLB(c.Request.Context(), id, domain.LBUpdate{
+		Name:       req.Name,
+		Algorithm:  req.Algorithm,
+		HashHeader: req.HashHeader,
+		Stickiness: req.Stickiness,
+	})
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+	httputil.Success(c, http.StatusOK, lb)
+}
+
 // Delete deletes a load balancer
 // @Summary Delete a load balancer
 // @Description Removes a load balancer and stops associated proxy
@@ -135,6 +224,155 @@ func (h *LBHandler) Delete(c *gin.Context) {
 	httputil.Success(c, http.StatusOK, gin.H{"message": "load balancer deletion initiated"})
 }
 
+// UpdateHealthCheck replaces the health check settings of a load balancer
+// @Summary Update target health checks
+// @Description Sets how targets are probed and when they change state; omitted fields take the defaults
+// @Tags loadbalancers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "LB ID"
+// @Param request body UpdateHealthCheckRequest true "Health check settings"
+// @Success 200 {object} domain.LoadBalancer
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Router /lb/{id}/health-check [put]
+func (h *LBHandler) UpdateHealthCheck(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid id format"))
+		return
+	}
+
+	var req UpdateHealthCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid request body"))
+		return
+	}
+
+	lb, err := h.svc.UpdateHealthCheck(c.Request.Context(), id, domain.HealthCheckConfig{
+		Protocol:           req.Protocol,
+		Path:               req.Path,
+		StatusCodes:        req.StatusCodes,
+		IntervalSeconds:    req.IntervalSeconds,
+		TimeoutSeconds:     req.TimeoutSeconds,
+		HealthyThreshold:   req.HealthyThreshold,
+		UnhealthyThreshold: req.UnhealthyThreshold,
+	})
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+	httputil.Success(c, http.StatusOK, lb)
+}
+
+// SetDeregistrationDelay sets how long deregistered targets drain
+// @Summary Set the deregistration delay
+// @Description Sets how long a deregistered target keeps serving in-flight requests before it is removed
+// @Tags loadbalancers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "LB ID"
+// @Param request body DeregistrationDelayRequest true "Delay in seconds"
+// @Success 200 {object} domain.LoadBalancer
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Router /lb/{id}/deregistration-delay [put]
+func (h *LBHandler) SetDeregistrationDelay(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid id format"))
+		return
+	}
+
+	var req DeregistrationDelayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid request body"))
+		return
+	}
+
+	lb, err := h.svc.SetDeregistrationDelay(c.Request.Context(), id, *req.Seconds)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+	httputil.Success(c, http.StatusOK, lb)
+}
+
+// SetAccessLogs configures shipping of access logs to a bucket
+// @Summary Configure access log shipping
+// @Description Enables or disables writing the access logs of a load balancer to a storage bucket
+// @Tags loadbalancers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "LB ID"
+// @Param request body AccessLogsRequest true "Access log settings"
+// @Success 200 {object} domain.LoadBalancer
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Router /lb/{id}/access-logs [put]
+func (h *LBHandler) SetAccessLogs(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid id format"))
+		return
+	}
+
+	var req AccessLogsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid request body"))
+		return
+	}
+
+	lb, err := h.svc.SetAccessLogs(c.Request.Context(), id, domain.AccessLogConfig{
+		Enabled: req.Enabled,
+		Bucket:  req.Bucket,
+		Prefix:  req.Prefix,
+	})
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+	httputil.Success(c, http.StatusOK, lb)
+}
+
+// GetMetrics returns the traffic of a load balancer
+// @Summary Get load balancer metrics
+// @Description Gets request counts, status code rates and latency of a load balancer and each of its targets
+// @Tags loadbalancers
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "LB ID"
+// @Param window query string false "Window to aggregate, e.g. 15m (default 1h, max 24h)"
+// @Success 200 {object} domain.LBMetrics
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Router /lb/{id}/metrics [get]
+func (h *LBHandler) GetMetrics(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid id format"))
+		return
+	}
+
+	var window time.Duration
+	if v := c.Query("window"); v != "" {
+		if window, err = time.ParseDuration(v); err != nil {
+			httputil.Error(c, errors.New(errors.InvalidInput, "invalid window"))
+			return
+		}
+	}
+
+	m, err := h.svc.GetMetrics(c.Request.Context(), id, window)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+	httputil.Success(c, http.StatusOK, m)
+}
+
 // AddTarget adds a target to a load balancer
 // @Summary Add a target to a load balancer
 // @Description Registers a compute instance to receive traffic from the load balancer
@@ -167,7 +405,7 @@ func (h *LBHandler) AddTarget(c *gin.Context) {
 		return
 	}
 
-	if err := h.svc.AddTarget(c.Request.Context(), lbID, instID, req.Port, req.Weight); err != nil {
+	if err := h.svc.AddTarget(c.Request.Context(), lbID, instID, req.Port, req.Weight, req.TargetGroup); err != nil {
 		httputil.Error(c, err)
 		return
 	}
@@ -177,7 +415,7 @@ func (h *LBHandler) AddTarget(c *gin.Context) {
 
 // RemoveTarget removes a target from a load balancer
 // @Summary Remove a target from a load balancer
-// @Description Deregisters a compute instance from the load balancer
+// @Description Deregisters a compute instance. The target stops receiving new requests and is removed once the deregistration delay has passed
 // @Tags loadbalancers
 // @Produce json
 // @Security ApiKeyAuth
@@ -201,12 +439,13 @@ func (h *LBHandler) RemoveTarget(c *gin.Context) {
 		return
 	}
 
-	if err := h.svc.RemoveTarget(c.Request.Context(), lbID, instID); err != nil {
+	removeAt, err := h.svc.RemoveTarget(c.Request.Context(), lbID, instID)
+	if err != nil {
 		httputil.Error(c, err)
 		return
 	}
 
-	httputil.Success(c, http.StatusOK, gin.H{"message": "target removed"})
+	httputil.Success(c, http.StatusOK, gin.H{"message": "target deregistered", "remove_at": removeAt})
 }
 
 // ListTargets returns all targets for a load balancer
@@ -233,3 +472,177 @@ func (h *LBHandler) ListTargets(c *gin.Context) {
 	}
 	httputil.Success(c, http.StatusOK, targets)
 }
+
+// AddListener adds a listener to a load balancer
+// @Summary Add a listener to a load balancer
+// @Description Opens a port on the load balancer speaking http, https or tcp
+// @Tags loadbalancers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "LB ID"
+// @Param request body AddListenerRequest true "Listener details"
+// @Success 201 {object} domain.LBListener
+// @Failure 400 {object} httputil.Response
+// @Failure 409 {object} httputil.Response
+// @Router /lb/{id}/listeners [post]
+func (h *LBHandler) AddListener(c *gin.Context) {
+	lbID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid lb_id format"))
+		return
+	}
+
+	var req AddListenerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid request body"))
+		return
+	}
+
+	listener, err := h.svc.AddListener(c.Request.Context(), lbID, domain.LBListener{
+		Port:               req.Port,
+		Protocol:           req.Protocol,
+		CertificateSecret:  req.CertificateSecret,
+		RedirectPort:       req.RedirectPort,
+		DefaultTargetGroup: req.DefaultTargetGroup,
+	})
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+	httputil.Success(c, http.StatusCreated, listener)
+}
+
+// ListListeners returns the listeners of a load balancer
+// @Summary List load balancer listeners
+// @Description Gets the listeners of a load balancer with their routing rules
+// @Tags loadbalancers
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "LB ID"
+// @Success 200 {array} domain.LBListener
+// @Router /lb/{id}/listeners [get]
+func (h *LBHandler) ListListeners(c *gin.Context) {
+	lbID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid lb_id format"))
+		return
+	}
+
+	listeners, err := h.svc.ListListeners(c.Request.Context(), lbID)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+	httputil.Success(c, http.StatusOK, listeners)
+}
+
+// RemoveListener removes a listener from a load balancer
+// @Summary Remove a listener
+// @Description Closes a listener port and drops its routing rules
+// @Tags loadbalancers
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "LB ID"
+// @Param listenerId path string true "Listener ID"
+// @Success 200 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Router /lb/{id}/listeners/{listenerId} [delete]
+func (h *LBHandler) RemoveListener(c *gin.Context) {
+	lbID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid lb_id format"))
+		return
+	}
+	listenerID, err := uuid.Parse(c.Param("listenerId"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid listener_id format"))
+		return
+	}
+
+	if err := h.svc.RemoveListener(c.Request.Context(), lbID, listenerID); err != nil {
+		httputil.Error(c, err)
+		return
+	}
+	httputil.Success(c, http.StatusOK, gin.H{"message": "listener removed"})
+}
+
+// AddRoutingRule adds a routing rule to a listener
+// @Summary Add a routing rule
+// @Description Routes requests matching a host and/or path prefix to a target group
+// @Tags loadbalancers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "LB ID"
+// @Param listenerId path string true "Listener ID"
+// @Param request body AddRoutingRuleRequest true "Rule details"
+// @Success 201 {object} domain.LBRoutingRule
+// @Failure 400 {object} httputil.Response
+// @Failure 409 {object} httputil.Response
+// @Router /lb/{id}/listeners/{listenerId}/rules [post]
+func (h *LBHandler) AddRoutingRule(c *gin.Context) {
+	lbID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid lb_id format"))
+		return
+	}
+	listenerID, err := uuid.Parse(c.Param("listenerId"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid listener_id format"))
+		return
+	}
+
+	var req AddRoutingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid request body"))
+		return
+	}
+
+	rule, err := h.svc.AddRoutingRule(c.Request.Context(), lbID, listenerID, domain.LBRoutingRule{
+		Host:        req.Host,
+		PathPrefix:  req.PathPrefix,
+		TargetGroup: req.TargetGroup,
+	})
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+	httputil.Success(c, http.StatusCreated, rule)
+}
+
+// RemoveRoutingRule removes a routing rule from a listener
+// @Summary Remove a routing rule
+// @Description Deletes a routing rule of a listener
+// @Tags loadbalancers
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "LB ID"
+// @Param listenerId path string true "Listener ID"
+// @Param ruleId path string true "Rule ID"
+// @Success 200 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Router /lb/{id}/listeners/{listenerId}/rules/{ruleId} [delete]
+func (h *LBHandler) RemoveRoutingRule(c *gin.Context) {
+	lbID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid lb_id format"))
+		return
+	}
+	listenerID, err := uuid.Parse(c.Param("listenerId"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid listener_id format"))
+		return
+	}
+	ruleID, err := uuid.Parse(c.Param("ruleId"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid rule_id format"))
+		return
+	}
+
+	if err := h.svc.RemoveRoutingRule(c.Request.Context(), lbID, listenerID, ruleID); err != nil {
+		httputil.Error(c, err)
+		return
+	}
+	httputil.Success(c, http.StatusOK, gin.H{"message": "rule removed"})
+}
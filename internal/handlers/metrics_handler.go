@@ -0,0 +1,70 @@
+package httphandlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/poyrazk/thecloud/pkg/httputil"
+)
+
+type MetricsHandler struct {
+	svc ports.MetricsService
+}
+
+func NewMetricsHandler(svc ports.MetricsService) *MetricsHandler {
+	return &MetricsHandler{svc: svc}
+}
+
+type CustomMetricData struct {
+	Name       string     `json:"name" binding:"required"`
+	Value      *float64   `json:"value" binding:"required"`
+	InstanceID *uuid.UUID `json:"instance_id"`
+	// Timestamp defaults to the time of the request.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type PutCustomMetricsRequest struct {
+	Metrics []CustomMetricData `json:"metrics" binding:"required,dive"`
+}
+
+// PutCustomMetrics stores application metrics
+// @Summary Put custom metrics
+// @Description Stores data points of application metrics that scaling policies of type custom can track
+// @Tags metrics
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body PutCustomMetricsRequest true "Metric data points"
+// @Success 204
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Router /metrics/custom [put]
+func (h *MetricsHandler) PutCustomMetrics(c *gin.Context) {
+	var req PutCustomMetricsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, err.Error()))
+		return
+	}
+
+	metrics := make([]*domain.CustomMetric, len(req.Metrics))
+	for i, m := range req.Metrics {
+		metrics[i] = &domain.CustomMetric{
+			Name:       m.Name,
+			Value:      *m.Value,
+			InstanceID: m.InstanceID,
+			Timestamp:  m.Timestamp,
+		}
+	}
+
+	if err := h.svc.PutCustomMetrics(c.Request.Context(), metrics); err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusNoContent, nil)
+}
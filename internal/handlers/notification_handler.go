@@ -0,0 +1,109 @@
+package httphandlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/poyrazk/thecloud/pkg/httputil"
+)
+
+type NotificationHandler struct {
+	svc ports.NotificationService
+}
+
+func NewNotificationHandler(svc ports.NotificationService) *NotificationHandler {
+	return &NotificationHandler{svc: svc}
+}
+
+type BucketNotificationRequest struct {
+	Events     []string   `json:"events"`
+	Prefix     string     `json:"prefix"`
+	Suffix     string     `json:"suffix"`
+	FunctionID *uuid.UUID `json:"function_id"`
+	WebhookURL string     `json:"webhook_url"`
+}
+
+// Create adds an event notification to a bucket
+// @Summary Create a bucket notification
+// @Description Delivers ObjectCreated and ObjectDeleted events for matching keys to a function or a webhook
+// @Tags storage
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Param request body BucketNotificationRequest true "Notification"
+// @Success 201 {object} domain.BucketNotification
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Router /buckets/{bucket}/notifications [post]
+func (h *NotificationHandler) Create(c *gin.Context) {
+	var req BucketNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid request body"))
+		return
+	}
+
+	n := domain.BucketNotification{
+		Events:     req.Events,
+		Prefix:     req.Prefix,
+		Suffix:     req.Suffix,
+		FunctionID: req.FunctionID,
+		WebhookURL: req.WebhookURL,
+	}
+
+	created, err := h.svc.CreateNotification(c.Request.Context(), c.Param("bucket"), n)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusCreated, created)
+}
+
+// List lists the event notifications of a bucket
+// @Summary List bucket notifications
+// @Tags storage
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Success 200 {array} domain.BucketNotification
+// @Router /buckets/{bucket}/notifications [get]
+func (h *NotificationHandler) List(c *gin.Context) {
+	notifications, err := h.svc.ListNotifications(c.Request.Context(), c.Param("bucket"))
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusOK, notifications)
+}
+
+// Delete removes an event notification
+// @Summary Delete a bucket notification
+// @Description Pending deliveries of the notification are dropped
+// @Tags storage
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Param id path string true "Notification ID"
+// @Success 204
+// @Failure 404 {object} httputil.Response
+// @Router /buckets/{bucket}/notifications/{id} [delete]
+func (h *NotificationHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid id format"))
+		return
+	}
+
+	if err := h.svc.DeleteNotification(c.Request.Context(), c.Param("bucket"), id); err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusNoContent, nil)
+}
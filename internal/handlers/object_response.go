@@ -0,0 +1,206 @@
+package httphandlers
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+)
+
+// Header prefixes of the native and S3-compatible APIs. User metadata and
+// encryption headers are named after the API's prefix.
+const (
+	cloudHeaderPrefix = "X-Cloud-"
+	s3HeaderPrefix    = "X-Amz-"
+
+	metaHeader        = "Meta-"
+	sseHeader         = "Server-Side-Encryption"
+	sseCustomerHeader = "Server-Side-Encryption-Customer-"
+	// copySourceHeader prefixes the customer key headers of a copy's source object.
+	copySourceHeader = "Copy-Source-"
+)
+
+// objectOptionsFromRequest collects the content type, user metadata and
+// encryption headers of an upload.
+func objectOptionsFromRequest(c *gin.Context, headerPrefix string) (domain.ObjectOptions, error) {
+	opts := domain.ObjectOptions{
+		ContentType: c.GetHeader("Content-Type"),
+		Encryption:  c.GetHeader(headerPrefix + sseHeader),
+	}
+
+	metaPrefix := headerPrefix + metaHeader
+	for name, values := range c.Request.Header {
+		if k, ok := strings.CutPrefix(name, metaPrefix); ok && k != "" && len(values) > 0 {
+			if opts.Metadata == nil {
+				opts.Metadata = make(map[string]string)
+			}
+			opts.Metadata[strings.ToLower(k)] = values[0]
+		}
+	}
+
+	key, err := customerKeyFromRequest(c, headerPrefix+sseCustomerHeader)
+	if err != nil {
+		return opts, err
+	}
+	if key != nil {
+		opts.Encryption, opts.CustomerKey = domain.EncryptionCustomer, key
+	}
+	return opts, nil
+}
+
+// readOptionsFromRequest collects the customer key needed to read an object.
+func readOptionsFromRequest(c *gin.Context, headerPrefix string) (domain.ReadOptions, error) {
+	key, err := customerKeyFromRequest(c, headerPrefix+sseCustomerHeader)
+	return domain.ReadOptions{CustomerKey: key}, err
+}
+
+// customerKeyFromRequest decodes the base64 customer-provided key sent in the
+// Key header under prefix, checking it against the optional Key-MD5 header.
+func customerKeyFromRequest(c *gin.Context, prefix string) ([]byte, error) {
+	encoded := c.GetHeader(prefix + "Key")
+	if encoded == "" {
+		return nil, nil
+	}
+	if alg := c.GetHeader(prefix + "Algorithm"); alg != "" && alg != domain.EncryptionAES256 {
+		return nil, errors.New(errors.InvalidInput, "customer-provided key algorithm must be "+domain.EncryptionAES256)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New(errors.InvalidInput, "customer-provided key must be base64 encoded")
+	}
+	if sum := c.GetHeader(prefix + "Key-Md5"); sum != "" {
+		digest := md5.Sum(key)
+		if sum != base64.StdEncoding.EncodeToString(digest[:]) {
+			return nil, errors.New(errors.InvalidInput, "customer-provided key does not match its MD5")
+		}
+	}
+	return key, nil
+}
+
+// objectETag returns the quoted entity tag of an object. Objects stored before
+// checksums were recorded fall back to their ID.
+func objectETag(obj *domain.Object) string {
+	if obj.ETag != "" {
+		return `"` + obj.ETag + `"`
+	}
+	return `"` + obj.ID.String() + `"`
+}
+
+func setObjectHeaders(c *gin.Context, obj *domain.Object, headerPrefix string) {
+	c.Header("ETag", objectETag(obj))
+	c.Header("Last-Modified", obj.CreatedAt.UTC().Format(http.TimeFormat))
+	c.Header("Content-Type", obj.ContentType)
+	c.Header("Accept-Ranges", "bytes")
+	for k, v := range obj.Metadata {
+		c.Header(headerPrefix+metaHeader+k, v)
+	}
+	setEncryptionHeaders(c, obj, headerPrefix)
+}
+
+// setEncryptionHeaders reports how an object is encrypted at rest.
+func setEncryptionHeaders(c *gin.Context, obj *domain.Object, headerPrefix string) {
+	switch obj.Encryption {
+	case domain.EncryptionAES256:
+		c.Header(headerPrefix+sseHeader, domain.EncryptionAES256)
+	case domain.EncryptionCustomer:
+		c.Header(headerPrefix+sseCustomerHeader+"Algorithm", domain.EncryptionAES256)
+	}
+}
+
+// resolveObjectRead applies If-None-Match and Range to a GET or HEAD of obj and
+// returns the response status with the byte window to send. A 304 or 416 status
+// means no body must be written; the caller reports a 416 in its own error format.
+func resolveObjectRead(c *gin.Context, obj *domain.Object) (status int, start, length int64) {
+	if inm := c.GetHeader("If-None-Match"); inm != "" && etagMatches(inm, objectETag(obj)) {
+		return http.StatusNotModified, 0, 0
+	}
+
+	rangeHeader := c.GetHeader("Range")
+	if rangeHeader == "" {
+		return http.StatusOK, 0, obj.SizeBytes
+	}
+
+	s, e, ok := parseByteRange(rangeHeader, obj.SizeBytes)
+	if !ok {
+		c.Header("Content-Range", fmt.Sprintf("bytes */%d", obj.SizeBytes))
+		return http.StatusRequestedRangeNotSatisfiable, 0, 0
+	}
+	c.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", s, e, obj.SizeBytes))
+	return http.StatusPartialContent, s, e - s + 1
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using the
+// weak comparison RFC 9110 prescribes for that header.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeObject writes the status and, unless this is a HEAD, streams length bytes
+// of the object starting at start, seeking when the reader supports it.
+func writeObject(c *gin.Context, status int, reader io.Reader, start, length int64, withBody bool) {
+	c.Header("Content-Length", strconv.FormatInt(length, 10))
+	c.Status(status)
+	if !withBody {
+		return
+	}
+	if start > 0 {
+		if seeker, ok := reader.(io.Seeker); ok {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return
+			}
+		} else if _, err := io.CopyN(io.Discard, reader, start); err != nil {
+			return
+		}
+	}
+	_, _ = io.CopyN(c.Writer, reader, length)
+}
+
+// parseByteRange parses a single "bytes=start-end" range (including suffix and
+// open-ended forms) and returns inclusive offsets.
+func parseByteRange(header string, size int64) (int64, int64, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") || size == 0 {
+		return 0, 0, false
+	}
+	startStr, endStr, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, 0, false
+	}
+
+	if startStr == "" {
+		// Suffix range: last N bytes
+		n, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		return max(size-n, 0), size - 1, true
+	}
+
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false
+	}
+	end := size - 1
+	if endStr != "" {
+		end, err = strconv.ParseInt(endStr, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, false
+		}
+		end = min(end, size-1)
+	}
+	return start, end, true
+}
@@ -0,0 +1,743 @@
+package httphandlers
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/poyrazk/thecloud/pkg/httputil"
+)
+
+const (
+	s3XMLNamespace  = "http://s3.amazonaws.com/doc/2006-03-01/"
+	s3MaxKeys       = 1000
+	s3ListTimestamp = "2006-01-02T15:04:05.000Z"
+)
+
+// S3Handler exposes the storage service through a path-style, S3-compatible API
+// so that aws-cli, rclone and S3 SDKs can be pointed at TheCloud.
+type S3Handler struct {
+	svc ports.StorageService
+}
+
+func NewS3Handler(svc ports.StorageService) *S3Handler {
+	return &S3Handler{svc: svc}
+}
+
+type s3Owner struct {
+	ID          string `xml:"ID"`
+	DisplayName string `xml:"DisplayName"`
+}
+
+type s3Bucket struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+type listAllMyBucketsResult struct {
+	XMLName xml.Name   `xml:"ListAllMyBucketsResult"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	Owner   s3Owner    `xml:"Owner"`
+	Buckets []s3Bucket `xml:"Buckets>Bucket"`
+}
+
+type s3Object struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type s3CommonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+type listBucketResult struct {
+	XMLName               xml.Name         `xml:"ListBucketResult"`
+	Xmlns                 string           `xml:"xmlns,attr"`
+	Name                  string           `xml:"Name"`
+	Prefix                string           `xml:"Prefix"`
+	Delimiter             string           `xml:"Delimiter,omitempty"`
+	MaxKeys               int              `xml:"MaxKeys"`
+	IsTruncated           bool             `xml:"IsTruncated"`
+	KeyCount              int              `xml:"KeyCount,omitempty"`
+	Marker                string           `xml:"Marker,omitempty"`
+	NextMarker            string           `xml:"NextMarker,omitempty"`
+	ContinuationToken     string           `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string           `xml:"NextContinuationToken,omitempty"`
+	StartAfter            string           `xml:"StartAfter,omitempty"`
+	Contents              []s3Object       `xml:"Contents"`
+	CommonPrefixes        []s3CommonPrefix `xml:"CommonPrefixes"`
+}
+
+type initiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadID string   `xml:"UploadId"`
+}
+
+type completeMultipartUploadRequest struct {
+	Parts []struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	} `xml:"Part"`
+}
+
+type completeMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Location string   `xml:"Location"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	ETag     string   `xml:"ETag"`
+}
+
+type s3Part struct {
+	PartNumber   int    `xml:"PartNumber"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+}
+
+type listPartsResult struct {
+	XMLName     xml.Name `xml:"ListPartsResult"`
+	Xmlns       string   `xml:"xmlns,attr"`
+	Bucket      string   `xml:"Bucket"`
+	Key         string   `xml:"Key"`
+	UploadID    string   `xml:"UploadId"`
+	IsTruncated bool     `xml:"IsTruncated"`
+	Parts       []s3Part `xml:"Part"`
+}
+
+type copyObjectResult struct {
+	XMLName      xml.Name `xml:"CopyObjectResult"`
+	LastModified string   `xml:"LastModified"`
+	ETag         string   `xml:"ETag"`
+}
+
+type deleteObjectsRequest struct {
+	Quiet   bool `xml:"Quiet"`
+	Objects []struct {
+		Key string `xml:"Key"`
+	} `xml:"Object"`
+}
+
+type s3DeletedObject struct {
+	Key string `xml:"Key"`
+}
+
+type s3DeleteError struct {
+	Key     string `xml:"Key"`
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+type deleteResult struct {
+	XMLName xml.Name          `xml:"DeleteResult"`
+	Xmlns   string            `xml:"xmlns,attr"`
+	Deleted []s3DeletedObject `xml:"Deleted"`
+	Errors  []s3DeleteError   `xml:"Error"`
+}
+
+// ListBuckets handles GET /s3/
+func (h *S3Handler) ListBuckets(c *gin.Context) {
+	buckets, err := h.svc.ListBuckets(c.Request.Context())
+	if err != nil {
+		s3Error(c, err)
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	res := listAllMyBucketsResult{
+		Xmlns: s3XMLNamespace,
+		Owner: s3Owner{ID: fmt.Sprint(userID), DisplayName: fmt.Sprint(userID)},
+	}
+	for _, b := range buckets {
+		res.Buckets = append(res.Buckets, s3Bucket{Name: b.Name, CreationDate: b.CreatedAt.UTC().Format(s3ListTimestamp)})
+	}
+	c.XML(http.StatusOK, res)
+}
+
+// CreateBucket handles PUT /s3/:bucket. Buckets are implicit, so this always succeeds.
+func (h *S3Handler) CreateBucket(c *gin.Context) {
+	c.Header("Location", "/"+c.Param("bucket"))
+	c.Status(http.StatusOK)
+}
+
+// HeadBucket handles HEAD /s3/:bucket. A bucket exists once it holds an object.
+func (h *S3Handler) HeadBucket(c *gin.Context) {
+	buckets, err := h.svc.ListBuckets(c.Request.Context())
+	if err != nil {
+		s3Error(c, err)
+		return
+	}
+	for _, b := range buckets {
+		if b.Name == c.Param("bucket") {
+			c.Status(http.StatusOK)
+			return
+		}
+	}
+	httputil.S3Error(c, http.StatusNotFound, "NoSuchBucket", "the specified bucket does not exist")
+}
+
+// DeleteBucket handles DELETE /s3/:bucket. Only empty buckets can be deleted.
+func (h *S3Handler) DeleteBucket(c *gin.Context) {
+	objects, err := h.svc.ListObjects(c.Request.Context(), c.Param("bucket"))
+	if err != nil {
+		s3Error(c, err)
+		return
+	}
+	if len(objects) > 0 {
+		httputil.S3Error(c, http.StatusConflict, "BucketNotEmpty", "the bucket you tried to delete is not empty")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListObjects handles GET /s3/:bucket for both ListObjects (V1) and ListObjectsV2.
+func (h *S3Handler) ListObjects(c *gin.Context) {
+	bucket := c.Param("bucket")
+	objects, err := h.svc.ListObjects(c.Request.Context(), bucket)
+	if err != nil {
+		s3Error(c, err)
+		return
+	}
+
+	maxKeys := s3MaxKeys
+	if v := c.Query("max-keys"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			httputil.S3Error(c, http.StatusBadRequest, "InvalidArgument", "invalid max-keys")
+			return
+		}
+		maxKeys = min(n, s3MaxKeys)
+	}
+
+	v2 := c.Query("list-type") == "2"
+	res := listBucketResult{
+		Xmlns:     s3XMLNamespace,
+		Name:      bucket,
+		Prefix:    c.Query("prefix"),
+		Delimiter: c.Query("delimiter"),
+		MaxKeys:   maxKeys,
+	}
+
+	marker := c.Query("marker")
+	if v2 {
+		res.StartAfter = c.Query("start-after")
+		res.ContinuationToken = c.Query("continuation-token")
+		marker = res.StartAfter
+		if res.ContinuationToken != "" {
+			decoded, err := base64.StdEncoding.DecodeString(res.ContinuationToken)
+			if err != nil {
+				httputil.S3Error(c, http.StatusBadRequest, "InvalidArgument", "invalid continuation token")
+				return
+			}
+			marker = string(decoded)
+		}
+	} else {
+		res.Marker = marker
+	}
+
+	entries, prefixes, next := paginateObjects(objects, res.Prefix, res.Delimiter, marker, maxKeys)
+	for _, obj := range entries {
+		res.Contents = append(res.Contents, s3Object{
+			Key:          obj.Key,
+			LastModified: obj.CreatedAt.UTC().Format(s3ListTimestamp),
+			ETag:         objectETag(obj),
+			Size:         obj.SizeBytes,
+			StorageClass: "STANDARD",
+		})
+	}
+	for _, p := range prefixes {
+		res.CommonPrefixes = append(res.CommonPrefixes, s3CommonPrefix{Prefix: p})
+	}
+
+	if next != "" {
+		res.IsTruncated = true
+		if v2 {
+			res.NextContinuationToken = base64.StdEncoding.EncodeToString([]byte(next))
+		} else {
+			res.NextMarker = next
+		}
+	}
+	if v2 {
+		res.KeyCount = len(res.Contents) + len(res.CommonPrefixes)
+	}
+
+	c.XML(http.StatusOK, res)
+}
+
+// paginateObjects applies S3 listing semantics: keys sorted lexicographically,
+// filtered by prefix, grouped by delimiter into common prefixes, resumed after
+// marker and capped at maxKeys. It returns the marker for the next page, if any.
+func paginateObjects(objects []*domain.Object, prefix, delimiter, marker string, maxKeys int) ([]*domain.Object, []string, string) {
+	sorted := make([]*domain.Object, len(objects))
+	copy(sorted, objects)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+
+	var entries []*domain.Object
+	var prefixes []string
+	last := ""
+
+	for _, obj := range sorted {
+		if !strings.HasPrefix(obj.Key, prefix) || obj.Key <= marker {
+			continue
+		}
+		// A marker that is a common prefix covers every key beneath it
+		if delimiter != "" && strings.HasSuffix(marker, delimiter) && strings.HasPrefix(obj.Key, marker) {
+			continue
+		}
+
+		item := obj.Key
+		isPrefix := false
+		if delimiter != "" {
+			if idx := strings.Index(obj.Key[len(prefix):], delimiter); idx >= 0 {
+				item = obj.Key[:len(prefix)+idx+len(delimiter)]
+				isPrefix = true
+			}
+		}
+		if isPrefix && item == last {
+			continue
+		}
+
+		if len(entries)+len(prefixes) >= maxKeys {
+			return entries, prefixes, last
+		}
+
+		if isPrefix {
+			prefixes = append(prefixes, item)
+		} else {
+			entries = append(entries, obj)
+		}
+		last = item
+	}
+	return entries, prefixes, ""
+}
+
+// PostBucket handles POST /s3/:bucket?delete (DeleteObjects).
+func (h *S3Handler) PostBucket(c *gin.Context) {
+	if _, ok := c.GetQuery("delete"); !ok {
+		httputil.S3Error(c, http.StatusNotImplemented, "NotImplemented", "the requested bucket operation is not supported")
+		return
+	}
+	if httputil.RequireS3Permission("storage", httputil.ActionDelete)(c); c.IsAborted() {
+		return
+	}
+
+	var req deleteObjectsRequest
+	if err := xml.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+		httputil.S3Error(c, http.StatusBadRequest, "MalformedXML", "the XML you provided was not well-formed")
+		return
+	}
+
+	bucket := c.Param("bucket")
+	res := deleteResult{Xmlns: s3XMLNamespace}
+	for _, o := range req.Objects {
+		if !validObjectKey(o.Key) {
+			res.Errors = append(res.Errors, s3DeleteError{Key: o.Key, Code: "InvalidArgument", Message: invalidKeyMessage})
+			continue
+		}
+		err := h.svc.DeleteObject(c.Request.Context(), bucket, o.Key)
+		if err != nil && !errors.Is(err, errors.ObjectNotFound) {
+			res.Errors = append(res.Errors, s3DeleteError{Key: o.Key, Code: "InternalError", Message: "failed to delete object"})
+			continue
+		}
+		if !req.Quiet {
+			res.Deleted = append(res.Deleted, s3DeletedObject{Key: o.Key})
+		}
+	}
+	c.XML(http.StatusOK, res)
+}
+
+// PutObject handles PUT /s3/:bucket/*key for PutObject, CopyObject and UploadPart.
+func (h *S3Handler) PutObject(c *gin.Context) {
+	bucket, key, ok := objectParams(c)
+	if !ok {
+		return
+	}
+	if key == "" {
+		h.CreateBucket(c)
+		return
+	}
+
+	if uploadIDStr := c.Query("uploadId"); uploadIDStr != "" {
+		h.uploadPart(c, bucket, key, uploadIDStr)
+		return
+	}
+
+	if source := c.GetHeader("X-Amz-Copy-Source"); source != "" {
+		h.copyObject(c, bucket, key, source)
+		return
+	}
+
+	opts, err := objectOptionsFromRequest(c, s3HeaderPrefix)
+	if err != nil {
+		s3Error(c, err)
+		return
+	}
+
+	obj, err := h.svc.Upload(c.Request.Context(), bucket, key, c.Request.Body, opts)
+	if err != nil {
+		s3Error(c, err)
+		return
+	}
+	c.Header("ETag", objectETag(obj))
+	setEncryptionHeaders(c, obj, s3HeaderPrefix)
+	c.Status(http.StatusOK)
+}
+
+func (h *S3Handler) uploadPart(c *gin.Context, bucket, key, uploadIDStr string) {
+	uploadID, err := uuid.Parse(uploadIDStr)
+	if err != nil {
+		httputil.S3Error(c, http.StatusNotFound, "NoSuchUpload", "the specified upload does not exist")
+		return
+	}
+	partNumber, err := strconv.Atoi(c.Query("partNumber"))
+	if err != nil {
+		httputil.S3Error(c, http.StatusBadRequest, "InvalidArgument", "invalid partNumber")
+		return
+	}
+
+	part, err := h.svc.UploadPart(c.Request.Context(), bucket, key, uploadID, partNumber, c.Request.Body)
+	if err != nil {
+		s3UploadError(c, err)
+		return
+	}
+	c.Header("ETag", `"`+part.ETag+`"`)
+	c.Status(http.StatusOK)
+}
+
+func (h *S3Handler) copyObject(c *gin.Context, bucket, key, source string) {
+	source, err := url.PathUnescape(strings.TrimPrefix(source, "/"))
+	if err != nil {
+		httputil.S3Error(c, http.StatusBadRequest, "InvalidArgument", "invalid copy source")
+		return
+	}
+	srcBucket, srcKey, ok := strings.Cut(source, "/")
+	if !ok || srcKey == "" || !validObjectKey(srcKey) {
+		httputil.S3Error(c, http.StatusBadRequest, "InvalidArgument", "invalid copy source")
+		return
+	}
+
+	readOpts, err := readOptionsFromRequest(c, s3HeaderPrefix+copySourceHeader)
+	if err != nil {
+		s3Error(c, err)
+		return
+	}
+	opts, err := objectOptionsFromRequest(c, s3HeaderPrefix)
+	if err != nil {
+		s3Error(c, err)
+		return
+	}
+
+	reader, src, err := h.svc.Download(c.Request.Context(), srcBucket, srcKey, readOpts)
+	if err != nil {
+		s3Error(c, err)
+		return
+	}
+	defer reader.Close()
+
+	// The copy keeps the source attributes unless the client asks to replace them.
+	// Encryption of the copy always follows the request.
+	if !strings.EqualFold(c.GetHeader("X-Amz-Metadata-Directive"), "REPLACE") {
+		opts.ContentType, opts.Metadata = src.ContentType, src.Metadata
+	}
+
+	obj, err := h.svc.Upload(c.Request.Context(), bucket, key, reader, opts)
+	if err != nil {
+		s3Error(c, err)
+		return
+	}
+	c.XML(http.StatusOK, copyObjectResult{
+		LastModified: obj.CreatedAt.UTC().Format(s3ListTimestamp),
+		ETag:         objectETag(obj),
+	})
+}
+
+// GetObject handles GET /s3/:bucket/*key for GetObject (with Range) and ListParts.
+func (h *S3Handler) GetObject(c *gin.Context) {
+	bucket, key, ok := objectParams(c)
+	if !ok {
+		return
+	}
+	if key == "" {
+		h.ListObjects(c)
+		return
+	}
+
+	if uploadIDStr := c.Query("uploadId"); uploadIDStr != "" {
+		h.listParts(c, bucket, key, uploadIDStr)
+		return
+	}
+
+	h.serveObject(c, bucket, key, true)
+}
+
+// HeadObject handles HEAD /s3/:bucket/*key
+func (h *S3Handler) HeadObject(c *gin.Context) {
+	bucket, key, ok := objectParams(c)
+	if !ok {
+		return
+	}
+	if key == "" {
+		h.HeadBucket(c)
+		return
+	}
+	h.serveObject(c, bucket, key, false)
+}
+
+func (h *S3Handler) serveObject(c *gin.Context, bucket, key string, withBody bool) {
+	opts, err := readOptionsFromRequest(c, s3HeaderPrefix)
+	if err != nil {
+		if !withBody {
+			c.Status(s3Status(err))
+			return
+		}
+		s3Error(c, err)
+		return
+	}
+
+	reader, obj, err := h.svc.Download(c.Request.Context(), bucket, key, opts)
+	if err != nil {
+		if !withBody {
+			// HEAD responses carry no body, only the status
+			c.Status(s3Status(err))
+			return
+		}
+		s3Error(c, err)
+		return
+	}
+	defer reader.Close()
+
+	setObjectHeaders(c, obj, s3HeaderPrefix)
+	status, start, length := resolveObjectRead(c, obj)
+	switch status {
+	case http.StatusNotModified:
+		c.Status(status)
+		return
+	case http.StatusRequestedRangeNotSatisfiable:
+		if !withBody {
+			c.Status(status)
+			return
+		}
+		httputil.S3Error(c, status, "InvalidRange", "the requested range is not satisfiable")
+		return
+	}
+	writeObject(c, status, reader, start, length, withBody)
+}
+
+func (h *S3Handler) listParts(c *gin.Context, bucket, key, uploadIDStr string) {
+	uploadID, err := uuid.Parse(uploadIDStr)
+	if err != nil {
+		httputil.S3Error(c, http.StatusNotFound, "NoSuchUpload", "the specified upload does not exist")
+		return
+	}
+
+	parts, err := h.svc.ListParts(c.Request.Context(), bucket, key, uploadID)
+	if err != nil {
+		s3UploadError(c, err)
+		return
+	}
+
+	res := listPartsResult{Xmlns: s3XMLNamespace, Bucket: bucket, Key: key, UploadID: uploadIDStr}
+	for _, p := range parts {
+		res.Parts = append(res.Parts, s3Part{
+			PartNumber:   p.PartNumber,
+			LastModified: p.CreatedAt.UTC().Format(s3ListTimestamp),
+			ETag:         `"` + p.ETag + `"`,
+			Size:         p.SizeBytes,
+		})
+	}
+	c.XML(http.StatusOK, res)
+}
+
+// DeleteObject handles DELETE /s3/:bucket/*key for DeleteObject and AbortMultipartUpload.
+func (h *S3Handler) DeleteObject(c *gin.Context) {
+	bucket, key, ok := objectParams(c)
+	if !ok {
+		return
+	}
+	if key == "" {
+		h.DeleteBucket(c)
+		return
+	}
+
+	if uploadIDStr := c.Query("uploadId"); uploadIDStr != "" {
+		uploadID, err := uuid.Parse(uploadIDStr)
+		if err != nil {
+			httputil.S3Error(c, http.StatusNotFound, "NoSuchUpload", "the specified upload does not exist")
+			return
+		}
+		if err := h.svc.AbortMultipartUpload(c.Request.Context(), bucket, key, uploadID); err != nil {
+			s3UploadError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	// S3 reports success for keys that do not exist
+	if err := h.svc.DeleteObject(c.Request.Context(), bucket, key); err != nil && !errors.Is(err, errors.ObjectNotFound) {
+		s3Error(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// PostObject handles POST /s3/:bucket/*key for CreateMultipartUpload (?uploads)
+// and CompleteMultipartUpload (?uploadId). Completion assembles the parts listed
+// in the request body, which must match uploaded parts and be in order.
+func (h *S3Handler) PostObject(c *gin.Context) {
+	bucket, key, ok := objectParams(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := c.GetQuery("uploads"); ok {
+		opts, err := objectOptionsFromRequest(c, s3HeaderPrefix)
+		if err != nil {
+			s3Error(c, err)
+			return
+		}
+		upload, err := h.svc.InitiateMultipartUpload(c.Request.Context(), bucket, key, opts)
+		if err != nil {
+			s3Error(c, err)
+			return
+		}
+		c.XML(http.StatusOK, initiateMultipartUploadResult{
+			Xmlns:    s3XMLNamespace,
+			Bucket:   bucket,
+			Key:      key,
+			UploadID: upload.ID.String(),
+		})
+		return
+	}
+
+	uploadIDStr := c.Query("uploadId")
+	if uploadIDStr == "" {
+		httputil.S3Error(c, http.StatusNotImplemented, "NotImplemented", "the requested object operation is not supported")
+		return
+	}
+	uploadID, err := uuid.Parse(uploadIDStr)
+	if err != nil {
+		httputil.S3Error(c, http.StatusNotFound, "NoSuchUpload", "the specified upload does not exist")
+		return
+	}
+
+	var req completeMultipartUploadRequest
+	if err := xml.NewDecoder(c.Request.Body).Decode(&req); err != nil || len(req.Parts) == 0 {
+		httputil.S3Error(c, http.StatusBadRequest, "MalformedXML", "the XML you provided was not well-formed")
+		return
+	}
+	parts := make([]domain.CompletedPart, 0, len(req.Parts))
+	for _, p := range req.Parts {
+		parts = append(parts, domain.CompletedPart{PartNumber: p.PartNumber, ETag: p.ETag})
+	}
+
+	obj, err := h.svc.CompleteMultipartUpload(c.Request.Context(), bucket, key, uploadID, parts)
+	if err != nil {
+		s3UploadError(c, err)
+		return
+	}
+	c.XML(http.StatusOK, completeMultipartUploadResult{
+		Xmlns:    s3XMLNamespace,
+		Location: "/" + bucket + "/" + key,
+		Bucket:   bucket,
+		Key:      key,
+		ETag:     objectETag(obj),
+	})
+}
+
+func objectKey(c *gin.Context) string {
+	return strings.TrimPrefix(c.Param("key"), "/")
+}
+
+const invalidKeyMessage = "object keys cannot start with / or contain .. segments"
+
+// objectParams returns the bucket and key of an object route. Keys that could
+// resolve outside the bucket in the file store are answered with
+// InvalidArgument, in which case ok is false.
+func objectParams(c *gin.Context) (bucket, key string, ok bool) {
+	bucket, key = c.Param("bucket"), objectKey(c)
+	if !validObjectKey(key) {
+		httputil.S3Error(c, http.StatusBadRequest, "InvalidArgument", invalidKeyMessage)
+		return "", "", false
+	}
+	return bucket, key, true
+}
+
+func validObjectKey(key string) bool {
+	if strings.HasPrefix(key, "/") {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func s3Status(err error) int {
+	switch {
+	case errors.Is(err, errors.ObjectNotFound), errors.Is(err, errors.NotFound), errors.Is(err, errors.BucketNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errors.InvalidInput), errors.Is(err, errors.InvalidPart), errors.Is(err, errors.InvalidPartOrder):
+		return http.StatusBadRequest
+	case errors.Is(err, errors.Unauthorized), errors.Is(err, errors.Forbidden), stderrors.Is(err, httputil.ErrPayloadSignature):
+		return http.StatusForbidden
+	case errors.Is(err, errors.ObjectTooLarge):
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusInternalServerError
+}
+
+func s3Error(c *gin.Context, err error) {
+	status := s3Status(err)
+	code := "InternalError"
+	message := "we encountered an internal error, please try again"
+
+	switch {
+	case errors.Is(err, errors.BucketNotFound):
+		code, message = "NoSuchBucket", "the specified bucket does not exist"
+	case errors.Is(err, errors.InvalidPart):
+		code, message = "InvalidPart", err.(errors.Error).Message
+	case errors.Is(err, errors.InvalidPartOrder):
+		code, message = "InvalidPartOrder", err.(errors.Error).Message
+	case stderrors.Is(err, httputil.ErrPayloadSignature):
+		code, message = "SignatureDoesNotMatch", "the request body does not match its signature"
+	case status == http.StatusNotFound:
+		code, message = "NoSuchKey", "the specified key does not exist"
+	case status == http.StatusBadRequest:
+		code, message = "InvalidArgument", err.(errors.Error).Message
+	case status == http.StatusForbidden:
+		code, message = "AccessDenied", "access denied"
+	case status == http.StatusRequestEntityTooLarge:
+		code, message = "EntityTooLarge", "your proposed upload exceeds the maximum allowed size"
+	}
+	httputil.S3Error(c, status, code, message)
+}
+
+// s3UploadError maps errors from multipart operations, where a missing resource is the upload.
+func s3UploadError(c *gin.Context, err error) {
+	if errors.Is(err, errors.NotFound) {
+		httputil.S3Error(c, http.StatusNotFound, "NoSuchUpload", "the specified upload does not exist")
+		return
+	}
+	s3Error(c, err)
+}
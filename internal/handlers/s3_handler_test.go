@@ -0,0 +1,414 @@
+package httphandlers
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type storageServiceMock struct {
+	mock.Mock
+}
+
+func (m *storageServiceMock) Upload(ctx context.Context, bucket, key string, r io.Reader, opts domain.ObjectOptions) (*domain.Object, error) {
+	args := m.Called(ctx, bucket, key, r, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Object), args.Error(1)
+}
+
+func (m *storageServiceMock) Download(ctx context.Context, bucket, key string, opts domain.ReadOptions) (io.ReadCloser, *domain.Object, error) {
+	args := m.Called(ctx, bucket, key, opts)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(io.ReadCloser), args.Get(1).(*domain.Object), args.Error(2)
+}
+
+func (m *storageServiceMock) ListObjects(ctx context.Context, bucket string) ([]*domain.Object, error) {
+	args := m.Called(ctx, bucket)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Object), args.Error(1)
+}
+
+func (m *storageServiceMock) DeleteObject(ctx context.Context, bucket, key string) error {
+	args := m.Called(ctx, bucket, key)
+	return args.Error(0)
+}
+
+func (m *storageServiceMock) ListBuckets(ctx context.Context) ([]*domain.Bucket, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Bucket), args.Error(1)
+}
+
+func (m *storageServiceMock) InitiateMultipartUpload(ctx context.Context, bucket, key string, opts domain.ObjectOptions) (*domain.MultipartUpload, error) {
+	args := m.Called(ctx, bucket, key, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.MultipartUpload), args.Error(1)
+}
+
+func (m *storageServiceMock) UploadPart(ctx context.Context, bucket, key string, uploadID uuid.UUID, partNumber int, r io.Reader) (*domain.Part, error) {
+	args := m.Called(ctx, bucket, key, uploadID, partNumber, r)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Part), args.Error(1)
+}
+
+func (m *storageServiceMock) ListParts(ctx context.Context, bucket, key string, uploadID uuid.UUID) ([]*domain.Part, error) {
+	args := m.Called(ctx, bucket, key, uploadID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Part), args.Error(1)
+}
+
+func (m *storageServiceMock) CompleteMultipartUpload(ctx context.Context, bucket, key string, uploadID uuid.UUID, parts []domain.CompletedPart) (*domain.Object, error) {
+	args := m.Called(ctx, bucket, key, uploadID, parts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Object), args.Error(1)
+}
+
+func (m *storageServiceMock) AbortMultipartUpload(ctx context.Context, bucket, key string, uploadID uuid.UUID) error {
+	args := m.Called(ctx, bucket, key, uploadID)
+	return args.Error(0)
+}
+
+func (m *storageServiceMock) PresignURL(ctx context.Context, bucket, key, method string, expiresIn time.Duration, maxContentLength int64) (*domain.PresignedURL, error) {
+	args := m.Called(ctx, bucket, key, method, expiresIn, maxContentLength)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PresignedURL), args.Error(1)
+}
+
+func (m *storageServiceMock) VerifyPresignedURL(ctx context.Context, method, bucket, key string, query url.Values) (*domain.PresignedURL, error) {
+	args := m.Called(ctx, method, bucket, key, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PresignedURL), args.Error(1)
+}
+
+func (m *storageServiceMock) CreateLifecycleRule(ctx context.Context, bucket string, rule domain.LifecycleRule) (*domain.LifecycleRule, error) {
+	args := m.Called(ctx, bucket, rule)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LifecycleRule), args.Error(1)
+}
+
+func (m *storageServiceMock) ListLifecycleRules(ctx context.Context, bucket string) ([]*domain.LifecycleRule, error) {
+	args := m.Called(ctx, bucket)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.LifecycleRule), args.Error(1)
+}
+
+func (m *storageServiceMock) DeleteLifecycleRule(ctx context.Context, bucket string, id uuid.UUID) error {
+	args := m.Called(ctx, bucket, id)
+	return args.Error(0)
+}
+
+func (m *storageServiceMock) PutBucketEncryption(ctx context.Context, bucket, algorithm string) (*domain.BucketEncryption, error) {
+	args := m.Called(ctx, bucket, algorithm)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BucketEncryption), args.Error(1)
+}
+
+func (m *storageServiceMock) GetBucketEncryption(ctx context.Context, bucket string) (*domain.BucketEncryption, error) {
+	args := m.Called(ctx, bucket)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BucketEncryption), args.Error(1)
+}
+
+func (m *storageServiceMock) DeleteBucketEncryption(ctx context.Context, bucket string) error {
+	args := m.Called(ctx, bucket)
+	return args.Error(0)
+}
+
+func (m *storageServiceMock) PutBucketWebsite(ctx context.Context, bucket string, cfg domain.BucketWebsite) (*domain.BucketWebsite, error) {
+	args := m.Called(ctx, bucket, cfg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BucketWebsite), args.Error(1)
+}
+
+func (m *storageServiceMock) GetBucketWebsite(ctx context.Context, bucket string) (*domain.BucketWebsite, error) {
+	args := m.Called(ctx, bucket)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BucketWebsite), args.Error(1)
+}
+
+func (m *storageServiceMock) DeleteBucketWebsite(ctx context.Context, bucket string) error {
+	args := m.Called(ctx, bucket)
+	return args.Error(0)
+}
+
+func (m *storageServiceMock) ResolveWebsite(ctx context.Context, bucket string) (*domain.BucketWebsite, error) {
+	args := m.Called(ctx, bucket)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BucketWebsite), args.Error(1)
+}
+
+func setupS3Router(svc *storageServiceMock) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewS3Handler(svc)
+	r := gin.New()
+	g := r.Group("/s3")
+	g.HEAD("/:bucket", h.HeadBucket)
+	g.GET("/:bucket", h.ListObjects)
+	g.PUT("/:bucket/*key", h.PutObject)
+	g.POST("/:bucket/*key", h.PostObject)
+	g.GET("/:bucket/*key", h.GetObject)
+	g.HEAD("/:bucket/*key", h.HeadObject)
+	g.DELETE("/:bucket/*key", h.DeleteObject)
+	return r
+}
+
+func TestS3Handler_GetObjectRange(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupS3Router(svc)
+
+	obj := &domain.Object{ID: uuid.New(), Bucket: "b", Key: "dir/file.txt", SizeBytes: 11, ContentType: "text/plain"}
+	svc.On("Download", mock.Anything, "b", "dir/file.txt", mock.Anything).Return(io.NopCloser(strings.NewReader("hello world")), obj, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/s3/b/dir/file.txt", nil)
+	req.Header.Set("Range", "bytes=6-")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "world", w.Body.String())
+	assert.Equal(t, "bytes 6-10/11", w.Header().Get("Content-Range"))
+	assert.Equal(t, "5", w.Header().Get("Content-Length"))
+}
+
+func TestS3Handler_GetObjectNotFound(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupS3Router(svc)
+
+	svc.On("Download", mock.Anything, "b", "missing", mock.Anything).Return(nil, nil, errors.New(errors.ObjectNotFound, "object metadata not found"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/s3/b/missing", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	var res struct {
+		Code string `xml:"Code"`
+	}
+	assert.NoError(t, xml.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, "NoSuchKey", res.Code)
+}
+
+func TestS3Handler_HeadObject(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupS3Router(svc)
+
+	obj := &domain.Object{ID: uuid.New(), Bucket: "b", Key: "k", SizeBytes: 4, ContentType: "application/octet-stream"}
+	svc.On("Download", mock.Anything, "b", "k", mock.Anything).Return(io.NopCloser(strings.NewReader("data")), obj, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/s3/b/k", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "4", w.Header().Get("Content-Length"))
+	assert.Empty(t, w.Body.String())
+}
+
+func TestS3Handler_DeleteMissingObjectSucceeds(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupS3Router(svc)
+
+	svc.On("DeleteObject", mock.Anything, "b", "gone").Return(errors.New(errors.ObjectNotFound, "object not found or already deleted"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/s3/b/gone", nil))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestS3Handler_RejectsKeysOutsideBucket(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupS3Router(svc)
+
+	for _, path := range []string{"/s3/b/a/../../etc/passwd", "/s3/b/..", "/s3/b//etc/passwd"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, path, strings.NewReader("x")))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+		assert.Contains(t, w.Body.String(), "InvalidArgument", path)
+	}
+	svc.AssertNotCalled(t, "Upload", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestS3Handler_HeadBucket(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupS3Router(svc)
+	svc.On("ListBuckets", mock.Anything).Return([]*domain.Bucket{{Name: "b"}}, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/s3/b", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/s3/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestS3Handler_CompleteMultipartUpload(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupS3Router(svc)
+
+	uploadID := uuid.New()
+	parts := []domain.CompletedPart{{PartNumber: 1, ETag: `"e1"`}, {PartNumber: 2, ETag: `"e2"`}}
+	svc.On("CompleteMultipartUpload", mock.Anything, "b", "k", uploadID, parts).Return(&domain.Object{Bucket: "b", Key: "k", ETag: "abc"}, nil)
+
+	body := `<CompleteMultipartUpload>
+  <Part><PartNumber>1</PartNumber><ETag>"e1"</ETag></Part>
+  <Part><PartNumber>2</PartNumber><ETag>"e2"</ETag></Part>
+</CompleteMultipartUpload>`
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/s3/b/k?uploadId="+uploadID.String(), strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<CompleteMultipartUploadResult")
+	svc.AssertExpectations(t)
+}
+
+func TestS3Handler_CompleteMultipartUploadErrors(t *testing.T) {
+	uploadID := uuid.New()
+	body := `<CompleteMultipartUpload><Part><PartNumber>2</PartNumber><ETag>"e2"</ETag></Part></CompleteMultipartUpload>`
+
+	tests := []struct {
+		name string
+		body string
+		err  error
+		code string
+	}{
+		{"malformed", "<CompleteMultipartUpload>", nil, "MalformedXML"},
+		{"no parts", "<CompleteMultipartUpload></CompleteMultipartUpload>", nil, "MalformedXML"},
+		{"invalid part", body, errors.New(errors.InvalidPart, "part 2 was not uploaded or its ETag does not match"), "InvalidPart"},
+		{"invalid order", body, errors.New(errors.InvalidPartOrder, "parts must be listed in ascending part number order"), "InvalidPartOrder"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(storageServiceMock)
+			r := setupS3Router(svc)
+			if tt.err != nil {
+				svc.On("CompleteMultipartUpload", mock.Anything, "b", "k", uploadID, mock.Anything).Return(nil, tt.err)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/s3/b/k?uploadId="+uploadID.String(), strings.NewReader(tt.body)))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var res struct {
+				Code string `xml:"Code"`
+			}
+			assert.NoError(t, xml.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, tt.code, res.Code)
+		})
+	}
+}
+
+func TestS3Handler_ListObjectsV2(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupS3Router(svc)
+
+	svc.On("ListObjects", mock.Anything, "b").Return([]*domain.Object{
+		{ID: uuid.New(), Key: "photos/2024/a.jpg"},
+		{ID: uuid.New(), Key: "photos/2025/b.jpg"},
+		{ID: uuid.New(), Key: "photos/c.jpg"},
+		{ID: uuid.New(), Key: "readme.txt"},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/s3/b?list-type=2&prefix=photos/&delimiter=/", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var res listBucketResult
+	assert.NoError(t, xml.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, 3, res.KeyCount)
+	assert.Len(t, res.Contents, 1)
+	assert.Equal(t, "photos/c.jpg", res.Contents[0].Key)
+	assert.Equal(t, []s3CommonPrefix{{Prefix: "photos/2024/"}, {Prefix: "photos/2025/"}}, res.CommonPrefixes)
+}
+
+func TestPaginateObjects(t *testing.T) {
+	objects := []*domain.Object{{Key: "c"}, {Key: "a"}, {Key: "d/1"}, {Key: "d/2"}, {Key: "b"}}
+
+	entries, prefixes, next := paginateObjects(objects, "", "/", "", 2)
+	assert.Equal(t, "a", entries[0].Key)
+	assert.Equal(t, "b", entries[1].Key)
+	assert.Empty(t, prefixes)
+	assert.Equal(t, "b", next)
+
+	entries, prefixes, next = paginateObjects(objects, "", "/", next, 2)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "c", entries[0].Key)
+	assert.Equal(t, []string{"d/"}, prefixes)
+	assert.Empty(t, next)
+
+	entries, prefixes, _ = paginateObjects(objects, "", "/", "d/", 10)
+	assert.Empty(t, entries)
+	assert.Empty(t, prefixes)
+}
+
+func TestParseByteRange(t *testing.T) {
+	tests := []struct {
+		header     string
+		start, end int64
+		ok         bool
+	}{
+		{"bytes=0-9", 0, 9, true},
+		{"bytes=5-", 5, 99, true},
+		{"bytes=-10", 90, 99, true},
+		{"bytes=90-200", 90, 99, true},
+		{"bytes=100-", 0, 0, false},
+		{"bytes=5-1", 0, 0, false},
+		{"bytes=0-1,5-6", 0, 0, false},
+		{"items=0-1", 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, end, ok := parseByteRange(tt.header, 100)
+		assert.Equal(t, tt.ok, ok, tt.header)
+		if tt.ok {
+			assert.Equal(t, tt.start, start, tt.header)
+			assert.Equal(t, tt.end, end, tt.header)
+		}
+	}
+}
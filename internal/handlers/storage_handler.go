@@ -2,10 +2,14 @@ package httphandlers
 
 import (
 	"fmt"
-	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
 	"github.com/poyrazk/thecloud/internal/core/ports"
 	"github.com/poyrazk/thecloud/internal/errors"
 	"github.com/poyrazk/thecloud/pkg/httputil"
@@ -31,6 +35,8 @@ func NewStorageHandler(svc ports.StorageService) *StorageHandler {
 // @Param bucket path string true "Bucket name"
 // @Param key path string true "Object key"
 // @Param file formData file true "File to upload"
+// @Param Content-Type header string false "Content type; detected from the key or content when omitted"
+// @Param X-Cloud-Meta-* header string false "User metadata stored with the object"
 // @Success 201 {object} domain.Object
 // @Failure 400 {object} httputil.Response
 // @Router /storage/{bucket}/{key} [put]
@@ -43,8 +49,14 @@ func (h *StorageHandler) Upload(c *gin.Context) {
 		return
 	}
 
+	opts, err := objectOptionsFromRequest(c, cloudHeaderPrefix)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
 	// Read from request body (stream)
-	obj, err := h.svc.Upload(c.Request.Context(), bucket, key, c.Request.Body)
+	obj, err := h.svc.Upload(c.Request.Context(), bucket, key, c.Request.Body, opts)
 	if err != nil {
 		httputil.Error(c, err)
 		return
@@ -55,20 +67,49 @@ func (h *StorageHandler) Upload(c *gin.Context) {
 
 // Download downloads an object from a bucket
 // @Summary Download an object
-// @Description Streams the specified object as an attachment
+// @Description Streams the specified object as an attachment. Supports Range and If-None-Match.
 // @Tags storage
 // @Produce octet-stream
 // @Security ApiKeyAuth
 // @Param bucket path string true "Bucket name"
 // @Param key path string true "Object key"
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {file} file "Object content"
+// @Success 206 {file} file "Partial object content"
+// @Success 304 "Not modified"
 // @Failure 404 {object} httputil.Response
+// @Failure 416 {object} httputil.Response
 // @Router /storage/{bucket}/{key} [get]
 func (h *StorageHandler) Download(c *gin.Context) {
+	h.serveObject(c, true)
+}
+
+// Head returns object metadata
+// @Summary Get object metadata
+// @Description Returns the headers of a download (size, content type, ETag, user metadata) without the body
+// @Tags storage
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Param key path string true "Object key"
+// @Success 200 "Object headers"
+// @Failure 404 "Object not found"
+// @Router /storage/{bucket}/{key} [head]
+func (h *StorageHandler) Head(c *gin.Context) {
+	h.serveObject(c, false)
+}
+
+func (h *StorageHandler) serveObject(c *gin.Context, withBody bool) {
 	bucket := c.Param("bucket")
 	key := c.Param("key")
 
-	reader, obj, err := h.svc.Download(c.Request.Context(), bucket, key)
+	opts, err := readOptionsFromRequest(c, cloudHeaderPrefix)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	reader, obj, err := h.svc.Download(c.Request.Context(), bucket, key, opts)
 	if err != nil {
 		httputil.Error(c, err)
 		return
@@ -77,11 +118,23 @@ func (h *StorageHandler) Download(c *gin.Context) {
 
 	// Set headers
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", key))
-	c.Header("Content-Type", obj.ContentType)
-	c.Header("Content-Length", fmt.Sprintf("%d", obj.SizeBytes))
+	if obj.SHA256 != "" {
+		c.Header("X-Cloud-Checksum-Sha256", obj.SHA256)
+	}
+	setObjectHeaders(c, obj, cloudHeaderPrefix)
+
+	status, start, length := resolveObjectRead(c, obj)
+	switch status {
+	case http.StatusNotModified:
+		c.Status(status)
+		return
+	case http.StatusRequestedRangeNotSatisfiable:
+		httputil.Error(c, errors.New(errors.InvalidRange, "the requested range is not satisfiable"))
+		return
+	}
 
 	// Stream file to client
-	_, _ = io.Copy(c.Writer, reader)
+	writeObject(c, status, reader, start, length, withBody)
 }
 
 // List returns objects in a bucket
@@ -128,3 +181,465 @@ func (h *StorageHandler) Delete(c *gin.Context) {
 
 	httputil.Success(c, http.StatusNoContent, nil)
 }
+
+type PresignRequest struct {
+	Method           string `json:"method"`
+	ExpiresIn        int    `json:"expires_in"` // seconds, defaults to 15 minutes
+	MaxContentLength int64  `json:"max_content_length"`
+}
+
+// Presign creates a pre-signed URL for an object
+// @Summary Create a pre-signed URL
+// @Description Mints a temporary URL that allows a GET or PUT of the object without an API key
+// @Tags storage
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Param key path string true "Object key"
+// @Param request body PresignRequest false "Presign options"
+// @Success 201 {object} domain.PresignedURL
+// @Failure 400 {object} httputil.Response
+// @Router /storage/{bucket}/{key}/presign [post]
+func (h *StorageHandler) Presign(c *gin.Context) {
+	var req PresignRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			httputil.Error(c, errors.New(errors.InvalidInput, "invalid request body"))
+			return
+		}
+	}
+
+	// The route needs read access; an upload URL also needs the access an
+	// upload does.
+	if strings.EqualFold(req.Method, http.MethodPut) {
+		if err := httputil.CheckPermission(c, "storage", httputil.ActionCreate); err != nil {
+			httputil.Error(c, err)
+			return
+		}
+	}
+
+	p, err := h.svc.PresignURL(c.Request.Context(), c.Param("bucket"), c.Param("key"), req.Method, time.Duration(req.ExpiresIn)*time.Second, req.MaxContentLength)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	// The service returns a path; make it absolute so it can be handed out as-is
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	p.URL = fmt.Sprintf("%s://%s%s", scheme, c.Request.Host, p.URL)
+
+	httputil.Success(c, http.StatusCreated, p)
+}
+
+// InitiateMultipartUpload starts a multipart upload
+// @Summary Initiate a multipart upload
+// @Description Starts a multipart upload for the specified bucket and key
+// @Tags storage
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Param key path string true "Object key"
+// @Success 201 {object} domain.MultipartUpload
+// @Failure 400 {object} httputil.Response
+// @Router /storage/{bucket}/{key}/uploads [post]
+func (h *StorageHandler) InitiateMultipartUpload(c *gin.Context) {
+	bucket := c.Param("bucket")
+	key := c.Param("key")
+
+	opts, err := objectOptionsFromRequest(c, cloudHeaderPrefix)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	upload, err := h.svc.InitiateMultipartUpload(c.Request.Context(), bucket, key, opts)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusCreated, upload)
+}
+
+// UploadPart uploads a single part of a multipart upload
+// @Summary Upload a part
+// @Description Streams one part of a multipart upload. Re-uploading a part number replaces it.
+// @Tags storage
+// @Accept octet-stream
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Param key path string true "Object key"
+// @Param id path string true "Upload ID"
+// @Param part path int true "Part number (1-10000)"
+// @Success 200 {object} domain.Part
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Router /storage/{bucket}/{key}/uploads/{id}/parts/{part} [put]
+func (h *StorageHandler) UploadPart(c *gin.Context) {
+	bucket := c.Param("bucket")
+	key := c.Param("key")
+	uploadID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid upload id format"))
+		return
+	}
+
+	partNumber, err := strconv.Atoi(c.Param("part"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid part number"))
+		return
+	}
+
+	part, err := h.svc.UploadPart(c.Request.Context(), bucket, key, uploadID, partNumber, c.Request.Body)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusOK, part)
+}
+
+// ListParts returns the parts uploaded so far
+// @Summary List uploaded parts
+// @Description Lists the parts already stored for a multipart upload, used to resume an interrupted upload
+// @Tags storage
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Param key path string true "Object key"
+// @Param id path string true "Upload ID"
+// @Success 200 {array} domain.Part
+// @Failure 404 {object} httputil.Response
+// @Router /storage/{bucket}/{key}/uploads/{id}/parts [get]
+func (h *StorageHandler) ListParts(c *gin.Context) {
+	bucket := c.Param("bucket")
+	key := c.Param("key")
+	uploadID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid upload id format"))
+		return
+	}
+
+	parts, err := h.svc.ListParts(c.Request.Context(), bucket, key, uploadID)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusOK, parts)
+}
+
+type CompleteMultipartUploadRequest struct {
+	Parts []domain.CompletedPart `json:"parts" binding:"required"`
+}
+
+// CompleteMultipartUpload assembles the uploaded parts into an object
+// @Summary Complete a multipart upload
+// @Description Concatenates the listed parts, in ascending part-number order, into the final object and discards any other uploaded parts
+// @Tags storage
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Param key path string true "Object key"
+// @Param id path string true "Upload ID"
+// @Param request body CompleteMultipartUploadRequest true "Parts with the ETags returned on upload"
+// @Success 201 {object} domain.Object
+// @Failure 400 {object} httputil.Response
+// @Failure 404 {object} httputil.Response
+// @Router /storage/{bucket}/{key}/uploads/{id}/complete [post]
+func (h *StorageHandler) CompleteMultipartUpload(c *gin.Context) {
+	bucket := c.Param("bucket")
+	key := c.Param("key")
+	uploadID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid upload id format"))
+		return
+	}
+
+	var req CompleteMultipartUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid request body"))
+		return
+	}
+
+	obj, err := h.svc.CompleteMultipartUpload(c.Request.Context(), bucket, key, uploadID, req.Parts)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusCreated, obj)
+}
+
+// AbortMultipartUpload discards a multipart upload
+// @Summary Abort a multipart upload
+// @Description Deletes all staged parts of a multipart upload
+// @Tags storage
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Param key path string true "Object key"
+// @Param id path string true "Upload ID"
+// @Success 204
+// @Failure 404 {object} httputil.Response
+// @Router /storage/{bucket}/{key}/uploads/{id} [delete]
+func (h *StorageHandler) AbortMultipartUpload(c *gin.Context) {
+	bucket := c.Param("bucket")
+	key := c.Param("key")
+	uploadID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid upload id format"))
+		return
+	}
+
+	if err := h.svc.AbortMultipartUpload(c.Request.Context(), bucket, key, uploadID); err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusNoContent, nil)
+}
+
+type LifecycleRuleRequest struct {
+	Prefix                    string `json:"prefix"`
+	ExpirationDays            int    `json:"expiration_days"`
+	DeletedRetentionDays      int    `json:"deleted_retention_days"`
+	AbortIncompleteUploadDays int    `json:"abort_incomplete_upload_days"`
+	Enabled                   *bool  `json:"enabled"`
+}
+
+// CreateLifecycleRule adds a lifecycle rule to a bucket
+// @Summary Create a lifecycle rule
+// @Description Expires objects, purges deleted objects and aborts incomplete uploads under a key prefix
+// @Tags storage
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Param request body LifecycleRuleRequest true "Rule"
+// @Success 201 {object} domain.LifecycleRule
+// @Failure 400 {object} httputil.Response
+// @Router /buckets/{bucket}/lifecycle [post]
+func (h *StorageHandler) CreateLifecycleRule(c *gin.Context) {
+	var req LifecycleRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid request body"))
+		return
+	}
+
+	rule := domain.LifecycleRule{
+		Prefix:                    req.Prefix,
+		ExpirationDays:            req.ExpirationDays,
+		DeletedRetentionDays:      req.DeletedRetentionDays,
+		AbortIncompleteUploadDays: req.AbortIncompleteUploadDays,
+		Enabled:                   req.Enabled == nil || *req.Enabled,
+	}
+
+	created, err := h.svc.CreateLifecycleRule(c.Request.Context(), c.Param("bucket"), rule)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusCreated, created)
+}
+
+// ListLifecycleRules lists the lifecycle rules of a bucket
+// @Summary List lifecycle rules
+// @Tags storage
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Success 200 {array} domain.LifecycleRule
+// @Router /buckets/{bucket}/lifecycle [get]
+func (h *StorageHandler) ListLifecycleRules(c *gin.Context) {
+	rules, err := h.svc.ListLifecycleRules(c.Request.Context(), c.Param("bucket"))
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusOK, rules)
+}
+
+// DeleteLifecycleRule removes a lifecycle rule
+// @Summary Delete a lifecycle rule
+// @Tags storage
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Param id path string true "Rule ID"
+// @Success 204
+// @Failure 404 {object} httputil.Response
+// @Router /buckets/{bucket}/lifecycle/{id} [delete]
+func (h *StorageHandler) DeleteLifecycleRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid id format"))
+		return
+	}
+
+	if err := h.svc.DeleteLifecycleRule(c.Request.Context(), c.Param("bucket"), id); err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusNoContent, nil)
+}
+
+type BucketEncryptionRequest struct {
+	Algorithm string `json:"algorithm" binding:"required"`
+}
+
+// PutBucketEncryption sets the default encryption of a bucket
+// @Summary Set bucket default encryption
+// @Description Encrypts new objects uploaded without an explicit encryption mode. Only AES256 is supported.
+// @Tags storage
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Param request body BucketEncryptionRequest true "Encryption"
+// @Success 200 {object} domain.BucketEncryption
+// @Failure 400 {object} httputil.Response
+// @Router /buckets/{bucket}/encryption [put]
+func (h *StorageHandler) PutBucketEncryption(c *gin.Context) {
+	var req BucketEncryptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid request body"))
+		return
+	}
+
+	cfg, err := h.svc.PutBucketEncryption(c.Request.Context(), c.Param("bucket"), req.Algorithm)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusOK, cfg)
+}
+
+// GetBucketEncryption returns the default encryption of a bucket
+// @Summary Get bucket default encryption
+// @Tags storage
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Success 200 {object} domain.BucketEncryption
+// @Failure 404 {object} httputil.Response
+// @Router /buckets/{bucket}/encryption [get]
+func (h *StorageHandler) GetBucketEncryption(c *gin.Context) {
+	cfg, err := h.svc.GetBucketEncryption(c.Request.Context(), c.Param("bucket"))
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusOK, cfg)
+}
+
+// DeleteBucketEncryption removes the default encryption of a bucket
+// @Summary Remove bucket default encryption
+// @Description Existing objects stay encrypted; only new uploads are affected
+// @Tags storage
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Success 204
+// @Failure 404 {object} httputil.Response
+// @Router /buckets/{bucket}/encryption [delete]
+func (h *StorageHandler) DeleteBucketEncryption(c *gin.Context) {
+	if err := h.svc.DeleteBucketEncryption(c.Request.Context(), c.Param("bucket")); err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusNoContent, nil)
+}
+
+type BucketWebsiteRequest struct {
+	IndexDocument string `json:"index_document"`
+	ErrorDocument string `json:"error_document"`
+	CacheMaxAge   *int   `json:"cache_max_age"`
+}
+
+// PutBucketWebsite enables static website hosting for a bucket
+// @Summary Configure a bucket website
+// @Description Serves the bucket publicly on /website/{bucket}/ and on its website hostname
+// @Tags storage
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Param request body BucketWebsiteRequest true "Website"
+// @Success 200 {object} domain.BucketWebsite
+// @Failure 400 {object} httputil.Response
+// @Failure 409 {object} httputil.Response
+// @Router /buckets/{bucket}/website [put]
+func (h *StorageHandler) PutBucketWebsite(c *gin.Context) {
+	var req BucketWebsiteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.Error(c, errors.New(errors.InvalidInput, "invalid request body"))
+		return
+	}
+
+	website := domain.BucketWebsite{
+		IndexDocument: req.IndexDocument,
+		ErrorDocument: req.ErrorDocument,
+		CacheMaxAge:   domain.DefaultWebsiteCacheMaxAge,
+	}
+	if req.CacheMaxAge != nil {
+		website.CacheMaxAge = *req.CacheMaxAge
+	}
+
+	cfg, err := h.svc.PutBucketWebsite(c.Request.Context(), c.Param("bucket"), website)
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusOK, cfg)
+}
+
+// GetBucketWebsite returns the website configuration of a bucket
+// @Summary Get bucket website
+// @Tags storage
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Success 200 {object} domain.BucketWebsite
+// @Failure 404 {object} httputil.Response
+// @Router /buckets/{bucket}/website [get]
+func (h *StorageHandler) GetBucketWebsite(c *gin.Context) {
+	cfg, err := h.svc.GetBucketWebsite(c.Request.Context(), c.Param("bucket"))
+	if err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusOK, cfg)
+}
+
+// DeleteBucketWebsite stops serving a bucket as a website
+// @Summary Remove bucket website
+// @Tags storage
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bucket path string true "Bucket name"
+// @Success 204
+// @Failure 404 {object} httputil.Response
+// @Router /buckets/{bucket}/website [delete]
+func (h *StorageHandler) DeleteBucketWebsite(c *gin.Context) {
+	if err := h.svc.DeleteBucketWebsite(c.Request.Context(), c.Param("bucket")); err != nil {
+		httputil.Error(c, err)
+		return
+	}
+
+	httputil.Success(c, http.StatusNoContent, nil)
+}
@@ -0,0 +1,233 @@
+package httphandlers
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupStorageRouter(svc *storageServiceMock) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewStorageHandler(svc)
+	r := gin.New()
+	r.PUT("/storage/:bucket/:key", h.Upload)
+	r.GET("/storage/:bucket/:key", h.Download)
+	r.HEAD("/storage/:bucket/:key", h.Head)
+	r.POST("/storage/:bucket/:key/uploads/:id/complete", h.CompleteMultipartUpload)
+	return r
+}
+
+func TestStorageHandler_PresignPutNeedsUploadPermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := new(storageServiceMock)
+	h := NewStorageHandler(svc)
+	r := gin.New()
+	r.POST("/storage/:bucket/:key/presign", func(c *gin.Context) {
+		c.Set("userRole", domain.RoleViewer)
+	}, h.Presign)
+
+	req := httptest.NewRequest(http.MethodPost, "/storage/b/k.txt/presign", strings.NewReader(`{"method":"PUT"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	svc.AssertNotCalled(t, "PresignURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// Download URLs only need read access.
+	svc.On("PresignURL", mock.Anything, "b", "k.txt", "GET", time.Duration(0), int64(0)).Return(&domain.PresignedURL{URL: "/storage/b/k.txt?signature=x"}, nil).Once()
+	req = httptest.NewRequest(http.MethodPost, "/storage/b/k.txt/presign", strings.NewReader(`{"method":"GET"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	svc.AssertExpectations(t)
+}
+
+func testObject() *domain.Object {
+	return &domain.Object{
+		ID:          uuid.New(),
+		Bucket:      "b",
+		Key:         "k.txt",
+		SizeBytes:   11,
+		ContentType: "text/plain",
+		ETag:        "5eb63bbbe01eeed093cb22bb8f5acdc3",
+		Metadata:    map[string]string{"owner": "alice"},
+	}
+}
+
+func TestStorageHandler_UploadPassesMetadata(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupStorageRouter(svc)
+
+	expected := domain.ObjectOptions{ContentType: "text/plain", Metadata: map[string]string{"owner": "alice"}}
+	svc.On("Upload", mock.Anything, "b", "k.txt", mock.Anything, expected).Return(testObject(), nil)
+
+	req := httptest.NewRequest(http.MethodPut, "/storage/b/k.txt", strings.NewReader("hello world"))
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("X-Cloud-Meta-Owner", "alice")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	svc.AssertExpectations(t)
+}
+
+func TestStorageHandler_DownloadHeaders(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupStorageRouter(svc)
+
+	svc.On("Download", mock.Anything, "b", "k.txt", mock.Anything).Return(io.NopCloser(strings.NewReader("hello world")), testObject(), nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/storage/b/k.txt", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello world", w.Body.String())
+	assert.Equal(t, `"5eb63bbbe01eeed093cb22bb8f5acdc3"`, w.Header().Get("ETag"))
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, "alice", w.Header().Get("X-Cloud-Meta-Owner"))
+}
+
+func TestStorageHandler_DownloadRange(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupStorageRouter(svc)
+
+	svc.On("Download", mock.Anything, "b", "k.txt", mock.Anything).Return(io.NopCloser(strings.NewReader("hello world")), testObject(), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/storage/b/k.txt", nil)
+	req.Header.Set("Range", "bytes=0-4")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "hello", w.Body.String())
+	assert.Equal(t, "bytes 0-4/11", w.Header().Get("Content-Range"))
+}
+
+func TestStorageHandler_DownloadRangeNotSatisfiable(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupStorageRouter(svc)
+
+	svc.On("Download", mock.Anything, "b", "k.txt", mock.Anything).Return(io.NopCloser(strings.NewReader("hello world")), testObject(), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/storage/b/k.txt", nil)
+	req.Header.Set("Range", "bytes=50-")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+	assert.Equal(t, "bytes */11", w.Header().Get("Content-Range"))
+}
+
+func TestStorageHandler_DownloadNotModified(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupStorageRouter(svc)
+
+	svc.On("Download", mock.Anything, "b", "k.txt", mock.Anything).Return(io.NopCloser(strings.NewReader("hello world")), testObject(), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/storage/b/k.txt", nil)
+	req.Header.Set("If-None-Match", `"other", W/"5eb63bbbe01eeed093cb22bb8f5acdc3"`)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestStorageHandler_Head(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupStorageRouter(svc)
+
+	svc.On("Download", mock.Anything, "b", "k.txt", mock.Anything).Return(io.NopCloser(strings.NewReader("hello world")), testObject(), nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/storage/b/k.txt", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "11", w.Header().Get("Content-Length"))
+	assert.Empty(t, w.Body.String())
+}
+
+func TestStorageHandler_CustomerKey(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupStorageRouter(svc)
+
+	key := bytes.Repeat([]byte{7}, 32)
+	sum := md5.Sum(key)
+	obj := testObject()
+	obj.Encryption = domain.EncryptionCustomer
+	svc.On("Download", mock.Anything, "b", "k.txt", domain.ReadOptions{CustomerKey: key}).Return(io.NopCloser(strings.NewReader("hello world")), obj, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/storage/b/k.txt", nil)
+	req.Header.Set("X-Cloud-Server-Side-Encryption-Customer-Key", base64.StdEncoding.EncodeToString(key))
+	req.Header.Set("X-Cloud-Server-Side-Encryption-Customer-Key-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "AES256", w.Header().Get("X-Cloud-Server-Side-Encryption-Customer-Algorithm"))
+	svc.AssertExpectations(t)
+}
+
+func TestStorageHandler_CustomerKeyMD5Mismatch(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupStorageRouter(svc)
+
+	req := httptest.NewRequest(http.MethodPut, "/storage/b/k.txt", strings.NewReader("hello world"))
+	req.Header.Set("X-Cloud-Server-Side-Encryption-Customer-Key", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+	req.Header.Set("X-Cloud-Server-Side-Encryption-Customer-Key-MD5", "bm90IHRoZSBtZDU=")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	svc.AssertNotCalled(t, "Upload", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestStorageHandler_CompleteMultipartUploadPassesParts(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupStorageRouter(svc)
+
+	uploadID := uuid.New()
+	parts := []domain.CompletedPart{{PartNumber: 1, ETag: "a"}, {PartNumber: 2, ETag: "b"}}
+	svc.On("CompleteMultipartUpload", mock.Anything, "b", "k.txt", uploadID, parts).Return(testObject(), nil)
+
+	body := `{"parts":[{"part_number":1,"etag":"a"},{"part_number":2,"etag":"b"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/storage/b/k.txt/uploads/"+uploadID.String()+"/complete", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	svc.AssertExpectations(t)
+}
+
+func TestStorageHandler_CompleteMultipartUploadInvalidPart(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupStorageRouter(svc)
+
+	uploadID := uuid.New()
+	svc.On("CompleteMultipartUpload", mock.Anything, "b", "k.txt", uploadID, mock.Anything).
+		Return(nil, errors.New(errors.InvalidPart, "part 3 was not uploaded or its ETag does not match"))
+
+	body := `{"parts":[{"part_number":3,"etag":"c"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/storage/b/k.txt/uploads/"+uploadID.String()+"/complete", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_PART")
+}
@@ -0,0 +1,166 @@
+package httphandlers
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/poyrazk/thecloud/internal/errors"
+)
+
+// WebsiteHandler serves buckets configured as static websites. Sites are
+// reachable without authentication on /website/<bucket>/ and, when a website
+// domain is configured, on <bucket>.<domain>.
+type WebsiteHandler struct {
+	svc    ports.StorageService
+	domain string
+}
+
+func NewWebsiteHandler(svc ports.StorageService, domain string) *WebsiteHandler {
+	return &WebsiteHandler{svc: svc, domain: strings.ToLower(strings.TrimPrefix(domain, "."))}
+}
+
+// Serve handles path-style website requests.
+// @Summary Serve a static website
+// @Description Serves objects of a website bucket with its index and error documents
+// @Tags storage
+// @Param bucket path string true "Bucket name"
+// @Param path path string true "Object path"
+// @Success 200 "Object content"
+// @Failure 404 "Not found"
+// @Router /website/{bucket}/{path} [get]
+func (h *WebsiteHandler) Serve(c *gin.Context) {
+	h.serve(c, c.Param("bucket"), c.Param("path"))
+}
+
+// Host serves requests for <bucket>.<domain> and passes all others on. It must
+// run before routing so that website paths never reach the API routes.
+func (h *WebsiteHandler) Host() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.domain == "" {
+			c.Next()
+			return
+		}
+
+		host := c.Request.Host
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		}
+		bucket, ok := strings.CutSuffix(strings.ToLower(host), "."+h.domain)
+		if !ok || bucket == "" || strings.Contains(bucket, ".") {
+			c.Next()
+			return
+		}
+
+		h.serve(c, bucket, c.Request.URL.Path)
+		c.Abort()
+	}
+}
+
+func (h *WebsiteHandler) serve(c *gin.Context, bucket, urlPath string) {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		c.Header("Allow", "GET, HEAD")
+		websiteStatus(c, http.StatusMethodNotAllowed)
+		return
+	}
+	withBody := c.Request.Method == http.MethodGet
+
+	site, err := h.svc.ResolveWebsite(c.Request.Context(), bucket)
+	if err != nil {
+		if errors.Is(err, errors.NotFound) {
+			websiteStatus(c, http.StatusNotFound)
+		} else {
+			websiteStatus(c, http.StatusInternalServerError)
+		}
+		return
+	}
+	ctx := appcontext.WithUserID(c.Request.Context(), site.UserID)
+
+	key := strings.TrimPrefix(urlPath, "/")
+	if key == "" || strings.HasSuffix(key, "/") {
+		key += site.IndexDocument
+	}
+
+	reader, obj, err := h.svc.Download(ctx, bucket, key, domain.ReadOptions{})
+	if err == nil {
+		defer reader.Close()
+		setWebsiteHeaders(c, site, obj)
+
+		status, start, length := resolveObjectRead(c, obj)
+		switch status {
+		case http.StatusNotModified, http.StatusRequestedRangeNotSatisfiable:
+			c.Status(status)
+			return
+		}
+		writeObject(c, status, reader, start, length, withBody)
+		return
+	}
+	if !isMissingObject(err) {
+		// Objects under customer-provided keys cannot be served publicly
+		if errors.Is(err, errors.InvalidInput) {
+			websiteStatus(c, http.StatusForbidden)
+		} else {
+			websiteStatus(c, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// A path naming a directory with an index document redirects to it
+	if path.Base(key) != site.IndexDocument {
+		if dir, _, err := h.svc.Download(ctx, bucket, key+"/"+site.IndexDocument, domain.ReadOptions{}); err == nil {
+			dir.Close()
+			c.Redirect(http.StatusMovedPermanently, c.Request.URL.Path+"/")
+			return
+		}
+	}
+
+	if site.ErrorDocument != "" {
+		if errReader, errObj, err := h.svc.Download(ctx, bucket, site.ErrorDocument, domain.ReadOptions{}); err == nil {
+			defer errReader.Close()
+			setWebsiteHeaders(c, site, errObj)
+			writeObject(c, http.StatusNotFound, errReader, 0, errObj.SizeBytes, withBody)
+			return
+		}
+	}
+	websiteStatus(c, http.StatusNotFound)
+}
+
+func isMissingObject(err error) bool {
+	return errors.Is(err, errors.ObjectNotFound) || errors.Is(err, errors.NotFound)
+}
+
+// setWebsiteHeaders sets the caching and content headers of a website response.
+// Objects can override the bucket's caching with a cache-control metadata entry.
+func setWebsiteHeaders(c *gin.Context, site *domain.BucketWebsite, obj *domain.Object) {
+	contentType := obj.ContentType
+	if contentType == "" || contentType == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(path.Ext(obj.Key)); byExt != "" {
+			contentType = byExt
+		}
+	}
+
+	cacheControl := obj.Metadata["cache-control"]
+	if cacheControl == "" {
+		cacheControl = fmt.Sprintf("public, max-age=%d", site.CacheMaxAge)
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Cache-Control", cacheControl)
+	c.Header("ETag", objectETag(obj))
+	c.Header("Last-Modified", obj.CreatedAt.UTC().Format(http.TimeFormat))
+	c.Header("Accept-Ranges", "bytes")
+}
+
+// websiteStatus writes a bare status page. Website visitors get plain text
+// rather than the JSON errors of the API.
+func websiteStatus(c *gin.Context, status int) {
+	c.Header("Cache-Control", "no-store")
+	c.String(status, "%d %s\n", status, http.StatusText(status))
+}
@@ -0,0 +1,164 @@
+package httphandlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupWebsiteRouter(svc *storageServiceMock) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewWebsiteHandler(svc, "sites.example.com")
+	r := gin.New()
+	r.Use(h.Host())
+	r.GET("/website/:bucket/*path", h.Serve)
+	r.HEAD("/website/:bucket/*path", h.Serve)
+	r.GET("/health", func(c *gin.Context) { c.String(http.StatusOK, "api") })
+	return r
+}
+
+var testSite = &domain.BucketWebsite{
+	UserID:        uuid.New(),
+	Bucket:        "docs",
+	IndexDocument: "index.html",
+	ErrorDocument: "404.html",
+	CacheMaxAge:   300,
+}
+
+// ownerContext matches contexts carrying the site owner's user ID.
+var ownerContext = mock.MatchedBy(func(ctx context.Context) bool {
+	return appcontext.UserIDFromContext(ctx) == testSite.UserID
+})
+
+var errMissingObject = errors.New(errors.ObjectNotFound, "object metadata not found")
+
+func websiteObject(key, contentType, body string) (io.ReadCloser, *domain.Object) {
+	return io.NopCloser(strings.NewReader(body)), &domain.Object{
+		ID:          uuid.New(),
+		Bucket:      "docs",
+		Key:         key,
+		SizeBytes:   int64(len(body)),
+		ContentType: contentType,
+		ETag:        "abc",
+	}
+}
+
+func TestWebsiteHandler_ServesIndexDocument(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupWebsiteRouter(svc)
+
+	body, obj := websiteObject("guide/index.html", "text/html; charset=utf-8", "<h1>Guide</h1>")
+	svc.On("ResolveWebsite", mock.Anything, "docs").Return(testSite, nil)
+	svc.On("Download", ownerContext, "docs", "guide/index.html", domain.ReadOptions{}).Return(body, obj, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/website/docs/guide/", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<h1>Guide</h1>", w.Body.String())
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+	assert.Equal(t, `"abc"`, w.Header().Get("ETag"))
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+}
+
+func TestWebsiteHandler_HostStyle(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupWebsiteRouter(svc)
+
+	body, obj := websiteObject("app.js", "application/octet-stream", "alert(1)")
+	obj.Metadata = map[string]string{"cache-control": "public, max-age=31536000, immutable"}
+	svc.On("ResolveWebsite", mock.Anything, "docs").Return(testSite, nil)
+	svc.On("Download", ownerContext, "docs", "app.js", domain.ReadOptions{}).Return(body, obj, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/app.js", nil)
+	req.Host = "docs.sites.example.com:8080"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+	assert.Equal(t, "public, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+}
+
+func TestWebsiteHandler_OtherHostsReachAPI(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupWebsiteRouter(svc)
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Host = "api.example.com"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "api", w.Body.String())
+	svc.AssertNotCalled(t, "ResolveWebsite", mock.Anything, mock.Anything)
+}
+
+func TestWebsiteHandler_RedirectsDirectory(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupWebsiteRouter(svc)
+
+	body, obj := websiteObject("guide/index.html", "text/html", "x")
+	svc.On("ResolveWebsite", mock.Anything, "docs").Return(testSite, nil)
+	svc.On("Download", ownerContext, "docs", "guide", domain.ReadOptions{}).Return(nil, nil, errMissingObject)
+	svc.On("Download", ownerContext, "docs", "guide/index.html", domain.ReadOptions{}).Return(body, obj, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/website/docs/guide", nil))
+
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/website/docs/guide/", w.Header().Get("Location"))
+}
+
+func TestWebsiteHandler_ErrorDocument(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupWebsiteRouter(svc)
+
+	body, obj := websiteObject("404.html", "text/html", "gone")
+	svc.On("ResolveWebsite", mock.Anything, "docs").Return(testSite, nil)
+	svc.On("Download", ownerContext, "docs", "missing.html", domain.ReadOptions{}).Return(nil, nil, errMissingObject)
+	svc.On("Download", ownerContext, "docs", "missing.html/index.html", domain.ReadOptions{}).Return(nil, nil, errMissingObject)
+	svc.On("Download", ownerContext, "docs", "404.html", domain.ReadOptions{}).Return(body, obj, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/website/docs/missing.html", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "gone", w.Body.String())
+}
+
+func TestWebsiteHandler_UnknownSite(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupWebsiteRouter(svc)
+
+	svc.On("ResolveWebsite", mock.Anything, "private").Return(nil, errors.New(errors.NotFound, "bucket website is not configured"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/website/private/secret.txt", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	svc.AssertNotCalled(t, "Download", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWebsiteHandler_RejectsWrites(t *testing.T) {
+	svc := new(storageServiceMock)
+	r := setupWebsiteRouter(svc)
+
+	req := httptest.NewRequest(http.MethodPut, "/index.html", strings.NewReader("x"))
+	req.Host = "docs.sites.example.com"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -39,6 +39,14 @@ func (m *mockIdentityService) ValidateApiKey(ctx context.Context, key string) (*
 	return args.Get(0).(*domain.ApiKey), args.Error(1)
 }
 
+func (m *mockIdentityService) GetApiKeyByID(ctx context.Context, id uuid.UUID) (*domain.ApiKey, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ApiKey), args.Error(1)
+}
+
 func TestWebSocket_Lifecycle(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
@@ -29,9 +29,60 @@ var (
 		Help: "Current instance count per scaling group",
 	}, []string{"scaling_group_id"})
 
+	// Leader election metrics
+	// LeaderElected is 1 on the replica holding the lease of a singleton
+	// worker and 0 on the others.
+	LeaderElected = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mini_aws_leader_elected",
+		Help: "Whether this replica holds the leader lease of a worker",
+	}, []string{"lease", "replica"})
+
 	// Load Balancer metrics
+	// The code label is the status class, e.g. "5xx".
 	LBRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "mini_aws_lb_requests_total",
 		Help: "Total requests proxied by load balancers",
+	}, []string{"lb_id", "code"})
+	// The target label is the instance ID of a registered target. The LB
+	// worker deletes the series of deregistered targets, so instances that
+	// come and go do not leave series behind.
+	LBTargetRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mini_aws_lb_target_requests_total",
+		Help: "Requests proxied by load balancers by target",
+	}, []string{"lb_id", "target"})
+	LBRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mini_aws_lb_request_duration_seconds",
+		Help:    "Latency of requests proxied by load balancers",
+		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
 	}, []string{"lb_id"})
+	LBAccessLogsShipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mini_aws_lb_access_logs_shipped_total",
+		Help: "Access log objects written to buckets by result",
+	}, []string{"result"})
+
+	// Storage metrics
+	StorageReclaimedBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mini_aws_storage_reclaimed_bytes_total",
+		Help: "Bytes freed by storage garbage collection",
+	}, []string{"reason"})
+	StorageObjectsExpired = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "mini_aws_storage_objects_expired_total",
+		Help: "Total number of objects deleted by lifecycle expiration",
+	})
+	StorageObjectsPurged = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "mini_aws_storage_objects_purged_total",
+		Help: "Total number of deleted objects whose bytes were purged",
+	})
+	StorageScrubRepairs = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "mini_aws_storage_scrub_repairs_total",
+		Help: "Total number of object replicas rewritten by the scrubber",
+	})
+	StorageScrubUnrecoverable = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "mini_aws_storage_scrub_unrecoverable_objects",
+		Help: "Objects without a healthy replica found by the last scrub",
+	})
+	StorageNotificationsDelivered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mini_aws_storage_notifications_total",
+		Help: "Bucket event delivery attempts by target type and result",
+	}, []string{"target", "result"})
 )
@@ -47,12 +47,24 @@ func (a *DockerAdapter) Ping(ctx context.Context) error {
 	return err
 }
 
-func (a *DockerAdapter) CreateContainer(ctx context.Context, name, imageName string, ports []string, networkID string, volumeBinds []string, env []string, cmd []string) (string, error) {
+func (a *DockerAdapter) CreateContainer(ctx context.Context, name, imageName string, portList []string, networkID string, volumeBinds []string, env []string, cmd []string) (string, error) {
+	return a.CreateContainerWithOptions(ctx, ports.CreateContainerOptions{
+		Name:        name,
+		Image:       imageName,
+		Ports:       portList,
+		NetworkID:   networkID,
+		VolumeBinds: volumeBinds,
+		Env:         env,
+		Cmd:         cmd,
+	})
+}
+
+func (a *DockerAdapter) CreateContainerWithOptions(ctx context.Context, opts ports.CreateContainerOptions) (string, error) {
 	// 1. Ensure image exists (pull if not) - with timeout
 	pullCtx, pullCancel := context.WithTimeout(ctx, ImagePullTimeout)
 	defer pullCancel()
 
-	reader, err := a.cli.ImagePull(pullCtx, imageName, image.PullOptions{})
+	reader, err := a.cli.ImagePull(pullCtx, opts.Image, image.PullOptions{})
 	if err != nil {
 		return "", fmt.Errorf("failed to pull image: %w", err)
 	}
@@ -61,24 +73,28 @@ func (a *DockerAdapter) CreateContainer(ctx context.Context, name, imageName str
 
 	// 2. Configure container
 	config := &container.Config{
-		Image:        imageName,
-		Env:          env,
-		Cmd:          cmd,
+		Image:        opts.Image,
+		Env:          opts.Env,
+		Cmd:          opts.Cmd,
 		ExposedPorts: make(nat.PortSet),
 	}
 	hostConfig := &container.HostConfig{
 		PortBindings: make(nat.PortMap),
-		Binds:        volumeBinds,
+		Binds:        opts.VolumeBinds,
+		Resources: container.Resources{
+			Memory:   opts.MemoryMB * 1024 * 1024,
+			NanoCPUs: int64(opts.CPUs * 1e9),
+		},
 	}
 	networkingConfig := &network.NetworkingConfig{}
 
-	if networkID != "" {
+	if opts.NetworkID != "" {
 		networkingConfig.EndpointsConfig = map[string]*network.EndpointSettings{
-			networkID: {},
+			opts.NetworkID: {},
 		}
 	}
 
-	for _, p := range ports {
+	for _, p := range opts.Ports {
 		parts := strings.Split(p, ":")
 		if len(parts) == 2 {
 			hostPort := parts[0]
@@ -97,7 +113,7 @@ func (a *DockerAdapter) CreateContainer(ctx context.Context, name, imageName str
 	}
 
 	// 3. Create container
-	resp, err := a.cli.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, name)
+	resp, err := a.cli.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, opts.Name)
 	if err != nil {
 		return "", fmt.Errorf("failed to create container: %w", err)
 	}
@@ -282,6 +298,17 @@ func (a *DockerAdapter) WaitContainer(ctx context.Context, containerID string) (
 	return 0, nil
 }
 
+func (a *DockerAdapter) IsContainerRunning(ctx context.Context, containerID string) (bool, error) {
+	inspect, err := a.cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	return inspect.State != nil && inspect.State.Running, nil
+}
+
 func (a *DockerAdapter) Exec(ctx context.Context, containerID string, cmd []string) (string, error) {
 	config := container.ExecOptions{
 		Cmd:          cmd,
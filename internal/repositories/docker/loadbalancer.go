@@ -3,12 +3,19 @@ package docker
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"text/template"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
@@ -22,12 +29,23 @@ import (
 
 const (
 	NginxImage = "nginx:alpine"
+
+	// lbCertDir is where listener certificates are mounted in the proxy container.
+	lbCertDir = "/etc/nginx/certs"
+	// lbLogDir is where the proxy writes its access log, mounted from the
+	// host so the adapter can collect it.
+	lbLogDir = "/var/log/thecloud"
 )
 
 type LBProxyAdapter struct {
 	cli          *client.Client
 	instanceRepo ports.InstanceRepository
 	vpcRepo      ports.VpcRepository
+
+	mu sync.Mutex
+	// upstreams maps the "ip:port" nginx logs for a target to its instance,
+	// per load balancer, as resolved when the configuration was last pushed.
+	upstreams map[uuid.UUID]map[string]uuid.UUID
 }
 
 func NewLBProxyAdapter(instanceRepo ports.InstanceRepository, vpcRepo ports.VpcRepository) (*LBProxyAdapter, error) {
@@ -39,6 +57,7 @@ func NewLBProxyAdapter(instanceRepo ports.InstanceRepository, vpcRepo ports.VpcR
 		cli:          cli,
 		instanceRepo: instanceRepo,
 		vpcRepo:      vpcRepo,
+		upstreams:    make(map[uuid.UUID]map[string]uuid.UUID),
 	}, nil
 }
 
@@ -57,14 +76,10 @@ func (a *LBProxyAdapter) DeployProxy(ctx context.Context, lb *domain.LoadBalance
 		return "", err
 	}
 
-	// 3. Create temp config file to mount or use env?
+	// 3. Write config and certificates to a per-LB directory mounted into the container.
 	// Docker doesn't support mounting strings directly easily without a file.
-	// We'll create a temp directory for LB configs.
-	configPath := filepath.Join("/tmp", "thecloud", "lb", lb.ID.String())
-	if err := os.MkdirAll(configPath, 0755); err != nil {
-		return "", err
-	}
-	if err := os.WriteFile(filepath.Join(configPath, "nginx.conf"), []byte(config), 0644); err != nil {
+	configPath := lbConfigPath(lb.ID)
+	if err := writeProxyFiles(configPath, lb, config); err != nil {
 		return "", err
 	}
 
@@ -73,27 +88,19 @@ func (a *LBProxyAdapter) DeployProxy(ctx context.Context, lb *domain.LoadBalance
 	// Cleanup if exists
 	_ = a.cli.ContainerRemove(ctx, containerName, container.RemoveOptions{Force: true})
 
-	cPort := nat.Port(fmt.Sprintf("%d/tcp", lb.Port))
-
+	exposed, bindings := listenerPorts(lb)
 	config_opt := &container.Config{
-		Image: NginxImage,
-		ExposedPorts: nat.PortSet{
-			cPort: struct{}{},
-		},
+		Image:        NginxImage,
+		ExposedPorts: exposed,
 	}
 
 	hostConfig := &container.HostConfig{
 		Binds: []string{
 			fmt.Sprintf("%s:/etc/nginx/nginx.conf:ro", filepath.Join(configPath, "nginx.conf")),
+			fmt.Sprintf("%s:%s:ro", filepath.Join(configPath, "certs"), lbCertDir),
+			fmt.Sprintf("%s:%s", filepath.Join(configPath, "logs"), lbLogDir),
 		},
-		PortBindings: nat.PortMap{
-			cPort: []nat.PortBinding{
-				{
-					HostIP:   "0.0.0.0",
-					HostPort: fmt.Sprintf("%d", lb.Port),
-				},
-			},
-		},
+		PortBindings: bindings,
 	}
 
 	// 5. Networking
@@ -116,6 +123,7 @@ func (a *LBProxyAdapter) DeployProxy(ctx context.Context, lb *domain.LoadBalance
 	if err := a.cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
 		return "", err
 	}
+	a.resolveUpstreams(ctx, lb.ID, targets)
 
 	return resp.ID, nil
 }
@@ -128,31 +136,51 @@ func (a *LBProxyAdapter) RemoveProxy(ctx context.Context, lbID uuid.UUID) error
 	}
 
 	// Cleanup config file
-	configPath := filepath.Join("/tmp", "thecloud", "lb", lbID.String())
-	os.RemoveAll(configPath)
+	os.RemoveAll(lbConfigPath(lbID))
+
+	a.mu.Lock()
+	delete(a.upstreams, lbID)
+	a.mu.Unlock()
 
 	return nil
 }
 
 func (a *LBProxyAdapter) UpdateProxyConfig(ctx context.Context, lb *domain.LoadBalancer, targets []*domain.LBTarget) error {
+	containerName := fmt.Sprintf("lb-%s", lb.ID.String())
+
+	// Published ports and mounts cannot change on a running container, so a
+	// listener change that adds or removes a port recreates the proxy, as
+	// does a proxy deployed before access logs were collected.
+	exposed, _ := listenerPorts(lb)
+	if inspect, err := a.cli.ContainerInspect(ctx, containerName); err == nil && (!samePorts(inspect.Config.ExposedPorts, exposed) || !hasMount(inspect.Mounts, lbLogDir)) {
+		_, err := a.DeployProxy(ctx, lb, targets)
+		return err
+	}
+
 	// Re-generate and overwrite config
 	config, err := a.generateNginxConfig(ctx, lb, targets)
 	if err != nil {
 		return err
 	}
 
-	configPath := filepath.Join("/tmp", "thecloud", "lb", lb.ID.String(), "nginx.conf")
-	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+	if err := writeProxyFiles(lbConfigPath(lb.ID), lb, config); err != nil {
 		return err
 	}
 
-	containerName := fmt.Sprintf("lb-%s", lb.ID.String())
 	// Try starting container if stopped
 	_ = a.cli.ContainerStart(ctx, containerName, container.StartOptions{})
 
 	// Reload nginx in container
-	execResp, err := a.cli.ContainerExecCreate(ctx, containerName, container.ExecOptions{
-		Cmd: []string{"nginx", "-s", "reload"},
+	if err := a.signalNginx(ctx, lb.ID, "reload"); err != nil {
+		return err
+	}
+	a.resolveUpstreams(ctx, lb.ID, targets)
+	return nil
+}
+
+func (a *LBProxyAdapter) signalNginx(ctx context.Context, lbID uuid.UUID, signal string) error {
+	execResp, err := a.cli.ContainerExecCreate(ctx, fmt.Sprintf("lb-%s", lbID.String()), container.ExecOptions{
+		Cmd: []string{"nginx", "-s", signal},
 	})
 	if err != nil {
 		return err
@@ -161,68 +189,360 @@ func (a *LBProxyAdapter) UpdateProxyConfig(ctx context.Context, lb *domain.LoadB
 	return a.cli.ContainerExecStart(ctx, execResp.ID, container.ExecStartOptions{})
 }
 
-func (a *LBProxyAdapter) generateNginxConfig(ctx context.Context, lb *domain.LoadBalancer, targets []*domain.LBTarget) (string, error) {
-	tmplRaw := `
+// TargetAddress returns the address of the target container on the VPC
+// network, which is what nginx resolves the container name to.
+func (a *LBProxyAdapter) TargetAddress(ctx context.Context, lb *domain.LoadBalancer, t *domain.LBTarget) (string, error) {
+	vpc, err := a.vpcRepo.GetByID(ctx, lb.VpcID)
+	if err != nil {
+		return "", err
+	}
+	inspect, err := a.cli.ContainerInspect(ctx, fmt.Sprintf("thecloud-%s", t.InstanceID.String()[:8]))
+	if err != nil {
+		return "", err
+	}
+	if inspect.NetworkSettings != nil {
+		for name, n := range inspect.NetworkSettings.Networks {
+			if (name == vpc.NetworkID || n.NetworkID == vpc.NetworkID) && n.IPAddress != "" {
+				return net.JoinHostPort(n.IPAddress, strconv.Itoa(t.Port)), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("instance %s is not attached to the network of VPC %s", t.InstanceID, vpc.ID)
+}
+
+// resolveUpstreams records the addresses nginx resolved the target containers
+// to, so access log lines can be attributed to targets. nginx resolves names
+// only when it loads its configuration, so these stay valid until the next push.
+func (a *LBProxyAdapter) resolveUpstreams(ctx context.Context, lbID uuid.UUID, targets []*domain.LBTarget) {
+	addrs := make(map[string]uuid.UUID)
+	for _, t := range targets {
+		inspect, err := a.cli.ContainerInspect(ctx, fmt.Sprintf("thecloud-%s", t.InstanceID.String()[:8]))
+		if err != nil || inspect.NetworkSettings == nil {
+			continue
+		}
+		for _, n := range inspect.NetworkSettings.Networks {
+			if n.IPAddress != "" {
+				addrs[net.JoinHostPort(n.IPAddress, strconv.Itoa(t.Port))] = t.InstanceID
+			}
+		}
+	}
+
+	a.mu.Lock()
+	a.upstreams[lbID] = addrs
+	a.mu.Unlock()
+}
+
+// CollectAccessLogs reads the access log in two steps so no line is lost to
+// a file nginx still writes: the file rotated by the previous call is read
+// and removed, then the live file is rotated and nginx reopens its log.
+func (a *LBProxyAdapter) CollectAccessLogs(ctx context.Context, lbID uuid.UUID) ([]domain.LBAccessLogEntry, error) {
+	live := filepath.Join(lbConfigPath(lbID), "logs", "access.log")
+	rotated := live + ".1"
+
+	a.mu.Lock()
+	addrs := a.upstreams[lbID]
+	a.mu.Unlock()
+
+	data, err := os.ReadFile(rotated)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	entries := parseAccessLog(data, addrs)
+	_ = os.Remove(rotated)
+
+	if info, err := os.Stat(live); err != nil || info.Size() == 0 {
+		return entries, nil
+	}
+	if err := os.Rename(live, rotated); err != nil {
+		log.Printf("Failed to rotate access log of LB %s: %v", lbID, err)
+		return entries, nil
+	}
+	if err := a.signalNginx(ctx, lbID, "reopen"); err != nil {
+		log.Printf("Failed to reopen access log of LB %s: %v", lbID, err)
+	}
+	return entries, nil
+}
+
+func hasMount(mounts []container.MountPoint, dest string) bool {
+	for _, m := range mounts {
+		if m.Destination == dest {
+			return true
+		}
+	}
+	return false
+}
+
+func lbConfigPath(lbID uuid.UUID) string {
+	return filepath.Join("/tmp", "thecloud", "lb", lbID.String())
+}
+
+// writeProxyFiles writes nginx.conf and one PEM file per https listener.
+// The certs directory is bind-mounted, so it is emptied in place rather than
+// recreated.
+func writeProxyFiles(configPath string, lb *domain.LoadBalancer, config string) error {
+	certPath := filepath.Join(configPath, "certs")
+	if err := os.MkdirAll(certPath, 0700); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(configPath, "logs"), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(configPath, "nginx.conf"), []byte(config), 0644); err != nil {
+		return err
+	}
+
+	stale, err := os.ReadDir(certPath)
+	if err != nil {
+		return err
+	}
+	for _, e := range stale {
+		_ = os.Remove(filepath.Join(certPath, e.Name()))
+	}
+	for _, l := range lb.Listeners {
+		if l.Protocol != domain.LBProtocolHTTPS {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(certPath, l.ID.String()+".pem"), l.Certificate, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func listenerPorts(lb *domain.LoadBalancer) (nat.PortSet, nat.PortMap) {
+	exposed := nat.PortSet{}
+	bindings := nat.PortMap{}
+	for _, l := range lb.ServedListeners() {
+		p := nat.Port(fmt.Sprintf("%d/tcp", l.Port))
+		exposed[p] = struct{}{}
+		bindings[p] = []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: fmt.Sprintf("%d", l.Port)}}
+	}
+	return exposed, bindings
+}
+
+func samePorts(a, b nat.PortSet) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for p := range a {
+		if _, ok := b[p]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+const nginxTemplate = `
 user root;
 events {
     worker_connections 1024;
 }
 
 http {
-    {{if .Targets}}
-    upstream backend {
-        {{range .Targets}}
-        server {{.ContainerID}}:{{.Port}} weight={{.Weight}};
+    log_format lb_json escape=json '{"time":"$time_iso8601","protocol":"http","listener_port":$server_port,'
+        '"client_ip":"$remote_addr","method":"$request_method","host":"$host","path":"$uri",'
+        '"status":$status,"bytes_sent":$bytes_sent,"request_time":$request_time,"upstream_addr":"$upstream_addr"}';
+    access_log {{.AccessLog}} lb_json;
+    {{if .StickyTTL}}
+    map $cookie_{{.StickyCookie}} $lb_session {
+        "" $request_id;
+        default $cookie_{{.StickyCookie}};
+    }
+    map $cookie_{{.StickyCookie}} $lb_session_cookie {
+        "" "{{.StickyCookie}}=$request_id; Max-Age={{.StickyTTL}}; Path=/; HttpOnly";
+        default "";
+    }
+    {{end}}
+    {{if .HashHeader}}
+    map $http_{{.HashHeader}} $lb_hash_key {
+        "" $request_id;
+        default $http_{{.HashHeader}};
+    }
+    {{end}}
+    {{range .Upstreams}}
+    upstream {{.Name}} {
+        {{range .Servers}}
+        server {{.Host}}:{{.Port}} weight={{.Weight}};
         {{end}}
-        {{if .LeastConn}}least_conn;{{end}}
+        {{$.HTTPBalance}}
     }
     {{end}}
-
+    {{range .HTTPServers}}{{$srv := .}}
     server {
-        listen {{.Port}};
-        location / {
-            {{if .Targets}}
-            proxy_pass http://backend;
+        listen {{.Port}}{{if .TLS}} ssl{{end}}{{if .DefaultServer}} default_server{{end}};
+        {{if .ServerName}}server_name {{.ServerName}};{{end}}
+        {{if .TLS}}
+        ssl_certificate {{.CertFile}};
+        ssl_certificate_key {{.CertFile}};
+        {{end}}
+        {{if .Redirect}}
+        return 301 https://$host{{.RedirectTo}}$request_uri;
+        {{else}}{{range .Locations}}
+        location {{.Path}} {
+            {{if .Upstream}}
+            proxy_pass http://{{.Upstream}};
             proxy_set_header Host $host;
             proxy_set_header X-Real-IP $remote_addr;
+            proxy_set_header X-Forwarded-Proto {{if $srv.TLS}}https{{else}}http{{end}};
+            {{if $.StickyTTL}}add_header Set-Cookie $lb_session_cookie;{{end}}
             {{else}}
             return 503 "No targets available";
             {{end}}
         }
+        {{end}}{{end}}
+    }
+    {{end}}
+}
+{{if .TCPServers}}
+stream {
+    log_format lb_json escape=json '{"time":"$time_iso8601","protocol":"tcp","listener_port":$server_port,'
+        '"client_ip":"$remote_addr","status":$status,"bytes_sent":$bytes_sent,"request_time":$session_time,'
+        '"upstream_addr":"$upstream_addr"}';
+    access_log {{.AccessLog}} lb_json;
+    {{range .TCPUpstreams}}
+    upstream {{.Name}} {
+        {{range .Servers}}
+        server {{.Host}}:{{.Port}} weight={{.Weight}};
+        {{end}}
+        {{$.TCPBalance}}
+    }
+    {{end}}
+    {{range .TCPServers}}
+    server {
+        listen {{.Port}};
+        {{if .Upstream}}proxy_pass {{.Upstream}};{{else}}return "";{{end}}
     }
+    {{end}}
 }
+{{end}}
 `
-	type targetInfo struct {
-		ContainerID string
-		Port        int
-		Weight      int
-	}
-	type data struct {
-		Port      int
-		LeastConn bool
-		Targets   []targetInfo
-	}
 
-	d := data{
-		Port:      lb.Port,
-		LeastConn: lb.Algorithm == "least-conn",
-	}
+type nginxBackend struct {
+	Host   string
+	Port   int
+	Weight int
+}
+
+type nginxUpstream struct {
+	Name    string
+	Servers []nginxBackend
+}
+
+type nginxLocation struct {
+	Path string
+	// Upstream is empty when the target group has no targets.
+	Upstream string
+}
+
+type nginxHTTPServer struct {
+	Port          int
+	DefaultServer bool
+	ServerName    string
+	TLS           bool
+	CertFile      string
+	// Redirect answers every request with a redirect to https; RedirectTo is
+	// the ":port" suffix of the target, empty for 443.
+	Redirect   bool
+	RedirectTo string
+	Locations  []nginxLocation
+}
+
+type nginxTCPServer struct {
+	Port     int
+	Upstream string
+}
 
+type nginxConfig struct {
+	AccessLog string
+	// HTTPBalance and TCPBalance are the balancing directives of the http and
+	// stream upstreams; empty means nginx's weighted round-robin.
+	HTTPBalance string
+	TCPBalance  string
+	// StickyTTL enables sticky sessions with a cookie of that lifetime.
+	StickyTTL    int
+	StickyCookie string
+	// HashHeader is the header hashed by header-hash, as an nginx variable
+	// suffix.
+	HashHeader   string
+	Upstreams    []nginxUpstream
+	HTTPServers  []nginxHTTPServer
+	TCPUpstreams []nginxUpstream
+	TCPServers   []nginxTCPServer
+}
+
+func (a *LBProxyAdapter) generateNginxConfig(ctx context.Context, lb *domain.LoadBalancer, targets []*domain.LBTarget) (string, error) {
+	groups := make(map[string][]nginxBackend)
 	for _, t := range targets {
 		inst, err := a.instanceRepo.GetByID(ctx, t.InstanceID)
 		if err != nil {
 			continue
 		}
+		group := t.TargetGroup
+		if group == "" {
+			group = domain.DefaultTargetGroup
+		}
 		// Predictable docker name used by InstanceService
-		host := fmt.Sprintf("thecloud-%s", inst.ID.String()[:8])
-		d.Targets = append(d.Targets, targetInfo{
-			ContainerID: host,
-			Port:        t.Port,
-			Weight:      t.Weight,
+		groups[group] = append(groups[group], nginxBackend{
+			Host:   fmt.Sprintf("thecloud-%s", inst.ID.String()[:8]),
+			Port:   t.Port,
+			Weight: t.Weight,
 		})
 	}
 
-	tmpl, err := template.New("nginx").Parse(tmplRaw)
+	d := nginxConfig{AccessLog: lbLogDir + "/access.log"}
+	d.balancing(lb)
+	httpGroups := make(map[string]bool)
+	tcpGroups := make(map[string]bool)
+
+	// upstream returns the upstream name of a group, or "" if it has no targets.
+	upstream := func(group, prefix string, used map[string]bool) string {
+		if len(groups[group]) == 0 {
+			return ""
+		}
+		used[group] = true
+		return prefix + group
+	}
+
+	for _, l := range lb.ServedListeners() {
+		switch {
+		case l.Protocol == domain.LBProtocolTCP:
+			d.TCPServers = append(d.TCPServers, nginxTCPServer{
+				Port:     l.Port,
+				Upstream: upstream(l.DefaultTargetGroup, "tcp_", tcpGroups),
+			})
+		case l.RedirectPort != 0:
+			srv := nginxHTTPServer{Port: l.Port, Redirect: true}
+			if l.RedirectPort != 443 {
+				srv.RedirectTo = fmt.Sprintf(":%d", l.RedirectPort)
+			}
+			d.HTTPServers = append(d.HTTPServers, srv)
+		default:
+			for _, vh := range virtualHosts(l) {
+				srv := nginxHTTPServer{
+					Port:       l.Port,
+					ServerName: vh.host,
+					TLS:        l.Protocol == domain.LBProtocolHTTPS,
+				}
+				if srv.TLS {
+					srv.CertFile = lbCertDir + "/" + l.ID.String() + ".pem"
+				}
+				// Only listeners with per-host servers need an explicit default.
+				srv.DefaultServer = vh.host == "" && hasHostRules(l)
+				for _, path := range vh.paths() {
+					srv.Locations = append(srv.Locations, nginxLocation{
+						Path:     path,
+						Upstream: upstream(vh.routes[path], "group_", httpGroups),
+					})
+				}
+				d.HTTPServers = append(d.HTTPServers, srv)
+			}
+		}
+	}
+
+	d.Upstreams = collectUpstreams(groups, httpGroups, "group_")
+	d.TCPUpstreams = collectUpstreams(groups, tcpGroups, "tcp_")
+
+	tmpl, err := template.New("nginx").Parse(nginxTemplate)
 	if err != nil {
 		return "", err
 	}
@@ -234,3 +554,170 @@ http {
 
 	return buf.String(), nil
 }
+
+// balancing renders the algorithm and stickiness of a load balancer. Hashed
+// requests without a key, i.e. new sessions or requests without the header,
+// hash a random request ID instead, so they still spread by weight. Streams
+// have no cookies or headers and hash the client address instead.
+func (d *nginxConfig) balancing(lb *domain.LoadBalancer) {
+	switch lb.Algorithm {
+	case domain.LBAlgorithmLeastConn:
+		d.HTTPBalance, d.TCPBalance = "least_conn;", "least_conn;"
+	case domain.LBAlgorithmSourceIPHash:
+		d.HTTPBalance, d.TCPBalance = "hash $remote_addr consistent;", "hash $remote_addr consistent;"
+	case domain.LBAlgorithmHeaderHash:
+		d.HashHeader = strings.ReplaceAll(strings.ToLower(lb.HashHeader), "-", "_")
+		d.HTTPBalance, d.TCPBalance = "hash $lb_hash_key consistent;", "hash $remote_addr consistent;"
+	}
+	if lb.Stickiness.Enabled {
+		d.StickyTTL = lb.Stickiness.TTLSeconds
+		d.StickyCookie = domain.LBStickyCookie
+		d.HTTPBalance = "hash $lb_session consistent;"
+	}
+}
+
+func collectUpstreams(groups map[string][]nginxBackend, used map[string]bool, prefix string) []nginxUpstream {
+	names := make([]string, 0, len(used))
+	for g := range used {
+		names = append(names, g)
+	}
+	sort.Strings(names)
+
+	var res []nginxUpstream
+	for _, g := range names {
+		res = append(res, nginxUpstream{Name: prefix + g, Servers: groups[g]})
+	}
+	return res
+}
+
+// virtualHost maps the path prefixes served for one host to target groups.
+// An empty host is the catch-all server.
+type virtualHost struct {
+	host   string
+	routes map[string]string
+}
+
+func (vh virtualHost) paths() []string {
+	paths := make([]string, 0, len(vh.routes))
+	for p := range vh.routes {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func hasHostRules(l *domain.LBListener) bool {
+	for _, r := range l.Rules {
+		if r.Host != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// virtualHosts expands the rules of a listener into one nginx server per
+// host. Host-less rules apply to every host unless a host rule claims the
+// same path, and "/" falls back to the listener's default group. nginx
+// itself then picks the most specific server name and the longest prefix.
+func virtualHosts(l *domain.LBListener) []virtualHost {
+	base := map[string]string{"/": l.DefaultTargetGroup}
+	hostRoutes := make(map[string]map[string]string)
+	for _, r := range l.Rules {
+		if r.Host == "" {
+			base[r.PathPrefix] = r.TargetGroup
+			continue
+		}
+		if hostRoutes[r.Host] == nil {
+			hostRoutes[r.Host] = make(map[string]string)
+		}
+		hostRoutes[r.Host][r.PathPrefix] = r.TargetGroup
+	}
+
+	hosts := make([]string, 0, len(hostRoutes))
+	for h := range hostRoutes {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+
+	res := []virtualHost{{routes: base}}
+	for _, h := range hosts {
+		routes := make(map[string]string, len(base))
+		for p, g := range base {
+			routes[p] = g
+		}
+		for p, g := range hostRoutes[h] {
+			routes[p] = g
+		}
+		res = append(res, virtualHost{host: h, routes: routes})
+	}
+	return res
+}
+
+// nginxLogLine is a line of the lb_json log format.
+type nginxLogLine struct {
+	Time         string  `json:"time"`
+	Protocol     string  `json:"protocol"`
+	ListenerPort int     `json:"listener_port"`
+	ClientIP     string  `json:"client_ip"`
+	Method       string  `json:"method"`
+	Host         string  `json:"host"`
+	Path         string  `json:"path"`
+	Status       int     `json:"status"`
+	BytesSent    int64   `json:"bytes_sent"`
+	RequestTime  float64 `json:"request_time"`
+	UpstreamAddr string  `json:"upstream_addr"`
+}
+
+// parseAccessLog parses lb_json log lines, skipping lines it cannot read, and
+// attributes each entry to the target at its upstream address.
+func parseAccessLog(data []byte, addrs map[string]uuid.UUID) []domain.LBAccessLogEntry {
+	var entries []domain.LBAccessLogEntry
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var l nginxLogLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339, l.Time)
+		if err != nil {
+			continue
+		}
+
+		e := domain.LBAccessLogEntry{
+			Time:            ts,
+			Protocol:        l.Protocol,
+			ListenerPort:    l.ListenerPort,
+			ClientIP:        l.ClientIP,
+			Method:          l.Method,
+			Host:            l.Host,
+			Path:            l.Path,
+			Status:          l.Status,
+			BytesSent:       l.BytesSent,
+			DurationSeconds: l.RequestTime,
+			UpstreamAddr:    servingUpstream(l.UpstreamAddr),
+		}
+		if id, ok := addrs[e.UpstreamAddr]; ok {
+			e.InstanceID = &id
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// servingUpstream returns the upstream that answered a request. nginx lists
+// every upstream it tried, separated by ", ", and groups of upstreams from
+// internal redirects separated by " : "; the last one answered.
+func servingUpstream(addr string) string {
+	if i := strings.LastIndex(addr, " : "); i >= 0 {
+		addr = addr[i+3:]
+	}
+	if i := strings.LastIndex(addr, ", "); i >= 0 {
+		addr = addr[i+2:]
+	}
+	if addr == "-" {
+		return ""
+	}
+	return strings.TrimSpace(addr)
+}
@@ -43,6 +43,14 @@ func (m *mockInstanceRepo) List(ctx context.Context) ([]*domain.Instance, error)
 	return args.Get(0).([]*domain.Instance), args.Error(1)
 }
 
+func (m *mockInstanceRepo) ListAll(ctx context.Context) ([]*domain.Instance, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Instance), args.Error(1)
+}
+
 func (m *mockInstanceRepo) Update(ctx context.Context, instance *domain.Instance) error {
 	args := m.Called(ctx, instance)
 	return args.Error(0)
@@ -127,6 +135,7 @@ func TestLBProxyAdapter_GenerateNginxConfig(t *testing.T) {
 		assert.Contains(t, conf, "server thecloud-"+inst2ID.String()[:8]+":9090 weight=2;")
 		assert.Contains(t, conf, "listen 80;")
 		assert.NotContains(t, conf, "least_conn;")
+		assert.Contains(t, conf, "access_log /var/log/thecloud/access.log lb_json;")
 	})
 
 	t.Run("least-conn config", func(t *testing.T) {
@@ -135,4 +144,146 @@ func TestLBProxyAdapter_GenerateNginxConfig(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Contains(t, conf, "least_conn;")
 	})
+
+	t.Run("source-ip-hash config", func(t *testing.T) {
+		lb.Algorithm = domain.LBAlgorithmSourceIPHash
+		conf, err := adapter.generateNginxConfig(ctx, lb, targets)
+		assert.NoError(t, err)
+		assert.Contains(t, conf, "hash $remote_addr consistent;")
+	})
+
+	t.Run("header-hash config", func(t *testing.T) {
+		lb.Algorithm = domain.LBAlgorithmHeaderHash
+		lb.HashHeader = "X-Tenant-ID"
+		conf, err := adapter.generateNginxConfig(ctx, lb, targets)
+		assert.NoError(t, err)
+		assert.Contains(t, conf, "map $http_x_tenant_id $lb_hash_key {")
+		assert.Contains(t, conf, "hash $lb_hash_key consistent;")
+		assert.NotContains(t, conf, "Set-Cookie")
+	})
+
+	t.Run("sticky sessions config", func(t *testing.T) {
+		lb.Stickiness = domain.LBStickiness{Enabled: true, TTLSeconds: 600}
+		conf, err := adapter.generateNginxConfig(ctx, lb, targets)
+		assert.NoError(t, err)
+		assert.Contains(t, conf, "map $cookie_thecloud_lb $lb_session {")
+		assert.Contains(t, conf, `"" "thecloud_lb=$request_id; Max-Age=600; Path=/; HttpOnly";`)
+		assert.Contains(t, conf, "hash $lb_session consistent;")
+		assert.Contains(t, conf, "add_header Set-Cookie $lb_session_cookie;")
+		assert.NotContains(t, conf, "hash $lb_hash_key consistent;")
+	})
+}
+
+func TestLBProxyAdapter_GenerateNginxConfigListeners(t *testing.T) {
+	instRepo := new(mockInstanceRepo)
+	adapter := &LBProxyAdapter{instanceRepo: instRepo, vpcRepo: new(mockVpcRepo)}
+	ctx := context.Background()
+
+	webID := uuid.New()
+	apiID := uuid.New()
+	dbID := uuid.New()
+	instRepo.On("GetByID", ctx, webID).Return(&domain.Instance{ID: webID}, nil)
+	instRepo.On("GetByID", ctx, apiID).Return(&domain.Instance{ID: apiID}, nil)
+	instRepo.On("GetByID", ctx, dbID).Return(&domain.Instance{ID: dbID}, nil)
+
+	targets := []*domain.LBTarget{
+		{InstanceID: webID, TargetGroup: "default", Port: 8080, Weight: 1},
+		{InstanceID: apiID, TargetGroup: "api", Port: 9000, Weight: 1},
+		{InstanceID: dbID, TargetGroup: "db", Port: 5432, Weight: 1},
+	}
+
+	httpsID := uuid.New()
+	lb := &domain.LoadBalancer{
+		ID:   uuid.New(),
+		Port: 80,
+		Listeners: []*domain.LBListener{
+			{Port: 80, Protocol: domain.LBProtocolHTTP, RedirectPort: 443, DefaultTargetGroup: "default"},
+			{
+				ID: httpsID, Port: 443, Protocol: domain.LBProtocolHTTPS, DefaultTargetGroup: "default",
+				Rules: []*domain.LBRoutingRule{
+					{PathPrefix: "/api/", TargetGroup: "api"},
+					{Host: "admin.example.com", PathPrefix: "/", TargetGroup: "admin"},
+				},
+			},
+			{Port: 5432, Protocol: domain.LBProtocolTCP, DefaultTargetGroup: "db"},
+		},
+	}
+
+	conf, err := adapter.generateNginxConfig(ctx, lb, targets)
+	assert.NoError(t, err)
+
+	t.Run("redirects http to https", func(t *testing.T) {
+		assert.Contains(t, conf, "return 301 https://$host$request_uri;")
+	})
+
+	t.Run("terminates tls with the listener certificate", func(t *testing.T) {
+		assert.Contains(t, conf, "listen 443 ssl default_server;")
+		assert.Contains(t, conf, "ssl_certificate /etc/nginx/certs/"+httpsID.String()+".pem;")
+	})
+
+	t.Run("routes by path and host", func(t *testing.T) {
+		assert.Contains(t, conf, "upstream group_api {")
+		assert.Contains(t, conf, "proxy_pass http://group_api;")
+		assert.Contains(t, conf, "server_name admin.example.com;")
+		// The admin group has no targets.
+		assert.Contains(t, conf, `return 503 "No targets available";`)
+		assert.NotContains(t, conf, "group_admin")
+	})
+
+	t.Run("forwards tcp in a stream block", func(t *testing.T) {
+		assert.Contains(t, conf, "stream {")
+		assert.Contains(t, conf, "upstream tcp_db {")
+		assert.Contains(t, conf, "server thecloud-"+dbID.String()[:8]+":5432 weight=1;")
+		assert.Contains(t, conf, "proxy_pass tcp_db;")
+		assert.NotContains(t, conf, "upstream group_db")
+	})
+}
+
+func TestVirtualHosts(t *testing.T) {
+	l := &domain.LBListener{
+		DefaultTargetGroup: "web",
+		Rules: []*domain.LBRoutingRule{
+			{PathPrefix: "/api/", TargetGroup: "api"},
+			{Host: "*.example.com", PathPrefix: "/api/", TargetGroup: "tenant-api"},
+		},
+	}
+
+	hosts := virtualHosts(l)
+	assert.Len(t, hosts, 2)
+	assert.Equal(t, "", hosts[0].host)
+	assert.Equal(t, map[string]string{"/": "web", "/api/": "api"}, hosts[0].routes)
+	assert.Equal(t, "*.example.com", hosts[1].host)
+	assert.Equal(t, map[string]string{"/": "web", "/api/": "tenant-api"}, hosts[1].routes)
+}
+
+func TestParseAccessLog(t *testing.T) {
+	instID := uuid.New()
+	addrs := map[string]uuid.UUID{"172.18.0.5:8080": instID}
+	data := []byte(`{"time":"2026-10-19T12:00:01+00:00","protocol":"http","listener_port":80,"client_ip":"10.0.0.1","method":"GET","host":"example.com","path":"/api","status":200,"bytes_sent":512,"request_time":0.042,"upstream_addr":"172.18.0.9:8080, 172.18.0.5:8080"}
+not json
+{"time":"2026-10-19T12:00:02+00:00","protocol":"http","listener_port":80,"client_ip":"10.0.0.2","method":"GET","host":"example.com","path":"/","status":503,"bytes_sent":180,"request_time":0.000,"upstream_addr":""}
+{"time":"2026-10-19T12:00:03+00:00","protocol":"tcp","listener_port":5432,"client_ip":"10.0.0.3","status":200,"bytes_sent":4096,"request_time":1.500,"upstream_addr":"172.18.0.5:8080"}
+`)
+
+	entries := parseAccessLog(data, addrs)
+
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "172.18.0.5:8080", entries[0].UpstreamAddr)
+	assert.Equal(t, instID, *entries[0].InstanceID)
+	assert.Equal(t, 200, entries[0].Status)
+	assert.InDelta(t, 0.042, entries[0].DurationSeconds, 1e-9)
+	assert.Equal(t, "/api", entries[0].Path)
+	assert.Nil(t, entries[1].InstanceID)
+	assert.Equal(t, 503, entries[1].Status)
+	assert.Equal(t, "tcp", entries[2].Protocol)
+	assert.Equal(t, 5432, entries[2].ListenerPort)
+	assert.NotNil(t, entries[2].InstanceID)
+}
+
+func TestServingUpstream(t *testing.T) {
+	assert.Equal(t, "10.0.0.2:80", servingUpstream("10.0.0.2:80"))
+	assert.Equal(t, "10.0.0.3:80", servingUpstream("10.0.0.2:80, 10.0.0.3:80"))
+	assert.Equal(t, "10.0.0.4:80", servingUpstream("10.0.0.2:80, 10.0.0.3:80 : 10.0.0.4:80"))
+	assert.Equal(t, "", servingUpstream("-"))
+	assert.Equal(t, "", servingUpstream(""))
 }
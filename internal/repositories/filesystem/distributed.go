@@ -0,0 +1,540 @@
+package filesystem
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+)
+
+// Layout of every data directory used by DistributedFileStore.
+const (
+	objectsDir   = "objects"
+	checksumsDir = "checksums"
+	tempDir      = "tmp"
+)
+
+// staleTempAge is how long a temp file goes unmodified before it counts as
+// the leftover of an interrupted write. Writes in progress, possibly by
+// another API replica sharing the directories, keep touching theirs.
+const staleTempAge = 24 * time.Hour
+
+// DistributedFileStore replicates every object onto several data directories,
+// which would normally be mount points of separate disks. Placement uses
+// rendezvous hashing, so adding a directory only moves the objects that now
+// belong on it. Each replica carries a SHA-256 checksum; reads verify it as
+// they stream and fall back to another replica when a directory is lost or a
+// copy is corrupted, and Scrub repairs missing or corrupted replicas from a
+// healthy one.
+type DistributedFileStore struct {
+	dirs     []string
+	replicas int
+
+	mu        sync.Mutex
+	repairing map[string]bool
+	repairs   sync.WaitGroup
+}
+
+func NewDistributedFileStore(dirs []string, replicas int) (*DistributedFileStore, error) {
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("distributed file store needs at least one data directory")
+	}
+	if replicas < 1 || replicas > len(dirs) {
+		return nil, fmt.Errorf("replicas must be between 1 and the number of data directories (%d)", len(dirs))
+	}
+
+	for _, dir := range dirs {
+		for _, sub := range []string{objectsDir, checksumsDir, tempDir} {
+			if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+				return nil, fmt.Errorf("failed to create data directory %s: %w", dir, err)
+			}
+		}
+		removeStaleTempFiles(filepath.Join(dir, tempDir), time.Now().Add(-staleTempAge))
+	}
+	return &DistributedFileStore{dirs: dirs, replicas: replicas, repairing: map[string]bool{}}, nil
+}
+
+// removeStaleTempFiles deletes the leftovers of interrupted writes, which are
+// never referenced again, leaving files modified after cutoff alone.
+func removeStaleTempFiles(dir string, cutoff time.Time) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		_ = os.RemoveAll(filepath.Join(dir, e.Name()))
+	}
+}
+
+// placement returns the data directories that hold the replicas of an object.
+func (s *DistributedFileStore) placement(bucket, key string) []string {
+	type scored struct {
+		dir   string
+		score uint64
+	}
+	scores := make([]scored, len(s.dirs))
+	for i, dir := range s.dirs {
+		h := fnv.New64a()
+		h.Write([]byte(dir))
+		h.Write([]byte{0})
+		h.Write([]byte(bucket + "/" + key))
+		scores[i] = scored{dir: dir, score: h.Sum64()}
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i].score > scores[j].score })
+
+	dirs := make([]string, s.replicas)
+	for i := range dirs {
+		dirs[i] = scores[i].dir
+	}
+	return dirs
+}
+
+func objectPath(dir, bucket, key string) string {
+	return filepath.Join(dir, objectsDir, bucket, key)
+}
+
+func checksumPath(dir, bucket, key string) string {
+	return filepath.Join(dir, checksumsDir, bucket, key)
+}
+
+func (s *DistributedFileStore) Write(ctx context.Context, bucket, key string, r io.Reader) (int64, error) {
+	placement := s.placement(bucket, key)
+
+	var replicas []*os.File
+	for _, dir := range placement {
+		f, err := os.CreateTemp(filepath.Join(dir, tempDir), "obj-*")
+		if err != nil {
+			log.Printf("Storage: data directory %s unavailable: %v", dir, err)
+			continue
+		}
+		replicas = append(replicas, f)
+	}
+	if len(replicas) == 0 {
+		return 0, errors.New(errors.Internal, "no data directory available")
+	}
+
+	fanOut := &fanOutWriter{files: replicas}
+	sum := sha256.New()
+	n, err := io.Copy(io.MultiWriter(sum, fanOut), r)
+	for _, f := range replicas {
+		_ = f.Close()
+	}
+	if err != nil {
+		for _, f := range replicas {
+			_ = os.Remove(f.Name())
+		}
+		return 0, errors.Wrap(errors.Internal, "failed to write file", err)
+	}
+
+	checksum := hex.EncodeToString(sum.Sum(nil))
+	written := 0
+	for _, f := range fanOut.live() {
+		dir := filepath.Dir(filepath.Dir(f.Name()))
+		if err := commitReplica(f.Name(), dir, bucket, key, checksum); err != nil {
+			log.Printf("Storage: failed to store replica of %s/%s in %s: %v", bucket, key, dir, err)
+			continue
+		}
+		written++
+	}
+	if written == 0 {
+		return 0, errors.New(errors.Internal, "failed to store any replica")
+	}
+	if written < len(placement) {
+		log.Printf("Storage: %s/%s stored with %d of %d replicas; the scrubber will restore the rest", bucket, key, written, len(placement))
+	}
+	return n, nil
+}
+
+// commitReplica moves a fully written temp file into place and records its checksum.
+func commitReplica(tmpName, dir, bucket, key, checksum string) error {
+	dst := objectPath(dir, bucket, key)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, dst); err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+	return writeChecksum(dir, bucket, key, checksum)
+}
+
+func writeChecksum(dir, bucket, key, checksum string) error {
+	dst := checksumPath(dir, bucket, key)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Join(dir, tempDir), "sum-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(checksum)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), dst)
+}
+
+func (s *DistributedFileStore) Read(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	// Placement directories first, then the rest in case placement changed
+	// since the object was written and the scrubber has not moved it yet.
+	r := &verifyingReader{store: s, bucket: bucket, key: key, dirs: s.searchOrder(bucket, key)}
+	if !r.next() {
+		return nil, errors.New(errors.ObjectNotFound, "object not found on disk")
+	}
+	return r, nil
+}
+
+// verifyingReader serves a replica while hashing it, and checks the hash
+// against the recorded checksum before it hands out the last bytes. A
+// mismatch queues a repair and fails the read, or moves on to the next
+// replica when nothing has been served yet. Seeking, as range reads do, stops
+// verification; the scrubber checks those replicas.
+type verifyingReader struct {
+	store       *DistributedFileStore
+	bucket, key string
+	// dirs are the data directories not tried yet.
+	dirs []string
+
+	f    *os.File
+	dir  string
+	size int64
+	want string
+	// hash is nil when the replica is not verified.
+	hash   hash.Hash
+	read   int64
+	served bool
+	err    error
+}
+
+// next opens the replica in the next data directory that has one. Copies
+// written before checksums existed are served unverified until the scrubber
+// adopts them.
+func (r *verifyingReader) next() bool {
+	for len(r.dirs) > 0 {
+		dir := r.dirs[0]
+		r.dirs = r.dirs[1:]
+		f, err := os.Open(objectPath(dir, r.bucket, r.key))
+		if err != nil {
+			continue
+		}
+		info, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			continue
+		}
+		r.f, r.dir, r.size, r.read = f, dir, info.Size(), 0
+		r.want, r.hash = "", nil
+		if want, err := os.ReadFile(checksumPath(dir, r.bucket, r.key)); err == nil && len(want) > 0 {
+			r.want, r.hash = strings.TrimSpace(string(want)), sha256.New()
+		}
+		return true
+	}
+	return false
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	for r.err == nil {
+		n, err := r.f.Read(p)
+		if r.hash == nil || (err != nil && err != io.EOF) {
+			r.served = r.served || n > 0
+			return n, err
+		}
+		r.hash.Write(p[:n])
+		r.read += int64(n)
+		if r.read < r.size && err == nil {
+			r.served = r.served || n > 0
+			return n, nil
+		}
+
+		if hex.EncodeToString(r.hash.Sum(nil)) == r.want {
+			r.hash = nil
+			r.served = r.served || n > 0
+			return n, err
+		}
+		log.Printf("Storage: replica of %s/%s in %s failed verification", r.bucket, r.key, r.dir)
+		r.store.queueRepair(r.bucket, r.key)
+		_ = r.f.Close()
+		if r.served || !r.next() {
+			r.err = errors.New(errors.Internal, "no replica matches the object checksum")
+		}
+	}
+	return 0, r.err
+}
+
+func (r *verifyingReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := r.f.Seek(offset, whence)
+	if err == nil && pos != r.read {
+		r.hash = nil
+	}
+	return pos, err
+}
+
+func (r *verifyingReader) Close() error {
+	return r.f.Close()
+}
+
+// queueRepair restores the replicas of an object in the background after a read
+// skipped a corrupted copy. Concurrent reads of the same object queue it once.
+func (s *DistributedFileStore) queueRepair(bucket, key string) {
+	name := bucket + "/" + key
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.repairing[name] {
+		return
+	}
+	s.repairing[name] = true
+	s.repairs.Add(1)
+	go func() {
+		defer s.repairs.Done()
+		s.scrubObject(bucket, key, &domain.ScrubReport{})
+		s.mu.Lock()
+		delete(s.repairing, name)
+		s.mu.Unlock()
+	}()
+}
+
+func (s *DistributedFileStore) searchOrder(bucket, key string) []string {
+	order := s.placement(bucket, key)
+	for _, dir := range s.dirs {
+		if !contains(order, dir) {
+			order = append(order, dir)
+		}
+	}
+	return order
+}
+
+func (s *DistributedFileStore) Delete(ctx context.Context, bucket, key string) error {
+	for _, dir := range s.dirs {
+		for _, path := range []string{objectPath(dir, bucket, key), checksumPath(dir, bucket, key)} {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return errors.Wrap(errors.Internal, "failed to delete file", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Scrub verifies every replica against its checksum, restores missing or corrupted
+// replicas on their placement directories from a healthy copy, and removes copies
+// that no longer belong to a placement directory.
+func (s *DistributedFileStore) Scrub(ctx context.Context) (*domain.ScrubReport, error) {
+	objects := map[string]struct{}{}
+	for _, dir := range s.dirs {
+		root := filepath.Join(dir, objectsDir)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if rel, err := filepath.Rel(root, path); err == nil {
+				objects[filepath.ToSlash(rel)] = struct{}{}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Storage: failed to scan data directory %s: %v", dir, err)
+		}
+	}
+
+	report := &domain.ScrubReport{}
+	for name := range objects {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		bucket, key, ok := strings.Cut(name, "/")
+		if !ok {
+			continue
+		}
+		report.Objects++
+		s.scrubObject(bucket, key, report)
+	}
+	return report, nil
+}
+
+type replicaState struct {
+	exists   bool
+	healthy  bool
+	checksum string
+}
+
+func (s *DistributedFileStore) scrubObject(bucket, key string, report *domain.ScrubReport) {
+	states := map[string]replicaState{}
+	source, checksum := "", ""
+	for _, dir := range s.dirs {
+		st := inspectReplica(dir, bucket, key)
+		states[dir] = st
+		if st.healthy && source == "" {
+			source, checksum = dir, st.checksum
+		}
+	}
+
+	if source == "" {
+		// Copies written before checksums existed are adopted as-is
+		for _, dir := range s.dirs {
+			if st := states[dir]; st.exists && st.checksum == "" {
+				actual, err := fileChecksum(objectPath(dir, bucket, key))
+				if err == nil && writeChecksum(dir, bucket, key, actual) == nil {
+					source, checksum = dir, actual
+					states[dir] = replicaState{exists: true, healthy: true, checksum: actual}
+					break
+				}
+			}
+		}
+	}
+	if source == "" {
+		report.Unrecoverable++
+		log.Printf("Storage: no healthy replica left for %s/%s", bucket, key)
+		return
+	}
+
+	placement := s.placement(bucket, key)
+	for _, dir := range placement {
+		if states[dir].healthy {
+			continue
+		}
+		if err := copyReplica(source, dir, bucket, key, checksum); err != nil {
+			log.Printf("Storage: failed to repair %s/%s in %s: %v", bucket, key, dir, err)
+			continue
+		}
+		states[dir] = replicaState{exists: true, healthy: true, checksum: checksum}
+		report.Repaired++
+		log.Printf("Storage: repaired replica of %s/%s in %s", bucket, key, dir)
+	}
+
+	for _, dir := range placement {
+		if !states[dir].healthy {
+			return
+		}
+	}
+	// Only drop stray copies once every placement replica is healthy
+	for _, dir := range s.dirs {
+		if states[dir].exists && !contains(placement, dir) {
+			_ = os.Remove(objectPath(dir, bucket, key))
+			_ = os.Remove(checksumPath(dir, bucket, key))
+		}
+	}
+}
+
+func inspectReplica(dir, bucket, key string) replicaState {
+	if _, err := os.Stat(objectPath(dir, bucket, key)); err != nil {
+		return replicaState{}
+	}
+	recorded, err := os.ReadFile(checksumPath(dir, bucket, key))
+	if err != nil {
+		return replicaState{exists: true}
+	}
+	actual, err := fileChecksum(objectPath(dir, bucket, key))
+	if err != nil {
+		return replicaState{exists: true, checksum: string(recorded)}
+	}
+	return replicaState{exists: true, healthy: actual == strings.TrimSpace(string(recorded)), checksum: actual}
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func copyReplica(srcDir, dstDir, bucket, key, checksum string) error {
+	src, err := os.Open(objectPath(srcDir, bucket, key))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Join(dstDir, tempDir), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Join(dstDir, tempDir), "obj-*")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tmp, src)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return commitReplica(tmp.Name(), dstDir, bucket, key, checksum)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// fanOutWriter writes to every replica and drops the ones that fail, so a
+// failing disk does not fail the upload as long as one replica survives.
+type fanOutWriter struct {
+	files  []*os.File
+	failed []bool
+}
+
+func (w *fanOutWriter) Write(p []byte) (int, error) {
+	if w.failed == nil {
+		w.failed = make([]bool, len(w.files))
+	}
+	ok := 0
+	for i, f := range w.files {
+		if w.failed[i] {
+			continue
+		}
+		if _, err := f.Write(p); err != nil {
+			log.Printf("Storage: dropping replica %s: %v", f.Name(), err)
+			w.failed[i] = true
+			_ = os.Remove(f.Name())
+			continue
+		}
+		ok++
+	}
+	if ok == 0 {
+		return 0, fmt.Errorf("all replicas failed")
+	}
+	return len(p), nil
+}
+
+func (w *fanOutWriter) live() []*os.File {
+	var files []*os.File
+	for i, f := range w.files {
+		if w.failed == nil || !w.failed[i] {
+			files = append(files, f)
+		}
+	}
+	return files
+}
@@ -0,0 +1,235 @@
+package filesystem
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDistributedStore(t *testing.T, n, replicas int) (*DistributedFileStore, []string) {
+	t.Helper()
+	base := t.TempDir()
+	dirs := make([]string, n)
+	for i := range dirs {
+		dirs[i] = filepath.Join(base, string(rune('a'+i)))
+	}
+	store, err := NewDistributedFileStore(dirs, replicas)
+	require.NoError(t, err)
+	return store, dirs
+}
+
+func readAll(t *testing.T, store *DistributedFileStore, bucket, key string) (string, error) {
+	t.Helper()
+	r, err := store.Read(context.Background(), bucket, key)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	return string(data), err
+}
+
+func TestDistributedFileStore_WriteRead(t *testing.T) {
+	store, _ := newTestDistributedStore(t, 3, 2)
+	ctx := context.Background()
+
+	n, err := store.Write(ctx, "b", "dir/k", strings.NewReader("hello world"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), n)
+
+	data, err := readAll(t, store, "b", "dir/k")
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", data)
+
+	placement := store.placement("b", "dir/k")
+	assert.Len(t, placement, 2)
+	for _, dir := range placement {
+		assert.FileExists(t, objectPath(dir, "b", "dir/k"))
+		assert.FileExists(t, checksumPath(dir, "b", "dir/k"))
+	}
+}
+
+func TestDistributedFileStore_ReadSurvivesLostDirectory(t *testing.T) {
+	store, _ := newTestDistributedStore(t, 3, 2)
+	ctx := context.Background()
+
+	_, err := store.Write(ctx, "b", "k", strings.NewReader("payload"))
+	require.NoError(t, err)
+
+	require.NoError(t, os.RemoveAll(store.placement("b", "k")[0]))
+
+	data, err := readAll(t, store, "b", "k")
+	require.NoError(t, err)
+	assert.Equal(t, "payload", data)
+}
+
+func TestDistributedFileStore_ScrubRepairsBitRot(t *testing.T) {
+	store, _ := newTestDistributedStore(t, 3, 2)
+	ctx := context.Background()
+
+	_, err := store.Write(ctx, "b", "k", strings.NewReader("original"))
+	require.NoError(t, err)
+
+	placement := store.placement("b", "k")
+	require.NoError(t, os.WriteFile(objectPath(placement[0], "b", "k"), []byte("0riginal"), 0644))
+
+	report, err := store.Scrub(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Objects)
+	assert.Equal(t, 1, report.Repaired)
+	assert.Equal(t, 0, report.Unrecoverable)
+
+	data, err := readAll(t, store, "b", "k")
+	require.NoError(t, err)
+	assert.Equal(t, "original", data)
+}
+
+func TestDistributedFileStore_ReadSkipsCorruptedReplica(t *testing.T) {
+	store, _ := newTestDistributedStore(t, 3, 2)
+	ctx := context.Background()
+
+	_, err := store.Write(ctx, "b", "k", strings.NewReader("original"))
+	require.NoError(t, err)
+
+	corrupted := objectPath(store.placement("b", "k")[0], "b", "k")
+	require.NoError(t, os.WriteFile(corrupted, []byte("0riginal"), 0644))
+
+	data, err := readAll(t, store, "b", "k")
+	require.NoError(t, err)
+	assert.Equal(t, "original", data)
+
+	// The read queued the corrupted copy for repair
+	store.repairs.Wait()
+	restored, err := os.ReadFile(corrupted)
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(restored))
+}
+
+func TestDistributedFileStore_ReadAllReplicasCorrupted(t *testing.T) {
+	store, _ := newTestDistributedStore(t, 2, 2)
+	ctx := context.Background()
+
+	_, err := store.Write(ctx, "b", "k", strings.NewReader("original"))
+	require.NoError(t, err)
+	for _, dir := range store.placement("b", "k") {
+		require.NoError(t, os.WriteFile(objectPath(dir, "b", "k"), []byte("0riginal"), 0644))
+	}
+
+	_, err = readAll(t, store, "b", "k")
+	assert.Error(t, err)
+}
+
+func TestDistributedFileStore_ReadFailsAfterServingCorruptedReplica(t *testing.T) {
+	store, _ := newTestDistributedStore(t, 2, 2)
+	ctx := context.Background()
+
+	original := strings.Repeat("a", 64*1024)
+	_, err := store.Write(ctx, "b", "k", strings.NewReader(original))
+	require.NoError(t, err)
+	corrupted := objectPath(store.placement("b", "k")[0], "b", "k")
+	require.NoError(t, os.WriteFile(corrupted, []byte("b"+original[1:]), 0644))
+
+	// Part of the object is out before the mismatch shows, so the read fails
+	// rather than switching replicas midway.
+	_, err = readAll(t, store, "b", "k")
+	assert.Error(t, err)
+
+	store.repairs.Wait()
+	restored, err := os.ReadFile(corrupted)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(restored))
+}
+
+func TestDistributedFileStore_RangeReadLeavesVerificationToScrub(t *testing.T) {
+	store, _ := newTestDistributedStore(t, 1, 1)
+	ctx := context.Background()
+
+	_, err := store.Write(ctx, "b", "k", strings.NewReader("original"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(objectPath(store.placement("b", "k")[0], "b", "k"), []byte("0riginal"), 0644))
+
+	r, err := store.Read(ctx, "b", "k")
+	require.NoError(t, err)
+	defer r.Close()
+	_, err = r.(io.Seeker).Seek(4, io.SeekStart)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "inal", string(data))
+}
+
+func TestDistributedFileStore_ScrubRestoresMissingReplica(t *testing.T) {
+	store, _ := newTestDistributedStore(t, 3, 2)
+	ctx := context.Background()
+
+	_, err := store.Write(ctx, "b", "k", strings.NewReader("data"))
+	require.NoError(t, err)
+
+	lost := store.placement("b", "k")[1]
+	require.NoError(t, os.Remove(objectPath(lost, "b", "k")))
+
+	report, err := store.Scrub(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Repaired)
+	assert.FileExists(t, objectPath(lost, "b", "k"))
+}
+
+func TestDistributedFileStore_ScrubUnrecoverable(t *testing.T) {
+	store, _ := newTestDistributedStore(t, 2, 1)
+	ctx := context.Background()
+
+	_, err := store.Write(ctx, "b", "k", strings.NewReader("data"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(objectPath(store.placement("b", "k")[0], "b", "k"), []byte("dat4"), 0644))
+
+	report, err := store.Scrub(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Unrecoverable)
+}
+
+func TestDistributedFileStore_Delete(t *testing.T) {
+	store, dirs := newTestDistributedStore(t, 3, 3)
+	ctx := context.Background()
+
+	_, err := store.Write(ctx, "b", "k", strings.NewReader("data"))
+	require.NoError(t, err)
+	require.NoError(t, store.Delete(ctx, "b", "k"))
+
+	for _, dir := range dirs {
+		assert.NoFileExists(t, objectPath(dir, "b", "k"))
+	}
+	_, err = store.Read(ctx, "b", "k")
+	assert.Error(t, err)
+}
+
+func TestNewDistributedFileStore_KeepsFreshTempFiles(t *testing.T) {
+	store, dirs := newTestDistributedStore(t, 1, 1)
+	tmp := filepath.Join(dirs[0], tempDir)
+	fresh := filepath.Join(tmp, "obj-fresh")
+	stale := filepath.Join(tmp, "obj-stale")
+	require.NoError(t, os.WriteFile(fresh, []byte("in flight"), 0644))
+	require.NoError(t, os.WriteFile(stale, []byte("left over"), 0644))
+	old := time.Now().Add(-2 * staleTempAge)
+	require.NoError(t, os.Chtimes(stale, old, old))
+
+	// Another replica starting on the same directories
+	_, err := NewDistributedFileStore(store.dirs, 1)
+	require.NoError(t, err)
+
+	assert.FileExists(t, fresh)
+	assert.NoFileExists(t, stale)
+}
+
+func TestNewDistributedFileStore_InvalidReplicas(t *testing.T) {
+	_, err := NewDistributedFileStore([]string{t.TempDir()}, 2)
+	assert.Error(t, err)
+	_, err = NewDistributedFileStore(nil, 1)
+	assert.Error(t, err)
+}
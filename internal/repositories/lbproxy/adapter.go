@@ -0,0 +1,309 @@
+// Package lbproxy is a load balancer data plane that runs in-process: an HTTP
+// and TCP reverse proxy written in Go, as an alternative to the nginx
+// containers of the docker adapter.
+package lbproxy
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+)
+
+// maxLogEntries caps the access log buffer of a load balancer between two
+// collections; newer entries are dropped once it is full.
+const maxLogEntries = 100000
+
+// TargetResolver returns the address the proxy dials for a target.
+type TargetResolver func(ctx context.Context, t *domain.LBTarget) (string, error)
+
+// HostPortResolver dials targets on the host port their instance publishes
+// for the target port, as the health checks do. Container names only resolve
+// inside the VPC network, which the API process is not attached to.
+func HostPortResolver(instanceRepo ports.InstanceRepository) TargetResolver {
+	return func(ctx context.Context, t *domain.LBTarget) (string, error) {
+		inst, err := instanceRepo.GetByID(ctx, t.InstanceID)
+		if err != nil {
+			return "", err
+		}
+		for _, mapping := range strings.Split(inst.Ports, ",") {
+			host, container, ok := strings.Cut(strings.TrimSpace(mapping), ":")
+			if ok && container == strconv.Itoa(t.Port) {
+				return net.JoinHostPort("localhost", host), nil
+			}
+		}
+		return "", fmt.Errorf("instance %s publishes no host port for port %d", t.InstanceID, t.Port)
+	}
+}
+
+// Adapter serves load balancers from in-process listeners bound to the host
+// ports of their listeners. Configuration changes are applied to the running
+// listeners in place, without dropping connections.
+type Adapter struct {
+	resolve TargetResolver
+
+	mu      sync.Mutex
+	proxies map[uuid.UUID]*lbProxy
+}
+
+func NewAdapter(resolve TargetResolver) *Adapter {
+	return &Adapter{
+		resolve: resolve,
+		proxies: make(map[uuid.UUID]*lbProxy),
+	}
+}
+
+// TargetAddress returns the address the proxy dials for a target.
+func (a *Adapter) TargetAddress(ctx context.Context, lb *domain.LoadBalancer, t *domain.LBTarget) (string, error) {
+	return a.resolve(ctx, t)
+}
+
+// DeployProxy starts serving a load balancer. The returned ID is the load
+// balancer's own, as there is no container.
+func (a *Adapter) DeployProxy(ctx context.Context, lb *domain.LoadBalancer, targets []*domain.LBTarget) (string, error) {
+	if err := a.UpdateProxyConfig(ctx, lb, targets); err != nil {
+		return "", err
+	}
+	return lb.ID.String(), nil
+}
+
+// UpdateProxyConfig applies a configuration, starting the proxy if it is not
+// running, e.g. after a restart of the API.
+func (a *Adapter) UpdateProxyConfig(ctx context.Context, lb *domain.LoadBalancer, targets []*domain.LBTarget) error {
+	groups := make(map[string][]*backend)
+	for _, t := range targets {
+		addr, err := a.resolve(ctx, t)
+		if err != nil {
+			continue
+		}
+		group := t.TargetGroup
+		if group == "" {
+			group = domain.DefaultTargetGroup
+		}
+		groups[group] = append(groups[group], newBackend(t.InstanceID, addr, t.Weight))
+	}
+
+	a.mu.Lock()
+	p, ok := a.proxies[lb.ID]
+	if !ok {
+		p = newLBProxy()
+		a.proxies[lb.ID] = p
+	}
+	a.mu.Unlock()
+
+	return p.apply(lb, groups)
+}
+
+func (a *Adapter) RemoveProxy(ctx context.Context, lbID uuid.UUID) error {
+	a.mu.Lock()
+	p, ok := a.proxies[lbID]
+	delete(a.proxies, lbID)
+	a.mu.Unlock()
+
+	if ok {
+		p.stop()
+	}
+	return nil
+}
+
+func (a *Adapter) CollectAccessLogs(ctx context.Context, lbID uuid.UUID) ([]domain.LBAccessLogEntry, error) {
+	a.mu.Lock()
+	p, ok := a.proxies[lbID]
+	a.mu.Unlock()
+
+	if !ok {
+		return nil, nil
+	}
+	return p.takeLogs(), nil
+}
+
+// Close stops every proxy.
+func (a *Adapter) Close() {
+	a.mu.Lock()
+	proxies := a.proxies
+	a.proxies = make(map[uuid.UUID]*lbProxy)
+	a.mu.Unlock()
+
+	for _, p := range proxies {
+		p.stop()
+	}
+}
+
+// lbProxy is the running data plane of one load balancer.
+type lbProxy struct {
+	mu        sync.RWMutex
+	balancing domain.LBBalancing
+	pools     map[string]*pool
+	listeners map[int]*listener
+
+	logMu sync.Mutex
+	logs  []domain.LBAccessLogEntry
+}
+
+func newLBProxy() *lbProxy {
+	return &lbProxy{
+		pools:     make(map[string]*pool),
+		listeners: make(map[int]*listener),
+	}
+}
+
+func (p *lbProxy) apply(lb *domain.LoadBalancer, groups map[string][]*backend) error {
+	served := lb.ServedListeners()
+	certs := make(map[int]*tls.Certificate)
+	for _, l := range served {
+		if l.Protocol != domain.LBProtocolHTTPS {
+			continue
+		}
+		cert, err := tls.X509KeyPair(l.Certificate, l.Certificate)
+		if err != nil {
+			return fmt.Errorf("listener %d: invalid certificate: %w", l.Port, err)
+		}
+		certs[l.Port] = &cert
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.balancing = domain.LBBalancing{Algorithm: lb.Algorithm, HashHeader: lb.HashHeader, Stickiness: lb.Stickiness}
+	for name, pl := range p.pools {
+		if _, ok := groups[name]; !ok {
+			pl.update(lb.Algorithm, nil)
+		}
+	}
+	for name, backends := range groups {
+		pl, ok := p.pools[name]
+		if !ok {
+			pl = &pool{}
+			p.pools[name] = pl
+		}
+		pl.update(lb.Algorithm, backends)
+	}
+
+	wanted := make(map[int]bool, len(served))
+	for _, l := range served {
+		wanted[l.Port] = true
+		cfg := *l
+		if cur, ok := p.listeners[l.Port]; ok {
+			if cur.protocol == l.Protocol {
+				cur.configure(&cfg, certs[l.Port])
+				continue
+			}
+			cur.stop(false)
+			delete(p.listeners, l.Port)
+		}
+
+		ln, err := startListener(p, &cfg, certs[l.Port])
+		if err != nil {
+			return fmt.Errorf("listener %d: %w", l.Port, err)
+		}
+		p.listeners[l.Port] = ln
+	}
+	for port, ln := range p.listeners {
+		if !wanted[port] {
+			ln.stop(false)
+			delete(p.listeners, port)
+		}
+	}
+	return nil
+}
+
+// pick returns a backend of a target group, or nil if it has none. A
+// non-empty key is hashed, see requestKey.
+func (p *lbProxy) pick(group, key string) *backend {
+	p.mu.RLock()
+	pl := p.pools[group]
+	p.mu.RUnlock()
+
+	if pl == nil {
+		return nil
+	}
+	return pl.pick(key)
+}
+
+// requestKey returns the key an http request is hashed by, or "" to balance
+// it by the algorithm. Requests without a sticky session get a new random
+// session key and the cookie carrying it, as the nginx proxy does.
+func (p *lbProxy) requestKey(r *http.Request) (string, *http.Cookie) {
+	p.mu.RLock()
+	b := p.balancing
+	p.mu.RUnlock()
+
+	if b.Stickiness.Enabled {
+		if c, err := r.Cookie(domain.LBStickyCookie); err == nil && c.Value != "" {
+			return c.Value, nil
+		}
+		key := newSessionKey()
+		return key, &http.Cookie{
+			Name:     domain.LBStickyCookie,
+			Value:    key,
+			MaxAge:   b.Stickiness.TTLSeconds,
+			Path:     "/",
+			HttpOnly: true,
+		}
+	}
+
+	switch b.Algorithm {
+	case domain.LBAlgorithmSourceIPHash:
+		return clientIP(r.RemoteAddr), nil
+	case domain.LBAlgorithmHeaderHash:
+		return r.Header.Get(b.HashHeader), nil
+	}
+	return "", nil
+}
+
+// connKey returns the key a TCP connection is hashed by. Both hash
+// algorithms hash the client address, as connections carry no headers.
+func (p *lbProxy) connKey(c net.Conn) string {
+	p.mu.RLock()
+	algorithm := p.balancing.Algorithm
+	p.mu.RUnlock()
+
+	if algorithm == domain.LBAlgorithmSourceIPHash || algorithm == domain.LBAlgorithmHeaderHash {
+		return clientIP(c.RemoteAddr().String())
+	}
+	return ""
+}
+
+func newSessionKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (p *lbProxy) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for port, ln := range p.listeners {
+		ln.stop(true)
+		delete(p.listeners, port)
+	}
+}
+
+func (p *lbProxy) log(e domain.LBAccessLogEntry) {
+	p.logMu.Lock()
+	defer p.logMu.Unlock()
+
+	if len(p.logs) < maxLogEntries {
+		p.logs = append(p.logs, e)
+	}
+}
+
+func (p *lbProxy) takeLogs() []domain.LBAccessLogEntry {
+	p.logMu.Lock()
+	defer p.logMu.Unlock()
+
+	logs := p.logs
+	p.logs = nil
+	return logs
+}
@@ -0,0 +1,351 @@
+package lbproxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mapResolver dials targets at fixed addresses, by instance.
+type mapResolver map[uuid.UUID]string
+
+func (m mapResolver) resolve(ctx context.Context, t *domain.LBTarget) (string, error) {
+	if addr, ok := m[t.InstanceID]; ok {
+		return addr, nil
+	}
+	return "", fmt.Errorf("unknown target %s", t.InstanceID)
+}
+
+type mockInstanceRepo struct {
+	mock.Mock
+	ports.InstanceRepository
+}
+
+func (m *mockInstanceRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Instance, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Instance), args.Error(1)
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func namedBackend(t *testing.T, name string) (*httptest.Server, string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s %s", name, r.Host, r.Header.Get("X-Forwarded-Proto"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, strings.TrimPrefix(srv.URL, "http://")
+}
+
+func get(t *testing.T, url, host string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if host != "" {
+		req.Host = host
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if loc := resp.Header.Get("Location"); loc != "" {
+		return resp.StatusCode, loc
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestAdapter_HTTP(t *testing.T) {
+	ctx := context.Background()
+	_, webAddr := namedBackend(t, "web")
+	_, apiAddr := namedBackend(t, "api")
+	webID, apiID := uuid.New(), uuid.New()
+	adapter := NewAdapter(mapResolver{webID: webAddr, apiID: apiAddr}.resolve)
+	t.Cleanup(adapter.Close)
+
+	port := freePort(t)
+	lb := &domain.LoadBalancer{ID: uuid.New(), Port: port, Algorithm: domain.LBAlgorithmRoundRobin}
+	targets := []*domain.LBTarget{
+		{InstanceID: webID, Port: 80, Weight: 1},
+		{InstanceID: apiID, Port: 80, Weight: 1, TargetGroup: "api"},
+	}
+	_, err := adapter.DeployProxy(ctx, lb, targets)
+	require.NoError(t, err)
+	base := fmt.Sprintf("http://127.0.0.1:%d", port)
+
+	status, body := get(t, base+"/", "example.com")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "web example.com http", body)
+
+	t.Run("applies routing rules in place", func(t *testing.T) {
+		lb.Listeners = []*domain.LBListener{{
+			Port: port, Protocol: domain.LBProtocolHTTP, DefaultTargetGroup: domain.DefaultTargetGroup,
+			Rules: []*domain.LBRoutingRule{{PathPrefix: "/api/", TargetGroup: "api"}},
+		}}
+		require.NoError(t, adapter.UpdateProxyConfig(ctx, lb, targets))
+
+		_, body := get(t, base+"/api/users", "example.com")
+		assert.True(t, strings.HasPrefix(body, "api "), body)
+		_, body = get(t, base+"/", "example.com")
+		assert.True(t, strings.HasPrefix(body, "web "), body)
+	})
+
+	t.Run("empty group answers 503", func(t *testing.T) {
+		require.NoError(t, adapter.UpdateProxyConfig(ctx, lb, targets[1:]))
+		status, body := get(t, base+"/", "")
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		assert.Contains(t, body, "No targets available")
+	})
+
+	t.Run("redirect listener", func(t *testing.T) {
+		redirectPort := freePort(t)
+		lb.Listeners = append(lb.Listeners, &domain.LBListener{Port: redirectPort, Protocol: domain.LBProtocolHTTP, RedirectPort: 443})
+		require.NoError(t, adapter.UpdateProxyConfig(ctx, lb, targets))
+
+		status, loc := get(t, fmt.Sprintf("http://127.0.0.1:%d/a?b=c", redirectPort), "example.com")
+		assert.Equal(t, http.StatusMovedPermanently, status)
+		assert.Equal(t, "https://example.com/a?b=c", loc)
+	})
+
+	t.Run("access logs", func(t *testing.T) {
+		logs, err := adapter.CollectAccessLogs(ctx, lb.ID)
+		require.NoError(t, err)
+		require.NotEmpty(t, logs)
+
+		first := logs[0]
+		assert.Equal(t, domain.LBProtocolHTTP, first.Protocol)
+		assert.Equal(t, port, first.ListenerPort)
+		assert.Equal(t, "127.0.0.1", first.ClientIP)
+		assert.Equal(t, "example.com", first.Host)
+		assert.Equal(t, http.StatusOK, first.Status)
+		assert.Equal(t, webID, *first.InstanceID)
+		assert.Equal(t, webAddr, first.UpstreamAddr)
+
+		var sawUnavailable bool
+		for _, e := range logs {
+			if e.Status == http.StatusServiceUnavailable {
+				sawUnavailable = true
+				assert.Nil(t, e.InstanceID)
+			}
+		}
+		assert.True(t, sawUnavailable)
+
+		again, _ := adapter.CollectAccessLogs(ctx, lb.ID)
+		assert.Empty(t, again)
+	})
+
+	t.Run("remove closes the listeners", func(t *testing.T) {
+		require.NoError(t, adapter.RemoveProxy(ctx, lb.ID))
+		_, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), time.Second)
+		assert.Error(t, err)
+	})
+}
+
+func TestAdapter_WeightedDistribution(t *testing.T) {
+	ctx := context.Background()
+	_, heavyAddr := namedBackend(t, "heavy")
+	_, lightAddr := namedBackend(t, "light")
+	heavyID, lightID := uuid.New(), uuid.New()
+	adapter := NewAdapter(mapResolver{heavyID: heavyAddr, lightID: lightAddr}.resolve)
+	t.Cleanup(adapter.Close)
+
+	port := freePort(t)
+	lb := &domain.LoadBalancer{ID: uuid.New(), Port: port, Algorithm: domain.LBAlgorithmWeighted}
+	_, err := adapter.DeployProxy(ctx, lb, []*domain.LBTarget{
+		{InstanceID: heavyID, Port: 80, Weight: 3},
+		{InstanceID: lightID, Port: 80, Weight: 1},
+	})
+	require.NoError(t, err)
+
+	counts := map[string]int{}
+	for i := 0; i < 40; i++ {
+		_, body := get(t, fmt.Sprintf("http://127.0.0.1:%d/", port), "")
+		counts[strings.Fields(body)[0]]++
+	}
+	assert.Equal(t, 30, counts["heavy"])
+	assert.Equal(t, 10, counts["light"])
+}
+
+func TestAdapter_StickySessions(t *testing.T) {
+	ctx := context.Background()
+	ids := make(mapResolver)
+	for _, name := range []string{"a", "b", "c"} {
+		_, addr := namedBackend(t, name)
+		ids[uuid.New()] = addr
+	}
+	adapter := NewAdapter(ids.resolve)
+	t.Cleanup(adapter.Close)
+
+	port := freePort(t)
+	lb := &domain.LoadBalancer{
+		ID:         uuid.New(),
+		Port:       port,
+		Stickiness: domain.LBStickiness{Enabled: true, TTLSeconds: 600},
+	}
+	var targets []*domain.LBTarget
+	for id := range ids {
+		targets = append(targets, &domain.LBTarget{InstanceID: id, Port: 80, Weight: 1})
+	}
+	_, err := adapter.DeployProxy(ctx, lb, targets)
+	require.NoError(t, err)
+	url := fmt.Sprintf("http://127.0.0.1:%d/", port)
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	cookies := resp.Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, domain.LBStickyCookie, cookies[0].Name)
+	assert.Equal(t, 600, cookies[0].MaxAge)
+
+	for i := 0; i < 10; i++ {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.AddCookie(cookies[0])
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		again, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, string(body), string(again))
+		assert.Empty(t, resp.Cookies(), "an existing session is not reissued")
+	}
+}
+
+func TestAdapter_HeaderHash(t *testing.T) {
+	ctx := context.Background()
+	ids := make(mapResolver)
+	for _, name := range []string{"a", "b", "c"} {
+		_, addr := namedBackend(t, name)
+		ids[uuid.New()] = addr
+	}
+	adapter := NewAdapter(ids.resolve)
+	t.Cleanup(adapter.Close)
+
+	port := freePort(t)
+	lb := &domain.LoadBalancer{ID: uuid.New(), Port: port, Algorithm: domain.LBAlgorithmHeaderHash, HashHeader: "X-Tenant-ID"}
+	var targets []*domain.LBTarget
+	for id := range ids {
+		targets = append(targets, &domain.LBTarget{InstanceID: id, Port: 80, Weight: 1})
+	}
+	_, err := adapter.DeployProxy(ctx, lb, targets)
+	require.NoError(t, err)
+
+	served := func(tenant string) string {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d/", port), nil)
+		if tenant != "" {
+			req.Header.Set("X-Tenant-ID", tenant)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return strings.Fields(string(body))[0]
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < 30; i++ {
+		tenant := fmt.Sprintf("tenant-%d", i)
+		first := served(tenant)
+		assert.Equal(t, first, served(tenant), tenant)
+		seen[first] = true
+	}
+	assert.Len(t, seen, 3, "tenants spread over every target")
+
+	// Requests without the header rotate.
+	rotated := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		rotated[served("")] = true
+	}
+	assert.Len(t, rotated, 3)
+}
+
+func TestAdapter_TCP(t *testing.T) {
+	ctx := context.Background()
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { echo.Close() })
+	go func() {
+		for {
+			c, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				data, _ := io.ReadAll(c)
+				_, _ = c.Write([]byte(strings.ToUpper(string(data))))
+			}()
+		}
+	}()
+
+	dbID := uuid.New()
+	adapter := NewAdapter(mapResolver{dbID: echo.Addr().String()}.resolve)
+	t.Cleanup(adapter.Close)
+
+	httpPort, tcpPort := freePort(t), freePort(t)
+	lb := &domain.LoadBalancer{
+		ID:   uuid.New(),
+		Port: httpPort,
+		Listeners: []*domain.LBListener{
+			{Port: tcpPort, Protocol: domain.LBProtocolTCP, DefaultTargetGroup: "db"},
+		},
+	}
+	_, err = adapter.DeployProxy(ctx, lb, []*domain.LBTarget{{InstanceID: dbID, Port: 5432, Weight: 1, TargetGroup: "db"}})
+	require.NoError(t, err)
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", tcpPort))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("select 1"))
+	require.NoError(t, err)
+	require.NoError(t, conn.(*net.TCPConn).CloseWrite())
+	reply, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	conn.Close()
+	assert.Equal(t, "SELECT 1", string(reply))
+
+	assert.Eventually(t, func() bool {
+		logs, _ := adapter.CollectAccessLogs(ctx, lb.ID)
+		if len(logs) != 1 {
+			return false
+		}
+		e := logs[0]
+		return e.Protocol == domain.LBProtocolTCP && e.Status == http.StatusOK && e.BytesSent == 8 && *e.InstanceID == dbID
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestHostPortResolver(t *testing.T) {
+	ctx := context.Background()
+	instID := uuid.New()
+	repo := new(mockInstanceRepo)
+	repo.On("GetByID", mock.Anything, instID).Return(&domain.Instance{ID: instID, Ports: "30080:80, 30443:443"}, nil)
+	resolve := HostPortResolver(repo)
+
+	addr, err := resolve(ctx, &domain.LBTarget{InstanceID: instID, Port: 443})
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:30443", addr)
+
+	_, err = resolve(ctx, &domain.LBTarget{InstanceID: instID, Port: 8080})
+	assert.Error(t, err)
+}
@@ -0,0 +1,192 @@
+package lbproxy
+
+import (
+	"hash/fnv"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+)
+
+// backend is a target of a target group as seen by the proxy.
+type backend struct {
+	instanceID uuid.UUID
+	addr       string
+	weight     int
+	active     atomic.Int64
+	// current is the smooth weighted round-robin counter, guarded by the
+	// pool's mutex.
+	current int
+	proxy   *httputil.ReverseProxy
+}
+
+func newBackend(instanceID uuid.UUID, addr string, weight int) *backend {
+	if weight < 1 {
+		weight = 1
+	}
+	b := &backend{instanceID: instanceID, addr: addr, weight: weight}
+	target := &url.URL{Scheme: "http", Host: addr}
+	b.proxy = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			// Pass the client's Host through, as the nginx proxy does.
+			pr.Out.Host = pr.In.Host
+			pr.Out.Header.Set("X-Real-IP", clientIP(pr.In.RemoteAddr))
+		},
+		ErrorHandler: badGateway,
+	}
+	return b
+}
+
+// ringPointsPerWeight is the number of points a backend of weight 1 has on
+// the hash ring, as in nginx's consistent hashing.
+const ringPointsPerWeight = 160
+
+// pool balances requests over the backends of one target group.
+type pool struct {
+	mu        sync.Mutex
+	algorithm string
+	backends  []*backend
+	// ring is the consistent hash ring of the backends, built on first use.
+	ring []ringPoint
+}
+
+type ringPoint struct {
+	hash    uint64
+	backend *backend
+}
+
+// update replaces the backends of the pool. Backends that stay keep their
+// state, so in-flight connections still count towards least-conn and the
+// rotation is not reset on every configuration change.
+func (p *pool) update(algorithm string, backends []*backend) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	existing := make(map[string]*backend, len(p.backends))
+	for _, b := range p.backends {
+		existing[b.addr] = b
+	}
+	next := make([]*backend, 0, len(backends))
+	for _, b := range backends {
+		if old, ok := existing[b.addr]; ok && old.instanceID == b.instanceID {
+			old.weight = b.weight
+			b = old
+		}
+		next = append(next, b)
+	}
+	p.algorithm = algorithm
+	p.backends = next
+	p.ring = nil
+}
+
+// pick returns the backend for the next request, or nil if the pool is empty.
+// A non-empty key is hashed onto the ring instead of applying the algorithm.
+func (p *pool) pick(key string) *backend {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.backends) == 0 {
+		return nil
+	}
+	if key != "" {
+		return p.hashed(key)
+	}
+	if p.algorithm == domain.LBAlgorithmLeastConn {
+		return p.leastConn()
+	}
+	return p.weightedRoundRobin()
+}
+
+// weightedRoundRobin is nginx's smooth weighted round-robin: every pick adds
+// each backend's weight to its counter and takes the backend with the highest
+// counter, which then pays back the total weight. Picks are spread evenly and
+// in proportion to the weights.
+func (p *pool) weightedRoundRobin() *backend {
+	var best *backend
+	total := 0
+	for _, b := range p.backends {
+		b.current += b.weight
+		total += b.weight
+		if best == nil || b.current > best.current {
+			best = b
+		}
+	}
+	best.current -= total
+	return best
+}
+
+// leastConn picks the backend with the fewest active connections relative to
+// its weight, breaking ties by weighted round-robin.
+func (p *pool) leastConn() *backend {
+	var candidates []*backend
+	var bestActive int64
+	var bestWeight int
+	for _, b := range p.backends {
+		active := b.active.Load()
+		// Compare active/weight without division.
+		switch {
+		case candidates == nil || active*int64(bestWeight) < bestActive*int64(b.weight):
+			candidates = []*backend{b}
+			bestActive, bestWeight = active, b.weight
+		case active*int64(bestWeight) == bestActive*int64(b.weight):
+			candidates = append(candidates, b)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	var best *backend
+	total := 0
+	for _, b := range candidates {
+		b.current += b.weight
+		total += b.weight
+		if best == nil || b.current > best.current {
+			best = b
+		}
+	}
+	best.current -= total
+	return best
+}
+
+// hashed maps a key to the backend owning the next point of the ring. Each
+// backend has points in proportion to its weight, so keys spread by weight,
+// and a backend joining or leaving only moves the keys of its own points.
+func (p *pool) hashed(key string) *backend {
+	if p.ring == nil {
+		for _, b := range p.backends {
+			for i := 0; i < b.weight*ringPointsPerWeight; i++ {
+				p.ring = append(p.ring, ringPoint{hash: hashKey(b.addr + "-" + strconv.Itoa(i)), backend: b})
+			}
+		}
+		sort.Slice(p.ring, func(i, j int) bool { return p.ring[i].hash < p.ring[j].hash })
+	}
+
+	h := hashKey(key)
+	i := sort.Search(len(p.ring), func(i int) bool { return p.ring[i].hash >= h })
+	if i == len(p.ring) {
+		i = 0
+	}
+	return p.ring[i].backend
+}
+
+// hashKey is FNV-1a with a final mix, as FNV alone spreads short keys that
+// differ only at the end poorly.
+func hashKey(key string) uint64 {
+	f := fnv.New64a()
+	_, _ = f.Write([]byte(key))
+	h := f.Sum64()
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
@@ -0,0 +1,141 @@
+package lbproxy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func testBackends(weights ...int) []*backend {
+	res := make([]*backend, len(weights))
+	for i, w := range weights {
+		res[i] = newBackend(uuid.New(), "10.0.0."+string(rune('1'+i))+":80", w)
+	}
+	return res
+}
+
+func TestPool_WeightedRoundRobin(t *testing.T) {
+	for _, algo := range []string{domain.LBAlgorithmRoundRobin, domain.LBAlgorithmWeighted} {
+		t.Run(algo, func(t *testing.T) {
+			backends := testBackends(5, 1, 1)
+			p := &pool{}
+			p.update(algo, backends)
+
+			counts := make(map[*backend]int)
+			var sequence []*backend
+			for i := 0; i < 700; i++ {
+				b := p.pick("")
+				counts[b]++
+				sequence = append(sequence, b)
+			}
+
+			assert.Equal(t, 500, counts[backends[0]])
+			assert.Equal(t, 100, counts[backends[1]])
+			assert.Equal(t, 100, counts[backends[2]])
+			// Smooth: the light backends are interleaved, as nginx does: a a b a c a a.
+			assert.Equal(t, []*backend{
+				backends[0], backends[0], backends[1], backends[0], backends[2], backends[0], backends[0],
+			}, sequence[:7])
+		})
+	}
+}
+
+func TestPool_EqualWeightsRotate(t *testing.T) {
+	backends := testBackends(1, 1, 1)
+	p := &pool{}
+	p.update(domain.LBAlgorithmRoundRobin, backends)
+
+	first := []*backend{p.pick(""), p.pick(""), p.pick("")}
+	assert.ElementsMatch(t, backends, first)
+	assert.Equal(t, first[0], p.pick(""))
+}
+
+func TestPool_LeastConn(t *testing.T) {
+	backends := testBackends(1, 1, 2)
+	p := &pool{}
+	p.update(domain.LBAlgorithmLeastConn, backends)
+
+	backends[0].active.Store(3)
+	backends[1].active.Store(1)
+	backends[2].active.Store(4)
+	// 1/1 beats 4/2 and 3/1.
+	assert.Equal(t, backends[1], p.pick(""))
+
+	backends[1].active.Store(2)
+	// 2/1 ties with 4/2; ties go by weight.
+	picks := map[*backend]int{}
+	for i := 0; i < 30; i++ {
+		picks[p.pick("")]++
+	}
+	assert.Equal(t, 10, picks[backends[1]])
+	assert.Equal(t, 20, picks[backends[2]])
+	assert.Zero(t, picks[backends[0]])
+}
+
+func TestPool_UpdateKeepsBackendState(t *testing.T) {
+	backends := testBackends(1, 1)
+	p := &pool{}
+	p.update(domain.LBAlgorithmLeastConn, backends)
+	backends[0].active.Store(7)
+
+	again := []*backend{
+		newBackend(backends[0].instanceID, backends[0].addr, 3),
+		newBackend(uuid.New(), "10.0.0.9:80", 1),
+	}
+	p.update(domain.LBAlgorithmLeastConn, again)
+
+	assert.Same(t, backends[0], p.backends[0])
+	assert.Equal(t, int64(7), p.backends[0].active.Load())
+	assert.Equal(t, 3, p.backends[0].weight)
+	assert.Same(t, again[1], p.backends[1])
+}
+
+func TestPool_Empty(t *testing.T) {
+	p := &pool{}
+	assert.Nil(t, p.pick(""))
+}
+
+func TestPool_Hashed(t *testing.T) {
+	backends := testBackends(3, 1)
+	p := &pool{}
+	p.update(domain.LBAlgorithmRoundRobin, backends)
+
+	t.Run("same key same backend", func(t *testing.T) {
+		first := p.pick("10.1.2.3")
+		for i := 0; i < 10; i++ {
+			assert.Same(t, first, p.pick("10.1.2.3"))
+		}
+	})
+
+	t.Run("keys spread by weight", func(t *testing.T) {
+		counts := map[*backend]int{}
+		for i := 0; i < 10000; i++ {
+			counts[p.pick(fmt.Sprintf("session-%d", i))]++
+		}
+		assert.InDelta(t, 7500, counts[backends[0]], 500)
+		assert.InDelta(t, 2500, counts[backends[1]], 500)
+	})
+
+	t.Run("adding a backend only moves keys to it", func(t *testing.T) {
+		before := map[string]*backend{}
+		for i := 0; i < 1000; i++ {
+			key := fmt.Sprintf("user-%d", i)
+			before[key] = p.pick(key)
+		}
+
+		added := newBackend(uuid.New(), "10.0.0.9:80", 1)
+		p.update(domain.LBAlgorithmRoundRobin, append(append([]*backend{}, backends...), added))
+
+		moved := 0
+		for key, b := range before {
+			if now := p.pick(key); now != b {
+				assert.Same(t, added, now, key)
+				moved++
+			}
+		}
+		assert.InDelta(t, 200, moved, 80)
+	})
+}
@@ -0,0 +1,298 @@
+package lbproxy
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/poyrazk/thecloud/internal/core/domain"
+)
+
+const (
+	dialTimeout = 5 * time.Second
+	// drainTimeout bounds how long a replaced http listener waits for
+	// in-flight requests before closing them.
+	drainTimeout = 30 * time.Second
+)
+
+// listener serves one port of a load balancer. Its configuration is swapped
+// atomically, so rule and certificate changes apply to the next request.
+type listener struct {
+	proxy    *lbProxy
+	port     int
+	protocol string
+	cfg      atomic.Pointer[domain.LBListener]
+	cert     atomic.Pointer[tls.Certificate]
+
+	ln  net.Listener
+	srv *http.Server
+
+	// conns tracks open TCP connections so they can be closed on removal.
+	connMu sync.Mutex
+	conns  map[net.Conn]struct{}
+}
+
+func startListener(p *lbProxy, cfg *domain.LBListener, cert *tls.Certificate) (*listener, error) {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
+	if err != nil {
+		return nil, err
+	}
+
+	l := &listener{
+		proxy:    p,
+		port:     cfg.Port,
+		protocol: cfg.Protocol,
+		ln:       ln,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	l.configure(cfg, cert)
+
+	switch cfg.Protocol {
+	case domain.LBProtocolTCP:
+		go l.serveTCP()
+	case domain.LBProtocolHTTPS:
+		l.srv = &http.Server{Handler: l, ReadHeaderTimeout: 30 * time.Second}
+		tlsLn := tls.NewListener(ln, &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+				return l.cert.Load(), nil
+			},
+		})
+		go l.serveHTTP(tlsLn)
+	default:
+		l.srv = &http.Server{Handler: l, ReadHeaderTimeout: 30 * time.Second}
+		go l.serveHTTP(ln)
+	}
+	return l, nil
+}
+
+func (l *listener) configure(cfg *domain.LBListener, cert *tls.Certificate) {
+	l.cfg.Store(cfg)
+	if cert != nil {
+		l.cert.Store(cert)
+	}
+}
+
+func (l *listener) serveHTTP(ln net.Listener) {
+	if err := l.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("lbproxy: listener %d stopped: %v", l.port, err)
+	}
+}
+
+// stop closes the listener. In-flight requests and connections are allowed
+// to finish unless force is set.
+func (l *listener) stop(force bool) {
+	if l.srv != nil {
+		if force {
+			_ = l.srv.Close()
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+			defer cancel()
+			if err := l.srv.Shutdown(ctx); err != nil {
+				_ = l.srv.Close()
+			}
+		}()
+		return
+	}
+
+	_ = l.ln.Close()
+	if force {
+		l.connMu.Lock()
+		for c := range l.conns {
+			_ = c.Close()
+		}
+		l.connMu.Unlock()
+	}
+}
+
+func (l *listener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	cfg := l.cfg.Load()
+	rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+	var b *backend
+	if cfg.RedirectPort != 0 {
+		http.Redirect(rec, r, redirectURL(r, cfg.RedirectPort), http.StatusMovedPermanently)
+	} else {
+		b = l.forward(rec, r, cfg)
+	}
+
+	e := domain.LBAccessLogEntry{
+		Time:            time.Now(),
+		Protocol:        domain.LBProtocolHTTP,
+		ListenerPort:    l.port,
+		ClientIP:        clientIP(r.RemoteAddr),
+		Method:          r.Method,
+		Host:            stripPort(r.Host),
+		Path:            r.URL.Path,
+		Status:          rec.status,
+		BytesSent:       rec.bytes,
+		DurationSeconds: time.Since(start).Seconds(),
+	}
+	if b != nil {
+		e.UpstreamAddr = b.addr
+		e.InstanceID = &b.instanceID
+	}
+	l.proxy.log(e)
+}
+
+// forward proxies a request to a target of the group it routes to and returns
+// that target, or answers 503 and returns nil if the group has none.
+func (l *listener) forward(w http.ResponseWriter, r *http.Request, cfg *domain.LBListener) *backend {
+	key, cookie := l.proxy.requestKey(r)
+	b := l.proxy.pick(route(cfg, r.Host, r.URL.Path), key)
+	if b == nil {
+		http.Error(w, "No targets available", http.StatusServiceUnavailable)
+		return nil
+	}
+	if cookie != nil {
+		http.SetCookie(w, cookie)
+	}
+
+	b.active.Add(1)
+	defer b.active.Add(-1)
+	b.proxy.ServeHTTP(w, r)
+	return b
+}
+
+// redirectURL points a request at https on the given port, leaving the port
+// out for 443.
+func redirectURL(r *http.Request, port int) string {
+	host := stripPort(r.Host)
+	if port != 443 {
+		host = net.JoinHostPort(host, strconv.Itoa(port))
+	}
+	return "https://" + host + r.URL.RequestURI()
+}
+
+func (l *listener) serveTCP() {
+	for {
+		conn, err := l.ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("lbproxy: listener %d stopped: %v", l.port, err)
+			}
+			return
+		}
+		go l.handleConn(conn)
+	}
+}
+
+// handleConn forwards a TCP connection to a target of the listener's default
+// group. Statuses follow nginx stream logging: 200 for a forwarded session,
+// 502 when no target could be reached.
+func (l *listener) handleConn(c net.Conn) {
+	l.track(c, true)
+	defer l.track(c, false)
+	defer c.Close()
+
+	start := time.Now()
+	e := domain.LBAccessLogEntry{
+		Protocol:     domain.LBProtocolTCP,
+		ListenerPort: l.port,
+		ClientIP:     clientIP(c.RemoteAddr().String()),
+		Status:       http.StatusBadGateway,
+	}
+
+	if b := l.proxy.pick(l.cfg.Load().DefaultTargetGroup, l.proxy.connKey(c)); b != nil {
+		e.UpstreamAddr = b.addr
+		e.InstanceID = &b.instanceID
+		if upstream, err := net.DialTimeout("tcp", b.addr, dialTimeout); err == nil {
+			b.active.Add(1)
+			e.BytesSent = pipe(c, upstream)
+			b.active.Add(-1)
+			e.Status = http.StatusOK
+		}
+	}
+
+	e.Time = time.Now()
+	e.DurationSeconds = time.Since(start).Seconds()
+	l.proxy.log(e)
+}
+
+func (l *listener) track(c net.Conn, open bool) {
+	l.connMu.Lock()
+	defer l.connMu.Unlock()
+	if open {
+		l.conns[c] = struct{}{}
+	} else {
+		delete(l.conns, c)
+	}
+}
+
+// pipe copies between a client and an upstream connection until the upstream
+// is done, passing half-closes along, and returns the bytes sent to the client.
+func pipe(client, upstream net.Conn) int64 {
+	done := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(upstream, client)
+		closeWrite(upstream)
+		close(done)
+	}()
+
+	sent, _ := io.Copy(client, upstream)
+	_ = client.Close()
+	_ = upstream.Close()
+	<-done
+	return sent
+}
+
+func closeWrite(c net.Conn) {
+	if tc, ok := c.(interface{ CloseWrite() error }); ok {
+		_ = tc.CloseWrite()
+	}
+}
+
+func clientIP(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
+
+func badGateway(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("lbproxy: %s %s: %v", r.Method, r.URL.Path, err)
+	w.WriteHeader(http.StatusBadGateway)
+}
+
+// responseRecorder captures the status and body size of a response for the
+// access log.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	// Informational responses precede the final one.
+	if !r.wroteHeader && status >= http.StatusOK {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush streamed responses.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
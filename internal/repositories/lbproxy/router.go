@@ -0,0 +1,74 @@
+package lbproxy
+
+import (
+	"net"
+	"strings"
+
+	"github.com/poyrazk/thecloud/internal/core/domain"
+)
+
+// route returns the target group of a request to an http(s) listener. It
+// follows the nginx configuration the docker adapter renders: the most
+// specific host wins first (exact, then the longest wildcard, then none),
+// and within it the longest path prefix. Host-less rules apply to every host
+// unless a rule of the matched host claims the same prefix, and "/" falls back
+// to the listener's default group.
+func route(l *domain.LBListener, host, path string) string {
+	host = strings.ToLower(stripPort(host))
+
+	matched := ""
+	for _, r := range l.Rules {
+		if r.Host == "" || !hostMatches(r.Host, host) {
+			continue
+		}
+		if matched == "" || moreSpecific(r.Host, matched) {
+			matched = r.Host
+		}
+	}
+
+	routes := map[string]string{"/": l.DefaultTargetGroup}
+	for _, r := range l.Rules {
+		if r.Host == "" {
+			routes[r.PathPrefix] = r.TargetGroup
+		}
+	}
+	if matched != "" {
+		for _, r := range l.Rules {
+			if r.Host == matched {
+				routes[r.PathPrefix] = r.TargetGroup
+			}
+		}
+	}
+
+	group, longest := "", -1
+	for prefix, g := range routes {
+		if strings.HasPrefix(path, prefix) && len(prefix) > longest {
+			group, longest = g, len(prefix)
+		}
+	}
+	return group
+}
+
+func hostMatches(pattern, host string) bool {
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+	return pattern == host
+}
+
+// moreSpecific reports whether host pattern a beats b: exact hosts beat
+// wildcards, and longer wildcards beat shorter ones.
+func moreSpecific(a, b string) bool {
+	aWild, bWild := strings.HasPrefix(a, "*"), strings.HasPrefix(b, "*")
+	if aWild != bWild {
+		return !aWild
+	}
+	return len(a) > len(b)
+}
+
+func stripPort(hostport string) string {
+	if host, _, err := net.SplitHostPort(hostport); err == nil {
+		return host
+	}
+	return hostport
+}
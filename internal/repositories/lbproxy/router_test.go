@@ -0,0 +1,38 @@
+package lbproxy
+
+import (
+	"testing"
+
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoute(t *testing.T) {
+	l := &domain.LBListener{
+		DefaultTargetGroup: "web",
+		Rules: []*domain.LBRoutingRule{
+			{PathPrefix: "/api/", TargetGroup: "api"},
+			{PathPrefix: "/api/admin/", TargetGroup: "admin"},
+			{Host: "*.example.com", PathPrefix: "/api/", TargetGroup: "tenant-api"},
+			{Host: "shop.example.com", PathPrefix: "/", TargetGroup: "shop"},
+		},
+	}
+
+	tests := []struct {
+		host, path, want string
+	}{
+		{"other.org", "/", "web"},
+		{"other.org", "/api/users", "api"},
+		{"other.org", "/api/admin/x", "admin"},
+		{"a.example.com", "/api/users", "tenant-api"},
+		{"a.example.com:8080", "/index.html", "web"},
+		{"a.example.com", "/api/admin/x", "admin"},
+		{"example.com", "/api/users", "api"},
+		{"shop.example.com", "/cart", "shop"},
+		// The exact host wins over the wildcard, so its /api/ is the host-less one.
+		{"SHOP.example.com", "/api/users", "api"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, route(l, tt.host, tt.path), "%s%s", tt.host, tt.path)
+	}
+}
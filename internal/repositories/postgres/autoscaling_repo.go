@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	appcontext "github.com/poyrazk/thecloud/internal/core/context"
 	"github.com/poyrazk/thecloud/internal/core/domain"
@@ -26,9 +27,10 @@ func NewAutoScalingRepo(db *pgxpool.Pool) *AutoScalingRepo {
 func (r *AutoScalingRepo) CreateGroup(ctx context.Context, group *domain.ScalingGroup) error {
 	query := `
 		INSERT INTO scaling_groups (
-			id, user_id, idempotency_key, name, vpc_id, load_balancer_id, image, ports,
-			min_instances, max_instances, desired_count, current_count, status, version, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+			id, user_id, idempotency_key, name, vpc_id, load_balancer_id, image, ports, launch_template_id, launch_template_version,
+			min_instances, max_instances, desired_count, current_count, health_check_type, health_check_grace_period_sec,
+			status, version, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
 	`
 	var idempotencyKey interface{}
 	if group.IdempotencyKey != "" {
@@ -37,126 +39,84 @@ func (r *AutoScalingRepo) CreateGroup(ctx context.Context, group *domain.Scaling
 
 	_, err := r.db.Exec(ctx, query,
 		group.ID, group.UserID, idempotencyKey, group.Name, group.VpcID, group.LoadBalancerID,
-		group.Image, group.Ports, group.MinInstances, group.MaxInstances,
-		group.DesiredCount, group.CurrentCount, group.Status, group.Version,
-		group.CreatedAt, group.UpdatedAt,
+		group.Image, group.Ports, group.LaunchTemplateID, templateVersionArg(group),
+		group.MinInstances, group.MaxInstances,
+		group.DesiredCount, group.CurrentCount, group.HealthCheckType, group.HealthCheckGracePeriodSec,
+		group.Status, group.Version, group.CreatedAt, group.UpdatedAt,
 	)
 	return err
 }
 
-func (r *AutoScalingRepo) GetGroupByID(ctx context.Context, id uuid.UUID) (*domain.ScalingGroup, error) {
-	userID := appcontext.UserIDFromContext(ctx)
-	query := `
-		SELECT id, user_id, idempotency_key, name, vpc_id, load_balancer_id, image, ports,
-			   min_instances, max_instances, desired_count, current_count, status, version, created_at, updated_at
-		FROM scaling_groups WHERE id = $1 AND user_id = $2
-	`
-	var g domain.ScalingGroup
-	var lbID *uuid.UUID
-	var ports sql.NullString
-	var idk sql.NullString
+// templateVersionArg stores no version for groups without a launch template.
+func templateVersionArg(group *domain.ScalingGroup) interface{} {
+	if group.LaunchTemplateID == nil {
+		return nil
+	}
+	return group.LaunchTemplateVersion
+}
+
+const groupColumns = `id, user_id, COALESCE(idempotency_key, ''), name, vpc_id, load_balancer_id, image, COALESCE(ports, ''),
+	launch_template_id, COALESCE(launch_template_version, 0),
+	min_instances, max_instances, desired_count, current_count, health_check_type, health_check_grace_period_sec,
+	status, suspended_processes, version, created_at, updated_at`
 
-	err := r.db.QueryRow(ctx, query, id, userID).Scan(
-		&g.ID, &g.UserID, &idk, &g.Name, &g.VpcID, &lbID, &g.Image, &ports,
+func scanGroup(row pgx.Row) (*domain.ScalingGroup, error) {
+	var g domain.ScalingGroup
+	var suspended []string
+	if err := row.Scan(
+		&g.ID, &g.UserID, &g.IdempotencyKey, &g.Name, &g.VpcID, &g.LoadBalancerID, &g.Image, &g.Ports,
+		&g.LaunchTemplateID, &g.LaunchTemplateVersion,
 		&g.MinInstances, &g.MaxInstances, &g.DesiredCount, &g.CurrentCount,
-		&g.Status, &g.Version, &g.CreatedAt, &g.UpdatedAt,
-	)
-	if err == pgx.ErrNoRows {
-		return nil, errs.New(errs.NotFound, "scaling group not found")
-	}
-	if err != nil {
+		&g.HealthCheckType, &g.HealthCheckGracePeriodSec,
+		&g.Status, &suspended, &g.Version, &g.CreatedAt, &g.UpdatedAt,
+	); err != nil {
 		return nil, err
 	}
-	g.LoadBalancerID = lbID
-	if ports.Valid {
-		g.Ports = ports.String
-	}
-	if idk.Valid {
-		g.IdempotencyKey = idk.String
+	for _, p := range suspended {
+		g.SuspendedProcesses = append(g.SuspendedProcesses, domain.ScalingProcess(p))
 	}
 	return &g, nil
 }
 
-func (r *AutoScalingRepo) GetGroupByIdempotencyKey(ctx context.Context, key string) (*domain.ScalingGroup, error) {
+func (r *AutoScalingRepo) GetGroupByID(ctx context.Context, id uuid.UUID) (*domain.ScalingGroup, error) {
 	userID := appcontext.UserIDFromContext(ctx)
-	query := `
-		SELECT id, user_id, idempotency_key, name, vpc_id, load_balancer_id, image, ports,
-			   min_instances, max_instances, desired_count, current_count, status, version, created_at, updated_at
-		FROM scaling_groups WHERE idempotency_key = $1 AND user_id = $2
-	`
-	var g domain.ScalingGroup
-	var lbID *uuid.UUID
-	var ports sql.NullString
-	var idk sql.NullString // Should match key
-
-	err := r.db.QueryRow(ctx, query, key, userID).Scan(
-		&g.ID, &g.UserID, &idk, &g.Name, &g.VpcID, &lbID, &g.Image, &ports,
-		&g.MinInstances, &g.MaxInstances, &g.DesiredCount, &g.CurrentCount,
-		&g.Status, &g.Version, &g.CreatedAt, &g.UpdatedAt,
-	)
+	query := `SELECT ` + groupColumns + ` FROM scaling_groups WHERE id = $1 AND user_id = $2`
+	g, err := scanGroup(r.db.QueryRow(ctx, query, id, userID))
 	if err == pgx.ErrNoRows {
 		return nil, errs.New(errs.NotFound, "scaling group not found")
 	}
 	if err != nil {
 		return nil, err
 	}
-	g.LoadBalancerID = lbID
-	if ports.Valid {
-		g.Ports = ports.String
-	}
-	if idk.Valid {
-		g.IdempotencyKey = idk.String
-	}
-	return &g, nil
+	return g, nil
 }
 
-func (r *AutoScalingRepo) ListGroups(ctx context.Context) ([]*domain.ScalingGroup, error) {
+func (r *AutoScalingRepo) GetGroupByIdempotencyKey(ctx context.Context, key string) (*domain.ScalingGroup, error) {
 	userID := appcontext.UserIDFromContext(ctx)
-	query := `
-		SELECT id, user_id, idempotency_key, name, vpc_id, load_balancer_id, image, ports,
-			   min_instances, max_instances, desired_count, current_count, status, version, created_at, updated_at
-		FROM scaling_groups
-		WHERE user_id = $1
-	`
-	rows, err := r.db.Query(ctx, query, userID)
+	query := `SELECT ` + groupColumns + ` FROM scaling_groups WHERE idempotency_key = $1 AND user_id = $2`
+	g, err := scanGroup(r.db.QueryRow(ctx, query, key, userID))
+	if err == pgx.ErrNoRows {
+		return nil, errs.New(errs.NotFound, "scaling group not found")
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var groups []*domain.ScalingGroup
-	for rows.Next() {
-		var g domain.ScalingGroup
-		var lbID *uuid.UUID
-		var ports sql.NullString
-		var idk sql.NullString
+	return g, nil
+}
 
-		if err := rows.Scan(
-			&g.ID, &g.UserID, &idk, &g.Name, &g.VpcID, &lbID, &g.Image, &ports,
-			&g.MinInstances, &g.MaxInstances, &g.DesiredCount, &g.CurrentCount,
-			&g.Status, &g.Version, &g.CreatedAt, &g.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		g.LoadBalancerID = lbID
-		if ports.Valid {
-			g.Ports = ports.String
-		}
-		if idk.Valid {
-			g.IdempotencyKey = idk.String
-		}
-		groups = append(groups, &g)
-	}
-	return groups, nil
+func (r *AutoScalingRepo) ListGroups(ctx context.Context) ([]*domain.ScalingGroup, error) {
+	userID := appcontext.UserIDFromContext(ctx)
+	query := `SELECT ` + groupColumns + ` FROM scaling_groups WHERE user_id = $1`
+	return r.queryGroups(ctx, query, userID)
 }
 
 func (r *AutoScalingRepo) ListAllGroups(ctx context.Context) ([]*domain.ScalingGroup, error) {
-	query := `
-		SELECT id, user_id, idempotency_key, name, vpc_id, load_balancer_id, image, ports,
-			   min_instances, max_instances, desired_count, current_count, status, version, created_at, updated_at
-		FROM scaling_groups
-	`
-	rows, err := r.db.Query(ctx, query)
+	query := `SELECT ` + groupColumns + ` FROM scaling_groups`
+	return r.queryGroups(ctx, query)
+}
+
+func (r *AutoScalingRepo) queryGroups(ctx context.Context, query string, args ...interface{}) ([]*domain.ScalingGroup, error) {
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -164,26 +124,11 @@ func (r *AutoScalingRepo) ListAllGroups(ctx context.Context) ([]*domain.ScalingG
 
 	var groups []*domain.ScalingGroup
 	for rows.Next() {
-		var g domain.ScalingGroup
-		var lbID *uuid.UUID
-		var ports sql.NullString
-		var idk sql.NullString
-
-		if err := rows.Scan(
-			&g.ID, &g.UserID, &idk, &g.Name, &g.VpcID, &lbID, &g.Image, &ports,
-			&g.MinInstances, &g.MaxInstances, &g.DesiredCount, &g.CurrentCount,
-			&g.Status, &g.Version, &g.CreatedAt, &g.UpdatedAt,
-		); err != nil {
+		g, err := scanGroup(rows)
+		if err != nil {
 			return nil, err
 		}
-		g.LoadBalancerID = lbID
-		if ports.Valid {
-			g.Ports = ports.String
-		}
-		if idk.Valid {
-			g.IdempotencyKey = idk.String
-		}
-		groups = append(groups, &g)
+		groups = append(groups, g)
 	}
 	return groups, nil
 }
@@ -195,6 +140,9 @@ func (r *AutoScalingRepo) CountGroupsByVPC(ctx context.Context, vpcID uuid.UUID)
 	return count, err
 }
 
+// UpdateGroup saves the group only if it is still at the version it was read
+// at, so a stale copy, such as the one the worker holds during a tick, cannot
+// overwrite a concurrent change.
 func (r *AutoScalingRepo) UpdateGroup(ctx context.Context, group *domain.ScalingGroup) error {
 	query := `
 		UPDATE scaling_groups
@@ -202,14 +150,86 @@ func (r *AutoScalingRepo) UpdateGroup(ctx context.Context, group *domain.Scaling
 			min_instances = $4, max_instances = $5,
 			failure_count = $6, last_failure_at = $7,
 			version = version + 1, updated_at = NOW()
-		WHERE id = $8 AND user_id = $9
+		WHERE id = $8 AND user_id = $9 AND version = $10
 	`
-	_, err := r.db.Exec(ctx, query,
+	tag, err := r.db.Exec(ctx, query,
 		group.DesiredCount, group.CurrentCount, group.Status,
 		group.MinInstances, group.MaxInstances,
 		group.FailureCount, group.LastFailureAt,
+		group.ID, group.UserID, group.Version,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.New(errs.Conflict, "scaling group was changed concurrently")
+	}
+	group.Version++
+	return nil
+}
+
+func (r *AutoScalingRepo) UpdateGroupLaunchTemplate(ctx context.Context, group *domain.ScalingGroup) error {
+	query := `
+		UPDATE scaling_groups
+		SET launch_template_id = $1, launch_template_version = $2, image = $3, ports = $4,
+			version = version + 1, updated_at = NOW()
+		WHERE id = $5 AND user_id = $6
+	`
+	tag, err := r.db.Exec(ctx, query,
+		group.LaunchTemplateID, templateVersionArg(group), group.Image, group.Ports,
 		group.ID, group.UserID,
 	)
+	return bumpVersion(group, tag, err)
+}
+
+// UpdateGroupSettings saves the settings only if the group is still at the
+// version it was read at, like UpdateGroup, so an edit does not revert a
+// desired count the worker set in between.
+func (r *AutoScalingRepo) UpdateGroupSettings(ctx context.Context, group *domain.ScalingGroup) error {
+	query := `
+		UPDATE scaling_groups
+		SET name = $1, load_balancer_id = $2, image = $3,
+			min_instances = $4, max_instances = $5, desired_count = $6,
+			health_check_type = $7, health_check_grace_period_sec = $8,
+			version = version + 1, updated_at = NOW()
+		WHERE id = $9 AND user_id = $10 AND version = $11
+	`
+	tag, err := r.db.Exec(ctx, query,
+		group.Name, group.LoadBalancerID, group.Image,
+		group.MinInstances, group.MaxInstances, group.DesiredCount,
+		group.HealthCheckType, group.HealthCheckGracePeriodSec,
+		group.ID, group.UserID, group.Version,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.New(errs.Conflict, "scaling group was changed concurrently")
+	}
+	group.Version++
+	return nil
+}
+
+func (r *AutoScalingRepo) UpdateGroupSuspendedProcesses(ctx context.Context, group *domain.ScalingGroup) error {
+	suspended := make([]string, len(group.SuspendedProcesses))
+	for i, p := range group.SuspendedProcesses {
+		suspended[i] = string(p)
+	}
+	query := `
+		UPDATE scaling_groups
+		SET suspended_processes = $1, version = version + 1, updated_at = NOW()
+		WHERE id = $2 AND user_id = $3
+	`
+	tag, err := r.db.Exec(ctx, query, suspended, group.ID, group.UserID)
+	return bumpVersion(group, tag, err)
+}
+
+// bumpVersion keeps the version of the group in step with an update that
+// incremented it, so the copy can still be saved with UpdateGroup.
+func bumpVersion(group *domain.ScalingGroup, tag pgconn.CommandTag, err error) error {
+	if err == nil && tag.RowsAffected() > 0 {
+		group.Version++
+	}
 	return err
 }
 
@@ -224,19 +244,37 @@ func (r *AutoScalingRepo) DeleteGroup(ctx context.Context, id uuid.UUID) error {
 func (r *AutoScalingRepo) CreatePolicy(ctx context.Context, policy *domain.ScalingPolicy) error {
 	query := `
 		INSERT INTO scaling_policies (
-			id, scaling_group_id, name, metric_type, target_value,
-			scale_out_step, scale_in_step, cooldown_sec, last_scaled_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			id, scaling_group_id, name, policy_type, metric_type, metric_name, target_value, scale_in_threshold, steps,
+			evaluation_period_sec, scale_out_cooldown_sec, scale_in_cooldown_sec, last_scaled_at, last_scale_out_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
+	steps := policy.Steps
+	if steps == nil {
+		steps = []domain.ScalingStep{}
+	}
 	_, err := r.db.Exec(ctx, query,
-		policy.ID, policy.ScalingGroupID, policy.Name, policy.MetricType, policy.TargetValue,
-		policy.ScaleOutStep, policy.ScaleInStep, policy.CooldownSec, policy.LastScaledAt,
+		policy.ID, policy.ScalingGroupID, policy.Name, policy.PolicyType, policy.MetricType, policy.MetricName, policy.TargetValue, policy.ScaleInThreshold, steps,
+		policy.EvaluationPeriodSec, policy.ScaleOutCooldownSec, policy.ScaleInCooldownSec, policy.LastScaledAt, policy.LastScaleOutAt,
 	)
 	return err
 }
 
+const policyColumns = `id, scaling_group_id, name, policy_type, metric_type, metric_name, target_value, scale_in_threshold, steps,
+	evaluation_period_sec, scale_out_cooldown_sec, scale_in_cooldown_sec, last_scaled_at, last_scale_out_at`
+
+func scanPolicy(row pgx.Row) (*domain.ScalingPolicy, error) {
+	var p domain.ScalingPolicy
+	if err := row.Scan(
+		&p.ID, &p.ScalingGroupID, &p.Name, &p.PolicyType, &p.MetricType, &p.MetricName, &p.TargetValue, &p.ScaleInThreshold, &p.Steps,
+		&p.EvaluationPeriodSec, &p.ScaleOutCooldownSec, &p.ScaleInCooldownSec, &p.LastScaledAt, &p.LastScaleOutAt,
+	); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
 func (r *AutoScalingRepo) GetPoliciesForGroup(ctx context.Context, groupID uuid.UUID) ([]*domain.ScalingPolicy, error) {
-	query := `SELECT id, scaling_group_id, name, metric_type, target_value, scale_out_step, scale_in_step, cooldown_sec, last_scaled_at FROM scaling_policies WHERE scaling_group_id = $1`
+	query := `SELECT ` + policyColumns + ` FROM scaling_policies WHERE scaling_group_id = $1`
 	rows, err := r.db.Query(ctx, query, groupID)
 	if err != nil {
 		return nil, err
@@ -245,19 +283,11 @@ func (r *AutoScalingRepo) GetPoliciesForGroup(ctx context.Context, groupID uuid.
 
 	var policies []*domain.ScalingPolicy
 	for rows.Next() {
-		var p domain.ScalingPolicy
-		var lastScaledAt sql.NullTime
-		if err := rows.Scan(
-			&p.ID, &p.ScalingGroupID, &p.Name, &p.MetricType, &p.TargetValue,
-			&p.ScaleOutStep, &p.ScaleInStep, &p.CooldownSec, &lastScaledAt,
-		); err != nil {
+		p, err := scanPolicy(rows)
+		if err != nil {
 			return nil, err
 		}
-		if lastScaledAt.Valid {
-			t := lastScaledAt.Time
-			p.LastScaledAt = &t
-		}
-		policies = append(policies, &p)
+		policies = append(policies, p)
 	}
 	return policies, nil
 }
@@ -267,11 +297,7 @@ func (r *AutoScalingRepo) GetAllPolicies(ctx context.Context, groupIDs []uuid.UU
 		return make(map[uuid.UUID][]*domain.ScalingPolicy), nil
 	}
 
-	query := `
-		SELECT id, scaling_group_id, name, metric_type, target_value, 
-			   scale_out_step, scale_in_step, cooldown_sec, last_scaled_at 
-		FROM scaling_policies WHERE scaling_group_id = ANY($1)
-	`
+	query := `SELECT ` + policyColumns + ` FROM scaling_policies WHERE scaling_group_id = ANY($1)`
 	rows, err := r.db.Query(ctx, query, groupIDs)
 	if err != nil {
 		return nil, err
@@ -280,25 +306,22 @@ func (r *AutoScalingRepo) GetAllPolicies(ctx context.Context, groupIDs []uuid.UU
 
 	result := make(map[uuid.UUID][]*domain.ScalingPolicy)
 	for rows.Next() {
-		var p domain.ScalingPolicy
-		var lastScaledAt sql.NullTime
-		if err := rows.Scan(
-			&p.ID, &p.ScalingGroupID, &p.Name, &p.MetricType, &p.TargetValue,
-			&p.ScaleOutStep, &p.ScaleInStep, &p.CooldownSec, &lastScaledAt,
-		); err != nil {
+		p, err := scanPolicy(rows)
+		if err != nil {
 			return nil, err
 		}
-		if lastScaledAt.Valid {
-			t := lastScaledAt.Time
-			p.LastScaledAt = &t
-		}
-		result[p.ScalingGroupID] = append(result[p.ScalingGroupID], &p)
+		result[p.ScalingGroupID] = append(result[p.ScalingGroupID], p)
 	}
 	return result, nil
 }
 
-func (r *AutoScalingRepo) UpdatePolicyLastScaled(ctx context.Context, policyID uuid.UUID, t time.Time) error {
-	_, err := r.db.Exec(ctx, "UPDATE scaling_policies SET last_scaled_at = $1 WHERE id = $2", t, policyID)
+func (r *AutoScalingRepo) UpdatePolicyLastScaled(ctx context.Context, policyID uuid.UUID, t time.Time, scaleOut bool) error {
+	query := `
+		UPDATE scaling_policies
+		SET last_scaled_at = $1, last_scale_out_at = CASE WHEN $3 THEN $1 ELSE last_scale_out_at END
+		WHERE id = $2
+	`
+	_, err := r.db.Exec(ctx, query, t, policyID, scaleOut)
 	return err
 }
 
@@ -307,6 +330,403 @@ func (r *AutoScalingRepo) DeletePolicy(ctx context.Context, id uuid.UUID) error
 	return err
 }
 
+// Scheduled Actions
+
+func (r *AutoScalingRepo) CreateScheduledAction(ctx context.Context, action *domain.ScheduledAction) error {
+	query := `
+		INSERT INTO scaling_scheduled_actions (
+			id, scaling_group_id, name, schedule, time_zone, min_instances, max_instances, desired_count,
+			next_run_at, last_run_at, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err := r.db.Exec(ctx, query,
+		action.ID, action.ScalingGroupID, action.Name, action.Schedule, action.TimeZone, action.MinInstances, action.MaxInstances, action.DesiredCount,
+		action.NextRunAt, action.LastRunAt, action.CreatedAt,
+	)
+	return err
+}
+
+const scheduledActionColumns = `a.id, a.scaling_group_id, a.name, a.schedule, a.time_zone, a.min_instances, a.max_instances, a.desired_count,
+	a.next_run_at, a.last_run_at, a.created_at`
+
+func scanScheduledAction(row pgx.Row) (*domain.ScheduledAction, error) {
+	var a domain.ScheduledAction
+	if err := row.Scan(
+		&a.ID, &a.ScalingGroupID, &a.Name, &a.Schedule, &a.TimeZone, &a.MinInstances, &a.MaxInstances, &a.DesiredCount,
+		&a.NextRunAt, &a.LastRunAt, &a.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *AutoScalingRepo) ListScheduledActions(ctx context.Context, groupID uuid.UUID) ([]*domain.ScheduledAction, error) {
+	userID := appcontext.UserIDFromContext(ctx)
+	query := `
+		SELECT ` + scheduledActionColumns + `
+		FROM scaling_scheduled_actions a JOIN scaling_groups g ON g.id = a.scaling_group_id
+		WHERE a.scaling_group_id = $1 AND g.user_id = $2
+		ORDER BY a.next_run_at
+	`
+	rows, err := r.db.Query(ctx, query, groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []*domain.ScheduledAction
+	for rows.Next() {
+		a, err := scanScheduledAction(rows)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, a)
+	}
+	return actions, nil
+}
+
+func (r *AutoScalingRepo) DeleteScheduledAction(ctx context.Context, id uuid.UUID) error {
+	userID := appcontext.UserIDFromContext(ctx)
+	tag, err := r.db.Exec(ctx, `
+		DELETE FROM scaling_scheduled_actions a USING scaling_groups g
+		WHERE a.id = $1 AND g.id = a.scaling_group_id AND g.user_id = $2
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.New(errs.NotFound, "scheduled action not found")
+	}
+	return nil
+}
+
+func (r *AutoScalingRepo) GetDueScheduledActions(ctx context.Context, groupIDs []uuid.UUID, now time.Time) (map[uuid.UUID][]*domain.ScheduledAction, error) {
+	if len(groupIDs) == 0 {
+		return make(map[uuid.UUID][]*domain.ScheduledAction), nil
+	}
+
+	query := `
+		SELECT ` + scheduledActionColumns + `
+		FROM scaling_scheduled_actions a
+		WHERE a.scaling_group_id = ANY($1) AND a.next_run_at <= $2
+		ORDER BY a.next_run_at
+	`
+	rows, err := r.db.Query(ctx, query, groupIDs, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[uuid.UUID][]*domain.ScheduledAction)
+	for rows.Next() {
+		a, err := scanScheduledAction(rows)
+		if err != nil {
+			return nil, err
+		}
+		result[a.ScalingGroupID] = append(result[a.ScalingGroupID], a)
+	}
+	return result, nil
+}
+
+func (r *AutoScalingRepo) UpdateScheduledActionRun(ctx context.Context, id uuid.UUID, lastRunAt, nextRunAt time.Time) error {
+	_, err := r.db.Exec(ctx, "UPDATE scaling_scheduled_actions SET last_run_at = $1, next_run_at = $2 WHERE id = $3", lastRunAt, nextRunAt, id)
+	return err
+}
+
+// Instance Refreshes
+
+func (r *AutoScalingRepo) CreateInstanceRefresh(ctx context.Context, refresh *domain.InstanceRefresh) error {
+	query := `
+		INSERT INTO scaling_instance_refreshes (
+			id, scaling_group_id, status, status_reason, min_healthy_percentage, health_check_timeout_sec,
+			instances_to_replace, pending_instance_ids, batch_started_at, rollback_config, started_at, updated_at, ended_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+	_, err := r.db.Exec(ctx, query,
+		refresh.ID, refresh.ScalingGroupID, refresh.Status, refresh.StatusReason, refresh.MinHealthyPercentage, refresh.HealthCheckTimeoutSec,
+		refresh.InstancesToReplace, pendingInstancesArg(refresh), refresh.BatchStartedAt, refresh.RollbackConfig,
+		refresh.StartedAt, refresh.UpdatedAt, refresh.EndedAt,
+	)
+	return err
+}
+
+// pendingInstancesArg stores an empty array rather than NULL.
+func pendingInstancesArg(refresh *domain.InstanceRefresh) []uuid.UUID {
+	if refresh.PendingInstanceIDs == nil {
+		return []uuid.UUID{}
+	}
+	return refresh.PendingInstanceIDs
+}
+
+const instanceRefreshColumns = `f.id, f.scaling_group_id, f.status, f.status_reason, f.min_healthy_percentage, f.health_check_timeout_sec,
+	f.instances_to_replace, f.pending_instance_ids, f.batch_started_at, f.rollback_config, f.started_at, f.updated_at, f.ended_at`
+
+func scanInstanceRefresh(row pgx.Row) (*domain.InstanceRefresh, error) {
+	var f domain.InstanceRefresh
+	if err := row.Scan(
+		&f.ID, &f.ScalingGroupID, &f.Status, &f.StatusReason, &f.MinHealthyPercentage, &f.HealthCheckTimeoutSec,
+		&f.InstancesToReplace, &f.PendingInstanceIDs, &f.BatchStartedAt, &f.RollbackConfig, &f.StartedAt, &f.UpdatedAt, &f.EndedAt,
+	); err != nil {
+		return nil, err
+	}
+	f.InstancesReplaced = f.InstancesToReplace - len(f.PendingInstanceIDs)
+	return &f, nil
+}
+
+func (r *AutoScalingRepo) GetLatestInstanceRefresh(ctx context.Context, groupID uuid.UUID) (*domain.InstanceRefresh, error) {
+	userID := appcontext.UserIDFromContext(ctx)
+	query := `
+		SELECT ` + instanceRefreshColumns + `
+		FROM scaling_instance_refreshes f JOIN scaling_groups g ON g.id = f.scaling_group_id
+		WHERE f.scaling_group_id = $1 AND g.user_id = $2
+		ORDER BY f.started_at DESC
+		LIMIT 1
+	`
+	f, err := scanInstanceRefresh(r.db.QueryRow(ctx, query, groupID, userID))
+	if err == pgx.ErrNoRows {
+		return nil, errs.New(errs.NotFound, "scaling group has no instance refresh")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (r *AutoScalingRepo) GetRunningInstanceRefreshes(ctx context.Context, groupIDs []uuid.UUID) (map[uuid.UUID]*domain.InstanceRefresh, error) {
+	result := make(map[uuid.UUID]*domain.InstanceRefresh)
+	if len(groupIDs) == 0 {
+		return result, nil
+	}
+
+	query := `
+		SELECT ` + instanceRefreshColumns + `
+		FROM scaling_instance_refreshes f
+		WHERE f.scaling_group_id = ANY($1) AND f.status IN ('IN_PROGRESS', 'ROLLING_BACK')
+	`
+	rows, err := r.db.Query(ctx, query, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		f, err := scanInstanceRefresh(rows)
+		if err != nil {
+			return nil, err
+		}
+		result[f.ScalingGroupID] = f
+	}
+	return result, nil
+}
+
+func (r *AutoScalingRepo) UpdateInstanceRefresh(ctx context.Context, refresh *domain.InstanceRefresh, from domain.InstanceRefreshStatus) error {
+	query := `
+		UPDATE scaling_instance_refreshes
+		SET status = $1, status_reason = $2, instances_to_replace = $3, pending_instance_ids = $4,
+			batch_started_at = $5, rollback_config = $6, ended_at = $7, updated_at = NOW()
+		WHERE id = $8 AND status = $9
+	`
+	tag, err := r.db.Exec(ctx, query,
+		refresh.Status, refresh.StatusReason, refresh.InstancesToReplace, pendingInstancesArg(refresh),
+		refresh.BatchStartedAt, refresh.RollbackConfig, refresh.EndedAt,
+		refresh.ID, from,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.New(errs.Conflict, "instance refresh was changed concurrently")
+	}
+	return nil
+}
+
+// Lifecycle Hooks
+
+func (r *AutoScalingRepo) CreateLifecycleHook(ctx context.Context, hook *domain.LifecycleHook) error {
+	query := `
+		INSERT INTO scaling_lifecycle_hooks (id, scaling_group_id, name, transition, heartbeat_timeout_sec, default_result, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := r.db.Exec(ctx, query,
+		hook.ID, hook.ScalingGroupID, hook.Name, hook.Transition, hook.HeartbeatTimeoutSec, hook.DefaultResult, hook.CreatedAt,
+	)
+	return err
+}
+
+const lifecycleHookColumns = `h.id, h.scaling_group_id, h.name, h.transition, h.heartbeat_timeout_sec, h.default_result, h.created_at`
+
+func scanLifecycleHook(row pgx.Row) (*domain.LifecycleHook, error) {
+	var h domain.LifecycleHook
+	if err := row.Scan(&h.ID, &h.ScalingGroupID, &h.Name, &h.Transition, &h.HeartbeatTimeoutSec, &h.DefaultResult, &h.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func (r *AutoScalingRepo) ListLifecycleHooks(ctx context.Context, groupID uuid.UUID) ([]*domain.LifecycleHook, error) {
+	userID := appcontext.UserIDFromContext(ctx)
+	query := `
+		SELECT ` + lifecycleHookColumns + `
+		FROM scaling_lifecycle_hooks h JOIN scaling_groups g ON g.id = h.scaling_group_id
+		WHERE h.scaling_group_id = $1 AND g.user_id = $2
+		ORDER BY h.transition
+	`
+	rows, err := r.db.Query(ctx, query, groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hooks []*domain.LifecycleHook
+	for rows.Next() {
+		h, err := scanLifecycleHook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, h)
+	}
+	return hooks, nil
+}
+
+func (r *AutoScalingRepo) DeleteLifecycleHook(ctx context.Context, id uuid.UUID) error {
+	userID := appcontext.UserIDFromContext(ctx)
+	tag, err := r.db.Exec(ctx, `
+		DELETE FROM scaling_lifecycle_hooks h USING scaling_groups g
+		WHERE h.id = $1 AND g.id = h.scaling_group_id AND g.user_id = $2
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.New(errs.NotFound, "lifecycle hook not found")
+	}
+	return nil
+}
+
+func (r *AutoScalingRepo) GetAllLifecycleHooks(ctx context.Context, groupIDs []uuid.UUID) (map[uuid.UUID][]*domain.LifecycleHook, error) {
+	result := make(map[uuid.UUID][]*domain.LifecycleHook)
+	if len(groupIDs) == 0 {
+		return result, nil
+	}
+
+	query := `SELECT ` + lifecycleHookColumns + ` FROM scaling_lifecycle_hooks h WHERE h.scaling_group_id = ANY($1)`
+	rows, err := r.db.Query(ctx, query, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		h, err := scanLifecycleHook(rows)
+		if err != nil {
+			return nil, err
+		}
+		result[h.ScalingGroupID] = append(result[h.ScalingGroupID], h)
+	}
+	return result, nil
+}
+
+func (r *AutoScalingRepo) SetInstanceLifecycleState(ctx context.Context, groupID, instanceID uuid.UUID, state domain.LifecycleState, timeoutAt *time.Time) error {
+	query := `
+		UPDATE scaling_group_instances
+		SET lifecycle_state = $1, lifecycle_timeout_at = $2, lifecycle_result = NULL, drain_until = NULL
+		WHERE scaling_group_id = $3 AND instance_id = $4
+	`
+	_, err := r.db.Exec(ctx, query, state, timeoutAt, groupID, instanceID)
+	return err
+}
+
+func (r *AutoScalingRepo) CompleteLifecycleAction(ctx context.Context, groupID, instanceID uuid.UUID, result domain.LifecycleActionResult) error {
+	userID := appcontext.UserIDFromContext(ctx)
+	tag, err := r.db.Exec(ctx, `
+		UPDATE scaling_group_instances i SET lifecycle_result = $1
+		FROM scaling_groups g
+		WHERE i.scaling_group_id = $2 AND i.instance_id = $3 AND g.id = i.scaling_group_id AND g.user_id = $4
+			AND i.lifecycle_state <> 'InService' AND i.lifecycle_result IS NULL
+	`, result, groupID, instanceID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.New(errs.NotFound, "instance is not awaiting a lifecycle action")
+	}
+	return nil
+}
+
+func (r *AutoScalingRepo) GetAllWaitingInstances(ctx context.Context, groupIDs []uuid.UUID) (map[uuid.UUID][]domain.ScalingGroupInstance, error) {
+	result := make(map[uuid.UUID][]domain.ScalingGroupInstance)
+	if len(groupIDs) == 0 {
+		return result, nil
+	}
+
+	query := `
+		SELECT scaling_group_id, instance_id, joined_at, lifecycle_state, lifecycle_timeout_at, COALESCE(lifecycle_result, '')
+		FROM scaling_group_instances
+		WHERE scaling_group_id = ANY($1) AND lifecycle_state <> 'InService'
+	`
+	rows, err := r.db.Query(ctx, query, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var i domain.ScalingGroupInstance
+		if err := rows.Scan(&i.ScalingGroupID, &i.InstanceID, &i.JoinedAt, &i.LifecycleState, &i.LifecycleTimeoutAt, &i.LifecycleResult); err != nil {
+			return nil, err
+		}
+		result[i.ScalingGroupID] = append(result[i.ScalingGroupID], i)
+	}
+	return result, nil
+}
+
+// Activities
+
+func (r *AutoScalingRepo) CreateActivity(ctx context.Context, a *domain.ScalingActivity) error {
+	query := `
+		INSERT INTO scaling_activities (
+			id, scaling_group_id, description, cause, from_capacity, to_capacity, status, status_message, started_at, ended_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := r.db.Exec(ctx, query,
+		a.ID, a.ScalingGroupID, a.Description, a.Cause, a.FromCapacity, a.ToCapacity, a.Status, a.StatusMessage, a.StartedAt, a.EndedAt,
+	)
+	return err
+}
+
+func (r *AutoScalingRepo) ListActivities(ctx context.Context, groupID uuid.UUID, limit int) ([]*domain.ScalingActivity, error) {
+	userID := appcontext.UserIDFromContext(ctx)
+	query := `
+		SELECT a.id, a.scaling_group_id, a.description, a.cause, a.from_capacity, a.to_capacity, a.status, a.status_message, a.started_at, a.ended_at
+		FROM scaling_activities a JOIN scaling_groups g ON g.id = a.scaling_group_id
+		WHERE a.scaling_group_id = $1 AND g.user_id = $2
+		ORDER BY a.started_at DESC
+		LIMIT $3
+	`
+	rows, err := r.db.Query(ctx, query, groupID, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activities []*domain.ScalingActivity
+	for rows.Next() {
+		var a domain.ScalingActivity
+		if err := rows.Scan(
+			&a.ID, &a.ScalingGroupID, &a.Description, &a.Cause, &a.FromCapacity, &a.ToCapacity, &a.Status, &a.StatusMessage, &a.StartedAt, &a.EndedAt,
+		); err != nil {
+			return nil, err
+		}
+		activities = append(activities, &a)
+	}
+	return activities, nil
+}
+
+func (r *AutoScalingRepo) DeleteActivitiesBefore(ctx context.Context, before time.Time) error {
+	_, err := r.db.Exec(ctx, "DELETE FROM scaling_activities WHERE started_at < $1", before)
+	return err
+}
+
 // Group Instances
 
 func (r *AutoScalingRepo) AddInstanceToGroup(ctx context.Context, groupID, instanceID uuid.UUID) error {
@@ -343,7 +763,7 @@ func (r *AutoScalingRepo) GetAllScalingGroupInstances(ctx context.Context, group
 	query := `
 		SELECT scaling_group_id, instance_id
 		FROM scaling_group_instances
-		WHERE scaling_group_id = ANY($1)
+		WHERE scaling_group_id = ANY($1) AND drain_until IS NULL AND lifecycle_state <> 'Terminating:Wait'
 	`
 	rows, err := r.db.Query(ctx, query, groupIDs)
 	if err != nil {
@@ -362,19 +782,127 @@ func (r *AutoScalingRepo) GetAllScalingGroupInstances(ctx context.Context, group
 	return result, nil
 }
 
+func (r *AutoScalingRepo) MarkInstanceDraining(ctx context.Context, groupID, instanceID uuid.UUID, until time.Time) error {
+	_, err := r.db.Exec(ctx, "UPDATE scaling_group_instances SET drain_until = $1 WHERE scaling_group_id = $2 AND instance_id = $3", until, groupID, instanceID)
+	return err
+}
+
+func (r *AutoScalingRepo) GetAllDrainingInstances(ctx context.Context, groupIDs []uuid.UUID) (map[uuid.UUID][]domain.ScalingGroupInstance, error) {
+	result := make(map[uuid.UUID][]domain.ScalingGroupInstance)
+	if len(groupIDs) == 0 {
+		return result, nil
+	}
+
+	query := `
+		SELECT scaling_group_id, instance_id, joined_at, drain_until
+		FROM scaling_group_instances
+		WHERE scaling_group_id = ANY($1) AND drain_until IS NOT NULL
+	`
+	rows, err := r.db.Query(ctx, query, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var i domain.ScalingGroupInstance
+		if err := rows.Scan(&i.ScalingGroupID, &i.InstanceID, &i.JoinedAt, &i.DrainUntil); err != nil {
+			return nil, err
+		}
+		result[i.ScalingGroupID] = append(result[i.ScalingGroupID], i)
+	}
+	return result, nil
+}
+
 // Metrics
 
 func (r *AutoScalingRepo) GetAverageCPU(ctx context.Context, instanceIDs []uuid.UUID, since time.Time) (float64, error) {
+	query := `
+		SELECT AVG(cpu_percent)
+		FROM metrics_history
+		WHERE instance_id = ANY($1) AND recorded_at >= $2
+	`
+	return r.averageMetric(ctx, "cpu", query, instanceIDs, since)
+}
+
+func (r *AutoScalingRepo) GetAverageMemory(ctx context.Context, instanceIDs []uuid.UUID, since time.Time) (float64, error) {
+	query := `
+		SELECT AVG(memory_bytes::float8 * 100 / NULLIF(memory_limit_bytes, 0))
+		FROM metrics_history
+		WHERE instance_id = ANY($1) AND recorded_at >= $2
+	`
+	return r.averageMetric(ctx, "memory", query, instanceIDs, since)
+}
+
+// averageMetric runs an AVG query over the samples of the instances. An empty
+// window is reported as NotFound rather than as an average of 0.
+func (r *AutoScalingRepo) averageMetric(ctx context.Context, name, query string, instanceIDs []uuid.UUID, since time.Time) (float64, error) {
+	var avg sql.NullFloat64
+	if len(instanceIDs) > 0 {
+		if err := r.db.QueryRow(ctx, query, instanceIDs, since).Scan(&avg); err != nil {
+			return 0, err
+		}
+	}
+	if !avg.Valid {
+		return 0, errs.New(errs.NotFound, "no data points for metric "+name)
+	}
+	return avg.Float64, nil
+}
+
+func (r *AutoScalingRepo) GetAverageNetworkThroughput(ctx context.Context, instanceIDs []uuid.UUID, since time.Time) (float64, float64, error) {
+	if len(instanceIDs) == 0 {
+		return 0, 0, nil
+	}
+
+	// The counters are totals since the container started: the rate of an
+	// instance is its last minus its first sample over the time between them.
+	// A counter that went back was reset by a restart and counts as 0.
+	query := `
+		SELECT COALESCE(AVG(rx), 0), COALESCE(AVG(tx), 0) FROM (
+			SELECT
+				GREATEST((array_agg(network_rx_bytes ORDER BY recorded_at DESC))[1] - (array_agg(network_rx_bytes ORDER BY recorded_at))[1], 0)::float8
+					/ EXTRACT(EPOCH FROM MAX(recorded_at) - MIN(recorded_at))::float8 AS rx,
+				GREATEST((array_agg(network_tx_bytes ORDER BY recorded_at DESC))[1] - (array_agg(network_tx_bytes ORDER BY recorded_at))[1], 0)::float8
+					/ EXTRACT(EPOCH FROM MAX(recorded_at) - MIN(recorded_at))::float8 AS tx
+			FROM metrics_history
+			WHERE instance_id = ANY($1) AND recorded_at >= $2
+			GROUP BY instance_id
+			HAVING MAX(recorded_at) > MIN(recorded_at)
+		) rates
+	`
+	var rx, tx float64
+	err := r.db.QueryRow(ctx, query, instanceIDs, since).Scan(&rx, &tx)
+	return rx, tx, err
+}
+
+func (r *AutoScalingRepo) GetRequestCount(ctx context.Context, lbID uuid.UUID, instanceIDs []uuid.UUID, from, to time.Time) (int64, error) {
 	if len(instanceIDs) == 0 {
 		return 0, nil
 	}
 
 	query := `
-		SELECT COALESCE(AVG(cpu_percent), 0)
-		FROM metrics_history
-		WHERE instance_id = ANY($1) AND recorded_at >= $2
+		SELECT COALESCE(SUM(requests), 0)::bigint
+		FROM lb_request_stats
+		WHERE lb_id = $1 AND instance_id = ANY($2) AND minute >= $3 AND minute < $4
 	`
-	var avg float64
-	err := r.db.QueryRow(ctx, query, instanceIDs, since).Scan(&avg)
-	return avg, err
+	var count int64
+	err := r.db.QueryRow(ctx, query, lbID, instanceIDs, from, to).Scan(&count)
+	return count, err
+}
+
+func (r *AutoScalingRepo) GetAverageCustomMetric(ctx context.Context, userID uuid.UUID, name string, instanceIDs []uuid.UUID, since time.Time) (float64, error) {
+	query := `
+		SELECT AVG(value)
+		FROM custom_metrics
+		WHERE user_id = $1 AND name = $2 AND recorded_at >= $3
+			AND (instance_id IS NULL OR instance_id = ANY($4))
+	`
+	var avg sql.NullFloat64
+	if err := r.db.QueryRow(ctx, query, userID, name, since, instanceIDs).Scan(&avg); err != nil {
+		return 0, err
+	}
+	if !avg.Valid {
+		return 0, errs.New(errs.NotFound, "no data points for custom metric "+name)
+	}
+	return avg.Float64, nil
 }
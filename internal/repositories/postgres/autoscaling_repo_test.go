@@ -10,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	appcontext "github.com/poyrazk/thecloud/internal/core/context"
 	"github.com/poyrazk/thecloud/internal/core/domain"
+	errs "github.com/poyrazk/thecloud/internal/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -42,6 +43,9 @@ func TestAutoScalingRepo_Integration(t *testing.T) {
 		IdempotencyKey: "asg-key-1",
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
+
+		HealthCheckType:           domain.ScalingHealthCheckContainer,
+		HealthCheckGracePeriodSec: domain.DefaultHealthCheckGracePeriodSec,
 	}
 
 	t.Run("Scaling Group CRUD", func(t *testing.T) {
@@ -64,6 +68,34 @@ func TestAutoScalingRepo_Integration(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, 3, fetched3.DesiredCount)
 
+		// A copy read before the update is stale and must not overwrite it.
+		fetched.DesiredCount = 1
+		err = repo.UpdateGroup(ctx, fetched)
+		assert.True(t, errs.Is(err, errs.Conflict))
+		require.NoError(t, repo.UpdateGroup(ctx, fetched3))
+
+		// A rename based on a stale copy must not revert the desired count.
+		fetched.Name = "stale-rename"
+		err = repo.UpdateGroupSettings(ctx, fetched)
+		assert.True(t, errs.Is(err, errs.Conflict))
+
+		group, err = repo.GetGroupByID(ctx, groupID)
+		require.NoError(t, err)
+		group.Name = "test-asg-renamed"
+		group.MaxInstances = 8
+		group.HealthCheckGracePeriodSec = 60
+		require.NoError(t, repo.UpdateGroupSettings(ctx, group))
+		group.SuspendedProcesses = []domain.ScalingProcess{domain.ScalingProcessLaunch, domain.ScalingProcessScheduledActions}
+		require.NoError(t, repo.UpdateGroupSuspendedProcesses(ctx, group))
+
+		fetched4, err := repo.GetGroupByID(ctx, groupID)
+		require.NoError(t, err)
+		assert.Equal(t, "test-asg-renamed", fetched4.Name)
+		assert.Equal(t, 8, fetched4.MaxInstances)
+		assert.Equal(t, domain.ScalingHealthCheckContainer, fetched4.HealthCheckType)
+		assert.Equal(t, 60, fetched4.HealthCheckGracePeriodSec)
+		assert.Equal(t, group.SuspendedProcesses, fetched4.SuspendedProcesses)
+
 		count, err := repo.CountGroupsByVPC(ctx, vpcID)
 		require.NoError(t, err)
 		assert.Equal(t, 1, count)
@@ -75,15 +107,17 @@ func TestAutoScalingRepo_Integration(t *testing.T) {
 
 	t.Run("Policy Management", func(t *testing.T) {
 		policyID := uuid.New()
+		lower := 80.0
 		policy := &domain.ScalingPolicy{
-			ID:             policyID,
-			ScalingGroupID: groupID,
-			Name:           "scale-out",
-			MetricType:     "cpu",
-			TargetValue:    70.0,
-			ScaleOutStep:   1,
-			ScaleInStep:    1,
-			CooldownSec:    300,
+			ID:                  policyID,
+			ScalingGroupID:      groupID,
+			Name:                "scale-out",
+			PolicyType:          domain.ScalingPolicyStep,
+			MetricType:          "cpu",
+			Steps:               []domain.ScalingStep{{LowerBound: &lower, Adjustment: 2}},
+			EvaluationPeriodSec: 120,
+			ScaleOutCooldownSec: 60,
+			ScaleInCooldownSec:  600,
 		}
 
 		err := repo.CreatePolicy(ctx, policy)
@@ -93,14 +127,25 @@ func TestAutoScalingRepo_Integration(t *testing.T) {
 		require.NoError(t, err)
 		assert.Len(t, policies, 1)
 		assert.Equal(t, "scale-out", policies[0].Name)
+		assert.Equal(t, domain.ScalingPolicyStep, policies[0].PolicyType)
+		assert.Equal(t, policy.Steps, policies[0].Steps)
+		assert.Equal(t, 120, policies[0].EvaluationPeriodSec)
+		assert.Equal(t, 600, policies[0].ScaleInCooldownSec)
 
 		now := time.Now()
-		err = repo.UpdatePolicyLastScaled(ctx, policyID, now)
+		err = repo.UpdatePolicyLastScaled(ctx, policyID, now, false)
 		require.NoError(t, err)
 
 		policyMap, err := repo.GetAllPolicies(ctx, []uuid.UUID{groupID})
 		require.NoError(t, err)
 		assert.NotNil(t, policyMap[groupID][0].LastScaledAt)
+		assert.Nil(t, policyMap[groupID][0].LastScaleOutAt)
+
+		err = repo.UpdatePolicyLastScaled(ctx, policyID, now, true)
+		require.NoError(t, err)
+		policies, err = repo.GetPoliciesForGroup(ctx, groupID)
+		require.NoError(t, err)
+		assert.NotNil(t, policies[0].LastScaleOutAt)
 
 		err = repo.DeletePolicy(ctx, policyID)
 		require.NoError(t, err)
@@ -110,6 +155,46 @@ func TestAutoScalingRepo_Integration(t *testing.T) {
 		assert.Empty(t, policies)
 	})
 
+	t.Run("Scheduled Actions", func(t *testing.T) {
+		desired := 6
+		now := time.Now().UTC().Truncate(time.Second)
+		action := &domain.ScheduledAction{
+			ID:             uuid.New(),
+			ScalingGroupID: groupID,
+			Name:           "morning",
+			Schedule:       "0 8 * * MON-FRI",
+			TimeZone:       "Europe/Istanbul",
+			DesiredCount:   &desired,
+			NextRunAt:      now.Add(-time.Minute),
+			CreatedAt:      now,
+		}
+		require.NoError(t, repo.CreateScheduledAction(ctx, action))
+
+		actions, err := repo.ListScheduledActions(ctx, groupID)
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		assert.Equal(t, "Europe/Istanbul", actions[0].TimeZone)
+		assert.Nil(t, actions[0].MinInstances)
+		require.NotNil(t, actions[0].DesiredCount)
+		assert.Equal(t, 6, *actions[0].DesiredCount)
+
+		due, err := repo.GetDueScheduledActions(ctx, []uuid.UUID{groupID}, now)
+		require.NoError(t, err)
+		assert.Len(t, due[groupID], 1)
+
+		require.NoError(t, repo.UpdateScheduledActionRun(ctx, action.ID, now, now.Add(time.Hour)))
+		due, err = repo.GetDueScheduledActions(ctx, []uuid.UUID{groupID}, now)
+		require.NoError(t, err)
+		assert.Empty(t, due[groupID])
+
+		otherCtx := appcontext.WithUserID(ctx, uuid.New())
+		assert.Error(t, repo.DeleteScheduledAction(otherCtx, action.ID))
+		require.NoError(t, repo.DeleteScheduledAction(ctx, action.ID))
+		actions, err = repo.ListScheduledActions(ctx, groupID)
+		require.NoError(t, err)
+		assert.Empty(t, actions)
+	})
+
 	t.Run("Group Instance Management", func(t *testing.T) {
 		instID := uuid.New()
 		_, err := db.Exec(ctx, "INSERT INTO instances (id, name, image, status, version, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
@@ -147,6 +232,10 @@ func TestAutoScalingRepo_Integration(t *testing.T) {
 		avg, err := repo.GetAverageCPU(ctx, []uuid.UUID{instID}, time.Now().Add(-5*time.Minute))
 		require.NoError(t, err)
 		assert.InDelta(t, 50.0, avg, 0.1)
+
+		// An empty window has no average rather than one of 0
+		_, err = repo.GetAverageCPU(ctx, []uuid.UUID{instID}, time.Now().Add(time.Minute))
+		assert.True(t, errs.Is(err, errs.NotFound))
 	})
 
 	t.Run("Delete Group", func(t *testing.T) {
@@ -60,7 +60,12 @@ func cleanDB(t *testing.T, db *pgxpool.Pool) {
 		"DELETE FROM lb_targets",
 		"DELETE FROM scaling_group_instances",
 		"DELETE FROM scaling_policies",
+		"DELETE FROM scaling_scheduled_actions",
+		"DELETE FROM scaling_instance_refreshes",
+		"DELETE FROM scaling_lifecycle_hooks",
+		"DELETE FROM scaling_activities",
 		"DELETE FROM scaling_groups",
+		"DELETE FROM launch_templates",
 		"DELETE FROM load_balancers",
 		"DELETE FROM volumes",
 		"DELETE FROM instances",
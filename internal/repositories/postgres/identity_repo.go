@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/poyrazk/thecloud/internal/core/domain"
@@ -52,3 +53,26 @@ func (r *IdentityRepository) GetApiKeyByKey(ctx context.Context, keyStr string)
 	}
 	return &key, nil
 }
+
+func (r *IdentityRepository) GetApiKeyByID(ctx context.Context, id uuid.UUID) (*domain.ApiKey, error) {
+	query := `
+		SELECT id, user_id, key, name, created_at, last_used
+		FROM api_keys
+		WHERE id = $1
+	`
+	var key domain.ApiKey
+	var lastUsed *time.Time
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&key.ID, &key.UserID, &key.Key, &key.Name, &key.CreatedAt, &lastUsed,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New(errors.Unauthorized, "invalid api key")
+		}
+		return nil, errors.Wrap(errors.Internal, "failed to get api key", err)
+	}
+	if lastUsed != nil {
+		key.LastUsed = *lastUsed
+	}
+	return &key, nil
+}
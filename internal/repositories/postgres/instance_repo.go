@@ -23,11 +23,17 @@ func NewInstanceRepository(db *pgxpool.Pool) *InstanceRepository {
 
 func (r *InstanceRepository) Create(ctx context.Context, inst *domain.Instance) error {
 	query := `
-		INSERT INTO instances (id, user_id, name, image, container_id, status, ports, vpc_id, version, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		INSERT INTO instances (id, user_id, name, image, container_id, status, ports, vpc_id,
+			launch_template_id, launch_template_version, instance_type, version, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
+	var templateVersion interface{}
+	if inst.LaunchTemplateID != nil {
+		templateVersion = inst.LaunchTemplateVersion
+	}
 	_, err := r.db.Exec(ctx, query,
-		inst.ID, inst.UserID, inst.Name, inst.Image, inst.ContainerID, inst.Status, inst.Ports, inst.VpcID, inst.Version, inst.CreatedAt, inst.UpdatedAt,
+		inst.ID, inst.UserID, inst.Name, inst.Image, inst.ContainerID, inst.Status, inst.Ports, inst.VpcID,
+		inst.LaunchTemplateID, templateVersion, inst.InstanceType, inst.Version, inst.CreatedAt, inst.UpdatedAt,
 	)
 	if err != nil {
 		return errors.Wrap(errors.Internal, "failed to create instance", err)
@@ -35,16 +41,20 @@ func (r *InstanceRepository) Create(ctx context.Context, inst *domain.Instance)
 	return nil
 }
 
+const instanceColumns = `id, user_id, name, image, COALESCE(container_id, ''), status, COALESCE(ports, ''), vpc_id,
+	launch_template_id, COALESCE(launch_template_version, 0), instance_type, version, created_at, updated_at`
+
 func (r *InstanceRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Instance, error) {
 	userID := appcontext.UserIDFromContext(ctx)
 	query := `
-		SELECT id, user_id, name, image, COALESCE(container_id, ''), status, COALESCE(ports, ''), vpc_id, version, created_at, updated_at
+		SELECT ` + instanceColumns + `
 		FROM instances
 		WHERE id = $1 AND user_id = $2
 	`
 	var inst domain.Instance
 	err := r.db.QueryRow(ctx, query, id, userID).Scan(
-		&inst.ID, &inst.UserID, &inst.Name, &inst.Image, &inst.ContainerID, &inst.Status, &inst.Ports, &inst.VpcID, &inst.Version, &inst.CreatedAt, &inst.UpdatedAt,
+		&inst.ID, &inst.UserID, &inst.Name, &inst.Image, &inst.ContainerID, &inst.Status, &inst.Ports, &inst.VpcID,
+		&inst.LaunchTemplateID, &inst.LaunchTemplateVersion, &inst.InstanceType, &inst.Version, &inst.CreatedAt, &inst.UpdatedAt,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -58,13 +68,14 @@ func (r *InstanceRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain
 func (r *InstanceRepository) GetByName(ctx context.Context, name string) (*domain.Instance, error) {
 	userID := appcontext.UserIDFromContext(ctx)
 	query := `
-		SELECT id, user_id, name, image, COALESCE(container_id, ''), status, COALESCE(ports, ''), vpc_id, version, created_at, updated_at
+		SELECT ` + instanceColumns + `
 		FROM instances
 		WHERE name = $1 AND user_id = $2
 	`
 	var inst domain.Instance
 	err := r.db.QueryRow(ctx, query, name, userID).Scan(
-		&inst.ID, &inst.UserID, &inst.Name, &inst.Image, &inst.ContainerID, &inst.Status, &inst.Ports, &inst.VpcID, &inst.Version, &inst.CreatedAt, &inst.UpdatedAt,
+		&inst.ID, &inst.UserID, &inst.Name, &inst.Image, &inst.ContainerID, &inst.Status, &inst.Ports, &inst.VpcID,
+		&inst.LaunchTemplateID, &inst.LaunchTemplateVersion, &inst.InstanceType, &inst.Version, &inst.CreatedAt, &inst.UpdatedAt,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -78,7 +89,7 @@ func (r *InstanceRepository) GetByName(ctx context.Context, name string) (*domai
 func (r *InstanceRepository) List(ctx context.Context) ([]*domain.Instance, error) {
 	userID := appcontext.UserIDFromContext(ctx)
 	query := `
-		SELECT id, user_id, name, image, COALESCE(container_id, ''), status, COALESCE(ports, ''), vpc_id, version, created_at, updated_at
+		SELECT ` + instanceColumns + `
 		FROM instances
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -93,7 +104,35 @@ func (r *InstanceRepository) List(ctx context.Context) ([]*domain.Instance, erro
 	for rows.Next() {
 		var inst domain.Instance
 		err := rows.Scan(
-			&inst.ID, &inst.UserID, &inst.Name, &inst.Image, &inst.ContainerID, &inst.Status, &inst.Ports, &inst.VpcID, &inst.Version, &inst.CreatedAt, &inst.UpdatedAt,
+			&inst.ID, &inst.UserID, &inst.Name, &inst.Image, &inst.ContainerID, &inst.Status, &inst.Ports, &inst.VpcID,
+			&inst.LaunchTemplateID, &inst.LaunchTemplateVersion, &inst.InstanceType, &inst.Version, &inst.CreatedAt, &inst.UpdatedAt,
+		)
+		if err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to scan instance", err)
+		}
+		instances = append(instances, &inst)
+	}
+	return instances, nil
+}
+
+func (r *InstanceRepository) ListAll(ctx context.Context) ([]*domain.Instance, error) {
+	query := `
+		SELECT ` + instanceColumns + `
+		FROM instances
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to list all instances", err)
+	}
+	defer rows.Close()
+
+	var instances []*domain.Instance
+	for rows.Next() {
+		var inst domain.Instance
+		err := rows.Scan(
+			&inst.ID, &inst.UserID, &inst.Name, &inst.Image, &inst.ContainerID, &inst.Status, &inst.Ports, &inst.VpcID,
+			&inst.LaunchTemplateID, &inst.LaunchTemplateVersion, &inst.InstanceType, &inst.Version, &inst.CreatedAt, &inst.UpdatedAt,
 		)
 		if err != nil {
 			return nil, errors.Wrap(errors.Internal, "failed to scan instance", err)
@@ -0,0 +1,219 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+)
+
+type LaunchTemplateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLaunchTemplateRepository(db *pgxpool.Pool) *LaunchTemplateRepository {
+	return &LaunchTemplateRepository{db: db}
+}
+
+func (r *LaunchTemplateRepository) Create(ctx context.Context, t *domain.LaunchTemplate, v *domain.LaunchTemplateVersion) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to create launch template", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	query := `
+		INSERT INTO launch_templates (id, user_id, name, description, latest_version, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	if _, err := tx.Exec(ctx, query, t.ID, t.UserID, t.Name, t.Description, t.LatestVersion, t.CreatedAt, t.UpdatedAt); err != nil {
+		return errors.Wrap(errors.Internal, "failed to create launch template", err)
+	}
+	if err := insertTemplateVersion(ctx, tx, v); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(errors.Internal, "failed to create launch template", err)
+	}
+	return nil
+}
+
+const launchTemplateColumns = `id, user_id, name, description, latest_version, created_at, updated_at`
+
+func scanLaunchTemplate(row pgx.Row) (*domain.LaunchTemplate, error) {
+	var t domain.LaunchTemplate
+	if err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.Description, &t.LatestVersion, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *LaunchTemplateRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.LaunchTemplate, error) {
+	userID := appcontext.UserIDFromContext(ctx)
+	query := `SELECT ` + launchTemplateColumns + ` FROM launch_templates WHERE id = $1 AND user_id = $2`
+	t, err := scanLaunchTemplate(r.db.QueryRow(ctx, query, id, userID))
+	if err == pgx.ErrNoRows {
+		return nil, errors.New(errors.NotFound, fmt.Sprintf("launch template %s not found", id))
+	}
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to get launch template", err)
+	}
+	return t, nil
+}
+
+func (r *LaunchTemplateRepository) List(ctx context.Context) ([]*domain.LaunchTemplate, error) {
+	userID := appcontext.UserIDFromContext(ctx)
+	query := `SELECT ` + launchTemplateColumns + ` FROM launch_templates WHERE user_id = $1 ORDER BY name`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to list launch templates", err)
+	}
+	defer rows.Close()
+
+	var templates []*domain.LaunchTemplate
+	for rows.Next() {
+		t, err := scanLaunchTemplate(rows)
+		if err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to scan launch template", err)
+		}
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+func (r *LaunchTemplateRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	userID := appcontext.UserIDFromContext(ctx)
+	cmd, err := r.db.Exec(ctx, `DELETE FROM launch_templates WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to delete launch template", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errors.New(errors.NotFound, fmt.Sprintf("launch template %s not found", id))
+	}
+	return nil
+}
+
+func (r *LaunchTemplateRepository) CountGroupsUsing(ctx context.Context, id uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM scaling_groups WHERE launch_template_id = $1`, id).Scan(&count)
+	if err != nil {
+		return 0, errors.Wrap(errors.Internal, "failed to count scaling groups", err)
+	}
+	return count, nil
+}
+
+func (r *LaunchTemplateRepository) CreateVersion(ctx context.Context, v *domain.LaunchTemplateVersion) error {
+	userID := appcontext.UserIDFromContext(ctx)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to create launch template version", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Bumping the latest version locks the template row, so concurrent
+	// versions are numbered one after the other.
+	query := `
+		UPDATE launch_templates SET latest_version = latest_version + 1, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING latest_version
+	`
+	err = tx.QueryRow(ctx, query, v.TemplateID, userID).Scan(&v.Version)
+	if err == pgx.ErrNoRows {
+		return errors.New(errors.NotFound, fmt.Sprintf("launch template %s not found", v.TemplateID))
+	}
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to create launch template version", err)
+	}
+	if err := insertTemplateVersion(ctx, tx, v); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(errors.Internal, "failed to create launch template version", err)
+	}
+	return nil
+}
+
+func insertTemplateVersion(ctx context.Context, tx pgx.Tx, v *domain.LaunchTemplateVersion) error {
+	query := `
+		INSERT INTO launch_template_versions (
+			template_id, version, image, ports, instance_type, env, user_data, volumes, vpc_id, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	env := v.Env
+	if env == nil {
+		env = map[string]string{}
+	}
+	volumes := v.Volumes
+	if volumes == nil {
+		volumes = []domain.VolumeAttachment{}
+	}
+	_, err := tx.Exec(ctx, query,
+		v.TemplateID, v.Version, v.Image, v.Ports, v.InstanceType, env, v.UserData, volumes, v.VpcID, v.CreatedAt,
+	)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to create launch template version", err)
+	}
+	return nil
+}
+
+const templateVersionColumns = `v.template_id, v.version, v.image, v.ports, v.instance_type, v.env, v.user_data, v.volumes, v.vpc_id, v.created_at`
+
+func scanTemplateVersion(row pgx.Row) (*domain.LaunchTemplateVersion, error) {
+	var v domain.LaunchTemplateVersion
+	if err := row.Scan(
+		&v.TemplateID, &v.Version, &v.Image, &v.Ports, &v.InstanceType, &v.Env, &v.UserData, &v.Volumes, &v.VpcID, &v.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *LaunchTemplateRepository) GetVersion(ctx context.Context, templateID uuid.UUID, version int) (*domain.LaunchTemplateVersion, error) {
+	userID := appcontext.UserIDFromContext(ctx)
+	query := `
+		SELECT ` + templateVersionColumns + `
+		FROM launch_template_versions v
+		JOIN launch_templates t ON t.id = v.template_id
+		WHERE v.template_id = $1 AND t.user_id = $2
+			AND v.version = CASE WHEN $3::int = 0 THEN t.latest_version ELSE $3::int END
+	`
+	v, err := scanTemplateVersion(r.db.QueryRow(ctx, query, templateID, userID, version))
+	if err == pgx.ErrNoRows {
+		return nil, errors.New(errors.NotFound, fmt.Sprintf("launch template %s has no version %d", templateID, version))
+	}
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to get launch template version", err)
+	}
+	return v, nil
+}
+
+func (r *LaunchTemplateRepository) ListVersions(ctx context.Context, templateID uuid.UUID) ([]*domain.LaunchTemplateVersion, error) {
+	userID := appcontext.UserIDFromContext(ctx)
+	query := `
+		SELECT ` + templateVersionColumns + `
+		FROM launch_template_versions v
+		JOIN launch_templates t ON t.id = v.template_id
+		WHERE v.template_id = $1 AND t.user_id = $2
+		ORDER BY v.version
+	`
+	rows, err := r.db.Query(ctx, query, templateID, userID)
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to list launch template versions", err)
+	}
+	defer rows.Close()
+
+	var versions []*domain.LaunchTemplateVersion
+	for rows.Next() {
+		v, err := scanTemplateVersion(rows)
+		if err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to scan launch template version", err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
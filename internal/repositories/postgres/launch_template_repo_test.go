@@ -0,0 +1,88 @@
+//go:build integration
+
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLaunchTemplateRepository_Integration(t *testing.T) {
+	db := setupDB(t)
+	defer db.Close()
+	repo := NewLaunchTemplateRepository(db)
+	ctx := setupTestUser(t, db)
+	userID := appcontext.UserIDFromContext(ctx)
+
+	cleanDB(t, db)
+
+	now := time.Now().Truncate(time.Microsecond)
+	template := &domain.LaunchTemplate{
+		ID:            uuid.New(),
+		UserID:        userID,
+		Name:          "web",
+		LatestVersion: 1,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	first := &domain.LaunchTemplateVersion{
+		TemplateID:   template.ID,
+		Version:      1,
+		Image:        "nginx:1.26",
+		Ports:        "80:80",
+		InstanceType: "small",
+		Env:          map[string]string{"MODE": "prod"},
+		UserData:     "echo ready",
+		CreatedAt:    now,
+	}
+
+	t.Run("Create and Get", func(t *testing.T) {
+		require.NoError(t, repo.Create(ctx, template, first))
+
+		fetched, err := repo.GetByID(ctx, template.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "web", fetched.Name)
+
+		v, err := repo.GetVersion(ctx, template.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, first.Env, v.Env)
+		assert.Equal(t, "small", v.InstanceType)
+		assert.Empty(t, v.Volumes)
+	})
+
+	t.Run("CreateVersion", func(t *testing.T) {
+		second := &domain.LaunchTemplateVersion{TemplateID: template.ID, Image: "nginx:1.27", CreatedAt: now}
+		require.NoError(t, repo.CreateVersion(ctx, second))
+		assert.Equal(t, 2, second.Version)
+
+		latest, err := repo.GetVersion(ctx, template.ID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "nginx:1.27", latest.Image)
+
+		versions, err := repo.ListVersions(ctx, template.ID)
+		require.NoError(t, err)
+		assert.Len(t, versions, 2)
+	})
+
+	t.Run("OtherUser", func(t *testing.T) {
+		otherCtx := setupTestUser(t, db)
+		_, err := repo.GetVersion(otherCtx, template.ID, 1)
+		assert.Error(t, err)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		count, err := repo.CountGroupsUsing(ctx, template.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+
+		require.NoError(t, repo.Delete(ctx, template.ID))
+		_, err = repo.GetByID(ctx, template.ID)
+		assert.Error(t, err)
+	})
+}
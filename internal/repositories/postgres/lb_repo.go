@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -21,11 +22,11 @@ func NewLBRepository(db *pgxpool.Pool) *LBRepository {
 
 func (r *LBRepository) Create(ctx context.Context, lb *domain.LoadBalancer) error {
 	query := `
-		INSERT INTO load_balancers (id, user_id, idempotency_key, name, vpc_id, port, algorithm, status, version, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO load_balancers (id, user_id, idempotency_key, name, vpc_id, port, algorithm, status, version, created_at, health_check, deregistration_delay_seconds, access_logs, hash_header, stickiness)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 	`
 	_, err := r.db.Exec(ctx, query,
-		lb.ID, lb.UserID, lb.IdempotencyKey, lb.Name, lb.VpcID, lb.Port, lb.Algorithm, lb.Status, lb.Version, lb.CreatedAt,
+		lb.ID, lb.UserID, lb.IdempotencyKey, lb.Name, lb.VpcID, lb.Port, lb.Algorithm, lb.Status, lb.Version, lb.CreatedAt, lb.HealthCheck, lb.DeregistrationDelaySeconds, lb.AccessLogs, lb.HashHeader, lb.Stickiness,
 	)
 	if err != nil {
 		// Check for unique constraint violation on idempotency_key
@@ -37,13 +38,13 @@ func (r *LBRepository) Create(ctx context.Context, lb *domain.LoadBalancer) erro
 func (r *LBRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.LoadBalancer, error) {
 	userID := appcontext.UserIDFromContext(ctx)
 	query := `
-		SELECT id, user_id, COALESCE(idempotency_key, ''), name, vpc_id, port, algorithm, status, version, created_at
+		SELECT id, user_id, COALESCE(idempotency_key, ''), name, vpc_id, port, algorithm, status, version, created_at, health_check, deregistration_delay_seconds, access_logs, hash_header, stickiness
 		FROM load_balancers
 		WHERE id = $1 AND user_id = $2
 	`
 	var lb domain.LoadBalancer
 	err := r.db.QueryRow(ctx, query, id, userID).Scan(
-		&lb.ID, &lb.UserID, &lb.IdempotencyKey, &lb.Name, &lb.VpcID, &lb.Port, &lb.Algorithm, &lb.Status, &lb.Version, &lb.CreatedAt,
+		&lb.ID, &lb.UserID, &lb.IdempotencyKey, &lb.Name, &lb.VpcID, &lb.Port, &lb.Algorithm, &lb.Status, &lb.Version, &lb.CreatedAt, &lb.HealthCheck, &lb.DeregistrationDelaySeconds, &lb.AccessLogs, &lb.HashHeader, &lb.Stickiness,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -60,13 +61,13 @@ func (r *LBRepository) GetByIdempotencyKey(ctx context.Context, key string) (*do
 	}
 	userID := appcontext.UserIDFromContext(ctx)
 	query := `
-		SELECT id, user_id, idempotency_key, name, vpc_id, port, algorithm, status, version, created_at
+		SELECT id, user_id, idempotency_key, name, vpc_id, port, algorithm, status, version, created_at, health_check, deregistration_delay_seconds, access_logs, hash_header, stickiness
 		FROM load_balancers
 		WHERE idempotency_key = $1 AND user_id = $2
 	`
 	var lb domain.LoadBalancer
 	err := r.db.QueryRow(ctx, query, key, userID).Scan(
-		&lb.ID, &lb.UserID, &lb.IdempotencyKey, &lb.Name, &lb.VpcID, &lb.Port, &lb.Algorithm, &lb.Status, &lb.Version, &lb.CreatedAt,
+		&lb.ID, &lb.UserID, &lb.IdempotencyKey, &lb.Name, &lb.VpcID, &lb.Port, &lb.Algorithm, &lb.Status, &lb.Version, &lb.CreatedAt, &lb.HealthCheck, &lb.DeregistrationDelaySeconds, &lb.AccessLogs, &lb.HashHeader, &lb.Stickiness,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -80,7 +81,7 @@ func (r *LBRepository) GetByIdempotencyKey(ctx context.Context, key string) (*do
 func (r *LBRepository) List(ctx context.Context) ([]*domain.LoadBalancer, error) {
 	userID := appcontext.UserIDFromContext(ctx)
 	query := `
-		SELECT id, user_id, COALESCE(idempotency_key, ''), name, vpc_id, port, algorithm, status, version, created_at
+		SELECT id, user_id, COALESCE(idempotency_key, ''), name, vpc_id, port, algorithm, status, version, created_at, health_check, deregistration_delay_seconds, access_logs, hash_header, stickiness
 		FROM load_balancers
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -95,7 +96,7 @@ func (r *LBRepository) List(ctx context.Context) ([]*domain.LoadBalancer, error)
 	for rows.Next() {
 		var lb domain.LoadBalancer
 		err := rows.Scan(
-			&lb.ID, &lb.UserID, &lb.IdempotencyKey, &lb.Name, &lb.VpcID, &lb.Port, &lb.Algorithm, &lb.Status, &lb.Version, &lb.CreatedAt,
+			&lb.ID, &lb.UserID, &lb.IdempotencyKey, &lb.Name, &lb.VpcID, &lb.Port, &lb.Algorithm, &lb.Status, &lb.Version, &lb.CreatedAt, &lb.HealthCheck, &lb.DeregistrationDelaySeconds, &lb.AccessLogs, &lb.HashHeader, &lb.Stickiness,
 		)
 		if err != nil {
 			return nil, errors.Wrap(errors.Internal, "failed to scan load balancer", err)
@@ -107,7 +108,7 @@ func (r *LBRepository) List(ctx context.Context) ([]*domain.LoadBalancer, error)
 
 func (r *LBRepository) ListAll(ctx context.Context) ([]*domain.LoadBalancer, error) {
 	query := `
-		SELECT id, user_id, COALESCE(idempotency_key, ''), name, vpc_id, port, algorithm, status, version, created_at
+		SELECT id, user_id, COALESCE(idempotency_key, ''), name, vpc_id, port, algorithm, status, version, created_at, health_check, deregistration_delay_seconds, access_logs, hash_header, stickiness
 		FROM load_balancers
 		ORDER BY created_at DESC
 	`
@@ -121,7 +122,7 @@ func (r *LBRepository) ListAll(ctx context.Context) ([]*domain.LoadBalancer, err
 	for rows.Next() {
 		var lb domain.LoadBalancer
 		err := rows.Scan(
-			&lb.ID, &lb.UserID, &lb.IdempotencyKey, &lb.Name, &lb.VpcID, &lb.Port, &lb.Algorithm, &lb.Status, &lb.Version, &lb.CreatedAt,
+			&lb.ID, &lb.UserID, &lb.IdempotencyKey, &lb.Name, &lb.VpcID, &lb.Port, &lb.Algorithm, &lb.Status, &lb.Version, &lb.CreatedAt, &lb.HealthCheck, &lb.DeregistrationDelaySeconds, &lb.AccessLogs, &lb.HashHeader, &lb.Stickiness,
 		)
 		if err != nil {
 			return nil, errors.Wrap(errors.Internal, "failed to scan load balancer", err)
@@ -134,10 +135,10 @@ func (r *LBRepository) ListAll(ctx context.Context) ([]*domain.LoadBalancer, err
 func (r *LBRepository) Update(ctx context.Context, lb *domain.LoadBalancer) error {
 	query := `
 		UPDATE load_balancers
-		SET name = $1, port = $2, algorithm = $3, status = $4, version = version + 1
-		WHERE id = $5 AND version = $6 AND user_id = $7
+		SET name = $1, port = $2, algorithm = $3, status = $4, health_check = $5, deregistration_delay_seconds = $6, access_logs = $7, hash_header = $8, stickiness = $9, version = version + 1
+		WHERE id = $10 AND version = $11 AND user_id = $12
 	`
-	cmd, err := r.db.Exec(ctx, query, lb.Name, lb.Port, lb.Algorithm, lb.Status, lb.ID, lb.Version, lb.UserID)
+	cmd, err := r.db.Exec(ctx, query, lb.Name, lb.Port, lb.Algorithm, lb.Status, lb.HealthCheck, lb.DeregistrationDelaySeconds, lb.AccessLogs, lb.HashHeader, lb.Stickiness, lb.ID, lb.Version, lb.UserID)
 	if err != nil {
 		return errors.Wrap(errors.Internal, "failed to update load balancer", err)
 	}
@@ -163,11 +164,11 @@ func (r *LBRepository) Delete(ctx context.Context, id uuid.UUID) error {
 
 func (r *LBRepository) AddTarget(ctx context.Context, target *domain.LBTarget) error {
 	query := `
-		INSERT INTO lb_targets (id, lb_id, instance_id, port, weight, health)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO lb_targets (id, lb_id, instance_id, target_group, port, weight, health)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 	_, err := r.db.Exec(ctx, query,
-		target.ID, target.LBID, target.InstanceID, target.Port, target.Weight, target.Health,
+		target.ID, target.LBID, target.InstanceID, target.TargetGroup, target.Port, target.Weight, target.Health,
 	)
 	if err != nil {
 		// Handle unique constraint on (lb_id, instance_id)
@@ -188,9 +189,25 @@ func (r *LBRepository) RemoveTarget(ctx context.Context, lbID, instanceID uuid.U
 	return nil
 }
 
+func (r *LBRepository) DrainTarget(ctx context.Context, lbID, instanceID uuid.UUID, since time.Time) error {
+	query := `
+		UPDATE lb_targets
+		SET health = $1, draining_since = $2
+		WHERE lb_id = $3 AND instance_id = $4
+	`
+	cmd, err := r.db.Exec(ctx, query, domain.TargetHealthDraining, since, lbID, instanceID)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to drain load balancer target", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errors.New(errors.NotFound, "target not found")
+	}
+	return nil
+}
+
 func (r *LBRepository) ListTargets(ctx context.Context, lbID uuid.UUID) ([]*domain.LBTarget, error) {
 	query := `
-		SELECT id, lb_id, instance_id, port, weight, health
+		SELECT id, lb_id, instance_id, target_group, port, weight, health, draining_since
 		FROM lb_targets
 		WHERE lb_id = $1
 	`
@@ -203,7 +220,7 @@ func (r *LBRepository) ListTargets(ctx context.Context, lbID uuid.UUID) ([]*doma
 	var targets []*domain.LBTarget
 	for rows.Next() {
 		var t domain.LBTarget
-		err := rows.Scan(&t.ID, &t.LBID, &t.InstanceID, &t.Port, &t.Weight, &t.Health)
+		err := rows.Scan(&t.ID, &t.LBID, &t.InstanceID, &t.TargetGroup, &t.Port, &t.Weight, &t.Health, &t.DrainingSince)
 		if err != nil {
 			return nil, errors.Wrap(errors.Internal, "failed to scan load balancer target", err)
 		}
@@ -216,7 +233,7 @@ func (r *LBRepository) UpdateTargetHealth(ctx context.Context, lbID, instanceID
 	query := `
 		UPDATE lb_targets
 		SET health = $1
-		WHERE lb_id = $2 AND instance_id = $3
+		WHERE lb_id = $2 AND instance_id = $3 AND draining_since IS NULL
 	`
 	_, err := r.db.Exec(ctx, query, health, lbID, instanceID)
 	if err != nil {
@@ -227,7 +244,7 @@ func (r *LBRepository) UpdateTargetHealth(ctx context.Context, lbID, instanceID
 
 func (r *LBRepository) GetTargetsForInstance(ctx context.Context, instanceID uuid.UUID) ([]*domain.LBTarget, error) {
 	query := `
-		SELECT id, lb_id, instance_id, port, weight, health
+		SELECT id, lb_id, instance_id, target_group, port, weight, health, draining_since
 		FROM lb_targets
 		WHERE instance_id = $1
 	`
@@ -240,7 +257,7 @@ func (r *LBRepository) GetTargetsForInstance(ctx context.Context, instanceID uui
 	var targets []*domain.LBTarget
 	for rows.Next() {
 		var t domain.LBTarget
-		err := rows.Scan(&t.ID, &t.LBID, &t.InstanceID, &t.Port, &t.Weight, &t.Health)
+		err := rows.Scan(&t.ID, &t.LBID, &t.InstanceID, &t.TargetGroup, &t.Port, &t.Weight, &t.Health, &t.DrainingSince)
 		if err != nil {
 			return nil, errors.Wrap(errors.Internal, "failed to scan load balancer target", err)
 		}
@@ -248,3 +265,160 @@ func (r *LBRepository) GetTargetsForInstance(ctx context.Context, instanceID uui
 	}
 	return targets, nil
 }
+
+func (r *LBRepository) CreateListener(ctx context.Context, listener *domain.LBListener) error {
+	query := `
+		INSERT INTO lb_listeners (id, lb_id, port, protocol, certificate_secret, redirect_port, default_target_group, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.Exec(ctx, query,
+		listener.ID, listener.LBID, listener.Port, listener.Protocol, listener.CertificateSecret, listener.RedirectPort, listener.DefaultTargetGroup, listener.CreatedAt,
+	)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to create listener", err)
+	}
+	return nil
+}
+
+func (r *LBRepository) ListListeners(ctx context.Context, lbID uuid.UUID) ([]*domain.LBListener, error) {
+	query := `
+		SELECT id, lb_id, port, protocol, certificate_secret, redirect_port, default_target_group, created_at
+		FROM lb_listeners
+		WHERE lb_id = $1
+		ORDER BY port ASC
+	`
+	rows, err := r.db.Query(ctx, query, lbID)
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to list listeners", err)
+	}
+	defer rows.Close()
+
+	var listeners []*domain.LBListener
+	byID := make(map[uuid.UUID]*domain.LBListener)
+	for rows.Next() {
+		var l domain.LBListener
+		err := rows.Scan(&l.ID, &l.LBID, &l.Port, &l.Protocol, &l.CertificateSecret, &l.RedirectPort, &l.DefaultTargetGroup, &l.CreatedAt)
+		if err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to scan listener", err)
+		}
+		listeners = append(listeners, &l)
+		byID[l.ID] = &l
+	}
+	rows.Close()
+
+	ruleQuery := `
+		SELECT r.id, r.listener_id, r.host, r.path_prefix, r.target_group, r.created_at
+		FROM lb_routing_rules r
+		JOIN lb_listeners l ON l.id = r.listener_id
+		WHERE l.lb_id = $1
+		ORDER BY r.created_at ASC
+	`
+	ruleRows, err := r.db.Query(ctx, ruleQuery, lbID)
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to list routing rules", err)
+	}
+	defer ruleRows.Close()
+
+	for ruleRows.Next() {
+		var rule domain.LBRoutingRule
+		err := ruleRows.Scan(&rule.ID, &rule.ListenerID, &rule.Host, &rule.PathPrefix, &rule.TargetGroup, &rule.CreatedAt)
+		if err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to scan routing rule", err)
+		}
+		if l, ok := byID[rule.ListenerID]; ok {
+			l.Rules = append(l.Rules, &rule)
+		}
+	}
+	return listeners, nil
+}
+
+func (r *LBRepository) DeleteListener(ctx context.Context, lbID, listenerID uuid.UUID) error {
+	cmd, err := r.db.Exec(ctx, `DELETE FROM lb_listeners WHERE id = $1 AND lb_id = $2`, listenerID, lbID)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to delete listener", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errors.New(errors.NotFound, "listener not found")
+	}
+	return nil
+}
+
+func (r *LBRepository) CreateRoutingRule(ctx context.Context, rule *domain.LBRoutingRule) error {
+	query := `
+		INSERT INTO lb_routing_rules (id, listener_id, host, path_prefix, target_group, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.db.Exec(ctx, query, rule.ID, rule.ListenerID, rule.Host, rule.PathPrefix, rule.TargetGroup, rule.CreatedAt)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to create routing rule", err)
+	}
+	return nil
+}
+
+func (r *LBRepository) DeleteRoutingRule(ctx context.Context, listenerID, ruleID uuid.UUID) error {
+	cmd, err := r.db.Exec(ctx, `DELETE FROM lb_routing_rules WHERE id = $1 AND listener_id = $2`, ruleID, listenerID)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to delete routing rule", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errors.New(errors.NotFound, "routing rule not found")
+	}
+	return nil
+}
+
+func (r *LBRepository) SaveRequestStats(ctx context.Context, stats []*domain.LBRequestStats) error {
+	query := `
+		INSERT INTO lb_request_stats (lb_id, instance_id, minute, requests, status_2xx, status_3xx, status_4xx, status_5xx, bytes_sent, latency_sum, latency_counts)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to save request stats", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, s := range stats {
+		_, err := tx.Exec(ctx, query,
+			s.LBID, s.InstanceID, s.Minute, s.Requests, s.Status2xx, s.Status3xx, s.Status4xx, s.Status5xx, s.BytesSent, s.LatencySum, s.LatencyCounts,
+		)
+		if err != nil {
+			return errors.Wrap(errors.Internal, "failed to save request stats", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(errors.Internal, "failed to save request stats", err)
+	}
+	return nil
+}
+
+func (r *LBRepository) ListRequestStats(ctx context.Context, lbID uuid.UUID, since time.Time) ([]*domain.LBRequestStats, error) {
+	query := `
+		SELECT lb_id, instance_id, minute, requests, status_2xx, status_3xx, status_4xx, status_5xx, bytes_sent, latency_sum, latency_counts
+		FROM lb_request_stats
+		WHERE lb_id = $1 AND minute >= $2
+		ORDER BY minute
+	`
+	rows, err := r.db.Query(ctx, query, lbID, since)
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to list request stats", err)
+	}
+	defer rows.Close()
+
+	var stats []*domain.LBRequestStats
+	for rows.Next() {
+		var s domain.LBRequestStats
+		err := rows.Scan(&s.LBID, &s.InstanceID, &s.Minute, &s.Requests, &s.Status2xx, &s.Status3xx, &s.Status4xx, &s.Status5xx, &s.BytesSent, &s.LatencySum, &s.LatencyCounts)
+		if err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to scan request stats", err)
+		}
+		stats = append(stats, &s)
+	}
+	return stats, nil
+}
+
+func (r *LBRepository) DeleteRequestStatsBefore(ctx context.Context, before time.Time) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM lb_request_stats WHERE minute < $1`, before); err != nil {
+		return errors.Wrap(errors.Internal, "failed to delete request stats", err)
+	}
+	return nil
+}
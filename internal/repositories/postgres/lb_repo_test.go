@@ -69,6 +69,9 @@ func TestLBRepository_Integration(t *testing.T) {
 
 	t.Run("Update", func(t *testing.T) {
 		lb.Status = domain.LBStatusActive
+		lb.Algorithm = domain.LBAlgorithmHeaderHash
+		lb.HashHeader = "X-User-ID"
+		lb.Stickiness = domain.LBStickiness{Enabled: true, TTLSeconds: 600}
 		err := repo.Update(ctx, lb)
 		require.NoError(t, err)
 		assert.Equal(t, 2, lb.Version)
@@ -76,6 +79,8 @@ func TestLBRepository_Integration(t *testing.T) {
 		fetched, err := repo.GetByID(ctx, lbID)
 		require.NoError(t, err)
 		assert.Equal(t, domain.LBStatusActive, fetched.Status)
+		assert.Equal(t, "X-User-ID", fetched.HashHeader)
+		assert.Equal(t, lb.Stickiness, fetched.Stickiness)
 	})
 
 	t.Run("Target Management", func(t *testing.T) {
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+)
+
+type LeaseRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLeaseRepository(db *pgxpool.Pool) *LeaseRepository {
+	return &LeaseRepository{db: db}
+}
+
+const leaseColumns = `name, holder_id, acquired_at, renewed_at, expires_at`
+
+func scanLease(row pgx.Row) (*domain.LeaderLease, error) {
+	var l domain.LeaderLease
+	if err := row.Scan(&l.Name, &l.HolderID, &l.AcquiredAt, &l.RenewedAt, &l.ExpiresAt); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *LeaseRepository) AcquireLease(ctx context.Context, name, holderID string, ttl time.Duration) (*domain.LeaderLease, error) {
+	// The conditional upsert runs under the row lock, so of two replicas
+	// racing for an expired lease only one takes it.
+	query := `
+		INSERT INTO leader_leases (name, holder_id, acquired_at, renewed_at, expires_at)
+		VALUES ($1, $2, NOW(), NOW(), NOW() + $3 * INTERVAL '1 millisecond')
+		ON CONFLICT (name) DO UPDATE SET
+			holder_id = EXCLUDED.holder_id,
+			acquired_at = CASE WHEN leader_leases.holder_id = EXCLUDED.holder_id THEN leader_leases.acquired_at ELSE NOW() END,
+			renewed_at = NOW(),
+			expires_at = EXCLUDED.expires_at
+		WHERE leader_leases.holder_id = EXCLUDED.holder_id OR leader_leases.expires_at <= NOW()
+		RETURNING ` + leaseColumns
+	lease, err := scanLease(r.db.QueryRow(ctx, query, name, holderID, ttl.Milliseconds()))
+	if err == pgx.ErrNoRows {
+		// Another holder's lease is still valid.
+		lease, err = scanLease(r.db.QueryRow(ctx, `SELECT `+leaseColumns+` FROM leader_leases WHERE name = $1`, name))
+	}
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to acquire lease", err)
+	}
+	return lease, nil
+}
+
+func (r *LeaseRepository) ReleaseLease(ctx context.Context, name, holderID string) error {
+	query := `UPDATE leader_leases SET expires_at = NOW() WHERE name = $1 AND holder_id = $2`
+	if _, err := r.db.Exec(ctx, query, name, holderID); err != nil {
+		return errors.Wrap(errors.Internal, "failed to release lease", err)
+	}
+	return nil
+}
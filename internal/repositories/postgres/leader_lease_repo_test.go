@@ -0,0 +1,48 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaseRepository_Integration(t *testing.T) {
+	db := setupDB(t)
+	defer db.Close()
+	repo := NewLeaseRepository(db)
+	ctx := context.Background()
+	name := "test-worker-" + uuid.NewString()
+	defer func() { _, _ = db.Exec(ctx, "DELETE FROM leader_leases WHERE name = $1", name) }()
+
+	lease, err := repo.AcquireLease(ctx, name, "replica-a", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "replica-a", lease.HolderID)
+
+	// The lease is held, so another replica cannot take it.
+	lease, err = repo.AcquireLease(ctx, name, "replica-b", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "replica-a", lease.HolderID)
+
+	// Renewing keeps the acquisition time.
+	renewed, err := repo.AcquireLease(ctx, name, "replica-a", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, lease.AcquiredAt, renewed.AcquiredAt)
+	assert.True(t, !renewed.ExpiresAt.Before(lease.ExpiresAt))
+
+	// Only the holder can release it, after which it is free.
+	require.NoError(t, repo.ReleaseLease(ctx, name, "replica-b"))
+	lease, err = repo.AcquireLease(ctx, name, "replica-b", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "replica-a", lease.HolderID)
+
+	require.NoError(t, repo.ReleaseLease(ctx, name, "replica-a"))
+	lease, err = repo.AcquireLease(ctx, name, "replica-b", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "replica-b", lease.HolderID)
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+)
+
+type MetricsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMetricsRepository(db *pgxpool.Pool) *MetricsRepository {
+	return &MetricsRepository{db: db}
+}
+
+func (r *MetricsRepository) SaveInstanceMetrics(ctx context.Context, samples []*domain.InstanceMetric) error {
+	query := `
+		INSERT INTO metrics_history (instance_id, cpu_percent, memory_bytes, memory_limit_bytes, network_rx_bytes, network_tx_bytes, recorded_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to save instance metrics", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, s := range samples {
+		_, err := tx.Exec(ctx, query,
+			s.InstanceID, s.CPUPercent, s.MemoryBytes, s.MemoryLimitBytes, s.NetworkRxBytes, s.NetworkTxBytes, s.RecordedAt,
+		)
+		if err != nil {
+			return errors.Wrap(errors.Internal, "failed to save instance metrics", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(errors.Internal, "failed to save instance metrics", err)
+	}
+	return nil
+}
+
+// rollupUpsert replaces the buckets of a rolled up window, so a window that
+// was rolled up while still filling is corrected on the next pass.
+const rollupUpsert = `
+	ON CONFLICT (instance_id, resolution_seconds, bucket_start) DO UPDATE SET
+		samples = EXCLUDED.samples,
+		cpu_avg = EXCLUDED.cpu_avg,
+		cpu_max = EXCLUDED.cpu_max,
+		memory_avg_bytes = EXCLUDED.memory_avg_bytes,
+		memory_max_bytes = EXCLUDED.memory_max_bytes,
+		memory_limit_bytes = EXCLUDED.memory_limit_bytes,
+		network_rx_bytes = EXCLUDED.network_rx_bytes,
+		network_tx_bytes = EXCLUDED.network_tx_bytes
+`
+
+func (r *MetricsRepository) RollupInstanceMetrics(ctx context.Context, source, resolution time.Duration, from, to time.Time) error {
+	var query string
+	args := []interface{}{int(resolution.Seconds()), from, to}
+	if source == 0 {
+		query = `
+			INSERT INTO metrics_rollups (instance_id, resolution_seconds, bucket_start, samples, cpu_avg, cpu_max, memory_avg_bytes, memory_max_bytes, memory_limit_bytes, network_rx_bytes, network_tx_bytes)
+			SELECT instance_id, $1::int, to_timestamp(floor(extract(epoch FROM recorded_at) / $1::int) * $1::int) AS bucket,
+				COUNT(*), AVG(cpu_percent), MAX(cpu_percent), AVG(memory_bytes)::bigint, MAX(memory_bytes),
+				MAX(memory_limit_bytes), MAX(network_rx_bytes), MAX(network_tx_bytes)
+			FROM metrics_history
+			WHERE recorded_at >= $2 AND recorded_at < $3
+			GROUP BY instance_id, bucket
+		` + rollupUpsert
+	} else {
+		// Averages of a coarser bucket weigh the finer buckets by their samples.
+		query = `
+			INSERT INTO metrics_rollups (instance_id, resolution_seconds, bucket_start, samples, cpu_avg, cpu_max, memory_avg_bytes, memory_max_bytes, memory_limit_bytes, network_rx_bytes, network_tx_bytes)
+			SELECT instance_id, $1::int, to_timestamp(floor(extract(epoch FROM bucket_start) / $1::int) * $1::int) AS bucket,
+				SUM(samples), SUM(cpu_avg * samples) / SUM(samples), MAX(cpu_max),
+				(SUM(memory_avg_bytes::numeric * samples) / SUM(samples))::bigint, MAX(memory_max_bytes),
+				MAX(memory_limit_bytes), MAX(network_rx_bytes), MAX(network_tx_bytes)
+			FROM metrics_rollups
+			WHERE resolution_seconds = $4 AND bucket_start >= $2 AND bucket_start < $3
+			GROUP BY instance_id, bucket
+		` + rollupUpsert
+		args = append(args, int(source.Seconds()))
+	}
+
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(errors.Internal, "failed to roll up instance metrics", err)
+	}
+	return nil
+}
+
+func (r *MetricsRepository) DeleteInstanceMetricsBefore(ctx context.Context, resolution time.Duration, before time.Time) error {
+	var err error
+	if resolution == 0 {
+		_, err = r.db.Exec(ctx, `DELETE FROM metrics_history WHERE recorded_at < $1`, before)
+	} else {
+		_, err = r.db.Exec(ctx, `DELETE FROM metrics_rollups WHERE resolution_seconds = $1 AND bucket_start < $2`, int(resolution.Seconds()), before)
+	}
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to prune instance metrics", err)
+	}
+	return nil
+}
+
+func (r *MetricsRepository) SaveCustomMetrics(ctx context.Context, metrics []*domain.CustomMetric) error {
+	query := `
+		INSERT INTO custom_metrics (user_id, name, value, instance_id, recorded_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to save custom metrics", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, m := range metrics {
+		if _, err := tx.Exec(ctx, query, m.UserID, m.Name, m.Value, m.InstanceID, m.Timestamp); err != nil {
+			return errors.Wrap(errors.Internal, "failed to save custom metrics", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(errors.Internal, "failed to save custom metrics", err)
+	}
+	return nil
+}
+
+func (r *MetricsRepository) DeleteCustomMetricsBefore(ctx context.Context, before time.Time) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM custom_metrics WHERE recorded_at < $1`, before); err != nil {
+		return errors.Wrap(errors.Internal, "failed to prune custom metrics", err)
+	}
+	return nil
+}
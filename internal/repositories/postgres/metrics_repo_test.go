@@ -0,0 +1,137 @@
+//go:build integration
+
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	errs "github.com/poyrazk/thecloud/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsRepository_Integration(t *testing.T) {
+	db := setupDB(t)
+	defer db.Close()
+	cleanDB(t, db)
+	ctx := setupTestUser(t, db)
+	repo := NewMetricsRepository(db)
+
+	inst := &domain.Instance{
+		ID:        uuid.New(),
+		UserID:    appcontext.UserIDFromContext(ctx),
+		Name:      "metrics-test-inst",
+		Image:     "alpine",
+		Status:    domain.StatusRunning,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Version:   1,
+	}
+	require.NoError(t, NewInstanceRepository(db).Create(ctx, inst))
+
+	start := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	var samples []*domain.InstanceMetric
+	for i := 0; i < 4; i++ {
+		samples = append(samples, &domain.InstanceMetric{
+			InstanceID:     inst.ID,
+			CPUPercent:     float64(100 + 50*i),
+			MemoryBytes:    int64(1000 * (i + 1)),
+			NetworkRxBytes: int64(10 * (i + 1)),
+			RecordedAt:     start.Add(time.Duration(i) * 3 * time.Minute),
+		})
+	}
+	require.NoError(t, repo.SaveInstanceMetrics(ctx, samples))
+
+	// Samples at :00 and :03 fall in the first 5m bucket, :06 and :09 in the second.
+	require.NoError(t, repo.RollupInstanceMetrics(ctx, 0, domain.MetricsResolution5m, start, start.Add(10*time.Minute)))
+	require.NoError(t, repo.RollupInstanceMetrics(ctx, domain.MetricsResolution5m, domain.MetricsResolution1h, start, start.Add(time.Hour)))
+
+	var samplesCount int
+	var cpuAvg, cpuMax float64
+	var memAvg, rx int64
+	err := db.QueryRow(ctx, `
+		SELECT samples, cpu_avg, cpu_max, memory_avg_bytes, network_rx_bytes FROM metrics_rollups
+		WHERE instance_id = $1 AND resolution_seconds = 300 AND bucket_start = $2
+	`, inst.ID, start).Scan(&samplesCount, &cpuAvg, &cpuMax, &memAvg, &rx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, samplesCount)
+	assert.InDelta(t, 125.0, cpuAvg, 0.001)
+	assert.InDelta(t, 150.0, cpuMax, 0.001)
+	assert.Equal(t, int64(1500), memAvg)
+	assert.Equal(t, int64(20), rx)
+
+	err = db.QueryRow(ctx, `
+		SELECT samples, cpu_avg, cpu_max FROM metrics_rollups
+		WHERE instance_id = $1 AND resolution_seconds = 3600 AND bucket_start = $2
+	`, inst.ID, start).Scan(&samplesCount, &cpuAvg, &cpuMax)
+	require.NoError(t, err)
+	assert.Equal(t, 4, samplesCount)
+	assert.InDelta(t, 175.0, cpuAvg, 0.001)
+	assert.InDelta(t, 250.0, cpuMax, 0.001)
+
+	require.NoError(t, repo.DeleteInstanceMetricsBefore(ctx, 0, start.Add(5*time.Minute)))
+	require.NoError(t, repo.DeleteInstanceMetricsBefore(ctx, domain.MetricsResolution5m, start.Add(time.Hour)))
+	var raw, rollups int
+	require.NoError(t, db.QueryRow(ctx, `SELECT COUNT(*) FROM metrics_history WHERE instance_id = $1`, inst.ID).Scan(&raw))
+	require.NoError(t, db.QueryRow(ctx, `SELECT COUNT(*) FROM metrics_rollups WHERE instance_id = $1`, inst.ID).Scan(&rollups))
+	assert.Equal(t, 2, raw)
+	assert.Equal(t, 1, rollups)
+}
+
+func TestAutoScalingRepo_PolicyMetrics_Integration(t *testing.T) {
+	db := setupDB(t)
+	defer db.Close()
+	cleanDB(t, db)
+	ctx := setupTestUser(t, db)
+	userID := appcontext.UserIDFromContext(ctx)
+	repo := NewAutoScalingRepo(db)
+	metricsRepo := NewMetricsRepository(db)
+
+	inst := &domain.Instance{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      "policy-metrics-inst",
+		Image:     "alpine",
+		Status:    domain.StatusRunning,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Version:   1,
+	}
+	require.NoError(t, NewInstanceRepository(db).Create(ctx, inst))
+	ids := []uuid.UUID{inst.ID}
+
+	now := time.Now()
+	require.NoError(t, metricsRepo.SaveInstanceMetrics(ctx, []*domain.InstanceMetric{
+		{InstanceID: inst.ID, MemoryBytes: 200, MemoryLimitBytes: 1000, NetworkRxBytes: 1000, NetworkTxBytes: 0, RecordedAt: now.Add(-40 * time.Second)},
+		{InstanceID: inst.ID, MemoryBytes: 400, MemoryLimitBytes: 1000, NetworkRxBytes: 4000, NetworkTxBytes: 600, RecordedAt: now.Add(-10 * time.Second)},
+	}))
+
+	memory, err := repo.GetAverageMemory(ctx, ids, now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.InDelta(t, 30.0, memory, 0.001)
+
+	rx, tx, err := repo.GetAverageNetworkThroughput(ctx, ids, now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.InDelta(t, 100.0, rx, 0.001)
+	assert.InDelta(t, 20.0, tx, 0.001)
+
+	_, err = repo.GetAverageCustomMetric(ctx, userID, "queue_depth", ids, now.Add(-time.Minute))
+	assert.True(t, errs.Is(err, errs.NotFound))
+
+	require.NoError(t, metricsRepo.SaveCustomMetrics(ctx, []*domain.CustomMetric{
+		{UserID: userID, Name: "queue_depth", Value: 10, Timestamp: now},
+		{UserID: userID, Name: "queue_depth", Value: 20, InstanceID: &inst.ID, Timestamp: now},
+		{UserID: userID, Name: "queue_depth", Value: 90, Timestamp: now.Add(-time.Hour)},
+	}))
+	avg, err := repo.GetAverageCustomMetric(ctx, userID, "queue_depth", ids, now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.InDelta(t, 15.0, avg, 0.001)
+	// Data points of instances outside the group do not count.
+	avg, err = repo.GetAverageCustomMetric(ctx, userID, "queue_depth", nil, now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.InDelta(t, 10.0, avg, 0.001)
+}
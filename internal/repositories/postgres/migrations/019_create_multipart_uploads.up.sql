@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS multipart_uploads (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    bucket VARCHAR(255) NOT NULL,
+    key VARCHAR(512) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS multipart_parts (
+    upload_id UUID NOT NULL REFERENCES multipart_uploads(id) ON DELETE CASCADE,
+    part_number INT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    etag VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (upload_id, part_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_multipart_uploads_user_id ON multipart_uploads(user_id);
+CREATE INDEX IF NOT EXISTS idx_multipart_uploads_created_at ON multipart_uploads(created_at);
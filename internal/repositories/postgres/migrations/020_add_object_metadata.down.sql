@@ -0,0 +1,6 @@
+ALTER TABLE multipart_uploads DROP COLUMN IF EXISTS metadata;
+ALTER TABLE multipart_uploads DROP COLUMN IF EXISTS content_type;
+
+ALTER TABLE objects DROP COLUMN IF EXISTS metadata;
+ALTER TABLE objects DROP COLUMN IF EXISTS sha256;
+ALTER TABLE objects DROP COLUMN IF EXISTS etag;
@@ -0,0 +1,6 @@
+ALTER TABLE objects ADD COLUMN IF NOT EXISTS etag VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE objects ADD COLUMN IF NOT EXISTS sha256 VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE objects ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+ALTER TABLE multipart_uploads ADD COLUMN IF NOT EXISTS content_type VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE multipart_uploads ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_objects_deleted_at;
+DROP TABLE IF EXISTS lifecycle_rules;
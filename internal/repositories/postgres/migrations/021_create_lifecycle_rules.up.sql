@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS lifecycle_rules (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    bucket VARCHAR(255) NOT NULL,
+    prefix VARCHAR(512) NOT NULL DEFAULT '',
+    expiration_days INT NOT NULL DEFAULT 0,
+    deleted_retention_days INT NOT NULL DEFAULT 0,
+    abort_incomplete_upload_days INT NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_lifecycle_rules_user_bucket ON lifecycle_rules(user_id, bucket);
+CREATE INDEX IF NOT EXISTS idx_objects_deleted_at ON objects(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS bucket_encryption;
+
+ALTER TABLE multipart_uploads DROP COLUMN IF EXISTS encryption;
+
+ALTER TABLE objects DROP COLUMN IF EXISTS encrypted_key;
+ALTER TABLE objects DROP COLUMN IF EXISTS encryption;
@@ -0,0 +1,12 @@
+ALTER TABLE objects ADD COLUMN IF NOT EXISTS encryption VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE objects ADD COLUMN IF NOT EXISTS encrypted_key TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE multipart_uploads ADD COLUMN IF NOT EXISTS encryption VARCHAR(16) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS bucket_encryption (
+    user_id UUID NOT NULL REFERENCES users(id),
+    bucket VARCHAR(255) NOT NULL,
+    algorithm VARCHAR(16) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, bucket)
+);
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS bucket_notifications (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    bucket VARCHAR(255) NOT NULL,
+    events TEXT[] NOT NULL DEFAULT '{}',
+    prefix VARCHAR(512) NOT NULL DEFAULT '',
+    suffix VARCHAR(512) NOT NULL DEFAULT '',
+    function_id UUID REFERENCES functions(id) ON DELETE CASCADE,
+    webhook_url TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bucket_notifications_user_bucket ON bucket_notifications(user_id, bucket);
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id UUID PRIMARY KEY,
+    notification_id UUID NOT NULL REFERENCES bucket_notifications(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id),
+    event JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'PENDING',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_due ON notification_deliveries(next_attempt_at) WHERE status = 'PENDING';
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS bucket_websites (
+    bucket VARCHAR(255) PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    index_document VARCHAR(1024) NOT NULL,
+    error_document VARCHAR(1024) NOT NULL DEFAULT '',
+    cache_max_age INT NOT NULL DEFAULT 300,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bucket_websites_user ON bucket_websites(user_id);
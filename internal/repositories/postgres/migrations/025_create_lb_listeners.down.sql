@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS lb_routing_rules;
+DROP TABLE IF EXISTS lb_listeners;
+ALTER TABLE lb_targets DROP COLUMN IF EXISTS target_group;
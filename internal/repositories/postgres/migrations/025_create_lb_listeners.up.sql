@@ -0,0 +1,25 @@
+ALTER TABLE lb_targets ADD COLUMN IF NOT EXISTS target_group VARCHAR(64) NOT NULL DEFAULT 'default';
+
+CREATE TABLE IF NOT EXISTS lb_listeners (
+    id UUID PRIMARY KEY,
+    lb_id UUID NOT NULL REFERENCES load_balancers(id) ON DELETE CASCADE,
+    port INT NOT NULL,
+    protocol VARCHAR(16) NOT NULL,
+    certificate_secret VARCHAR(255) NOT NULL DEFAULT '',
+    redirect_port INT NOT NULL DEFAULT 0,
+    default_target_group VARCHAR(64) NOT NULL DEFAULT 'default',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(lb_id, port)
+);
+
+CREATE TABLE IF NOT EXISTS lb_routing_rules (
+    id UUID PRIMARY KEY,
+    listener_id UUID NOT NULL REFERENCES lb_listeners(id) ON DELETE CASCADE,
+    host VARCHAR(255) NOT NULL DEFAULT '',
+    path_prefix VARCHAR(1024) NOT NULL DEFAULT '/',
+    target_group VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(listener_id, host, path_prefix)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lb_listeners_lb ON lb_listeners(lb_id);
@@ -0,0 +1 @@
+ALTER TABLE load_balancers DROP COLUMN IF EXISTS health_check;
@@ -0,0 +1,4 @@
+-- Existing load balancers get a TCP check, as their targets were never required
+-- to answer HTTP on /. New load balancers are created with the HTTP default.
+ALTER TABLE load_balancers ADD COLUMN IF NOT EXISTS health_check JSONB NOT NULL DEFAULT
+    '{"protocol": "tcp", "interval_seconds": 10, "timeout_seconds": 5, "healthy_threshold": 3, "unhealthy_threshold": 2}';
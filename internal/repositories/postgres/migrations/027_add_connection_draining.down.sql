@@ -0,0 +1,3 @@
+ALTER TABLE scaling_group_instances DROP COLUMN IF EXISTS drain_until;
+ALTER TABLE lb_targets DROP COLUMN IF EXISTS draining_since;
+ALTER TABLE load_balancers DROP COLUMN IF EXISTS deregistration_delay_seconds;
@@ -0,0 +1,3 @@
+ALTER TABLE load_balancers ADD COLUMN IF NOT EXISTS deregistration_delay_seconds INT NOT NULL DEFAULT 30;
+ALTER TABLE lb_targets ADD COLUMN IF NOT EXISTS draining_since TIMESTAMPTZ;
+ALTER TABLE scaling_group_instances ADD COLUMN IF NOT EXISTS drain_until TIMESTAMPTZ;
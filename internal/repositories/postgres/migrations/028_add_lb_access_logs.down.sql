@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS lb_request_stats;
+ALTER TABLE load_balancers DROP COLUMN IF EXISTS access_logs;
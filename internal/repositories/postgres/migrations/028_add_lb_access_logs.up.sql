@@ -0,0 +1,20 @@
+ALTER TABLE load_balancers ADD COLUMN IF NOT EXISTS access_logs JSONB NOT NULL DEFAULT '{"enabled": false}';
+
+-- One row per load balancer, target and minute collected; the worker may
+-- write several rows for the same minute, which readers add up.
+CREATE TABLE IF NOT EXISTS lb_request_stats (
+    id BIGSERIAL PRIMARY KEY,
+    lb_id UUID NOT NULL REFERENCES load_balancers(id) ON DELETE CASCADE,
+    instance_id UUID,
+    minute TIMESTAMPTZ NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    status_2xx BIGINT NOT NULL DEFAULT 0,
+    status_3xx BIGINT NOT NULL DEFAULT 0,
+    status_4xx BIGINT NOT NULL DEFAULT 0,
+    status_5xx BIGINT NOT NULL DEFAULT 0,
+    bytes_sent BIGINT NOT NULL DEFAULT 0,
+    latency_sum DOUBLE PRECISION NOT NULL DEFAULT 0,
+    latency_counts BIGINT[] NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX IF NOT EXISTS idx_lb_request_stats_lb_minute ON lb_request_stats(lb_id, minute);
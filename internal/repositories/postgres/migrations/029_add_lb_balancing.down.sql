@@ -0,0 +1,2 @@
+ALTER TABLE load_balancers DROP COLUMN IF EXISTS stickiness;
+ALTER TABLE load_balancers DROP COLUMN IF EXISTS hash_header;
@@ -0,0 +1,2 @@
+ALTER TABLE load_balancers ADD COLUMN IF NOT EXISTS hash_header TEXT NOT NULL DEFAULT '';
+ALTER TABLE load_balancers ADD COLUMN IF NOT EXISTS stickiness JSONB NOT NULL DEFAULT '{"enabled": false}';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS metrics_rollups;
+ALTER TABLE metrics_history ALTER COLUMN cpu_percent TYPE DECIMAL(5,2) USING LEAST(cpu_percent, 999.99);
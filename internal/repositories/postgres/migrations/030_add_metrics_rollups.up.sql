@@ -0,0 +1,21 @@
+-- Multi-core containers report more than 100% CPU, beyond DECIMAL(5,2).
+ALTER TABLE metrics_history ALTER COLUMN cpu_percent TYPE DOUBLE PRECISION;
+
+-- Downsampled instance metrics, one row per instance, resolution and bucket.
+-- Network counters hold the highest total seen in the bucket.
+CREATE TABLE IF NOT EXISTS metrics_rollups (
+    instance_id UUID NOT NULL REFERENCES instances(id) ON DELETE CASCADE,
+    resolution_seconds INT NOT NULL,
+    bucket_start TIMESTAMPTZ NOT NULL,
+    samples INT NOT NULL,
+    cpu_avg DOUBLE PRECISION NOT NULL,
+    cpu_max DOUBLE PRECISION NOT NULL,
+    memory_avg_bytes BIGINT NOT NULL,
+    memory_max_bytes BIGINT NOT NULL,
+    memory_limit_bytes BIGINT NOT NULL DEFAULT 0,
+    network_rx_bytes BIGINT NOT NULL DEFAULT 0,
+    network_tx_bytes BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (instance_id, resolution_seconds, bucket_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_metrics_rollups_resolution_bucket ON metrics_rollups(resolution_seconds, bucket_start);
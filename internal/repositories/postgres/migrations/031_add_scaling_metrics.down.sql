@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS custom_metrics;
+
+DELETE FROM scaling_policies WHERE metric_type NOT IN ('cpu', 'memory') OR target_value > 100;
+ALTER TABLE scaling_policies DROP CONSTRAINT IF EXISTS scaling_policies_metric_type_check;
+ALTER TABLE scaling_policies DROP CONSTRAINT IF EXISTS scaling_policies_target_value_check;
+ALTER TABLE scaling_policies DROP COLUMN IF EXISTS metric_name;
+ALTER TABLE scaling_policies ALTER COLUMN target_value TYPE DECIMAL(5,2);
+ALTER TABLE scaling_policies ADD CONSTRAINT scaling_policies_metric_type_check CHECK (metric_type IN ('cpu', 'memory'));
+ALTER TABLE scaling_policies ADD CONSTRAINT scaling_policies_target_value_check CHECK (target_value > 0 AND target_value <= 100);
@@ -0,0 +1,23 @@
+-- Policies may scale on more than CPU and memory percentages, so the target
+-- is no longer capped at 100.
+ALTER TABLE scaling_policies DROP CONSTRAINT IF EXISTS scaling_policies_metric_type_check;
+ALTER TABLE scaling_policies DROP CONSTRAINT IF EXISTS scaling_policies_target_value_check;
+ALTER TABLE scaling_policies ALTER COLUMN target_value TYPE DOUBLE PRECISION;
+ALTER TABLE scaling_policies ADD COLUMN IF NOT EXISTS metric_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE scaling_policies ADD CONSTRAINT scaling_policies_metric_type_check
+    CHECK (metric_type IN ('cpu', 'memory', 'network_in', 'network_out', 'lb_request_count', 'custom'));
+ALTER TABLE scaling_policies ADD CONSTRAINT scaling_policies_target_value_check CHECK (target_value > 0);
+
+-- Application metrics pushed through PUT /metrics/custom. Data points without
+-- an instance apply to every scaling group of the user.
+CREATE TABLE IF NOT EXISTS custom_metrics (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    instance_id UUID REFERENCES instances(id) ON DELETE CASCADE,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_custom_metrics_user_name_time ON custom_metrics(user_id, name, recorded_at);
+CREATE INDEX IF NOT EXISTS idx_custom_metrics_recorded_at ON custom_metrics(recorded_at);
@@ -0,0 +1,18 @@
+DELETE FROM scaling_policies WHERE policy_type = 'step';
+ALTER TABLE scaling_policies DROP CONSTRAINT IF EXISTS scaling_policies_cooldowns_check;
+ALTER TABLE scaling_policies DROP CONSTRAINT IF EXISTS scaling_policies_policy_type_check;
+ALTER TABLE scaling_policies DROP CONSTRAINT IF EXISTS scaling_policies_target_value_check;
+ALTER TABLE scaling_policies ADD CONSTRAINT scaling_policies_target_value_check CHECK (target_value > 0);
+
+ALTER TABLE scaling_policies ADD COLUMN IF NOT EXISTS scale_out_step INT DEFAULT 1 CHECK (scale_out_step > 0);
+ALTER TABLE scaling_policies ADD COLUMN IF NOT EXISTS scale_in_step INT DEFAULT 1 CHECK (scale_in_step > 0);
+ALTER TABLE scaling_policies ADD COLUMN IF NOT EXISTS cooldown_sec INT DEFAULT 300 CHECK (cooldown_sec >= 60);
+UPDATE scaling_policies SET cooldown_sec = GREATEST(scale_out_cooldown_sec, scale_in_cooldown_sec);
+
+ALTER TABLE scaling_policies DROP COLUMN IF EXISTS scale_in_cooldown_sec;
+ALTER TABLE scaling_policies DROP COLUMN IF EXISTS scale_out_cooldown_sec;
+ALTER TABLE scaling_policies DROP COLUMN IF EXISTS scale_in_threshold;
+ALTER TABLE scaling_policies DROP COLUMN IF EXISTS last_scale_out_at;
+ALTER TABLE scaling_policies DROP COLUMN IF EXISTS evaluation_period_sec;
+ALTER TABLE scaling_policies DROP COLUMN IF EXISTS steps;
+ALTER TABLE scaling_policies DROP COLUMN IF EXISTS policy_type;
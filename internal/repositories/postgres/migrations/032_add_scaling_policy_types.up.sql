@@ -0,0 +1,33 @@
+ALTER TABLE scaling_policies ADD COLUMN IF NOT EXISTS policy_type TEXT NOT NULL DEFAULT 'target_tracking';
+ALTER TABLE scaling_policies ADD COLUMN IF NOT EXISTS steps JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE scaling_policies ADD COLUMN IF NOT EXISTS evaluation_period_sec INT NOT NULL DEFAULT 60;
+ALTER TABLE scaling_policies ADD COLUMN IF NOT EXISTS last_scale_out_at TIMESTAMPTZ;
+
+-- Replace the fixed steps and the shared cooldown. Existing policies keep
+-- their cooldown for both directions and the scale-in threshold the worker
+-- used to hard-code.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_name='scaling_policies' AND column_name='cooldown_sec') THEN
+        ALTER TABLE scaling_policies ADD COLUMN scale_in_threshold DOUBLE PRECISION NOT NULL DEFAULT 0;
+        ALTER TABLE scaling_policies ADD COLUMN scale_out_cooldown_sec INT NOT NULL DEFAULT 300;
+        ALTER TABLE scaling_policies ADD COLUMN scale_in_cooldown_sec INT NOT NULL DEFAULT 300;
+        UPDATE scaling_policies SET
+            scale_in_threshold = CASE WHEN metric_type IN ('cpu', 'memory') THEN GREATEST(target_value - 10, 0) ELSE target_value * 0.9 END,
+            scale_out_cooldown_sec = cooldown_sec,
+            scale_in_cooldown_sec = cooldown_sec,
+            last_scale_out_at = last_scaled_at;
+        ALTER TABLE scaling_policies DROP COLUMN cooldown_sec;
+        ALTER TABLE scaling_policies DROP COLUMN scale_out_step;
+        ALTER TABLE scaling_policies DROP COLUMN scale_in_step;
+    END IF;
+END $$;
+
+-- Step policies have no target.
+ALTER TABLE scaling_policies DROP CONSTRAINT IF EXISTS scaling_policies_target_value_check;
+ALTER TABLE scaling_policies ADD CONSTRAINT scaling_policies_target_value_check CHECK (target_value >= 0);
+ALTER TABLE scaling_policies DROP CONSTRAINT IF EXISTS scaling_policies_policy_type_check;
+ALTER TABLE scaling_policies ADD CONSTRAINT scaling_policies_policy_type_check CHECK (policy_type IN ('target_tracking', 'step'));
+ALTER TABLE scaling_policies DROP CONSTRAINT IF EXISTS scaling_policies_cooldowns_check;
+ALTER TABLE scaling_policies ADD CONSTRAINT scaling_policies_cooldowns_check CHECK (scale_out_cooldown_sec >= 60 AND scale_in_cooldown_sec >= 60);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS scaling_scheduled_actions;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS scaling_scheduled_actions (
+    id UUID PRIMARY KEY,
+    scaling_group_id UUID NOT NULL REFERENCES scaling_groups(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    schedule VARCHAR(255) NOT NULL,
+    time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    min_instances INT CHECK (min_instances >= 0),
+    max_instances INT CHECK (max_instances >= 0),
+    desired_count INT CHECK (desired_count >= 0),
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_run_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(scaling_group_id, name),
+    CHECK (min_instances IS NOT NULL OR max_instances IS NOT NULL OR desired_count IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ssa_next_run ON scaling_scheduled_actions(next_run_at);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_sg_launch_template;
+
+ALTER TABLE instances DROP COLUMN IF EXISTS instance_type;
+ALTER TABLE instances DROP COLUMN IF EXISTS launch_template_version;
+ALTER TABLE instances DROP COLUMN IF EXISTS launch_template_id;
+
+ALTER TABLE scaling_groups DROP COLUMN IF EXISTS launch_template_version;
+ALTER TABLE scaling_groups DROP COLUMN IF EXISTS launch_template_id;
+
+DROP TABLE IF EXISTS launch_template_versions;
+DROP TABLE IF EXISTS launch_templates;
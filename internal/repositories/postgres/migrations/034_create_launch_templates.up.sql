@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS launch_templates (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    latest_version INT NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS launch_template_versions (
+    template_id UUID NOT NULL REFERENCES launch_templates(id) ON DELETE CASCADE,
+    version INT NOT NULL CHECK (version > 0),
+    image VARCHAR(255) NOT NULL,
+    ports VARCHAR(255) NOT NULL DEFAULT '',
+    instance_type VARCHAR(32) NOT NULL DEFAULT '',
+    env JSONB NOT NULL DEFAULT '{}',
+    user_data TEXT NOT NULL DEFAULT '',
+    volumes JSONB NOT NULL DEFAULT '[]',
+    vpc_id UUID REFERENCES vpcs(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY(template_id, version)
+);
+
+ALTER TABLE scaling_groups ADD COLUMN IF NOT EXISTS launch_template_id UUID REFERENCES launch_templates(id);
+ALTER TABLE scaling_groups ADD COLUMN IF NOT EXISTS launch_template_version INT;
+
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS launch_template_id UUID REFERENCES launch_templates(id) ON DELETE SET NULL;
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS launch_template_version INT;
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS instance_type VARCHAR(32) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_sg_launch_template ON scaling_groups(launch_template_id);
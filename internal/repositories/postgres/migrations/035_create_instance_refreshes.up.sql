@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS scaling_instance_refreshes (
+    id UUID PRIMARY KEY,
+    scaling_group_id UUID NOT NULL REFERENCES scaling_groups(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    status_reason TEXT NOT NULL DEFAULT '',
+    min_healthy_percentage INT NOT NULL CHECK (min_healthy_percentage BETWEEN 0 AND 100),
+    health_check_timeout_sec INT NOT NULL CHECK (health_check_timeout_sec > 0),
+    instances_to_replace INT NOT NULL DEFAULT 0,
+    pending_instance_ids UUID[] NOT NULL DEFAULT '{}',
+    batch_started_at TIMESTAMPTZ,
+    rollback_config JSONB,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ended_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_sir_group ON scaling_instance_refreshes(scaling_group_id, started_at DESC);
+
+-- A group has at most one refresh that has not ended.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sir_group_active ON scaling_instance_refreshes(scaling_group_id)
+    WHERE status IN ('IN_PROGRESS', 'PAUSED', 'ROLLING_BACK');
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS scaling_activities;
+ALTER TABLE scaling_group_instances DROP COLUMN IF EXISTS lifecycle_result;
+ALTER TABLE scaling_group_instances DROP COLUMN IF EXISTS lifecycle_timeout_at;
+ALTER TABLE scaling_group_instances DROP COLUMN IF EXISTS lifecycle_state;
+DROP TABLE IF EXISTS scaling_lifecycle_hooks;
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS scaling_lifecycle_hooks (
+    id UUID PRIMARY KEY,
+    scaling_group_id UUID NOT NULL REFERENCES scaling_groups(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    transition VARCHAR(20) NOT NULL,
+    heartbeat_timeout_sec INT NOT NULL CHECK (heartbeat_timeout_sec > 0),
+    default_result VARCHAR(20) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(scaling_group_id, name),
+    UNIQUE(scaling_group_id, transition)
+);
+
+ALTER TABLE scaling_group_instances ADD COLUMN IF NOT EXISTS lifecycle_state VARCHAR(32) NOT NULL DEFAULT 'InService';
+ALTER TABLE scaling_group_instances ADD COLUMN IF NOT EXISTS lifecycle_timeout_at TIMESTAMPTZ;
+ALTER TABLE scaling_group_instances ADD COLUMN IF NOT EXISTS lifecycle_result VARCHAR(20);
+
+CREATE TABLE IF NOT EXISTS scaling_activities (
+    id UUID PRIMARY KEY,
+    scaling_group_id UUID NOT NULL REFERENCES scaling_groups(id) ON DELETE CASCADE,
+    description TEXT NOT NULL,
+    cause TEXT NOT NULL,
+    from_capacity INT NOT NULL,
+    to_capacity INT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    status_message TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sact_group ON scaling_activities(scaling_group_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_sact_started ON scaling_activities(started_at);
@@ -0,0 +1 @@
+ALTER TABLE scaling_groups DROP COLUMN IF EXISTS suspended_processes;
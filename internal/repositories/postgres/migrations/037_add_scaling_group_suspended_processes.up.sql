@@ -0,0 +1 @@
+ALTER TABLE scaling_groups ADD COLUMN IF NOT EXISTS suspended_processes TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+ALTER TABLE scaling_groups DROP COLUMN IF EXISTS health_check_grace_period_sec;
+ALTER TABLE scaling_groups DROP COLUMN IF EXISTS health_check_type;
@@ -0,0 +1,2 @@
+ALTER TABLE scaling_groups ADD COLUMN IF NOT EXISTS health_check_type VARCHAR(20) NOT NULL DEFAULT 'container';
+ALTER TABLE scaling_groups ADD COLUMN IF NOT EXISTS health_check_grace_period_sec INT NOT NULL DEFAULT 300;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS leader_leases (
+    name VARCHAR(100) PRIMARY KEY,
+    holder_id VARCHAR(255) NOT NULL,
+    acquired_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    renewed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
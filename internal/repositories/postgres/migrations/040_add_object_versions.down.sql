@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS object_versions;
+
+ALTER TABLE objects DROP COLUMN IF EXISTS storage_key;
@@ -0,0 +1,16 @@
+-- Each write stores its bytes under a fresh storage key; rows without one are
+-- older objects whose bytes live at bucket/key.
+ALTER TABLE objects ADD COLUMN IF NOT EXISTS storage_key TEXT NOT NULL DEFAULT '';
+
+-- Bytes of versions replaced by a newer upload, kept until their retention window passes.
+CREATE TABLE IF NOT EXISTS object_versions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    bucket VARCHAR(255) NOT NULL,
+    key VARCHAR(512) NOT NULL,
+    storage_key TEXT NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    noncurrent_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_object_versions_noncurrent_at ON object_versions(noncurrent_at);
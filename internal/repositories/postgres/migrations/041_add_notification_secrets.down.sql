@@ -0,0 +1 @@
+ALTER TABLE bucket_notifications DROP COLUMN IF EXISTS secret;
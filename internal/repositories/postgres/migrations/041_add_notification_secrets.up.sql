@@ -0,0 +1,5 @@
+ALTER TABLE bucket_notifications ADD COLUMN IF NOT EXISTS secret TEXT NOT NULL DEFAULT '';
+
+UPDATE bucket_notifications
+SET secret = replace(gen_random_uuid()::text, '-', '') || replace(gen_random_uuid()::text, '-', '')
+WHERE secret = '' AND webhook_url <> '';
@@ -0,0 +1,160 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/domain"
+	"github.com/poyrazk/thecloud/internal/errors"
+)
+
+type NotificationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewNotificationRepository(db *pgxpool.Pool) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+func (r *NotificationRepository) Create(ctx context.Context, n *domain.BucketNotification) error {
+	query := `
+		INSERT INTO bucket_notifications (id, user_id, bucket, events, prefix, suffix, function_id, webhook_url, secret, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	events := n.Events
+	if events == nil {
+		events = []string{}
+	}
+	_, err := r.db.Exec(ctx, query, n.ID, n.UserID, n.Bucket, events, n.Prefix, n.Suffix, n.FunctionID, n.WebhookURL, n.Secret, n.CreatedAt)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to create bucket notification", err)
+	}
+	return nil
+}
+
+func (r *NotificationRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.BucketNotification, error) {
+	userID := appcontext.UserIDFromContext(ctx)
+	query := `
+		SELECT id, user_id, bucket, events, prefix, suffix, function_id, webhook_url, secret, created_at
+		FROM bucket_notifications
+		WHERE id = $1 AND user_id = $2
+	`
+	n, err := scanNotification(r.db.QueryRow(ctx, query, id, userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New(errors.NotFound, "notification not found")
+		}
+		return nil, errors.Wrap(errors.Internal, "failed to get bucket notification", err)
+	}
+	return n, nil
+}
+
+func (r *NotificationRepository) ListByBucket(ctx context.Context, bucket string) ([]*domain.BucketNotification, error) {
+	userID := appcontext.UserIDFromContext(ctx)
+	query := `
+		SELECT id, user_id, bucket, events, prefix, suffix, function_id, webhook_url, secret, created_at
+		FROM bucket_notifications
+		WHERE bucket = $1 AND user_id = $2
+		ORDER BY created_at ASC
+	`
+	rows, err := r.db.Query(ctx, query, bucket, userID)
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to list bucket notifications", err)
+	}
+	defer rows.Close()
+
+	var notifications []*domain.BucketNotification
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to scan bucket notification", err)
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, nil
+}
+
+func scanNotification(row pgx.Row) (*domain.BucketNotification, error) {
+	var n domain.BucketNotification
+	if err := row.Scan(&n.ID, &n.UserID, &n.Bucket, &n.Events, &n.Prefix, &n.Suffix, &n.FunctionID, &n.WebhookURL, &n.Secret, &n.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (r *NotificationRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	userID := appcontext.UserIDFromContext(ctx)
+	cmd, err := r.db.Exec(ctx, `DELETE FROM bucket_notifications WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to delete bucket notification", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errors.New(errors.NotFound, "notification not found")
+	}
+	return nil
+}
+
+func (r *NotificationRepository) CreateDelivery(ctx context.Context, d *domain.NotificationDelivery) error {
+	query := `
+		INSERT INTO notification_deliveries (id, notification_id, user_id, event, status, attempts, next_attempt_at, last_error, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := r.db.Exec(ctx, query, d.ID, d.NotificationID, d.UserID, d.Event, d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.CreatedAt)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to queue notification delivery", err)
+	}
+	return nil
+}
+
+func (r *NotificationRepository) ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*domain.NotificationDelivery, error) {
+	query := `
+		UPDATE notification_deliveries
+		SET next_attempt_at = $3
+		WHERE id IN (
+			SELECT id FROM notification_deliveries
+			WHERE status = $1 AND next_attempt_at <= $2
+			ORDER BY next_attempt_at ASC
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, notification_id, user_id, event, status, attempts, next_attempt_at, last_error, created_at
+	`
+	rows, err := r.db.Query(ctx, query, domain.DeliveryPending, now, leaseUntil, limit)
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to claim notification deliveries", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.NotificationDelivery
+	for rows.Next() {
+		var d domain.NotificationDelivery
+		if err := rows.Scan(&d.ID, &d.NotificationID, &d.UserID, &d.Event, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.LastError, &d.CreatedAt); err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to scan notification delivery", err)
+		}
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries, nil
+}
+
+func (r *NotificationRepository) UpdateDelivery(ctx context.Context, d *domain.NotificationDelivery) error {
+	query := `
+		UPDATE notification_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4
+		WHERE id = $5
+	`
+	if _, err := r.db.Exec(ctx, query, d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.ID); err != nil {
+		return errors.Wrap(errors.Internal, "failed to update notification delivery", err)
+	}
+	return nil
+}
+
+func (r *NotificationRepository) DeleteDelivery(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM notification_deliveries WHERE id = $1`, id); err != nil {
+		return errors.Wrap(errors.Internal, "failed to delete notification delivery", err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	appcontext "github.com/poyrazk/thecloud/internal/core/context"
@@ -20,18 +21,39 @@ func NewStorageRepository(db *pgxpool.Pool) *StorageRepository {
 }
 
 func (r *StorageRepository) SaveMeta(ctx context.Context, obj *domain.Object) error {
+	// The row of an overwritten object is reused, so the version it pointed to
+	// is recorded as non-current in the same statement; the lock keeps
+	// concurrent uploads of the key from losing track of each other's bytes
 	query := `
-		INSERT INTO objects (id, user_id, arn, bucket, key, size_bytes, content_type, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		WITH previous AS (
+			SELECT user_id, bucket, key, storage_key, size_bytes
+			FROM objects
+			WHERE bucket = $4 AND key = $5
+			FOR UPDATE
+		), retired AS (
+			INSERT INTO object_versions (id, user_id, bucket, key, storage_key, size_bytes, noncurrent_at)
+			SELECT $15, user_id, bucket, key, storage_key, size_bytes, NOW()
+			FROM previous
+			WHERE storage_key <> $14 AND user_id IS NOT NULL
+		)
+		INSERT INTO objects (id, user_id, arn, bucket, key, size_bytes, content_type, etag, sha256, metadata, encryption, encrypted_key, created_at, storage_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		ON CONFLICT (bucket, key) DO UPDATE SET
 			size_bytes = EXCLUDED.size_bytes,
 			content_type = EXCLUDED.content_type,
+			etag = EXCLUDED.etag,
+			sha256 = EXCLUDED.sha256,
+			metadata = EXCLUDED.metadata,
+			encryption = EXCLUDED.encryption,
+			encrypted_key = EXCLUDED.encrypted_key,
+			storage_key = EXCLUDED.storage_key,
 			created_at = EXCLUDED.created_at,
 			deleted_at = NULL,
 			user_id = EXCLUDED.user_id
 	`
 	_, err := r.db.Exec(ctx, query,
-		obj.ID, obj.UserID, obj.ARN, obj.Bucket, obj.Key, obj.SizeBytes, obj.ContentType, obj.CreatedAt,
+		obj.ID, obj.UserID, obj.ARN, obj.Bucket, obj.Key, obj.SizeBytes, obj.ContentType, obj.ETag, obj.SHA256, metadataOrEmpty(obj.Metadata), obj.Encryption, obj.EncryptedKey, obj.CreatedAt, obj.StorageKey,
+		uuid.New(),
 	)
 	if err != nil {
 		return errors.Wrap(errors.Internal, "failed to save object metadata", err)
@@ -42,13 +64,13 @@ func (r *StorageRepository) SaveMeta(ctx context.Context, obj *domain.Object) er
 func (r *StorageRepository) GetMeta(ctx context.Context, bucket, key string) (*domain.Object, error) {
 	userID := appcontext.UserIDFromContext(ctx)
 	query := `
-		SELECT id, user_id, arn, bucket, key, size_bytes, content_type, created_at
+		SELECT id, user_id, arn, bucket, key, size_bytes, content_type, etag, sha256, metadata, encryption, encrypted_key, storage_key, created_at
 		FROM objects
 		WHERE bucket = $1 AND key = $2 AND deleted_at IS NULL AND user_id = $3
 	`
 	var obj domain.Object
 	err := r.db.QueryRow(ctx, query, bucket, key, userID).Scan(
-		&obj.ID, &obj.UserID, &obj.ARN, &obj.Bucket, &obj.Key, &obj.SizeBytes, &obj.ContentType, &obj.CreatedAt,
+		&obj.ID, &obj.UserID, &obj.ARN, &obj.Bucket, &obj.Key, &obj.SizeBytes, &obj.ContentType, &obj.ETag, &obj.SHA256, &obj.Metadata, &obj.Encryption, &obj.EncryptedKey, &obj.StorageKey, &obj.CreatedAt,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -62,7 +84,7 @@ func (r *StorageRepository) GetMeta(ctx context.Context, bucket, key string) (*d
 func (r *StorageRepository) List(ctx context.Context, bucket string) ([]*domain.Object, error) {
 	userID := appcontext.UserIDFromContext(ctx)
 	query := `
-		SELECT id, user_id, arn, bucket, key, size_bytes, content_type, created_at
+		SELECT id, user_id, arn, bucket, key, size_bytes, content_type, etag, sha256, metadata, encryption, encrypted_key, storage_key, created_at
 		FROM objects
 		WHERE bucket = $1 AND deleted_at IS NULL AND user_id = $2
 		ORDER BY created_at DESC
@@ -77,7 +99,7 @@ func (r *StorageRepository) List(ctx context.Context, bucket string) ([]*domain.
 	for rows.Next() {
 		var obj domain.Object
 		err := rows.Scan(
-			&obj.ID, &obj.UserID, &obj.ARN, &obj.Bucket, &obj.Key, &obj.SizeBytes, &obj.ContentType, &obj.CreatedAt,
+			&obj.ID, &obj.UserID, &obj.ARN, &obj.Bucket, &obj.Key, &obj.SizeBytes, &obj.ContentType, &obj.ETag, &obj.SHA256, &obj.Metadata, &obj.Encryption, &obj.EncryptedKey, &obj.StorageKey, &obj.CreatedAt,
 		)
 		if err != nil {
 			return nil, errors.Wrap(errors.Internal, "failed to scan object metadata", err)
@@ -103,3 +125,393 @@ func (r *StorageRepository) SoftDelete(ctx context.Context, bucket, key string)
 	}
 	return nil
 }
+
+func (r *StorageRepository) ListBuckets(ctx context.Context) ([]*domain.Bucket, error) {
+	userID := appcontext.UserIDFromContext(ctx)
+	query := `
+		SELECT bucket, MIN(created_at)
+		FROM objects
+		WHERE deleted_at IS NULL AND user_id = $1
+		GROUP BY bucket
+		ORDER BY bucket ASC
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to list buckets", err)
+	}
+	defer rows.Close()
+
+	var buckets []*domain.Bucket
+	for rows.Next() {
+		var b domain.Bucket
+		if err := rows.Scan(&b.Name, &b.CreatedAt); err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to scan bucket", err)
+		}
+		buckets = append(buckets, &b)
+	}
+	return buckets, nil
+}
+
+func (r *StorageRepository) CreateMultipartUpload(ctx context.Context, upload *domain.MultipartUpload) error {
+	query := `
+		INSERT INTO multipart_uploads (id, user_id, bucket, key, content_type, metadata, encryption, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.Exec(ctx, query, upload.ID, upload.UserID, upload.Bucket, upload.Key, upload.ContentType, metadataOrEmpty(upload.Metadata), upload.Encryption, upload.CreatedAt)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to create multipart upload", err)
+	}
+	return nil
+}
+
+func (r *StorageRepository) GetMultipartUpload(ctx context.Context, id uuid.UUID) (*domain.MultipartUpload, error) {
+	userID := appcontext.UserIDFromContext(ctx)
+	query := `
+		SELECT id, user_id, bucket, key, content_type, metadata, encryption, created_at
+		FROM multipart_uploads
+		WHERE id = $1 AND user_id = $2
+	`
+	var u domain.MultipartUpload
+	err := r.db.QueryRow(ctx, query, id, userID).Scan(&u.ID, &u.UserID, &u.Bucket, &u.Key, &u.ContentType, &u.Metadata, &u.Encryption, &u.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New(errors.NotFound, "multipart upload not found")
+		}
+		return nil, errors.Wrap(errors.Internal, "failed to get multipart upload", err)
+	}
+	return &u, nil
+}
+
+func (r *StorageRepository) DeleteMultipartUpload(ctx context.Context, id uuid.UUID) error {
+	userID := appcontext.UserIDFromContext(ctx)
+	query := `DELETE FROM multipart_uploads WHERE id = $1 AND user_id = $2`
+	cmd, err := r.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to delete multipart upload", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errors.New(errors.NotFound, "multipart upload not found")
+	}
+	return nil
+}
+
+func (r *StorageRepository) SavePart(ctx context.Context, part *domain.Part) error {
+	query := `
+		INSERT INTO multipart_parts (upload_id, part_number, size_bytes, etag, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (upload_id, part_number) DO UPDATE SET
+			size_bytes = EXCLUDED.size_bytes,
+			etag = EXCLUDED.etag,
+			created_at = EXCLUDED.created_at
+	`
+	_, err := r.db.Exec(ctx, query, part.UploadID, part.PartNumber, part.SizeBytes, part.ETag, part.CreatedAt)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to save upload part", err)
+	}
+	return nil
+}
+
+func (r *StorageRepository) ListParts(ctx context.Context, uploadID uuid.UUID) ([]*domain.Part, error) {
+	query := `
+		SELECT upload_id, part_number, size_bytes, etag, created_at
+		FROM multipart_parts
+		WHERE upload_id = $1
+		ORDER BY part_number ASC
+	`
+	rows, err := r.db.Query(ctx, query, uploadID)
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to list upload parts", err)
+	}
+	defer rows.Close()
+
+	var parts []*domain.Part
+	for rows.Next() {
+		var p domain.Part
+		if err := rows.Scan(&p.UploadID, &p.PartNumber, &p.SizeBytes, &p.ETag, &p.CreatedAt); err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to scan upload part", err)
+		}
+		parts = append(parts, &p)
+	}
+	return parts, nil
+}
+
+func (r *StorageRepository) ListStaleMultipartUploads(ctx context.Context, olderThan time.Time) ([]*domain.MultipartUpload, error) {
+	query := `
+		SELECT id, user_id, bucket, key, content_type, metadata, encryption, created_at
+		FROM multipart_uploads
+		WHERE created_at < $1
+		ORDER BY created_at ASC
+	`
+	rows, err := r.db.Query(ctx, query, olderThan)
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to list stale multipart uploads", err)
+	}
+	defer rows.Close()
+
+	var uploads []*domain.MultipartUpload
+	for rows.Next() {
+		var u domain.MultipartUpload
+		if err := rows.Scan(&u.ID, &u.UserID, &u.Bucket, &u.Key, &u.ContentType, &u.Metadata, &u.Encryption, &u.CreatedAt); err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to scan multipart upload", err)
+		}
+		uploads = append(uploads, &u)
+	}
+	return uploads, nil
+}
+
+func (r *StorageRepository) CreateLifecycleRule(ctx context.Context, rule *domain.LifecycleRule) error {
+	query := `
+		INSERT INTO lifecycle_rules (id, user_id, bucket, prefix, expiration_days, deleted_retention_days, abort_incomplete_upload_days, enabled, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := r.db.Exec(ctx, query,
+		rule.ID, rule.UserID, rule.Bucket, rule.Prefix, rule.ExpirationDays, rule.DeletedRetentionDays, rule.AbortIncompleteUploadDays, rule.Enabled, rule.CreatedAt,
+	)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to create lifecycle rule", err)
+	}
+	return nil
+}
+
+func (r *StorageRepository) ListLifecycleRules(ctx context.Context, bucket string) ([]*domain.LifecycleRule, error) {
+	userID := appcontext.UserIDFromContext(ctx)
+	query := `
+		SELECT id, user_id, bucket, prefix, expiration_days, deleted_retention_days, abort_incomplete_upload_days, enabled, created_at
+		FROM lifecycle_rules
+		WHERE bucket = $1 AND user_id = $2
+		ORDER BY created_at ASC
+	`
+	rows, err := r.db.Query(ctx, query, bucket, userID)
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to list lifecycle rules", err)
+	}
+	return scanLifecycleRules(rows)
+}
+
+func (r *StorageRepository) ListAllLifecycleRules(ctx context.Context) ([]*domain.LifecycleRule, error) {
+	query := `
+		SELECT id, user_id, bucket, prefix, expiration_days, deleted_retention_days, abort_incomplete_upload_days, enabled, created_at
+		FROM lifecycle_rules
+		WHERE enabled = TRUE
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to list lifecycle rules", err)
+	}
+	return scanLifecycleRules(rows)
+}
+
+func scanLifecycleRules(rows pgx.Rows) ([]*domain.LifecycleRule, error) {
+	defer rows.Close()
+	var rules []*domain.LifecycleRule
+	for rows.Next() {
+		var rule domain.LifecycleRule
+		if err := rows.Scan(
+			&rule.ID, &rule.UserID, &rule.Bucket, &rule.Prefix, &rule.ExpirationDays, &rule.DeletedRetentionDays, &rule.AbortIncompleteUploadDays, &rule.Enabled, &rule.CreatedAt,
+		); err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to scan lifecycle rule", err)
+		}
+		rules = append(rules, &rule)
+	}
+	return rules, nil
+}
+
+func (r *StorageRepository) DeleteLifecycleRule(ctx context.Context, id uuid.UUID) error {
+	userID := appcontext.UserIDFromContext(ctx)
+	cmd, err := r.db.Exec(ctx, `DELETE FROM lifecycle_rules WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to delete lifecycle rule", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errors.New(errors.NotFound, "lifecycle rule not found")
+	}
+	return nil
+}
+
+func (r *StorageRepository) ListDeletedObjects(ctx context.Context, deletedBefore time.Time) ([]*domain.Object, error) {
+	query := `
+		SELECT id, user_id, arn, bucket, key, size_bytes, content_type, etag, sha256, metadata, encryption, encrypted_key, storage_key, created_at, deleted_at
+		FROM objects
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1
+		ORDER BY deleted_at ASC
+	`
+	rows, err := r.db.Query(ctx, query, deletedBefore)
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to list deleted objects", err)
+	}
+	defer rows.Close()
+
+	var objects []*domain.Object
+	for rows.Next() {
+		var obj domain.Object
+		err := rows.Scan(
+			&obj.ID, &obj.UserID, &obj.ARN, &obj.Bucket, &obj.Key, &obj.SizeBytes, &obj.ContentType, &obj.ETag, &obj.SHA256, &obj.Metadata, &obj.Encryption, &obj.EncryptedKey, &obj.StorageKey, &obj.CreatedAt, &obj.DeletedAt,
+		)
+		if err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to scan object metadata", err)
+		}
+		objects = append(objects, &obj)
+	}
+	return objects, nil
+}
+
+func (r *StorageRepository) HardDelete(ctx context.Context, id uuid.UUID) error {
+	cmd, err := r.db.Exec(ctx, `DELETE FROM objects WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to delete object", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errors.New(errors.ObjectNotFound, "object not found or no longer deleted")
+	}
+	return nil
+}
+
+func (r *StorageRepository) ListNoncurrentVersions(ctx context.Context, replacedBefore time.Time) ([]*domain.ObjectVersion, error) {
+	query := `
+		SELECT id, user_id, bucket, key, storage_key, size_bytes, noncurrent_at
+		FROM object_versions
+		WHERE noncurrent_at < $1
+		ORDER BY noncurrent_at ASC
+	`
+	rows, err := r.db.Query(ctx, query, replacedBefore)
+	if err != nil {
+		return nil, errors.Wrap(errors.Internal, "failed to list noncurrent versions", err)
+	}
+	defer rows.Close()
+
+	var versions []*domain.ObjectVersion
+	for rows.Next() {
+		var v domain.ObjectVersion
+		if err := rows.Scan(&v.ID, &v.UserID, &v.Bucket, &v.Key, &v.StorageKey, &v.SizeBytes, &v.NoncurrentAt); err != nil {
+			return nil, errors.Wrap(errors.Internal, "failed to scan noncurrent version", err)
+		}
+		versions = append(versions, &v)
+	}
+	return versions, nil
+}
+
+func (r *StorageRepository) DeleteNoncurrentVersion(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM object_versions WHERE id = $1`, id); err != nil {
+		return errors.Wrap(errors.Internal, "failed to delete noncurrent version", err)
+	}
+	return nil
+}
+
+func (r *StorageRepository) PutBucketEncryption(ctx context.Context, cfg *domain.BucketEncryption) error {
+	query := `
+		INSERT INTO bucket_encryption (user_id, bucket, algorithm, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, bucket) DO UPDATE SET
+			algorithm = EXCLUDED.algorithm,
+			created_at = EXCLUDED.created_at
+	`
+	_, err := r.db.Exec(ctx, query, cfg.UserID, cfg.Bucket, cfg.Algorithm, cfg.CreatedAt)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to save bucket encryption", err)
+	}
+	return nil
+}
+
+func (r *StorageRepository) GetBucketEncryption(ctx context.Context, bucket string) (*domain.BucketEncryption, error) {
+	userID := appcontext.UserIDFromContext(ctx)
+	query := `
+		SELECT user_id, bucket, algorithm, created_at
+		FROM bucket_encryption
+		WHERE bucket = $1 AND user_id = $2
+	`
+	var cfg domain.BucketEncryption
+	err := r.db.QueryRow(ctx, query, bucket, userID).Scan(&cfg.UserID, &cfg.Bucket, &cfg.Algorithm, &cfg.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New(errors.NotFound, "bucket encryption is not configured")
+		}
+		return nil, errors.Wrap(errors.Internal, "failed to get bucket encryption", err)
+	}
+	return &cfg, nil
+}
+
+func (r *StorageRepository) DeleteBucketEncryption(ctx context.Context, bucket string) error {
+	userID := appcontext.UserIDFromContext(ctx)
+	cmd, err := r.db.Exec(ctx, `DELETE FROM bucket_encryption WHERE bucket = $1 AND user_id = $2`, bucket, userID)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to delete bucket encryption", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errors.New(errors.NotFound, "bucket encryption is not configured")
+	}
+	return nil
+}
+
+// PutBucketWebsite saves the website of a bucket. It fails with Conflict when
+// another user already hosts a website under the same bucket name.
+func (r *StorageRepository) PutBucketWebsite(ctx context.Context, cfg *domain.BucketWebsite) error {
+	query := `
+		INSERT INTO bucket_websites (bucket, user_id, index_document, error_document, cache_max_age, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (bucket) DO UPDATE SET
+			index_document = EXCLUDED.index_document,
+			error_document = EXCLUDED.error_document,
+			cache_max_age = EXCLUDED.cache_max_age,
+			created_at = EXCLUDED.created_at
+		WHERE bucket_websites.user_id = EXCLUDED.user_id
+	`
+	cmd, err := r.db.Exec(ctx, query, cfg.Bucket, cfg.UserID, cfg.IndexDocument, cfg.ErrorDocument, cfg.CacheMaxAge, cfg.CreatedAt)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to save bucket website", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errors.New(errors.Conflict, "a website is already hosted under this bucket name")
+	}
+	return nil
+}
+
+func (r *StorageRepository) GetBucketWebsite(ctx context.Context, bucket string) (*domain.BucketWebsite, error) {
+	userID := appcontext.UserIDFromContext(ctx)
+	query := `
+		SELECT bucket, user_id, index_document, error_document, cache_max_age, created_at
+		FROM bucket_websites
+		WHERE bucket = $1 AND user_id = $2
+	`
+	return r.scanBucketWebsite(r.db.QueryRow(ctx, query, bucket, userID))
+}
+
+func (r *StorageRepository) FindBucketWebsite(ctx context.Context, bucket string) (*domain.BucketWebsite, error) {
+	query := `
+		SELECT bucket, user_id, index_document, error_document, cache_max_age, created_at
+		FROM bucket_websites
+		WHERE bucket = $1
+	`
+	return r.scanBucketWebsite(r.db.QueryRow(ctx, query, bucket))
+}
+
+func (r *StorageRepository) scanBucketWebsite(row pgx.Row) (*domain.BucketWebsite, error) {
+	var cfg domain.BucketWebsite
+	err := row.Scan(&cfg.Bucket, &cfg.UserID, &cfg.IndexDocument, &cfg.ErrorDocument, &cfg.CacheMaxAge, &cfg.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New(errors.NotFound, "bucket website is not configured")
+		}
+		return nil, errors.Wrap(errors.Internal, "failed to get bucket website", err)
+	}
+	return &cfg, nil
+}
+
+func (r *StorageRepository) DeleteBucketWebsite(ctx context.Context, bucket string) error {
+	userID := appcontext.UserIDFromContext(ctx)
+	cmd, err := r.db.Exec(ctx, `DELETE FROM bucket_websites WHERE bucket = $1 AND user_id = $2`, bucket, userID)
+	if err != nil {
+		return errors.Wrap(errors.Internal, "failed to delete bucket website", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errors.New(errors.NotFound, "bucket website is not configured")
+	}
+	return nil
+}
+
+// metadataOrEmpty keeps NULL-free JSON in the metadata columns.
+func metadataOrEmpty(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
@@ -0,0 +1,79 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"* * * FOO *",
+	} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	// Friday
+	from := time.Date(2026, 1, 2, 7, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 1, 2, 7, 31, 0, 0, time.UTC)},
+		{"0 8 * * MON-FRI", time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * 1-5", time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC)},
+		{"30 7 * * mon-fri", time.Date(2026, 1, 5, 7, 30, 0, 0, time.UTC)},
+		{"0 22 * * *", time.Date(2026, 1, 2, 22, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 1, 2, 7, 45, 0, 0, time.UTC)},
+		{"0 9 * * 0", time.Date(2026, 1, 4, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2026, 1, 4, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 MAR *", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// With both day fields restricted either one matches.
+		{"0 12 15 * SAT", time.Date(2026, 1, 3, 12, 0, 0, 0, time.UTC)},
+		{"0,30 8-18/2 * * *", time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		s, err := Parse(c.expr)
+		require.NoError(t, err, c.expr)
+		assert.Equal(t, c.want, s.Next(from), c.expr)
+	}
+
+	s, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, s.Next(from).IsZero())
+}
+
+func TestSchedule_NextTimeZone(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	s, err := Parse("0 8 * * *")
+	require.NoError(t, err)
+
+	// 12:00 UTC is 07:00 in New York.
+	next := s.Next(time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC).In(loc))
+	assert.Equal(t, time.Date(2026, 1, 2, 13, 0, 0, 0, time.UTC), next.UTC())
+
+	// 02:30 does not exist on the day clocks spring forward.
+	s, err = Parse("30 2 * * *")
+	require.NoError(t, err)
+	next = s.Next(time.Date(2026, 3, 8, 0, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2026, 3, 9, 2, 30, 0, 0, loc), next)
+}
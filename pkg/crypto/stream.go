@@ -0,0 +1,213 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Streams are encrypted in fixed-size chunks so that neither side holds more
+// than one chunk in memory. Layout:
+//
+//	version (1 byte) | salt (32 bytes) | chunk 0 | chunk 1 | ... | final chunk
+//
+// Each chunk is sealed with AES-256-GCM under a key derived from the caller's
+// key and the random salt. The nonce encodes the chunk index and whether the
+// chunk is the last one, so reordering, dropping or truncating chunks fails
+// authentication. The final chunk may be empty.
+const (
+	streamVersion   = 1
+	streamSaltSize  = 32
+	streamChunkSize = 64 * 1024
+	streamTagSize   = 16
+	streamNonceSize = 12
+)
+
+var ErrStreamCorrupted = errors.New("encrypted stream is corrupted or the key is wrong")
+
+func newStreamAEAD(key, salt []byte) (cipher.AEAD, error) {
+	h := hkdf.New(sha256.New, key, salt, []byte("thecloud-stream"))
+	streamKey := make([]byte, 32)
+	if _, err := io.ReadFull(h, streamKey); err != nil {
+		return nil, fmt.Errorf("failed to derive stream key: %w", err)
+	}
+	block, err := aes.NewCipher(streamKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(index uint64, last bool) []byte {
+	nonce := make([]byte, streamNonceSize)
+	binary.BigEndian.PutUint64(nonce[4:], index)
+	if last {
+		nonce[0] = 1
+	}
+	return nonce
+}
+
+type encryptReader struct {
+	src     io.Reader
+	aead    cipher.AEAD
+	index   uint64
+	plain   []byte // holds one chunk plus one byte of look-ahead
+	have    int
+	out     []byte // sealed bytes not yet returned
+	pending []byte
+	done    bool
+	err     error
+}
+
+// NewEncryptReader returns a reader that yields the encryption of src under the
+// 32-byte key.
+func NewEncryptReader(src io.Reader, key []byte) (io.Reader, error) {
+	salt := make([]byte, streamSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	aead, err := newStreamAEAD(key, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append([]byte{streamVersion}, salt...)
+	return &encryptReader{
+		src:     src,
+		aead:    aead,
+		plain:   make([]byte, streamChunkSize+1),
+		out:     make([]byte, 0, streamChunkSize+streamTagSize),
+		pending: header,
+	}, nil
+}
+
+func (r *encryptReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		r.sealNext()
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// sealNext reads the next chunk of plaintext and seals it. A chunk is known to
+// be the last one when the look-ahead byte cannot be filled.
+func (r *encryptReader) sealNext() {
+	n, err := io.ReadFull(r.src, r.plain[r.have:])
+	r.have += n
+
+	switch err {
+	case nil:
+		r.pending = r.aead.Seal(r.out[:0], chunkNonce(r.index, false), r.plain[:streamChunkSize], nil)
+		r.plain[0] = r.plain[streamChunkSize]
+		r.have = 1
+		r.index++
+	case io.EOF, io.ErrUnexpectedEOF:
+		r.pending = r.aead.Seal(r.out[:0], chunkNonce(r.index, true), r.plain[:r.have], nil)
+		r.done = true
+	default:
+		r.err = err
+	}
+}
+
+type decryptReader struct {
+	src     io.Reader
+	key     []byte
+	aead    cipher.AEAD
+	index   uint64
+	sealed  []byte // holds one sealed chunk plus one byte of look-ahead
+	have    int
+	pending []byte
+	done    bool
+	err     error
+}
+
+// NewDecryptReader returns a reader that decrypts a stream produced by
+// NewEncryptReader with the same key. Reads fail with ErrStreamCorrupted if the
+// stream was modified or the key is wrong.
+func NewDecryptReader(src io.Reader, key []byte) io.Reader {
+	return &decryptReader{
+		src:    src,
+		key:    key,
+		sealed: make([]byte, streamChunkSize+streamTagSize+1),
+	}
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		if r.aead == nil {
+			r.err = r.readHeader()
+			continue
+		}
+		r.openNext()
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+func (r *decryptReader) readHeader() error {
+	header := make([]byte, 1+streamSaltSize)
+	if _, err := io.ReadFull(r.src, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrStreamCorrupted
+		}
+		return err
+	}
+	if header[0] != streamVersion {
+		return fmt.Errorf("unsupported encrypted stream version %d", header[0])
+	}
+	aead, err := newStreamAEAD(r.key, header[1:])
+	if err != nil {
+		return err
+	}
+	r.aead = aead
+	return nil
+}
+
+func (r *decryptReader) openNext() {
+	const sealedChunkSize = streamChunkSize + streamTagSize
+
+	n, err := io.ReadFull(r.src, r.sealed[r.have:])
+	r.have += n
+
+	var plain []byte
+	switch err {
+	case nil:
+		plain, err = r.aead.Open(nil, chunkNonce(r.index, false), r.sealed[:sealedChunkSize], nil)
+		r.sealed[0] = r.sealed[sealedChunkSize]
+		r.have = 1
+		r.index++
+	case io.EOF, io.ErrUnexpectedEOF:
+		plain, err = r.aead.Open(nil, chunkNonce(r.index, true), r.sealed[:r.have], nil)
+		r.done = true
+	default:
+		r.err = err
+		return
+	}
+
+	if err != nil {
+		r.err = ErrStreamCorrupted
+		return
+	}
+	r.pending = plain
+}
@@ -0,0 +1,65 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encryptAll(t *testing.T, plaintext, key []byte) []byte {
+	t.Helper()
+	r, err := NewEncryptReader(bytes.NewReader(plaintext), key)
+	require.NoError(t, err)
+	ciphertext, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return ciphertext
+}
+
+func TestStream_RoundTrip(t *testing.T) {
+	key := make([]byte, 32)
+	sizes := []int{0, 1, streamChunkSize - 1, streamChunkSize, streamChunkSize + 1, 3*streamChunkSize + 17}
+
+	for _, size := range sizes {
+		plaintext := make([]byte, size)
+		_, _ = rand.Read(plaintext)
+
+		ciphertext := encryptAll(t, plaintext, key)
+		if size > 0 {
+			assert.NotContains(t, string(ciphertext), string(plaintext[:min(size, 64)]))
+		}
+
+		decrypted, err := io.ReadAll(NewDecryptReader(bytes.NewReader(ciphertext), key))
+		require.NoError(t, err, "size %d", size)
+		assert.Equal(t, plaintext, decrypted, "size %d", size)
+	}
+}
+
+func TestStream_WrongKey(t *testing.T) {
+	key := make([]byte, 32)
+	ciphertext := encryptAll(t, []byte("secret"), key)
+
+	otherKey := make([]byte, 32)
+	otherKey[0] = 1
+	_, err := io.ReadAll(NewDecryptReader(bytes.NewReader(ciphertext), otherKey))
+	assert.ErrorIs(t, err, ErrStreamCorrupted)
+}
+
+func TestStream_DetectsTampering(t *testing.T) {
+	key := make([]byte, 32)
+	plaintext := make([]byte, 2*streamChunkSize+100)
+	ciphertext := encryptAll(t, plaintext, key)
+
+	flipped := bytes.Clone(ciphertext)
+	flipped[len(flipped)/2] ^= 0xFF
+	_, err := io.ReadAll(NewDecryptReader(bytes.NewReader(flipped), key))
+	assert.ErrorIs(t, err, ErrStreamCorrupted)
+
+	// Dropping the final chunk must not look like a shorter valid stream
+	truncated := ciphertext[:1+streamSaltSize+2*(streamChunkSize+streamTagSize)]
+	_, err = io.ReadAll(NewDecryptReader(bytes.NewReader(truncated), key))
+	assert.ErrorIs(t, err, ErrStreamCorrupted)
+}
@@ -1,6 +1,7 @@
 package httputil
 
 import (
+	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -27,10 +28,31 @@ func (p Permission) String() string {
 }
 
 func RequirePermission(resource, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := CheckPermission(c, resource, action); err != nil {
+			Error(c, err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// CheckPermission returns a Forbidden error unless the caller may perform the
+// action, for handlers whose action depends on the request body.
+func CheckPermission(c *gin.Context, resource, action string) error {
+	if !HasPermission(roleFromContext(c), Permission{Resource: resource, Action: action}) {
+		return errors.New(errors.Forbidden, "insufficient permissions")
+	}
+	return nil
+}
+
+// RequireS3Permission is RequirePermission for the S3-compatible API, which reports errors as XML.
+func RequireS3Permission(resource, action string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role := roleFromContext(c)
 		if !HasPermission(role, Permission{Resource: resource, Action: action}) {
-			Error(c, errors.New(errors.Forbidden, "insufficient permissions"))
+			S3Error(c, http.StatusForbidden, "AccessDenied", "insufficient permissions")
 			c.Abort()
 			return
 		}
@@ -94,6 +116,7 @@ func developerPermissions() map[string]bool {
 		"functions",
 		"caches",
 		"autoscaling",
+		"metrics",
 	} {
 		grantAllActions(perms, resource)
 	}
@@ -116,6 +139,7 @@ func viewerPermissions() map[string]bool {
 		"functions",
 		"caches",
 		"autoscaling",
+		"metrics",
 	} {
 		perms[resource+":"+ActionRead] = true
 	}
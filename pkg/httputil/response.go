@@ -67,6 +67,10 @@ func Error(c *gin.Context, err error) {
 		statusCode = http.StatusNotFound
 	case errors.ObjectTooLarge:
 		statusCode = http.StatusRequestEntityTooLarge
+	case errors.InvalidPart, errors.InvalidPartOrder:
+		statusCode = http.StatusBadRequest
+	case errors.InvalidRange:
+		statusCode = http.StatusRequestedRangeNotSatisfiable
 	case errors.InstanceNotRunning, errors.PortConflict, errors.TooManyPorts:
 		statusCode = http.StatusConflict
 	case errors.ResourceLimitExceeded:
@@ -0,0 +1,503 @@
+package httputil
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	stderrors "errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	appcontext "github.com/poyrazk/thecloud/internal/core/context"
+	"github.com/poyrazk/thecloud/internal/core/ports"
+)
+
+const (
+	sigV4Algorithm    = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat   = "20060102T150405Z"
+	sigV4MaxClockSkew = 15 * time.Minute
+	unsignedPayload   = "UNSIGNED-PAYLOAD"
+	// sigV4MaxExpires is the longest validity S3 accepts for a presigned URL.
+	sigV4MaxExpires = 7 * 24 * 60 * 60
+
+	streamingPayloadV4       = "STREAMING-"
+	streamingSignedPayload   = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	streamingSignedTrailer   = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	streamingUnsignedTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+	chunkSigningAlgorithm    = "AWS4-HMAC-SHA256-PAYLOAD"
+	emptyPayloadHash         = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	// maxSignedChunkSize bounds the buffer a signed chunk is verified in
+	// before any of it is passed on. SDKs send 64 KiB to 8 MiB chunks.
+	maxSignedChunkSize = 16 << 20
+)
+
+// ErrPayloadSignature reports a request body that does not match its signature.
+var ErrPayloadSignature = stderrors.New("payload does not match its signature")
+
+// ErrAuthorizationQueryParameters reports presigned query parameters that S3
+// rejects outright rather than failing the signature check.
+var ErrAuthorizationQueryParameters = stderrors.New("invalid authorization query parameters")
+
+// SigV4Request holds the parsed fields of an AWS Signature Version 4 request,
+// taken either from the Authorization header or from presigned query parameters.
+type SigV4Request struct {
+	AccessKeyID   string
+	Date          string // YYYYMMDD from the credential scope
+	Region        string
+	Service       string
+	SignedHeaders []string
+	Signature     string
+	AmzDate       string
+	PayloadHash   string
+	Expires       time.Duration // only set for presigned requests
+	Presigned     bool
+}
+
+// ParseSigV4 extracts SigV4 authentication data from a request.
+func ParseSigV4(r *http.Request) (*SigV4Request, error) {
+	q := r.URL.Query()
+	if q.Get("X-Amz-Algorithm") != "" {
+		return parseSigV4Query(r)
+	}
+
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, sigV4Algorithm+" ") {
+		return nil, fmt.Errorf("unsupported authorization scheme")
+	}
+
+	s := &SigV4Request{
+		AmzDate:     r.Header.Get("X-Amz-Date"),
+		PayloadHash: r.Header.Get("X-Amz-Content-Sha256"),
+	}
+	if s.PayloadHash == "" {
+		s.PayloadHash = unsignedPayload
+	}
+
+	for _, field := range strings.Split(strings.TrimPrefix(auth, sigV4Algorithm+" "), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "Credential":
+			if err := s.parseCredential(v); err != nil {
+				return nil, err
+			}
+		case "SignedHeaders":
+			s.SignedHeaders = strings.Split(v, ";")
+		case "Signature":
+			s.Signature = v
+		}
+	}
+
+	if s.AccessKeyID == "" || s.Signature == "" || len(s.SignedHeaders) == 0 || s.AmzDate == "" {
+		return nil, fmt.Errorf("malformed authorization header")
+	}
+	if !s.signsHost() {
+		return nil, fmt.Errorf("signed headers must include host")
+	}
+	return s, nil
+}
+
+func parseSigV4Query(r *http.Request) (*SigV4Request, error) {
+	q := r.URL.Query()
+	if q.Get("X-Amz-Algorithm") != sigV4Algorithm {
+		return nil, fmt.Errorf("unsupported signing algorithm")
+	}
+
+	expires, err := strconv.Atoi(q.Get("X-Amz-Expires"))
+	if err != nil || expires <= 0 {
+		return nil, fmt.Errorf("invalid X-Amz-Expires: %w", ErrAuthorizationQueryParameters)
+	}
+	if expires > sigV4MaxExpires {
+		return nil, fmt.Errorf("X-Amz-Expires must be at most %d seconds: %w", sigV4MaxExpires, ErrAuthorizationQueryParameters)
+	}
+
+	s := &SigV4Request{
+		SignedHeaders: strings.Split(q.Get("X-Amz-SignedHeaders"), ";"),
+		Signature:     q.Get("X-Amz-Signature"),
+		AmzDate:       q.Get("X-Amz-Date"),
+		PayloadHash:   unsignedPayload,
+		Expires:       time.Duration(expires) * time.Second,
+		Presigned:     true,
+	}
+	if err := s.parseCredential(q.Get("X-Amz-Credential")); err != nil {
+		return nil, err
+	}
+	if s.Signature == "" || s.AmzDate == "" {
+		return nil, fmt.Errorf("malformed presigned request")
+	}
+	if !s.signsHost() {
+		return nil, fmt.Errorf("X-Amz-SignedHeaders must include host: %w", ErrAuthorizationQueryParameters)
+	}
+	return s, nil
+}
+
+// signsHost reports whether the signature covers the Host header. Without it
+// a request signed for one endpoint could be replayed against another.
+func (s *SigV4Request) signsHost() bool {
+	for _, h := range s.SignedHeaders {
+		if h == "host" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SigV4Request) parseCredential(v string) error {
+	parts := strings.Split(v, "/")
+	if len(parts) != 5 || parts[4] != "aws4_request" {
+		return fmt.Errorf("malformed credential scope")
+	}
+	s.AccessKeyID, s.Date, s.Region, s.Service = parts[0], parts[1], parts[2], parts[3]
+	return nil
+}
+
+// Verify checks the request signature against the secret key and the request time window.
+func (s *SigV4Request) Verify(r *http.Request, secret string, now time.Time) error {
+	t, err := time.Parse(sigV4TimeFormat, s.AmzDate)
+	if err != nil {
+		return fmt.Errorf("invalid request date")
+	}
+	if !strings.HasPrefix(s.AmzDate, s.Date) {
+		return fmt.Errorf("credential date does not match request date")
+	}
+
+	if s.Presigned {
+		if now.Before(t.Add(-sigV4MaxClockSkew)) || now.After(t.Add(s.Expires)) {
+			return fmt.Errorf("request has expired")
+		}
+	} else if now.Sub(t) > sigV4MaxClockSkew || t.Sub(now) > sigV4MaxClockSkew {
+		return fmt.Errorf("request time too skewed")
+	}
+
+	expected := s.sign(r, secret)
+	if !hmac.Equal([]byte(expected), []byte(s.Signature)) {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
+
+func (s *SigV4Request) sign(r *http.Request, secret string) string {
+	canonical := s.canonicalRequest(r)
+	hashed := sha256.Sum256([]byte(canonical))
+	stringToSign := strings.Join([]string{sigV4Algorithm, s.AmzDate, s.scope(), hex.EncodeToString(hashed[:])}, "\n")
+	return hex.EncodeToString(hmacSHA256(s.signingKey(secret), stringToSign))
+}
+
+func (s *SigV4Request) scope() string {
+	return strings.Join([]string{s.Date, s.Region, s.Service, "aws4_request"}, "/")
+}
+
+func (s *SigV4Request) signingKey(secret string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), s.Date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// chunkSigner returns the verifier for the chunk signatures of a streaming
+// upload, chained from the seed signature of the request.
+func (s *SigV4Request) chunkSigner(secret string) *chunkSigner {
+	return &chunkSigner{key: s.signingKey(secret), amzDate: s.AmzDate, scope: s.scope(), prev: s.Signature}
+}
+
+func (s *SigV4Request) canonicalRequest(r *http.Request) string {
+	// Query string, sorted by key then value, excluding the signature itself
+	var pairs []string
+	for k, values := range r.URL.Query() {
+		if s.Presigned && k == "X-Amz-Signature" {
+			continue
+		}
+		for _, v := range values {
+			pairs = append(pairs, awsURIEncode(k, true)+"="+awsURIEncode(v, true))
+		}
+	}
+	sort.Strings(pairs)
+
+	var headers strings.Builder
+	for _, h := range s.SignedHeaders {
+		var value string
+		if h == "host" {
+			value = r.Host
+		} else {
+			value = strings.Join(r.Header.Values(h), ",")
+		}
+		headers.WriteString(h + ":" + strings.Join(strings.Fields(value), " ") + "\n")
+	}
+
+	return strings.Join([]string{
+		r.Method,
+		awsURIEncode(r.URL.Path, false),
+		strings.Join(pairs, "&"),
+		headers.String(),
+		strings.Join(s.SignedHeaders, ";"),
+		s.PayloadHash,
+	}, "\n")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsURIEncode percent-encodes everything except RFC 3986 unreserved characters,
+// optionally leaving '/' intact as required for S3 canonical paths.
+func awsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// S3Auth authenticates S3-compatible requests signed with SigV4. The access key ID
+// is the API key ID and the secret access key is the API key itself.
+func S3Auth(identitySvc ports.IdentityService, authSvc ports.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sig, err := ParseSigV4(c.Request)
+		if stderrors.Is(err, ErrAuthorizationQueryParameters) {
+			S3Error(c, http.StatusBadRequest, "AuthorizationQueryParametersError", err.Error())
+			c.Abort()
+			return
+		}
+		if err != nil {
+			S3Error(c, http.StatusForbidden, "AccessDenied", err.Error())
+			c.Abort()
+			return
+		}
+
+		keyID, err := uuid.Parse(sig.AccessKeyID)
+		if err != nil {
+			S3Error(c, http.StatusForbidden, "InvalidAccessKeyId", "the access key ID does not exist")
+			c.Abort()
+			return
+		}
+
+		apiKey, err := identitySvc.GetApiKeyByID(c.Request.Context(), keyID)
+		if err != nil {
+			S3Error(c, http.StatusForbidden, "InvalidAccessKeyId", "the access key ID does not exist")
+			c.Abort()
+			return
+		}
+
+		if err := sig.Verify(c.Request, apiKey.Key, time.Now().UTC()); err != nil {
+			S3Error(c, http.StatusForbidden, "SignatureDoesNotMatch", err.Error())
+			c.Abort()
+			return
+		}
+
+		user, err := authSvc.ValidateUser(c.Request.Context(), apiKey.UserID)
+		if err != nil {
+			S3Error(c, http.StatusForbidden, "AccessDenied", "invalid user")
+			c.Abort()
+			return
+		}
+
+		// The signature covers the declared payload hash, so the body must match it.
+		switch {
+		case sig.PayloadHash == streamingSignedPayload, sig.PayloadHash == streamingSignedTrailer:
+			// aws-chunked framing; every chunk is signed, chained from the seed signature
+			c.Request.Body = io.NopCloser(newSignedChunkedReader(c.Request.Body, sig.chunkSigner(apiKey.Key)))
+		case sig.PayloadHash == streamingUnsignedTrailer:
+			c.Request.Body = io.NopCloser(newChunkedReader(c.Request.Body))
+		case strings.HasPrefix(sig.PayloadHash, streamingPayloadV4):
+			S3Error(c, http.StatusNotImplemented, "NotImplemented", "unsupported streaming payload "+sig.PayloadHash)
+			c.Abort()
+			return
+		case sig.PayloadHash != unsignedPayload && c.Request.Body != nil:
+			c.Request.Body = &hashVerifyingReader{r: c.Request.Body, h: sha256.New(), expected: sig.PayloadHash}
+		}
+
+		ctx := appcontext.WithUserID(c.Request.Context(), apiKey.UserID)
+		ctx = appcontext.WithUserRole(ctx, user.Role)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Set("userID", apiKey.UserID)
+		c.Set("userRole", user.Role)
+		c.Next()
+	}
+}
+
+// S3ErrorResponse is the XML error body returned by the S3-compatible API.
+type S3ErrorResponse struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	Resource  string   `xml:"Resource,omitempty"`
+	RequestID string   `xml:"RequestId,omitempty"`
+}
+
+// S3Error writes an S3-style XML error.
+func S3Error(c *gin.Context, status int, code, message string) {
+	requestID, _ := c.Get("requestID")
+	reqIDStr, _ := requestID.(string)
+
+	c.XML(status, S3ErrorResponse{
+		Code:      code,
+		Message:   message,
+		Resource:  c.Request.URL.Path,
+		RequestID: reqIDStr,
+	})
+}
+
+// hashVerifyingReader fails the read at EOF if the body does not match the signed SHA-256.
+type hashVerifyingReader struct {
+	r        io.ReadCloser
+	h        hash.Hash
+	expected string
+}
+
+func (v *hashVerifyingReader) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.h.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(v.h.Sum(nil)) != v.expected {
+		return n, fmt.Errorf("x-amz-content-sha256: %w", ErrPayloadSignature)
+	}
+	return n, err
+}
+
+func (v *hashVerifyingReader) Close() error {
+	return v.r.Close()
+}
+
+// chunkedReader decodes an aws-chunked body: "<hex-size>[;chunk-signature=...]\r\n<data>\r\n"
+// repeated until a zero-size chunk, optionally followed by trailing headers.
+// With a signer, each chunk is buffered and its signature verified before any
+// of its data is returned; a body that ends before the zero-size chunk fails.
+type chunkedReader struct {
+	r         *bufio.Reader
+	signer    *chunkSigner
+	chunk     []byte // verified data of the current signed chunk
+	buf       []byte
+	remaining int64
+	done      bool
+}
+
+func newChunkedReader(r io.Reader) *chunkedReader {
+	return &chunkedReader{r: bufio.NewReader(r)}
+}
+
+func newSignedChunkedReader(r io.Reader, signer *chunkSigner) *chunkedReader {
+	return &chunkedReader{r: bufio.NewReader(r), signer: signer}
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	if len(c.chunk) == 0 && c.remaining == 0 && !c.done {
+		if err := c.nextChunk(); err != nil {
+			return 0, err
+		}
+	}
+	if len(c.chunk) > 0 {
+		n := copy(p, c.chunk)
+		c.chunk = c.chunk[n:]
+		return n, nil
+	}
+	if c.done {
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+	if c.remaining == 0 && err == nil {
+		// Consume the CRLF that terminates the chunk data
+		if _, err := c.r.Discard(2); err != nil {
+			return n, err
+		}
+	}
+	if err == io.EOF && c.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// nextChunk reads a chunk header. Unsigned chunk data is then streamed by Read;
+// signed chunk data is read and verified here.
+func (c *chunkedReader) nextChunk() error {
+	line, err := c.r.ReadString('\n')
+	if err == io.EOF {
+		return fmt.Errorf("aws-chunked body ended before the final chunk: %w", io.ErrUnexpectedEOF)
+	}
+	if err != nil {
+		return err
+	}
+	sizeStr, ext, _ := strings.Cut(strings.TrimSpace(line), ";")
+	size, err := strconv.ParseInt(sizeStr, 16, 64)
+	if err != nil || size < 0 {
+		return fmt.Errorf("invalid aws-chunked chunk size")
+	}
+
+	if c.signer == nil {
+		c.remaining = size
+		c.done = size == 0
+		return nil
+	}
+
+	signature, ok := strings.CutPrefix(ext, "chunk-signature=")
+	if !ok {
+		return fmt.Errorf("missing chunk signature: %w", ErrPayloadSignature)
+	}
+	if size > maxSignedChunkSize {
+		return fmt.Errorf("aws-chunked chunk exceeds %d bytes", maxSignedChunkSize)
+	}
+	if int64(cap(c.buf)) < size {
+		c.buf = make([]byte, size)
+	}
+	data := c.buf[:size]
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		return io.ErrUnexpectedEOF
+	}
+	if size > 0 {
+		if _, err := c.r.Discard(2); err != nil {
+			return io.ErrUnexpectedEOF
+		}
+	}
+	if err := c.signer.verify(data, signature); err != nil {
+		return err
+	}
+	c.chunk = data
+	c.done = size == 0
+	return nil
+}
+
+// chunkSigner verifies the chain of chunk signatures of a streaming upload:
+// each chunk signs its data together with the previous chunk's signature.
+type chunkSigner struct {
+	key     []byte
+	amzDate string
+	scope   string
+	prev    string
+}
+
+func (s *chunkSigner) verify(data []byte, signature string) error {
+	hashed := sha256.Sum256(data)
+	stringToSign := strings.Join([]string{chunkSigningAlgorithm, s.amzDate, s.scope, s.prev, emptyPayloadHash, hex.EncodeToString(hashed[:])}, "\n")
+	expected := hex.EncodeToString(hmacSHA256(s.key, stringToSign))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("chunk signature: %w", ErrPayloadSignature)
+	}
+	s.prev = signature
+	return nil
+}
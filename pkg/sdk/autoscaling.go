@@ -6,31 +6,50 @@ import (
 )
 
 type ScalingGroup struct {
-	ID             string    `json:"id"`
-	Name           string    `json:"name"`
-	VpcID          string    `json:"vpc_id"`
-	LoadBalancerID string    `json:"load_balancer_id,omitempty"`
-	Image          string    `json:"image"`
-	Ports          string    `json:"ports,omitempty"`
-	MinInstances   int       `json:"min_instances"`
-	MaxInstances   int       `json:"max_instances"`
-	DesiredCount   int       `json:"desired_count"`
-	CurrentCount   int       `json:"current_count"`
-	Status         string    `json:"status"`
-	CreatedAt      time.Time `json:"created_at"`
+	ID                        string    `json:"id"`
+	Name                      string    `json:"name"`
+	VpcID                     string    `json:"vpc_id"`
+	LoadBalancerID            string    `json:"load_balancer_id,omitempty"`
+	Image                     string    `json:"image"`
+	Ports                     string    `json:"ports,omitempty"`
+	LaunchTemplateID          string    `json:"launch_template_id,omitempty"`
+	LaunchTemplateVersion     int       `json:"launch_template_version,omitempty"`
+	MinInstances              int       `json:"min_instances"`
+	MaxInstances              int       `json:"max_instances"`
+	DesiredCount              int       `json:"desired_count"`
+	CurrentCount              int       `json:"current_count"`
+	Status                    string    `json:"status"`
+	HealthCheckType           string    `json:"health_check_type"`
+	HealthCheckGracePeriodSec int       `json:"health_check_grace_period_sec"`
+	SuspendedProcesses        []string  `json:"suspended_processes,omitempty"`
+	CreatedAt                 time.Time `json:"created_at"`
 }
 
 type CreateScalingGroupRequest struct {
 	Name           string  `json:"name"`
-	VpcID          string  `json:"vpc_id"`
+	VpcID          string  `json:"vpc_id,omitempty"`
 	LoadBalancerID *string `json:"load_balancer_id,omitempty"`
-	Image          string  `json:"image"`
-	Ports          string  `json:"ports"`
-	MinInstances   int     `json:"min_instances"`
-	MaxInstances   int     `json:"max_instances"`
-	DesiredCount   int     `json:"desired_count"`
+	Image          string  `json:"image,omitempty"`
+	Ports          string  `json:"ports,omitempty"`
+	// LaunchTemplateID replaces Image and Ports; the VPC defaults to the
+	// template's. LaunchTemplateVersion 0 is the latest version.
+	LaunchTemplateID      string `json:"launch_template_id,omitempty"`
+	LaunchTemplateVersion int    `json:"launch_template_version,omitempty"`
+	MinInstances          int    `json:"min_instances"`
+	MaxInstances          int    `json:"max_instances"`
+	DesiredCount          int    `json:"desired_count"`
+	// HealthCheckType is ScalingHealthCheckContainer (default) or
+	// ScalingHealthCheckLB.
+	HealthCheckType           string `json:"health_check_type,omitempty"`
+	HealthCheckGracePeriodSec *int   `json:"health_check_grace_period_sec,omitempty"`
 }
 
+// Health check types of scaling groups.
+const (
+	ScalingHealthCheckContainer = "container"
+	ScalingHealthCheckLB        = "lb"
+)
+
 func (c *Client) CreateScalingGroup(req CreateScalingGroupRequest) (*ScalingGroup, error) {
 	var respData Response[ScalingGroup]
 	resp, err := c.resty.R().
@@ -88,13 +107,26 @@ func (c *Client) DeleteScalingGroup(id string) error {
 	return nil
 }
 
+// ScalingStep is one band of a step scaling policy. A nil bound leaves the
+// band open on that side.
+type ScalingStep struct {
+	LowerBound *float64 `json:"lower_bound,omitempty"`
+	UpperBound *float64 `json:"upper_bound,omitempty"`
+	Adjustment int      `json:"adjustment"`
+}
+
 type CreatePolicyRequest struct {
-	Name        string  `json:"name"`
-	MetricType  string  `json:"metric_type"`
-	TargetValue float64 `json:"target_value"`
-	ScaleOut    int     `json:"scale_out_step"`
-	ScaleIn     int     `json:"scale_in_step"`
-	CooldownSec int     `json:"cooldown_sec"`
+	Name                string        `json:"name"`
+	PolicyType          string        `json:"policy_type,omitempty"`
+	MetricType          string        `json:"metric_type"`
+	MetricName          string        `json:"metric_name,omitempty"`
+	TargetValue         float64       `json:"target_value,omitempty"`
+	ScaleInThreshold    float64       `json:"scale_in_threshold,omitempty"`
+	Steps               []ScalingStep `json:"steps,omitempty"`
+	EvaluationPeriodSec int           `json:"evaluation_period_sec,omitempty"`
+	CooldownSec         int           `json:"cooldown_sec,omitempty"`
+	ScaleOutCooldownSec int           `json:"scale_out_cooldown_sec,omitempty"`
+	ScaleInCooldownSec  int           `json:"scale_in_cooldown_sec,omitempty"`
 }
 
 func (c *Client) CreateScalingPolicy(groupID string, req CreatePolicyRequest) error {
@@ -121,3 +153,284 @@ func (c *Client) DeleteScalingPolicy(id string) error {
 	}
 	return nil
 }
+
+// ScheduledAction resizes a scaling group on a cron schedule. Unset sizes
+// are left as they are.
+type ScheduledAction struct {
+	ID             string     `json:"id"`
+	ScalingGroupID string     `json:"scaling_group_id"`
+	Name           string     `json:"name"`
+	Schedule       string     `json:"schedule"`
+	TimeZone       string     `json:"time_zone"`
+	MinInstances   *int       `json:"min_instances,omitempty"`
+	MaxInstances   *int       `json:"max_instances,omitempty"`
+	DesiredCount   *int       `json:"desired_count,omitempty"`
+	NextRunAt      time.Time  `json:"next_run_at"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+}
+
+type CreateScheduledActionRequest struct {
+	Name         string `json:"name"`
+	Schedule     string `json:"schedule"`
+	TimeZone     string `json:"time_zone,omitempty"`
+	MinInstances *int   `json:"min_instances,omitempty"`
+	MaxInstances *int   `json:"max_instances,omitempty"`
+	DesiredCount *int   `json:"desired_count,omitempty"`
+}
+
+func (c *Client) CreateScheduledAction(groupID string, req CreateScheduledActionRequest) (*ScheduledAction, error) {
+	var respData Response[ScheduledAction]
+	resp, err := c.resty.R().
+		SetBody(req).
+		SetResult(&respData).
+		Post(fmt.Sprintf("%s/autoscaling/groups/%s/schedules", c.apiURL, groupID))
+
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("api error: %s", resp.String())
+	}
+	return &respData.Data, nil
+}
+
+func (c *Client) ListScheduledActions(groupID string) ([]ScheduledAction, error) {
+	var respData Response[[]ScheduledAction]
+	resp, err := c.resty.R().
+		SetResult(&respData).
+		Get(fmt.Sprintf("%s/autoscaling/groups/%s/schedules", c.apiURL, groupID))
+
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("api error: %s", resp.String())
+	}
+	return respData.Data, nil
+}
+
+func (c *Client) DeleteScheduledAction(id string) error {
+	resp, err := c.resty.R().Delete(c.apiURL + "/autoscaling/schedules/" + id)
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("api error: %s", resp.String())
+	}
+	return nil
+}
+
+// UpdateScalingGroupRequest changes the fields it sets. An empty
+// LoadBalancerID detaches the load balancer.
+type UpdateScalingGroupRequest struct {
+	Name                      *string `json:"name,omitempty"`
+	Image                     *string `json:"image,omitempty"`
+	MinInstances              *int    `json:"min_instances,omitempty"`
+	MaxInstances              *int    `json:"max_instances,omitempty"`
+	DesiredCount              *int    `json:"desired_count,omitempty"`
+	LoadBalancerID            *string `json:"load_balancer_id,omitempty"`
+	HealthCheckType           *string `json:"health_check_type,omitempty"`
+	HealthCheckGracePeriodSec *int    `json:"health_check_grace_period_sec,omitempty"`
+}
+
+func (c *Client) UpdateScalingGroup(id string, req UpdateScalingGroupRequest) (*ScalingGroup, error) {
+	var res Response[ScalingGroup]
+	if err := c.patch(fmt.Sprintf("/autoscaling/groups/%s", id), req, &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+// Scaling processes that can be suspended.
+const (
+	ScalingProcessLaunch           = "LAUNCH"
+	ScalingProcessTerminate        = "TERMINATE"
+	ScalingProcessReplaceUnhealthy = "REPLACE_UNHEALTHY"
+	ScalingProcessScheduledActions = "SCHEDULED_ACTIONS"
+)
+
+// SuspendScalingProcesses pauses worker processes of a group, all of them
+// when none are given.
+func (c *Client) SuspendScalingProcesses(groupID string, processes ...string) (*ScalingGroup, error) {
+	return c.scalingProcessesAction(groupID, "suspend", processes)
+}
+
+// ResumeScalingProcesses resumes suspended processes of a group, all of them
+// when none are given.
+func (c *Client) ResumeScalingProcesses(groupID string, processes ...string) (*ScalingGroup, error) {
+	return c.scalingProcessesAction(groupID, "resume", processes)
+}
+
+func (c *Client) scalingProcessesAction(groupID, action string, processes []string) (*ScalingGroup, error) {
+	body := map[string][]string{"processes": processes}
+	var res Response[ScalingGroup]
+	if err := c.post(fmt.Sprintf("/autoscaling/groups/%s/%s", groupID, action), body, &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+// SetScalingGroupLaunchTemplate moves a group to a launch template version,
+// the latest for 0. Running instances keep their version.
+func (c *Client) SetScalingGroupLaunchTemplate(groupID, templateID string, version int) (*ScalingGroup, error) {
+	body := map[string]interface{}{
+		"launch_template_id": templateID,
+		"version":            version,
+	}
+	var res Response[ScalingGroup]
+	if err := c.put(fmt.Sprintf("/autoscaling/groups/%s/launch-template", groupID), body, &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+// LaunchConfig is the launch template version or image of a scaling group.
+type LaunchConfig struct {
+	LaunchTemplateID      string `json:"launch_template_id,omitempty"`
+	LaunchTemplateVersion int    `json:"launch_template_version,omitempty"`
+	Image                 string `json:"image"`
+	Ports                 string `json:"ports,omitempty"`
+}
+
+// InstanceRefresh replaces the instances of a scaling group in batches.
+type InstanceRefresh struct {
+	ID                    string        `json:"id"`
+	ScalingGroupID        string        `json:"scaling_group_id"`
+	Status                string        `json:"status"`
+	StatusReason          string        `json:"status_reason,omitempty"`
+	MinHealthyPercentage  int           `json:"min_healthy_percentage"`
+	HealthCheckTimeoutSec int           `json:"health_check_timeout_sec"`
+	InstancesToReplace    int           `json:"instances_to_replace"`
+	InstancesReplaced     int           `json:"instances_replaced"`
+	PendingInstanceIDs    []string      `json:"pending_instance_ids"`
+	BatchStartedAt        *time.Time    `json:"batch_started_at,omitempty"`
+	RollbackConfig        *LaunchConfig `json:"rollback_config,omitempty"`
+	StartedAt             time.Time     `json:"started_at"`
+	UpdatedAt             time.Time     `json:"updated_at"`
+	EndedAt               *time.Time    `json:"ended_at,omitempty"`
+}
+
+// StartInstanceRefreshRequest moves the group to a launch template version
+// or image before replacing its instances. Leave them unset to replace the
+// instances with the current configuration.
+type StartInstanceRefreshRequest struct {
+	MinHealthyPercentage  *int   `json:"min_healthy_percentage,omitempty"`
+	HealthCheckTimeoutSec int    `json:"health_check_timeout_sec,omitempty"`
+	LaunchTemplateID      string `json:"launch_template_id,omitempty"`
+	LaunchTemplateVersion int    `json:"launch_template_version,omitempty"`
+	Image                 string `json:"image,omitempty"`
+}
+
+func (c *Client) StartInstanceRefresh(groupID string, req StartInstanceRefreshRequest) (*InstanceRefresh, error) {
+	var res Response[InstanceRefresh]
+	if err := c.post(fmt.Sprintf("/autoscaling/groups/%s/refresh", groupID), req, &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+// GetInstanceRefresh fetches the latest instance refresh of the group.
+func (c *Client) GetInstanceRefresh(groupID string) (*InstanceRefresh, error) {
+	var res Response[InstanceRefresh]
+	if err := c.get(fmt.Sprintf("/autoscaling/groups/%s/refresh", groupID), &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+func (c *Client) PauseInstanceRefresh(groupID string) (*InstanceRefresh, error) {
+	return c.instanceRefreshAction(groupID, "pause")
+}
+
+func (c *Client) ResumeInstanceRefresh(groupID string) (*InstanceRefresh, error) {
+	return c.instanceRefreshAction(groupID, "resume")
+}
+
+func (c *Client) CancelInstanceRefresh(groupID string) (*InstanceRefresh, error) {
+	return c.instanceRefreshAction(groupID, "cancel")
+}
+
+func (c *Client) instanceRefreshAction(groupID, action string) (*InstanceRefresh, error) {
+	var res Response[InstanceRefresh]
+	if err := c.post(fmt.Sprintf("/autoscaling/groups/%s/refresh/%s", groupID, action), nil, &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+// LifecycleHook holds instances of a group in Pending:Wait or
+// Terminating:Wait until the lifecycle action is completed or times out.
+type LifecycleHook struct {
+	ID                  string    `json:"id"`
+	ScalingGroupID      string    `json:"scaling_group_id"`
+	Name                string    `json:"name"`
+	Transition          string    `json:"transition"`
+	HeartbeatTimeoutSec int       `json:"heartbeat_timeout_sec"`
+	DefaultResult       string    `json:"default_result"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+type CreateLifecycleHookRequest struct {
+	Name                string `json:"name"`
+	Transition          string `json:"transition"`
+	HeartbeatTimeoutSec int    `json:"heartbeat_timeout_sec,omitempty"`
+	DefaultResult       string `json:"default_result,omitempty"`
+}
+
+func (c *Client) CreateLifecycleHook(groupID string, req CreateLifecycleHookRequest) (*LifecycleHook, error) {
+	var res Response[LifecycleHook]
+	if err := c.post(fmt.Sprintf("/autoscaling/groups/%s/lifecycle-hooks", groupID), req, &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+func (c *Client) ListLifecycleHooks(groupID string) ([]LifecycleHook, error) {
+	var res Response[[]LifecycleHook]
+	if err := c.get(fmt.Sprintf("/autoscaling/groups/%s/lifecycle-hooks", groupID), &res); err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
+func (c *Client) DeleteLifecycleHook(id string) error {
+	return c.delete(fmt.Sprintf("/autoscaling/lifecycle-hooks/%s", id), nil)
+}
+
+// CompleteLifecycleAction releases an instance waiting on a lifecycle hook
+// with CONTINUE or ABANDON.
+func (c *Client) CompleteLifecycleAction(groupID, instanceID, result string) error {
+	body := map[string]string{
+		"instance_id": instanceID,
+		"result":      result,
+	}
+	return c.post(fmt.Sprintf("/autoscaling/groups/%s/lifecycle-actions", groupID), body, nil)
+}
+
+// ScalingActivity is a change to the capacity of a scaling group.
+type ScalingActivity struct {
+	ID             string    `json:"id"`
+	ScalingGroupID string    `json:"scaling_group_id"`
+	Description    string    `json:"description"`
+	Cause          string    `json:"cause"`
+	FromCapacity   int       `json:"from_capacity"`
+	ToCapacity     int       `json:"to_capacity"`
+	Status         string    `json:"status"`
+	StatusMessage  string    `json:"status_message,omitempty"`
+	StartedAt      time.Time `json:"started_at"`
+	EndedAt        time.Time `json:"ended_at"`
+}
+
+// ListScalingActivities fetches the latest activities of the group, newest
+// first; limit 0 uses the server default.
+func (c *Client) ListScalingActivities(groupID string, limit int) ([]ScalingActivity, error) {
+	path := fmt.Sprintf("/autoscaling/groups/%s/activities", groupID)
+	if limit > 0 {
+		path += fmt.Sprintf("?limit=%d", limit)
+	}
+	var res Response[[]ScalingActivity]
+	if err := c.get(path, &res); err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
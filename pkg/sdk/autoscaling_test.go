@@ -34,6 +34,118 @@ func TestClient_AutoScaling(t *testing.T) {
 			return
 		}
 
+		if r.Method == "POST" && r.URL.Path == "/autoscaling/groups/asg-1/schedules" {
+			var req CreateScheduledActionRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(Response[ScheduledAction]{
+				Data: ScheduledAction{ID: "sa-1", Name: req.Name, Schedule: req.Schedule, TimeZone: req.TimeZone, DesiredCount: req.DesiredCount},
+			})
+			return
+		}
+
+		if r.Method == "GET" && r.URL.Path == "/autoscaling/groups/asg-1/schedules" {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(Response[[]ScheduledAction]{
+				Data: []ScheduledAction{{ID: "sa-1", Name: "morning"}},
+			})
+			return
+		}
+
+		if r.Method == "DELETE" && r.URL.Path == "/autoscaling/schedules/sa-1" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if r.Method == "POST" && r.URL.Path == "/autoscaling/groups/asg-1/refresh" {
+			var req StartInstanceRefreshRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(Response[InstanceRefresh]{
+				Data: InstanceRefresh{ID: "ir-1", Status: "IN_PROGRESS", MinHealthyPercentage: *req.MinHealthyPercentage, InstancesToReplace: 4},
+			})
+			return
+		}
+
+		if r.URL.Path == "/autoscaling/groups/asg-1/refresh" || r.URL.Path == "/autoscaling/groups/asg-1/refresh/pause" {
+			status := "IN_PROGRESS"
+			if r.Method == "POST" {
+				status = "PAUSED"
+			}
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(Response[InstanceRefresh]{
+				Data: InstanceRefresh{ID: "ir-1", Status: status, InstancesToReplace: 4, InstancesReplaced: 2},
+			})
+			return
+		}
+
+		if r.Method == "PATCH" && r.URL.Path == "/autoscaling/groups/asg-1" {
+			var req map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if _, ok := req["image"]; ok || req["load_balancer_id"] != "" || req["max_instances"] != float64(8) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			grace, _ := req["health_check_grace_period_sec"].(float64)
+			json.NewEncoder(w).Encode(Response[ScalingGroup]{Data: ScalingGroup{ID: "asg-1", MaxInstances: 8, HealthCheckGracePeriodSec: int(grace)}})
+			return
+		}
+
+		if r.Method == "POST" && r.URL.Path == "/autoscaling/groups/asg-1/suspend" {
+			var req struct {
+				Processes []string `json:"processes"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(Response[ScalingGroup]{Data: ScalingGroup{ID: "asg-1", SuspendedProcesses: req.Processes}})
+			return
+		}
+
+		if r.Method == "POST" && r.URL.Path == "/autoscaling/groups/asg-1/lifecycle-hooks" {
+			var req CreateLifecycleHookRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(Response[LifecycleHook]{
+				Data: LifecycleHook{ID: "lh-1", Name: req.Name, Transition: req.Transition, HeartbeatTimeoutSec: 3600, DefaultResult: "ABANDON"},
+			})
+			return
+		}
+
+		if r.Method == "POST" && r.URL.Path == "/autoscaling/groups/asg-1/lifecycle-actions" {
+			var body map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["instance_id"] != "inst-1" || body["result"] != "CONTINUE" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if r.Method == "GET" && r.URL.Path == "/autoscaling/groups/asg-1/activities" {
+			if r.URL.Query().Get("limit") != "10" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(Response[[]ScalingActivity]{
+				Data: []ScalingActivity{{ID: "act-1", Cause: "policy cpu", FromCapacity: 2, ToCapacity: 4, Status: "SUCCESSFUL"}},
+			})
+			return
+		}
+
+		if r.Method == "PUT" && r.URL.Path == "/metrics/custom" {
+			var body struct {
+				Metrics []CustomMetric `json:"metrics"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Metrics) != 1 || body.Metrics[0].Name != "queue_depth" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
 		w.WriteHeader(http.StatusNotFound)
 	}))
 	defer server.Close()
@@ -68,4 +180,72 @@ func TestClient_AutoScaling(t *testing.T) {
 		err := client.CreateScalingPolicy("asg-1", req)
 		assert.NoError(t, err)
 	})
+
+	t.Run("ScheduledActions", func(t *testing.T) {
+		desired := 6
+		action, err := client.CreateScheduledAction("asg-1", CreateScheduledActionRequest{
+			Name: "morning", Schedule: "0 8 * * MON-FRI", TimeZone: "Europe/Istanbul", DesiredCount: &desired,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "sa-1", action.ID)
+		assert.Equal(t, "Europe/Istanbul", action.TimeZone)
+		assert.Equal(t, 6, *action.DesiredCount)
+
+		actions, err := client.ListScheduledActions("asg-1")
+		assert.NoError(t, err)
+		assert.Len(t, actions, 1)
+
+		assert.NoError(t, client.DeleteScheduledAction("sa-1"))
+	})
+
+	t.Run("InstanceRefresh", func(t *testing.T) {
+		minHealthy := 75
+		refresh, err := client.StartInstanceRefresh("asg-1", StartInstanceRefreshRequest{MinHealthyPercentage: &minHealthy, Image: "nginx:1.27"})
+		assert.NoError(t, err)
+		assert.Equal(t, "ir-1", refresh.ID)
+		assert.Equal(t, 75, refresh.MinHealthyPercentage)
+
+		refresh, err = client.GetInstanceRefresh("asg-1")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, refresh.InstancesReplaced)
+
+		refresh, err = client.PauseInstanceRefresh("asg-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "PAUSED", refresh.Status)
+	})
+
+	t.Run("UpdateScalingGroup", func(t *testing.T) {
+		max, detach, grace := 8, "", 60
+		group, err := client.UpdateScalingGroup("asg-1", UpdateScalingGroupRequest{MaxInstances: &max, LoadBalancerID: &detach, HealthCheckGracePeriodSec: &grace})
+		assert.NoError(t, err)
+		assert.Equal(t, 8, group.MaxInstances)
+		assert.Equal(t, 60, group.HealthCheckGracePeriodSec)
+	})
+
+	t.Run("SuspendScalingProcesses", func(t *testing.T) {
+		group, err := client.SuspendScalingProcesses("asg-1", ScalingProcessLaunch)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{ScalingProcessLaunch}, group.SuspendedProcesses)
+	})
+
+	t.Run("LifecycleHooks", func(t *testing.T) {
+		hook, err := client.CreateLifecycleHook("asg-1", CreateLifecycleHookRequest{Name: "warm-cache", Transition: "LAUNCHING"})
+		assert.NoError(t, err)
+		assert.Equal(t, "lh-1", hook.ID)
+		assert.Equal(t, "LAUNCHING", hook.Transition)
+
+		assert.NoError(t, client.CompleteLifecycleAction("asg-1", "inst-1", "CONTINUE"))
+	})
+
+	t.Run("ListScalingActivities", func(t *testing.T) {
+		activities, err := client.ListScalingActivities("asg-1", 10)
+		assert.NoError(t, err)
+		assert.Len(t, activities, 1)
+		assert.Equal(t, 4, activities[0].ToCapacity)
+	})
+
+	t.Run("PutCustomMetrics", func(t *testing.T) {
+		err := client.PutCustomMetrics([]CustomMetric{{Name: "queue_depth", Value: 12}})
+		assert.NoError(t, err)
+	})
 }
@@ -6,16 +6,19 @@ import (
 )
 
 type Instance struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Image       string    `json:"image"`
-	Status      string    `json:"status"`
-	Ports       string    `json:"ports"`
-	VpcID       string    `json:"vpc_id,omitempty"`
-	ContainerID string    `json:"container_id"`
-	Version     int       `json:"version"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                    string    `json:"id"`
+	Name                  string    `json:"name"`
+	Image                 string    `json:"image"`
+	Status                string    `json:"status"`
+	Ports                 string    `json:"ports"`
+	VpcID                 string    `json:"vpc_id,omitempty"`
+	ContainerID           string    `json:"container_id"`
+	LaunchTemplateID      string    `json:"launch_template_id,omitempty"`
+	LaunchTemplateVersion int       `json:"launch_template_version,omitempty"`
+	InstanceType          string    `json:"instance_type,omitempty"`
+	Version               int       `json:"version"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
 }
 
 func (c *Client) ListInstances() ([]Instance, error) {
@@ -54,6 +57,21 @@ func (c *Client) LaunchInstance(name, image, ports string, vpcID string, volumes
 	return &res.Data, nil
 }
 
+// LaunchInstanceFromTemplate launches an instance from a launch template
+// version, the latest for 0.
+func (c *Client) LaunchInstanceFromTemplate(name, templateID string, version int) (*Instance, error) {
+	body := map[string]interface{}{
+		"name":                    name,
+		"launch_template_id":      templateID,
+		"launch_template_version": version,
+	}
+	var res Response[Instance]
+	if err := c.post("/instances", body, &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
 func (c *Client) StopInstance(idOrName string) error {
 	return c.post(fmt.Sprintf("/instances/%s/stop", idOrName), nil, nil)
 }
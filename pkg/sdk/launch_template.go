@@ -0,0 +1,103 @@
+package sdk
+
+import (
+	"fmt"
+	"time"
+)
+
+type LaunchTemplate struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description,omitempty"`
+	LatestVersion int       `json:"latest_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type LaunchTemplateVersion struct {
+	TemplateID   string                  `json:"template_id"`
+	Version      int                     `json:"version"`
+	Image        string                  `json:"image"`
+	Ports        string                  `json:"ports,omitempty"`
+	InstanceType string                  `json:"instance_type,omitempty"`
+	Env          map[string]string       `json:"env,omitempty"`
+	UserData     string                  `json:"user_data,omitempty"`
+	Volumes      []VolumeAttachmentInput `json:"volumes,omitempty"`
+	VpcID        string                  `json:"vpc_id,omitempty"`
+	CreatedAt    time.Time               `json:"created_at"`
+}
+
+// LaunchTemplateVersionInput is the configuration of a new template version.
+type LaunchTemplateVersionInput struct {
+	Image        string                  `json:"image"`
+	Ports        string                  `json:"ports,omitempty"`
+	InstanceType string                  `json:"instance_type,omitempty"`
+	Env          map[string]string       `json:"env,omitempty"`
+	UserData     string                  `json:"user_data,omitempty"`
+	Volumes      []VolumeAttachmentInput `json:"volumes,omitempty"`
+	VpcID        string                  `json:"vpc_id,omitempty"`
+}
+
+type CreateLaunchTemplateRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	LaunchTemplateVersionInput
+}
+
+func (c *Client) CreateLaunchTemplate(req CreateLaunchTemplateRequest) (*LaunchTemplate, error) {
+	var res Response[LaunchTemplate]
+	if err := c.post("/launch-templates", req, &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+func (c *Client) ListLaunchTemplates() ([]LaunchTemplate, error) {
+	var res Response[[]LaunchTemplate]
+	if err := c.get("/launch-templates", &res); err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
+func (c *Client) GetLaunchTemplate(id string) (*LaunchTemplate, error) {
+	var res Response[LaunchTemplate]
+	if err := c.get(fmt.Sprintf("/launch-templates/%s", id), &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+func (c *Client) DeleteLaunchTemplate(id string) error {
+	return c.delete(fmt.Sprintf("/launch-templates/%s", id), nil)
+}
+
+func (c *Client) CreateLaunchTemplateVersion(templateID string, req LaunchTemplateVersionInput) (*LaunchTemplateVersion, error) {
+	var res Response[LaunchTemplateVersion]
+	if err := c.post(fmt.Sprintf("/launch-templates/%s/versions", templateID), req, &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+func (c *Client) ListLaunchTemplateVersions(templateID string) ([]LaunchTemplateVersion, error) {
+	var res Response[[]LaunchTemplateVersion]
+	if err := c.get(fmt.Sprintf("/launch-templates/%s/versions", templateID), &res); err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
+// GetLaunchTemplateVersion fetches a version of the template, the latest
+// for 0.
+func (c *Client) GetLaunchTemplateVersion(templateID string, version int) (*LaunchTemplateVersion, error) {
+	v := "latest"
+	if version > 0 {
+		v = fmt.Sprint(version)
+	}
+	var res Response[LaunchTemplateVersion]
+	if err := c.get(fmt.Sprintf("/launch-templates/%s/versions/%s", templateID, v), &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
@@ -0,0 +1,118 @@
+package sdk
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_CreateLaunchTemplate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/launch-templates", r.URL.Path)
+		assert.Equal(t, "POST", r.Method)
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, "web", body["name"])
+		assert.Equal(t, "nginx:1.27", body["image"])
+		assert.Equal(t, "small", body["instance_type"])
+		assert.Equal(t, map[string]interface{}{"MODE": "prod"}, body["env"])
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(Response[LaunchTemplate]{Data: LaunchTemplate{ID: "lt-1", Name: "web", LatestVersion: 1}})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	template, err := client.CreateLaunchTemplate(CreateLaunchTemplateRequest{
+		Name: "web",
+		LaunchTemplateVersionInput: LaunchTemplateVersionInput{
+			Image:        "nginx:1.27",
+			InstanceType: "small",
+			Env:          map[string]string{"MODE": "prod"},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "lt-1", template.ID)
+	assert.Equal(t, 1, template.LatestVersion)
+}
+
+func TestClient_GetLaunchTemplateVersion(t *testing.T) {
+	tests := []struct {
+		version int
+		path    string
+	}{
+		{0, "/launch-templates/lt-1/versions/latest"},
+		{3, "/launch-templates/lt-1/versions/3"},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, tt.path, r.URL.Path)
+			assert.Equal(t, "GET", r.Method)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(Response[LaunchTemplateVersion]{Data: LaunchTemplateVersion{TemplateID: "lt-1", Version: 3}})
+		}))
+
+		client := NewClient(server.URL, "test-key")
+		v, err := client.GetLaunchTemplateVersion("lt-1", tt.version)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, v.Version)
+		server.Close()
+	}
+}
+
+func TestClient_LaunchInstanceFromTemplate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/instances", r.URL.Path)
+		assert.Equal(t, "POST", r.Method)
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, "lt-1", body["launch_template_id"])
+		assert.Equal(t, float64(2), body["launch_template_version"])
+		assert.NotContains(t, body, "image")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(Response[Instance]{Data: Instance{ID: "inst-1", LaunchTemplateID: "lt-1", LaunchTemplateVersion: 2}})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	inst, err := client.LaunchInstanceFromTemplate("web-1", "lt-1", 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, inst.LaunchTemplateVersion)
+}
+
+func TestClient_SetScalingGroupLaunchTemplate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/autoscaling/groups/asg-1/launch-template", r.URL.Path)
+		assert.Equal(t, "PUT", r.Method)
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, "lt-1", body["launch_template_id"])
+		assert.Equal(t, float64(4), body["version"])
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response[ScalingGroup]{Data: ScalingGroup{ID: "asg-1", LaunchTemplateID: "lt-1", LaunchTemplateVersion: 4}})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	group, err := client.SetScalingGroupLaunchTemplate("asg-1", "lt-1", 4)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, group.LaunchTemplateVersion)
+}
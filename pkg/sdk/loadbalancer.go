@@ -2,35 +2,162 @@ package sdk
 
 import (
 	"fmt"
+	"net/url"
+	"time"
 )
 
 type LBStatus string
 
 type LoadBalancer struct {
-	ID             string   `json:"id"`
-	IdempotencyKey string   `json:"idempotency_key,omitempty"`
-	Name           string   `json:"name"`
-	VpcID          string   `json:"vpc_id"`
-	Port           int      `json:"port"`
-	Algorithm      string   `json:"algorithm"`
-	Status         LBStatus `json:"status"`
+	ID             string       `json:"id"`
+	IdempotencyKey string       `json:"idempotency_key,omitempty"`
+	Name           string       `json:"name"`
+	VpcID          string       `json:"vpc_id"`
+	Port           int          `json:"port"`
+	Algorithm      string       `json:"algorithm"`
+	Status         LBStatus     `json:"status"`
+	Listeners      []LBListener `json:"listeners,omitempty"`
+	HealthCheck    HealthCheck  `json:"health_check"`
+
+	DeregistrationDelaySeconds int             `json:"deregistration_delay_seconds"`
+	AccessLogs                 AccessLogConfig `json:"access_logs"`
+	HashHeader                 string          `json:"hash_header,omitempty"`
+	Stickiness                 LBStickiness    `json:"stickiness"`
 }
 
-type LBTarget struct {
-	ID         string `json:"id"`
-	LBID       string `json:"lb_id"`
+// LBBalancing selects how a load balancer spreads requests over targets.
+// HashHeader is required by the header-hash algorithm.
+type LBBalancing struct {
+	Algorithm  string       `json:"algorithm,omitempty"`
+	HashHeader string       `json:"hash_header,omitempty"`
+	Stickiness LBStickiness `json:"stickiness"`
+}
+
+// LBStickiness pins clients to a target with a session cookie; a zero TTL
+// takes the server default.
+type LBStickiness struct {
+	Enabled    bool `json:"enabled"`
+	TTLSeconds int  `json:"ttl_seconds,omitempty"`
+}
+
+// LBUpdate changes the fields it sets and leaves the others as they are.
+type LBUpdate struct {
+	Name       *string       `json:"name,omitempty"`
+	Algorithm  *string       `json:"algorithm,omitempty"`
+	HashHeader *string       `json:"hash_header,omitempty"`
+	Stickiness *LBStickiness `json:"stickiness,omitempty"`
+}
+
+// AccessLogConfig controls shipping of access logs to a storage bucket.
+type AccessLogConfig struct {
+	Enabled bool   `json:"enabled"`
+	Bucket  string `json:"bucket,omitempty"`
+	Prefix  string `json:"prefix,omitempty"`
+}
+
+// LBMetricsSummary is the traffic of a load balancer or target over a window.
+type LBMetricsSummary struct {
+	Requests          int64   `json:"requests"`
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	BytesSent         int64   `json:"bytes_sent"`
+	Status2xx         int64   `json:"status_2xx"`
+	Status3xx         int64   `json:"status_3xx"`
+	Status4xx         int64   `json:"status_4xx"`
+	Status5xx         int64   `json:"status_5xx"`
+	Rate4xx           float64 `json:"rate_4xx"`
+	Rate5xx           float64 `json:"rate_5xx"`
+	LatencyAvgMs      float64 `json:"latency_avg_ms"`
+	LatencyP50Ms      float64 `json:"latency_p50_ms"`
+	LatencyP90Ms      float64 `json:"latency_p90_ms"`
+	LatencyP99Ms      float64 `json:"latency_p99_ms"`
+	Latency           []struct {
+		LeSeconds float64 `json:"le_seconds"`
+		Count     int64   `json:"count"`
+	} `json:"latency_histogram"`
+}
+
+type LBTargetMetrics struct {
 	InstanceID string `json:"instance_id"`
-	Port       int    `json:"port"`
-	Weight     int    `json:"weight"`
-	Health     string `json:"health"`
+	LBMetricsSummary
+}
+
+type LBMetrics struct {
+	LBID    string            `json:"lb_id"`
+	Since   time.Time         `json:"since"`
+	Total   LBMetricsSummary  `json:"total"`
+	Targets []LBTargetMetrics `json:"targets"`
+}
+
+// HealthCheck configures how load balancer targets are probed. Zero values
+// sent to UpdateLBHealthCheck take the server defaults.
+type HealthCheck struct {
+	Protocol           string `json:"protocol,omitempty"`
+	Path               string `json:"path,omitempty"`
+	StatusCodes        string `json:"status_codes,omitempty"`
+	IntervalSeconds    int    `json:"interval_seconds,omitempty"`
+	TimeoutSeconds     int    `json:"timeout_seconds,omitempty"`
+	HealthyThreshold   int    `json:"healthy_threshold,omitempty"`
+	UnhealthyThreshold int    `json:"unhealthy_threshold,omitempty"`
+}
+
+// Health check protocols.
+const (
+	HealthCheckHTTP = "http"
+	HealthCheckTCP  = "tcp"
+)
+
+type LBTarget struct {
+	ID          string `json:"id"`
+	LBID        string `json:"lb_id"`
+	InstanceID  string `json:"instance_id"`
+	TargetGroup string `json:"target_group"`
+	Port        int    `json:"port"`
+	Weight      int    `json:"weight"`
+	Health      string `json:"health"`
+
+	DrainingSince *time.Time `json:"draining_since,omitempty"`
+}
+
+// Listener protocols.
+const (
+	LBProtocolHTTP  = "http"
+	LBProtocolHTTPS = "https"
+	LBProtocolTCP   = "tcp"
+)
+
+type LBListener struct {
+	ID                 string          `json:"id,omitempty"`
+	LBID               string          `json:"lb_id,omitempty"`
+	Port               int             `json:"port"`
+	Protocol           string          `json:"protocol"`
+	CertificateSecret  string          `json:"certificate_secret,omitempty"`
+	RedirectPort       int             `json:"redirect_port,omitempty"`
+	DefaultTargetGroup string          `json:"default_target_group,omitempty"`
+	Rules              []LBRoutingRule `json:"rules,omitempty"`
+}
+
+type LBRoutingRule struct {
+	ID          string `json:"id,omitempty"`
+	ListenerID  string `json:"listener_id,omitempty"`
+	Host        string `json:"host,omitempty"`
+	PathPrefix  string `json:"path_prefix,omitempty"`
+	TargetGroup string `json:"target_group"`
 }
 
 func (c *Client) CreateLB(name, vpcID string, port int, algo string) (*LoadBalancer, error) {
+	return c.CreateLBWithBalancing(name, vpcID, port, LBBalancing{Algorithm: algo})
+}
+
+// CreateLBWithBalancing creates a load balancer with a hash header or sticky
+// sessions in addition to the algorithm.
+func (c *Client) CreateLBWithBalancing(name, vpcID string, port int, balancing LBBalancing) (*LoadBalancer, error) {
 	req := map[string]interface{}{
-		"name":      name,
-		"vpc_id":    vpcID,
-		"port":      port,
-		"algorithm": algo,
+		"name":        name,
+		"vpc_id":      vpcID,
+		"port":        port,
+		"algorithm":   balancing.Algorithm,
+		"hash_header": balancing.HashHeader,
+		"stickiness":  balancing.Stickiness,
 	}
 
 	var resp Response[LoadBalancer]
@@ -60,18 +187,86 @@ func (c *Client) DeleteLB(id string) error {
 	return c.delete(fmt.Sprintf("/lb/%s", id), nil)
 }
 
+func (c *Client) UpdateLBHealthCheck(id string, hc HealthCheck) (*LoadBalancer, error) {
+	var resp Response[LoadBalancer]
+	if err := c.put(fmt.Sprintf("/lb/%s/health-check", id), hc, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+func (c *Client) SetLBAccessLogs(id string, cfg AccessLogConfig) (*LoadBalancer, error) {
+	var resp Response[LoadBalancer]
+	if err := c.put(fmt.Sprintf("/lb/%s/access-logs", id), cfg, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// GetLBMetrics returns the traffic of a load balancer over a window such as
+// "15m"; an empty window means the last hour.
+func (c *Client) GetLBMetrics(id, window string) (*LBMetrics, error) {
+	path := fmt.Sprintf("/lb/%s/metrics", id)
+	if window != "" {
+		path += "?window=" + url.QueryEscape(window)
+	}
+	var resp Response[LBMetrics]
+	if err := c.get(path, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
 func (c *Client) AddLBTarget(lbID, instanceID string, port, weight int) error {
+	return c.AddLBTargetToGroup(lbID, instanceID, "", port, weight)
+}
+
+// AddLBTargetToGroup registers a target in a target group; an empty group
+// selects the default group.
+func (c *Client) AddLBTargetToGroup(lbID, instanceID, group string, port, weight int) error {
 	req := map[string]interface{}{
-		"instance_id": instanceID,
-		"port":        port,
-		"weight":      weight,
+		"instance_id":  instanceID,
+		"target_group": group,
+		"port":         port,
+		"weight":       weight,
 	}
 
 	return c.post(fmt.Sprintf("/lb/%s/targets", lbID), req, nil)
 }
 
+// RemoveLBTarget deregisters a target. The target stops receiving new
+// connections at once and is removed after the LB's deregistration delay.
 func (c *Client) RemoveLBTarget(lbID, instanceID string) error {
-	return c.delete(fmt.Sprintf("/lb/%s/targets/%s", lbID, instanceID), nil)
+	_, err := c.DeregisterLBTarget(lbID, instanceID)
+	return err
+}
+
+// DeregisterLBTarget deregisters a target and returns when it will be removed.
+func (c *Client) DeregisterLBTarget(lbID, instanceID string) (time.Time, error) {
+	var resp Response[struct {
+		RemoveAt time.Time `json:"remove_at"`
+	}]
+	if err := c.delete(fmt.Sprintf("/lb/%s/targets/%s", lbID, instanceID), &resp); err != nil {
+		return time.Time{}, err
+	}
+	return resp.Data.RemoveAt, nil
+}
+
+func (c *Client) UpdateLB(id string, update LBUpdate) (*LoadBalancer, error) {
+	var resp Response[LoadBalancer]
+	if err := c.patch(fmt.Sprintf("/lb/%s", id), update, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+func (c *Client) SetLBDeregistrationDelay(id string, seconds int) (*LoadBalancer, error) {
+	var resp Response[LoadBalancer]
+	req := map[string]int{"seconds": seconds}
+	if err := c.put(fmt.Sprintf("/lb/%s/deregistration-delay", id), req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
 }
 
 func (c *Client) ListLBTargets(lbID string) ([]LBTarget, error) {
@@ -81,3 +276,35 @@ func (c *Client) ListLBTargets(lbID string) ([]LBTarget, error) {
 	}
 	return resp.Data, nil
 }
+
+func (c *Client) AddLBListener(lbID string, listener LBListener) (*LBListener, error) {
+	var resp Response[LBListener]
+	if err := c.post(fmt.Sprintf("/lb/%s/listeners", lbID), listener, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+func (c *Client) ListLBListeners(lbID string) ([]LBListener, error) {
+	var resp Response[[]LBListener]
+	if err := c.get(fmt.Sprintf("/lb/%s/listeners", lbID), &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (c *Client) RemoveLBListener(lbID, listenerID string) error {
+	return c.delete(fmt.Sprintf("/lb/%s/listeners/%s", lbID, listenerID), nil)
+}
+
+func (c *Client) AddLBRoutingRule(lbID, listenerID string, rule LBRoutingRule) (*LBRoutingRule, error) {
+	var resp Response[LBRoutingRule]
+	if err := c.post(fmt.Sprintf("/lb/%s/listeners/%s/rules", lbID, listenerID), rule, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+func (c *Client) RemoveLBRoutingRule(lbID, listenerID, ruleID string) error {
+	return c.delete(fmt.Sprintf("/lb/%s/listeners/%s/rules/%s", lbID, listenerID, ruleID), nil)
+}
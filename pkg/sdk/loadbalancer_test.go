@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -29,11 +30,74 @@ func TestClient_LoadBalancer(t *testing.T) {
 			return
 		}
 
+		if r.Method == "POST" && r.URL.Path == "/lb/lb-1/listeners" {
+			var l LBListener
+			_ = json.NewDecoder(r.Body).Decode(&l)
+			l.ID = "lis-1"
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(Response[LBListener]{Data: l})
+			return
+		}
+
+		if r.Method == "POST" && r.URL.Path == "/lb/lb-1/listeners/lis-1/rules" {
+			var rule LBRoutingRule
+			_ = json.NewDecoder(r.Body).Decode(&rule)
+			rule.ID = "rule-1"
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(Response[LBRoutingRule]{Data: rule})
+			return
+		}
+
 		if r.Method == "POST" && r.URL.Path == "/lb/lb-1/targets" {
 			w.WriteHeader(http.StatusOK)
 			return
 		}
 
+		if r.Method == "DELETE" && r.URL.Path == "/lb/lb-1/targets/inst-1" {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(Response[map[string]interface{}]{
+				Data: map[string]interface{}{"message": "target deregistered", "remove_at": "2026-01-02T15:04:05Z"},
+			})
+			return
+		}
+
+		if r.Method == "PUT" && r.URL.Path == "/lb/lb-1/deregistration-delay" {
+			var req map[string]int
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(Response[LoadBalancer]{
+				Data: LoadBalancer{ID: "lb-1", DeregistrationDelaySeconds: req["seconds"]},
+			})
+			return
+		}
+
+		if r.Method == "PATCH" && r.URL.Path == "/lb/lb-1" {
+			var req map[string]json.RawMessage
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			lb := LoadBalancer{ID: "lb-1", Name: "test-lb", Algorithm: "round-robin"}
+			if _, ok := req["name"]; ok {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_ = json.Unmarshal(req["algorithm"], &lb.Algorithm)
+			_ = json.Unmarshal(req["stickiness"], &lb.Stickiness)
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(Response[LoadBalancer]{Data: lb})
+			return
+		}
+
+		if r.Method == "GET" && r.URL.Path == "/lb/lb-1/metrics" {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(Response[LBMetrics]{Data: LBMetrics{
+				LBID:  "lb-1",
+				Total: LBMetricsSummary{Requests: 42, Rate5xx: 0.1},
+				Targets: []LBTargetMetrics{
+					{InstanceID: r.URL.Query().Get("window")},
+				},
+			}})
+			return
+		}
+
 		w.WriteHeader(http.StatusNotFound)
 	}))
 	defer server.Close()
@@ -56,4 +120,49 @@ func TestClient_LoadBalancer(t *testing.T) {
 		err := client.AddLBTarget("lb-1", "inst-1", 80, 1)
 		assert.NoError(t, err)
 	})
+
+	t.Run("DeregisterLBTarget", func(t *testing.T) {
+		removeAt, err := client.DeregisterLBTarget("lb-1", "inst-1")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC), removeAt.UTC())
+	})
+
+	t.Run("SetLBDeregistrationDelay", func(t *testing.T) {
+		lb, err := client.SetLBDeregistrationDelay("lb-1", 120)
+		assert.NoError(t, err)
+		assert.Equal(t, 120, lb.DeregistrationDelaySeconds)
+	})
+
+	t.Run("UpdateLB", func(t *testing.T) {
+		algo := "source-ip-hash"
+		lb, err := client.UpdateLB("lb-1", LBUpdate{
+			Algorithm:  &algo,
+			Stickiness: &LBStickiness{Enabled: true, TTLSeconds: 300},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "source-ip-hash", lb.Algorithm)
+		assert.Equal(t, LBStickiness{Enabled: true, TTLSeconds: 300}, lb.Stickiness)
+	})
+
+	t.Run("GetLBMetrics", func(t *testing.T) {
+		m, err := client.GetLBMetrics("lb-1", "15m")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), m.Total.Requests)
+		assert.Equal(t, 0.1, m.Total.Rate5xx)
+		assert.Equal(t, "15m", m.Targets[0].InstanceID)
+	})
+
+	t.Run("AddLBListener", func(t *testing.T) {
+		l, err := client.AddLBListener("lb-1", LBListener{Port: 443, Protocol: LBProtocolHTTPS, CertificateSecret: "cert"})
+		assert.NoError(t, err)
+		assert.Equal(t, "lis-1", l.ID)
+		assert.Equal(t, "cert", l.CertificateSecret)
+	})
+
+	t.Run("AddLBRoutingRule", func(t *testing.T) {
+		r, err := client.AddLBRoutingRule("lb-1", "lis-1", LBRoutingRule{PathPrefix: "/api/", TargetGroup: "api"})
+		assert.NoError(t, err)
+		assert.Equal(t, "rule-1", r.ID)
+		assert.Equal(t, "api", r.TargetGroup)
+	})
 }
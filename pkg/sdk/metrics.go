@@ -0,0 +1,17 @@
+package sdk
+
+import "time"
+
+type CustomMetric struct {
+	Name       string     `json:"name"`
+	Value      float64    `json:"value"`
+	InstanceID string     `json:"instance_id,omitempty"`
+	Timestamp  *time.Time `json:"timestamp,omitempty"`
+}
+
+// PutCustomMetrics pushes data points of application metrics that custom
+// scaling policies track.
+func (c *Client) PutCustomMetrics(metrics []CustomMetric) error {
+	body := map[string]interface{}{"metrics": metrics}
+	return c.put("/metrics/custom", body, nil)
+}
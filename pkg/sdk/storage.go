@@ -1,19 +1,87 @@
 package sdk
 
 import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 type Object struct {
-	ID          string    `json:"id"`
-	ARN         string    `json:"arn"`
-	Bucket      string    `json:"bucket"`
-	Key         string    `json:"key"`
-	SizeBytes   int64     `json:"size_bytes"`
-	ContentType string    `json:"content_type"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          string            `json:"id"`
+	ARN         string            `json:"arn"`
+	Bucket      string            `json:"bucket"`
+	Key         string            `json:"key"`
+	SizeBytes   int64             `json:"size_bytes"`
+	ContentType string            `json:"content_type"`
+	ETag        string            `json:"etag"`
+	SHA256      string            `json:"sha256"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Encryption  string            `json:"encryption,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// MetadataHeaderPrefix is the header prefix that carries user metadata.
+const MetadataHeaderPrefix = "X-Cloud-Meta-"
+
+// Server-side encryption headers and modes.
+const (
+	EncryptionHeader           = "X-Cloud-Server-Side-Encryption"
+	CustomerKeyHeaderPrefix    = "X-Cloud-Server-Side-Encryption-Customer-"
+	EncryptionAES256           = "AES256"
+	EncryptionCustomer         = "SSE-C"
+	customerKeyAlgorithmHeader = CustomerKeyHeaderPrefix + "Algorithm"
+	customerKeyHeader          = CustomerKeyHeaderPrefix + "Key"
+	customerKeyChecksumHeader  = CustomerKeyHeaderPrefix + "Key-MD5"
+)
+
+// ObjectOptions sets the content type, user metadata and encryption of an upload.
+// The server detects the content type when it is left empty.
+type ObjectOptions struct {
+	ContentType string
+	Metadata    map[string]string
+	// Encryption requests server-side encryption (EncryptionAES256); empty
+	// applies the bucket default.
+	Encryption string
+	// CustomerKey encrypts the object with a 32-byte key the caller keeps. The
+	// same key must be sent to read the object.
+	CustomerKey []byte
+}
+
+func (o ObjectOptions) headers() map[string]string {
+	headers := customerKeyHeaders(o.CustomerKey)
+	if o.ContentType != "" {
+		headers["Content-Type"] = o.ContentType
+	}
+	for k, v := range o.Metadata {
+		headers[MetadataHeaderPrefix+k] = v
+	}
+	if o.Encryption != "" && o.CustomerKey == nil {
+		headers[EncryptionHeader] = o.Encryption
+	}
+	return headers
+}
+
+// ReadOptions carries the customer key of an object encrypted with one.
+type ReadOptions struct {
+	CustomerKey []byte
+}
+
+func customerKeyHeaders(key []byte) map[string]string {
+	headers := map[string]string{}
+	if key != nil {
+		sum := md5.Sum(key)
+		headers[customerKeyAlgorithmHeader] = EncryptionAES256
+		headers[customerKeyHeader] = base64.StdEncoding.EncodeToString(key)
+		headers[customerKeyChecksumHeader] = base64.StdEncoding.EncodeToString(sum[:])
+	}
+	return headers
 }
 
 func (c *Client) ListObjects(bucket string) ([]Object, error) {
@@ -25,21 +93,78 @@ func (c *Client) ListObjects(bucket string) ([]Object, error) {
 }
 
 func (c *Client) UploadObject(bucket, key string, body io.Reader) error {
+	_, err := c.UploadObjectWithOptions(bucket, key, body, ObjectOptions{})
+	return err
+}
+
+func (c *Client) UploadObjectWithOptions(bucket, key string, body io.Reader, opts ObjectOptions) (*Object, error) {
+	var res Response[Object]
 	resp, err := c.resty.R().
+		SetHeaders(opts.headers()).
 		SetBody(body).
+		SetResult(&res).
 		Put(fmt.Sprintf("%s/storage/%s/%s", c.apiURL, bucket, key))
 
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("api error: %s", resp.String())
+	}
+	return &res.Data, nil
+}
+
+// HeadObject returns the metadata of an object without downloading it.
+func (c *Client) HeadObject(bucket, key string) (*Object, error) {
+	return c.HeadObjectWithOptions(bucket, key, ReadOptions{})
+}
+
+// HeadObjectWithOptions is HeadObject for objects encrypted with a customer key.
+func (c *Client) HeadObjectWithOptions(bucket, key string, opts ReadOptions) (*Object, error) {
+	resp, err := c.resty.R().
+		SetHeaders(customerKeyHeaders(opts.CustomerKey)).
+		Head(fmt.Sprintf("%s/storage/%s/%s", c.apiURL, bucket, key))
+
+	if err != nil {
+		return nil, err
 	}
 	if resp.IsError() {
-		return fmt.Errorf("api error: %s", resp.String())
+		return nil, fmt.Errorf("api error: status %d", resp.StatusCode())
+	}
+
+	h := resp.Header()
+	obj := &Object{
+		Bucket:      bucket,
+		Key:         key,
+		ContentType: h.Get("Content-Type"),
+		ETag:        strings.Trim(h.Get("ETag"), `"`),
+		SHA256:      h.Get("X-Cloud-Checksum-Sha256"),
+		Encryption:  h.Get(EncryptionHeader),
+	}
+	if h.Get(customerKeyAlgorithmHeader) != "" {
+		obj.Encryption = EncryptionCustomer
 	}
-	return nil
+	obj.SizeBytes, _ = strconv.ParseInt(h.Get("Content-Length"), 10, 64)
+	obj.CreatedAt, _ = http.ParseTime(h.Get("Last-Modified"))
+	for name, values := range h {
+		if k, ok := strings.CutPrefix(name, MetadataHeaderPrefix); ok && len(values) > 0 {
+			if obj.Metadata == nil {
+				obj.Metadata = map[string]string{}
+			}
+			obj.Metadata[strings.ToLower(k)] = values[0]
+		}
+	}
+	return obj, nil
 }
 
 func (c *Client) DownloadObject(bucket, key string) (io.ReadCloser, error) {
+	return c.DownloadObjectWithOptions(bucket, key, ReadOptions{})
+}
+
+// DownloadObjectWithOptions is DownloadObject for objects encrypted with a customer key.
+func (c *Client) DownloadObjectWithOptions(bucket, key string, opts ReadOptions) (io.ReadCloser, error) {
 	resp, err := c.resty.R().
+		SetHeaders(customerKeyHeaders(opts.CustomerKey)).
 		SetDoNotParseResponse(true).
 		Get(fmt.Sprintf("%s/storage/%s/%s", c.apiURL, bucket, key))
 
@@ -56,3 +181,386 @@ func (c *Client) DownloadObject(bucket, key string) (io.ReadCloser, error) {
 func (c *Client) DeleteObject(bucket, key string) error {
 	return c.delete(fmt.Sprintf("/storage/%s/%s", bucket, key), nil)
 }
+
+type PresignedURL struct {
+	URL              string    `json:"url"`
+	Method           string    `json:"method"`
+	Bucket           string    `json:"bucket"`
+	Key              string    `json:"key"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	MaxContentLength int64     `json:"max_content_length,omitempty"`
+}
+
+// PresignOptions controls the URL minted by PresignURL.
+type PresignOptions struct {
+	Method           string // GET (default) or PUT
+	ExpiresIn        time.Duration
+	MaxContentLength int64 // PUT only; 0 means no limit
+}
+
+// PresignURL returns a temporary URL that grants GET or PUT access to an object without an API key.
+func (c *Client) PresignURL(bucket, key string, opts PresignOptions) (*PresignedURL, error) {
+	body := map[string]interface{}{
+		"method":             opts.Method,
+		"expires_in":         int(opts.ExpiresIn / time.Second),
+		"max_content_length": opts.MaxContentLength,
+	}
+
+	var res Response[PresignedURL]
+	if err := c.post(fmt.Sprintf("/storage/%s/%s/presign", bucket, key), body, &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+const (
+	// DefaultPartSize is the part size used by UploadObjectMultipart when none is given.
+	DefaultPartSize int64 = 16 * 1024 * 1024
+	// DefaultUploadConcurrency is the number of parts uploaded in parallel by default.
+	DefaultUploadConcurrency = 4
+)
+
+type MultipartUpload struct {
+	ID        string    `json:"id"`
+	Bucket    string    `json:"bucket"`
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Part struct {
+	UploadID   string    `json:"upload_id"`
+	PartNumber int       `json:"part_number"`
+	SizeBytes  int64     `json:"size_bytes"`
+	ETag       string    `json:"etag"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CompletedPart names an uploaded part to include in the final object.
+type CompletedPart struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+}
+
+// MultipartOptions controls how UploadObjectMultipart splits and sends a file.
+type MultipartOptions struct {
+	ObjectOptions
+	PartSize    int64
+	Concurrency int
+	// UploadID resumes an existing upload; parts already stored with a matching ETag are skipped.
+	UploadID string
+}
+
+func uploadPath(bucket, key, uploadID string) string {
+	return fmt.Sprintf("/storage/%s/%s/uploads/%s", bucket, key, uploadID)
+}
+
+func (c *Client) InitiateMultipartUpload(bucket, key string) (*MultipartUpload, error) {
+	return c.InitiateMultipartUploadWithOptions(bucket, key, ObjectOptions{})
+}
+
+// InitiateMultipartUploadWithOptions starts an upload whose object gets the given content type and metadata.
+func (c *Client) InitiateMultipartUploadWithOptions(bucket, key string, opts ObjectOptions) (*MultipartUpload, error) {
+	var res Response[MultipartUpload]
+	resp, err := c.resty.R().
+		SetHeaders(opts.headers()).
+		SetResult(&res).
+		Post(fmt.Sprintf("%s/storage/%s/%s/uploads", c.apiURL, bucket, key))
+
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("api error: %s", resp.String())
+	}
+	return &res.Data, nil
+}
+
+func (c *Client) UploadPart(bucket, key, uploadID string, partNumber int, body io.Reader) (*Part, error) {
+	var res Response[Part]
+	resp, err := c.resty.R().
+		SetBody(body).
+		SetResult(&res).
+		Put(fmt.Sprintf("%s%s/parts/%d", c.apiURL, uploadPath(bucket, key, uploadID), partNumber))
+
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("api error: %s", resp.String())
+	}
+	return &res.Data, nil
+}
+
+func (c *Client) ListParts(bucket, key, uploadID string) ([]Part, error) {
+	var res Response[[]Part]
+	if err := c.get(uploadPath(bucket, key, uploadID)+"/parts", &res); err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
+// CompleteMultipartUpload assembles the listed parts, which must be in
+// ascending part number order with the ETags returned by UploadPart. Other
+// uploaded parts are discarded.
+func (c *Client) CompleteMultipartUpload(bucket, key, uploadID string, parts []CompletedPart) (*Object, error) {
+	var res Response[Object]
+	body := map[string]interface{}{"parts": parts}
+	if err := c.post(uploadPath(bucket, key, uploadID)+"/complete", body, &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+func (c *Client) AbortMultipartUpload(bucket, key, uploadID string) error {
+	return c.delete(uploadPath(bucket, key, uploadID), nil)
+}
+
+// UploadObjectMultipart uploads size bytes from r as a multipart upload, sending
+// parts in parallel. If opts.UploadID is set, the upload is resumed instead of started.
+// On failure the upload is left in place so it can be resumed with the returned ID.
+func (c *Client) UploadObjectMultipart(bucket, key string, r io.ReaderAt, size int64, opts MultipartOptions) (*Object, string, error) {
+	if opts.PartSize <= 0 {
+		opts.PartSize = DefaultPartSize
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultUploadConcurrency
+	}
+
+	uploadID := opts.UploadID
+	existing := map[int]string{}
+	if uploadID == "" {
+		upload, err := c.InitiateMultipartUploadWithOptions(bucket, key, opts.ObjectOptions)
+		if err != nil {
+			return nil, "", err
+		}
+		uploadID = upload.ID
+	} else {
+		parts, err := c.ListParts(bucket, key, uploadID)
+		if err != nil {
+			return nil, uploadID, err
+		}
+		for _, p := range parts {
+			existing[p.PartNumber] = p.ETag
+		}
+	}
+
+	numParts := int((size + opts.PartSize - 1) / opts.PartSize)
+	if numParts == 0 {
+		numParts = 1
+	}
+
+	jobs := make(chan int)
+	errs := make(chan error, numParts)
+	etags := make([]string, numParts)
+	var wg sync.WaitGroup
+
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range jobs {
+				offset := int64(n-1) * opts.PartSize
+				length := min(opts.PartSize, size-offset)
+
+				if etag, ok := existing[n]; ok {
+					sum, err := partMD5(io.NewSectionReader(r, offset, length))
+					if err == nil && sum == etag {
+						etags[n-1] = etag
+						continue
+					}
+				}
+
+				part, err := c.UploadPart(bucket, key, uploadID, n, io.NewSectionReader(r, offset, length))
+				if err != nil {
+					errs <- fmt.Errorf("part %d: %w", n, err)
+					continue
+				}
+				etags[n-1] = part.ETag
+			}
+		}()
+	}
+
+	for n := 1; n <= numParts; n++ {
+		jobs <- n
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return nil, uploadID, err
+	}
+
+	// Only this upload's parts are listed, so parts left over from an earlier
+	// attempt with a different part size or a longer file are dropped
+	parts := make([]CompletedPart, numParts)
+	for i, etag := range etags {
+		parts[i] = CompletedPart{PartNumber: i + 1, ETag: etag}
+	}
+	obj, err := c.CompleteMultipartUpload(bucket, key, uploadID, parts)
+	if err != nil {
+		return nil, uploadID, err
+	}
+	return obj, uploadID, nil
+}
+
+func partMD5(r io.Reader) (string, error) {
+	h := md5.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+type LifecycleRule struct {
+	ID                        string    `json:"id"`
+	Bucket                    string    `json:"bucket"`
+	Prefix                    string    `json:"prefix"`
+	ExpirationDays            int       `json:"expiration_days,omitempty"`
+	DeletedRetentionDays      int       `json:"deleted_retention_days,omitempty"`
+	AbortIncompleteUploadDays int       `json:"abort_incomplete_upload_days,omitempty"`
+	Enabled                   bool      `json:"enabled"`
+	CreatedAt                 time.Time `json:"created_at"`
+}
+
+func (c *Client) CreateLifecycleRule(bucket string, rule LifecycleRule) (*LifecycleRule, error) {
+	body := map[string]interface{}{
+		"prefix":                       rule.Prefix,
+		"expiration_days":              rule.ExpirationDays,
+		"deleted_retention_days":       rule.DeletedRetentionDays,
+		"abort_incomplete_upload_days": rule.AbortIncompleteUploadDays,
+		"enabled":                      rule.Enabled,
+	}
+
+	var res Response[LifecycleRule]
+	if err := c.post(fmt.Sprintf("/buckets/%s/lifecycle", bucket), body, &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+func (c *Client) ListLifecycleRules(bucket string) ([]LifecycleRule, error) {
+	var res Response[[]LifecycleRule]
+	if err := c.get(fmt.Sprintf("/buckets/%s/lifecycle", bucket), &res); err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
+func (c *Client) DeleteLifecycleRule(bucket, id string) error {
+	return c.delete(fmt.Sprintf("/buckets/%s/lifecycle/%s", bucket, id), nil)
+}
+
+type BucketEncryption struct {
+	Bucket    string    `json:"bucket"`
+	Algorithm string    `json:"algorithm"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PutBucketEncryption sets the encryption applied to new objects of a bucket.
+func (c *Client) PutBucketEncryption(bucket, algorithm string) (*BucketEncryption, error) {
+	var res Response[BucketEncryption]
+	if err := c.put(fmt.Sprintf("/buckets/%s/encryption", bucket), map[string]string{"algorithm": algorithm}, &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+func (c *Client) GetBucketEncryption(bucket string) (*BucketEncryption, error) {
+	var res Response[BucketEncryption]
+	if err := c.get(fmt.Sprintf("/buckets/%s/encryption", bucket), &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+func (c *Client) DeleteBucketEncryption(bucket string) error {
+	return c.delete(fmt.Sprintf("/buckets/%s/encryption", bucket), nil)
+}
+
+type BucketWebsite struct {
+	Bucket        string    `json:"bucket"`
+	IndexDocument string    `json:"index_document"`
+	ErrorDocument string    `json:"error_document,omitempty"`
+	CacheMaxAge   int       `json:"cache_max_age"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// PutBucketWebsite serves a bucket as a public static website.
+func (c *Client) PutBucketWebsite(bucket string, website BucketWebsite) (*BucketWebsite, error) {
+	body := map[string]interface{}{
+		"index_document": website.IndexDocument,
+		"error_document": website.ErrorDocument,
+		"cache_max_age":  website.CacheMaxAge,
+	}
+
+	var res Response[BucketWebsite]
+	if err := c.put(fmt.Sprintf("/buckets/%s/website", bucket), body, &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+func (c *Client) GetBucketWebsite(bucket string) (*BucketWebsite, error) {
+	var res Response[BucketWebsite]
+	if err := c.get(fmt.Sprintf("/buckets/%s/website", bucket), &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+func (c *Client) DeleteBucketWebsite(bucket string) error {
+	return c.delete(fmt.Sprintf("/buckets/%s/website", bucket), nil)
+}
+
+const (
+	EventObjectCreated = "ObjectCreated"
+	EventObjectDeleted = "ObjectDeleted"
+)
+
+// BucketNotification delivers object events of a bucket to a function or a
+// webhook. Exactly one of FunctionID and WebhookURL is set.
+type BucketNotification struct {
+	ID         string   `json:"id"`
+	Bucket     string   `json:"bucket"`
+	Events     []string `json:"events,omitempty"`
+	Prefix     string   `json:"prefix,omitempty"`
+	Suffix     string   `json:"suffix,omitempty"`
+	FunctionID string   `json:"function_id,omitempty"`
+	WebhookURL string   `json:"webhook_url,omitempty"`
+	// Secret is the key of the HMAC-SHA256 X-Cloud-Signature header of webhook deliveries.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (c *Client) CreateBucketNotification(bucket string, n BucketNotification) (*BucketNotification, error) {
+	body := map[string]interface{}{
+		"events": n.Events,
+		"prefix": n.Prefix,
+		"suffix": n.Suffix,
+	}
+	if n.FunctionID != "" {
+		body["function_id"] = n.FunctionID
+	}
+	if n.WebhookURL != "" {
+		body["webhook_url"] = n.WebhookURL
+	}
+
+	var res Response[BucketNotification]
+	if err := c.post(fmt.Sprintf("/buckets/%s/notifications", bucket), body, &res); err != nil {
+		return nil, err
+	}
+	return &res.Data, nil
+}
+
+func (c *Client) ListBucketNotifications(bucket string) ([]BucketNotification, error) {
+	var res Response[[]BucketNotification]
+	if err := c.get(fmt.Sprintf("/buckets/%s/notifications", bucket), &res); err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
+func (c *Client) DeleteBucketNotification(bucket, id string) error {
+	return c.delete(fmt.Sprintf("/buckets/%s/notifications/%s", bucket, id), nil)
+}
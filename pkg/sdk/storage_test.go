@@ -0,0 +1,117 @@
+package sdk
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_UploadObjectMultipart(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string]string{}
+	completed := false
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/storage/b/k/uploads":
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(Response[MultipartUpload]{Data: MultipartUpload{ID: "u1", Bucket: "b", Key: "k"}})
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/storage/b/k/uploads/u1/parts/"):
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			received[strings.TrimPrefix(r.URL.Path, "/storage/b/k/uploads/u1/parts/")] = string(body)
+			mu.Unlock()
+			json.NewEncoder(w).Encode(Response[Part]{Data: Part{UploadID: "u1"}})
+		case r.Method == http.MethodPost && r.URL.Path == "/storage/b/k/uploads/u1/complete":
+			completed = true
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(Response[Object]{Data: Object{Bucket: "b", Key: "k", SizeBytes: 10}})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	data := "0123456789"
+	obj, uploadID, err := client.UploadObjectMultipart("b", "k", strings.NewReader(data), int64(len(data)), MultipartOptions{PartSize: 4, Concurrency: 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", uploadID)
+	assert.Equal(t, int64(10), obj.SizeBytes)
+	assert.True(t, completed)
+	assert.Equal(t, map[string]string{"1": "0123", "2": "4567", "3": "89"}, received)
+}
+
+func TestClient_UploadObjectMultipart_ResumeSkipsStoredParts(t *testing.T) {
+	var uploaded []string
+	var completed []CompletedPart
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/storage/b/k/uploads/u1/parts":
+			// md5("0123") - part 1 is already stored; part 3 is left over from a longer file
+			json.NewEncoder(w).Encode(Response[[]Part]{Data: []Part{
+				{PartNumber: 1, ETag: "eb62f6b9306db575c2d596b1279627a4"},
+				{PartNumber: 3, ETag: "stale"},
+			}})
+		case r.Method == http.MethodPut:
+			uploaded = append(uploaded, r.URL.Path)
+			json.NewEncoder(w).Encode(Response[Part]{Data: Part{UploadID: "u1", ETag: "e2"}})
+		case r.Method == http.MethodPost && r.URL.Path == "/storage/b/k/uploads/u1/complete":
+			var body struct {
+				Parts []CompletedPart `json:"parts"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			completed = body.Parts
+			json.NewEncoder(w).Encode(Response[Object]{Data: Object{Key: "k"}})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	data := "01234567"
+	_, _, err := client.UploadObjectMultipart("b", "k", strings.NewReader(data), int64(len(data)), MultipartOptions{PartSize: 4, Concurrency: 1, UploadID: "u1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/storage/b/k/uploads/u1/parts/2"}, uploaded)
+	assert.Equal(t, []CompletedPart{{PartNumber: 1, ETag: "eb62f6b9306db575c2d596b1279627a4"}, {PartNumber: 2, ETag: "e2"}}, completed)
+}
+
+func TestClient_PresignURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/storage/b/k/presign", r.URL.Path)
+
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, "PUT", body["method"])
+		assert.Equal(t, float64(300), body["expires_in"])
+		assert.Equal(t, float64(1024), body["max_content_length"])
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(Response[PresignedURL]{Data: PresignedURL{URL: "http://host/storage/b/k?X-Cloud-Signature=abc", Method: "PUT"}})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key")
+	p, err := client.PresignURL("b", "k", PresignOptions{Method: "PUT", ExpiresIn: 5 * time.Minute, MaxContentLength: 1024})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "PUT", p.Method)
+	assert.Contains(t, p.URL, "X-Cloud-Signature")
+}